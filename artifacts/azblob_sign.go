@@ -0,0 +1,71 @@
+package artifacts
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// signAzureBlobRequest signs req with the access key of account, using Shared Key
+// authorization. The X-Ms-Date header must already be set.
+func signAzureBlobRequest(req *http.Request, account string, key []byte) {
+	contentLength := ""
+	if req.ContentLength > 0 {
+		contentLength = strconv.FormatInt(req.ContentLength, 10)
+	}
+
+	var stringToSign strings.Builder
+	stringToSign.WriteString(strings.Join([]string{
+		req.Method,
+		req.Header.Get("Content-Encoding"),
+		req.Header.Get("Content-Language"),
+		contentLength,
+		req.Header.Get("Content-MD5"),
+		req.Header.Get("Content-Type"),
+		req.Header.Get("Date"),
+		req.Header.Get("If-Modified-Since"),
+		req.Header.Get("If-Match"),
+		req.Header.Get("If-None-Match"),
+		req.Header.Get("If-Unmodified-Since"),
+		req.Header.Get("Range"),
+	}, "\n"))
+	stringToSign.WriteString("\n")
+
+	headers := []string{}
+	for name := range req.Header {
+		if name := strings.ToLower(name); strings.HasPrefix(name, "x-ms-") {
+			headers = append(headers, name)
+		}
+	}
+	sort.Strings(headers)
+	for _, name := range headers {
+		fmt.Fprintf(&stringToSign, "%s:%s\n", name, strings.TrimSpace(req.Header.Get(name)))
+	}
+
+	stringToSign.WriteString("/" + account + req.URL.EscapedPath())
+	query := req.URL.Query()
+	params := make([]string, 0, len(query))
+	for name := range query {
+		params = append(params, name)
+	}
+	sort.Strings(params)
+	for _, name := range params {
+		values := query[name]
+		sort.Strings(values)
+		fmt.Fprintf(&stringToSign, "\n%s:%s", strings.ToLower(name), strings.Join(values, ","))
+	}
+
+	signature := base64.StdEncoding.EncodeToString(hmacSHA256(key, stringToSign.String()))
+	req.Header.Set("Authorization", fmt.Sprintf("SharedKey %s:%s", account, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
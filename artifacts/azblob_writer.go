@@ -0,0 +1,286 @@
+package artifacts
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+)
+
+// azureStorageScope is the OAuth 2.0 scope of the access tokens for Azure Storage.
+const azureStorageScope = "https://storage.azure.com/.default"
+
+// AzureBlobScheme is the scheme of the URIs of Azure Blob Storage containers, e.g.
+// azblob://container/prefix.
+const AzureBlobScheme = "azblob://"
+
+// azureBlobAPIVersion is the version of the Blob service REST API requests are made with.
+const azureBlobAPIVersion = "2021-08-06"
+
+// IsAzureBlobURI returns true if s is the URI of an Azure Blob Storage container, e.g.
+// azblob://container/prefix.
+func IsAzureBlobURI(s string) bool {
+	return strings.HasPrefix(s, AzureBlobScheme)
+}
+
+// AzureBlobWriter is an ArtifactWriter that writes artifacts as blobs in an Azure Blob
+// Storage container, under a prefix. By default, it is configured from the environment:
+// AZURE_STORAGE_CONNECTION_STRING, or AZURE_STORAGE_ACCOUNT with AZURE_STORAGE_KEY or
+// AZURE_STORAGE_SAS_TOKEN. Without a key or SAS token, requests are authenticated with
+// Microsoft Entra ID by the Azure SDK's DefaultAzureCredential: a service principal in
+// AZURE_TENANT_ID, AZURE_CLIENT_ID, and AZURE_CLIENT_SECRET or
+// AZURE_CLIENT_CERTIFICATE_PATH, a workload identity in AZURE_FEDERATED_TOKEN_FILE, the
+// managed identity of the host, or the Azure CLI.
+type AzureBlobWriter struct {
+	container string
+	prefix    string
+	account   string
+	endpoint  string
+	sharedKey string
+	sasToken  string
+	cred      azcore.TokenCredential
+	client    HTTPClient
+	now       func() time.Time
+	key       []byte
+
+	written writtenFiles
+}
+
+type AzureBlobWriterOption = func(*AzureBlobWriter)
+
+// WithAzureBlobAccount sets the storage account of the container.
+func WithAzureBlobAccount(account string) AzureBlobWriterOption {
+	return func(w *AzureBlobWriter) {
+		w.account = account
+	}
+}
+
+// WithAzureBlobEndpoint sets the endpoint of the Blob service, e.g. that of Azurite.
+// Defaults to https://<account>.blob.core.windows.net.
+func WithAzureBlobEndpoint(endpoint string) AzureBlobWriterOption {
+	return func(w *AzureBlobWriter) {
+		w.endpoint = strings.TrimSuffix(endpoint, "/")
+	}
+}
+
+// WithAzureBlobSharedKey authenticates requests with the base64 encoded access key of
+// the storage account.
+func WithAzureBlobSharedKey(key string) AzureBlobWriterOption {
+	return func(w *AzureBlobWriter) {
+		w.sharedKey = key
+	}
+}
+
+// WithAzureBlobSASToken authenticates requests with the shared access signature token.
+func WithAzureBlobSASToken(token string) AzureBlobWriterOption {
+	return func(w *AzureBlobWriter) {
+		w.sasToken = strings.TrimPrefix(token, "?")
+	}
+}
+
+// WithAzureBlobAccessToken authenticates requests with the OAuth 2.0 access token,
+// rather than the default credentials.
+func WithAzureBlobAccessToken(token string) AzureBlobWriterOption {
+	return func(w *AzureBlobWriter) {
+		w.cred = staticCredential(token)
+	}
+}
+
+// WithAzureBlobHTTPClient sets the client that sends requests, including those for
+// access tokens. Defaults to http.DefaultClient.
+func WithAzureBlobHTTPClient(client HTTPClient) AzureBlobWriterOption {
+	return func(w *AzureBlobWriter) {
+		w.client = client
+	}
+}
+
+// NewAzureBlobWriter returns an artifact writer writing to the container and prefix of
+// uri, e.g. azblob://container/prefix, configured by opts.
+func NewAzureBlobWriter(uri string, opts ...AzureBlobWriterOption) (*AzureBlobWriter, error) {
+	if !IsAzureBlobURI(uri) {
+		return nil, fmt.Errorf("%s is not an azblob URI, e.g. azblob://container/prefix", uri)
+	}
+
+	container, prefix, err := splitBucketURI(uri, AzureBlobScheme)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &AzureBlobWriter{
+		container: container,
+		prefix:    prefix,
+		account:   os.Getenv("AZURE_STORAGE_ACCOUNT"),
+		sharedKey: os.Getenv("AZURE_STORAGE_KEY"),
+		sasToken:  strings.TrimPrefix(os.Getenv("AZURE_STORAGE_SAS_TOKEN"), "?"),
+		client:    http.DefaultClient,
+		now:       time.Now,
+	}
+	if conn := os.Getenv("AZURE_STORAGE_CONNECTION_STRING"); conn != "" {
+		if err := w.applyConnectionString(conn); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	if w.endpoint == "" {
+		if w.account == "" {
+			return nil, errors.New("the azure storage account is required, e.g. from AZURE_STORAGE_ACCOUNT")
+		}
+		w.endpoint = "https://" + w.account + ".blob.core.windows.net"
+	}
+	if u, err := url.Parse(w.endpoint); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, fmt.Errorf("the azure blob endpoint %s must be an http or https URL", w.endpoint)
+	}
+
+	switch {
+	case w.cred != nil:
+	case w.sasToken != "":
+		if _, err := url.ParseQuery(w.sasToken); err != nil {
+			return nil, fmt.Errorf("the azure storage SAS token is invalid: %w", err)
+		}
+	case w.sharedKey != "":
+		if w.account == "" {
+			return nil, errors.New("the azure storage account is required to authenticate with its key, e.g. from AZURE_STORAGE_ACCOUNT")
+		}
+		if w.key, err = base64.StdEncoding.DecodeString(w.sharedKey); err != nil {
+			return nil, fmt.Errorf("the azure storage account key must be base64 encoded: %w", err)
+		}
+	default:
+		// The credentials are found when the first token is requested, as the managed
+		// identity of the host cannot be found without requesting one.
+		if w.cred, err = azidentity.NewDefaultAzureCredential(&azidentity.DefaultAzureCredentialOptions{
+			ClientOptions: azcore.ClientOptions{Transport: w.client},
+		}); err != nil {
+			return nil, fmt.Errorf("could not find azure credentials: %w", err)
+		}
+	}
+
+	return w, nil
+}
+
+// applyConnectionString configures w from conn, the connection string of a storage
+// account, e.g. AccountName=account;AccountKey=key;EndpointSuffix=core.windows.net.
+func (w *AzureBlobWriter) applyConnectionString(conn string) error {
+	values := map[string]string{}
+	for _, part := range strings.Split(conn, ";") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return errors.New("the azure storage connection string is invalid: its settings must be key=value pairs")
+		}
+		values[strings.ToLower(key)] = value
+	}
+
+	w.account = values["accountname"]
+	w.sharedKey = values["accountkey"]
+	w.sasToken = strings.TrimPrefix(values["sharedaccesssignature"], "?")
+	w.endpoint = strings.TrimSuffix(values["blobendpoint"], "/")
+	if w.endpoint == "" && w.account != "" {
+		protocol := firstNonEmpty(values["defaultendpointsprotocol"], "https")
+		suffix := firstNonEmpty(values["endpointsuffix"], "core.windows.net")
+		w.endpoint = fmt.Sprintf("%s://%s.blob.%s", protocol, w.account, suffix)
+	}
+
+	return nil
+}
+
+// WriteFile writes contents to the blob named filename under the prefix, and returns
+// the URI of the blob.
+func (w *AzureBlobWriter) WriteFile(filename string, contents io.Reader) (string, error) {
+	key := path.Join(w.prefix, filepath.ToSlash(filename))
+	uri := AzureBlobScheme + path.Join(w.container, key)
+
+	body, err := io.ReadAll(contents)
+	if err != nil {
+		return uri, fmt.Errorf("could not write file to azure blob storage: %w", err)
+	}
+
+	blobURL := w.endpoint + "/" + escapeObjectPath(w.container) + "/" + escapeObjectPath(key)
+	if w.sasToken != "" && w.cred == nil {
+		blobURL += "?" + w.sasToken
+	}
+	req, err := http.NewRequest(http.MethodPut, blobURL, bytes.NewReader(body))
+	if err != nil {
+		return uri, err
+	}
+	req.Header.Set("Content-Type", contentType(key))
+	req.Header.Set("X-Ms-Blob-Type", "BlockBlob")
+	req.Header.Set("X-Ms-Date", w.now().UTC().Format(http.TimeFormat))
+	req.Header.Set("X-Ms-Version", azureBlobAPIVersion)
+
+	switch {
+	case w.cred != nil:
+		token, err := w.accessToken()
+		if err != nil {
+			return uri, fmt.Errorf("could not write %s: %w", uri, err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	case w.key != nil:
+		signAzureBlobRequest(req, w.account, w.key)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return uri, fmt.Errorf("could not write file to azure blob storage: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := checkWriteResponse(resp, uri, http.StatusCreated); err != nil {
+		return uri, err
+	}
+	w.written.add(uri)
+
+	return uri, nil
+}
+
+// WrittenFiles returns the URI of every blob written by this writer, in the order they
+// were written.
+func (w *AzureBlobWriter) WrittenFiles() []string {
+	return w.written.list()
+}
+
+// Location is the URI of the container and prefix that artifacts are written to.
+func (w *AzureBlobWriter) Location() string {
+	return AzureBlobScheme + path.Join(w.container, w.prefix)
+}
+
+// accessToken requests an access token for Azure Storage with the credentials of w,
+// which cache it until it expires.
+func (w *AzureBlobWriter) accessToken() (string, error) {
+	token, err := w.cred.GetToken(context.Background(), policy.TokenRequestOptions{Scopes: []string{azureStorageScope}})
+	if err != nil {
+		var authErr *azidentity.AuthenticationFailedError
+		if errors.As(err, &authErr) && authErr.RawResponse != nil {
+			return "", fmt.Errorf("could not request an access token: status code: %d: %w", authErr.RawResponse.StatusCode, err)
+		}
+		return "", fmt.Errorf("could not request an access token: %w", err)
+	}
+
+	return token.Token, nil
+}
+
+// staticCredential is an azcore.TokenCredential for an access token, which does not
+// expire.
+type staticCredential string
+
+func (c staticCredential) GetToken(context.Context, policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: string(c), ExpiresOn: time.Now().AddDate(100, 0, 0)}, nil
+}
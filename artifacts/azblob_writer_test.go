@@ -0,0 +1,192 @@
+package artifacts
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// azuriteKey is the well-known access key of the devstoreaccount1 account of Azurite,
+// the Azure Storage emulator.
+const azuriteKey = "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw=="
+
+var _ = Describe("Azure Blob Artifact Writer", func() {
+	BeforeEach(func() {
+		for _, name := range []string{
+			"AZURE_STORAGE_CONNECTION_STRING",
+			"AZURE_STORAGE_ACCOUNT",
+			"AZURE_STORAGE_KEY",
+			"AZURE_STORAGE_SAS_TOKEN",
+			"AZURE_TENANT_ID",
+			"AZURE_CLIENT_ID",
+			"AZURE_CLIENT_SECRET",
+			"AZURE_CLIENT_CERTIFICATE_PATH",
+			"AZURE_USERNAME",
+			"AZURE_PASSWORD",
+			"AZURE_FEDERATED_TOKEN_FILE",
+			"AZURE_AUTHORITY_HOST",
+		} {
+			GinkgoT().Setenv(name, "")
+		}
+	})
+
+	Context("When creating an Azure Blob Artifact Writer", func() {
+		It("should require an azblob URI", func() {
+			_, err := NewAzureBlobWriter("gs://bucket/prefix", WithAzureBlobAccount("account"))
+			Expect(err).To(MatchError(ContainSubstring("is not an azblob URI")))
+		})
+
+		It("should require a container", func() {
+			_, err := NewAzureBlobWriter("azblob:///prefix", WithAzureBlobAccount("account"))
+			Expect(err).To(MatchError(ContainSubstring("does not name a bucket")))
+		})
+
+		It("should require a storage account", func() {
+			_, err := NewAzureBlobWriter("azblob://container/prefix")
+			Expect(err).To(MatchError(ContainSubstring("storage account is required")))
+		})
+
+		It("should require the account key to be base64 encoded", func() {
+			GinkgoT().Setenv("AZURE_STORAGE_ACCOUNT", "account")
+			GinkgoT().Setenv("AZURE_STORAGE_KEY", "not base64!")
+			_, err := NewAzureBlobWriter("azblob://container/prefix")
+			Expect(err).To(MatchError(ContainSubstring("must be base64 encoded")))
+		})
+	})
+
+	Context("When writing artifacts to Azure Blob Storage", func() {
+		var (
+			server  *httptest.Server
+			mu      sync.Mutex
+			blobs   map[string]string
+			query   string
+			headers http.Header
+		)
+
+		BeforeEach(func() {
+			blobs = map[string]string{}
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				// Microsoft Entra ID is discovered before a token is requested from it.
+				switch r.URL.Path {
+				case "/common/discovery/instance":
+					_, _ = w.Write([]byte(`{"tenant_discovery_endpoint": "https://login.microsoftonline.com/tenant/v2.0/.well-known/openid-configuration",
+						"metadata": [{"preferred_network": "login.microsoftonline.com", "aliases": ["login.microsoftonline.com"]}]}`))
+					return
+				case "/tenant/v2.0/.well-known/openid-configuration":
+					_, _ = w.Write([]byte(`{"token_endpoint": "https://login.microsoftonline.com/tenant/oauth2/v2.0/token",
+						"authorization_endpoint": "https://login.microsoftonline.com/tenant/oauth2/v2.0/authorize",
+						"issuer": "https://login.microsoftonline.com/tenant/v2.0"}`))
+					return
+				}
+				if strings.HasSuffix(r.URL.Path, "/oauth2/v2.0/token") {
+					if r.FormValue("client_secret") != "clientsecret" || !strings.Contains(r.FormValue("scope"), azureStorageScope) {
+						w.WriteHeader(http.StatusUnauthorized)
+						return
+					}
+					_, _ = w.Write([]byte(`{"access_token": "accesstoken", "expires_in": 3599, "token_type": "Bearer"}`))
+					return
+				}
+
+				b, _ := io.ReadAll(r.Body)
+				blobs[r.URL.Path] = string(b)
+				query = r.URL.RawQuery
+				headers = r.Header.Clone()
+				w.WriteHeader(http.StatusCreated)
+			}))
+			DeferCleanup(server.Close)
+		})
+
+		It("should write the artifact with the account key of the connection string", func() {
+			GinkgoT().Setenv("AZURE_STORAGE_CONNECTION_STRING",
+				"DefaultEndpointsProtocol=http;AccountName=devstoreaccount1;AccountKey="+azuriteKey+";BlobEndpoint="+server.URL+"/devstoreaccount1;")
+			w, err := NewAzureBlobWriter("azblob://container/prefix")
+			Expect(err).ToNot(HaveOccurred())
+
+			uri, err := w.WriteFile("component/results.json", strings.NewReader("{}"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(uri).To(Equal("azblob://container/prefix/component/results.json"))
+			Expect(w.WrittenFiles()).To(Equal([]string{uri}))
+
+			mu.Lock()
+			defer mu.Unlock()
+			Expect(blobs).To(HaveKeyWithValue("/devstoreaccount1/container/prefix/component/results.json", "{}"))
+			Expect(headers.Get("X-Ms-Blob-Type")).To(Equal("BlockBlob"))
+			Expect(headers.Get("Content-Type")).To(Equal("application/json"))
+			Expect(headers.Get("Authorization")).To(HavePrefix("SharedKey devstoreaccount1:"))
+		})
+
+		It("should write the artifact with the SAS token", func() {
+			GinkgoT().Setenv("AZURE_STORAGE_SAS_TOKEN", "?sv=2021-08-06&sp=cw&sig=signature")
+			w, err := NewAzureBlobWriter("azblob://container/prefix", WithAzureBlobEndpoint(server.URL))
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = w.WriteFile("results.json", strings.NewReader("{}"))
+			Expect(err).ToNot(HaveOccurred())
+
+			mu.Lock()
+			defer mu.Unlock()
+			Expect(blobs).To(HaveKey("/container/prefix/results.json"))
+			Expect(query).To(Equal("sv=2021-08-06&sp=cw&sig=signature"))
+			Expect(headers.Get("Authorization")).To(BeEmpty())
+		})
+
+		It("should authenticate with the client secret in the environment", func() {
+			GinkgoT().Setenv("AZURE_STORAGE_ACCOUNT", "account")
+			GinkgoT().Setenv("AZURE_TENANT_ID", "tenant")
+			GinkgoT().Setenv("AZURE_CLIENT_ID", "client")
+			GinkgoT().Setenv("AZURE_CLIENT_SECRET", "clientsecret")
+			// Microsoft Entra ID is only requested with https, so it is redirected to the server.
+			client := redirectingClient{host: strings.TrimPrefix(server.URL, "http://"), only: "login.microsoftonline.com"}
+			w, err := NewAzureBlobWriter("azblob://container/prefix", WithAzureBlobEndpoint(server.URL), WithAzureBlobHTTPClient(client))
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = w.WriteFile("results.json", strings.NewReader("{}"))
+			Expect(err).ToNot(HaveOccurred())
+
+			mu.Lock()
+			defer mu.Unlock()
+			Expect(headers.Get("Authorization")).To(Equal("Bearer accesstoken"))
+		})
+
+		It("should throw an error if an access token cannot be requested", func() {
+			GinkgoT().Setenv("AZURE_TENANT_ID", "tenant")
+			GinkgoT().Setenv("AZURE_CLIENT_ID", "client")
+			GinkgoT().Setenv("AZURE_CLIENT_SECRET", "wrongsecret")
+			// Microsoft Entra ID is only requested with https, so it is redirected to the server.
+			client := redirectingClient{host: strings.TrimPrefix(server.URL, "http://"), only: "login.microsoftonline.com"}
+			w, err := NewAzureBlobWriter("azblob://container/prefix", WithAzureBlobEndpoint(server.URL), WithAzureBlobHTTPClient(client))
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = w.WriteFile("results.json", strings.NewReader("{}"))
+			Expect(err).To(MatchError(ContainSubstring("status code: 401")))
+			Expect(w.WrittenFiles()).To(BeEmpty())
+		})
+	})
+
+	Context("When signing requests with Shared Key", func() {
+		It("should sign the canonicalized headers and resource", func() {
+			req, err := http.NewRequest(http.MethodPut, "http://127.0.0.1:10000/devstoreaccount1/container/prefix/results.json", strings.NewReader("{}"))
+			Expect(err).ToNot(HaveOccurred())
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-Ms-Blob-Type", "BlockBlob")
+			req.Header.Set("X-Ms-Date", time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC).Format(http.TimeFormat))
+			req.Header.Set("X-Ms-Version", azureBlobAPIVersion)
+
+			w, err := NewAzureBlobWriter("azblob://container", WithAzureBlobAccount("devstoreaccount1"), WithAzureBlobSharedKey(azuriteKey))
+			Expect(err).ToNot(HaveOccurred())
+			signAzureBlobRequest(req, "devstoreaccount1", w.key)
+
+			// The HMAC-SHA256 of the string to sign, computed with openssl.
+			Expect(req.Header.Get("Authorization")).To(Equal("SharedKey devstoreaccount1:uIgfdyFL60NxGensbiYHA6E5WmeR+JshTYZgZzcxKIM="))
+		})
+	})
+})
@@ -0,0 +1,147 @@
+package artifacts
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+)
+
+// BucketWriter is an ArtifactWriter that writes artifacts as objects in a bucket of
+// object storage.
+type BucketWriter interface {
+	ArtifactWriter
+	// WrittenFiles returns the URI of every object written, in the order they were written.
+	WrittenFiles() []string
+	// Location is the URI of the bucket and prefix that artifacts are written to.
+	Location() string
+}
+
+var (
+	_ BucketWriter = &S3Writer{}
+	_ BucketWriter = &GCSWriter{}
+	_ BucketWriter = &AzureBlobWriter{}
+)
+
+// IsBucketURI returns true if s is the URI of a bucket that a BucketWriter can write
+// to, i.e. s3://bucket/prefix, gs://bucket/prefix, or azblob://container/prefix.
+func IsBucketURI(s string) bool {
+	return IsS3URI(s) || IsGCSURI(s) || IsAzureBlobURI(s)
+}
+
+// NewBucketWriter returns the BucketWriter for the scheme of uri, configured from the
+// environment, sending requests with client. If client is nil, the writer's default
+// client is used.
+func NewBucketWriter(uri string, client HTTPClient) (BucketWriter, error) {
+	// The writers are returned only without errors, so that a nil writer is never
+	// returned as a non-nil BucketWriter.
+	switch {
+	case IsS3URI(uri):
+		opts := []S3WriterOption{}
+		if client != nil {
+			opts = append(opts, WithS3HTTPClient(client))
+		}
+		w, err := NewS3Writer(uri, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return w, nil
+	case IsGCSURI(uri):
+		opts := []GCSWriterOption{}
+		if client != nil {
+			opts = append(opts, WithGCSHTTPClient(client))
+		}
+		w, err := NewGCSWriter(uri, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return w, nil
+	case IsAzureBlobURI(uri):
+		opts := []AzureBlobWriterOption{}
+		if client != nil {
+			opts = append(opts, WithAzureBlobHTTPClient(client))
+		}
+		w, err := NewAzureBlobWriter(uri, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return w, nil
+	}
+
+	return nil, fmt.Errorf("%s is not the URI of a bucket, e.g. s3://bucket/prefix, gs://bucket/prefix, or azblob://container/prefix", uri)
+}
+
+// splitBucketURI returns the bucket and prefix of uri, which has scheme.
+func splitBucketURI(uri, scheme string) (bucket, prefix string, err error) {
+	bucket, prefix, _ = strings.Cut(strings.TrimPrefix(uri, scheme), "/")
+	if bucket == "" {
+		return "", "", fmt.Errorf("%s does not name a bucket", uri)
+	}
+
+	return bucket, strings.Trim(prefix, "/"), nil
+}
+
+// writtenFiles records the URIs of the objects written by a BucketWriter.
+type writtenFiles struct {
+	mu    sync.Mutex
+	files []string
+}
+
+func (w *writtenFiles) add(uri string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.files = append(w.files, uri)
+}
+
+func (w *writtenFiles) list() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	files := make([]string, len(w.files))
+	copy(files, w.files)
+
+	return files
+}
+
+// escapeObjectPath escapes the object key or bucket p for the path of a URL, as object
+// storage expects it in signed requests: every byte except unreserved characters and
+// slashes is percent-encoded.
+func escapeObjectPath(p string) string {
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+
+	return b.String()
+}
+
+// contentType returns the media type of the object with key, from its extension.
+func contentType(key string) string {
+	if t := mime.TypeByExtension(path.Ext(key)); t != "" {
+		return t
+	}
+
+	return "application/octet-stream"
+}
+
+// checkWriteResponse returns an error if resp, the response to writing the object at
+// uri, does not have status code want.
+func checkWriteResponse(resp *http.Response, uri string, want int) error {
+	if resp.StatusCode == want {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+		return nil
+	}
+
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	return fmt.Errorf("could not write %s: status code: %d: %s", uri, resp.StatusCode, strings.TrimSpace(string(msg)))
+}
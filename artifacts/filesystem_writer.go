@@ -1,9 +1,12 @@
 package artifacts
 
 import (
+	"bytes"
 	"fmt"
 	"io"
+	"os"
 	"path/filepath"
+	"sync"
 
 	"github.com/spf13/afero"
 )
@@ -11,8 +14,12 @@ import (
 // FilesystemWriter is an ArtifactWriter that targets a particular directory on
 // the underlying filesystem.
 type FilesystemWriter struct {
-	dir string
-	fs  afero.Fs
+	dir   string
+	fs    afero.Fs
+	quota *Quota
+
+	mu      sync.Mutex
+	written []string
 }
 
 // NewFilesystemWriter creates an artifact writer which writes to the filesystem.
@@ -46,12 +53,68 @@ type FilesystemWriterOption = func(*FilesystemWriter)
 func (w *FilesystemWriter) WriteFile(filename string, contents io.Reader) (string, error) {
 	fullFilePath := filepath.Join(w.Path(), filename)
 
+	if w.quota != nil {
+		b, err := io.ReadAll(contents)
+		if err != nil {
+			return fullFilePath, fmt.Errorf("could not write file to artifacts directory: %v", err)
+		}
+
+		b = w.quota.admit(filename, fullFilePath, b, func() error { return w.remove(fullFilePath) })
+		if b == nil {
+			// The file was dropped, so a previous version of it is stale.
+			_ = w.remove(fullFilePath)
+			return fullFilePath, nil
+		}
+		contents = bytes.NewReader(b)
+	}
+
 	if err := afero.WriteReader(w.fs, fullFilePath, contents); err != nil {
 		return fullFilePath, fmt.Errorf("could not write file to artifacts directory: %v", err)
 	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, f := range w.written {
+		if f == fullFilePath {
+			return fullFilePath, nil
+		}
+	}
+	w.written = append(w.written, fullFilePath)
+
 	return fullFilePath, nil
 }
 
+// remove removes the file at path, written by this writer.
+func (w *FilesystemWriter) remove(path string) error {
+	if err := w.fs.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for i, f := range w.written {
+		if f == path {
+			w.written = append(w.written[:i], w.written[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+// WrittenFiles returns the full path of every file written by this writer, in
+// the order they were first written. Files already present in the artifacts
+// directory, e.g. from previous executions, are not included.
+func (w *FilesystemWriter) WrittenFiles() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	files := make([]string, len(w.written))
+	copy(files, w.written)
+
+	return files
+}
+
 // Path is the full artifacts path.
 func (w *FilesystemWriter) Path() string {
 	return w.dir
@@ -46,5 +46,16 @@ var _ = Describe("Filesystem Artifact Writer", func() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(readin).To(Equal(contents))
 		})
+
+		It("Should track only the files written by the writer", func() {
+			Expect(os.WriteFile(filepath.Join(tempdir, "stale.txt"), contents, 0o644)).To(Succeed())
+
+			fullpath, err := aw.WriteFile(filename, bytes.NewBuffer(contents))
+			Expect(err).ToNot(HaveOccurred())
+			_, err = aw.WriteFile(filename, bytes.NewBuffer(contents))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(aw.WrittenFiles()).To(Equal([]string{fullpath}))
+		})
 	})
 })
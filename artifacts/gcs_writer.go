@@ -0,0 +1,215 @@
+package artifacts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+)
+
+// GCSScheme is the scheme of the URIs of Google Cloud Storage buckets, e.g.
+// gs://bucket/prefix.
+const GCSScheme = "gs://"
+
+// IsGCSURI returns true if s is the URI of a Google Cloud Storage bucket, e.g.
+// gs://bucket/prefix.
+func IsGCSURI(s string) bool {
+	return strings.HasPrefix(s, GCSScheme)
+}
+
+// GCSWriter is an ArtifactWriter that writes artifacts as objects in a Google Cloud
+// Storage bucket, under a prefix. By default, requests are authenticated with the
+// Application Default Credentials, as the Google Cloud client libraries find them: the
+// credentials file in GOOGLE_APPLICATION_CREDENTIALS, e.g. of a service account or of
+// workload identity federation, the credentials written by
+// `gcloud auth application-default login`, or the service account of the GCE instance
+// or GKE workload, from the metadata server. If STORAGE_EMULATOR_HOST is set, objects
+// are written to the emulator at that host, unauthenticated.
+type GCSWriter struct {
+	bucket   string
+	prefix   string
+	endpoint string
+	token    string
+	client   HTTPClient
+	gcs      *storage.Client
+
+	written writtenFiles
+}
+
+type GCSWriterOption = func(*GCSWriter)
+
+// WithGCSEndpoint sets the endpoint of the Google Cloud Storage JSON API, e.g. that of
+// an emulator.
+func WithGCSEndpoint(endpoint string) GCSWriterOption {
+	return func(w *GCSWriter) {
+		w.endpoint = strings.TrimSuffix(endpoint, "/")
+	}
+}
+
+// WithGCSAccessToken authenticates requests with the OAuth 2.0 access token, rather
+// than the Application Default Credentials.
+func WithGCSAccessToken(token string) GCSWriterOption {
+	return func(w *GCSWriter) {
+		w.token = token
+	}
+}
+
+// WithGCSHTTPClient sets the client that sends requests, including those for access
+// tokens. Defaults to the Google Cloud client libraries' client.
+func WithGCSHTTPClient(client HTTPClient) GCSWriterOption {
+	return func(w *GCSWriter) {
+		w.client = client
+	}
+}
+
+// NewGCSWriter returns an artifact writer writing to the bucket and prefix of uri, e.g.
+// gs://bucket/prefix, configured by opts. It returns an error if no credentials are
+// found.
+func NewGCSWriter(uri string, opts ...GCSWriterOption) (*GCSWriter, error) {
+	if !IsGCSURI(uri) {
+		return nil, fmt.Errorf("%s is not a gs URI, e.g. gs://bucket/prefix", uri)
+	}
+
+	bucket, prefix, err := splitBucketURI(uri, GCSScheme)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &GCSWriter{
+		bucket: bucket,
+		prefix: prefix,
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	ctx := context.Background()
+	clientOpts := []option.ClientOption{}
+	if w.endpoint != "" {
+		if u, err := url.Parse(w.endpoint); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return nil, fmt.Errorf("the gcs endpoint %s must be an http or https URL", w.endpoint)
+		}
+		clientOpts = append(clientOpts, option.WithEndpoint(w.endpoint+"/storage/v1/"))
+	}
+	if w.client != nil {
+		// Access tokens are requested with the client too.
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, asHTTPClient(w.client))
+	}
+
+	// The emulator is not authenticated with, so the credentials are only needed without it.
+	var creds *google.Credentials
+	if w.token == "" && os.Getenv("STORAGE_EMULATOR_HOST") == "" {
+		if creds, err = google.FindDefaultCredentials(ctx, storage.ScopeReadWrite); err != nil {
+			return nil, fmt.Errorf("gcs credentials are required, e.g. from GOOGLE_APPLICATION_CREDENTIALS, "+
+				"`gcloud auth application-default login`, or the metadata server: %w", err)
+		}
+	}
+
+	switch {
+	case creds != nil && w.client == nil:
+		clientOpts = append(clientOpts, option.WithCredentials(creds))
+	case creds != nil:
+		clientOpts = append(clientOpts, option.WithHTTPClient(oauth2.NewClient(ctx, creds.TokenSource)))
+	case w.token != "":
+		// The client is used even for the emulator, which the credentials options are not.
+		token := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: w.token})
+		clientOpts = append(clientOpts, option.WithHTTPClient(oauth2.NewClient(ctx, token)))
+	case w.client != nil:
+		clientOpts = append(clientOpts, option.WithHTTPClient(asHTTPClient(w.client)))
+	}
+
+	if w.gcs, err = storage.NewClient(ctx, clientOpts...); err != nil {
+		return nil, fmt.Errorf("could not create the gcs client: %w", err)
+	}
+
+	return w, nil
+}
+
+// WriteFile writes contents to the object named filename under the prefix, and returns
+// the URI of the object.
+func (w *GCSWriter) WriteFile(filename string, contents io.Reader) (string, error) {
+	key := path.Join(w.prefix, filepath.ToSlash(filename))
+	uri := GCSScheme + path.Join(w.bucket, key)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ow := w.gcs.Bucket(w.bucket).Object(key).NewWriter(ctx)
+	ow.ContentType = contentType(key)
+	// The artifacts are small, so each is uploaded in a single request.
+	ow.ChunkSize = 0
+
+	// The object is uploaded as it is written, so an upload that fails is reported by
+	// either Write or Close.
+	if _, err := io.Copy(ow, contents); err != nil {
+		// Cancelling the context discards the partial object.
+		cancel()
+		_ = ow.Close()
+		return uri, gcsWriteError(uri, err)
+	}
+	if err := ow.Close(); err != nil {
+		return uri, gcsWriteError(uri, err)
+	}
+	w.written.add(uri)
+
+	return uri, nil
+}
+
+// WrittenFiles returns the URI of every object written by this writer, in the order
+// they were written.
+func (w *GCSWriter) WrittenFiles() []string {
+	return w.written.list()
+}
+
+// Location is the URI of the bucket and prefix that artifacts are written to.
+func (w *GCSWriter) Location() string {
+	return GCSScheme + path.Join(w.bucket, w.prefix)
+}
+
+// gcsWriteError returns the error of writing the object at uri, with the status code of
+// the response to the upload or to the request for an access token, if there was one.
+func gcsWriteError(uri string, err error) error {
+	var apiErr *googleapi.Error
+	var tokenErr *oauth2.RetrieveError
+	switch {
+	case errors.As(err, &apiErr):
+		return fmt.Errorf("could not write %s: status code: %d: %s", uri, apiErr.Code, apiErr.Message)
+	case errors.As(err, &tokenErr):
+		return fmt.Errorf("could not write %s: could not request an access token: status code: %d: %s",
+			uri, tokenErr.Response.StatusCode, strings.TrimSpace(string(tokenErr.Body)))
+	}
+
+	return fmt.Errorf("could not write file to gcs: %w", err)
+}
+
+// asHTTPClient returns client as an *http.Client, as the Google Cloud client libraries
+// require.
+func asHTTPClient(client HTTPClient) *http.Client {
+	if c, ok := client.(*http.Client); ok {
+		return c
+	}
+
+	return &http.Client{Transport: clientTransport{client}}
+}
+
+// clientTransport is an http.RoundTripper sending requests with an HTTPClient.
+type clientTransport struct {
+	client HTTPClient
+}
+
+func (t clientTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.client.Do(req)
+}
@@ -0,0 +1,193 @@
+package artifacts
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GCS Artifact Writer", func() {
+	Context("When creating a GCS Artifact Writer", func() {
+		It("should require a gs URI", func() {
+			_, err := NewGCSWriter("s3://bucket/prefix", WithGCSAccessToken("token"))
+			Expect(err).To(MatchError(ContainSubstring("is not a gs URI")))
+		})
+
+		It("should require a bucket", func() {
+			_, err := NewGCSWriter("gs:///prefix", WithGCSAccessToken("token"))
+			Expect(err).To(MatchError(ContainSubstring("does not name a bucket")))
+		})
+
+		It("should reject credentials files that are not supported", func() {
+			file := filepath.Join(GinkgoT().TempDir(), "credentials.json")
+			Expect(os.WriteFile(file, []byte(`{"type": "unknown"}`), 0o600)).To(Succeed())
+			GinkgoT().Setenv("STORAGE_EMULATOR_HOST", "")
+			GinkgoT().Setenv("GOOGLE_APPLICATION_CREDENTIALS", file)
+
+			_, err := NewGCSWriter("gs://bucket/prefix")
+			Expect(err).To(MatchError(ContainSubstring("gcs credentials are required")))
+		})
+	})
+
+	Context("When writing artifacts to Google Cloud Storage", func() {
+		var (
+			server        *httptest.Server
+			key           *rsa.PrivateKey
+			mu            sync.Mutex
+			objects       map[string]string
+			contentTypes  map[string]string
+			headers       http.Header
+			tokenRequests int
+		)
+
+		BeforeEach(func() {
+			var err error
+			key, err = rsa.GenerateKey(rand.Reader, 2048)
+			Expect(err).ToNot(HaveOccurred())
+
+			objects = map[string]string{}
+			contentTypes = map[string]string{}
+			tokenRequests = 0
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				if r.URL.Path == "/token" {
+					tokenRequests++
+					if r.FormValue("grant_type") != "urn:ietf:params:oauth:grant-type:jwt-bearer" || !verifyJWT(r.FormValue("assertion"), &key.PublicKey) {
+						w.WriteHeader(http.StatusBadRequest)
+						return
+					}
+					_, _ = w.Write([]byte(`{"access_token": "accesstoken", "expires_in": 3599, "token_type": "Bearer"}`))
+					return
+				}
+
+				// Objects are uploaded as multipart requests of their metadata and contents.
+				_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+				if err != nil || r.URL.Path != "/upload/storage/v1/b/bucket/o" || r.URL.Query().Get("uploadType") != "multipart" {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				parts := multipart.NewReader(r.Body, params["boundary"])
+				var metadata struct {
+					Name string `json:"name"`
+				}
+				part, err := parts.NextPart()
+				if err != nil || json.NewDecoder(part).Decode(&metadata) != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				if part, err = parts.NextPart(); err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				b, _ := io.ReadAll(part)
+				objects[metadata.Name] = string(b)
+				headers = r.Header.Clone()
+				contentTypes[metadata.Name] = part.Header.Get("Content-Type")
+
+				_ = json.NewEncoder(w).Encode(map[string]string{"bucket": "bucket", "name": metadata.Name})
+			}))
+			DeferCleanup(server.Close)
+			GinkgoT().Setenv("STORAGE_EMULATOR_HOST", "")
+		})
+
+		It("should write the artifact to an emulator without authenticating", func() {
+			GinkgoT().Setenv("STORAGE_EMULATOR_HOST", strings.TrimPrefix(server.URL, "http://"))
+			w, err := NewGCSWriter("gs://bucket/prefix")
+			Expect(err).ToNot(HaveOccurred())
+
+			uri, err := w.WriteFile("component/results.json", strings.NewReader("{}"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(uri).To(Equal("gs://bucket/prefix/component/results.json"))
+			Expect(w.WrittenFiles()).To(Equal([]string{uri}))
+
+			mu.Lock()
+			defer mu.Unlock()
+			Expect(objects).To(HaveKeyWithValue("prefix/component/results.json", "{}"))
+			Expect(contentTypes).To(HaveKeyWithValue("prefix/component/results.json", "application/json"))
+			Expect(headers.Get("Authorization")).To(BeEmpty())
+		})
+
+		It("should authenticate with the service account in GOOGLE_APPLICATION_CREDENTIALS", func() {
+			der, err := x509.MarshalPKCS8PrivateKey(key)
+			Expect(err).ToNot(HaveOccurred())
+			creds, err := json.Marshal(map[string]string{
+				"type":           "service_account",
+				"client_email":   "preflight@project.iam.gserviceaccount.com",
+				"private_key_id": "keyid",
+				"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+				"token_uri":      server.URL + "/token",
+			})
+			Expect(err).ToNot(HaveOccurred())
+			file := filepath.Join(GinkgoT().TempDir(), "credentials.json")
+			Expect(os.WriteFile(file, creds, 0o600)).To(Succeed())
+			GinkgoT().Setenv("GOOGLE_APPLICATION_CREDENTIALS", file)
+
+			w, err := NewGCSWriter("gs://bucket/prefix", WithGCSEndpoint(server.URL))
+			Expect(err).ToNot(HaveOccurred())
+			_, err = w.WriteFile("results.json", strings.NewReader("{}"))
+			Expect(err).ToNot(HaveOccurred())
+			_, err = w.WriteFile("preflight.log", strings.NewReader("log"))
+			Expect(err).ToNot(HaveOccurred())
+
+			mu.Lock()
+			defer mu.Unlock()
+			Expect(headers.Get("Authorization")).To(Equal("Bearer accesstoken"))
+			Expect(objects).To(HaveLen(2))
+			Expect(tokenRequests).To(Equal(1))
+		})
+
+		It("should throw an error if an access token cannot be requested", func() {
+			other, err := rsa.GenerateKey(rand.Reader, 2048)
+			Expect(err).ToNot(HaveOccurred())
+			file := filepath.Join(GinkgoT().TempDir(), "credentials.json")
+			creds, err := json.Marshal(map[string]string{
+				"type":        "service_account",
+				"private_key": string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(other)})),
+				"token_uri":   server.URL + "/token",
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(os.WriteFile(file, creds, 0o600)).To(Succeed())
+			GinkgoT().Setenv("GOOGLE_APPLICATION_CREDENTIALS", file)
+
+			w, err := NewGCSWriter("gs://bucket/prefix", WithGCSEndpoint(server.URL))
+			Expect(err).ToNot(HaveOccurred())
+			_, err = w.WriteFile("results.json", strings.NewReader("{}"))
+			Expect(err).To(MatchError(ContainSubstring("could not request an access token: status code: 400")))
+			Expect(w.WrittenFiles()).To(BeEmpty())
+		})
+	})
+})
+
+// verifyJWT returns true if the RS256 signature of the JWT token is valid for key.
+func verifyJWT(token string, key *rsa.PublicKey) bool {
+	i := strings.LastIndex(token, ".")
+	if i < 0 {
+		return false
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(token[i+1:])
+	if err != nil {
+		return false
+	}
+	digest := sha256.Sum256([]byte(token[:i]))
+
+	return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil
+}
@@ -1,34 +1,90 @@
 package artifacts
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
 	"io"
+	"io/fs"
+	"sort"
+	"sync"
 )
 
 var ErrFileAlreadyExists = errors.New("file already exists")
 
-// MapWriter implements an ArtifactWriter storing contents in a map.
+// MapWriter implements an ArtifactWriter storing contents in memory, for library users
+// that capture artifacts without writing them to disk. Contents are read when they are
+// written, so the readers passed to WriteFile may be closed afterwards. It is safe for
+// concurrent use.
 type MapWriter struct {
-	files map[string]io.Reader
+	mu    sync.RWMutex
+	files map[string][]byte
 }
 
 // NewMapWriter creates an artifact writer in memory using a map.
 func NewMapWriter() (*MapWriter, error) {
 	return &MapWriter{
-		files: map[string]io.Reader{},
+		files: map[string][]byte{},
 	}, nil
 }
 
-// WriteFile places contents into files at filename.
+// WriteFile reads contents into memory, as filename. Each filename may only be written
+// once; later writes return ErrFileAlreadyExists.
 func (w *MapWriter) WriteFile(filename string, contents io.Reader) (string, error) {
+	b, err := io.ReadAll(contents)
+	if err != nil {
+		return "", fmt.Errorf("could not read the contents of %s: %w", filename, err)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
 	if _, exists := w.files[filename]; exists {
 		return "", ErrFileAlreadyExists
 	}
 
-	w.files[filename] = contents
+	w.files[filename] = b
 	return filename, nil
 }
 
+// Filenames returns the names of the artifacts written, sorted.
+func (w *MapWriter) Filenames() []string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	names := make([]string, 0, len(w.files))
+	for name := range w.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// ReadFile returns the contents of the artifact filename. If it was not written, the
+// error is fs.ErrNotExist.
+func (w *MapWriter) ReadFile(filename string) ([]byte, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	b, ok := w.files[filename]
+	if !ok {
+		return nil, &fs.PathError{Op: "read", Path: filename, Err: fs.ErrNotExist}
+	}
+
+	return append([]byte(nil), b...), nil
+}
+
+// Files returns a reader of the contents of each artifact written, by filename. Every
+// call returns new readers, so artifacts may be read more than once.
 func (w *MapWriter) Files() map[string]io.Reader {
-	return w.files
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	files := make(map[string]io.Reader, len(w.files))
+	for name, b := range w.files {
+		files[name] = bytes.NewReader(b)
+	}
+
+	return files
 }
@@ -3,6 +3,7 @@ package artifacts
 import (
 	"bytes"
 	"io"
+	"io/fs"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -40,5 +41,41 @@ var _ = Describe("Map Artifact Writer", func() {
 			_, err = aw.WriteFile(filename, bytes.NewBuffer([]byte("rejected")))
 			Expect(err).To(Equal(ErrFileAlreadyExists))
 		})
+
+		It("Should enumerate the written files by name", func() {
+			_, err := aw.WriteFile("z.txt", bytes.NewBuffer(contents))
+			Expect(err).ToNot(HaveOccurred())
+			_, err = aw.WriteFile(filename, bytes.NewBuffer(contents))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(aw.Filenames()).To(Equal([]string{filename, "z.txt"}))
+		})
+
+		It("Should keep the contents after the reader they were written from changes", func() {
+			buf := bytes.NewBuffer(contents)
+			_, err := aw.WriteFile(filename, buf)
+			Expect(err).ToNot(HaveOccurred())
+			buf.Reset()
+
+			readin, err := aw.ReadFile(filename)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(readin).To(Equal(contents))
+		})
+
+		It("Should return new readers of the contents every time", func() {
+			_, err := aw.WriteFile(filename, bytes.NewBuffer(contents))
+			Expect(err).ToNot(HaveOccurred())
+
+			for i := 0; i < 2; i++ {
+				readin, err := io.ReadAll(aw.Files()[filename])
+				Expect(err).ToNot(HaveOccurred())
+				Expect(readin).To(Equal(contents))
+			}
+		})
+
+		It("Should return fs.ErrNotExist for files that were not written", func() {
+			_, err := aw.ReadFile("missing.txt")
+			Expect(err).To(MatchError(fs.ErrNotExist))
+		})
 	})
 })
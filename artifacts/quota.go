@@ -0,0 +1,181 @@
+package artifacts
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Priority is how important it is to keep an artifact when a Quota is exceeded.
+type Priority int
+
+const (
+	// PriorityRaw artifacts, e.g. the raw output of commands, are dropped first.
+	PriorityRaw Priority = iota
+	// PriorityLog artifacts are truncated to fit, keeping their end, after raw
+	// artifacts are dropped.
+	PriorityLog
+	// PriorityEssential artifacts, e.g. results, are always written, dropping raw
+	// and log artifacts to make room for them.
+	PriorityEssential
+)
+
+// PriorityFunc returns the priority of the artifact named filename.
+type PriorityFunc = func(filename string) Priority
+
+// Truncation actions.
+const (
+	// TruncationDropped is an artifact that was not written.
+	TruncationDropped = "dropped"
+	// TruncationTruncated is an artifact of which only the end was written.
+	TruncationTruncated = "truncated"
+	// TruncationRemoved is an artifact that was removed to make room for another.
+	TruncationRemoved = "removed"
+)
+
+// Truncation records an artifact that was not written in full to stay within a Quota.
+type Truncation struct {
+	Path    string `json:"path"`
+	Action  string `json:"action"`
+	Size    int64  `json:"size"`
+	Written int64  `json:"written"`
+}
+
+// truncationMarker precedes the end of an artifact that was truncated.
+const truncationMarker = "[preflight: %d bytes truncated to stay within the artifacts quota]\n"
+
+// Quota limits the total size of the artifacts written by one or more FilesystemWriters.
+// When an artifact does not fit, raw artifacts are dropped, and log artifacts are
+// truncated, to make room for artifacts with a higher priority. What was truncated is
+// recorded.
+type Quota struct {
+	max      int64
+	priority PriorityFunc
+
+	mu          sync.Mutex
+	used        int64
+	entries     []*quotaEntry
+	truncations []Truncation
+}
+
+// quotaEntry is an artifact written within a Quota.
+type quotaEntry struct {
+	path     string
+	size     int64
+	priority Priority
+	remove   func() error
+}
+
+// NewQuota returns a Quota of max bytes, prioritizing artifacts with priority.
+func NewQuota(max int64, priority PriorityFunc) *Quota {
+	return &Quota{max: max, priority: priority}
+}
+
+// Truncations returns the artifacts that were not written in full, in the order
+// they were truncated.
+func (q *Quota) Truncations() []Truncation {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	truncations := make([]Truncation, len(q.truncations))
+	copy(truncations, q.truncations)
+
+	return truncations
+}
+
+// Used returns the total size of the artifacts written within q.
+func (q *Quota) Used() int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.used
+}
+
+// admit returns the contents of the artifact named filename at path that fit in q,
+// or nil if it is dropped, making room for it by removing artifacts with a lower
+// priority. remove removes the artifact if another needs its room later.
+func (q *Quota) admit(filename, path string, contents []byte, remove func() error) []byte {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	// An artifact that is written again replaces its previous contents.
+	for i, e := range q.entries {
+		if e.path == path {
+			q.used -= e.size
+			q.entries = append(q.entries[:i], q.entries[i+1:]...)
+			break
+		}
+	}
+
+	priority := q.priority(filename)
+	size := int64(len(contents))
+	if q.used+size > q.max && priority > PriorityRaw {
+		q.removeFor(priority, q.used+size-q.max)
+	}
+
+	available := q.max - q.used
+	switch {
+	case size <= available, priority == PriorityEssential:
+	case priority == PriorityLog && available > int64(len(fmt.Sprintf(truncationMarker, size))):
+		// The marker is at most as long as the one for the whole artifact.
+		kept := available - int64(len(fmt.Sprintf(truncationMarker, size)))
+		marker := []byte(fmt.Sprintf(truncationMarker, size-kept))
+		contents = append(marker, contents[size-kept:]...)
+		q.truncations = append(q.truncations, Truncation{Path: path, Action: TruncationTruncated, Size: size, Written: int64(len(contents))})
+	default:
+		q.truncations = append(q.truncations, Truncation{Path: path, Action: TruncationDropped, Size: size})
+		return nil
+	}
+
+	q.used += int64(len(contents))
+	q.entries = append(q.entries, &quotaEntry{path: path, size: int64(len(contents)), priority: priority, remove: remove})
+
+	return contents
+}
+
+// removeFor removes artifacts with a lower priority than priority, lowest priority and
+// largest first, until needed bytes are freed or there are none left.
+func (q *Quota) removeFor(priority Priority, needed int64) {
+	candidates := make([]*quotaEntry, 0, len(q.entries))
+	for _, e := range q.entries {
+		if e.priority < priority {
+			candidates = append(candidates, e)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].priority != candidates[j].priority {
+			return candidates[i].priority < candidates[j].priority
+		}
+		return candidates[i].size > candidates[j].size
+	})
+
+	removed := map[*quotaEntry]bool{}
+	for _, e := range candidates {
+		if needed <= 0 {
+			break
+		}
+		if err := e.remove(); err != nil {
+			continue
+		}
+		removed[e] = true
+		needed -= e.size
+		q.used -= e.size
+		q.truncations = append(q.truncations, Truncation{Path: e.path, Action: TruncationRemoved, Size: e.size})
+	}
+
+	entries := q.entries[:0]
+	for _, e := range q.entries {
+		if !removed[e] {
+			entries = append(entries, e)
+		}
+	}
+	q.entries = entries
+}
+
+// WithQuota limits the total size of the artifacts written by the writer, and any other
+// writer sharing q, to q.
+func WithQuota(q *Quota) FilesystemWriterOption {
+	return func(w *FilesystemWriter) {
+		w.quota = q
+	}
+}
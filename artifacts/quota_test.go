@@ -0,0 +1,134 @@
+package artifacts
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Artifacts Quota", func() {
+	var (
+		tempdir string
+		quota   *Quota
+		aw      *FilesystemWriter
+	)
+
+	priority := func(filename string) Priority {
+		switch {
+		case strings.HasPrefix(filename, "results"):
+			return PriorityEssential
+		case strings.HasSuffix(filename, ".log"):
+			return PriorityLog
+		}
+		return PriorityRaw
+	}
+
+	BeforeEach(func() {
+		var err error
+		tempdir, err = os.MkdirTemp(os.TempDir(), "artifacts-quota-*")
+		Expect(err).ToNot(HaveOccurred())
+
+		quota = NewQuota(200, priority)
+		aw, err = NewFilesystemWriter(WithDirectory(tempdir), WithQuota(quota))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tempdir)).To(Succeed())
+	})
+
+	It("should write artifacts that fit", func() {
+		fullpath, err := aw.WriteFile("raw.json", bytes.NewReader(make([]byte, 100)))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fullpath).To(BeAnExistingFile())
+		Expect(quota.Used()).To(BeEquivalentTo(100))
+		Expect(quota.Truncations()).To(BeEmpty())
+	})
+
+	It("should drop raw artifacts that do not fit", func() {
+		_, err := aw.WriteFile("raw1.json", bytes.NewReader(make([]byte, 150)))
+		Expect(err).ToNot(HaveOccurred())
+		fullpath, err := aw.WriteFile("raw2.json", bytes.NewReader(make([]byte, 100)))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(fullpath).ToNot(BeAnExistingFile())
+		Expect(aw.WrittenFiles()).To(Equal([]string{filepath.Join(tempdir, "raw1.json")}))
+		Expect(quota.Truncations()).To(Equal([]Truncation{
+			{Path: fullpath, Action: TruncationDropped, Size: 100},
+		}))
+	})
+
+	It("should remove raw artifacts to make room for logs", func() {
+		rawpath, err := aw.WriteFile("raw.json", bytes.NewReader(make([]byte, 150)))
+		Expect(err).ToNot(HaveOccurred())
+		logpath, err := aw.WriteFile("check.log", bytes.NewReader(make([]byte, 100)))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(rawpath).ToNot(BeAnExistingFile())
+		Expect(logpath).To(BeAnExistingFile())
+		Expect(aw.WrittenFiles()).To(Equal([]string{logpath}))
+		Expect(quota.Used()).To(BeEquivalentTo(100))
+		Expect(quota.Truncations()).To(Equal([]Truncation{
+			{Path: rawpath, Action: TruncationRemoved, Size: 150},
+		}))
+	})
+
+	It("should keep the end of logs that do not fit", func() {
+		contents := strings.Repeat("0123456789", 30)
+		logpath, err := aw.WriteFile("check.log", strings.NewReader(contents))
+		Expect(err).ToNot(HaveOccurred())
+
+		written, err := os.ReadFile(logpath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(written).To(HaveLen(200))
+		Expect(string(written)).To(HavePrefix("[preflight: "))
+		Expect(contents).To(HaveSuffix(string(written[bytes.IndexByte(written, '\n')+1:])))
+		Expect(quota.Truncations()).To(Equal([]Truncation{
+			{Path: logpath, Action: TruncationTruncated, Size: 300, Written: 200},
+		}))
+	})
+
+	It("should always write essential artifacts, removing others to make room", func() {
+		rawpath, err := aw.WriteFile("raw.json", bytes.NewReader(make([]byte, 50)))
+		Expect(err).ToNot(HaveOccurred())
+		logpath, err := aw.WriteFile("check.log", bytes.NewReader(make([]byte, 100)))
+		Expect(err).ToNot(HaveOccurred())
+		resultspath, err := aw.WriteFile("results.json", bytes.NewReader(make([]byte, 250)))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(resultspath).To(BeAnExistingFile())
+		Expect(aw.WrittenFiles()).To(Equal([]string{resultspath}))
+		Expect(quota.Used()).To(BeEquivalentTo(250))
+		Expect(quota.Truncations()).To(Equal([]Truncation{
+			{Path: rawpath, Action: TruncationRemoved, Size: 50},
+			{Path: logpath, Action: TruncationRemoved, Size: 100},
+		}))
+	})
+
+	It("should count an artifact that is written again once", func() {
+		_, err := aw.WriteFile("raw.json", bytes.NewReader(make([]byte, 150)))
+		Expect(err).ToNot(HaveOccurred())
+		_, err = aw.WriteFile("raw.json", bytes.NewReader(make([]byte, 150)))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(quota.Used()).To(BeEquivalentTo(150))
+		Expect(quota.Truncations()).To(BeEmpty())
+	})
+
+	It("should share the quota between writers", func() {
+		other, err := NewFilesystemWriter(WithDirectory(filepath.Join(tempdir, "component")), WithQuota(quota))
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = aw.WriteFile("raw.json", bytes.NewReader(make([]byte, 150)))
+		Expect(err).ToNot(HaveOccurred())
+		fullpath, err := other.WriteFile("raw.json", bytes.NewReader(make([]byte, 100)))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(fullpath).ToNot(BeAnExistingFile())
+		Expect(quota.Used()).To(BeEquivalentTo(150))
+	})
+})
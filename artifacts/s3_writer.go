@@ -0,0 +1,239 @@
+package artifacts
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// S3Scheme is the scheme of the URIs of S3 buckets, e.g. s3://bucket/prefix.
+const S3Scheme = "s3://"
+
+// defaultS3Region is the region of the bucket if none is configured.
+const defaultS3Region = "us-east-1"
+
+// IsS3URI returns true if s is the URI of an S3 bucket, e.g. s3://bucket/prefix.
+func IsS3URI(s string) bool {
+	return strings.HasPrefix(s, S3Scheme)
+}
+
+// HTTPClient sends requests to object storage.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// S3Credentials authenticate requests to S3.
+type S3Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is set for temporary credentials, e.g. from an assumed role.
+	SessionToken string
+}
+
+// S3Writer is an ArtifactWriter that writes artifacts as objects in an S3 bucket, or
+// a bucket in S3-compatible object storage, under a prefix. By default, it is
+// configured as the AWS SDK is: its credentials are those of the default credential
+// chain, i.e. AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, and AWS_SESSION_TOKEN, the
+// shared configuration and credentials files, a web identity token, e.g. of an IAM role
+// for a Kubernetes service account, or the instance metadata service of EC2 or ECS, and
+// its region is that of AWS_REGION, AWS_DEFAULT_REGION, or the shared configuration.
+// AWS_ENDPOINT_URL_S3 or AWS_ENDPOINT_URL configure S3-compatible object storage.
+type S3Writer struct {
+	bucket   string
+	prefix   string
+	region   string
+	endpoint string
+	creds    aws.CredentialsProvider
+	client   HTTPClient
+	s3       *s3.Client
+
+	written writtenFiles
+}
+
+type S3WriterOption = func(*S3Writer)
+
+// WithS3Region sets the region of the bucket.
+func WithS3Region(region string) S3WriterOption {
+	return func(w *S3Writer) {
+		w.region = region
+	}
+}
+
+// WithS3Endpoint sets the endpoint of S3-compatible object storage, e.g.
+// https://minio.example.com. Buckets are addressed by path, rather than by host.
+func WithS3Endpoint(endpoint string) S3WriterOption {
+	return func(w *S3Writer) {
+		w.endpoint = strings.TrimSuffix(endpoint, "/")
+	}
+}
+
+// WithS3Credentials sets the credentials that requests are authenticated with, rather
+// than those of the default credential chain.
+func WithS3Credentials(creds S3Credentials) S3WriterOption {
+	return func(w *S3Writer) {
+		w.creds = credentials.NewStaticCredentialsProvider(creds.AccessKeyID, creds.SecretAccessKey, creds.SessionToken)
+	}
+}
+
+// WithS3HTTPClient sets the client that sends requests, including those for
+// credentials. Defaults to the AWS SDK's client.
+func WithS3HTTPClient(client HTTPClient) S3WriterOption {
+	return func(w *S3Writer) {
+		w.client = client
+	}
+}
+
+// NewS3Writer returns an artifact writer writing to the bucket and prefix of uri, e.g.
+// s3://bucket/prefix, configured by opts. It returns an error if no credentials are
+// found.
+func NewS3Writer(uri string, opts ...S3WriterOption) (*S3Writer, error) {
+	if !IsS3URI(uri) {
+		return nil, fmt.Errorf("%s is not an s3 URI, e.g. s3://bucket/prefix", uri)
+	}
+
+	bucket, prefix, err := splitBucketURI(uri, S3Scheme)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &S3Writer{
+		bucket: bucket,
+		prefix: prefix,
+		endpoint: strings.TrimSuffix(
+			firstNonEmpty(os.Getenv("AWS_ENDPOINT_URL_S3"), os.Getenv("AWS_ENDPOINT_URL")), "/"),
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	if w.endpoint != "" {
+		if u, err := url.Parse(w.endpoint); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return nil, fmt.Errorf("the s3 endpoint %s must be an http or https URL", w.endpoint)
+		}
+	}
+
+	ctx := context.Background()
+	loadOpts := []func(*config.LoadOptions) error{config.WithDefaultRegion(defaultS3Region)}
+	if w.region != "" {
+		loadOpts = append(loadOpts, config.WithRegion(w.region))
+	}
+	if w.creds != nil {
+		loadOpts = append(loadOpts, config.WithCredentialsProvider(w.creds))
+	}
+	if w.client != nil {
+		loadOpts = append(loadOpts, config.WithHTTPClient(buildableClient(w.client)))
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, loadOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("could not load the s3 configuration: %w", err)
+	}
+	w.region, w.creds = cfg.Region, cfg.Credentials
+
+	// The credentials are retrieved now, so that a misconfiguration is reported before
+	// any check is executed, rather than when the first artifact is written.
+	if _, err := cfg.Credentials.Retrieve(ctx); err != nil {
+		return nil, fmt.Errorf("s3 credentials are required, e.g. from AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY, "+
+			"a web identity token, or the instance metadata service: %w", err)
+	}
+
+	w.s3 = s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if w.endpoint != "" {
+			o.BaseEndpoint = aws.String(w.endpoint)
+			o.UsePathStyle = true
+		}
+	})
+
+	return w, nil
+}
+
+// WriteFile writes contents to the object named filename under the prefix, and returns
+// the URI of the object.
+func (w *S3Writer) WriteFile(filename string, contents io.Reader) (string, error) {
+	key := path.Join(w.prefix, filepath.ToSlash(filename))
+	uri := S3Scheme + path.Join(w.bucket, key)
+
+	// The body is read whole, so that its length and hash are known when it is signed.
+	body, err := io.ReadAll(contents)
+	if err != nil {
+		return uri, fmt.Errorf("could not write file to s3: %w", err)
+	}
+
+	_, err = w.s3.PutObject(context.Background(), &s3.PutObjectInput{
+		Bucket:        aws.String(w.bucket),
+		Key:           aws.String(key),
+		Body:          bytes.NewReader(body),
+		ContentLength: int64(len(body)),
+	})
+	if err != nil {
+		var respErr *awshttp.ResponseError
+		if errors.As(err, &respErr) {
+			return uri, fmt.Errorf("could not write %s: status code: %d: %v", uri, respErr.HTTPStatusCode(), respErr.Err)
+		}
+		return uri, fmt.Errorf("could not write file to s3: %w", err)
+	}
+	w.written.add(uri)
+
+	return uri, nil
+}
+
+// WrittenFiles returns the URI of every object written by this writer, in the order
+// they were written.
+func (w *S3Writer) WrittenFiles() []string {
+	return w.written.list()
+}
+
+// Location is the URI of the bucket and prefix that artifacts are written to.
+func (w *S3Writer) Location() string {
+	return S3Scheme + path.Join(w.bucket, w.prefix)
+}
+
+// buildableClient returns client as a client of the AWS SDK that AWS_CA_BUNDLE can be
+// added to, if it is an *http.Client with an *http.Transport, e.g. one configured with
+// the proxy and certificate authorities of preflight. Otherwise, it returns client.
+func buildableClient(client HTTPClient) HTTPClient {
+	c, ok := client.(*http.Client)
+	if !ok {
+		return client
+	}
+	t, ok := c.Transport.(*http.Transport)
+	if !ok {
+		return client
+	}
+
+	return awshttp.NewBuildableClient().WithTimeout(c.Timeout).WithTransportOptions(func(tr *http.Transport) {
+		tr.Proxy = t.Proxy
+		tr.DialContext = t.DialContext
+		if t.TLSClientConfig != nil {
+			tr.TLSClientConfig = t.TLSClientConfig.Clone()
+			// The bundle is added to a copy of the pool, rather than to the pool itself.
+			if pool := tr.TLSClientConfig.RootCAs; pool != nil {
+				tr.TLSClientConfig.RootCAs = pool.Clone()
+			}
+		}
+	})
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
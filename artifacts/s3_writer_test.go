@@ -0,0 +1,196 @@
+package artifacts
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("S3 Artifact Writer", func() {
+	creds := S3Credentials{AccessKeyID: "keyid", SecretAccessKey: "secretkey"}
+
+	Context("When creating an S3 Artifact Writer", func() {
+		It("should require an s3 URI", func() {
+			_, err := NewS3Writer("artifacts", WithS3Credentials(creds))
+			Expect(err).To(MatchError(ContainSubstring("is not an s3 URI")))
+		})
+
+		It("should require a bucket", func() {
+			_, err := NewS3Writer("s3:///prefix", WithS3Credentials(creds))
+			Expect(err).To(MatchError(ContainSubstring("does not name a bucket")))
+		})
+
+		It("should require credentials", func() {
+			isolateAWSEnv()
+			GinkgoT().Setenv("AWS_EC2_METADATA_DISABLED", "true")
+			_, err := NewS3Writer("s3://bucket/prefix")
+			Expect(err).To(MatchError(ContainSubstring("s3 credentials are required")))
+		})
+
+		It("should read the configuration from the environment", func() {
+			isolateAWSEnv()
+			GinkgoT().Setenv("AWS_ACCESS_KEY_ID", "envkeyid")
+			GinkgoT().Setenv("AWS_SECRET_ACCESS_KEY", "envsecretkey")
+			GinkgoT().Setenv("AWS_REGION", "eu-west-1")
+			w, err := NewS3Writer("s3://bucket/prefix/")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(w.Location()).To(Equal("s3://bucket/prefix"))
+			Expect(w.region).To(Equal("eu-west-1"))
+			Expect(w.endpoint).To(BeEmpty())
+		})
+
+		It("should read credentials from a web identity token, as for an IAM role for a service account", func() {
+			isolateAWSEnv()
+			sts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.ParseForm()).To(Succeed())
+				Expect(r.Form.Get("Action")).To(Equal("AssumeRoleWithWebIdentity"))
+				Expect(r.Form.Get("WebIdentityToken")).To(Equal("webidentitytoken"))
+				fmt.Fprint(w, `<AssumeRoleWithWebIdentityResponse><AssumeRoleWithWebIdentityResult><Credentials>`+
+					`<AccessKeyId>rolekeyid</AccessKeyId><SecretAccessKey>rolesecretkey</SecretAccessKey>`+
+					`<SessionToken>rolesessiontoken</SessionToken><Expiration>2100-01-01T00:00:00Z</Expiration>`+
+					`</Credentials></AssumeRoleWithWebIdentityResult></AssumeRoleWithWebIdentityResponse>`)
+			}))
+			DeferCleanup(sts.Close)
+
+			tokenFile := filepath.Join(GinkgoT().TempDir(), "token")
+			Expect(os.WriteFile(tokenFile, []byte("webidentitytoken"), 0o600)).To(Succeed())
+			GinkgoT().Setenv("AWS_WEB_IDENTITY_TOKEN_FILE", tokenFile)
+			GinkgoT().Setenv("AWS_ROLE_ARN", "arn:aws:iam::123456789012:role/preflight")
+
+			// Requests for credentials are sent to the STS server, and the rest to S3.
+			client := redirectingClient{host: strings.TrimPrefix(sts.URL, "http://"), only: "sts."}
+			w, err := NewS3Writer("s3://bucket", WithS3HTTPClient(client))
+			Expect(err).ToNot(HaveOccurred())
+
+			creds, err := w.creds.Retrieve(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(creds.AccessKeyID).To(Equal("rolekeyid"))
+			Expect(creds.SessionToken).To(Equal("rolesessiontoken"))
+		})
+	})
+
+	Context("When sending requests with the client of preflight", func() {
+		It("should add AWS_CA_BUNDLE to a copy of its certificate authorities", func() {
+			isolateAWSEnv()
+			server := httptest.NewTLSServer(http.NotFoundHandler())
+			DeferCleanup(server.Close)
+			bundle := filepath.Join(GinkgoT().TempDir(), "ca.pem")
+			Expect(os.WriteFile(bundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600)).To(Succeed())
+			GinkgoT().Setenv("AWS_CA_BUNDLE", bundle)
+			pool := x509.NewCertPool()
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}}}
+
+			_, err := NewS3Writer("s3://bucket", WithS3Credentials(creds), WithS3HTTPClient(client))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pool.Equal(x509.NewCertPool())).To(BeTrue())
+		})
+	})
+
+	Context("When writing artifacts to S3-compatible object storage", func() {
+		var (
+			server  *httptest.Server
+			mu      sync.Mutex
+			objects map[string]string
+			headers http.Header
+			status  int
+		)
+
+		BeforeEach(func() {
+			objects = map[string]string{}
+			status = http.StatusOK
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+				b, _ := io.ReadAll(r.Body)
+				objects[r.URL.EscapedPath()] = string(b)
+				headers = r.Header.Clone()
+				w.WriteHeader(status)
+			}))
+			DeferCleanup(server.Close)
+		})
+
+		It("should write the artifact under the prefix, addressing the bucket by path", func() {
+			w, err := NewS3Writer("s3://bucket/prefix", WithS3Endpoint(server.URL), WithS3Credentials(creds))
+			Expect(err).ToNot(HaveOccurred())
+
+			uri, err := w.WriteFile("component/results file.json", strings.NewReader("{}"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(uri).To(Equal("s3://bucket/prefix/component/results file.json"))
+			Expect(w.WrittenFiles()).To(Equal([]string{uri}))
+
+			mu.Lock()
+			defer mu.Unlock()
+			Expect(objects).To(HaveKeyWithValue("/bucket/prefix/component/results%20file.json", "{}"))
+			Expect(headers.Get("Authorization")).To(HavePrefix("AWS4-HMAC-SHA256 Credential=keyid/"))
+			Expect(headers.Get("X-Amz-Content-Sha256")).To(Equal("44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a"))
+		})
+
+		It("should send the session token of temporary credentials", func() {
+			w, err := NewS3Writer("s3://bucket", WithS3Endpoint(server.URL), WithS3Credentials(S3Credentials{
+				AccessKeyID:     "keyid",
+				SecretAccessKey: "secretkey",
+				SessionToken:    "sessiontoken",
+			}))
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = w.WriteFile("results.json", strings.NewReader("{}"))
+			Expect(err).ToNot(HaveOccurred())
+
+			mu.Lock()
+			defer mu.Unlock()
+			Expect(headers.Get("X-Amz-Security-Token")).To(Equal("sessiontoken"))
+			Expect(headers.Get("Authorization")).To(ContainSubstring("x-amz-security-token"))
+		})
+
+		It("should throw an error if the object cannot be written", func() {
+			status = http.StatusForbidden
+			w, err := NewS3Writer("s3://bucket/prefix", WithS3Endpoint(server.URL), WithS3Credentials(creds))
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = w.WriteFile("results.json", strings.NewReader("{}"))
+			Expect(err).To(MatchError(ContainSubstring("status code: 403")))
+			Expect(w.WrittenFiles()).To(BeEmpty())
+		})
+	})
+
+})
+
+// isolateAWSEnv unsets the AWS configuration of the environment for the spec, so that
+// the default credential chain does not find the credentials of whoever runs it.
+func isolateAWSEnv() {
+	for _, name := range []string{
+		"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_PROFILE",
+		"AWS_REGION", "AWS_DEFAULT_REGION", "AWS_ENDPOINT_URL", "AWS_ENDPOINT_URL_S3",
+		"AWS_WEB_IDENTITY_TOKEN_FILE", "AWS_ROLE_ARN", "AWS_CONTAINER_CREDENTIALS_FULL_URI",
+		"AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "AWS_CA_BUNDLE",
+	} {
+		GinkgoT().Setenv(name, "")
+	}
+	missing := filepath.Join(GinkgoT().TempDir(), "missing")
+	GinkgoT().Setenv("AWS_CONFIG_FILE", missing)
+	GinkgoT().Setenv("AWS_SHARED_CREDENTIALS_FILE", missing)
+}
+
+// redirectingClient sends the requests to hosts starting with only to host, over http.
+type redirectingClient struct {
+	host, only string
+}
+
+func (c redirectingClient) Do(req *http.Request) (*http.Response, error) {
+	if strings.HasPrefix(req.URL.Host, c.only) {
+		req.URL.Scheme, req.URL.Host = "http", c.host
+	}
+	return http.DefaultClient.Do(req)
+}
@@ -11,15 +11,112 @@ type openshiftClusterVersion = runtime.OpenshiftClusterVersion
 
 type Result struct {
 	check.Check
+	// StartTime is when the check started executing. It is zero if it is unknown, e.g.
+	// for results recorded by an older version of preflight.
+	StartTime   time.Time
 	ElapsedTime time.Duration
+	// Attempts is the number of times the check was executed. Only Retryable checks
+	// that did not pass are executed more than once; ElapsedTime includes every attempt.
+	Attempts int
+	// Waiver is the policy exception that waived the failure of the check, for
+	// the results in Waived.
+	Waiver *Waiver
+	// KnownIssues are the known issues, e.g. incidents of Red Hat's services, that
+	// may explain the failure or error of the check.
+	KnownIssues []KnownIssue
+	// Reason is why the check errored, for the results in Errors, when the check could
+	// not complete for a reason other than the asset, e.g. a lost cluster connection, and
+	// why it was skipped, for the results in Skipped.
+	Reason string
+}
+
+// KnownIssue records a known issue that may explain the failure or error of a check.
+type KnownIssue struct {
+	ID    string
+	Title string
+	URL   string
+}
+
+// Waiver records the policy exception that waived the failure of a check.
+type Waiver struct {
+	// Image is the pattern of the images the exception applies to.
+	Image         string
+	Justification string
+	Expires       time.Time
+}
+
+// Retried returns true if the check was executed more than once. For a passed
+// check, that means it failed or errored at first, which is likely a flake.
+func (r Result) Retried() bool {
+	return r.Attempts > 1
 }
 
 type Results struct {
-	TestedImage       string
+	TestedImage string
+	// ImageDigest is the manifest digest the image resolved to when it was pulled, so
+	// that the results identify the content that was checked, even if the image was
+	// referenced by a tag that has since moved.
+	ImageDigest       string
 	PassedOverall     bool
 	TestedOn          openshiftClusterVersion
 	CertificationHash string
 	Passed            []Result
 	Failed            []Result
 	Errors            []Result
+	// Waived are the checks that failed, but whose failures were waived by a
+	// policy exception, so that they do not fail the results overall.
+	Waived []Result
+	// Skipped are the checks that did not complete because the execution was
+	// interrupted, with the reason in their Reason. Results with skipped checks do not
+	// pass overall.
+	Skipped []Result
+	// SkippedLayers are the layers of the image whose contents could not be
+	// extracted, and were therefore not checked.
+	SkippedLayers []SkippedLayer
+	// DeniedConnections are the connections that checks attempted, but that the egress
+	// policy did not allow them to make.
+	DeniedConnections []DeniedConnection
+}
+
+// SkippedLayer describes a layer that was skipped, and why.
+type SkippedLayer struct {
+	Digest    string
+	MediaType string
+	Reason    string
+}
+
+// DeniedConnection describes a connection that a check was denied.
+type DeniedConnection struct {
+	Check   string
+	Address string
+}
+
+// Status is the outcome of a single check's execution.
+type Status string
+
+const (
+	StatusPassed  Status = "PASSED"
+	StatusFailed  Status = "FAILED"
+	StatusErrored Status = "ERROR"
+	StatusWaived  Status = "WAIVED"
+	StatusSkipped Status = "SKIPPED"
+)
+
+// OverallStatus returns StatusPassed if passedOverall is true, and StatusFailed
+// otherwise.
+func OverallStatus(passedOverall bool) Status {
+	if passedOverall {
+		return StatusPassed
+	}
+
+	return StatusFailed
+}
+
+// StepResult is the result of a single check, made available as soon as
+// that check has completed.
+type StepResult struct {
+	Result
+	Status Status
+	// Err is the error returned by the check, if Status is StatusErrored.
+	Err error
 }
@@ -0,0 +1,74 @@
+package certification
+
+import "context"
+
+// contextKey is a key used to store/retrieve values in/from context.Context.
+type contextKey string
+
+const stepResultsContextKey contextKey = "StepResults"
+
+// ContextWithStepResults adds ch to the context ctx. Check executions using the
+// returned context send each check's StepResult to ch as soon as the check has
+// completed. Sends are abandoned if ctx is cancelled.
+func ContextWithStepResults(ctx context.Context, ch chan<- StepResult) context.Context {
+	return context.WithValue(ctx, stepResultsContextKey, ch)
+}
+
+// StepResultsFromContext returns the step results channel from the context, or nil.
+func StepResultsFromContext(ctx context.Context) chan<- StepResult {
+	if ch, ok := ctx.Value(stepResultsContextKey).(chan<- StepResult); ok {
+		return ch
+	}
+
+	return nil
+}
+
+// ResultStream yields the results of a check execution one check at a time, while
+// the execution is still in progress.
+type ResultStream struct {
+	steps   chan StepResult
+	done    chan struct{}
+	results Results
+	err     error
+}
+
+// NewResultStream calls run in a new goroutine, and returns a ResultStream that yields
+// each check's result as run produces them. The run func will typically be the Run
+// method of a container or operator check.
+func NewResultStream(ctx context.Context, run func(context.Context) (Results, error)) *ResultStream {
+	s := &ResultStream{
+		steps: make(chan StepResult),
+		done:  make(chan struct{}),
+	}
+
+	go func() {
+		defer close(s.done)
+		defer close(s.steps)
+		s.results, s.err = run(ContextWithStepResults(ctx, s.steps))
+	}()
+
+	return s
+}
+
+// Next blocks until the next check has completed and returns its result. The boolean
+// is false once all checks have completed, at which point Results may be called.
+func (s *ResultStream) Next() (StepResult, bool) {
+	r, ok := <-s.steps
+	return r, ok
+}
+
+// Steps returns a channel yielding each check's result. The channel is closed once
+// all checks have completed. Callers should use either Steps or Next, but not both.
+func (s *ResultStream) Steps() <-chan StepResult {
+	return s.steps
+}
+
+// Results blocks until the execution has completed, and returns the final Results
+// and any error encountered. Step results that have not been consumed are discarded.
+func (s *ResultStream) Results() (Results, error) {
+	for range s.steps {
+		// discard step results the caller did not consume.
+	}
+	<-s.done
+	return s.results, s.err
+}
@@ -0,0 +1,69 @@
+// Package clock provides the source of time used when recording timestamps
+// and durations in preflight results, so that it can be replaced when
+// reproducible output is required.
+package clock
+
+import (
+	"context"
+	"time"
+)
+
+// Clock tells the time.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// Since returns the time elapsed since t.
+	Since(t time.Time) time.Duration
+}
+
+// contextKey is a key used to store/retrieve a Clock in/from context.Context.
+type contextKey string
+
+const clockContextKey contextKey = "Clock"
+
+// ContextWithClock adds Clock c to the context ctx.
+func ContextWithClock(ctx context.Context, c Clock) context.Context {
+	return context.WithValue(ctx, clockContextKey, c)
+}
+
+// FromContext returns the Clock from the context, or the real clock
+// if one has not been added.
+func FromContext(ctx context.Context) Clock {
+	if c, ok := ctx.Value(clockContextKey).(Clock); ok {
+		return c
+	}
+
+	return Real()
+}
+
+// Real returns a Clock backed by the system time.
+func Real() Clock {
+	return realClock{}
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) Since(t time.Time) time.Duration {
+	return time.Since(t)
+}
+
+// Deterministic returns a Clock that is always at the zero time, and for
+// which no time ever elapses. Timestamps and durations recorded with it
+// are zeroed.
+func Deterministic() Clock {
+	return deterministicClock{}
+}
+
+type deterministicClock struct{}
+
+func (deterministicClock) Now() time.Time {
+	return time.Time{}
+}
+
+func (deterministicClock) Since(time.Time) time.Duration {
+	return 0
+}
@@ -0,0 +1,29 @@
+package clock
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Clock", func() {
+	When("no clock is in the context", func() {
+		It("should return the real clock", func() {
+			c := FromContext(context.Background())
+			Expect(c).To(Equal(Real()))
+			Expect(c.Now()).To(BeTemporally("~", time.Now(), time.Second))
+		})
+	})
+
+	When("the deterministic clock is in the context", func() {
+		It("should always return the zero time and no elapsed time", func() {
+			c := FromContext(ContextWithClock(context.Background(), Deterministic()))
+			start := c.Now()
+			Expect(start.IsZero()).To(BeTrue())
+			Expect(c.Since(start)).To(BeZero())
+			Expect(c.Since(time.Now().Add(-time.Hour))).To(BeZero())
+		})
+	})
+})
@@ -0,0 +1,187 @@
+// Package cluster provides the clusters that operators are checked on, so that new
+// kinds of clusters, e.g. ephemeral clusters created for each check, can be used
+// without changing the checks.
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/interrupt"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/transport"
+
+	"github.com/go-logr/logr"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// Cluster is a cluster provided to check an operator on.
+type Cluster struct {
+	// Kubeconfig is the kubeconfig to access the cluster with.
+	Kubeconfig []byte
+	// Release, if not nil, is called once the operator is checked, e.g. to delete a
+	// cluster created for the check.
+	Release func(ctx context.Context) error
+}
+
+// ReleaseTimeout is how long releasing a cluster may take.
+const ReleaseTimeout = 5 * time.Minute
+
+// ReleaseContext returns a context with the values of ctx to release a cluster with, and
+// a function that cancels it. It is not cancelled with ctx, e.g. once preflight is
+// interrupted, so that the cluster is not left running, but it times out after
+// ReleaseTimeout.
+func ReleaseContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(interrupt.Detach(ctx), ReleaseTimeout)
+}
+
+// Provider provides the cluster to check an operator on.
+type Provider interface {
+	// Provide returns the cluster to check an operator on.
+	Provide(ctx context.Context) (Cluster, error)
+}
+
+// Func is a function that implements Provider.
+type Func func(ctx context.Context) (Cluster, error)
+
+// Provide calls f.
+func (f Func) Provide(ctx context.Context) (Cluster, error) {
+	return f(ctx)
+}
+
+// Kubeconfig returns a Provider of the cluster accessed with kubeconfig.
+func Kubeconfig(kubeconfig []byte) Provider {
+	return Func(func(ctx context.Context) (Cluster, error) {
+		return Cluster{Kubeconfig: kubeconfig}, nil
+	})
+}
+
+// KubeconfigFile returns a Provider of the cluster accessed with the kubeconfig file
+// at path.
+func KubeconfigFile(path string) Provider {
+	return Func(func(ctx context.Context) (Cluster, error) {
+		kubeconfig, err := os.ReadFile(path)
+		if err != nil {
+			return Cluster{}, fmt.Errorf("unable to read provided kubeconfig file's contents: %w", err)
+		}
+		return Cluster{Kubeconfig: kubeconfig}, nil
+	})
+}
+
+// inClusterName is the name of the cluster, user, and context of the kubeconfig of
+// the InCluster Provider.
+const inClusterName = "in-cluster"
+
+// InCluster returns a Provider of the cluster preflight runs in, accessed with the
+// service account of its pod.
+func InCluster() Provider {
+	return Func(func(ctx context.Context) (Cluster, error) {
+		restconfig, err := rest.InClusterConfig()
+		if err != nil {
+			return Cluster{}, fmt.Errorf("unable to configure access to the cluster preflight runs in: %w", err)
+		}
+
+		// The token is read from its file, so that a token that is rotated while
+		// checks are executed keeps working.
+		config := clientcmdapi.NewConfig()
+		config.Clusters[inClusterName] = &clientcmdapi.Cluster{
+			Server:               restconfig.Host,
+			CertificateAuthority: restconfig.TLSClientConfig.CAFile,
+		}
+		config.AuthInfos[inClusterName] = &clientcmdapi.AuthInfo{TokenFile: restconfig.BearerTokenFile}
+		config.Contexts[inClusterName] = &clientcmdapi.Context{Cluster: inClusterName, AuthInfo: inClusterName}
+		config.CurrentContext = inClusterName
+
+		kubeconfig, err := clientcmd.Write(*config)
+		if err != nil {
+			return Cluster{}, fmt.Errorf("unable to write the kubeconfig of the cluster preflight runs in: %w", err)
+		}
+		return Cluster{Kubeconfig: kubeconfig}, nil
+	})
+}
+
+// hostedCluster is a cluster leased from a hosted test service.
+type hostedCluster struct {
+	// Name identifies the cluster to release it.
+	Name string `json:"name"`
+	// Kubeconfig is the kubeconfig to access the cluster with.
+	Kubeconfig string `json:"kubeconfig"`
+}
+
+// Hosted returns a Provider of clusters leased from the hosted test service at
+// serviceURL. A cluster is leased with a POST to serviceURL, which responds, once the
+// cluster is ready, with a JSON object with the name and kubeconfig of the cluster,
+// e.g. {"name": "ci-4f2a", "kubeconfig": "apiVersion: v1\n..."}. The cluster is
+// released with a DELETE to serviceURL/name. If token is not empty, it is sent as a
+// bearer token.
+func Hosted(serviceURL, token string) Provider {
+	return Func(func(ctx context.Context) (Cluster, error) {
+		client := &http.Client{Transport: transport.Transport(ctx, http.DefaultTransport.(*http.Transport))}
+
+		var leased hostedCluster
+		if err := hostedRequest(ctx, client, http.MethodPost, serviceURL, token, &leased); err != nil {
+			return Cluster{}, fmt.Errorf("could not lease a cluster from %s: %w", serviceURL, err)
+		}
+		if leased.Name == "" || leased.Kubeconfig == "" {
+			return Cluster{}, fmt.Errorf("could not lease a cluster from %s: the response has no name or kubeconfig", serviceURL)
+		}
+		logr.FromContextOrDiscard(ctx).Info("leased cluster", "cluster", leased.Name, "service", serviceURL)
+
+		return Cluster{
+			Kubeconfig: []byte(leased.Kubeconfig),
+			Release: func(ctx context.Context) error {
+				clusterURL := strings.TrimSuffix(serviceURL, "/") + "/" + url.PathEscape(leased.Name)
+				if err := hostedRequest(ctx, client, http.MethodDelete, clusterURL, token, nil); err != nil {
+					return fmt.Errorf("could not release cluster %s: %w", leased.Name, err)
+				}
+				return nil
+			},
+		}, nil
+	})
+}
+
+// hostedRequest sends a request to a hosted test service, and decodes the JSON it
+// responds with into v, if v is not nil.
+func hostedRequest(ctx context.Context, client *http.Client, method, target, token string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		if message := strings.TrimSpace(string(body)); message != "" {
+			return fmt.Errorf("status code %d: %s", resp.StatusCode, message)
+		}
+		return fmt.Errorf("status code %d", resp.StatusCode)
+	}
+	if v == nil {
+		return nil
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
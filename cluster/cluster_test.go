@@ -0,0 +1,88 @@
+package cluster
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cluster providers", func() {
+	const kubeconfig = "apiVersion: v1\nkind: Config\n"
+
+	It("should provide the cluster of a kubeconfig", func() {
+		provided, err := Kubeconfig([]byte(kubeconfig)).Provide(context.TODO())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(provided.Kubeconfig).To(Equal([]byte(kubeconfig)))
+		Expect(provided.Release).To(BeNil())
+	})
+
+	Context("with a kubeconfig file", func() {
+		It("should provide the cluster of the kubeconfig file", func() {
+			path := filepath.Join(GinkgoT().TempDir(), "kubeconfig")
+			Expect(os.WriteFile(path, []byte(kubeconfig), 0o600)).To(Succeed())
+			provided, err := KubeconfigFile(path).Provide(context.TODO())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(provided.Kubeconfig).To(Equal([]byte(kubeconfig)))
+		})
+		It("should throw an error if the file does not exist", func() {
+			_, err := KubeconfigFile(filepath.Join(GinkgoT().TempDir(), "kubeconfig")).Provide(context.TODO())
+			Expect(err).To(MatchError(ContainSubstring("no such file or directory")))
+		})
+	})
+
+	It("should throw an error in cluster if preflight does not run in a pod", func() {
+		GinkgoT().Setenv("KUBERNETES_SERVICE_HOST", "")
+		_, err := InCluster().Provide(context.TODO())
+		Expect(err).To(MatchError(ContainSubstring("unable to configure access to the cluster preflight runs in")))
+	})
+
+	Context("with a hosted test service", func() {
+		var (
+			server   *httptest.Server
+			released []string
+		)
+		BeforeEach(func() {
+			released = nil
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "Bearer leasetoken" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				switch r.Method {
+				case http.MethodPost:
+					w.WriteHeader(http.StatusCreated)
+					_, _ = w.Write([]byte(`{"name": "ci-4f2a", "kubeconfig": "apiVersion: v1\nkind: Config\n"}`))
+				case http.MethodDelete:
+					released = append(released, r.URL.Path)
+				}
+			}))
+			DeferCleanup(server.Close)
+		})
+		It("should lease a cluster, and release it", func() {
+			provided, err := Hosted(server.URL+"/leases/", "leasetoken").Provide(context.TODO())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(provided.Kubeconfig).To(Equal([]byte(kubeconfig)))
+			Expect(released).To(BeEmpty())
+
+			Expect(provided.Release(context.TODO())).To(Succeed())
+			Expect(released).To(ConsistOf("/leases/ci-4f2a"))
+		})
+		It("should throw an error if the service rejects the request", func() {
+			_, err := Hosted(server.URL+"/leases", "wrongtoken").Provide(context.TODO())
+			Expect(err).To(MatchError(ContainSubstring("could not lease a cluster")))
+			Expect(err).To(MatchError(ContainSubstring("status code 401")))
+		})
+		It("should throw an error if the service responds without a kubeconfig", func() {
+			server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"name": "ci-4f2a"}`))
+			})
+			_, err := Hosted(server.URL+"/leases", "").Provide(context.TODO())
+			Expect(err).To(MatchError(ContainSubstring("the response has no name or kubeconfig")))
+		})
+	})
+})
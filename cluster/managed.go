@@ -0,0 +1,230 @@
+package cluster
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/log"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/ocm"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/transport"
+
+	"github.com/go-logr/logr"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// Products of managed OpenShift clusters.
+const (
+	ProductROSA = ocm.ProductROSA
+	ProductOSD  = ocm.ProductOSD
+)
+
+const (
+	// DefaultManagedLifetime is how long after it is provisioned that OCM deletes a
+	// managed cluster, if it was not released before, e.g. because preflight was killed.
+	DefaultManagedLifetime = 4 * time.Hour
+	// DefaultManagedProvisionTimeout is how long to wait for a managed cluster to be
+	// provisioned.
+	DefaultManagedProvisionTimeout = 90 * time.Minute
+)
+
+// managedAdmin is the name of the cluster admin of managed clusters.
+const managedAdmin = "preflight-admin"
+
+// ManagedOptions configure the managed OpenShift clusters provisioned by ManagedOpenShift.
+type ManagedOptions struct {
+	// OfflineToken is the OCM offline token of the account clusters are provisioned
+	// with, from https://console.redhat.com/openshift/token.
+	OfflineToken string
+	// Product is ProductROSA or ProductOSD. Defaults to ProductOSD.
+	Product string
+	// Region is the AWS region clusters are provisioned in.
+	Region string
+	// Version is the OpenShift version of clusters, e.g. 4.14.8. Defaults to the
+	// default version of OCM.
+	Version string
+	// AWSAccountID, AWSAccessKeyID, and AWSSecretAccessKey are the AWS account that
+	// clusters are provisioned in, as customer cloud subscription clusters. They are
+	// required for ROSA.
+	AWSAccountID       string
+	AWSAccessKeyID     string
+	AWSSecretAccessKey string
+	// Lifetime is how long after it is provisioned that OCM deletes a cluster that
+	// was not released. Defaults to DefaultManagedLifetime.
+	Lifetime time.Duration
+	// ProvisionTimeout is how long to wait for a cluster to be provisioned. Defaults
+	// to DefaultManagedProvisionTimeout.
+	ProvisionTimeout time.Duration
+	// OCMURL is the URL of the OCM API. Defaults to https://api.openshift.com.
+	OCMURL string
+}
+
+// managedProvider provisions a managed OpenShift cluster for each check.
+type managedProvider struct {
+	opts         ManagedOptions
+	pollInterval time.Duration
+	tokenURL     string
+}
+
+// ManagedOpenShift returns a Provider of short-lived managed OpenShift clusters, ROSA or
+// OSD, provisioned with OCM for each check, and deprovisioned once it is released.
+// Provisioning a cluster takes about 40 minutes. Clusters are accessed as a cluster
+// admin added with an htpasswd identity provider.
+func ManagedOpenShift(opts ManagedOptions) Provider {
+	if opts.Product == "" {
+		opts.Product = ProductOSD
+	}
+	if opts.Lifetime == 0 {
+		opts.Lifetime = DefaultManagedLifetime
+	}
+	if opts.ProvisionTimeout == 0 {
+		opts.ProvisionTimeout = DefaultManagedProvisionTimeout
+	}
+	if opts.OCMURL == "" {
+		opts.OCMURL = ocm.DefaultURL
+	}
+
+	return &managedProvider{opts: opts, pollInterval: 30 * time.Second, tokenURL: ocm.DefaultTokenURL}
+}
+
+// Provide provisions a cluster, and waits until it can be accessed.
+func (p *managedProvider) Provide(ctx context.Context) (Cluster, error) {
+	logger := logr.FromContextOrDiscard(ctx)
+	if p.opts.OfflineToken == "" {
+		return Cluster{}, fmt.Errorf("an OCM offline token is required to provision a managed cluster")
+	}
+
+	httpClient := &http.Client{Transport: transport.Transport(ctx, http.DefaultTransport.(*http.Transport))}
+	client := ocm.NewClient(p.opts.OfflineToken, httpClient, ocm.WithURL(p.opts.OCMURL), ocm.WithTokenURL(p.tokenURL))
+
+	suffix, err := randomHex(4)
+	if err != nil {
+		return Cluster{}, err
+	}
+	spec := ocm.ClusterSpec{
+		// Cluster names are at most 15 characters.
+		Name:       "pflt-" + suffix,
+		Product:    p.opts.Product,
+		Region:     p.opts.Region,
+		Version:    p.opts.Version,
+		Expiration: time.Now().Add(p.opts.Lifetime),
+	}
+	if p.opts.AWSAccountID != "" {
+		spec.AWS = &ocm.AWSAccount{AccountID: p.opts.AWSAccountID, AccessKeyID: p.opts.AWSAccessKeyID, SecretAccessKey: p.opts.AWSSecretAccessKey}
+	}
+
+	created, err := client.CreateCluster(ctx, spec)
+	if err != nil {
+		return Cluster{}, err
+	}
+	logger.Info("provisioning managed cluster", "cluster", created.Name, "id", created.ID, "product", spec.Product)
+
+	release := func(ctx context.Context) error {
+		logger.Info("deprovisioning managed cluster", "cluster", created.Name, "id", created.ID)
+		return client.DeleteCluster(ctx, created.ID)
+	}
+
+	kubeconfig, err := p.access(ctx, client, httpClient, created.ID)
+	if err != nil {
+		// The cluster is deprovisioned even if provisioning it was stopped, e.g. because
+		// preflight was interrupted.
+		releaseCtx, cancel := ReleaseContext(ctx)
+		defer cancel()
+		if releaseErr := release(releaseCtx); releaseErr != nil {
+			logger.Error(releaseErr, "could not deprovision managed cluster", "cluster", created.Name)
+		}
+		return Cluster{}, fmt.Errorf("could not provision managed cluster %s: %w", created.Name, err)
+	}
+
+	return Cluster{Kubeconfig: kubeconfig, Release: release}, nil
+}
+
+// access waits for the cluster with id to be ready, adds a cluster admin to it, and
+// returns a kubeconfig logged in as the admin.
+func (p *managedProvider) access(ctx context.Context, client *ocm.Client, httpClient *http.Client, id string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.opts.ProvisionTimeout)
+	defer cancel()
+
+	var ready ocm.Cluster
+	err := p.poll(ctx, func() (bool, error) {
+		var err error
+		if ready, err = client.GetCluster(ctx, id); err != nil {
+			return false, err
+		}
+		if ready.State == ocm.StateError {
+			return false, fmt.Errorf("the cluster is in the %s state", ready.State)
+		}
+		return ready.State == ocm.StateReady, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	password, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	// Passwords must have upper case letters, and digits or symbols.
+	password = "Pf-" + password
+	if err := client.AddClusterAdmin(ctx, id, managedAdmin, password); err != nil {
+		return nil, err
+	}
+
+	var token string
+	err = p.poll(ctx, func() (bool, error) {
+		var loginErr error
+		token, loginErr = ocm.Login(ctx, httpClient, ready.API.URL, managedAdmin, password)
+		if loginErr != nil {
+			// The identity provider takes a few minutes to be rolled out.
+			logr.FromContextOrDiscard(ctx).V(log.DBG).Info("waiting to log in to managed cluster", "reason", loginErr.Error())
+		}
+		return loginErr == nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	config := clientcmdapi.NewConfig()
+	config.Clusters[ready.Name] = &clientcmdapi.Cluster{Server: ready.API.URL}
+	config.AuthInfos[managedAdmin] = &clientcmdapi.AuthInfo{Token: token}
+	config.Contexts[ready.Name] = &clientcmdapi.Context{Cluster: ready.Name, AuthInfo: managedAdmin}
+	config.CurrentContext = ready.Name
+
+	return clientcmd.Write(*config)
+}
+
+// poll calls done every pollInterval until it returns true or an error, or ctx is done.
+func (p *managedProvider) poll(ctx context.Context, done func() (bool, error)) error {
+	ticker := time.NewTicker(p.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		ok, err := done()
+		if ctx.Err() != nil {
+			return fmt.Errorf("timed out waiting for the cluster: %w", ctx.Err())
+		}
+		if err != nil || ok {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for the cluster: %w", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// randomHex returns n random bytes, hex encoded.
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("could not generate random bytes: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
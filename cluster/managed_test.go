@@ -0,0 +1,123 @@
+package cluster
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+var _ = Describe("Managed OpenShift clusters", func() {
+	var (
+		server   *httptest.Server
+		state    string
+		polls    int
+		logins   int
+		requests []string
+		polled   func()
+		provider *managedProvider
+	)
+
+	BeforeEach(func() {
+		state = "ready"
+		polls = 0
+		logins = 0
+		requests = nil
+		polled = func() {}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"access_token": "accesstoken", "expires_in": 900}`))
+		})
+		mux.HandleFunc("/api/clusters_mgmt/v1/", func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r.Method+" "+r.URL.Path)
+			switch {
+			case r.Method == http.MethodPost && r.URL.Path == "/api/clusters_mgmt/v1/clusters":
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte(`{"id": "abc123", "name": "pflt-1234", "state": "pending"}`))
+			case r.Method == http.MethodGet:
+				polls++
+				polled()
+				current := "installing"
+				if polls > 1 {
+					current = state
+				}
+				_, _ = w.Write([]byte(`{"id": "abc123", "name": "pflt-1234", "state": "` + current + `", "api": {"url": "` + server.URL + `"}}`))
+			default:
+				w.WriteHeader(http.StatusNoContent)
+			}
+		})
+		mux.HandleFunc("/.well-known/oauth-authorization-server", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"authorization_endpoint": "` + server.URL + `/oauth/authorize"}`))
+		})
+		mux.HandleFunc("/oauth/authorize", func(w http.ResponseWriter, r *http.Request) {
+			// The identity provider is not rolled out on the first attempt.
+			logins++
+			if logins == 1 {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			http.Redirect(w, r, server.URL+"/oauth/token/implicit#access_token=sha256~token", http.StatusFound)
+		})
+		server = httptest.NewServer(mux)
+		DeferCleanup(server.Close)
+
+		provider = ManagedOpenShift(ManagedOptions{
+			OfflineToken: "offlinetoken",
+			Region:       "us-east-1",
+			OCMURL:       server.URL,
+		}).(*managedProvider)
+		provider.tokenURL = server.URL + "/token"
+		provider.pollInterval = time.Millisecond
+	})
+
+	It("should provision a cluster, log in as a cluster admin, and deprovision it when released", func() {
+		provided, err := provider.Provide(context.TODO())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(polls).To(Equal(2))
+		Expect(logins).To(Equal(2))
+
+		config, err := clientcmd.Load(provided.Kubeconfig)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(config.Clusters[config.Contexts[config.CurrentContext].Cluster].Server).To(Equal(server.URL))
+		Expect(config.AuthInfos[config.Contexts[config.CurrentContext].AuthInfo].Token).To(Equal("sha256~token"))
+		Expect(requests).ToNot(ContainElement(HavePrefix("DELETE")))
+
+		Expect(provided.Release(context.TODO())).To(Succeed())
+		Expect(requests).To(ContainElement("DELETE /api/clusters_mgmt/v1/clusters/abc123"))
+	})
+
+	It("should deprovision a cluster that fails to be provisioned", func() {
+		state = "error"
+		_, err := provider.Provide(context.TODO())
+		Expect(err).To(MatchError(ContainSubstring("could not provision managed cluster pflt-1234: the cluster is in the error state")))
+		Expect(requests).To(ContainElement("DELETE /api/clusters_mgmt/v1/clusters/abc123"))
+	})
+
+	It("should deprovision a cluster that is not provisioned in time", func() {
+		state = "installing"
+		provider.opts.ProvisionTimeout = 50 * time.Millisecond
+		_, err := provider.Provide(context.TODO())
+		Expect(err).To(MatchError(ContainSubstring("timed out waiting for the cluster")))
+		Expect(requests).To(ContainElement("DELETE /api/clusters_mgmt/v1/clusters/abc123"))
+	})
+
+	It("should deprovision a cluster whose provisioning is interrupted", func() {
+		state = "installing"
+		ctx, cancel := context.WithCancel(context.TODO())
+		defer cancel()
+		polled = cancel
+		_, err := provider.Provide(ctx)
+		Expect(err).To(MatchError(context.Canceled))
+		Expect(requests).To(ContainElement("DELETE /api/clusters_mgmt/v1/clusters/abc123"))
+	})
+
+	It("should throw an error without an offline token", func() {
+		provider.opts.OfflineToken = ""
+		_, err := provider.Provide(context.TODO())
+		Expect(err).To(MatchError(ContainSubstring("an OCM offline token is required")))
+	})
+})
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// argsFilePrefix marks an argument as a response file whose lines are arguments, e.g.
+// @args.txt, or @- to read them from stdin.
+const argsFilePrefix = "@"
+
+// argsFromStdin is the response file read from stdin.
+const argsFromStdin = argsFilePrefix + "-"
+
+// expandArgs returns args, with the arguments read from each response file in place of
+// the argument naming it, so that flags that are too long, or too sensitive, to pass on
+// the command line can be passed in a file, or on stdin. An argument starting with @@ is
+// passed with one @ removed, rather than read, and arguments after -- are never read.
+// Only standalone arguments are read: the value of a flag of cmd, or of its subcommands,
+// e.g. the token in --pyxis-api-token @abc, is passed as it is.
+func expandArgs(cmd *cobra.Command, args []string, stdin io.Reader) ([]string, error) {
+	takesValue := flagsTakingValues(cmd)
+	expanded := make([]string, 0, len(args))
+	readStdin := false
+	for i, arg := range args {
+		switch {
+		case len(expanded) > 0 && takesValue(expanded[len(expanded)-1]):
+			expanded = append(expanded, arg)
+		case arg == "--":
+			return append(expanded, args[i:]...), nil
+		case strings.HasPrefix(arg, argsFilePrefix+argsFilePrefix):
+			expanded = append(expanded, strings.TrimPrefix(arg, argsFilePrefix))
+		case arg == argsFromStdin:
+			if readStdin {
+				return nil, fmt.Errorf("arguments can only be read from stdin once")
+			}
+			readStdin = true
+
+			fileArgs, err := readArgs(stdin)
+			if err != nil {
+				return nil, fmt.Errorf("could not read arguments from stdin: %w", err)
+			}
+			expanded = append(expanded, fileArgs...)
+		case strings.HasPrefix(arg, argsFilePrefix) && len(arg) > len(argsFilePrefix):
+			path := strings.TrimPrefix(arg, argsFilePrefix)
+			f, err := os.Open(path)
+			if err != nil {
+				return nil, fmt.Errorf("could not read arguments from %s: %w", path, err)
+			}
+			fileArgs, err := readArgs(f)
+			f.Close()
+			if err != nil {
+				return nil, fmt.Errorf("could not read arguments from %s: %w", path, err)
+			}
+			expanded = append(expanded, fileArgs...)
+		default:
+			expanded = append(expanded, arg)
+		}
+	}
+
+	return expanded, nil
+}
+
+// flagsTakingValues returns a function that returns true if arg is a flag of cmd, or of
+// any of its subcommands, whose value is the next argument, e.g. --pyxis-api-token or -o,
+// but not --submit, --pyxis-api-token=abc, or -oabc.
+func flagsTakingValues(cmd *cobra.Command) func(arg string) bool {
+	long := map[string]bool{}
+	short := map[string]bool{}
+	var visit func(c *cobra.Command)
+	visit = func(c *cobra.Command) {
+		add := func(f *pflag.Flag) {
+			takesValue := f.NoOptDefVal == ""
+			long[f.Name] = long[f.Name] || takesValue
+			if f.Shorthand != "" {
+				short[f.Shorthand] = short[f.Shorthand] || takesValue
+			}
+		}
+		c.LocalFlags().VisitAll(add)
+		c.PersistentFlags().VisitAll(add)
+		for _, sub := range c.Commands() {
+			visit(sub)
+		}
+	}
+	visit(cmd)
+
+	return func(arg string) bool {
+		switch {
+		case arg == "--" || strings.Contains(arg, "="):
+			return false
+		case strings.HasPrefix(arg, "--"):
+			return long[strings.TrimPrefix(arg, "--")]
+		case strings.HasPrefix(arg, "-") && len(arg) == 2:
+			return short[arg[1:]]
+		default:
+			return false
+		}
+	}
+}
+
+// readArgs returns the arguments in r, one per line. Surrounding whitespace is trimmed,
+// and blank lines and lines starting with # are ignored, so that the arguments are not
+// split on spaces, e.g. those of a password.
+func readArgs(r io.Reader) ([]string, error) {
+	var args []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		args = append(args, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return args, nil
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Reading arguments from response files", func() {
+	var argsFile string
+	BeforeEach(func() {
+		argsFile = filepath.Join(GinkgoT().TempDir(), "args.txt")
+		contents := "# credentials\n--pyxis-api-token=a token with spaces\n\n  --certification-project-id\n  1234  \n"
+		Expect(os.WriteFile(argsFile, []byte(contents), 0o600)).To(Succeed())
+	})
+
+	It("should read the arguments of a file, one per line, in its place", func() {
+		args, err := expandArgs(rootCmd(), []string{"check", "container", "@" + argsFile, "quay.io/example/image:latest"}, strings.NewReader(""))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(args).To(Equal([]string{
+			"check", "container",
+			"--pyxis-api-token=a token with spaces", "--certification-project-id", "1234",
+			"quay.io/example/image:latest",
+		}))
+	})
+
+	It("should read the arguments from stdin", func() {
+		args, err := expandArgs(rootCmd(), []string{"check", "container", "@-"}, strings.NewReader("--submit\r\nquay.io/example/image:latest\n"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(args).To(Equal([]string{"check", "container", "--submit", "quay.io/example/image:latest"}))
+	})
+
+	It("should only read stdin once", func() {
+		_, err := expandArgs(rootCmd(), []string{"@-", "@-"}, strings.NewReader(""))
+		Expect(err).To(MatchError(ContainSubstring("only be read from stdin once")))
+	})
+
+	It("should not read escaped arguments, or arguments after --", func() {
+		args, err := expandArgs(rootCmd(), []string{"@@literal", "--", "@" + argsFile}, strings.NewReader(""))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(args).To(Equal([]string{"@literal", "--", "@" + argsFile}))
+	})
+
+	It("should not read the values of flags that start with @", func() {
+		args, err := expandArgs(rootCmd(), []string{"check", "container", "--pyxis-api-token", "@abc", "-d", "@@config.json", "@" + argsFile}, strings.NewReader(""))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(args).To(Equal([]string{
+			"check", "container", "--pyxis-api-token", "@abc", "-d", "@@config.json",
+			"--pyxis-api-token=a token with spaces", "--certification-project-id", "1234",
+		}))
+	})
+
+	It("should read a file following a flag that takes no value", func() {
+		args, err := expandArgs(rootCmd(), []string{"check", "container", "--submit", "@" + argsFile}, strings.NewReader(""))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(args).To(ContainElement("--certification-project-id"))
+	})
+
+	It("should fail if a file cannot be read", func() {
+		_, err := expandArgs(rootCmd(), []string{"@does-not-exist.txt"}, strings.NewReader(""))
+		Expect(err).To(MatchError(os.ErrNotExist))
+	})
+})
@@ -0,0 +1,18 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// bundleCmd contains subcommands that work with operator bundles.
+func bundleCmd() *cobra.Command {
+	bundleCmd := &cobra.Command{
+		Use:   "bundle",
+		Short: "Work with operator bundles",
+		Long:  "This command contains subcommands that operate on operator bundles, without checking them.",
+	}
+
+	bundleCmd.AddCommand(bundleDiffCmd())
+
+	return bundleCmd
+}
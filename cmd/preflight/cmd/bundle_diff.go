@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/authn"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/bundlediff"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/proxy"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/runtime"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/transport"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/viper"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/spf13/cobra"
+)
+
+const (
+	bundleDiffFormatText = "text"
+	bundleDiffFormatJSON = "json"
+)
+
+func bundleDiffCmd() *cobra.Command {
+	diffCmd := &cobra.Command{
+		Use:   "diff <old> <new>",
+		Short: "Compare two versions of an operator bundle",
+		Long: "Compare the fields of the ClusterServiceVersions, the RBAC permissions, and the image references of two versions of an operator bundle, " +
+			"so that reviewers can verify that an update changes no more than it should. Each bundle is a bundle image, which is compared by its digest, " +
+			"or a directory containing the manifests directory of a bundle.",
+		Args: cobra.ExactArgs(2),
+		// this fmt.Sprintf is in place to keep spacing consistent with cobras two spaces that's used in: Usage, Flags, etc
+		Example: fmt.Sprintf("  %s", "preflight bundle diff quay.io/example/my-operator-bundle:v1.0.0 quay.io/example/my-operator-bundle:v1.1.0"),
+		RunE:    bundleDiffRunE,
+	}
+
+	flags := diffCmd.Flags()
+	flags.String("format", bundleDiffFormatText, fmt.Sprintf("The format of the report, %s or %s.", bundleDiffFormatText, bundleDiffFormatJSON))
+	flags.String("docker-config", "", "Path to docker config.json file used to pull the bundle images. (env: PFLT_DOCKERCONFIG)")
+
+	return diffCmd
+}
+
+func bundleDiffRunE(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	format, _ := cmd.Flags().GetString("format")
+	if format != bundleDiffFormatText && format != bundleDiffFormatJSON {
+		return fmt.Errorf("invalid format %s, expected %s or %s", format, bundleDiffFormatText, bundleDiffFormatJSON)
+	}
+
+	cfg, err := runtime.NewConfigFrom(*viper.Instance())
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	proxyConfig := proxy.Config{URL: cfg.Proxy, NoProxy: cfg.NoProxy}
+	if err := proxyConfig.Validate(); err != nil {
+		return err
+	}
+	ctx = proxy.ContextWithConfig(ctx, proxyConfig)
+	if cfg.CABundle != "" {
+		rootCAs, err := transport.LoadRootCAs(cfg.CABundle)
+		if err != nil {
+			return err
+		}
+		ctx = transport.ContextWithRootCAs(ctx, rootCAs)
+	}
+
+	cmd.SilenceUsage = true
+
+	dockerConfig := flagOrConfig(cmd, "docker-config", "dockerConfig")
+	old, err := loadBundle(ctx, args[0], dockerConfig)
+	if err != nil {
+		return err
+	}
+	new, err := loadBundle(ctx, args[1], dockerConfig)
+	if err != nil {
+		return err
+	}
+
+	report := bundlediff.Diff(old, new)
+	if format == bundleDiffFormatText {
+		return bundlediff.WriteText(cmd.OutOrStdout(), report)
+	}
+
+	b, err := json.MarshalIndent(report, "", "    ")
+	if err != nil {
+		return fmt.Errorf("could not format bundle diff: %w", err)
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), string(b))
+
+	return nil
+}
+
+// loadBundle loads the bundle in the directory bundle, or pulls the bundle image bundle.
+func loadBundle(ctx context.Context, bundle, dockerConfig string) (*bundlediff.Bundle, error) {
+	if info, err := os.Stat(bundle); err == nil && info.IsDir() {
+		return bundlediff.Load(bundle)
+	}
+
+	dir, err := os.MkdirTemp("", "preflight-bundle-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	return bundlediff.Pull(ctx, bundle, dir,
+		crane.WithAuthFromKeychain(authn.PreflightKeychain(ctx, authn.WithDockerConfig(dockerConfig))),
+		crane.WithTransport(transport.Transport(ctx, remote.DefaultTransport.(*http.Transport))),
+	)
+}
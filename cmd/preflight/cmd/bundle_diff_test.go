@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("bundle diff command tests", func() {
+	var oldDir, newDir string
+
+	writeBundle := func(version string) string {
+		dir, err := os.MkdirTemp("", "bundle-diff-*")
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(os.RemoveAll, dir)
+
+		Expect(os.MkdirAll(filepath.Join(dir, "manifests"), 0o755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "manifests", "example.clusterserviceversion.yaml"), []byte(`apiVersion: operators.coreos.com/v1alpha1
+kind: ClusterServiceVersion
+metadata:
+  name: example-operator.v`+version+`
+spec:
+  version: `+version+`
+  install:
+    strategy: deployment
+    spec:
+      deployments:
+      - name: example-operator
+        spec:
+          template:
+            spec:
+              containers:
+              - name: manager
+                image: quay.io/example/operator:v`+version+`
+`), 0o644)).To(Succeed())
+
+		return dir
+	}
+
+	BeforeEach(func() {
+		createAndCleanupDirForArtifactsAndLogs()
+		oldDir = writeBundle("1.0.0")
+		newDir = writeBundle("1.1.0")
+	})
+
+	Context("with two bundle directories", func() {
+		It("should write the changes as text", func() {
+			out, err := executeCommand(bundleDiffCmd(), oldDir, newDir)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("Comparing example-operator.v1.0.0"))
+			Expect(out).To(ContainSubstring(`~ spec.version: "1.0.0" -> "1.1.0"`))
+		})
+
+		It("should write the changes as JSON", func() {
+			out, err := executeCommand(bundleDiffCmd(), oldDir, newDir, "--format", "json")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring(`"path": "spec.version"`))
+		})
+	})
+
+	Context("with an invalid format", func() {
+		It("should throw an error", func() {
+			_, err := executeCommand(bundleDiffCmd(), oldDir, newDir, "--format", "yaml")
+			Expect(err).To(MatchError(ContainSubstring("invalid format yaml")))
+		})
+	})
+
+	Context("with one bundle", func() {
+		It("should throw an error", func() {
+			_, err := executeCommand(bundleDiffCmd(), oldDir)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -9,15 +9,28 @@ import (
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/artifacts"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/certification"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/container"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/authn"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/ci"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/cli"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/compat"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/containerized"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/formatters"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/image"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/lib"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/oidc"
+	containerpol "github.com/redhat-openshift-ecosystem/openshift-preflight/internal/policy/container"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/proxy"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/pyxis"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/runtime"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/transport"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/viper"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/submission"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/version"
 
 	"github.com/go-logr/logr"
+	"github.com/google/go-containerregistry/pkg/name"
+	cranev1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
@@ -50,12 +63,74 @@ func checkContainerCmd(runpreflight runPreflight) *cobra.Command {
 	flags.Bool("insecure", false, "Use insecure protocol for the registry. Default is False. Cannot be used with submit.")
 	_ = viper.BindPFlag("insecure", flags.Lookup("insecure"))
 
+	flags.Bool("submit-dry-run", false, "Look up the certification project and image in Pyxis, and report what would be submitted\n"+
+		"to Red Hat, without submitting. Requires the same parameters as submit. (env: PFLT_SUBMIT_DRY_RUN)")
+	_ = viper.BindPFlag("submit_dry_run", flags.Lookup("submit-dry-run"))
+
 	// Make --submit mutually exclusive to --insecure
 	checkContainerCmd.MarkFlagsMutuallyExclusive("submit", "insecure")
+	checkContainerCmd.MarkFlagsMutuallyExclusive("submit", "submit-dry-run")
+	checkContainerCmd.MarkFlagsMutuallyExclusive("submit-dry-run", "insecure")
+
+	flags.Bool("offline", false, fmt.Sprintf("With --submit, write what would be submitted to Red Hat to %s in the artifacts directory,\n"+
+		"instead of submitting it, so that it can be submitted later with preflight submit-bundle. (env: PFLT_SUBMIT_OFFLINE)", lib.SubmissionBundleFilename))
+	_ = viper.BindPFlag("submit_offline", flags.Lookup("offline"))
+	checkContainerCmd.MarkFlagsMutuallyExclusive("offline", "submit-dry-run")
+
+	flags.String("mark-submitted", "", fmt.Sprintf("With --submit, mark the image in its registry as submitted after submission, with an artifact\n"+
+		"annotated with the test results ID and time, pushed as a %q to the image or tagged sha256-<digest>.preflight\n"+
+		"with %q. Requires credentials to push to the image's repository. (env: PFLT_MARK_SUBMITTED)", lib.MarkByReferrer, lib.MarkByTag))
+	_ = viper.BindPFlag("mark_submitted", flags.Lookup("mark-submitted"))
+
+	flags.Bool("attach-results", false, "Push the results, the log, and the artifacts to the image's registry after the check, as an artifact\n"+
+		"referring to the checked image's digest. Requires credentials to push to the image's repository. (env: PFLT_ATTACH_RESULTS)")
+	_ = viper.BindPFlag("attach_results", flags.Lookup("attach-results"))
+
+	flags.String("submit-to-url", "", "POST the results, as JSON, to this URL, e.g. of an internal compliance system. With --submit,\n"+
+		"results are submitted to Red Hat as well. (env: PFLT_SUBMIT_TO_URL)")
+	_ = viper.BindPFlag("submit_to_url", flags.Lookup("submit-to-url"))
+	flags.String("submit-to-url-secret-file", "", fmt.Sprintf("Path to a file containing a secret to sign the results POSTed to --submit-to-url with,\n"+
+		"using HMAC-SHA256. The signature is sent in the %s header, and the time it was made in the %s header.\n"+
+		"(env: PFLT_SUBMIT_TO_URL_SECRET_FILE)", submission.SignatureHeader, submission.TimestampHeader))
+	_ = viper.BindPFlag("submit_to_url_secret_file", flags.Lookup("submit-to-url-secret-file"))
+
+	flags.String("opensearch-url", "", "Index a document for the outcome of each check in this OpenSearch or Elasticsearch index, e.g.\n"+
+		"https://search.example.com:9200/preflight-results. With --submit, results are submitted to Red Hat as well. (env: PFLT_OPENSEARCH_URL)")
+	_ = viper.BindPFlag("opensearch_url", flags.Lookup("opensearch-url"))
+	flags.String("opensearch-api-key-file", "", "Path to a file containing an API key to authenticate with --opensearch-url. For basic authentication,\n"+
+		"include the username and password in the URL. (env: PFLT_OPENSEARCH_API_KEY_FILE)")
+	_ = viper.BindPFlag("opensearch_api_key_file", flags.Lookup("opensearch-api-key-file"))
+
+	flags.String("issue-tracker-config", "", "Path to a file describing how to file, or update, issues for the checks that did not pass\n"+
+		"with the REST API of an issue tracker, e.g. Jira. (env: PFLT_ISSUE_TRACKER_CONFIG)")
+	_ = viper.BindPFlag("issue_tracker_config", flags.Lookup("issue-tracker-config"))
 
 	flags.String("pyxis-api-token", "", "API token for Pyxis authentication (env: PFLT_PYXIS_API_TOKEN)")
 	_ = viper.BindPFlag("pyxis_api_token", flags.Lookup("pyxis-api-token"))
 
+	flags.String("pyxis-api-token-file", "", "Path to a file containing the API token for Pyxis authentication, e.g. a mounted secret.\n"+
+		"(env: PFLT_PYXIS_API_TOKEN_FILE)")
+	_ = viper.BindPFlag("pyxis_api_token_file", flags.Lookup("pyxis-api-token-file"))
+	checkContainerCmd.MarkFlagsMutuallyExclusive("pyxis-api-token", "pyxis-api-token-file")
+
+	flags.String("pyxis-token-secret", "", "Kubernetes Secret to read the API token for Pyxis authentication from, in the form\n"+
+		"[namespace/]name[:key], when running in a pod. The key defaults to token. (env: PFLT_PYXIS_TOKEN_SECRET)")
+	_ = viper.BindPFlag("pyxis_token_secret", flags.Lookup("pyxis-token-secret"))
+	checkContainerCmd.MarkFlagsMutuallyExclusive("pyxis-api-token", "pyxis-token-secret")
+	checkContainerCmd.MarkFlagsMutuallyExclusive("pyxis-api-token-file", "pyxis-token-secret")
+
+	flags.String("pyxis-oidc-token-file", "", "Path to a file containing a short-lived OIDC token, e.g. a CI job's ID token or a projected service\n"+
+		"account token, to exchange for Pyxis access tokens instead of using an API token. (env: PFLT_PYXIS_OIDC_TOKEN_FILE)")
+	_ = viper.BindPFlag("pyxis_oidc_token_file", flags.Lookup("pyxis-oidc-token-file"))
+	flags.String("pyxis-oidc-client-id", "", "The client that the OIDC token is exchanged for Pyxis access tokens as. (env: PFLT_PYXIS_OIDC_CLIENT_ID)")
+	_ = viper.BindPFlag("pyxis_oidc_client_id", flags.Lookup("pyxis-oidc-client-id"))
+	flags.String("pyxis-oidc-token-url", "", fmt.Sprintf("The token endpoint that the OIDC token is exchanged with. Defaults to %s.\n"+
+		"(env: PFLT_PYXIS_OIDC_TOKEN_URL)", oidc.DefaultTokenURL))
+	_ = viper.BindPFlag("pyxis_oidc_token_url", flags.Lookup("pyxis-oidc-token-url"))
+	checkContainerCmd.MarkFlagsMutuallyExclusive("pyxis-api-token", "pyxis-oidc-token-file")
+	checkContainerCmd.MarkFlagsMutuallyExclusive("pyxis-api-token-file", "pyxis-oidc-token-file")
+	checkContainerCmd.MarkFlagsMutuallyExclusive("pyxis-token-secret", "pyxis-oidc-token-file")
+
 	flags.String("pyxis-host", "", fmt.Sprintf("Host to use for Pyxis submissions. This will override Pyxis Env. Only set this if you know what you are doing.\n"+
 		"If you do set it, it should include just the host, and the URI path. (env: PFLT_PYXIS_HOST)"))
 	_ = viper.BindPFlag("pyxis_host", flags.Lookup("pyxis-host"))
@@ -63,18 +138,44 @@ func checkContainerCmd(runpreflight runPreflight) *cobra.Command {
 	flags.String("pyxis-env", check.DefaultPyxisEnv, "Env to use for Pyxis submissions.")
 	_ = viper.BindPFlag("pyxis_env", flags.Lookup("pyxis-env"))
 
+	flags.Float64("pyxis-max-qps", 0, "The maximum number of requests per second to make to Pyxis. Requests that Pyxis rate limits\n"+
+		"are always retried after the delay it requests. Defaults to no limit. (env: PFLT_PYXIS_MAX_QPS)")
+	_ = viper.BindPFlag("pyxis_max_qps", flags.Lookup("pyxis-max-qps"))
+
 	flags.String("certification-project-id", "", fmt.Sprintf("Certification Project ID from connect.redhat.com/projects/{certification-project-id}/overview\n"+
 		"URL paramater. This value may differ from the PID on the overview page. (env: PFLT_CERTIFICATION_PROJECT_ID)"))
 	_ = viper.BindPFlag("certification_project_id", flags.Lookup("certification-project-id"))
 
-	checkContainerCmd.Flags().String("platform", rt.GOARCH, "Architecture of image to pull. Defaults to current platform.")
+	checkContainerCmd.Flags().String("platform", rt.GOARCH, "Architecture of image to pull, e.g. arm64, or a full platform in the form os/arch[/variant],\n"+
+		"e.g. linux/arm/v7. Defaults to current platform. (env: PFLT_PLATFORM)")
 	_ = viper.BindPFlag("platform", checkContainerCmd.Flags().Lookup("platform"))
 
+	flags.StringSlice("approved-base-image", nil, "A base image approved by your organization, either a repository or an image referenced by digest.\n"+
+		"If set, the image must be built on one of them. May be repeated. (env: PFLT_APPROVED_BASE_IMAGES)")
+	_ = viper.BindPFlag("approved_base_images", flags.Lookup("approved-base-image"))
+
+	flags.StringSlice("decryption-key", nil, "Path to an RSA or EC private key, in PEM or DER form, that decrypts the layers of an image encrypted\n"+
+		"with ocicrypt for a JWE recipient. Without one, encrypted layers are skipped. May be repeated. (env: PFLT_DECRYPTION_KEYS)")
+	_ = viper.BindPFlag("decryption_keys", flags.Lookup("decryption-key"))
+
+	flags.String("rerun-failed", "", "Path to the results.json of a previous execution for the same image. Only the checks that did not pass\n"+
+		"are executed again, and the results are merged with the outcomes of those that did. Layers are cached\n"+
+		"in the user's cache directory for later executions. (env: PFLT_RERUN_FAILED)")
+	_ = viper.BindPFlag("rerun_failed", flags.Lookup("rerun-failed"))
+
+	flags.String("expected-digest", "", "The manifest digest the image is expected to have, e.g. sha256:... If the image that is pulled has\n"+
+		"another digest, e.g. because its tag was moved, no check is executed and preflight errors. (env: PFLT_EXPECTED_DIGEST)")
+	_ = viper.BindPFlag("expected_digest", flags.Lookup("expected-digest"))
+
+	flags.String("via", "", fmt.Sprintf("Run preflight in the official preflight container image with %s or %s, mounting the files\n"+
+		"and passing the configuration it needs, for hosts that preflight does not support.", containerized.EnginePodman, containerized.EngineDocker))
+	flags.String("via-image", containerized.DefaultImage(), "The preflight container image to run with --via.")
+
 	return checkContainerCmd
 }
 
 // checkContainerRunE executes checkContainer using the user args to inform the execution.
-func checkContainerRunE(cmd *cobra.Command, args []string, runpreflight runPreflight) error {
+func checkContainerRunE(cmd *cobra.Command, args []string, runpreflight runPreflight) (err error) {
 	ctx := cmd.Context()
 	logger, err := logr.FromContext(ctx)
 	if err != nil {
@@ -82,7 +183,15 @@ func checkContainerRunE(cmd *cobra.Command, args []string, runpreflight runPrefl
 	}
 	logger.Info("certification library version", "version", version.Version.String())
 
-	containerImage := args[0]
+	containerImage, err := resolveImage(ctx, viper.Instance().GetString("image_resolver"), args[0])
+	if err != nil {
+		return err
+	}
+	ctx = withImageLogging(ctx, containerImage)
+
+	if via, _ := cmd.Flags().GetString("via"); via != "" {
+		return checkContainerVia(cmd, via, containerImage)
+	}
 
 	// Render the Viper configuration as a runtime.Config
 	cfg, err := runtime.NewConfigFrom(*viper.Instance())
@@ -90,7 +199,111 @@ func checkContainerRunE(cmd *cobra.Command, args []string, runpreflight runPrefl
 		return fmt.Errorf("invalid configuration: %w", err)
 	}
 
-	artifactsWriter, err := artifacts.NewFilesystemWriter(artifacts.WithDirectory(cfg.Artifacts))
+	ciSystem, err := ci.Parse(cfg.CI)
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if err := validateRegistryMirrors(cfg.RegistryMirrors); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if err := validateLockfile(cfg); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if err := validateTmpdir(cfg); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if err := validateExpectedDigest(cfg.ExpectedDigest); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if _, err := image.ParsePlatform(cfg.Platform); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if err := validateCheckTimeouts(cmd.Context(), cfg); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if err := validateCheckEgress(cmd.Context(), cfg); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if err := validateExitCodes(cfg); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	compatVer, err := compatVersion(cfg)
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	if compatVer != "" {
+		ctx = compat.ContextWithVersion(ctx, compatVer)
+	}
+
+	quota, err := artifactsQuota(cfg.ArtifactsQuota)
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	artifactsDir, uploadArtifacts, err := artifactsDirectory(cfg.Artifacts, cfg.LogFile)
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	cfg.Artifacts = artifactsDir
+	defer func() {
+		if uploadErr := uploadArtifacts(ctx); uploadErr != nil && err == nil {
+			err = uploadErr
+		}
+	}()
+	if cfg.ArtifactArchive != "" {
+		// The archive is written before the artifacts are uploaded, and after everything
+		// else is written.
+		defer func() {
+			if archiveErr := writeArtifactArchive(ctx, cfg.ArtifactArchive, cfg.Artifacts, cfg.LogFile, cfg.JUnitPath); archiveErr != nil && err == nil {
+				err = archiveErr
+			}
+		}()
+	}
+
+	cleanupSecrets, err := withSecretCredentials(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("could not read credentials from kubernetes secrets: %w", err)
+	}
+	defer cleanupSecrets()
+
+	if err := withVaultCredentials(ctx, cfg); err != nil {
+		return fmt.Errorf("could not read credentials from vault: %w", err)
+	}
+	if cfg.RerunFailed != "" && (cfg.Submit || cfg.SubmitDryRun) {
+		// The checks that passed before may not pass for the image as it is now.
+		return fmt.Errorf("invalid configuration: --rerun-failed cannot be used with --submit or --submit-dry-run")
+	}
+	if cfg.SubmitOffline && !cfg.Submit {
+		return fmt.Errorf("invalid configuration: --offline requires --submit")
+	}
+	if cfg.MarkSubmitted != "" {
+		if !cfg.Submit || cfg.SubmitOffline {
+			return fmt.Errorf("invalid configuration: --mark-submitted requires --submit, and cannot be used with --offline")
+		}
+		if err := lib.ValidateMarkMode(cfg.MarkSubmitted); err != nil {
+			return fmt.Errorf("invalid configuration: %w", err)
+		}
+	}
+	if (cfg.Submit || cfg.SubmitDryRun) && !cfg.SubmitOffline && cfg.PyxisAPIToken == "" && !oidcConfig(cfg).IsSet() {
+		return fmt.Errorf("pyxis API Token must be specified when --submit is present")
+	}
+
+	for _, approved := range cfg.ApprovedBaseImages {
+		if _, err := containerpol.ParseApprovedBaseImage(approved); err != nil {
+			return fmt.Errorf("invalid configuration: %w", err)
+		}
+	}
+
+	artifactsWriter, err := artifacts.NewFilesystemWriter(artifacts.WithDirectory(cfg.Artifacts), artifacts.WithQuota(quota))
 	if err != nil {
 		return err
 	}
@@ -110,23 +323,166 @@ func checkContainerRunE(cmd *cobra.Command, args []string, runpreflight runPrefl
 		opts...,
 	)
 
-	pc := lib.NewPyxisClient(ctx, cfg.CertificationProjectID, cfg.PyxisAPIToken, cfg.PyxisHost)
-	resultSubmitter := lib.ResolveSubmitter(pc, cfg.CertificationProjectID, cfg.DockerConfig, cfg.LogFile)
+	// Submission happens outside of the check, so it needs the proxy and CA configuration as well.
+	proxyConfig := proxy.Config{URL: cfg.Proxy, NoProxy: cfg.NoProxy}
+	if err := proxyConfig.Validate(); err != nil {
+		return err
+	}
+	ctx = proxy.ContextWithConfig(ctx, proxyConfig)
+
+	if cfg.CABundle != "" {
+		rootCAs, err := transport.LoadRootCAs(cfg.CABundle)
+		if err != nil {
+			return err
+		}
+		ctx = transport.ContextWithRootCAs(ctx, rootCAs)
+	}
+
+	// Marking the image as submitted, and attaching the results to it, push to its
+	// repository, with the same credentials the image is pulled with.
+	if (cfg.MarkSubmitted != "" || cfg.AttachResults) && (cfg.RegistryUsername != "" || cfg.RegistryToken != "") {
+		creds, err := authn.Credentials{Username: cfg.RegistryUsername, Password: cfg.RegistryPassword, Token: cfg.RegistryToken}.ForImage(containerImage)
+		if err != nil {
+			return err
+		}
+		ctx = authn.ContextWithCredentials(ctx, creds)
+	}
+
+	if !cfg.SubmitOffline {
+		ctx, err = withPyxisAccessTokens(ctx, oidcConfig(cfg), cfg.PyxisAPIToken)
+		if err != nil {
+			return fmt.Errorf("could not exchange the OIDC token for a pyxis access token: %w", err)
+		}
+	}
+
+	pc := lib.NewPyxisClient(ctx, cfg.CertificationProjectID, cfg.PyxisAPIToken, cfg.PyxisHost, pyxis.WithMaxQPS(cfg.PyxisMaxQPS))
+	resultSubmitter := lib.ResolveSubmitter(pc, cfg.PyxisEnv, cfg.CertificationProjectID, cfg.DockerConfig, cfg.LogFile)
+	if s, ok := resultSubmitter.(*lib.ContainerCertificationSubmitter); ok {
+		s.DryRun = cfg.SubmitDryRun
+		if cfg.MarkSubmitted != "" {
+			s.Marker = &lib.SubmissionMarker{Mode: cfg.MarkSubmitted, DockerConfig: cfg.DockerConfig}
+		}
+	}
+	if cfg.Submit && cfg.SubmitOffline {
+		// The results are bundled to be submitted later, from a host that can reach Pyxis.
+		resultSubmitter = &lib.OfflineSubmitter{
+			CertificationProjectID: cfg.CertificationProjectID,
+			PreflightLogFile:       cfg.LogFile,
+		}
+	}
+	if cfg.SubmitToURL != "" {
+		resultSubmitter, err = withWebhookSubmitter(resultSubmitter, cfg.Submit || cfg.SubmitDryRun, cfg.SubmitToURL, cfg.SubmitToURLSecret)
+		if err != nil {
+			return fmt.Errorf("invalid configuration: %w", err)
+		}
+	}
+	if cfg.OpenSearchURL != "" {
+		resultSubmitter, err = withOpenSearchSubmitter(resultSubmitter, cfg.Submit || cfg.SubmitDryRun || cfg.SubmitToURL != "", cfg.OpenSearchURL, cfg.OpenSearchAPIKey)
+		if err != nil {
+			return fmt.Errorf("invalid configuration: %w", err)
+		}
+	}
+
+	if cfg.IssueTrackerConfig != "" {
+		resultSubmitter, err = withIssueTrackerSubmitter(resultSubmitter, cfg.Submit || cfg.SubmitDryRun || cfg.SubmitToURL != "" || cfg.OpenSearchURL != "" || cfg.IssueTrackerConfig != "", cfg.IssueTrackerConfig)
+		if err != nil {
+			return fmt.Errorf("invalid configuration: %w", err)
+		}
+	}
+
+	policyExceptions, err := loadExceptions(cfg.ExceptionsFile)
+	if err != nil {
+		return err
+	}
+
+	// The reference image is checked with the same options as the image under test.
+	compareToReference := compareTo(cfg.CompareTo, func(ctx context.Context, image string) (certification.Results, error) {
+		image, err := resolveImage(ctx, cfg.ImageResolver, image)
+		if err != nil {
+			return certification.Results{}, err
+		}
+		return container.NewCheck(image, opts...).Run(ctx)
+	}, "container", cfg.Platform)
 
 	// Run the  container check.
 	cmd.SilenceUsage = true
 
-	return runpreflight(
+	defer func() {
+		if err := writeArtifactTruncations(ctx, quota, artifactsWriter); err != nil {
+			logger.Error(err, "could not record truncated artifacts")
+		}
+	}()
+
+	runChecks := checkcontainer.Run
+	if cfg.AttachResults {
+		// The results are attached to the digest the image resolved to when it was
+		// checked, once they are written, and before the artifacts are uploaded.
+		var checked certification.Results
+		runChecks = func(ctx context.Context) (certification.Results, error) {
+			results, err := checkcontainer.Run(ctx)
+			checked = results
+			return results, err
+		}
+		defer func() {
+			if checked.ImageDigest == "" {
+				return
+			}
+			if attachErr := attachResults(ctx, containerImage, checked.ImageDigest, cfg.Insecure, cfg.DockerConfig, cfg.Artifacts, cfg.LogFile, cfg.JUnitPath); attachErr != nil && err == nil {
+				err = attachErr
+			}
+		}()
+	}
+
+	return silenceExitError(cmd, runpreflight(
 		ctx,
-		checkcontainer.Run,
+		runChecks,
 		cli.CheckConfig{
 			IncludeJUnitResults: cfg.WriteJUnit,
-			SubmitResults:       cfg.Submit,
+			JUnitPath:           cfg.JUnitPath,
+			IncludeChecklist:    cfg.WriteChecklist,
+			IncludeCodeQuality:  cfg.WriteCodeQuality,
+			EventsFile:          cfg.EventsFile,
+			Deterministic:       cfg.Deterministic,
+			Progress:            cfg.Progress,
+			Watch:               cfg.Watch,
+			Quiet:               cfg.Quiet,
+			Summary:             cfg.Summary,
+			CI:                  ciSystem,
+			CompareTo:           compareToReference,
+			Exceptions:          policyExceptions,
+			KnownIssues:         loadKnownIssues(ctx, cfg.KnownIssuesFeed),
+			PostRunCommand:      cfg.PostRunCommand,
+			ExitCodes:           exitCodes(cfg),
+			SubmitResults:       cfg.Submit || cfg.SubmitDryRun || cfg.SubmitToURL != "" || cfg.OpenSearchURL != "",
 		},
 		formatter,
 		&runtime.ResultWriterFile{},
 		resultSubmitter,
-	)
+	))
+}
+
+// attachResults pushes the files in dir, and those of files that exist, to the repository
+// of image as an artifact referring to digest.
+func attachResults(ctx context.Context, image, digest string, insecure bool, dockerConfig, dir string, files ...string) error {
+	var opts []name.Option
+	if insecure {
+		opts = append(opts, name.Insecure)
+	}
+	ref, err := name.ParseReference(image, opts...)
+	if err != nil {
+		return fmt.Errorf("image uri could not be parsed: %w", err)
+	}
+
+	attached, err := collectArtifacts("", dir, files...)
+	if err != nil {
+		return err
+	}
+
+	if _, err := (&lib.ResultsAttacher{DockerConfig: dockerConfig}).Attach(ctx, ref.Context().Digest(digest), attached); err != nil {
+		return fmt.Errorf("could not attach the results to the image: %w", err)
+	}
+
+	return nil
 }
 
 func checkContainerPositionalArgs(cmd *cobra.Command, args []string) error {
@@ -142,14 +498,22 @@ func checkContainerPositionalArgs(cmd *cobra.Command, args []string) error {
 		}
 	})
 
-	// --submit was specified
+	// --submit or --submit-dry-run was specified
 	viper := viper.Instance()
-	if submit {
+	if submit || viper.GetBool("submit_dry_run") {
 		// If the flag is not marked as changed AND viper hasn't gotten it from environment, it's an error
 		if !cmd.Flag("certification-project-id").Changed && !viper.IsSet("certification_project_id") {
 			return fmt.Errorf("certification Project ID must be specified when --submit is present")
 		}
-		if !cmd.Flag("pyxis-api-token").Changed && !viper.IsSet("pyxis_api_token") {
+		// The token may instead be read from a file, a Kubernetes Secret, or Vault, or an OIDC
+		// token exchanged for access tokens, which is validated when it is read. It is not
+		// needed when results are bundled to be submitted later.
+		tokenFromFile := cmd.Flag("pyxis-api-token-file").Changed || viper.IsSet("pyxis_api_token_file")
+		tokenFromSecret := viper.GetString("pyxis_token_secret") != ""
+		tokenFromVault := viper.GetString("vault_path") != ""
+		tokenFromOIDC := viper.GetString("pyxis_oidc_token") != "" || viper.GetString("pyxis_oidc_token_file") != ""
+		offline := viper.GetBool("submit_offline")
+		if !offline && !tokenFromFile && !tokenFromSecret && !tokenFromVault && !tokenFromOIDC && !cmd.Flag("pyxis-api-token").Changed && !viper.IsSet("pyxis_api_token") {
 			return fmt.Errorf("pyxis API Token must be specified when --submit is present")
 		}
 
@@ -170,21 +534,69 @@ func checkContainerPositionalArgs(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// withWebhookSubmitter returns a ResultSubmitter that POSTs results to url, signed with
+// secret if it is set, in addition to submitting them with rs if toRedHat is true.
+func withWebhookSubmitter(rs lib.ResultSubmitter, toRedHat bool, url, secret string) (lib.ResultSubmitter, error) {
+	webhook, err := submission.NewWebhook(url, submission.WithSecret(secret))
+	if err != nil {
+		return nil, err
+	}
+
+	if !toRedHat {
+		return webhook, nil
+	}
+
+	return submission.Multi(rs, webhook), nil
+}
+
+// withOpenSearchSubmitter returns a ResultSubmitter indexing results in the OpenSearch
+// index at url, and also submitting them with rs if they are submitted elsewhere.
+func withOpenSearchSubmitter(rs lib.ResultSubmitter, submitted bool, url, apiKey string) (lib.ResultSubmitter, error) {
+	index, err := submission.NewOpenSearch(url, submission.WithAPIKey(apiKey))
+	if err != nil {
+		return nil, err
+	}
+
+	if !submitted {
+		return index, nil
+	}
+
+	return submission.Multi(rs, index), nil
+}
+
+// withIssueTrackerSubmitter returns a ResultSubmitter filing issues for the checks that
+// did not pass as described by the configuration at path, and also submitting the results
+// with rs if they are submitted elsewhere.
+func withIssueTrackerSubmitter(rs lib.ResultSubmitter, submitted bool, path string) (lib.ResultSubmitter, error) {
+	config, err := submission.LoadIssueTrackerConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	tracker, err := submission.NewIssueTracker(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if !submitted {
+		return tracker, nil
+	}
+
+	return submission.Multi(rs, tracker), nil
+}
+
 // validateCertificationProjectID validates that the certification project id is in the proper format
 // and throws an error if the value provided is in a legacy format that is not usable to query pyxis
 func validateCertificationProjectID(cmd *cobra.Command, args []string) error {
 	viper := viper.Instance()
 	certificationProjectID := viper.GetString("certification_project_id")
-	// splitting the certification project id into parts. if there are more than 2 elements in the array,
-	// we know they inputted a legacy project id, which can not be used to query pyxis
-	parts := strings.Split(certificationProjectID, "-")
-
-	if len(parts) > 2 {
-		return fmt.Errorf("certification project id: %s is improperly formatted see help command for instructions on obtaining proper value", certificationProjectID)
+	normalized, err := normalizeCertificationProjectID(certificationProjectID)
+	if err != nil {
+		return err
 	}
 
-	if parts[0] == "ospid" {
-		viper.Set("certification_project_id", parts[1])
+	if normalized != certificationProjectID {
+		viper.Set("certification_project_id", normalized)
 	}
 
 	return nil
@@ -192,25 +604,25 @@ func validateCertificationProjectID(cmd *cobra.Command, args []string) error {
 
 // generateContainerCheckOptions returns appropriate container.Options based on cfg.
 func generateContainerCheckOptions(cfg *runtime.Config) []container.Option {
-	o := []container.Option{
-		container.WithCertificationProject(cfg.CertificationProjectID, cfg.PyxisAPIToken),
-		container.WithDockerConfigJSONFromFile(cfg.DockerConfig),
-		// Always add PyxisHost, since the value is always set in viper config parsing.
-		container.WithPyxisHost(cfg.PyxisHost),
-		container.WithPlatform(cfg.Platform),
-	}
-
-	// set auth information if both are present in config.
-	if cfg.PyxisAPIToken != "" && cfg.CertificationProjectID != "" {
-		o = append(o, container.WithCertificationProject(cfg.CertificationProjectID, cfg.PyxisAPIToken))
-	}
-
 	if cfg.Insecure {
 		// Do not allow for submission if Insecure is set.
 		// This is a secondary check to be safe.
 		cfg.Submit = false
-		o = append(o, container.WithInsecureConnection())
+		cfg.SubmitDryRun = false
 	}
 
-	return o
+	return []container.Option{container.WithConfig(cfg)}
+}
+
+// validateExpectedDigest returns an error if digest is set, but is not a digest, e.g.
+// sha256:....
+func validateExpectedDigest(digest string) error {
+	if digest == "" {
+		return nil
+	}
+	if _, err := cranev1.NewHash(digest); err != nil {
+		return fmt.Errorf("invalid --expected-digest %q: %w", digest, err)
+	}
+
+	return nil
 }
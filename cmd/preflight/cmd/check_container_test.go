@@ -4,16 +4,28 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/certification"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/cli"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/formatters"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/lib"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/viper"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/submission"
 
 	"github.com/go-logr/logr"
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 )
@@ -59,6 +71,11 @@ var _ = Describe("Check Container Command", func() {
 			Entry("submit is passed after empty api token", "pyxis API token and certification ID are required when --submit is present", []string{"foo", "--certification-project-id=fooid", "--pyxis-api-token", "--submit"}),
 			Entry("submit is passed with explicit value after empty api token", "pyxis API token and certification ID are required when --submit is present", []string{"foo", "--certification-project-id=fooid", "--pyxis-api-token", "--submit=true"}),
 			Entry("submit is passed and insecure is specified", "if any flags in the group [submit insecure] are set", []string{"foo", "--submit", "--insecure", "--certification-project-id=fooid", "--pyxis-api-token=footoken"}),
+			Entry("submit-dry-run is passed without certification-project-id", "certification Project ID must be specified when --submit is present", []string{"foo", "--submit-dry-run", "--pyxis-api-token=footoken"}),
+			Entry("submit-dry-run is passed without pyxis-api-token", "pyxis API Token must be specified when --submit is present", []string{"foo", "--submit-dry-run", "--certification-project-id=fooid"}),
+			Entry("submit and submit-dry-run are both passed", "if any flags in the group [submit submit-dry-run] are set", []string{"foo", "--submit", "--submit-dry-run", "--certification-project-id=fooid", "--pyxis-api-token=footoken"}),
+			Entry("offline and submit-dry-run are both passed", "if any flags in the group [offline submit-dry-run] are set", []string{"foo", "--submit-dry-run", "--offline", "--certification-project-id=fooid", "--pyxis-api-token=footoken"}),
+			Entry("offline is passed without certification-project-id", "certification Project ID must be specified when --submit is present", []string{"foo", "--submit", "--offline"}),
 		)
 
 		When("the user enables the submit flag", func() {
@@ -103,6 +120,224 @@ certification_project_id: mycertid`
 		})
 	})
 
+	Context("when submitting offline", func() {
+		BeforeEach(func() {
+			DeferCleanup(func() { submit = false })
+		})
+
+		It("should not require the pyxis API token", func() {
+			submit = true
+			// Values set in viper would take precedence over the flags of later tests.
+			GinkgoT().Setenv("PFLT_SUBMIT_OFFLINE", "true")
+			GinkgoT().Setenv("PFLT_CERTIFICATION_PROJECT_ID", "fooid")
+
+			err := checkContainerPositionalArgs(checkContainerCmd(mockRunPreflight), []string{"foo"})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should bundle the results instead of submitting them", func() {
+			var submitter lib.ResultSubmitter
+			run := func(_ context.Context, _ func(ctx context.Context) (certification.Results, error), _ cli.CheckConfig, _ formatters.ResponseFormatter, _ lib.ResultWriter, rs lib.ResultSubmitter) error {
+				submitter = rs
+				return nil
+			}
+			_, err := executeCommandWithLogger(checkContainerCmd(run), logr.Discard(), "example.com/example/image:mytag",
+				"--submit", "--offline", "--certification-project-id=000000000000")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(submitter).To(BeAssignableToTypeOf(&lib.OfflineSubmitter{}))
+		})
+
+		It("should require submit", func() {
+			_, err := executeCommandWithLogger(checkContainerCmd(mockRunPreflight), logr.Discard(), "example.com/example/image:mytag", "--offline")
+			Expect(err).To(MatchError(ContainSubstring("--offline requires --submit")))
+		})
+	})
+
+	Context("when attaching results", func() {
+		It("should not attach results if the image was not checked", func() {
+			_, err := executeCommandWithLogger(checkContainerCmd(mockRunPreflight), logr.Discard(), "example.com/example/image:mytag", "--attach-results")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should attach the artifacts and the log to the checked digest", func() {
+			s := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", log.Ldate)), registry.WithReferrersSupport(true)))
+			DeferCleanup(s.Close)
+			u, err := url.Parse(s.URL)
+			Expect(err).ToNot(HaveOccurred())
+
+			img, err := random.Image(1024, 1)
+			Expect(err).ToNot(HaveOccurred())
+			digest, err := img.Digest()
+			Expect(err).ToNot(HaveOccurred())
+			image := fmt.Sprintf("%s/test/preflight:latest", u.Host)
+			Expect(crane.Push(img, image)).To(Succeed())
+
+			dir := GinkgoT().TempDir()
+			Expect(os.WriteFile(filepath.Join(dir, "results.json"), []byte("{}"), 0o600)).To(Succeed())
+			logfile := filepath.Join(GinkgoT().TempDir(), "preflight.log")
+			Expect(os.WriteFile(logfile, []byte("log"), 0o600)).To(Succeed())
+
+			Expect(attachResults(context.TODO(), image, digest.String(), false, "", dir, logfile, filepath.Join(dir, "missing.xml"))).To(Succeed())
+
+			subject, err := name.NewDigest(fmt.Sprintf("%s/test/preflight@%s", u.Host, digest))
+			Expect(err).ToNot(HaveOccurred())
+			referrers, err := remote.Referrers(subject)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(referrers.Manifests).To(HaveLen(1))
+			Expect(referrers.Manifests[0].ArtifactType).To(Equal(lib.ResultsArtifactType))
+
+			artifact, err := remote.Image(subject.Context().Digest(referrers.Manifests[0].Digest.String()))
+			Expect(err).ToNot(HaveOccurred())
+			manifest, err := artifact.Manifest()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(manifest.Layers).To(HaveLen(2))
+			Expect(manifest.Layers[0].Annotations).To(HaveKeyWithValue(lib.AnnotationTitle, "results.json"))
+			Expect(manifest.Layers[1].Annotations).To(HaveKeyWithValue(lib.AnnotationTitle, "preflight.log"))
+		})
+
+		It("should fail if the image is not in its registry", func() {
+			s := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", log.Ldate))))
+			DeferCleanup(s.Close)
+			u, err := url.Parse(s.URL)
+			Expect(err).ToNot(HaveOccurred())
+
+			dir := GinkgoT().TempDir()
+			Expect(os.WriteFile(filepath.Join(dir, "results.json"), []byte("{}"), 0o600)).To(Succeed())
+
+			err = attachResults(context.TODO(), fmt.Sprintf("%s/test/missing:latest", u.Host),
+				"sha256:0000000000000000000000000000000000000000000000000000000000000000", false, "", dir)
+			Expect(err).To(MatchError(ContainSubstring("could not attach the results to the image")))
+		})
+	})
+
+	Context("when rerunning the checks that failed", func() {
+		It("should not submit the results", func() {
+			DeferCleanup(func() { submit = false })
+			_, err := executeCommandWithLogger(checkContainerCmd(mockRunPreflight), logr.Discard(), "example.com/example/image:mytag",
+				"--submit", "--certification-project-id=000000000000", "--pyxis-api-token=footoken", "--rerun-failed", "artifacts/results.json")
+			Expect(err).To(MatchError(ContainSubstring("--rerun-failed cannot be used with --submit")))
+		})
+	})
+
+	Context("when the image is expected to have a digest", func() {
+		It("should accept a digest", func() {
+			_, err := executeCommandWithLogger(checkContainerCmd(mockRunPreflight), logr.Discard(), "example.com/example/image:mytag",
+				"--expected-digest", "sha256:"+strings.Repeat("a", 64))
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should fail if it is not a digest", func() {
+			_, err := executeCommandWithLogger(checkContainerCmd(mockRunPreflight), logr.Discard(), "example.com/example/image:mytag",
+				"--expected-digest", "mytag")
+			Expect(err).To(MatchError(ContainSubstring(`invalid --expected-digest "mytag"`)))
+		})
+	})
+
+	Context("when a platform is given", func() {
+		It("should accept a platform with a variant", func() {
+			_, err := executeCommandWithLogger(checkContainerCmd(mockRunPreflight), logr.Discard(), "example.com/example/image:mytag",
+				"--platform", "linux/arm/v7")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should fail if the platform is invalid", func() {
+			_, err := executeCommandWithLogger(checkContainerCmd(mockRunPreflight), logr.Discard(), "example.com/example/image:mytag",
+				"--platform", "linux/arm/v7/extra")
+			Expect(err).To(MatchError(ContainSubstring(`invalid platform "linux/arm/v7/extra"`)))
+		})
+	})
+
+	Context("when marking submitted images", func() {
+		It("should mark the image after submitting", func() {
+			var submitter lib.ResultSubmitter
+			run := func(_ context.Context, _ func(ctx context.Context) (certification.Results, error), _ cli.CheckConfig, _ formatters.ResponseFormatter, _ lib.ResultWriter, rs lib.ResultSubmitter) error {
+				submitter = rs
+				return nil
+			}
+			_, err := executeCommandWithLogger(checkContainerCmd(run), logr.Discard(), "example.com/example/image:mytag",
+				"--submit", "--certification-project-id=000000000000", "--pyxis-api-token=footoken", "--mark-submitted", lib.MarkByReferrer)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(submitter).To(BeAssignableToTypeOf(&lib.ContainerCertificationSubmitter{}))
+			Expect(submitter.(*lib.ContainerCertificationSubmitter).Marker).To(HaveField("Mode", lib.MarkByReferrer))
+		})
+
+		It("should require submit", func() {
+			_, err := executeCommandWithLogger(checkContainerCmd(mockRunPreflight), logr.Discard(), "example.com/example/image:mytag", "--mark-submitted", lib.MarkByTag)
+			Expect(err).To(MatchError(ContainSubstring("--mark-submitted requires --submit")))
+		})
+
+		It("should reject an unsupported mode", func() {
+			_, err := executeCommandWithLogger(checkContainerCmd(mockRunPreflight), logr.Discard(), "example.com/example/image:mytag",
+				"--submit", "--certification-project-id=000000000000", "--pyxis-api-token=footoken", "--mark-submitted", "label")
+			Expect(err).To(MatchError(ContainSubstring("unsupported mode to mark submitted images")))
+		})
+	})
+
+	Context("when submitting to a url", func() {
+		It("should only submit to the url if results are not submitted to Red Hat", func() {
+			rs, err := withWebhookSubmitter(lib.NewNoopSubmitter(false, nil), false, "https://compliance.example.com/preflight", "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rs).To(BeAssignableToTypeOf(&submission.Webhook{}))
+		})
+
+		It("should submit to Red Hat as well", func() {
+			rs, err := withWebhookSubmitter(lib.NewNoopSubmitter(false, nil), true, "https://compliance.example.com/preflight", "secret")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rs).ToNot(BeAssignableToTypeOf(&submission.Webhook{}))
+		})
+
+		It("should reject an invalid url", func() {
+			_, err := executeCommandWithLogger(checkContainerCmd(mockRunPreflight), logr.Discard(), "example.com/example/image:mytag", "--submit-to-url", "compliance.example.com")
+			Expect(err).To(MatchError(ContainSubstring("must be an http or https URL")))
+		})
+	})
+
+	Context("when indexing results in opensearch", func() {
+		It("should only index the results if they are not submitted elsewhere", func() {
+			rs, err := withOpenSearchSubmitter(lib.NewNoopSubmitter(false, nil), false, "https://search.example.com:9200/preflight-results", "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rs).To(BeAssignableToTypeOf(&submission.OpenSearch{}))
+		})
+
+		It("should submit the results elsewhere as well", func() {
+			rs, err := withOpenSearchSubmitter(lib.NewNoopSubmitter(false, nil), true, "https://search.example.com:9200/preflight-results", "key")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rs).ToNot(BeAssignableToTypeOf(&submission.OpenSearch{}))
+		})
+
+		It("should reject a url without an index", func() {
+			_, err := executeCommandWithLogger(checkContainerCmd(mockRunPreflight), logr.Discard(), "example.com/example/image:mytag", "--opensearch-url", "https://search.example.com:9200")
+			Expect(err).To(MatchError(ContainSubstring("must be an http or https URL of an index")))
+		})
+	})
+
+	Context("when filing issues", func() {
+		var config string
+
+		BeforeEach(func() {
+			config = filepath.Join(GinkgoT().TempDir(), "issue-tracker.yaml")
+			Expect(os.WriteFile(config, []byte("create:\n  url: https://jira.example.com/rest/api/2/issue\n"), 0o644)).To(Succeed())
+		})
+
+		It("should only file issues if the results are not submitted elsewhere", func() {
+			rs, err := withIssueTrackerSubmitter(lib.NewNoopSubmitter(false, nil), false, config)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rs).To(BeAssignableToTypeOf(&submission.IssueTracker{}))
+		})
+
+		It("should submit the results elsewhere as well", func() {
+			rs, err := withIssueTrackerSubmitter(lib.NewNoopSubmitter(false, nil), true, config)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rs).ToNot(BeAssignableToTypeOf(&submission.IssueTracker{}))
+		})
+
+		It("should reject an invalid configuration", func() {
+			Expect(os.WriteFile(config, []byte("per: image\n"), 0o644)).To(Succeed())
+			_, err := executeCommandWithLogger(checkContainerCmd(mockRunPreflight), logr.Discard(), "example.com/example/image:mytag", "--issue-tracker-config", config)
+			Expect(err).To(MatchError(ContainSubstring("issues must be filed per check or run")))
+		})
+	})
+
 	Context("When validating the certification-project-id flag", func() {
 		Context("and the flag is set properly", func() {
 			BeforeEach(func() {
@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/artifacts"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/containerized"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/viper"
+
+	"github.com/go-logr/logr"
+	"github.com/spf13/cobra"
+	spfviper "github.com/spf13/viper"
+)
+
+// The configuration keys of files and directories, which are mounted in the container
+// when preflight is run in one.
+var (
+	viaInputFileKeys = []string{
+		"dockerconfig",
+		"ca_bundle",
+		"mirror_config",
+		"pyxis_api_token_file",
+		"registry_password_file",
+		"registry_token_file",
+		"vault_token_file",
+		"vault_secret_id_file",
+		"pyxis_oidc_token_file",
+		"submit_to_url_secret_file",
+		"opensearch_api_key_file",
+		"issue_tracker_config",
+		"post_run_cmd",
+		"rerun_failed",
+		"exceptions_file",
+	}
+	// viaInputFilesKeys are the keys of lists of files, each of which is mounted.
+	viaInputFilesKeys = []string{
+		"decryption_keys",
+	}
+	viaOutputFileKeys = []string{
+		"logfile",
+		"junit_path",
+		"events_file",
+		"artifact_archive",
+		"lockfile",
+	}
+	viaOutputDirKeys = []string{
+		"artifacts",
+		"tmpdir",
+	}
+	// viaBucketEnv are the environment variables configuring the bucket that artifacts
+	// are uploaded to, when the artifacts directory is the URI of one.
+	viaBucketEnv = []string{
+		"AWS_ACCESS_KEY_ID",
+		"AWS_SECRET_ACCESS_KEY",
+		"AWS_SESSION_TOKEN",
+		"AWS_PROFILE",
+		"AWS_ROLE_ARN",
+		"AWS_ROLE_SESSION_NAME",
+		"AWS_CONTAINER_CREDENTIALS_FULL_URI",
+		"AWS_CONTAINER_CREDENTIALS_RELATIVE_URI",
+		"AWS_CONTAINER_AUTHORIZATION_TOKEN",
+		"AWS_EC2_METADATA_DISABLED",
+		"AWS_REGION",
+		"AWS_DEFAULT_REGION",
+		"AWS_ENDPOINT_URL",
+		"AWS_ENDPOINT_URL_S3",
+		"STORAGE_EMULATOR_HOST",
+		"GCE_METADATA_HOST",
+		"AZURE_STORAGE_CONNECTION_STRING",
+		"AZURE_STORAGE_ACCOUNT",
+		"AZURE_STORAGE_KEY",
+		"AZURE_STORAGE_SAS_TOKEN",
+		"AZURE_TENANT_ID",
+		"AZURE_CLIENT_ID",
+		"AZURE_CLIENT_SECRET",
+		"AZURE_CLIENT_CERTIFICATE_PASSWORD",
+		"AZURE_AUTHORITY_HOST",
+	}
+	// viaBucketFileEnv are the environment variables naming files of credentials for the
+	// bucket that artifacts are uploaded to, which are mounted in the container.
+	viaBucketFileEnv = []string{
+		"AWS_WEB_IDENTITY_TOKEN_FILE",
+		"AWS_SHARED_CREDENTIALS_FILE",
+		"AWS_CONFIG_FILE",
+		"GOOGLE_APPLICATION_CREDENTIALS",
+		"AZURE_CLIENT_CERTIFICATE_PATH",
+		"AZURE_FEDERATED_TOKEN_FILE",
+	}
+)
+
+// checkContainerVia checks containerImage with preflight in the official preflight
+// container image, run with engine via, configured as this preflight is.
+func checkContainerVia(cmd *cobra.Command, via, containerImage string) error {
+	ctx := cmd.Context()
+
+	engine, err := containerized.ParseEngine(via)
+	if err != nil {
+		return err
+	}
+	image, _ := cmd.Flags().GetString("via-image")
+
+	// The reference is resolved on the host too, unless it is the results of a previous
+	// execution.
+	vcfg := viper.Instance()
+	if reference := vcfg.GetString("compare_to"); reference != "" && !isFile(reference) {
+		resolved, err := resolveImage(ctx, vcfg.GetString("image_resolver"), reference)
+		if err != nil {
+			return err
+		}
+		vcfg.Set("compare_to", resolved)
+	}
+
+	inv, err := containerizedCheckInvocation(engine, image, []string{"check", "container", containerImage}, vcfg)
+	if err != nil {
+		return err
+	}
+
+	logr.FromContextOrDiscard(ctx).Info("running preflight in a container", "engine", engine, "image", image)
+	cmd.SilenceUsage = true
+
+	return inv.Run(ctx, cmd.OutOrStdout(), cmd.ErrOrStderr())
+}
+
+// containerizedCheckInvocation returns the invocation of preflight with args in image,
+// with engine, configured as vcfg configures this preflight. Every configuration value
+// that is set is passed to the container in its environment, and the files and
+// directories configured are mounted in it.
+func containerizedCheckInvocation(engine, image string, args []string, vcfg *spfviper.Viper) (*containerized.Invocation, error) {
+	inv := containerized.NewInvocation(engine, image, args...)
+
+	mounted := map[string]func(key, value string) error{}
+	for _, key := range viaInputFileKeys {
+		mounted[key] = inv.MountFile
+	}
+	for _, key := range viaOutputFileKeys {
+		mounted[key] = inv.MountOutputFile
+	}
+	mountedLists := map[string]func(key string, values []string) error{}
+	for _, key := range viaInputFilesKeys {
+		mountedLists[key] = inv.MountFiles
+	}
+	for _, key := range viaOutputDirKeys {
+		mounted[key] = inv.MountOutputDir
+	}
+
+	keys := vcfg.AllKeys()
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !vcfg.IsSet(key) {
+			continue
+		}
+
+		env := "PFLT_" + strings.ToUpper(key)
+		if mount, ok := mountedLists[key]; ok {
+			if values := vcfg.GetStringSlice(key); len(values) > 0 {
+				if err := mount(env, values); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+
+		value := vcfg.GetString(key)
+		if values, ok := vcfg.Get(key).([]string); ok {
+			value = strings.Join(values, " ")
+		}
+		if value == "" {
+			continue
+		}
+
+		mount, ok := mounted[key]
+		switch {
+		case key == "config" || strings.HasPrefix(key, "config_"):
+			// The config file is read on the host, and its settings are passed in the
+			// environment.
+			continue
+		case key == "image_resolver":
+			// Images are resolved on the host, before preflight runs in the container.
+			continue
+		case key == "events_file" && value == "-":
+			// Events are written to stdout, which is the engine's.
+			ok = false
+		case key == "artifacts" && artifacts.IsBucketURI(value):
+			// Artifacts are uploaded from the container, with the host's credentials.
+			ok = false
+			for _, name := range viaBucketEnv {
+				if v := os.Getenv(name); v != "" {
+					inv.SetEnv(name, v)
+				}
+			}
+			for _, name := range viaBucketFileEnv {
+				if v := os.Getenv(name); v != "" {
+					if err := inv.MountFile(name, v); err != nil {
+						return nil, err
+					}
+				}
+			}
+		case key == "compare_to" && isFile(value):
+			// The reference may be the results of a previous execution, rather than an image.
+			mount, ok = inv.MountFile, true
+		case key == "known_issues_feed" && isFile(value):
+			// The feed may be a file, rather than a URL.
+			mount, ok = inv.MountFile, true
+		}
+		if !ok {
+			inv.SetEnv(env, value)
+			continue
+		}
+		if err := mount(env, value); err != nil {
+			return nil, err
+		}
+	}
+
+	return inv, nil
+}
+
+// isFile returns true if there is a regular file at path.
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/containerized"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	spfviper "github.com/spf13/viper"
+)
+
+var _ = Describe("Check container via a container engine", func() {
+	var (
+		tempdir string
+		vcfg    *spfviper.Viper
+	)
+
+	BeforeEach(func() {
+		tempdir = GinkgoT().TempDir()
+		vcfg = spfviper.New()
+		vcfg.SetDefault("artifacts", filepath.Join(tempdir, "artifacts"))
+		vcfg.SetDefault("logfile", filepath.Join(tempdir, "artifacts", "preflight.log"))
+	})
+
+	It("should mount the configured files, and pass the remaining configuration", func() {
+		dockerConfig := filepath.Join(tempdir, "config.json")
+		Expect(os.WriteFile(dockerConfig, []byte("{}"), 0o600)).To(Succeed())
+		vcfg.Set("dockerConfig", dockerConfig)
+		vcfg.Set("pyxis_api_token", "secret")
+		vcfg.Set("approved_base_images", []string{"registry.example.org/base", "registry.example.org/other"})
+		vcfg.Set("events_file", "-")
+
+		inv, err := containerizedCheckInvocation(containerized.EnginePodman, "quay.io/opdev/preflight:stable", []string{"check", "container", "image"}, vcfg)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(inv.Env).To(HaveKeyWithValue("PFLT_PYXIS_API_TOKEN", "secret"))
+		Expect(inv.Env).To(HaveKeyWithValue("PFLT_APPROVED_BASE_IMAGES", "registry.example.org/base registry.example.org/other"))
+		Expect(inv.Env).To(HaveKeyWithValue("PFLT_EVENTS_FILE", "-"))
+		Expect(inv.Env).To(HaveKeyWithValue("PFLT_ARTIFACTS", HavePrefix("/preflight/out/")))
+		Expect(inv.Env).To(HaveKeyWithValue("PFLT_LOGFILE", HaveSuffix("/preflight.log")))
+		Expect(inv.Env).To(HaveKeyWithValue("PFLT_DOCKERCONFIG", HaveSuffix("/config.json")))
+		Expect(inv.Mounts).To(ContainElement(containerized.Mount{Source: dockerConfig, Target: inv.Env["PFLT_DOCKERCONFIG"], ReadOnly: true}))
+		Expect(filepath.Join(tempdir, "artifacts")).To(BeADirectory())
+	})
+
+	It("should mount the scratch directory, so that images are extracted to it on the host", func() {
+		tmpdir := filepath.Join(tempdir, "scratch")
+		vcfg.Set("tmpdir", tmpdir)
+
+		inv, err := containerizedCheckInvocation(containerized.EnginePodman, "quay.io/opdev/preflight:stable", nil, vcfg)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(inv.Env).To(HaveKeyWithValue("PFLT_TMPDIR", HavePrefix("/preflight/out/")))
+		Expect(inv.Mounts).To(ContainElement(HaveField("Source", tmpdir)))
+	})
+
+	It("should mount the results of a previous execution to compare to", func() {
+		results := filepath.Join(tempdir, "results.json")
+		Expect(os.WriteFile(results, []byte("{}"), 0o600)).To(Succeed())
+		vcfg.Set("compare_to", results)
+
+		inv, err := containerizedCheckInvocation(containerized.EnginePodman, "quay.io/opdev/preflight:stable", nil, vcfg)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(inv.Env).To(HaveKeyWithValue("PFLT_COMPARE_TO", HaveSuffix("/results.json")))
+	})
+
+	It("should pass an artifacts bucket, and the credentials for it, rather than mount it", func() {
+		GinkgoT().Setenv("AWS_ACCESS_KEY_ID", "keyid")
+		GinkgoT().Setenv("AWS_SECRET_ACCESS_KEY", "secretkey")
+		vcfg.Set("artifacts", "s3://bucket/prefix")
+
+		inv, err := containerizedCheckInvocation(containerized.EnginePodman, "quay.io/opdev/preflight:stable", nil, vcfg)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(inv.Env).To(HaveKeyWithValue("PFLT_ARTIFACTS", "s3://bucket/prefix"))
+		Expect(inv.Env).To(HaveKeyWithValue("AWS_ACCESS_KEY_ID", "keyid"))
+		Expect(inv.Env).To(HaveKeyWithValue("AWS_SECRET_ACCESS_KEY", "secretkey"))
+		Expect(inv.Env).ToNot(HaveKey("AWS_SESSION_TOKEN"))
+	})
+
+	It("should mount the credentials file for an artifacts bucket", func() {
+		creds := filepath.Join(tempdir, "credentials.json")
+		Expect(os.WriteFile(creds, []byte("{}"), 0o600)).To(Succeed())
+		GinkgoT().Setenv("GOOGLE_APPLICATION_CREDENTIALS", creds)
+		vcfg.Set("artifacts", "gs://bucket/prefix")
+
+		inv, err := containerizedCheckInvocation(containerized.EnginePodman, "quay.io/opdev/preflight:stable", nil, vcfg)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(inv.Env).To(HaveKeyWithValue("PFLT_ARTIFACTS", "gs://bucket/prefix"))
+		Expect(inv.Env).To(HaveKeyWithValue("GOOGLE_APPLICATION_CREDENTIALS", HaveSuffix("/credentials.json")))
+		Expect(inv.Mounts).To(ContainElement(HaveField("Source", creds)))
+	})
+
+	It("should mount the exceptions file", func() {
+		exceptionsFile := filepath.Join(tempdir, "exceptions.yaml")
+		Expect(os.WriteFile(exceptionsFile, []byte("exceptions: []"), 0o600)).To(Succeed())
+		vcfg.Set("exceptions_file", exceptionsFile)
+
+		inv, err := containerizedCheckInvocation(containerized.EnginePodman, "quay.io/opdev/preflight:stable", nil, vcfg)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(inv.Env).To(HaveKeyWithValue("PFLT_EXCEPTIONS_FILE", HaveSuffix("/exceptions.yaml")))
+		Expect(inv.Mounts).To(ContainElement(containerized.Mount{Source: exceptionsFile, Target: inv.Env["PFLT_EXCEPTIONS_FILE"], ReadOnly: true}))
+	})
+
+	It("should mount each decryption key, even one with whitespace in its path", func() {
+		rsaKey := filepath.Join(tempdir, "rsa key.pem")
+		ecKey := filepath.Join(tempdir, "ec.pem")
+		Expect(os.WriteFile(rsaKey, []byte("rsa"), 0o600)).To(Succeed())
+		Expect(os.WriteFile(ecKey, []byte("ec"), 0o600)).To(Succeed())
+		vcfg.Set("decryption_keys", []string{rsaKey, ecKey})
+
+		inv, err := containerizedCheckInvocation(containerized.EnginePodman, "quay.io/opdev/preflight:stable", nil, vcfg)
+		Expect(err).ToNot(HaveOccurred())
+
+		targets := strings.Fields(inv.Env["PFLT_DECRYPTION_KEYS"])
+		Expect(targets).To(HaveLen(2))
+		Expect(targets[0]).To(HaveSuffix("/rsa_key.pem"))
+		Expect(targets[1]).To(HaveSuffix("/ec.pem"))
+		Expect(inv.Mounts).To(ContainElements(
+			containerized.Mount{Source: rsaKey, Target: targets[0], ReadOnly: true},
+			containerized.Mount{Source: ecKey, Target: targets[1], ReadOnly: true},
+		))
+	})
+
+	It("should fail if a configured file does not exist", func() {
+		vcfg.Set("ca_bundle", filepath.Join(tempdir, "missing.pem"))
+
+		_, err := containerizedCheckInvocation(containerized.EnginePodman, "quay.io/opdev/preflight:stable", nil, vcfg)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -7,7 +7,12 @@ import (
 	"os"
 
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/artifacts"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/certification"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/cluster"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/ci"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/cli"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/compat"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/formatters"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/lib"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/runtime"
@@ -48,13 +53,98 @@ func checkOperatorCmd(runpreflight runPreflight) *cobra.Command {
 		"(env: PFLT_SCORECARD_WAIT_TIME)")
 	_ = viper.BindPFlag("scorecard_wait_time", checkOperatorCmd.Flags().Lookup("scorecard-wait-time"))
 
+	checkOperatorCmd.Flags().String("operability-check", operabilityCheckScorecard, fmt.Sprintf("The checks that verify the operator can run on the cluster: %s, or %s, which parses the CSV\n"+
+		"and applies the operator to the cluster in dry-run mode, for clusters that cannot run the scorecard pod. (env: PFLT_OPERABILITY_CHECK)",
+		operabilityCheckScorecard, operabilityCheckBasic))
+	_ = viper.BindPFlag("operability_check", checkOperatorCmd.Flags().Lookup("operability-check"))
+
 	checkOperatorCmd.Flags().String("channel", "", "The name of the operator channel which is used by DeployableByOLM to deploy the operator.\n"+
 		"If empty, the default operator channel in bundle's annotations file is used.. (env: PFLT_CHANNEL)")
 	_ = viper.BindPFlag("channel", checkOperatorCmd.Flags().Lookup("channel"))
 
+	checkOperatorCmd.Flags().Int("cluster-check-attempts", 1, "The number of times checks that depend on the cluster, such as scorecard and DeployableByOLM,\n"+
+		"are executed until they pass. Checks that pass after a retry are reported as flakes. (env: PFLT_CLUSTER_CHECK_ATTEMPTS)")
+	_ = viper.BindPFlag("cluster_check_attempts", checkOperatorCmd.Flags().Lookup("cluster-check-attempts"))
+
+	checkOperatorCmd.Flags().String("cluster-provider", clusterProviderKubeconfig, fmt.Sprintf("Where the cluster the operator is checked on comes from: %s, the cluster of KUBECONFIG,\n"+
+		"%s, the cluster preflight runs in, %s, a cluster leased from the test service at --cluster-provider-url,\n"+
+		"or %s, a ROSA or OSD cluster provisioned with OCM for the check, and deprovisioned after it. (env: PFLT_CLUSTER_PROVIDER)",
+		clusterProviderKubeconfig, clusterProviderInCluster, clusterProviderHosted, clusterProviderManaged))
+	_ = viper.BindPFlag("cluster_provider", checkOperatorCmd.Flags().Lookup("cluster-provider"))
+
+	checkOperatorCmd.Flags().String("cluster-provider-url", "", "The URL of the test service clusters are leased from with --cluster-provider hosted.\n"+
+		"A bearer token for the service is read from PFLT_CLUSTER_PROVIDER_TOKEN. (env: PFLT_CLUSTER_PROVIDER_URL)")
+	_ = viper.BindPFlag("cluster_provider_url", checkOperatorCmd.Flags().Lookup("cluster-provider-url"))
+
+	checkOperatorCmd.Flags().String("cluster-proxy", "", "The proxy to access the cluster through, e.g. socks5://bastion.example.com:1080. Hostnames are resolved\n"+
+		"by SOCKS5 proxies. Defaults to the proxy in the kubeconfig, or the HTTPS_PROXY environment variable. (env: PFLT_CLUSTER_PROXY)")
+	_ = viper.BindPFlag("cluster_proxy", checkOperatorCmd.Flags().Lookup("cluster-proxy"))
+
 	return checkOperatorCmd
 }
 
+// Cluster providers accepted by --cluster-provider.
+const (
+	clusterProviderKubeconfig = "kubeconfig"
+	clusterProviderInCluster  = "in-cluster"
+	clusterProviderHosted     = "hosted"
+	clusterProviderManaged    = "managed"
+)
+
+// validateClusterProvider returns an error if the cluster provider of cfg is not a
+// cluster provider, or is not configured.
+func validateClusterProvider(cfg *runtime.Config) error {
+	switch cfg.ClusterProvider {
+	case "", clusterProviderKubeconfig, clusterProviderInCluster:
+		return nil
+	case clusterProviderHosted:
+		if cfg.ClusterProviderURL == "" {
+			return fmt.Errorf("the %s cluster provider requires PFLT_CLUSTER_PROVIDER_URL", clusterProviderHosted)
+		}
+		return nil
+	case clusterProviderManaged:
+		switch {
+		case cfg.OCMToken == "":
+			return fmt.Errorf("the %s cluster provider requires PFLT_OCM_TOKEN", clusterProviderManaged)
+		case cfg.ManagedClusterRegion == "":
+			return fmt.Errorf("the %s cluster provider requires PFLT_MANAGED_CLUSTER_REGION", clusterProviderManaged)
+		}
+		switch cfg.ManagedClusterProduct {
+		case "", cluster.ProductOSD:
+			return nil
+		case cluster.ProductROSA:
+			if cfg.ManagedClusterAWSAccountID == "" {
+				return fmt.Errorf("%s clusters require PFLT_MANAGED_CLUSTER_AWS_ACCOUNT_ID", cluster.ProductROSA)
+			}
+			return nil
+		}
+		return fmt.Errorf("unknown managed cluster product %q: must be %s or %s", cfg.ManagedClusterProduct, cluster.ProductOSD, cluster.ProductROSA)
+	}
+
+	return fmt.Errorf("unknown cluster provider %q: must be %s, %s, %s, or %s", cfg.ClusterProvider, clusterProviderKubeconfig, clusterProviderInCluster, clusterProviderHosted, clusterProviderManaged)
+}
+
+// usesKubeconfig returns true if the operator is checked on the cluster of KUBECONFIG.
+func usesKubeconfig(provider string) bool {
+	return provider == "" || provider == clusterProviderKubeconfig
+}
+
+// Operability checks accepted by --operability-check.
+const (
+	operabilityCheckScorecard = "scorecard"
+	operabilityCheckBasic     = "basic"
+)
+
+// validateOperabilityCheck returns an error if check is not an operability check.
+func validateOperabilityCheck(check string) error {
+	switch check {
+	case "", operabilityCheckScorecard, operabilityCheckBasic:
+		return nil
+	}
+
+	return fmt.Errorf("unknown operability check %q: must be %s or %s", check, operabilityCheckScorecard, operabilityCheckBasic)
+}
+
 // ensureKubeconfigIsSet ensures that the KUBECONFIG environment variable has a value.
 func ensureKubeconfigIsSet() error {
 	if _, ok := os.LookupEnv("KUBECONFIG"); !ok {
@@ -75,7 +165,7 @@ func ensureIndexImageConfigIsSet() error {
 }
 
 // checkOperatorRunE executes checkOperator using the user args to inform the execution.
-func checkOperatorRunE(cmd *cobra.Command, args []string, runpreflight runPreflight) error {
+func checkOperatorRunE(cmd *cobra.Command, args []string, runpreflight runPreflight) (err error) {
 	ctx := cmd.Context()
 	logger, err := logr.FromContext(ctx)
 	if err != nil {
@@ -83,7 +173,6 @@ func checkOperatorRunE(cmd *cobra.Command, args []string, runpreflight runPrefli
 	}
 
 	logger.Info("certification library version", "version", version.Version.String())
-	operatorImage := args[0]
 
 	// Render the Viper configuration as a runtime.Config
 	cfg, err := runtime.NewConfigFrom(*viper.Instance())
@@ -91,7 +180,97 @@ func checkOperatorRunE(cmd *cobra.Command, args []string, runpreflight runPrefli
 		return fmt.Errorf("invalid configuration: %w", err)
 	}
 
-	ctx, _, err = configureArtifactsWriter(ctx, cfg.Artifacts)
+	operatorImage, err := resolveImage(ctx, cfg.ImageResolver, args[0])
+	if err != nil {
+		return err
+	}
+	ctx = withImageLogging(ctx, operatorImage)
+	cfg.IndexImage, err = resolveImage(ctx, cfg.ImageResolver, cfg.IndexImage)
+	if err != nil {
+		return err
+	}
+
+	ciSystem, err := ci.Parse(cfg.CI)
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if err := validateRegistryMirrors(cfg.RegistryMirrors); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if err := validateLockfile(cfg); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if err := validateTmpdir(cfg); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if err := validateCheckTimeouts(cmd.Context(), cfg); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if err := validateCheckEgress(cmd.Context(), cfg); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if err := validateExitCodes(cfg); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	compatVer, err := compatVersion(cfg)
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	if compatVer != "" {
+		ctx = compat.ContextWithVersion(ctx, compatVer)
+	}
+
+	if err := validateOperabilityCheck(cfg.OperabilityCheck); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if err := validateClusterProvider(cfg); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	quota, err := artifactsQuota(cfg.ArtifactsQuota)
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	artifactsDir, uploadArtifacts, err := artifactsDirectory(cfg.Artifacts, cfg.LogFile)
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	cfg.Artifacts = artifactsDir
+	defer func() {
+		if uploadErr := uploadArtifacts(ctx); uploadErr != nil && err == nil {
+			err = uploadErr
+		}
+	}()
+	if cfg.ArtifactArchive != "" {
+		// The archive is written before the artifacts are uploaded, and after everything
+		// else is written.
+		defer func() {
+			if archiveErr := writeArtifactArchive(ctx, cfg.ArtifactArchive, cfg.Artifacts, cfg.LogFile, cfg.JUnitPath); archiveErr != nil && err == nil {
+				err = archiveErr
+			}
+		}()
+	}
+
+	cleanupSecrets, err := withSecretCredentials(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("could not read credentials from kubernetes secrets: %w", err)
+	}
+	defer cleanupSecrets()
+
+	if err := withVaultCredentials(ctx, cfg); err != nil {
+		return fmt.Errorf("could not read credentials from vault: %w", err)
+	}
+
+	ctx, artifactsWriter, err := configureArtifactsWriter(ctx, cfg.Artifacts, artifacts.WithQuota(quota))
 	if err != nil {
 		return err
 	}
@@ -101,34 +280,74 @@ func checkOperatorRunE(cmd *cobra.Command, args []string, runpreflight runPrefli
 		return err
 	}
 
+	policyExceptions, err := loadExceptions(cfg.ExceptionsFile)
+	if err != nil {
+		return err
+	}
+
 	opts := generateOperatorCheckOptions(cfg)
 
-	kubeconfig, err := func() ([]byte, error) {
-		kubeconfigFile, err := os.Open(cfg.Kubeconfig)
+	// Other cluster providers are configured by the options.
+	var kubeconfig []byte
+	if usesKubeconfig(cfg.ClusterProvider) {
+		kubeconfig, err = func() ([]byte, error) {
+			kubeconfigFile, err := os.Open(cfg.Kubeconfig)
+			if err != nil {
+				return nil, fmt.Errorf("unable to open provided kubeconfig file: %s", err)
+			}
+			defer kubeconfigFile.Close()
+			return io.ReadAll(kubeconfigFile)
+		}()
 		if err != nil {
-			return nil, fmt.Errorf("unable to open provided kubeconfig file: %s", err)
+			return fmt.Errorf("unable to read provided kubeconfig file's contents: %s", err)
 		}
-		defer kubeconfigFile.Close()
-		return io.ReadAll(kubeconfigFile)
-	}()
-	if err != nil {
-		return fmt.Errorf("unable to read provided kubeconfig file's contents: %s", err)
 	}
 
 	checkoperator := operator.NewCheck(operatorImage, cfg.IndexImage, kubeconfig, opts...)
 
+	// The reference image is checked with the same options as the image under test.
+	compareToReference := compareTo(cfg.CompareTo, func(ctx context.Context, image string) (certification.Results, error) {
+		image, err := resolveImage(ctx, cfg.ImageResolver, image)
+		if err != nil {
+			return certification.Results{}, err
+		}
+		return operator.NewCheck(image, cfg.IndexImage, kubeconfig, opts...).Run(ctx)
+	}, "operator", cfg.IndexImage, cfg.Channel)
+
 	cmd.SilenceUsage = true
-	return runpreflight(
+
+	defer func() {
+		if err := writeArtifactTruncations(ctx, quota, artifactsWriter); err != nil {
+			logger.Error(err, "could not record truncated artifacts")
+		}
+	}()
+
+	return silenceExitError(cmd, runpreflight(
 		ctx,
 		checkoperator.Run,
 		cli.CheckConfig{
 			IncludeJUnitResults: cfg.WriteJUnit,
+			JUnitPath:           cfg.JUnitPath,
+			IncludeChecklist:    cfg.WriteChecklist,
+			IncludeCodeQuality:  cfg.WriteCodeQuality,
+			EventsFile:          cfg.EventsFile,
+			Deterministic:       cfg.Deterministic,
+			Progress:            cfg.Progress,
+			Watch:               cfg.Watch,
+			Quiet:               cfg.Quiet,
+			Summary:             cfg.Summary,
+			CI:                  ciSystem,
+			CompareTo:           compareToReference,
+			Exceptions:          policyExceptions,
+			KnownIssues:         loadKnownIssues(ctx, cfg.KnownIssuesFeed),
+			PostRunCommand:      cfg.PostRunCommand,
+			ExitCodes:           exitCodes(cfg),
 			SubmitResults:       false, // operator results are not submitted.
 		},
 		formatter,
 		&runtime.ResultWriterFile{},
 		&lib.NoopSubmitter{},
-	)
+	))
 }
 
 func checkOperatorPositionalArgs(cmd *cobra.Command, args []string) error {
@@ -136,8 +355,10 @@ func checkOperatorPositionalArgs(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("an operator bundle image positional argument is required")
 	}
 
-	if err := ensureKubeconfigIsSet(); err != nil {
-		return err
+	if usesKubeconfig(viper.Instance().GetString("cluster_provider")) {
+		if err := ensureKubeconfigIsSet(); err != nil {
+			return err
+		}
 	}
 
 	if err := ensureIndexImageConfigIsSet(); err != nil {
@@ -165,16 +386,105 @@ func generateOperatorCheckOptions(cfg *runtime.Config) []operator.Option {
 		opts = append(opts, operator.WithOperatorChannel(cfg.Channel))
 	}
 
+	if cfg.OperabilityCheck == operabilityCheckBasic {
+		opts = append(opts, operator.WithBasicOperabilityCheck())
+	}
+
+	switch cfg.ClusterProvider {
+	case clusterProviderInCluster:
+		opts = append(opts, operator.WithClusterProvider(cluster.InCluster()))
+	case clusterProviderHosted:
+		opts = append(opts, operator.WithClusterProvider(cluster.Hosted(cfg.ClusterProviderURL, cfg.ClusterProviderToken)))
+	case clusterProviderManaged:
+		// The credentials of the AWS account are read as the AWS CLI does.
+		opts = append(opts, operator.WithClusterProvider(cluster.ManagedOpenShift(cluster.ManagedOptions{
+			OfflineToken:       cfg.OCMToken,
+			Product:            cfg.ManagedClusterProduct,
+			Region:             cfg.ManagedClusterRegion,
+			Version:            cfg.ManagedClusterVersion,
+			AWSAccountID:       cfg.ManagedClusterAWSAccountID,
+			AWSAccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			AWSSecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		})))
+	}
+
 	if cfg.Insecure {
 		opts = append(opts, operator.WithInsecureConnection())
 	}
 
+	if cfg.TraceOnFailure {
+		opts = append(opts, operator.WithTraceOnFailure())
+	}
+
+	if cfg.FailFast {
+		opts = append(opts, operator.WithFailFast())
+	}
+
+	if cfg.DenyCheckEgress {
+		opts = append(opts, operator.WithEgressPolicy(cfg.AllowCheckEgress...))
+	}
+
+	// The timeouts are validated before the options are generated.
+	if timeouts, err := check.ParseTimeouts(cfg.CheckTimeout, cfg.CheckTimeouts); err == nil {
+		if timeouts.Default > 0 {
+			opts = append(opts, operator.WithCheckTimeout(timeouts.Default))
+		}
+		for name, timeout := range timeouts.Checks {
+			opts = append(opts, operator.WithCheckTimeoutFor(name, timeout))
+		}
+	}
+
+	if cfg.ProbeServices {
+		opts = append(opts, operator.WithServiceProbes())
+	}
+
+	if cfg.CheckAttempts > 1 {
+		opts = append(opts, operator.WithCheckAttempts(cfg.CheckAttempts))
+	}
+
+	if cfg.Proxy != "" {
+		opts = append(opts, operator.WithProxy(cfg.Proxy, cfg.NoProxy))
+	}
+
+	if cfg.ClusterProxy != "" {
+		opts = append(opts, operator.WithClusterProxy(cfg.ClusterProxy))
+	}
+
+	if cfg.CABundle != "" {
+		opts = append(opts, operator.WithCABundle(cfg.CABundle))
+	}
+
+	if cfg.RegistryUsername != "" || cfg.RegistryPassword != "" || cfg.RegistryToken != "" {
+		opts = append(opts, operator.WithRegistryCredentials(cfg.RegistryUsername, cfg.RegistryPassword, cfg.RegistryToken))
+	}
+
+	for _, m := range registryMirrors(cfg) {
+		opts = append(opts, operator.WithRegistryMirror(m.Source, m.Mirrors...))
+	}
+
+	if cfg.MirrorConfig != "" {
+		opts = append(opts, operator.WithMirrorConfigFile(cfg.MirrorConfig))
+	}
+
+	if cfg.Lockfile != "" {
+		opts = append(opts, operator.WithLockfile(cfg.Lockfile))
+	}
+
+	if cfg.UpdateLockfile {
+		opts = append(opts, operator.WithLockfileUpdate())
+	}
+
+	if cfg.Tmpdir != "" {
+		opts = append(opts, operator.WithScratchDir(cfg.Tmpdir))
+	}
+
 	return opts
 }
 
-// configureArtifactsWriter adds a filesystem ArtifactsWriter to the context.
-func configureArtifactsWriter(ctx context.Context, dir string) (context.Context, *artifacts.FilesystemWriter, error) {
-	artifactsWriter, err := artifacts.NewFilesystemWriter(artifacts.WithDirectory(dir))
+// configureArtifactsWriter adds a filesystem ArtifactsWriter to the context, configured
+// with opts.
+func configureArtifactsWriter(ctx context.Context, dir string, opts ...artifacts.FilesystemWriterOption) (context.Context, *artifacts.FilesystemWriter, error) {
+	artifactsWriter, err := artifacts.NewFilesystemWriter(append([]artifacts.FilesystemWriterOption{artifacts.WithDirectory(dir)}, opts...)...)
 	if err != nil {
 		return ctx, &artifacts.FilesystemWriter{}, err
 	}
@@ -8,6 +8,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/runtime"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/viper"
 )
 
@@ -87,6 +88,22 @@ var _ = Describe("Check Operator", func() {
 				Expect(err).To(HaveOccurred())
 				Expect(out).To(ContainSubstring("random error"))
 			})
+			It("should return an error if the operability check is unknown", func() {
+				_, err := executeCommandWithLogger(checkOperatorCmd(mockRunPreflight), logr.Discard(), "quay.io/example/image:mytag", "--operability-check", "full")
+				Expect(err).To(MatchError(ContainSubstring("unknown operability check")))
+			})
+			It("should return an error if the cluster provider is unknown", func() {
+				_, err := executeCommandWithLogger(checkOperatorCmd(mockRunPreflight), logr.Discard(), "quay.io/example/image:mytag", "--cluster-provider", "rosa")
+				Expect(err).To(MatchError(ContainSubstring("unknown cluster provider")))
+			})
+			It("should return an error if the managed cluster provider has no OCM token", func() {
+				_, err := executeCommandWithLogger(checkOperatorCmd(mockRunPreflight), logr.Discard(), "quay.io/example/image:mytag", "--cluster-provider", "managed")
+				Expect(err).To(MatchError(ContainSubstring("requires PFLT_OCM_TOKEN")))
+			})
+			It("should return an error if the hosted cluster provider has no URL", func() {
+				_, err := executeCommandWithLogger(checkOperatorCmd(mockRunPreflight), logr.Discard(), "quay.io/example/image:mytag", "--cluster-provider", "hosted")
+				Expect(err).To(MatchError(ContainSubstring("requires PFLT_CLUSTER_PROVIDER_URL")))
+			})
 		})
 
 		Context("With an invalid KUBECONFIG file location", func() {
@@ -188,5 +205,35 @@ var _ = Describe("Check Operator", func() {
 			err := checkOperatorPositionalArgs(checkOperatorCmd(mockRunPreflight), posArgs)
 			Expect(err).ToNot(HaveOccurred())
 		})
+
+		DescribeTable("should validate the managed cluster provider",
+			func(product, awsAccountID, message string) {
+				cfg := &runtime.Config{
+					ClusterProvider:            "managed",
+					OCMToken:                   "offlinetoken",
+					ManagedClusterRegion:       "us-east-1",
+					ManagedClusterProduct:      product,
+					ManagedClusterAWSAccountID: awsAccountID,
+				}
+				err := validateClusterProvider(cfg)
+				if message == "" {
+					Expect(err).ToNot(HaveOccurred())
+					return
+				}
+				Expect(err).To(MatchError(ContainSubstring(message)))
+			},
+			Entry("OSD by default", "", "", ""),
+			Entry("ROSA in an AWS account", "rosa", "123456789012", ""),
+			Entry("ROSA without an AWS account", "rosa", "", "require PFLT_MANAGED_CLUSTER_AWS_ACCOUNT_ID"),
+			Entry("an unknown product", "aro", "", "unknown managed cluster product"),
+		)
+
+		It("should not require KUBECONFIG when the cluster is provided otherwise", func() {
+			DeferCleanup(viper.Instance().Set, "cluster_provider", viper.Instance().GetString("cluster_provider"))
+			viper.Instance().Set("cluster_provider", "in-cluster")
+			os.Unsetenv("KUBECONFIG")
+			err := checkOperatorPositionalArgs(checkOperatorCmd(mockRunPreflight), posArgs)
+			Expect(err).ToNot(HaveOccurred())
+		})
 	})
 })
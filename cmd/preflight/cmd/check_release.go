@@ -0,0 +1,398 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/artifacts"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/certification"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/container"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/cli"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/events"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/formatters"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/interrupt"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/metrics"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/release"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/runtime"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/viper"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/operator"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/version"
+
+	"github.com/go-logr/logr"
+	"github.com/spf13/cobra"
+)
+
+// ReleaseResultsFilename is the name of the unified report for a release.
+const ReleaseResultsFilename = "results-release.json"
+
+// ReleaseCheckpointFilename is the name of the checkpoint of a release, which is
+// written to the artifacts directory unless --checkpoint is specified.
+const ReleaseCheckpointFilename = "checkpoint-release.json"
+
+// releaseCheckpoint records the components of a release that were checked, so that an
+// interrupted run can be resumed with --resume rather than checking them again.
+type releaseCheckpoint struct {
+	Components []checkpointComponent `json:"components"`
+}
+
+// checkpointComponent is the result of a component in a releaseCheckpoint.
+type checkpointComponent struct {
+	release.ComponentResult
+	// JUnit is the JUnit test suite of the component, if it was checked, so that it
+	// can be reported when the run is resumed.
+	JUnit *formatters.JUnitTestSuite `json:"junit,omitempty"`
+}
+
+func checkReleaseCmd() *cobra.Command {
+	checkReleaseCmd := &cobra.Command{
+		Use:   "release",
+		Short: "Run checks for every image in an operator release",
+		Long: "This command will run the Certification checks for every operand image listed in a release manifest,\n" +
+			"followed by the Certification checks for the operator bundle, and write a unified report.",
+		Args: cobra.NoArgs,
+		// this fmt.Sprintf is in place to keep spacing consistent with cobras two spaces that's used in: Usage, Flags, etc
+		Example: fmt.Sprintf("  %s", "preflight check release --manifest release.yaml"),
+		RunE:    checkReleaseRunE,
+	}
+
+	checkReleaseCmd.Flags().String("manifest", "", "Path to the release manifest listing the bundle and its images.")
+	_ = checkReleaseCmd.MarkFlagRequired("manifest")
+	checkReleaseCmd.Flags().String("checkpoint", "", "Path to which a checkpoint of the components that were checked is written after each component.\n"+
+		"Defaults to "+ReleaseCheckpointFilename+" in the artifacts directory.")
+	checkReleaseCmd.Flags().String("resume", "", "Path to the checkpoint of an interrupted run of the same release. Components that passed or failed\n"+
+		"are not checked again.")
+
+	return checkReleaseCmd
+}
+
+// checkReleaseRunE checks every component of the release manifest, in dependency order.
+func checkReleaseRunE(cmd *cobra.Command, args []string) (err error) {
+	ctx := cmd.Context()
+	logger, err := logr.FromContext(ctx)
+	if err != nil {
+		return fmt.Errorf("invalid logging configuration")
+	}
+	logger.Info("certification library version", "version", version.Version.String())
+
+	manifestPath, _ := cmd.Flags().GetString("manifest")
+	manifest, err := release.ReadManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	components, err := manifest.Components()
+	if err != nil {
+		return fmt.Errorf("invalid release manifest: %w", err)
+	}
+
+	var resumed *releaseCheckpoint
+	if resume, _ := cmd.Flags().GetString("resume"); resume != "" {
+		if resumed, err = readReleaseCheckpoint(resume, components); err != nil {
+			return err
+		}
+	}
+
+	// Render the Viper configuration as a runtime.Config
+	cfg, err := runtime.NewConfigFrom(*viper.Instance())
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	if manifest.IndexImage != "" {
+		cfg.IndexImage = manifest.IndexImage
+	}
+	if manifest.Channel != "" {
+		cfg.Channel = manifest.Channel
+	}
+	if err := validateLockfile(cfg); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	if err := validateTmpdir(cfg); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if err := validateCheckTimeouts(cmd.Context(), cfg); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if err := validateCheckEgress(cmd.Context(), cfg); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if err := validateExitCodes(cfg); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if cfg.Compat != "" {
+		// Releases are checked by a command that no version --compat pins had.
+		return fmt.Errorf("invalid configuration: --compat cannot be used with check release")
+	}
+
+	// Every component's artifacts count towards the same quota.
+	quota, err := artifactsQuota(cfg.ArtifactsQuota)
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	artifactsDir, uploadArtifacts, err := artifactsDirectory(cfg.Artifacts, cfg.LogFile)
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	cfg.Artifacts = artifactsDir
+	defer func() {
+		if uploadErr := uploadArtifacts(ctx); uploadErr != nil && err == nil {
+			err = uploadErr
+		}
+	}()
+	if cfg.ArtifactArchive != "" {
+		// The archive is written before the artifacts are uploaded, and after everything
+		// else is written.
+		defer func() {
+			if archiveErr := writeArtifactArchive(ctx, cfg.ArtifactArchive, cfg.Artifacts, cfg.LogFile, cfg.JUnitPath); archiveErr != nil && err == nil {
+				err = archiveErr
+			}
+		}()
+	}
+
+	var kubeconfig []byte
+	if manifest.Bundle != "" {
+		if err := validateClusterProvider(cfg); err != nil {
+			return fmt.Errorf("invalid configuration: %w", err)
+		}
+		if usesKubeconfig(cfg.ClusterProvider) {
+			if err := ensureKubeconfigIsSet(); err != nil {
+				return err
+			}
+		}
+		if cfg.IndexImage == "" {
+			return fmt.Errorf("an index image is required to check the bundle: set indexImage in the release manifest or PFLT_INDEXIMAGE")
+		}
+		// Other cluster providers are configured by the options.
+		if usesKubeconfig(cfg.ClusterProvider) {
+			if kubeconfig, err = os.ReadFile(cfg.Kubeconfig); err != nil {
+				return fmt.Errorf("unable to read provided kubeconfig file's contents: %s", err)
+			}
+		}
+	}
+
+	ctx, artifactsWriter, err := configureArtifactsWriter(ctx, cfg.Artifacts, artifacts.WithQuota(quota))
+	if err != nil {
+		return err
+	}
+
+	formatter, err := formatters.NewByName(formatters.DefaultFormat)
+	if err != nil {
+		return err
+	}
+
+	cmd.SilenceUsage = true
+
+	defer func() {
+		if err := writeArtifactTruncations(ctx, quota, artifactsWriter); err != nil {
+			logger.Error(err, "could not record truncated artifacts")
+		}
+	}()
+
+	// The JUnit test suite of each component that was checked, by artifacts directory.
+	suites := map[string]formatters.JUnitTestSuite{}
+
+	checkpointPath, _ := cmd.Flags().GetString("checkpoint")
+	if checkpointPath == "" {
+		checkpointPath = filepath.Join(cfg.Artifacts, ReleaseCheckpointFilename)
+	}
+	checkpoint := releaseCheckpoint{Components: []checkpointComponent{}}
+	runOpts := []release.Option{
+		release.WithProgress(func(cr release.ComponentResult) {
+			component := checkpointComponent{ComponentResult: cr}
+			if suite, ok := suites[componentDir(release.Component{Image: cr.Image, Kind: cr.Kind})]; ok {
+				component.JUnit = &suite
+			}
+			checkpoint.Components = append(checkpoint.Components, component)
+			if err := writeReleaseCheckpoint(checkpointPath, checkpoint); err != nil {
+				logger.Error(err, "could not write the checkpoint of the release")
+			}
+		}),
+	}
+	if cfg.FailFast {
+		runOpts = append(runOpts, release.WithFailFast())
+	}
+	if resumed != nil {
+		completed := make([]release.ComponentResult, 0, len(resumed.Components))
+		for _, c := range resumed.Components {
+			completed = append(completed, c.ComponentResult)
+			if c.JUnit != nil {
+				suites[componentDir(release.Component{Image: c.Image, Kind: c.Kind})] = *c.JUnit
+			}
+		}
+		runOpts = append(runOpts, release.WithCompleted(completed))
+		logger.Info("resuming release from checkpoint", "components", len(resumed.Components))
+	}
+
+	// Each component checked is a run of its own.
+	if m := metrics.FromContext(ctx); m != nil {
+		ctx = events.ContextWithListener(ctx, m)
+	}
+
+	report := release.Run(ctx, components, func(ctx context.Context, c release.Component) (certification.Results, string, error) {
+		logger.Info("checking release component", "image", c.Image, "kind", c.Kind)
+
+		// Each component writes its artifacts and results to its own directory.
+		ctx, componentWriter, err := configureArtifactsWriter(ctx, filepath.Join(cfg.Artifacts, componentDir(c)), artifacts.WithQuota(quota))
+		if err != nil {
+			return certification.Results{}, "", err
+		}
+
+		var results certification.Results
+		switch c.Kind {
+		case release.KindOperator:
+			results, err = operator.NewCheck(c.Image, cfg.IndexImage, kubeconfig, generateOperatorCheckOptions(cfg)...).Run(ctx)
+		default:
+			results, err = container.NewCheck(c.Image, generateContainerCheckOptions(cfg)...).Run(ctx)
+		}
+		if err != nil {
+			return results, "", err
+		}
+
+		suites[componentDir(c)] = formatters.NewJUnitTestSuite(ctx, junitSuiteName(c.Kind, c.Image), results)
+
+		formattedResults, err := formatter.Format(ctx, results)
+		if err != nil {
+			return results, "", err
+		}
+
+		resultsFile, err := componentWriter.WriteFile(cli.ResultsFilenameWithExtension(formatter.FileExtension()), bytes.NewReader(formattedResults))
+		return results, resultsFile, err
+	}, runOpts...)
+
+	b, err := json.MarshalIndent(report, "", "    ")
+	if err != nil {
+		return fmt.Errorf("could not format release report: %w", err)
+	}
+
+	if _, err := artifactsWriter.WriteFile(ReleaseResultsFilename, bytes.NewReader(b)); err != nil {
+		return err
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), string(b))
+
+	if cfg.WriteJUnit || cfg.JUnitPath != "" {
+		junitReport, err := formatters.MarshalJUnit(releaseJUnitSuites(report, suites)...)
+		if err != nil {
+			return err
+		}
+		if err := cli.WriteJUnitReport(ctx, junitReport, cfg.JUnitPath); err != nil {
+			return err
+		}
+	}
+
+	logger.Info(fmt.Sprintf("Preflight release result: %s", certification.OverallStatus(report.PassedOverall)))
+
+	if interrupt.Interrupted(ctx) {
+		return fmt.Errorf("%w: resume the release with --resume %s", interrupt.ErrInterrupted, checkpointPath)
+	}
+
+	// Only whether every component passed is reported, so there are no warnings.
+	if codes := exitCodes(cfg); !report.PassedOverall && codes.Failed != 0 {
+		return silenceExitError(cmd, &cli.ExitError{Code: codes.Failed, Result: certification.StatusFailed})
+	}
+
+	return nil
+}
+
+// readReleaseCheckpoint reads the checkpoint at path, and ensures that it is a
+// checkpoint of the release whose components are components.
+func readReleaseCheckpoint(path string, components []release.Component) (*releaseCheckpoint, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read release checkpoint: %w", err)
+	}
+
+	var checkpoint releaseCheckpoint
+	if err := json.Unmarshal(b, &checkpoint); err != nil {
+		return nil, fmt.Errorf("could not parse release checkpoint: %w", err)
+	}
+
+	inRelease := make(map[string]bool, len(components))
+	for _, c := range components {
+		inRelease[componentDir(c)] = true
+	}
+	for _, c := range checkpoint.Components {
+		if !inRelease[componentDir(release.Component{Image: c.Image, Kind: c.Kind})] {
+			return nil, fmt.Errorf("release checkpoint %s is not for this release: %s %s is not part of it", path, c.Kind, c.Image)
+		}
+	}
+
+	return &checkpoint, nil
+}
+
+// writeReleaseCheckpoint writes checkpoint to path. It is written to a temporary file
+// first, so that an interruption never leaves a partial checkpoint behind.
+func writeReleaseCheckpoint(path string, checkpoint releaseCheckpoint) error {
+	b, err := json.MarshalIndent(checkpoint, "", "    ")
+	if err != nil {
+		return fmt.Errorf("could not format release checkpoint: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("could not write release checkpoint: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return fmt.Errorf("could not write release checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("could not write release checkpoint: %w", err)
+	}
+
+	return nil
+}
+
+// releaseJUnitSuites returns a JUnit test suite for each component in report, in order.
+// Components that were checked use their suite in suites, by artifacts directory.
+// Components that errored or were skipped have a single test case describing why.
+func releaseJUnitSuites(report release.Results, suites map[string]formatters.JUnitTestSuite) []formatters.JUnitTestSuite {
+	all := make([]formatters.JUnitTestSuite, 0, len(report.Components))
+	for _, cr := range report.Components {
+		suite, ok := suites[componentDir(release.Component{Image: cr.Image, Kind: cr.Kind})]
+		if !ok {
+			suite = formatters.JUnitTestSuite{
+				Tests: 1,
+				Time:  "0.000000",
+				Name:  junitSuiteName(cr.Kind, cr.Image),
+				Properties: []formatters.JUnitProperty{
+					{Name: "image", Value: cr.Image},
+				},
+			}
+
+			testCase := formatters.JUnitTestCase{Classname: cr.Image, Name: "Check", Time: "0.000000"}
+			if cr.Status == release.StatusSkipped {
+				testCase.SkipMessage = &formatters.JUnitSkipMessage{Message: cr.Error}
+			} else {
+				suite.Errors = 1
+				testCase.Error = &formatters.JUnitFailure{Message: "Errored", Contents: cr.Error}
+			}
+			suite.TestCases = []formatters.JUnitTestCase{testCase}
+		}
+
+		suite.Properties = append(suite.Properties, formatters.JUnitProperty{Name: "policy", Value: string(cr.Kind)})
+		all = append(all, suite)
+	}
+
+	return all
+}
+
+// junitSuiteName returns the name of the JUnit test suite for the image checked
+// with the policy of kind.
+func junitSuiteName(kind release.Kind, image string) string {
+	return fmt.Sprintf("%s %s", kind, image)
+}
+
+var unsafeDirChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// componentDir returns the artifacts directory name for c.
+func componentDir(c release.Component) string {
+	return fmt.Sprintf("%s-%s", c.Kind, unsafeDirChars.ReplaceAllString(c.Image, "_"))
+}
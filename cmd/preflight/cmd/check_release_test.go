@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/certification"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/formatters"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/release"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Check Release", func() {
+	BeforeEach(createAndCleanupDirForArtifactsAndLogs)
+
+	Context("when running the check release subcommand", func() {
+		Context("without a manifest", func() {
+			It("should return an error", func() {
+				_, err := executeCommand(checkReleaseCmd())
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring(`required flag(s) "manifest" not set`))
+			})
+		})
+
+		Context("with a manifest that does not exist", func() {
+			It("should return an error", func() {
+				_, err := executeCommandWithLogger(checkReleaseCmd(), logr.Discard(), "--manifest", filepath.Join(GinkgoT().TempDir(), "release.yaml"))
+				Expect(err).To(MatchError(ContainSubstring("could not read release manifest")))
+			})
+		})
+
+		Context("with a bundle, but without having set the KUBECONFIG environment variable", func() {
+			BeforeEach(func() {
+				if val, isSet := os.LookupEnv("KUBECONFIG"); isSet {
+					DeferCleanup(os.Setenv, "KUBECONFIG", val)
+				}
+				os.Unsetenv("KUBECONFIG")
+			})
+			It("should return an error", func() {
+				manifest := filepath.Join(GinkgoT().TempDir(), "release.yaml")
+				Expect(os.WriteFile(manifest, []byte("bundle: quay.io/example/bundle:v1\n"), 0o644)).To(Succeed())
+
+				_, err := executeCommandWithLogger(checkReleaseCmd(), logr.Discard(), "--manifest", manifest)
+				Expect(err).To(MatchError(ContainSubstring("KUBECONFIG could not")))
+			})
+		})
+
+		Context("when resuming from a checkpoint", func() {
+			var manifest string
+			BeforeEach(func() {
+				manifest = filepath.Join(GinkgoT().TempDir(), "release.yaml")
+				Expect(os.WriteFile(manifest, []byte("images:\n- image: quay.io/example/operand:v1\n"), 0o644)).To(Succeed())
+			})
+
+			It("should not check the components that were completed again, and checkpoint them", func() {
+				resume := filepath.Join(GinkgoT().TempDir(), "checkpoint.json")
+				Expect(writeReleaseCheckpoint(resume, releaseCheckpoint{Components: []checkpointComponent{{
+					ComponentResult: release.ComponentResult{
+						Image:  "quay.io/example/operand:v1",
+						Kind:   release.KindContainer,
+						Status: certification.StatusPassed,
+						Passed: 7,
+					},
+					JUnit: &formatters.JUnitTestSuite{Name: "container quay.io/example/operand:v1", Tests: 7},
+				}}})).To(Succeed())
+
+				out, err := executeCommandWithLogger(checkReleaseCmd(), logr.Discard(), "--manifest", manifest, "--resume", resume)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(out).To(ContainSubstring(`"passed": 7`))
+
+				checkpoint, err := readReleaseCheckpoint(filepath.Join(os.Getenv("PFLT_ARTIFACTS"), ReleaseCheckpointFilename), []release.Component{
+					{Image: "quay.io/example/operand:v1", Kind: release.KindContainer},
+				})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(checkpoint.Components).To(HaveLen(1))
+				Expect(checkpoint.Components[0].Status).To(Equal(certification.StatusPassed))
+				Expect(checkpoint.Components[0].JUnit).ToNot(BeNil())
+				Expect(checkpoint.Components[0].JUnit.Tests).To(Equal(7))
+			})
+
+			It("should reject a checkpoint of another release", func() {
+				resume := filepath.Join(GinkgoT().TempDir(), "checkpoint.json")
+				Expect(writeReleaseCheckpoint(resume, releaseCheckpoint{Components: []checkpointComponent{{
+					ComponentResult: release.ComponentResult{Image: "quay.io/example/other:v1", Kind: release.KindContainer, Status: certification.StatusPassed},
+				}}})).To(Succeed())
+
+				_, err := executeCommandWithLogger(checkReleaseCmd(), logr.Discard(), "--manifest", manifest, "--resume", resume)
+				Expect(err).To(MatchError(ContainSubstring("is not for this release")))
+			})
+
+			It("should reject a checkpoint that does not exist", func() {
+				_, err := executeCommandWithLogger(checkReleaseCmd(), logr.Discard(), "--manifest", manifest, "--resume", filepath.Join(GinkgoT().TempDir(), "checkpoint.json"))
+				Expect(err).To(MatchError(ContainSubstring("could not read release checkpoint")))
+			})
+		})
+	})
+
+	Context("when writing JUnit results for a release", func() {
+		It("should write a test suite per component, in order", func() {
+			report := release.Results{Components: []release.ComponentResult{
+				{Image: "quay.io/example/operand:v1", Kind: release.KindContainer, Status: certification.StatusPassed},
+				{Image: "quay.io/example/other:v1", Kind: release.KindContainer, Status: certification.StatusErrored, Error: "could not pull"},
+				{Image: "quay.io/example/bundle:v1", Kind: release.KindOperator, Status: release.StatusSkipped, Error: "dependency quay.io/example/other:v1 could not be checked"},
+			}}
+			suites := map[string]formatters.JUnitTestSuite{
+				"container-quay.io_example_operand_v1": {Name: "container quay.io/example/operand:v1", Tests: 3},
+			}
+
+			all := releaseJUnitSuites(report, suites)
+			Expect(all).To(HaveLen(3))
+
+			Expect(all[0].Name).To(Equal("container quay.io/example/operand:v1"))
+			Expect(all[0].Tests).To(Equal(3))
+			Expect(all[0].Properties).To(ContainElement(formatters.JUnitProperty{Name: "policy", Value: "container"}))
+
+			Expect(all[1].Name).To(Equal("container quay.io/example/other:v1"))
+			Expect(all[1].Errors).To(Equal(1))
+			Expect(all[1].TestCases).To(HaveLen(1))
+			Expect(all[1].TestCases[0].Error.Contents).To(Equal("could not pull"))
+
+			Expect(all[2].Name).To(Equal("operator quay.io/example/bundle:v1"))
+			Expect(all[2].Errors).To(BeZero())
+			Expect(all[2].TestCases[0].SkipMessage).ToNot(BeNil())
+			Expect(all[2].Properties).To(ContainElement(formatters.JUnitProperty{Name: "policy", Value: "operator"}))
+		})
+	})
+
+	Context("when naming component artifact directories", func() {
+		It("should only use characters that are safe in a path", func() {
+			Expect(componentDir(release.Component{Image: "quay.io/example/operand:v1", Kind: release.KindContainer})).
+				To(Equal("container-quay.io_example_operand_v1"))
+		})
+	})
+})
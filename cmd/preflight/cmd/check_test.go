@@ -1,82 +1,481 @@
 package cmd
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"time"
 
-	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/lib"
-	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/viper"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/artifacts"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/audit"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/cli"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/compat"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/incluster"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/oidc"
+	operatorpol "github.com/redhat-openshift-ecosystem/openshift-preflight/internal/policy/operator"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/pyxis"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/runtime"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/transport"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 var _ = Describe("cmd package check command", func() {
 	BeforeEach(createAndCleanupDirForArtifactsAndLogs)
 
-	Describe("Test Connect URL builders", func() {
+	Describe("Reading credentials from Vault", func() {
+		var cfg *runtime.Config
+
+		BeforeEach(func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/v1/secret/preflight" || r.Header.Get("X-Vault-Token") != "s.token" {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				_, _ = w.Write([]byte(`{"data": {"pyxis_api_token": "vaulttoken", "registry_username": "robot", "registry_password": "password"}}`))
+			}))
+			DeferCleanup(server.Close)
+
+			cfg = &runtime.Config{VaultAddress: server.URL, VaultToken: "s.token", VaultPath: "secret/preflight"}
+		})
+
+		It("should set the credentials that are not configured", func() {
+			Expect(withVaultCredentials(context.TODO(), cfg)).To(Succeed())
+			Expect(cfg.PyxisAPIToken).To(Equal("vaulttoken"))
+			Expect(cfg.RegistryUsername).To(Equal("robot"))
+			Expect(cfg.RegistryPassword).To(Equal("password"))
+		})
+
+		It("should not replace configured credentials", func() {
+			cfg.PyxisAPIToken = "configured"
+			cfg.RegistryToken = "configured"
+			Expect(withVaultCredentials(context.TODO(), cfg)).To(Succeed())
+			Expect(cfg.PyxisAPIToken).To(Equal("configured"))
+			Expect(cfg.RegistryUsername).To(BeEmpty())
+			Expect(cfg.RegistryPassword).To(BeEmpty())
+		})
+
+		It("should not contact Vault if no secret path is configured", func() {
+			cfg.VaultPath = ""
+			Expect(withVaultCredentials(context.TODO(), cfg)).To(Succeed())
+			Expect(cfg.PyxisAPIToken).To(BeEmpty())
+		})
+
+		It("should fail if the secret cannot be read", func() {
+			cfg.VaultToken = "s.wrong"
+			Expect(withVaultCredentials(context.TODO(), cfg)).ToNot(Succeed())
+		})
+	})
+
+	Describe("Exchanging an OIDC token for Pyxis access tokens", func() {
 		var (
-			projectID = "this-is-my-project-id"
-			imageID   = "my-image-id"
+			ctx context.Context
+			cfg *runtime.Config
 		)
+
+		BeforeEach(func() {
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := r.ParseForm(); err != nil || r.PostForm.Get("subject_token") != "oidc-token" {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				_, _ = w.Write([]byte(`{"access_token": "access-token", "token_type": "Bearer", "expires_in": 300}`))
+			}))
+			DeferCleanup(server.Close)
+
+			rootCAs := x509.NewCertPool()
+			rootCAs.AddCert(server.Certificate())
+			ctx = transport.ContextWithRootCAs(context.TODO(), rootCAs)
+
+			cfg = &runtime.Config{PyxisOIDCToken: "oidc-token", PyxisOIDCClientID: "preflight", PyxisOIDCTokenURL: server.URL}
+		})
+
+		It("should authenticate with access tokens", func() {
+			ctx, err := withPyxisAccessTokens(ctx, oidcConfig(cfg), "")
+			Expect(err).ToNot(HaveOccurred())
+
+			source, ok := pyxis.AccessTokenSourceFromContext(ctx)
+			Expect(ok).To(BeTrue())
+			Expect(source.AccessToken(ctx)).To(Equal("access-token"))
+		})
+
+		It("should not exchange a token that is not configured", func() {
+			ctx, err := withPyxisAccessTokens(ctx, oidc.Config{}, "token")
+			Expect(err).ToNot(HaveOccurred())
+
+			_, ok := pyxis.AccessTokenSourceFromContext(ctx)
+			Expect(ok).To(BeFalse())
+		})
+
+		It("should fail if a Pyxis API token is also configured", func() {
+			_, err := withPyxisAccessTokens(ctx, oidcConfig(cfg), "token")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should fail if the token cannot be exchanged", func() {
+			cfg.PyxisOIDCToken = "expired"
+			_, err := withPyxisAccessTokens(ctx, oidcConfig(cfg), "")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Reading credentials from Kubernetes Secrets", func() {
+		var cfg *runtime.Config
+
+		BeforeEach(func() {
+			client := fake.NewClientBuilder().WithObjects(
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "pyxis", Namespace: "ci"},
+					Data:       map[string][]byte{"token": []byte("secrettoken\n")},
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "registry", Namespace: "ci"},
+					Data:       map[string][]byte{incluster.DockerConfigKey: []byte(`{"auths": {}}`)},
+				},
+			).Build()
+
+			original := newSecretReader
+			newSecretReader = func() (*incluster.SecretReader, error) {
+				return incluster.NewSecretReaderForClient(client, "ci"), nil
+			}
+			DeferCleanup(func() { newSecretReader = original })
+
+			cfg = &runtime.Config{}
+		})
+
+		It("should set the Pyxis API token and docker config", func() {
+			cfg.PyxisTokenSecret = "pyxis"
+			cfg.DockerConfigSecret = "ci/registry"
+			cleanup, err := withSecretCredentials(context.TODO(), cfg)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cfg.PyxisAPIToken).To(Equal("secrettoken"))
+			Expect(cfg.DockerConfig).To(BeAnExistingFile())
+			Expect(os.ReadFile(cfg.DockerConfig)).To(Equal([]byte(`{"auths": {}}`)))
+
+			cleanup()
+			Expect(cfg.DockerConfig).ToNot(BeAnExistingFile())
+		})
+
+		It("should not read secrets that are not configured", func() {
+			_, err := withSecretCredentials(context.TODO(), cfg)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cfg.PyxisAPIToken).To(BeEmpty())
+			Expect(cfg.DockerConfig).To(BeEmpty())
+		})
+
+		It("should fail if the Pyxis API token is also configured", func() {
+			cfg.PyxisTokenSecret = "pyxis"
+			cfg.PyxisAPIToken = "configured"
+			_, err := withSecretCredentials(context.TODO(), cfg)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should fail if the docker config is also configured", func() {
+			cfg.DockerConfigSecret = "registry"
+			cfg.DockerConfig = "config.json"
+			_, err := withSecretCredentials(context.TODO(), cfg)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should fail if the secret does not contain the key", func() {
+			cfg.PyxisTokenSecret = "pyxis:other"
+			_, err := withSecretCredentials(context.TODO(), cfg)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Validating the lockfile", func() {
+		It("should accept a lockfile that is only verified", func() {
+			Expect(validateLockfile(&runtime.Config{Lockfile: "preflight.lock"})).To(Succeed())
+		})
+
+		It("should accept a lockfile that is updated", func() {
+			Expect(validateLockfile(&runtime.Config{Lockfile: "preflight.lock", UpdateLockfile: true})).To(Succeed())
+		})
+
+		It("should fail if the lockfile is updated, but not configured", func() {
+			Expect(validateLockfile(&runtime.Config{UpdateLockfile: true})).To(MatchError("--update-lockfile requires --lockfile"))
+		})
+	})
+
+	Describe("Validating the check timeouts", func() {
+		It("should accept timeouts of checks that exist", func() {
+			cfg := &runtime.Config{CheckTimeout: "10m", CheckTimeouts: []string{"DeployableByOLM=30m"}}
+			Expect(validateCheckTimeouts(context.TODO(), cfg)).To(Succeed())
+		})
+
+		It("should fail if a timeout is not a duration", func() {
+			cfg := &runtime.Config{CheckTimeout: "forever"}
+			Expect(validateCheckTimeouts(context.TODO(), cfg)).To(MatchError(ContainSubstring(`invalid check timeout "forever"`)))
+		})
+
+		It("should fail if a timeout is for a check that does not exist", func() {
+			cfg := &runtime.Config{CheckTimeouts: []string{"DeployableByOLMM=30m"}}
+			Expect(validateCheckTimeouts(context.TODO(), cfg)).To(MatchError(ContainSubstring(`unknown check "DeployableByOLMM"`)))
+		})
+	})
+
+	Describe("Validating the check egress", func() {
+		It("should accept denying every check access to the network", func() {
+			Expect(validateCheckEgress(context.TODO(), &runtime.Config{DenyCheckEgress: true})).To(Succeed())
+		})
+
+		It("should accept allowing checks that exist access to the network", func() {
+			cfg := &runtime.Config{DenyCheckEgress: true, AllowCheckEgress: []string{"HasUniqueTag", "BasedOnApprovedBaseImage"}}
+			Expect(validateCheckEgress(context.TODO(), cfg)).To(Succeed())
+		})
+
+		It("should fail if checks are allowed access to the network, but the others are not denied it", func() {
+			cfg := &runtime.Config{AllowCheckEgress: []string{"HasUniqueTag"}}
+			Expect(validateCheckEgress(context.TODO(), cfg)).To(MatchError("--allow-check-egress requires --deny-check-egress"))
+		})
+
+		It("should fail if a check that does not exist is allowed access to the network", func() {
+			cfg := &runtime.Config{DenyCheckEgress: true, AllowCheckEgress: []string{"HasUniqueTags"}}
+			Expect(validateCheckEgress(context.TODO(), cfg)).To(MatchError(ContainSubstring(`unknown check "HasUniqueTags"`)))
+		})
+	})
+
+	Describe("Validating the exit codes", func() {
+		It("should accept exit codes that a shell can report", func() {
+			Expect(validateExitCodes(&runtime.Config{ExitCodeFailed: 2, ExitCodeError: 3, ExitCodeWarning: 125})).To(Succeed())
+		})
+
+		It("should fail if an exit code is out of range", func() {
+			cfg := &runtime.Config{ExitCodeFailed: 256, ExitCodeError: 1}
+			Expect(validateExitCodes(cfg)).To(MatchError("--exit-code-failed must be between 0 and 125, not 256"))
+		})
+
+		It("should fail if errors would exit successfully", func() {
+			Expect(validateExitCodes(&runtime.Config{ExitCodeFailed: 2})).To(MatchError(ContainSubstring("--exit-code-error must not be 0")))
+		})
+	})
+
+	Describe("Pinning the behavior of a previous release", func() {
+		It("should not pin a version by default", func() {
+			Expect(compatVersion(&runtime.Config{ExitCodeError: DefaultExitCodeError})).To(BeEmpty())
+		})
+
+		It("should accept a supported version", func() {
+			Expect(compatVersion(&runtime.Config{Compat: "v1.5.2", ExitCodeError: DefaultExitCodeError})).To(Equal(compat.V1_5))
+		})
+
+		It("should fail if the version is not supported", func() {
+			_, err := compatVersion(&runtime.Config{Compat: "1.4", ExitCodeError: DefaultExitCodeError})
+			Expect(err).To(MatchError(compat.ErrUnsupportedVersion))
+		})
+
+		It("should fail if the exit codes are configured", func() {
+			_, err := compatVersion(&runtime.Config{Compat: "1.5", ExitCodeFailed: 2, ExitCodeError: DefaultExitCodeError})
+			Expect(err).To(MatchError(ContainSubstring("pins the exit codes")))
+		})
+
+		It("should fail if the platform is not an architecture", func() {
+			_, err := compatVersion(&runtime.Config{Compat: "1.5", ExitCodeError: DefaultExitCodeError, Platform: "linux/arm/v7"})
+			Expect(err).To(MatchError(ContainSubstring("only accepts an architecture")))
+		})
+
+		It("should fail if the artifacts are written to a bucket", func() {
+			_, err := compatVersion(&runtime.Config{Compat: "1.5", ExitCodeError: DefaultExitCodeError, Artifacts: "s3://bucket/artifacts"})
+			Expect(err).To(MatchError(ContainSubstring("only accepts a directory")))
+		})
+	})
+
+	Describe("Validating the scratch directory", func() {
+		It("should accept the default temporary directory", func() {
+			Expect(validateTmpdir(&runtime.Config{})).To(Succeed())
+		})
+
+		It("should accept an existing directory", func() {
+			Expect(validateTmpdir(&runtime.Config{Tmpdir: GinkgoT().TempDir()})).To(Succeed())
+		})
+
+		It("should fail if the directory does not exist", func() {
+			err := validateTmpdir(&runtime.Config{Tmpdir: filepath.Join(GinkgoT().TempDir(), "missing")})
+			Expect(err).To(MatchError(ContainSubstring("invalid scratch directory")))
+		})
+	})
+
+	Describe("Configuring the artifacts quota", func() {
+		It("should not limit artifacts if no quota is configured", func() {
+			quota, err := artifactsQuota("")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(quota).To(BeNil())
+
+			quota, err = artifactsQuota("0")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(quota).To(BeNil())
+		})
+
+		It("should parse a quantity", func() {
+			quota, err := artifactsQuota("500Mi")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(quota).ToNot(BeNil())
+		})
+
+		It("should fail if the quota is invalid", func() {
+			_, err := artifactsQuota("lots")
+			Expect(err).To(HaveOccurred())
+
+			_, err = artifactsQuota("-1Gi")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should keep results, truncate logs, and drop anything else", func() {
+			Expect(artifactPriority("results.json")).To(Equal(artifacts.PriorityEssential))
+			Expect(artifactPriority(cli.JUnitFilename)).To(Equal(artifacts.PriorityEssential))
+			Expect(artifactPriority(audit.PolicyManifestFilename)).To(Equal(artifacts.PriorityEssential))
+			Expect(artifactPriority(TruncatedArtifactsFilename)).To(Equal(artifacts.PriorityEssential))
+			Expect(artifactPriority(operatorpol.ResolvedImagesFilename)).To(Equal(artifacts.PriorityEssential))
+			Expect(artifactPriority("HasLicense-trace.log")).To(Equal(artifacts.PriorityLog))
+			Expect(artifactPriority("operator_bundle_scorecard_BasicSpecCheck.json")).To(Equal(artifacts.PriorityRaw))
+		})
+
+		It("should record what was truncated", func() {
+			quota := artifacts.NewQuota(10, artifactPriority)
+			fw, err := artifacts.NewFilesystemWriter(artifacts.WithDirectory(GinkgoT().TempDir()), artifacts.WithQuota(quota))
+			Expect(err).ToNot(HaveOccurred())
+			mw, err := artifacts.NewMapWriter()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(writeArtifactTruncations(context.TODO(), quota, mw)).To(Succeed())
+			Expect(mw.Files()).To(BeEmpty())
+
+			_, err = fw.WriteFile("scorecard.json", bytes.NewReader(make([]byte, 20)))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(writeArtifactTruncations(context.TODO(), quota, mw)).To(Succeed())
+			Expect(mw.Files()).To(HaveKey(TruncatedArtifactsFilename))
+		})
+	})
+
+	Describe("Writing artifacts to a bucket", func() {
+		It("should write artifacts to a directory that is not a bucket", func() {
+			dir, upload, err := artifactsDirectory("artifacts", "preflight.log")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(dir).To(Equal("artifacts"))
+			Expect(upload(context.TODO())).To(Succeed())
+		})
+
+		It("should fail if there are no credentials for the bucket", func() {
+			GinkgoT().Setenv("AWS_ACCESS_KEY_ID", "")
+			GinkgoT().Setenv("AWS_SECRET_ACCESS_KEY", "")
+			GinkgoT().Setenv("AWS_WEB_IDENTITY_TOKEN_FILE", "")
+			GinkgoT().Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(GinkgoT().TempDir(), "missing"))
+			GinkgoT().Setenv("AWS_EC2_METADATA_DISABLED", "true")
+			_, _, err := artifactsDirectory("s3://bucket/prefix", "preflight.log")
+			Expect(err).To(MatchError(ContainSubstring("s3 credentials are required")))
+		})
+
+		It("should fail if the storage account of the container is not configured", func() {
+			GinkgoT().Setenv("AZURE_STORAGE_CONNECTION_STRING", "")
+			GinkgoT().Setenv("AZURE_STORAGE_ACCOUNT", "")
+			_, _, err := artifactsDirectory("azblob://container/prefix", "preflight.log")
+			Expect(err).To(MatchError(ContainSubstring("storage account is required")))
+		})
+
+		It("should write artifacts to a temporary directory for a bucket", func() {
+			GinkgoT().Setenv("AWS_ACCESS_KEY_ID", "keyid")
+			GinkgoT().Setenv("AWS_SECRET_ACCESS_KEY", "secretkey")
+			dir, _, err := artifactsDirectory("s3://bucket/prefix", "preflight.log")
+			Expect(err).ToNot(HaveOccurred())
+			DeferCleanup(os.RemoveAll, dir)
+			Expect(dir).To(BeADirectory())
+		})
+
+		It("should upload the artifacts and the logfile", func() {
+			dir := GinkgoT().TempDir()
+			Expect(os.MkdirAll(filepath.Join(dir, "component"), 0o755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "results.json"), []byte("{}"), 0o644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "component", "results.json"), []byte("{}"), 0o644)).To(Succeed())
+			logfile := filepath.Join(GinkgoT().TempDir(), "preflight.log")
+			Expect(os.WriteFile(logfile, []byte("log"), 0o644)).To(Succeed())
+
+			mw, err := artifacts.NewMapWriter()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(uploadArtifacts(mw, dir, logfile)).To(Succeed())
+			Expect(mw.Files()).To(HaveKey("results.json"))
+			Expect(mw.Files()).To(HaveKey(filepath.Join("component", "results.json")))
+			Expect(mw.Files()).To(HaveKey("preflight.log"))
+		})
+	})
+
+	Describe("Archiving artifacts", func() {
+		var dir, logfile, junit string
+
 		BeforeEach(func() {
-			viper.Instance().SetEnvPrefix("pflt")
-			viper.Instance().AutomaticEnv()
-		})
-		AfterEach(func() {
-			os.Unsetenv("PFLT_PYXIS_ENV")
-			os.Unsetenv("PFLT_PYXIS_HOST")
-		})
-		Context("Regular Connect URL", func() {
-			It("should return a URL with just a project ID", func() {
-				expected := "https://connect.redhat.com/projects/this-is-my-project-id"
-				actual := lib.BuildConnectURL(projectID)
-				Expect(expected).To(Equal(actual))
-			})
-		})
-		Context("QA Connect URL", func() {
-			BeforeEach(func() {
-				os.Setenv("PFLT_PYXIS_ENV", "qa")
-			})
-			It("should return a URL for QA", func() {
-				expected := "https://connect.qa.redhat.com/projects/this-is-my-project-id"
-				actual := lib.BuildConnectURL(projectID)
-				Expect(expected).To(Equal(actual))
-			})
-		})
-		Context("UAT Scan Results URL", func() {
-			BeforeEach(func() {
-				os.Setenv("PFLT_PYXIS_ENV", "uat")
-			})
-			It("should return a URL for UAT", func() {
-				expected := "https://connect.uat.redhat.com/projects/this-is-my-project-id/images/my-image-id/scan-results"
-				actual := lib.BuildScanResultsURL(projectID, imageID)
-				Expect(expected).To(Equal(actual))
-			})
-		})
-		Context("QA Overview URL", func() {
-			BeforeEach(func() {
-				os.Setenv("PFLT_PYXIS_ENV", "qa")
-			})
-			It("should return a URL for QA", func() {
-				expected := "https://connect.qa.redhat.com/projects/this-is-my-project-id/overview"
-				actual := lib.BuildOverviewURL(projectID)
-				Expect(expected).To(Equal(actual))
-			})
-		})
-		Context("Override Pyxis Host", func() {
-			BeforeEach(func() {
-				os.Setenv("PFLT_PYXIS_HOST", "my.pyxis.host/some/path")
-			})
-			It("should return a Prod overview URL", func() {
-				expected := "https://connect.redhat.com/projects/this-is-my-project-id/overview"
-				actual := lib.BuildOverviewURL(projectID)
-				Expect(expected).To(Equal(actual))
-			})
-			It("should return a Prod scan URL", func() {
-				expected := "https://connect.redhat.com/projects/this-is-my-project-id/images/my-image-id/scan-results"
-				actual := lib.BuildScanResultsURL(projectID, imageID)
-				Expect(expected).To(Equal(actual))
-			})
+			dir = GinkgoT().TempDir()
+			Expect(os.MkdirAll(filepath.Join(dir, "component"), 0o755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "results.json"), []byte("{}"), 0o644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "component", "results.json"), []byte("{}"), 0o644)).To(Succeed())
+			logfile = filepath.Join(GinkgoT().TempDir(), "preflight.log")
+			Expect(os.WriteFile(logfile, []byte("log"), 0o644)).To(Succeed())
+			junit = filepath.Join(GinkgoT().TempDir(), "junit.xml")
+			Expect(os.WriteFile(junit, []byte("<testsuites/>"), 0o644)).To(Succeed())
+		})
+
+		archived := func(path string) map[string]string {
+			f, err := os.Open(path)
+			Expect(err).ToNot(HaveOccurred())
+			defer f.Close()
+			gr, err := gzip.NewReader(f)
+			Expect(err).ToNot(HaveOccurred())
+
+			files := map[string]string{}
+			tr := tar.NewReader(gr)
+			for {
+				hdr, err := tr.Next()
+				if errors.Is(err, io.EOF) {
+					return files
+				}
+				Expect(err).ToNot(HaveOccurred())
+				b, err := io.ReadAll(tr)
+				Expect(err).ToNot(HaveOccurred())
+				files[hdr.Name] = string(b)
+			}
+		}
+
+		It("should archive the artifacts, the log, and the JUnit report", func() {
+			archive := filepath.Join(GinkgoT().TempDir(), "out", "results.tar.gz")
+			Expect(writeArtifactArchive(context.TODO(), archive, dir, logfile, junit, filepath.Join(dir, "missing.xml"))).To(Succeed())
+			Expect(archived(archive)).To(Equal(map[string]string{
+				"results.json":           "{}",
+				"component/results.json": "{}",
+				"preflight.log":          "log",
+				"junit.xml":              "<testsuites/>",
+			}))
+		})
+
+		It("should produce the same archive from the same files", func() {
+			first := filepath.Join(GinkgoT().TempDir(), "first.tar.gz")
+			Expect(writeArtifactArchive(context.TODO(), first, dir, logfile)).To(Succeed())
+			Expect(os.Chtimes(logfile, time.Now(), time.Now().Add(time.Hour))).To(Succeed())
+			second := filepath.Join(GinkgoT().TempDir(), "second.tar.gz")
+			Expect(writeArtifactArchive(context.TODO(), second, dir, logfile)).To(Succeed())
+
+			contents, err := os.ReadFile(first)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(os.ReadFile(second)).To(Equal(contents))
+		})
+
+		It("should not archive the archive, or files in the artifacts directory twice", func() {
+			archive := filepath.Join(dir, "results.tar.gz")
+			Expect(writeArtifactArchive(context.TODO(), archive, dir, filepath.Join(dir, "component", "results.json"))).To(Succeed())
+			Expect(archived(archive)).To(Equal(map[string]string{
+				"results.json":           "{}",
+				"component/results.json": "{}",
+			}))
 		})
 	})
 })
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/config"
+
+	"github.com/spf13/cobra"
+	spfviper "github.com/spf13/viper"
+	"sigs.k8s.io/yaml"
+)
+
+// configCmd contains subcommands that work with the preflight configuration.
+func configCmd() *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Work with the preflight configuration",
+		Long:  "This command contains subcommands that operate on the configuration read from the config file and the environment.",
+	}
+
+	configCmd.AddCommand(configValidateCmd())
+
+	return configCmd
+}
+
+func configValidateCmd() *cobra.Command {
+	validateCmd := &cobra.Command{
+		Use:   "validate [config.yaml | URL]",
+		Short: "Validate the configuration and print the effective configuration",
+		Long: "Validate the configuration read from the config file, config.yaml in the working directory unless a path or URL is specified, " +
+			"and from PFLT_ environment variables against the configuration schema, reporting unknown keys and values of the wrong type. " +
+			"The effective configuration, with secrets masked, is written to stdout.",
+		Args:        cobra.MaximumNArgs(1),
+		RunE:        configValidateRunE,
+		Annotations: map[string]string{validatesConfigAnnotation: "true"},
+	}
+
+	return validateCmd
+}
+
+func configValidateRunE(cmd *cobra.Command, args []string) error {
+	var configFile string
+	if len(args) == 1 {
+		configFile = args[0]
+	}
+
+	cmd.SilenceUsage = true
+
+	// A new instance is used so that the config file being validated is not used by
+	// later commands.
+	v := spfviper.New()
+	for _, key := range []string{"logfile", "loglevel", "profile", "config", "config-ca-bundle", "config-client-cert", "config-client-key"} {
+		if flag := cmd.Flags().Lookup(key); flag != nil {
+			_ = v.BindPFlag(strings.ReplaceAll(key, "-", "_"), flag)
+		}
+	}
+	if err := loadConfig(cmd.Context(), v, configFile); err != nil {
+		var notFound spfviper.ConfigFileNotFoundError
+		if configFile != "" || v.GetString("config") != "" || !errors.As(err, &notFound) {
+			return fmt.Errorf("could not read config file: %w", err)
+		}
+	}
+	if err := applyProfile(v); err != nil {
+		return err
+	}
+
+	var problems []config.Problem
+	if path := v.ConfigFileUsed(); path != "" {
+		// Only the config file is read, so that its keys are not confused with
+		// those set in the environment or by defaults.
+		file := spfviper.New()
+		file.SetConfigFile(path)
+		if err := file.ReadInConfig(); err != nil {
+			return fmt.Errorf("could not read config file: %w", err)
+		}
+		problems = append(problems, config.ValidateSettings(path, file.AllSettings())...)
+	}
+	problems = append(problems, config.ValidateEnvironment(os.Environ())...)
+
+	effective, err := yaml.Marshal(config.Effective(v))
+	if err != nil {
+		return fmt.Errorf("could not encode the effective configuration: %w", err)
+	}
+	fmt.Fprint(cmd.OutOrStdout(), string(effective))
+
+	if len(problems) != 0 {
+		for _, p := range problems {
+			fmt.Fprintln(cmd.ErrOrStderr(), p)
+		}
+		if len(problems) == 1 {
+			return fmt.Errorf("the configuration is not valid: 1 problem was found")
+		}
+		return fmt.Errorf("the configuration is not valid: %d problems were found", len(problems))
+	}
+
+	return nil
+}
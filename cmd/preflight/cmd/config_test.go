@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("config validate command tests", func() {
+	var configFile string
+
+	BeforeEach(func() {
+		configFile = filepath.Join(GinkgoT().TempDir(), "config.yaml")
+	})
+
+	Context("with a valid config file", func() {
+		BeforeEach(func() {
+			Expect(os.WriteFile(configFile, []byte("dockerConfig: path/to/config.json\njunit: true\npyxis_api_token: my_nice_token\n"), 0o644)).To(Succeed())
+		})
+
+		It("should print the effective configuration with secrets masked", func() {
+			GinkgoT().Setenv("PFLT_CLUSTER_CHECK_ATTEMPTS", "3")
+			out, err := executeCommand(configCmd(), "validate", configFile)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("dockerConfig: path/to/config.json\n"))
+			Expect(out).To(ContainSubstring("junit: true\n"))
+			Expect(out).To(ContainSubstring("cluster_check_attempts: 3\n"))
+			Expect(out).To(ContainSubstring("namespace: " + DefaultNamespace + "\n"))
+			Expect(out).To(ContainSubstring("pyxis_api_token: <redacted>\n"))
+			Expect(out).ToNot(ContainSubstring("my_nice_token"))
+		})
+	})
+
+	Context("with an invalid config file", func() {
+		BeforeEach(func() {
+			Expect(os.WriteFile(configFile, []byte("sumbit: true\njunit: sometimes\n"), 0o644)).To(Succeed())
+		})
+
+		It("should report unknown keys and type errors", func() {
+			out, err := executeCommand(configCmd(), "validate", configFile)
+			Expect(err).To(MatchError(ContainSubstring("2 problems were found")))
+			Expect(out).To(ContainSubstring(configFile + ": junit: must be a boolean, not a string"))
+			Expect(out).To(ContainSubstring(configFile + ": sumbit: unknown key"))
+		})
+	})
+
+	Context("with a profile", func() {
+		BeforeEach(func() {
+			Expect(os.WriteFile(configFile, []byte(`
+pyxis_env: prod
+certification_project_id: prod-project
+profiles:
+  staging:
+    pyxis_env: qa
+    certification_project_id: staging-project
+    sumbit: true
+`), 0o644)).To(Succeed())
+		})
+
+		It("should print the settings of the profile", func() {
+			GinkgoT().Setenv("PFLT_PROFILE", "staging")
+			GinkgoT().Setenv("PFLT_CERTIFICATION_PROJECT_ID", "env-project")
+			out, err := executeCommand(configCmd(), "validate", configFile)
+			Expect(err).To(MatchError(ContainSubstring("1 problem was found")))
+			Expect(out).To(ContainSubstring("pyxis_env: qa\n"))
+			Expect(out).To(ContainSubstring("certification_project_id: env-project\n"))
+			Expect(out).To(ContainSubstring(configFile + ": profiles.staging.sumbit: unknown key"))
+		})
+
+		It("should throw an error if the profile is not defined", func() {
+			GinkgoT().Setenv("PFLT_PROFILE", "prod")
+			_, err := executeCommand(configCmd(), "validate", configFile)
+			Expect(err).To(MatchError(ContainSubstring("profile prod is not defined")))
+		})
+	})
+
+	Context("with an unknown environment variable", func() {
+		It("should report the variable", func() {
+			Expect(os.WriteFile(configFile, []byte("junit: true\n"), 0o644)).To(Succeed())
+			GinkgoT().Setenv("PFLT_SUBMT", "true")
+			out, err := executeCommand(configCmd(), "validate", configFile)
+			Expect(err).To(HaveOccurred())
+			Expect(out).To(ContainSubstring("environment: PFLT_SUBMT: unknown variable"))
+		})
+	})
+
+	Context("with a config file that does not exist", func() {
+		It("should throw an error", func() {
+			_, err := executeCommand(configCmd(), "validate", configFile)
+			Expect(err).To(MatchError(ContainSubstring("could not read config file")))
+		})
+	})
+})
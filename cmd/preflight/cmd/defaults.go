@@ -1,9 +1,12 @@
 package cmd
 
+import "github.com/redhat-openshift-ecosystem/openshift-preflight/internal/runtime"
+
 var (
-	DefaultLogFile           = "preflight.log"
-	DefaultLogLevel          = "info"
-	DefaultNamespace         = "default"
-	DefaultServiceAccount    = "default"
-	DefaultScorecardWaitTime = "240"
+	DefaultLogFile           = runtime.DefaultLogFile
+	DefaultLogLevel          = runtime.DefaultLogLevel
+	DefaultNamespace         = runtime.DefaultNamespace
+	DefaultServiceAccount    = runtime.DefaultServiceAccount
+	DefaultScorecardWaitTime = runtime.DefaultScorecardWaitTime
+	DefaultExitCodeError     = runtime.DefaultExitCodeError
 )
@@ -17,6 +17,8 @@ func experimentalCmd() *cobra.Command {
 		Hidden:  true,
 		Aliases: []string{"exp"},
 		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			cobra.CheckErr(configErr)
+
 			// The root command tries to create the prelfight log early
 			// even if we don't write to it. Set it to DevNull because
 			// experimental won't make use of it.
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	explainFormatText = "text"
+	explainFormatJSON = "json"
+)
+
+// checkExplanation is the explanation of a check written by the explain command.
+type checkExplanation struct {
+	Name        string   `json:"name"`
+	Policies    []string `json:"policies"`
+	Level       string   `json:"level"`
+	Description string   `json:"description"`
+	check.Explanation
+	check.Provenance
+	KnowledgeBaseURL string `json:"knowledge_base_url,omitempty"`
+	CheckURL         string `json:"check_url,omitempty"`
+}
+
+func explainCmd() *cobra.Command {
+	explainCmd := &cobra.Command{
+		Use:   "explain <check-name>",
+		Short: "Explain what a check evaluates, and how to remedy its failures",
+		Long: "Explain the purpose of a check, the exact criteria it evaluates, the common causes of its failures, and the steps " +
+			"to remedy them, so that a failure can be understood without reading the source of the check. The names of the " +
+			"checks are listed by the list-checks command, and are matched regardless of case.",
+		Args: cobra.ExactArgs(1),
+		// this fmt.Sprintf is in place to keep spacing consistent with cobras two spaces that's used in: Usage, Flags, etc
+		Example: fmt.Sprintf("  %s", "preflight explain HasNoProhibitedPackages --format json"),
+		RunE:    explainRunE,
+	}
+
+	explainCmd.Flags().String("format", explainFormatText, fmt.Sprintf("The format of the explanation, %s or %s.", explainFormatText, explainFormatJSON))
+
+	return explainCmd
+}
+
+func explainRunE(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	if format != explainFormatText && format != explainFormatJSON {
+		return fmt.Errorf("invalid format %s, expected %s or %s", format, explainFormatText, explainFormatJSON)
+	}
+
+	cmd.SilenceUsage = true
+
+	explanation, err := explainCheck(cmd.Context(), args[0])
+	if err != nil {
+		return err
+	}
+
+	if format == explainFormatText {
+		writeExplanation(cmd.OutOrStdout(), explanation)
+		return nil
+	}
+
+	b, err := json.MarshalIndent(explanation, "", "    ")
+	if err != nil {
+		return fmt.Errorf("could not format the explanation: %w", err)
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), string(b))
+
+	return nil
+}
+
+// explainCheck returns the explanation of the check named name, and the policies that
+// include it.
+func explainCheck(ctx context.Context, name string) (checkExplanation, error) {
+	checks, err := policyChecks(ctx)
+	if err != nil {
+		return checkExplanation{}, err
+	}
+
+	var found *policyCheck
+	for i := range checks {
+		if strings.EqualFold(checks[i].check.Name(), name) {
+			found = &checks[i]
+			break
+		}
+	}
+	if found == nil {
+		return checkExplanation{}, fmt.Errorf("unknown check %q: the available checks are listed by the list-checks command", name)
+	}
+
+	metadata := found.check.Metadata()
+	return checkExplanation{
+		Name:             found.check.Name(),
+		Policies:         found.policies,
+		Level:            metadata.Level,
+		Description:      metadata.Description,
+		Explanation:      check.Explain(found.check),
+		Provenance:       check.ProvenanceOf(found.check),
+		KnowledgeBaseURL: metadata.KnowledgeBaseURL,
+		CheckURL:         metadata.CheckURL,
+	}, nil
+}
+
+// writeExplanation writes e to w as text.
+func writeExplanation(w io.Writer, e checkExplanation) {
+	fmt.Fprintf(w, "%s\n\n", e.Name)
+	fmt.Fprintf(w, "Policies: %s\n", strings.Join(e.Policies, ", "))
+	fmt.Fprintf(w, "Level: %s\n", e.Level)
+	if len(e.DataSources) > 0 {
+		sources := make([]string, 0, len(e.DataSources))
+		for _, s := range e.DataSources {
+			sources = append(sources, string(s))
+		}
+		fmt.Fprintf(w, "Data Sources: %s\n", strings.Join(sources, ", "))
+	}
+	if e.Confidence != "" {
+		fmt.Fprintf(w, "Confidence: %s\n", e.Confidence)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "%s\n", e.Description)
+
+	writeExplanationSection(w, "Criteria", e.Criteria)
+	writeExplanationSection(w, "Common Causes of Failure", e.CommonCauses)
+	writeExplanationSection(w, "Remediation", e.Remediation)
+
+	if e.KnowledgeBaseURL != "" || e.CheckURL != "" {
+		fmt.Fprintln(w)
+	}
+	if e.KnowledgeBaseURL != "" {
+		fmt.Fprintf(w, "Knowledge Base: %s\n", e.KnowledgeBaseURL)
+	}
+	if e.CheckURL != "" {
+		fmt.Fprintf(w, "Check Documentation: %s\n", e.CheckURL)
+	}
+}
+
+// writeExplanationSection writes the titled section of items to w, unless there are no
+// items.
+func writeExplanationSection(w io.Writer, title string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "\n%s:\n%s", title, formatList(items))
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("explain", func() {
+	It("should explain a check as text, matching its name regardless of case", func() {
+		out, err := executeCommand(explainCmd(), "hasnoprohibitedpackages")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(HavePrefix("HasNoProhibitedPackages\n"))
+		Expect(out).To(ContainSubstring("Policies: container, root"))
+		Expect(out).To(ContainSubstring("Data Sources: filesystem\n"))
+		Expect(out).ToNot(ContainSubstring("Confidence:"))
+		Expect(out).To(ContainSubstring("Criteria:\n- "))
+		Expect(out).To(ContainSubstring("Common Causes of Failure:\n- "))
+		Expect(out).To(ContainSubstring("Remediation:\n- "))
+	})
+
+	It("should explain a check as JSON", func() {
+		out, err := executeCommand(explainCmd(), "RunAsNonRoot", "--format", "json")
+		Expect(err).ToNot(HaveOccurred())
+
+		var explanation map[string]interface{}
+		Expect(json.Unmarshal([]byte(out), &explanation)).To(Succeed())
+		Expect(explanation).To(HaveKeyWithValue("name", "RunAsNonRoot"))
+		Expect(explanation).To(HaveKeyWithValue("policies", ConsistOf("container", "scratch")))
+		Expect(explanation).To(HaveKey("criteria"))
+		Expect(explanation).To(HaveKey("common_causes"))
+		Expect(explanation).To(HaveKey("remediation"))
+		Expect(explanation).To(HaveKeyWithValue("data_sources", ConsistOf("manifest")))
+		Expect(explanation).To(HaveKeyWithValue("confidence", "medium"))
+	})
+
+	It("should explain the checks that do not detail their criteria from their description", func() {
+		out, err := executeCommand(explainCmd(), "SecurityContextConstraintsInCSV", "--format", "json")
+		Expect(err).ToNot(HaveOccurred())
+
+		var explanation map[string]interface{}
+		Expect(json.Unmarshal([]byte(out), &explanation)).To(Succeed())
+		Expect(explanation).To(HaveKeyWithValue("criteria", ConsistOf(explanation["description"])))
+		Expect(explanation).ToNot(HaveKey("common_causes"))
+	})
+
+	It("should fail for an unknown check", func() {
+		_, err := executeCommand(explainCmd(), "NotACheck")
+		Expect(err).To(MatchError(ContainSubstring(`unknown check "NotACheck"`)))
+	})
+
+	It("should fail for an unknown format", func() {
+		_, err := executeCommand(explainCmd(), "RunAsNonRoot", "--format", "yaml")
+		Expect(err).To(MatchError(ContainSubstring("invalid format yaml")))
+	})
+})
@@ -2,29 +2,174 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"strings"
 
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/engine"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/policy"
 
 	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
 )
 
+const (
+	listChecksFormatText = "text"
+	listChecksFormatJSON = "json"
+	listChecksFormatYAML = "yaml"
+)
+
+// Image types the checks of a policy apply to.
+const (
+	imageTypeContainer      = "container"
+	imageTypeOperatorBundle = "operator-bundle"
+)
+
+// listedPolicies are the policies whose checks are listed, in order.
+var listedPolicies = []policy.Policy{policy.PolicyOperator, policy.PolicyContainer, policy.PolicyRoot, policy.PolicyScratch, policy.PolicyWindows}
+
+// checkList is the structured output of list-checks.
+type checkList struct {
+	Checks []listedCheck `json:"checks"`
+}
+
+// listedCheck describes a check in the structured output of list-checks.
+type listedCheck struct {
+	Name             string   `json:"name"`
+	Description      string   `json:"description"`
+	Level            string   `json:"level"`
+	Policies         []string `json:"policies"`
+	ImageTypes       []string `json:"image_types"`
+	VersionAdded     string   `json:"version_added"`
+	KnowledgeBaseURL string   `json:"knowledge_base_url,omitempty"`
+	CheckURL         string   `json:"check_url,omitempty"`
+}
+
+// policyCheck is a check, and the policies that include it.
+type policyCheck struct {
+	check    check.Check
+	policies []policy.Policy
+}
+
 func listChecksCmd() *cobra.Command {
 	listChecksCmd := &cobra.Command{
 		Use:   "list-checks",
 		Short: "List all checks that will be executed for each policy",
-		Long:  "This command will list all checks that preflight uses against an asset by policy type",
-		Run:   listChecksRunFunc,
+		Long: "This command will list all checks that preflight uses against an asset by policy type. As JSON or YAML, each check\n" +
+			"is listed once, with the policies that include it, its level, the types of images it applies to, and the version of\n" +
+			"preflight that added it.",
+		Args: cobra.NoArgs,
+		RunE: listChecksRunE,
 	}
+
+	listChecksCmd.Flags().String("format", listChecksFormatText, fmt.Sprintf("The format of the list, %s, %s, or %s.",
+		listChecksFormatText, listChecksFormatJSON, listChecksFormatYAML))
+
 	return listChecksCmd
 }
 
-// listChecksRunFunc binds printChecks to cobra's Run function
-// definition, passing the cobra command's output as an io.Writer.
-func listChecksRunFunc(cmd *cobra.Command, args []string) {
-	printChecks(cmd.OutOrStdout())
+// listChecksRunE writes the checks of each policy to the cobra command's output in
+// the requested format.
+func listChecksRunE(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	switch format {
+	case listChecksFormatText:
+		printChecks(cmd.OutOrStdout())
+		return nil
+	case listChecksFormatJSON, listChecksFormatYAML:
+	default:
+		return fmt.Errorf("invalid format %s, expected %s, %s, or %s", format, listChecksFormatText, listChecksFormatJSON, listChecksFormatYAML)
+	}
+
+	cmd.SilenceUsage = true
+
+	list, err := listChecks(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	var b []byte
+	if format == listChecksFormatJSON {
+		b, err = json.MarshalIndent(list, "", "    ")
+		b = append(b, '\n')
+	} else {
+		b, err = yaml.Marshal(list)
+	}
+	if err != nil {
+		return fmt.Errorf("could not format the checks: %w", err)
+	}
+	_, err = cmd.OutOrStdout().Write(b)
+
+	return err
+}
+
+// listChecks returns the checks of every policy.
+func listChecks(ctx context.Context) (checkList, error) {
+	checks, err := policyChecks(ctx)
+	if err != nil {
+		return checkList{}, err
+	}
+
+	list := checkList{Checks: make([]listedCheck, 0, len(checks))}
+	for _, pc := range checks {
+		metadata := pc.check.Metadata()
+		listed := listedCheck{
+			Name:             pc.check.Name(),
+			Description:      metadata.Description,
+			Level:            metadata.Level,
+			Policies:         pc.policies,
+			ImageTypes:       []string{},
+			VersionAdded:     engine.VersionAdded(pc.check.Name()),
+			KnowledgeBaseURL: metadata.KnowledgeBaseURL,
+			CheckURL:         metadata.CheckURL,
+		}
+		for _, t := range []string{imageTypeContainer, imageTypeOperatorBundle} {
+			for _, p := range pc.policies {
+				if policyImageType(p) == t {
+					listed.ImageTypes = append(listed.ImageTypes, t)
+					break
+				}
+			}
+		}
+		list.Checks = append(list.Checks, listed)
+	}
+
+	return list, nil
+}
+
+// policyChecks returns each check of the listed policies once, in the order they are
+// listed, with the policies that include it.
+func policyChecks(ctx context.Context) ([]policyCheck, error) {
+	var checks []policyCheck
+	byName := map[string]int{}
+	for _, p := range listedPolicies {
+		policyChecks, err := engine.PolicyChecks(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range policyChecks {
+			i, ok := byName[c.Name()]
+			if !ok {
+				i = len(checks)
+				byName[c.Name()] = i
+				checks = append(checks, policyCheck{check: c})
+			}
+			checks[i].policies = append(checks[i].policies, p)
+		}
+	}
+
+	return checks, nil
+}
+
+// policyImageType returns the type of images the checks of policy p apply to.
+func policyImageType(p policy.Policy) string {
+	if p == policy.PolicyOperator {
+		return imageTypeOperatorBundle
+	}
+
+	return imageTypeContainer
 }
 
 // printChecks writes the formatted check list output to w.
@@ -36,6 +181,8 @@ func printChecks(w io.Writer) {
 		"automatically applied for container images if preflight determines a root exception flag has been added to your Red Hat Connect project"))
 	fmt.Fprintln(w, formattedPolicyBlock("Container Scratch Exception", engine.ScratchContainerPolicy(context.TODO()),
 		"automatically applied for container checks if preflight determines a scratch exception flag has been added to your Red Hat Connect project"))
+	fmt.Fprintln(w, formattedPolicyBlock("Windows Container", engine.WindowsContainerPolicy(context.TODO()),
+		"applied instead of the container policy for Windows images, checked with a Windows platform, e.g. --platform windows/amd64"))
 }
 
 // formattedPolicyBlock accepts information about the checklist
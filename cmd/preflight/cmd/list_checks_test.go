@@ -2,12 +2,14 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"strings"
 
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/engine"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"sigs.k8s.io/yaml"
 )
 
 var _ = Describe("list checks subcommand", func() {
@@ -64,6 +66,14 @@ var _ = Describe("list checks subcommand", func() {
 			Expect(buf.String()).To(ContainSubstring(expected))
 		})
 
+		It("should always contain the windows container policy", func() {
+			expected := formatList(engine.WindowsContainerPolicy(context.TODO()))
+			buf := strings.Builder{}
+			printChecks(&buf)
+
+			Expect(buf.String()).To(ContainSubstring(expected))
+		})
+
 		It("should always contain the scratch exception policy", func() {
 			expected := formatList(engine.ScratchContainerPolicy(context.TODO()))
 			buf := strings.Builder{}
@@ -89,5 +99,43 @@ var _ = Describe("list checks subcommand", func() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(out).To(ContainSubstring(expected))
 		})
+
+		It("should list each check once as JSON, with its policies, image types, and the version that added it", func() {
+			out, err := executeCommand(listChecksCmd(), "--format", "json")
+			Expect(err).ToNot(HaveOccurred())
+
+			var list checkList
+			Expect(json.Unmarshal([]byte(out), &list)).To(Succeed())
+
+			names := make([]string, 0, len(list.Checks))
+			byName := map[string]listedCheck{}
+			for _, c := range list.Checks {
+				names = append(names, c.Name)
+				byName[c.Name] = c
+			}
+			Expect(names).To(ConsistOf(append(engine.OperatorPolicy(context.TODO()), engine.ContainerPolicy(context.TODO())...)))
+
+			Expect(byName["HasLicense"].Policies).To(Equal([]string{"container", "root", "scratch", "windows"}))
+			Expect(byName["HasLicense"].ImageTypes).To(Equal([]string{"container"}))
+			Expect(byName["HasLicense"].Level).To(Equal("best"))
+			Expect(byName["HasLicense"].VersionAdded).To(Equal("1.0.0"))
+			Expect(byName["DeployableByOLM"].Policies).To(Equal([]string{"operator"}))
+			Expect(byName["DeployableByOLM"].ImageTypes).To(Equal([]string{"operator-bundle"}))
+		})
+
+		It("should list the checks as YAML", func() {
+			out, err := executeCommand(listChecksCmd(), "--format", "yaml")
+			Expect(err).ToNot(HaveOccurred())
+
+			var list checkList
+			Expect(yaml.Unmarshal([]byte(out), &list)).To(Succeed())
+			Expect(list.Checks).ToNot(BeEmpty())
+			Expect(list.Checks[0].VersionAdded).ToNot(BeEmpty())
+		})
+
+		It("should reject an unknown format", func() {
+			_, err := executeCommand(listChecksCmd(), "--format", "xml")
+			Expect(err).To(MatchError(ContainSubstring("invalid format xml")))
+		})
 	})
 })
@@ -0,0 +1,18 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// policyCmd contains subcommands that work with the policies checks are grouped in.
+func policyCmd() *cobra.Command {
+	policyCmd := &cobra.Command{
+		Use:   "policy",
+		Short: "Work with preflight policies",
+		Long:  "This command contains subcommands that operate on the policies that group the checks preflight executes.",
+	}
+
+	policyCmd.AddCommand(policyExportCmd())
+
+	return policyCmd
+}
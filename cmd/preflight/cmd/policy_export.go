@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/admission"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/engine"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/policy"
+
+	"github.com/spf13/cobra"
+)
+
+func policyExportCmd() *cobra.Command {
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export container checks as Kyverno or Gatekeeper admission policies",
+		Long: "Export the container checks that can be evaluated at admission as Kyverno or Gatekeeper policies, so that clusters " +
+			"can enforce the same rules as the checks. Kyverno policies evaluate the config and manifest of the images of pods, " +
+			"while Gatekeeper policies can only evaluate the pods themselves. The policies are written to stdout unless --output is specified.",
+		Args: cobra.NoArgs,
+		RunE: policyExportRunE,
+	}
+
+	flags := exportCmd.Flags()
+	flags.String("format", admission.FormatKyverno, fmt.Sprintf("The admission controller to export the policies for: %s or %s.", admission.FormatKyverno, admission.FormatGatekeeper))
+	flags.String("policy", policy.PolicyContainer, fmt.Sprintf("The container policy whose checks are exported: %s, or its %s or %s exception.",
+		policy.PolicyContainer, policy.PolicyRoot, policy.PolicyScratch))
+	flags.String("name", "preflight", "The name of the exported policies.")
+	flags.Bool("audit", false, "Only report the pods that violate the policies, rather than denying their admission.")
+	flags.StringP("output", "o", "", "Where the policies will be written. Defaults to stdout.")
+
+	return exportCmd
+}
+
+func policyExportRunE(cmd *cobra.Command, args []string) error {
+	flags := cmd.Flags()
+	format, _ := flags.GetString("format")
+	pol, _ := flags.GetString("policy")
+	name, _ := flags.GetString("name")
+	audit, _ := flags.GetBool("audit")
+	output, _ := flags.GetString("output")
+
+	switch pol {
+	case policy.PolicyContainer, policy.PolicyRoot, policy.PolicyScratch:
+	default:
+		return fmt.Errorf("unknown container policy %q: must be %s, %s, or %s", pol, policy.PolicyContainer, policy.PolicyRoot, policy.PolicyScratch)
+	}
+
+	cmd.SilenceUsage = true
+
+	checks, err := engine.PolicyChecks(cmd.Context(), pol)
+	if err != nil {
+		return err
+	}
+
+	opts := []admission.Option{admission.WithName(name)}
+	if audit {
+		opts = append(opts, admission.WithAudit())
+	}
+	bundle, err := admission.Export(format, checks, opts...)
+	if err != nil {
+		return err
+	}
+	if len(bundle.Exported) == 0 {
+		return fmt.Errorf("none of the checks of the %s policy can be exported as %s policies", pol, format)
+	}
+
+	if len(bundle.Unsupported) > 0 {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Not exported, as they cannot be evaluated at admission by %s: %s\n", format, strings.Join(bundle.Unsupported, ", "))
+	}
+
+	if output == "" {
+		fmt.Fprint(cmd.OutOrStdout(), string(bundle.Manifests))
+		return nil
+	}
+
+	if err := os.WriteFile(output, bundle.Manifests, 0o644); err != nil {
+		return fmt.Errorf("could not write the policies: %w", err)
+	}
+
+	return nil
+}
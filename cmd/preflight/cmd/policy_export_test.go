@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("policy export command tests", func() {
+	It("should write Kyverno policies to stdout, and list the checks that are not exported", func() {
+		out, err := executeCommand(policyExportCmd())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(ContainSubstring("kind: ClusterPolicy"))
+		Expect(out).To(ContainSubstring("name: run-as-non-root"))
+		Expect(out).To(ContainSubstring("Not exported, as they cannot be evaluated at admission by kyverno: HasLicense"))
+	})
+
+	It("should write Gatekeeper policies to the output file", func() {
+		output := filepath.Join(GinkgoT().TempDir(), "policies.yaml")
+		_, err := executeCommand(policyExportCmd(), "--format", "gatekeeper", "--audit", "--output", output)
+		Expect(err).ToNot(HaveOccurred())
+		contents, err := os.ReadFile(output)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).To(ContainSubstring("kind: ConstraintTemplate"))
+		Expect(string(contents)).To(ContainSubstring("enforcementAction: dryrun"))
+	})
+
+	It("should not export the RunAsNonRoot check for the root exception", func() {
+		out, err := executeCommand(policyExportCmd(), "--policy", "root")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).ToNot(ContainSubstring("run-as-non-root"))
+		Expect(out).To(ContainSubstring("name: has-required-label"))
+	})
+
+	It("should fail when no check can be exported", func() {
+		_, err := executeCommand(policyExportCmd(), "--format", "gatekeeper", "--policy", "root")
+		Expect(err).To(MatchError(ContainSubstring("none of the checks of the root policy")))
+	})
+
+	It("should reject an unknown policy", func() {
+		_, err := executeCommand(policyExportCmd(), "--policy", "operator")
+		Expect(err).To(MatchError(ContainSubstring("unknown container policy")))
+	})
+
+	It("should reject an unknown format", func() {
+		_, err := executeCommand(policyExportCmd(), "--format", "opa")
+		Expect(err).To(MatchError(ContainSubstring("unknown admission policy format")))
+	})
+})
@@ -0,0 +1,19 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// resultsCmd contains subcommands that work with existing preflight results.
+func resultsCmd() *cobra.Command {
+	resultsCmd := &cobra.Command{
+		Use:   "results",
+		Short: "Work with preflight results",
+		Long:  "This command contains subcommands that operate on results files written by previous preflight executions.",
+	}
+
+	resultsCmd.AddCommand(resultsRedactCmd())
+	resultsCmd.AddCommand(resultsEvaluateCmd())
+
+	return resultsCmd
+}
@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/artifacts"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/certification"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/audit"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/cli"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/engine"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/formatters"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/policy"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/runtime"
+
+	"github.com/go-logr/logr"
+	"github.com/spf13/cobra"
+)
+
+// evaluatedDirName is the directory, alongside the results being evaluated, where the
+// evaluated results are written by default.
+const evaluatedDirName = "evaluated"
+
+func resultsEvaluateCmd() *cobra.Command {
+	evaluateCmd := &cobra.Command{
+		Use:   "evaluate <results.json>",
+		Short: "Evaluate previous results under the current policy",
+		Long: "Evaluate the results of a previous execution under the policy of this version of preflight, without checking the image again,\n" +
+			"to determine whether the image would pass today. The outcome of each check is the one recorded. Checks that the policy no longer\n" +
+			"enforces are dropped, and checks that it now enforces, but that were not executed, are reported as errors. The results are\n" +
+			"written, and reports rendered, to the output directory.",
+		Args: cobra.ExactArgs(1),
+		// this fmt.Sprintf is in place to keep spacing consistent with cobras two spaces that's used in: Usage, Flags, etc
+		Example: fmt.Sprintf("  %s", "preflight results evaluate artifacts/results.json --checklist"),
+		RunE:    resultsEvaluateRunE,
+	}
+
+	flags := evaluateCmd.Flags()
+	flags.String("policy-manifest", "", fmt.Sprintf("Path to the %s written by the previous execution. Defaults to the one alongside the results.", audit.PolicyManifestFilename))
+	flags.String("policy", "", fmt.Sprintf("The policy the results were evaluated under, if there is no policy manifest. One of %s, %s, %s, %s, or %s.",
+		policy.PolicyContainer, policy.PolicyRoot, policy.PolicyScratch, policy.PolicyWindows, policy.PolicyOperator))
+	evaluateCmd.MarkFlagsMutuallyExclusive("policy-manifest", "policy")
+	flags.StringP("output-dir", "o", "", fmt.Sprintf("Where the evaluated results will be written. Defaults to %s/ alongside the results.", evaluatedDirName))
+	flags.Bool("junit", false, "Also write the evaluated results as JUnit XML to the output directory.")
+	flags.Bool("checklist", false, fmt.Sprintf("Also write the certification checklist to %s in the output directory.", cli.ChecklistFilename))
+
+	return evaluateCmd
+}
+
+func resultsEvaluateRunE(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	logger := logr.FromContextOrDiscard(ctx)
+
+	flags := cmd.Flags()
+	manifestPath, _ := flags.GetString("policy-manifest")
+	pol, _ := flags.GetString("policy")
+	outputDir, _ := flags.GetString("output-dir")
+	junit, _ := flags.GetBool("junit")
+	checklist, _ := flags.GetBool("checklist")
+
+	resultsPath := args[0]
+	results, err := audit.LoadResults(resultsPath)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := resolvePolicyManifest(resultsPath, manifestPath, pol)
+	if err != nil {
+		return err
+	}
+
+	current, err := engine.PolicyChecks(ctx, manifest.Policy)
+	if err != nil {
+		return err
+	}
+
+	if outputDir == "" {
+		outputDir = filepath.Join(filepath.Dir(resultsPath), evaluatedDirName)
+	}
+	if sameDir(outputDir, filepath.Dir(resultsPath)) {
+		return fmt.Errorf("the output directory must not be the directory of the results being evaluated")
+	}
+
+	artifactsWriter, err := artifacts.NewFilesystemWriter(artifacts.WithDirectory(outputDir))
+	if err != nil {
+		return err
+	}
+	ctx = artifacts.ContextWithWriter(ctx, artifactsWriter)
+
+	formatter, err := formatters.NewByName(formatters.DefaultFormat)
+	if err != nil {
+		return err
+	}
+
+	cmd.SilenceUsage = true
+
+	evaluated, changes := audit.Reevaluate(results, manifest, current)
+	logger.Info("evaluating results under the current policy", "image", results.Image, "policy", manifest.Policy,
+		"evaluatedBy", manifest.LibraryInfo.Version, "results", resultsPath)
+	for _, name := range changes.Added {
+		logger.Info("check is enforced by the current policy, but was not by the previous execution", "check", name)
+	}
+	for _, name := range changes.Removed {
+		logger.Info("check is no longer enforced, and its outcome does not count", "check", name)
+	}
+	for _, name := range changes.NotEvaluated {
+		logger.Info("check was not evaluated by the previous execution, and is reported as an error", "check", name)
+	}
+
+	return cli.RunPreflight(
+		ctx,
+		func(context.Context) (certification.Results, error) {
+			return evaluated, nil
+		},
+		cli.CheckConfig{
+			IncludeJUnitResults: junit,
+			IncludeChecklist:    checklist,
+		},
+		formatter,
+		&runtime.ResultWriterFile{},
+		nil,
+	)
+}
+
+// resolvePolicyManifest returns the policy manifest at manifestPath, or alongside the
+// results at resultsPath if it is empty. If pol is set, the policy is assumed instead.
+func resolvePolicyManifest(resultsPath, manifestPath, pol string) (audit.PolicyManifest, error) {
+	if pol != "" {
+		switch pol {
+		case policy.PolicyContainer, policy.PolicyRoot, policy.PolicyScratch, policy.PolicyWindows, policy.PolicyOperator:
+			return audit.PolicyManifest{Policy: pol}, nil
+		}
+		return audit.PolicyManifest{}, fmt.Errorf("provided policy %s is unknown", pol)
+	}
+
+	explicit := manifestPath != ""
+	if !explicit {
+		manifestPath = filepath.Join(filepath.Dir(resultsPath), audit.PolicyManifestFilename)
+	}
+
+	manifest, err := audit.LoadPolicyManifest(manifestPath)
+	if !explicit && errors.Is(err, fs.ErrNotExist) {
+		return audit.PolicyManifest{}, fmt.Errorf("%s was not found alongside the results, pass --policy-manifest or --policy", audit.PolicyManifestFilename)
+	}
+
+	return manifest, err
+}
+
+// sameDir returns true if a and b refer to the same directory.
+func sameDir(a, b string) bool {
+	aInfo, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	bInfo, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+
+	return os.SameFile(aInfo, bInfo)
+}
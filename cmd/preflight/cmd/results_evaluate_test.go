@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/audit"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/engine"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/formatters"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/policy"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("results evaluate command tests", func() {
+	var resultsDir string
+
+	writeResults := func(passed ...string) {
+		checks := make([]map[string]string, 0, len(passed))
+		for _, name := range passed {
+			checks = append(checks, map[string]string{"name": name})
+		}
+		results, err := json.Marshal(map[string]interface{}{
+			"image":   "quay.io/example/image:1",
+			"passed":  true,
+			"results": map[string]interface{}{"passed": checks},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.WriteFile(filepath.Join(resultsDir, "results.json"), results, 0o644)).To(Succeed())
+	}
+
+	writeManifest := func() {
+		checks, err := engine.PolicyChecks(context.TODO(), policy.PolicyContainer)
+		Expect(err).ToNot(HaveOccurred())
+		manifest, err := json.Marshal(audit.NewPolicyManifest(policy.PolicyContainer, checks))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.WriteFile(filepath.Join(resultsDir, audit.PolicyManifestFilename), manifest, 0o644)).To(Succeed())
+	}
+
+	readEvaluated := func() formatters.UserResponse {
+		contents, err := os.ReadFile(filepath.Join(resultsDir, evaluatedDirName, "results.json"))
+		Expect(err).ToNot(HaveOccurred())
+		var evaluated formatters.UserResponse
+		Expect(json.Unmarshal(contents, &evaluated)).To(Succeed())
+		return evaluated
+	}
+
+	BeforeEach(func() {
+		createAndCleanupDirForArtifactsAndLogs()
+
+		var err error
+		resultsDir, err = os.MkdirTemp("", "results-evaluate-*")
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(os.RemoveAll, resultsDir)
+	})
+
+	Context("with results that passed every check in the current policy", func() {
+		It("should write passing results", func() {
+			writeResults(engine.ContainerPolicy(context.TODO())...)
+			writeManifest()
+
+			_, err := executeCommand(resultsEvaluateCmd(), filepath.Join(resultsDir, "results.json"), "--checklist")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(readEvaluated().Passed).To(BeTrue())
+			Expect(filepath.Join(resultsDir, evaluatedDirName, "checklist.md")).To(BeAnExistingFile())
+		})
+	})
+
+	Context("with results that did not execute a check in the current policy", func() {
+		It("should write failing results", func() {
+			writeResults(engine.ContainerPolicy(context.TODO())[1:]...)
+			writeManifest()
+
+			_, err := executeCommand(resultsEvaluateCmd(), filepath.Join(resultsDir, "results.json"))
+			Expect(err).ToNot(HaveOccurred())
+			evaluated := readEvaluated()
+			Expect(evaluated.Passed).To(BeFalse())
+			Expect(evaluated.Results.Errors).To(HaveLen(1))
+		})
+	})
+
+	Context("without a policy manifest", func() {
+		BeforeEach(func() {
+			writeResults(engine.ScratchContainerPolicy(context.TODO())...)
+		})
+
+		It("should throw an error", func() {
+			_, err := executeCommand(resultsEvaluateCmd(), filepath.Join(resultsDir, "results.json"))
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should evaluate the results under the policy that is passed", func() {
+			_, err := executeCommand(resultsEvaluateCmd(), filepath.Join(resultsDir, "results.json"), "--policy", policy.PolicyScratch)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(readEvaluated().Passed).To(BeTrue())
+		})
+
+		It("should throw an error for an unknown policy", func() {
+			_, err := executeCommand(resultsEvaluateCmd(), filepath.Join(resultsDir, "results.json"), "--policy", "unknown")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("with the results directory as the output directory", func() {
+		It("should throw an error", func() {
+			writeResults(engine.ContainerPolicy(context.TODO())...)
+			writeManifest()
+
+			_, err := executeCommand(resultsEvaluateCmd(), filepath.Join(resultsDir, "results.json"), "--output-dir", resultsDir)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/redact"
+
+	"github.com/spf13/cobra"
+)
+
+func resultsRedactCmd() *cobra.Command {
+	redactCmd := &cobra.Command{
+		Use:   "redact <results.json>",
+		Short: "Remove identifying details from a results file",
+		Long: "Remove registry hostnames, certification project IDs, and local filesystem paths from a results file, " +
+			"so that it can be shared publicly, e.g. in forums or GitHub issues. The redacted results are written to stdout unless --output is specified.",
+		Args: cobra.ExactArgs(1),
+		RunE: resultsRedactRunE,
+	}
+
+	flags := redactCmd.Flags()
+	flags.StringP("output", "o", "", "Where the redacted results will be written. Defaults to stdout.")
+	flags.StringSlice("hostname", nil, "Additional hostnames to redact. The registry of the tested image is always redacted.")
+	flags.StringArray("path-prefix", nil, "Additional filesystem path prefixes to redact. Paths within home directories are always redacted. May be repeated.")
+	flags.StringArray("pattern", nil, "Additional regular expressions whose matches will be redacted. May be repeated.")
+
+	return redactCmd
+}
+
+func resultsRedactRunE(cmd *cobra.Command, args []string) error {
+	flags := cmd.Flags()
+	hostnames, _ := flags.GetStringSlice("hostname")
+	pathPrefixes, _ := flags.GetStringArray("path-prefix")
+	patterns, _ := flags.GetStringArray("pattern")
+	output, _ := flags.GetString("output")
+
+	opts := []redact.Option{
+		redact.WithHostnames(hostnames...),
+		redact.WithPathPrefixes(pathPrefixes...),
+	}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", p, err)
+		}
+		opts = append(opts, redact.WithPattern(re, "<redacted>"))
+	}
+
+	contents, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("could not read results file: %w", err)
+	}
+
+	cmd.SilenceUsage = true
+
+	redacted, err := redact.New(opts...).JSON(contents)
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		fmt.Fprintln(cmd.OutOrStdout(), string(redacted))
+		return nil
+	}
+
+	if err := os.WriteFile(output, append(redacted, '\n'), 0o644); err != nil {
+		return fmt.Errorf("could not write redacted results: %w", err)
+	}
+
+	return nil
+}
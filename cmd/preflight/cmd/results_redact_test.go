@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("results redact command tests", func() {
+	var resultsFile string
+
+	BeforeEach(func() {
+		createAndCleanupDirForArtifactsAndLogs()
+
+		tmpDir, err := os.MkdirTemp("", "results-redact-*")
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(os.RemoveAll, tmpDir)
+
+		resultsFile = filepath.Join(tmpDir, "results.json")
+		Expect(os.WriteFile(resultsFile, []byte(`{
+			"image": "quay.internal.corp/team/app:1.0",
+			"passed": false,
+			"results": {"failed": [{"name": "HasLicense", "help": "see /srv/ci/builds/42/artifacts for project 0123456789abcdef01234567"}]}
+		}`), 0o644)).To(Succeed())
+	})
+
+	Context("with a valid results file", func() {
+		It("should write redacted results to stdout", func() {
+			out, err := executeCommand(resultsRedactCmd(), resultsFile, "--path-prefix", "/srv/ci")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("registry.example.com/team/app:1.0"))
+			Expect(out).To(ContainSubstring("<path>/builds/42/artifacts"))
+			Expect(out).To(ContainSubstring("<project-id>"))
+			Expect(out).ToNot(ContainSubstring("quay.internal.corp"))
+		})
+
+		It("should write redacted results to the output file", func() {
+			output := filepath.Join(filepath.Dir(resultsFile), "redacted.json")
+			_, err := executeCommand(resultsRedactCmd(), resultsFile, "--output", output)
+			Expect(err).ToNot(HaveOccurred())
+			contents, err := os.ReadFile(output)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(contents)).ToNot(ContainSubstring("quay.internal.corp"))
+		})
+	})
+
+	Context("with a pattern containing a comma", func() {
+		It("should treat the pattern as a single regular expression", func() {
+			out, err := executeCommand(resultsRedactCmd(), resultsFile, "--pattern", "Has[A-Za-z]{1,10}")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring(`"name": "<redacted>"`))
+		})
+	})
+
+	Context("with an invalid pattern", func() {
+		It("should throw an error", func() {
+			_, err := executeCommand(resultsRedactCmd(), resultsFile, "--pattern", "(")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("with a missing results file", func() {
+		It("should throw an error", func() {
+			_, err := executeCommand(resultsRedactCmd(), "does-not-exist.json")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
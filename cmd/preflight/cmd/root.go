@@ -3,10 +3,20 @@ package cmd
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/artifacts"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/cli"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/config"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/events"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/interrupt"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/log"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/metrics"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/tracing"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/viper"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/version"
 
@@ -15,12 +25,34 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	spfviper "github.com/spf13/viper"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 var configFileUsed bool
 
+// shutdownTracing exports the spans of the command that were not yet exported, if
+// tracing was set up.
+var shutdownTracing func(context.Context) error
+
+// stopServingMetrics stops serving the metrics of the command, if they were served.
+var stopServingMetrics func(context.Context) error
+
+// configErr is why the configuration could not be loaded. It is reported before commands
+// run, except for those that validate the configuration themselves.
+var configErr error
+
+// configCtx is the context config files are fetched from URLs with: that of the command,
+// so that interrupting it stops the fetch. Config files are read by cobra.OnInitialize,
+// which is not passed the context of the command.
+var configCtx = context.Background()
+
+// validatesConfigAnnotation marks the commands that validate the configuration themselves,
+// so that they report why it could not be loaded, instead of exiting before they run.
+const validatesConfigAnnotation = "preflight.validates-config"
+
 func init() {
 	cobra.OnInitialize(initConfig)
+	cobra.OnFinalize(flushTracing, stopMetrics)
 }
 
 func rootCmd() *cobra.Command {
@@ -37,77 +69,386 @@ func rootCmd() *cobra.Command {
 	rootCmd.PersistentFlags().String("logfile", "", "Where the execution logfile will be written. (env: PFLT_LOGFILE)")
 	_ = viper.BindPFlag("logfile", rootCmd.PersistentFlags().Lookup("logfile"))
 
-	rootCmd.PersistentFlags().String("loglevel", "", "The verbosity of the preflight tool itself. Ex. warn, debug, trace, info, error. (env: PFLT_LOGLEVEL)")
+	rootCmd.PersistentFlags().String("logfile-max-size", "", "The size, e.g. 100Mi, the logfile may grow to before it is rotated. The logfile is appended to, rather than\n"+
+		"overwritten, when it is rotated. (env: PFLT_LOGFILE_MAX_SIZE)")
+	_ = viper.BindPFlag("logfile_max_size", rootCmd.PersistentFlags().Lookup("logfile-max-size"))
+
+	rootCmd.PersistentFlags().String("logfile-max-age", "", "How long, e.g. 24h, the logfile is written to before it is rotated. The logfile is appended to, rather than\n"+
+		"overwritten, when it is rotated. (env: PFLT_LOGFILE_MAX_AGE)")
+	_ = viper.BindPFlag("logfile_max_age", rootCmd.PersistentFlags().Lookup("logfile-max-age"))
+
+	rootCmd.PersistentFlags().Int("logfile-max-backups", 0, "How many rotated logfiles are kept. The oldest are removed. If 0, all are kept. (env: PFLT_LOGFILE_MAX_BACKUPS)")
+	_ = viper.BindPFlag("logfile_max_backups", rootCmd.PersistentFlags().Lookup("logfile-max-backups"))
+
+	rootCmd.PersistentFlags().String("loglevel", "", "The verbosity of the preflight tool itself, optionally followed by that of its components. Ex. warn, debug, trace, info, error, or info,pyxis=debug,crane=warn. Components: crane, olm, pyxis, scorecard. (env: PFLT_LOGLEVEL)")
 	_ = viper.BindPFlag("loglevel", rootCmd.PersistentFlags().Lookup("loglevel"))
 
+	rootCmd.PersistentFlags().String("log-format", LogFormatText, fmt.Sprintf("The format of the log, %s or %s, which writes one JSON object per line. (env: PFLT_LOG_FORMAT)", LogFormatText, LogFormatJSON))
+	_ = viper.BindPFlag("log_format", rootCmd.PersistentFlags().Lookup("log-format"))
+
+	rootCmd.PersistentFlags().String("config", "", "The path or the https URL of the config file, instead of config.yaml in the working directory. "+
+		"Config files fetched from a URL are cached, and only fetched again if they changed. (env: PFLT_CONFIG)")
+	_ = viper.BindPFlag("config", rootCmd.PersistentFlags().Lookup("config"))
+
+	rootCmd.PersistentFlags().String("config-ca-bundle", "", "The path to a PEM encoded CA bundle to trust when fetching the config file from a URL. (env: PFLT_CONFIG_CA_BUNDLE)")
+	_ = viper.BindPFlag("config_ca_bundle", rootCmd.PersistentFlags().Lookup("config-ca-bundle"))
+
+	rootCmd.PersistentFlags().String("config-client-cert", "", "The path to a PEM encoded client certificate to present when fetching the config file from a URL. "+
+		"Requires --config-client-key. (env: PFLT_CONFIG_CLIENT_CERT)")
+	_ = viper.BindPFlag("config_client_cert", rootCmd.PersistentFlags().Lookup("config-client-cert"))
+
+	rootCmd.PersistentFlags().String("config-client-key", "", "The path to the PEM encoded key of --config-client-cert. (env: PFLT_CONFIG_CLIENT_KEY)")
+	_ = viper.BindPFlag("config_client_key", rootCmd.PersistentFlags().Lookup("config-client-key"))
+
+	rootCmd.PersistentFlags().String("profile", "", "The profile in the config file whose settings are used, e.g. staging. "+
+		"Flags and environment variables take precedence over the settings of the profile. (env: PFLT_PROFILE)")
+	_ = viper.BindPFlag("profile", rootCmd.PersistentFlags().Lookup("profile"))
+
 	rootCmd.AddCommand(checkCmd())
+	rootCmd.AddCommand(bundleCmd())
 	rootCmd.AddCommand(listChecksCmd())
+	rootCmd.AddCommand(explainCmd())
+	rootCmd.AddCommand(policyCmd())
 	rootCmd.AddCommand(runtimeAssetsCmd())
 	rootCmd.AddCommand(supportCmd())
+	rootCmd.AddCommand(resultsCmd())
+	rootCmd.AddCommand(submitCmd())
+	rootCmd.AddCommand(submitBundleCmd())
 	rootCmd.AddCommand(experimentalCmd())
+	rootCmd.AddCommand(configCmd())
+	rootCmd.AddCommand(selftestCmd())
 
 	return rootCmd
 }
 
 func Execute() error {
-	return rootCmd().ExecuteContext(context.Background())
+	// Interrupted commands stop gracefully, e.g. writing the results of the checks that
+	// completed.
+	ctx, stop := interrupt.NotifyContext(context.Background())
+	defer stop()
+	configCtx = ctx
+
+	cmd := rootCmd()
+	args, err := expandArgs(cmd, os.Args[1:], os.Stdin)
+	if err != nil {
+		return err
+	}
+
+	cmd.SetArgs(args)
+	return cmd.ExecuteContext(ctx)
 }
 
-func initConfig() {
-	viper := viper.Instance()
-	// set up ENV var support
-	viper.SetEnvPrefix("pflt")
-	viper.AutomaticEnv()
+// ExitCode returns the code preflight exits with after Execute returned err: that of the
+// outcome of the checks if they completed, or the configured code of errors otherwise.
+func ExitCode(err error) int {
+	codes := cli.DefaultExitCodes()
+	// An invalid code is reported as an error, which must not exit with it.
+	if v := viper.Instance(); v.IsSet("exit_code_error") {
+		if code := v.GetInt("exit_code_error"); code > 0 && code <= maxExitCode {
+			codes.Error = code
+		}
+	}
 
-	// set up optional config file support
-	viper.SetConfigName("config")
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath(".")
+	return codes.ForError(err)
+}
 
+func initConfig() {
 	configFileUsed = true
-	if err := viper.ReadInConfig(); err != nil {
+	configErr = nil
+	if err := loadConfig(configCtx, viper.Instance(), ""); err != nil {
 		if _, ok := err.(spfviper.ConfigFileNotFoundError); ok {
 			configFileUsed = false
+		} else if viper.Instance().GetString("config") != "" {
+			// A config file that was asked for must be read.
+			configErr = fmt.Errorf("could not read config file: %w", err)
+			return
+		}
+	}
+
+	configErr = applyProfile(viper.Instance())
+}
+
+// loadConfig configures v to read the environment and the config file at configFile,
+// the config key if configFile is empty, or config.yaml in the working directory if
+// neither is set, and sets defaults. A config file at a URL is fetched first. The error
+// of reading the config file is returned. Config files fetched from URLs must not set
+// keys that execute commands.
+func loadConfig(ctx context.Context, v *spfviper.Viper, configFile string) error {
+	// set up ENV var support
+	v.SetEnvPrefix("pflt")
+	v.AutomaticEnv()
+
+	if configFile == "" {
+		configFile = v.GetString("config")
+	}
+
+	// set up optional config file support
+	var err error
+	if config.IsRemote(configFile) {
+		location := configFile
+		configFile, err = config.FetchRemote(ctx, configFile, config.RemoteOptions{
+			CABundle:   v.GetString("config_ca_bundle"),
+			ClientCert: v.GetString("config_client_cert"),
+			ClientKey:  v.GetString("config_client_key"),
+		})
+		if err == nil {
+			err = checkRemoteConfig(location, configFile)
+		}
+	}
+	if err == nil {
+		if configFile != "" {
+			v.SetConfigFile(configFile)
+		} else {
+			v.SetConfigName("config")
+			v.SetConfigType("yaml")
+			v.AddConfigPath(".")
 		}
+		err = v.ReadInConfig()
 	}
 
 	// Set up logging config defaults
-	viper.SetDefault("logfile", DefaultLogFile)
-	viper.SetDefault("loglevel", DefaultLogLevel)
-	viper.SetDefault("artifacts", artifacts.DefaultArtifactsDir)
+	v.SetDefault("logfile", DefaultLogFile)
+	v.SetDefault("loglevel", DefaultLogLevel)
+	v.SetDefault("artifacts", artifacts.DefaultArtifactsDir)
 
 	// Set up cluster defaults
-	viper.SetDefault("namespace", DefaultNamespace)
-	viper.SetDefault("serviceaccount", DefaultServiceAccount)
+	v.SetDefault("namespace", DefaultNamespace)
+	v.SetDefault("serviceaccount", DefaultServiceAccount)
 
 	// Set up scorecard wait time default
-	viper.SetDefault("scorecard_wait_time", DefaultScorecardWaitTime)
+	v.SetDefault("scorecard_wait_time", DefaultScorecardWaitTime)
+
+	// Set up the exit code of errors default
+	v.SetDefault("exit_code_error", DefaultExitCodeError)
+
+	return err
+}
+
+// checkRemoteConfig returns an error if the config file at path, fetched from location,
+// sets keys that execute commands, which are only read from local config files.
+func checkRemoteConfig(location, path string) error {
+	file := spfviper.New()
+	file.SetConfigFile(path)
+	if err := file.ReadInConfig(); err != nil {
+		return err
+	}
+	if keys := config.ExecSettings(file.AllSettings()); len(keys) > 0 {
+		return fmt.Errorf("config file %s cannot set %s: commands that preflight executes may only be configured by a local "+
+			"config file, the environment, or flags", location, strings.Join(keys, ", "))
+	}
+
+	return nil
+}
+
+// applyProfile merges the settings of the profile selected by the profile key into the
+// settings of the config file, so that flags and environment variables still take
+// precedence over them.
+func applyProfile(v *spfviper.Viper) error {
+	profile := v.GetString("profile")
+	if profile == "" {
+		return nil
+	}
+
+	key := config.ProfilesKey + "." + profile
+	if !v.InConfig(key) {
+		return fmt.Errorf("profile %s is not defined in the %s of the config file", profile, config.ProfilesKey)
+	}
+	settings := v.GetStringMap(key)
+	if len(settings) == 0 {
+		return fmt.Errorf("profile %s of the config file has no settings", profile)
+	}
+
+	if err := v.MergeConfigMap(settings); err != nil {
+		return fmt.Errorf("could not apply profile %s: %w", profile, err)
+	}
+
+	return nil
+}
+
+// Log formats accepted by --log-format.
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// logFormatter returns the formatter of the log format named format. The formatter of
+// LogFormatText is returned, with an error, if format is not known.
+func logFormatter(format string) (logrus.Formatter, error) {
+	switch format {
+	case "", LogFormatText:
+		return &logrus.TextFormatter{DisableColors: true}, nil
+	case LogFormatJSON:
+		// The keys are those log collectors, e.g. Loki and Elasticsearch, expect.
+		return &logrus.JSONFormatter{
+			TimestampFormat: time.RFC3339Nano,
+			FieldMap: logrus.FieldMap{
+				logrus.FieldKeyTime: "timestamp",
+				logrus.FieldKeyMsg:  "message",
+			},
+		}, nil
+	default:
+		return &logrus.TextFormatter{DisableColors: true}, fmt.Errorf("unknown log format %s, expected %s or %s", format, LogFormatText, LogFormatJSON)
+	}
+}
+
+// logFileRotation returns how the logfile is rotated, as configured in v.
+func logFileRotation(v *spfviper.Viper) (log.RotateOptions, error) {
+	var opts log.RotateOptions
+	if size := v.GetString("logfile_max_size"); size != "" {
+		q, err := resource.ParseQuantity(size)
+		if err != nil || q.Sign() < 0 {
+			return log.RotateOptions{}, fmt.Errorf("invalid logfile max size %q: must be a size, e.g. 100Mi", size)
+		}
+		opts.MaxSize = q.Value()
+	}
+	if age := v.GetString("logfile_max_age"); age != "" {
+		d, err := time.ParseDuration(age)
+		if err != nil || d < 0 {
+			return log.RotateOptions{}, fmt.Errorf("invalid logfile max age %q: must be a duration, e.g. 24h", age)
+		}
+		opts.MaxAge = d
+	}
+	if opts.MaxBackups = v.GetInt("logfile_max_backups"); opts.MaxBackups < 0 {
+		return log.RotateOptions{}, fmt.Errorf("invalid logfile max backups %d: must not be negative", opts.MaxBackups)
+	}
+
+	return opts, nil
+}
+
+// openLogFile opens the logfile at name, which is rotated as configured by rotation, or
+// overwritten if it is not rotated. Only regular files, e.g. not /dev/null, are rotated.
+func openLogFile(name string, rotation log.RotateOptions) (io.Writer, error) {
+	if info, err := os.Stat(name); !rotation.Enabled() || (err == nil && !info.Mode().IsRegular()) {
+		return os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	}
+
+	return log.OpenRotatingFile(name, rotation)
+}
+
+// withImageLogging returns a copy of ctx whose logger adds image to every line if the
+// log is JSON, so that each line can be attributed to the image on its own once it is
+// collected with the logs of other executions.
+func withImageLogging(ctx context.Context, image string) context.Context {
+	if viper.Instance().GetString("log_format") != LogFormatJSON {
+		return ctx
+	}
+
+	return logr.NewContext(ctx, logr.FromContextOrDiscard(ctx).WithValues("image", image))
 }
 
 // preRunConfig is used by cobra.PreRun in all non-root commands to load all necessary configurations
 func preRunConfig(cmd *cobra.Command, args []string) {
+	if _, ok := cmd.Annotations[validatesConfigAnnotation]; !ok {
+		cobra.CheckErr(configErr)
+	}
+
 	viper := viper.Instance()
 	l := logrus.New()
-	l.SetFormatter(&logrus.TextFormatter{DisableColors: true})
+	formatter, formatErr := logFormatter(viper.GetString("log_format"))
+	l.SetFormatter(formatter)
+	ctx := cmd.Context()
 
 	// set up logging
-	logname := viper.GetString("logfile")
-	logFile, err := os.OpenFile(logname, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	rotation, rotationErr := logFileRotation(viper)
+	logFile, err := openLogFile(viper.GetString("logfile"), rotation)
 	if err == nil {
-		mw := io.MultiWriter(os.Stderr, logFile)
-		l.SetOutput(mw)
+		// Quiet and summary output are meant to be consumed by scripts, so
+		// the log is only written to the logfile.
+		switch {
+		case viper.GetBool("quiet") || viper.GetBool("summary"):
+			l.SetOutput(logFile)
+		case viper.GetBool("watch") && events.IsTerminal(os.Stderr):
+			// The watch view shows the tail of the log instead.
+			tail := events.NewLogTail(events.DefaultLogTailLines)
+			ctx = events.ContextWithLogTail(ctx, tail)
+			l.SetOutput(io.MultiWriter(logFile, tail))
+		default:
+			l.SetOutput(io.MultiWriter(os.Stderr, logFile))
+		}
 	} else {
 		l.Debug("Failed to log to file, using default stderr")
 	}
-	if ll, err := logrus.ParseLevel(viper.GetString("loglevel")); err == nil {
-		l.SetLevel(ll)
-	}
+	levels, levelsErr := log.ParseLevels(viper.GetString("loglevel"))
+	l.SetLevel(levels.Max())
 
 	if !configFileUsed {
 		l.Debug("config file not found, proceeding without it")
 	}
+	if formatErr != nil {
+		l.Warn(fmt.Sprintf("%s, logging as %s", formatErr, LogFormatText))
+	}
+	if rotationErr != nil {
+		l.Warn(fmt.Sprintf("%s, not rotating the logfile", rotationErr))
+	}
+	if levelsErr != nil {
+		l.Warn(fmt.Sprintf("%s, logging at the %s level", levelsErr, levels.Default))
+	}
 
-	logger := logrusr.New(l)
-	ctx := logr.NewContext(cmd.Context(), logger)
+	setUpTracing(ctx, l)
+	ctx = setUpMetrics(ctx, l)
+
+	logger := logr.New(log.NewLevelSink(logrusr.New(l).GetSink(), levels))
+	ctx = logr.NewContext(ctx, logger)
 	cmd.SetContext(ctx)
 }
+
+// setUpTracing exports the spans of the command to the OTLP endpoint in the otel_endpoint
+// key, if any.
+func setUpTracing(ctx context.Context, l *logrus.Logger) {
+	endpoint := viper.Instance().GetString("otel_endpoint")
+	if endpoint == "" {
+		return
+	}
+
+	shutdown, err := tracing.Setup(ctx, endpoint)
+	if err != nil {
+		l.Warn(fmt.Sprintf("%s, not tracing", err))
+		return
+	}
+	shutdownTracing = shutdown
+}
+
+// setUpMetrics serves the metrics of the command on the address in the metrics_addr
+// key, if any, and returns a copy of ctx in which they are recorded.
+func setUpMetrics(ctx context.Context, l *logrus.Logger) context.Context {
+	addr := viper.Instance().GetString("metrics_addr")
+	if addr == "" {
+		return ctx
+	}
+
+	m := metrics.New()
+	stop, err := m.Serve(addr)
+	if err != nil {
+		l.Warn(fmt.Sprintf("%s, not serving metrics", err))
+		return ctx
+	}
+	stopServingMetrics = stop
+
+	return metrics.ContextWithMetrics(ctx, m)
+}
+
+// stopMetrics stops serving metrics once the command is done.
+func stopMetrics() {
+	if stopServingMetrics == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_ = stopServingMetrics(ctx)
+	stopServingMetrics = nil
+}
+
+// flushTracing exports the spans that were not yet exported once the command is done.
+func flushTracing() {
+	if shutdownTracing == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := shutdownTracing(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "could not export traces: %v\n", err)
+	}
+	shutdownTracing = nil
+}
@@ -3,17 +3,25 @@ package cmd
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/artifacts"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/cli"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/log"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/viper"
 
 	"github.com/go-logr/logr"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	spfviper "github.com/spf13/viper"
 )
 
 // executeCommand is used for cobra command testing. It is effectively what's seen here:
@@ -101,6 +109,169 @@ var _ = Describe("cmd package utility functions", func() {
 		})
 	})
 
+	Describe("Apply a configuration profile", func() {
+		var v *spfviper.Viper
+		BeforeEach(func() {
+			configFile := filepath.Join(GinkgoT().TempDir(), "config.yaml")
+			Expect(os.WriteFile(configFile, []byte(`
+artifacts: artifacts
+pyxis_env: prod
+profiles:
+  staging:
+    artifacts: staging-artifacts
+    pyxis_env: qa
+    dockerConfig: staging.json
+  empty: {}
+`), 0o644)).To(Succeed())
+			v = spfviper.New()
+			Expect(loadConfig(context.TODO(), v, configFile)).To(Succeed())
+		})
+		It("should not change the configuration without a profile", func() {
+			Expect(applyProfile(v)).To(Succeed())
+			Expect(v.GetString("pyxis_env")).To(Equal("prod"))
+		})
+		It("should use the settings of the profile over the config file", func() {
+			v.Set("profile", "staging")
+			Expect(applyProfile(v)).To(Succeed())
+			Expect(v.GetString("pyxis_env")).To(Equal("qa"))
+			Expect(v.GetString("artifacts")).To(Equal("staging-artifacts"))
+			Expect(v.GetString("dockerConfig")).To(Equal("staging.json"))
+		})
+		It("should not use the settings of the profile over the environment", func() {
+			GinkgoT().Setenv("PFLT_PYXIS_ENV", "stage")
+			v.Set("profile", "staging")
+			Expect(applyProfile(v)).To(Succeed())
+			Expect(v.GetString("pyxis_env")).To(Equal("stage"))
+		})
+		It("should throw an error if the profile is not defined", func() {
+			v.Set("profile", "prod")
+			Expect(applyProfile(v)).To(MatchError(ContainSubstring("profile prod is not defined")))
+		})
+		It("should throw an error if the profile has no settings", func() {
+			v.Set("profile", "empty")
+			Expect(applyProfile(v)).To(MatchError(ContainSubstring("has no settings")))
+		})
+	})
+
+	Describe("Load a config file from a URL", func() {
+		var (
+			v    *spfviper.Viper
+			body string
+			url  string
+		)
+		BeforeEach(func() {
+			GinkgoT().Setenv("XDG_CACHE_HOME", GinkgoT().TempDir())
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(body))
+			}))
+			DeferCleanup(server.Close)
+			url = server.URL + "/policy.yaml"
+
+			caBundle := filepath.Join(GinkgoT().TempDir(), "ca.pem")
+			Expect(os.WriteFile(caBundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600)).To(Succeed())
+			v = spfviper.New()
+			v.Set("config_ca_bundle", caBundle)
+		})
+		It("should read the config file", func() {
+			body = "pyxis_env: qa\n"
+			Expect(loadConfig(context.TODO(), v, url)).To(Succeed())
+			Expect(v.GetString("pyxis_env")).To(Equal("qa"))
+		})
+		It("should refuse a config file that sets a command", func() {
+			body = "pyxis_env: qa\npost_run_cmd: ./notify.sh\n"
+			err := loadConfig(context.TODO(), v, url)
+			Expect(err).To(MatchError(ContainSubstring("cannot set post_run_cmd")))
+			Expect(v.GetString("pyxis_env")).To(BeEmpty())
+			Expect(v.GetString("post_run_cmd")).To(BeEmpty())
+		})
+		It("should refuse a config file whose profile sets a command", func() {
+			body = "profiles:\n  staging:\n    image_resolver: ./resolve.sh\n"
+			err := loadConfig(context.TODO(), v, url)
+			Expect(err).To(MatchError(ContainSubstring("cannot set profiles.staging.image_resolver")))
+		})
+		It("should not fetch the config file if the command is cancelled", func() {
+			body = "pyxis_env: qa\n"
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			Expect(loadConfig(ctx, v, url)).To(MatchError(context.Canceled))
+		})
+		It("should refuse an http URL", func() {
+			Expect(loadConfig(context.TODO(), v, "http://example.com/policy.yaml")).To(MatchError(ContainSubstring("must use https")))
+		})
+	})
+
+	Describe("Log formats", func() {
+		It("should write the log as text by default", func() {
+			formatter, err := logFormatter("")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(formatter).To(BeAssignableToTypeOf(&logrus.TextFormatter{}))
+		})
+		It("should write one JSON object per line with the json format", func() {
+			formatter, err := logFormatter(LogFormatJSON)
+			Expect(err).ToNot(HaveOccurred())
+
+			line, err := formatter.Format(logrus.NewEntry(logrus.New()).WithField("check", "RunAsNonRoot").WithTime(time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)))
+			Expect(err).ToNot(HaveOccurred())
+			var fields map[string]interface{}
+			Expect(json.Unmarshal(line, &fields)).To(Succeed())
+			Expect(fields).To(HaveKeyWithValue("timestamp", "2024-06-01T12:00:00Z"))
+			Expect(fields).To(HaveKeyWithValue("check", "RunAsNonRoot"))
+			Expect(fields).To(HaveKey("level"))
+			Expect(fields).To(HaveKey("message"))
+		})
+		It("should fall back to text for an unknown format", func() {
+			formatter, err := logFormatter("xml")
+			Expect(err).To(MatchError(ContainSubstring("unknown log format xml")))
+			Expect(formatter).To(BeAssignableToTypeOf(&logrus.TextFormatter{}))
+		})
+	})
+
+	Describe("Logfile rotation", func() {
+		var v *spfviper.Viper
+		BeforeEach(func() {
+			v = spfviper.New()
+		})
+		It("should not rotate the logfile by default", func() {
+			opts, err := logFileRotation(v)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(opts.Enabled()).To(BeFalse())
+		})
+		It("should read the maximum size, age, and backups", func() {
+			v.Set("logfile_max_size", "10Mi")
+			v.Set("logfile_max_age", "24h")
+			v.Set("logfile_max_backups", 5)
+			opts, err := logFileRotation(v)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(opts.MaxSize).To(Equal(int64(10 << 20)))
+			Expect(opts.MaxAge).To(Equal(24 * time.Hour))
+			Expect(opts.MaxBackups).To(Equal(5))
+		})
+		DescribeTable("should throw an error for invalid values",
+			func(key string, value interface{}, message string) {
+				v.Set(key, value)
+				_, err := logFileRotation(v)
+				Expect(err).To(MatchError(ContainSubstring(message)))
+			},
+			Entry("size", "logfile_max_size", "big", "invalid logfile max size"),
+			Entry("age", "logfile_max_age", "1 day", "invalid logfile max age"),
+			Entry("backups", "logfile_max_backups", -1, "invalid logfile max backups"),
+		)
+		It("should not rotate files that are not regular files", func() {
+			w, err := openLogFile(os.DevNull, log.RotateOptions{MaxSize: 1})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(w).To(BeAssignableToTypeOf(&os.File{}))
+		})
+		It("should append to a logfile that is rotated", func() {
+			name := filepath.Join(GinkgoT().TempDir(), "preflight.log")
+			Expect(os.WriteFile(name, []byte("previous\n"), 0o600)).To(Succeed())
+			w, err := openLogFile(name, log.RotateOptions{MaxSize: 1 << 20})
+			Expect(err).ToNot(HaveOccurred())
+			_, err = w.Write([]byte("next\n"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(os.ReadFile(name)).To(Equal([]byte("previous\nnext\n")))
+		})
+	})
+
 	Describe("Pre-run configuration", func() {
 		var cmd *cobra.Command
 		BeforeEach(func() {
@@ -123,6 +294,16 @@ var _ = Describe("cmd package utility functions", func() {
 				_, err := os.Stat(filepath.Join(tmpDir, "foo.log"))
 				Expect(err).ToNot(HaveOccurred())
 			})
+			It("should log at the level of each component", func() {
+				DeferCleanup(viper.Instance().Set, "loglevel", viper.Instance().GetString("loglevel"))
+				viper.Instance().Set("logfile", filepath.Join(tmpDir, "foo.log"))
+				viper.Instance().Set("loglevel", "warn,pyxis=debug")
+				var ctx context.Context
+				cmd.Run = func(cmd *cobra.Command, args []string) { ctx = cmd.Context() }
+				Expect(cmd.ExecuteContext(context.TODO())).To(Succeed())
+				Expect(logr.FromContextOrDiscard(ctx).Enabled()).To(BeFalse())
+				Expect(log.FromContext(ctx, log.ComponentPyxis).V(log.DBG).Enabled()).To(BeTrue())
+			})
 		})
 	})
 })
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/selftest"
+
+	"github.com/spf13/cobra"
+)
+
+// errSelftestFailed is the error of a self-test in which a step failed.
+var errSelftestFailed = errors.New("self-test failed")
+
+func selftestCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "selftest",
+		Short: "Check that preflight works on this host",
+		Long: "Execute checks against a tiny test image, built and served from a registry in the process, and format and " +
+			"write their results, so that problems with the host, e.g. its temporary directory, can be ruled out before " +
+			"debugging the failures of a real image. Nothing is pulled from, or sent to, the network.",
+		Args: cobra.NoArgs,
+		RunE: selftestRunE,
+	}
+}
+
+func selftestRunE(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	steps := selftest.Run(cmd.Context())
+	writeSelftest(cmd.OutOrStdout(), steps)
+	if !selftest.Passed(steps) {
+		return errSelftestFailed
+	}
+
+	return nil
+}
+
+// writeSelftest writes the outcome of each step of a self-test to w.
+func writeSelftest(w io.Writer, steps []selftest.Step) {
+	for _, step := range steps {
+		if step.Err != nil {
+			fmt.Fprintf(w, "FAIL  %s: %v\n", step.Name, step.Err)
+			continue
+		}
+		fmt.Fprintf(w, "PASS  %s\n", step.Name)
+	}
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/selftest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Self-test", func() {
+	It("should write the outcome of each step", func() {
+		var buf strings.Builder
+		writeSelftest(&buf, []selftest.Step{
+			{Name: "build the test image"},
+			{Name: "serve the test image", Err: errors.New("address in use")},
+		})
+		Expect(buf.String()).To(Equal("PASS  build the test image\nFAIL  serve the test image: address in use\n"))
+	})
+
+	It("should pass on this host", func() {
+		out, err := executeCommand(selftestCmd())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(ContainSubstring("PASS  format and write the results"))
+	})
+})
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/artifacts"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/lib"
+
+	"github.com/spf13/cobra"
+)
+
+func submitBundleCmd() *cobra.Command {
+	submitBundleCmd := &cobra.Command{
+		Use:   "submit-bundle <bundle>",
+		Short: "Submit a bundle of container results written offline to Red Hat",
+		Long: fmt.Sprintf("This command will submit the %s written by check container --submit --offline to Red Hat,\n"+
+			"from a host that can reach Red Hat. The results are submitted to the certification project they were\n"+
+			"bundled for.", lib.SubmissionBundleFilename),
+		Args: cobra.ExactArgs(1),
+		// this fmt.Sprintf is in place to keep spacing consistent with cobras two spaces that's used in: Usage, Flags, etc
+		Example:          fmt.Sprintf("  %s", "preflight submit-bundle artifacts/"+lib.SubmissionBundleFilename+" --pyxis-api-token <token>"),
+		PersistentPreRun: preRunSubmitConfig,
+		RunE:             submitBundleRunE,
+	}
+
+	addSubmissionFlags(submitBundleCmd)
+
+	return submitBundleCmd
+}
+
+// submitBundleRunE submits the results in the submission bundle given as the argument.
+func submitBundleRunE(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	bundlePath := args[0]
+	bundle, err := lib.ReadSubmissionBundle(bundlePath)
+	if err != nil {
+		return err
+	}
+
+	// The project defaults to the one the results were bundled for.
+	projectID, err := normalizeCertificationProjectID(flagOrConfig(cmd, "certification-project-id", "certification_project_id"))
+	if err != nil {
+		return err
+	}
+	if projectID == "" {
+		projectID = bundle.Manifest.CertificationProjectID
+	}
+	if projectID == "" {
+		return fmt.Errorf("certification Project ID must be specified")
+	}
+
+	token, oidcCfg, err := submissionCredentials(ctx, cmd)
+	if err != nil {
+		return err
+	}
+
+	// A dry run writes what would be submitted next to the bundle.
+	artifactsWriter, err := artifacts.NewFilesystemWriter(artifacts.WithDirectory(filepath.Dir(bundlePath)))
+	if err != nil {
+		return err
+	}
+	ctx = artifacts.ContextWithWriter(ctx, artifactsWriter)
+
+	ctx, submitter, err := newSubmitter(ctx, cmd, projectID, token, oidcCfg)
+	if err != nil {
+		return err
+	}
+
+	s, ok := submitter.(*lib.ContainerCertificationSubmitter)
+	if !ok {
+		return errors.New("could not connect to pyxis to submit the bundle")
+	}
+
+	cmd.SilenceUsage = true
+
+	return s.SubmitBundle(ctx, bundle)
+}
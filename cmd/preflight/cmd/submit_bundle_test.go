@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Submit Bundle", func() {
+	Context("when running the submit-bundle command", func() {
+		It("should require the bundle", func() {
+			_, err := executeCommand(submitBundleCmd())
+			Expect(err).To(MatchError(ContainSubstring("accepts 1 arg(s), received 0")))
+		})
+
+		It("should require the bundle to exist", func() {
+			_, err := executeCommand(submitBundleCmd(), filepath.Join(GinkgoT().TempDir(), "submission-bundle.tar.gz"))
+			Expect(err).To(MatchError(ContainSubstring("could not open submission bundle")))
+		})
+
+		It("should require the bundle to be a submission bundle", func() {
+			bundlePath := filepath.Join(GinkgoT().TempDir(), "submission-bundle.tar.gz")
+			Expect(os.WriteFile(bundlePath, []byte(`{"image": "quay.io/example/image:mytag"}`), 0o644)).To(Succeed())
+			_, err := executeCommand(submitBundleCmd(), bundlePath)
+			Expect(err).To(MatchError(ContainSubstring("is not a submission bundle")))
+		})
+	})
+})
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Submit", func() {
+	var resultsPath string
+
+	BeforeEach(func() {
+		resultsPath = filepath.Join(GinkgoT().TempDir(), "results.json")
+		Expect(os.WriteFile(resultsPath, []byte(`{"image": "quay.io/example/image:mytag", "passed": true}`), 0o644)).To(Succeed())
+	})
+
+	Context("when running the submit command", func() {
+		It("should require the results", func() {
+			_, err := executeCommand(submitCmd())
+			Expect(err).To(MatchError(ContainSubstring(`required flag(s) "results" not set`)))
+		})
+
+		It("should require the results to be a results.json", func() {
+			_, err := executeCommand(submitCmd(), "--results", filepath.Join(filepath.Dir(resultsPath), "results.xml"))
+			Expect(err).To(MatchError(ContainSubstring("results must be the results.json")))
+		})
+
+		It("should require the results to be preflight results", func() {
+			Expect(os.WriteFile(resultsPath, []byte(`{}`), 0o644)).To(Succeed())
+			_, err := executeCommand(submitCmd(), "--results", resultsPath)
+			Expect(err).To(MatchError(ContainSubstring("does not contain preflight results")))
+		})
+
+		It("should require the certification project id", func() {
+			_, err := executeCommand(submitCmd(), "--results", resultsPath, "--pyxis-api-token", "token")
+			Expect(err).To(MatchError(ContainSubstring("certification Project ID must be specified")))
+		})
+
+		It("should reject legacy certification project ids", func() {
+			_, err := executeCommand(submitCmd(), "--results", resultsPath, "--pyxis-api-token", "token", "--certification-project-id", "p-1-2")
+			Expect(err).To(MatchError(ContainSubstring("is improperly formatted")))
+		})
+
+		It("should require the pyxis API token", func() {
+			// Other tests may leave a token in the shared configuration, so clear it explicitly.
+			_, err := executeCommand(submitCmd(), "--results", resultsPath, "--certification-project-id", "ospid-000000000000", "--pyxis-api-token", "")
+			Expect(err).To(MatchError(ContainSubstring("pyxis API Token must be specified")))
+		})
+
+		It("should reject an invalid pyxis max qps", func() {
+			GinkgoT().Setenv("PFLT_PYXIS_MAX_QPS", "fast")
+			_, err := executeCommand(submitCmd(), "--results", resultsPath, "--certification-project-id", "000000000000", "--pyxis-api-token", "token")
+			Expect(err).To(MatchError(ContainSubstring("invalid pyxis max qps")))
+		})
+
+		It("should fail if the remaining artifacts of the execution are missing", func() {
+			_, err := executeCommand(submitCmd(), "--results", resultsPath, "--certification-project-id", "000000000000", "--pyxis-api-token", "token", "--pyxis-host", "localhost:0")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("when normalizing certification project ids", func() {
+		It("should strip the ospid- prefix", func() {
+			Expect(normalizeCertificationProjectID("ospid-000000000000")).To(Equal("000000000000"))
+		})
+	})
+})
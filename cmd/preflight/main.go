@@ -1,13 +1,21 @@
 package main
 
 import (
+	"errors"
 	"log"
+	"os"
 
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/cmd/preflight/cmd"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/cli"
 )
 
 func main() {
 	if err := cmd.Execute(); err != nil {
-		log.Fatal(err)
+		// The outcome of the checks is already reported by their results.
+		var exitErr *cli.ExitError
+		if !errors.As(err, &exitErr) {
+			log.Print(err)
+		}
+		os.Exit(cmd.ExitCode(err))
 	}
 }
@@ -3,19 +3,33 @@ package container
 import (
 	"context"
 	"fmt"
-	"net/http"
 	"time"
 
 	goruntime "runtime"
 
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/artifacts"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/certification"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/clock"
 	preflighterr "github.com/redhat-openshift-ecosystem/openshift-preflight/errors"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/audit"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/authn"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/decrypt"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/engine"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/formatters"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/image"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/lib"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/lockfile"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/mirror"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/policy"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/proxy"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/pyxis"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/readiness"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/runtime"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/transport"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/resolve"
+
+	"github.com/go-logr/logr"
 )
 
 type Option = func(*containerCheck)
@@ -43,15 +57,145 @@ func (c *containerCheck) Run(ctx context.Context) (certification.Results, error)
 		return certification.Results{}, preflighterr.ErrImageEmpty
 	}
 
+	if c.configErr != nil {
+		return certification.Results{}, c.configErr
+	}
+
+	image, err := resolve.Image(ctx, c.image, c.resolvers...)
+	if err != nil {
+		return certification.Results{}, err
+	}
+
+	// The previous results are read before anything is written, since they are usually
+	// replaced by the results of this execution.
+	var previous formatters.UserResponse
+	if c.rerunFailed != "" {
+		previous, err = audit.LoadResults(c.rerunFailed)
+		if err != nil {
+			return certification.Results{}, err
+		}
+		if previous.Image != image {
+			return certification.Results{}, fmt.Errorf("%s are the results of %s, not of %s", c.rerunFailed, previous.Image, image)
+		}
+	}
+
+	if c.artifactsDir != "" && artifacts.WriterFromContext(ctx) == nil {
+		aw, err := artifacts.NewFilesystemWriter(artifacts.WithDirectory(c.artifactsDir))
+		if err != nil {
+			return certification.Results{}, err
+		}
+		ctx = artifacts.ContextWithWriter(ctx, aw)
+	}
+
+	if c.clock != nil {
+		ctx = clock.ContextWithClock(ctx, c.clock)
+	}
+
+	engineOpts := []engine.Option{engine.WithTimeouts(c.timeouts)}
+
+	if c.traceOnFailure {
+		engineOpts = append(engineOpts, engine.WithTraceOnFailure())
+	}
+
+	if c.failFast {
+		engineOpts = append(engineOpts, engine.WithFailFast())
+	}
+
+	if c.proxy.URL != "" {
+		if err := c.proxy.Validate(); err != nil {
+			return certification.Results{}, err
+		}
+		ctx = proxy.ContextWithConfig(ctx, c.proxy)
+	}
+
+	if c.caBundle != "" {
+		rootCAs, err := transport.LoadRootCAs(c.caBundle)
+		if err != nil {
+			return certification.Results{}, err
+		}
+		ctx = transport.ContextWithRootCAs(ctx, rootCAs)
+	}
+
+	if c.denyEgress {
+		ctx = transport.ContextWithEgressPolicy(ctx, transport.NewEgressPolicy(c.egressAllowed...))
+	}
+
+	if err := c.registryCredentials.Validate(); err != nil {
+		return certification.Results{}, err
+	}
+	if c.registryCredentials.IsSet() {
+		creds, err := c.registryCredentials.ForImage(image)
+		if err != nil {
+			return certification.Results{}, err
+		}
+		ctx = authn.ContextWithCredentials(ctx, creds)
+	}
+
+	mirrors := append([]mirror.Mirror{}, c.mirrors...)
+	if c.mirrorConfig != "" {
+		fileMirrors, err := mirror.LoadFile(c.mirrorConfig)
+		if err != nil {
+			return certification.Results{}, err
+		}
+		mirrors = append(mirrors, fileMirrors...)
+	}
+	engineOpts = append(engineOpts, engine.WithMirrors(mirrors))
+
+	if c.lockfile != "" {
+		var opts []lockfile.Option
+		if c.updateLockfile {
+			opts = append(opts, lockfile.WithUpdate())
+		}
+		lock, err := lockfile.Load(c.lockfile, opts...)
+		if err != nil {
+			return certification.Results{}, err
+		}
+		engineOpts = append(engineOpts, engine.WithLockfile(lock))
+	}
+
+	if c.expectedDigest != "" {
+		engineOpts = append(engineOpts, engine.WithExpectedDigest(c.expectedDigest))
+	}
+
+	if len(c.decryptionKeys) > 0 {
+		keys, err := decrypt.LoadKeys(c.decryptionKeys...)
+		if err != nil {
+			return certification.Results{}, err
+		}
+		engineOpts = append(engineOpts, engine.WithDecryptionKeys(keys))
+	}
+
+	if c.layerCache != "" {
+		engineOpts = append(engineOpts, engine.WithLayerCache(c.layerCache))
+	}
+
+	if c.scratchDir != "" {
+		engineOpts = append(engineOpts, engine.WithScratchDir(c.scratchDir))
+	}
+
+	if c.probeServices {
+		probes := readiness.Run(ctx, readiness.DefaultTimeout,
+			readiness.Registry(image, c.insecure, mirrors),
+			readiness.Pyxis(c.pyxisHost),
+		)
+		if !probes.Ready() {
+			return certification.Results{}, fmt.Errorf("%w:\n%s", preflighterr.ErrServicesNotReady, probes)
+		}
+	}
+
 	pol := policy.PolicyContainer
 
-	// If we have enough Pyxis information, resolve the policy.
-	if c.hasPyxisData() {
+	// Windows images are checked under their own policy, to which the exceptions of
+	// the project do not apply. Otherwise, if we have enough Pyxis information, resolve
+	// the policy.
+	if c.isWindows() {
+		pol = policy.PolicyWindows
+	} else if c.hasPyxisData() {
 		p := pyxis.NewPyxisClient(
 			c.pyxisHost,
 			c.pyxisToken,
 			c.certificationProjectID,
-			&http.Client{Timeout: 60 * time.Second},
+			transport.HTTPClient(ctx, 60*time.Second),
 		)
 
 		override, err := lib.GetContainerPolicyExceptions(ctx, p)
@@ -66,12 +210,26 @@ func (c *containerCheck) Run(ctx context.Context) (certification.Results, error)
 		DockerConfig:           c.dockerconfigjson,
 		PyxisAPIToken:          c.pyxisToken,
 		CertificationProjectID: c.certificationProjectID,
+		ApprovedBaseImages:     c.approvedBaseImages,
 	})
 	if err != nil {
 		return certification.Results{}, fmt.Errorf("%w: %s", preflighterr.ErrCannotInitializeChecks, err)
 	}
 
-	eng, err := engine.New(ctx, c.image, checks, nil, c.dockerconfigjson, false, pol == policy.PolicyScratch, c.insecure, c.platform)
+	// Record the policy, so that the results can be evaluated under a later version of it.
+	if err := audit.WritePolicyManifest(ctx, pol, checks); err != nil {
+		return certification.Results{}, err
+	}
+
+	// Only the checks that did not pass are executed again, and the recorded outcomes of
+	// those that did are merged into the results.
+	var passed []certification.Result
+	if c.rerunFailed != "" {
+		checks, passed = audit.Rerun(previous, checks)
+		logr.FromContextOrDiscard(ctx).Info("executing the checks that did not pass again", "results", c.rerunFailed, "checks", len(checks), "passed", len(passed))
+	}
+
+	eng, err := engine.New(ctx, image, checks, nil, c.dockerconfigjson, false, pol == policy.PolicyScratch, c.insecure, c.platform, engineOpts...)
 	if err != nil {
 		return certification.Results{}, err
 	}
@@ -84,7 +242,23 @@ func (c *containerCheck) Run(ctx context.Context) (certification.Results, error)
 		return certification.Results{}, err
 	}
 
-	return eng.Results(ctx), nil
+	results := eng.Results(ctx)
+	results.Passed = append(passed, results.Passed...)
+
+	return results, nil
+}
+
+// Stream executes the check like Run, but returns a ResultStream yielding each check's
+// result as soon as it completes. The final results are available from the stream once
+// all checks have completed.
+func (c *containerCheck) Stream(ctx context.Context) *certification.ResultStream {
+	return certification.NewResultStream(ctx, c.Run)
+}
+
+// isWindows returns true if the image is pulled for a Windows platform.
+func (c *containerCheck) isWindows() bool {
+	p, err := image.ParsePlatform(c.platform)
+	return err == nil && p.OS == image.OSWindows
 }
 
 // hasPyxisData returns true of the values necessary to make a pyxis
@@ -93,6 +267,129 @@ func (c *containerCheck) hasPyxisData() bool {
 	return (c.certificationProjectID != "" && c.pyxisToken != "" && c.pyxisHost != "")
 }
 
+// WithImageResolver resolves the image, before it is pulled, with r, if it is not
+// resolved by a resolver added before it. This allows images to be checked by the
+// names an organization uses for them, rather than their registry references.
+func WithImageResolver(r resolve.Resolver) Option {
+	return func(cc *containerCheck) {
+		cc.resolvers = append(cc.resolvers, r)
+	}
+}
+
+// WithConfig configures the check from cfg, built with runtime.NewConfig, as the
+// preflight binary configures it. If the context has no ArtifactWriter, artifacts are
+// written to the artifacts directory of cfg. Options after WithConfig override it.
+func WithConfig(cfg *runtime.Config) Option {
+	return func(cc *containerCheck) {
+		opts := []Option{
+			WithCertificationProject(cfg.CertificationProjectID, cfg.PyxisAPIToken),
+			WithDockerConfigJSONFromFile(cfg.DockerConfig),
+		}
+
+		if cfg.PyxisHost != "" {
+			opts = append(opts, WithPyxisHost(cfg.PyxisHost))
+		}
+
+		if cfg.Platform != "" {
+			opts = append(opts, WithPlatform(cfg.Platform))
+		}
+
+		if cfg.Insecure {
+			opts = append(opts, WithInsecureConnection())
+		}
+
+		if cfg.TraceOnFailure {
+			opts = append(opts, WithTraceOnFailure())
+		}
+
+		if cfg.FailFast {
+			opts = append(opts, WithFailFast())
+		}
+
+		if cfg.DenyCheckEgress {
+			opts = append(opts, WithEgressPolicy(cfg.AllowCheckEgress...))
+		}
+
+		if cfg.ProbeServices {
+			opts = append(opts, WithServiceProbes())
+		}
+
+		if cfg.Proxy != "" {
+			opts = append(opts, WithProxy(cfg.Proxy, cfg.NoProxy))
+		}
+
+		if cfg.CABundle != "" {
+			opts = append(opts, WithCABundle(cfg.CABundle))
+		}
+
+		if cfg.RegistryUsername != "" || cfg.RegistryPassword != "" || cfg.RegistryToken != "" {
+			opts = append(opts, WithRegistryCredentials(cfg.RegistryUsername, cfg.RegistryPassword, cfg.RegistryToken))
+		}
+
+		if cfg.MirrorConfig != "" {
+			opts = append(opts, WithMirrorConfigFile(cfg.MirrorConfig))
+		}
+
+		if cfg.Lockfile != "" {
+			opts = append(opts, WithLockfile(cfg.Lockfile))
+		}
+
+		if cfg.UpdateLockfile {
+			opts = append(opts, WithLockfileUpdate())
+		}
+
+		if cfg.ExpectedDigest != "" {
+			opts = append(opts, WithExpectedDigest(cfg.ExpectedDigest))
+		}
+
+		if cfg.Tmpdir != "" {
+			opts = append(opts, WithScratchDir(cfg.Tmpdir))
+		}
+
+		if len(cfg.ApprovedBaseImages) > 0 {
+			opts = append(opts, WithApprovedBaseImages(cfg.ApprovedBaseImages...))
+		}
+
+		if len(cfg.DecryptionKeys) > 0 {
+			opts = append(opts, WithDecryptionKeys(cfg.DecryptionKeys...))
+		}
+
+		if cfg.RerunFailed != "" {
+			opts = append(opts, WithRerunFailed(cfg.RerunFailed))
+			// Rerunning checks is iterative, so the layers are kept for the next execution.
+			if dir, err := engine.DefaultLayerCacheDir(); err == nil {
+				opts = append(opts, WithLayerCache(dir))
+			}
+		}
+
+		for _, opt := range opts {
+			opt(cc)
+		}
+
+		timeouts, err := check.ParseTimeouts(cfg.CheckTimeout, cfg.CheckTimeouts)
+		if err != nil {
+			cc.configErr = err
+		}
+		if timeouts.Default > 0 {
+			cc.timeouts.Default = timeouts.Default
+		}
+		for name, timeout := range timeouts.Checks {
+			WithCheckTimeoutFor(name, timeout)(cc)
+		}
+
+		for _, v := range cfg.RegistryMirrors {
+			m, err := mirror.Parse(v)
+			if err != nil {
+				cc.configErr = err
+				continue
+			}
+			cc.mirrors = append(cc.mirrors, m)
+		}
+
+		cc.artifactsDir = cfg.Artifacts
+	}
+}
+
 func WithDockerConfigJSONFromFile(s string) Option {
 	return func(cc *containerCheck) {
 		cc.dockerconfigjson = s
@@ -129,7 +426,7 @@ func WithPyxisEnv(env string) Option {
 }
 
 // WithPlatform will define for what platform the image should be pulled.
-// E.g. amd64, s390x.
+// E.g. amd64, s390x, or a full platform with a variant, like linux/arm/v7.
 func WithPlatform(platform string) Option {
 	return func(cc *containerCheck) {
 		cc.platform = platform
@@ -144,6 +441,194 @@ func WithInsecureConnection() Option {
 	}
 }
 
+// WithDeterministicTimes zeroes all timestamps and durations recorded in
+// results and artifacts, so that output is reproducible.
+func WithDeterministicTimes() Option {
+	return WithClock(clock.Deterministic())
+}
+
+// WithClock sets the Clock used to record timestamps and durations in
+// results and artifacts. This is useful for snapshot tests that need
+// control over the recorded times.
+func WithClock(c clock.Clock) Option {
+	return func(cc *containerCheck) {
+		cc.clock = c
+	}
+}
+
+// WithTraceOnFailure executes checks that fail or error again with trace
+// logging, and writes the log of each to the ArtifactWriter in the context.
+func WithTraceOnFailure() Option {
+	return func(cc *containerCheck) {
+		cc.traceOnFailure = true
+	}
+}
+
+// WithFailFast stops executing checks after the first check that fails or errors, so
+// that a single problem is reported as soon as possible. The checks after it are not
+// executed, and are not included in the results.
+func WithFailFast() Option {
+	return func(cc *containerCheck) {
+		cc.failFast = true
+	}
+}
+
+// WithEgressPolicy denies the checks access to the network, except those named allowed,
+// so that the analysis of untrusted images is contained. The connections that checks are
+// denied are reported in the results, and the checks that are denied one error.
+func WithEgressPolicy(allowed ...string) Option {
+	return func(cc *containerCheck) {
+		cc.denyEgress = true
+		cc.egressAllowed = append(cc.egressAllowed, allowed...)
+	}
+}
+
+// WithCheckTimeout errors the checks that do not complete within timeout, e.g. because
+// a registry or cluster they depend on hangs, rather than waiting for them, unless they
+// have a timeout of their own set with WithCheckTimeoutFor.
+func WithCheckTimeout(timeout time.Duration) Option {
+	return func(cc *containerCheck) {
+		cc.timeouts.Default = timeout
+	}
+}
+
+// WithCheckTimeoutFor errors the check named name if it does not complete within
+// timeout, overriding the timeout set with WithCheckTimeout.
+func WithCheckTimeoutFor(name string, timeout time.Duration) Option {
+	return func(cc *containerCheck) {
+		if cc.timeouts.Checks == nil {
+			cc.timeouts.Checks = map[string]time.Duration{}
+		}
+		cc.timeouts.Checks[name] = timeout
+	}
+}
+
+// WithServiceProbes probes the registry and Pyxis before executing any check, and fails
+// with a report of those that are not ready, rather than when a check first uses them.
+func WithServiceProbes() Option {
+	return func(cc *containerCheck) {
+		cc.probeServices = true
+	}
+}
+
+// WithProxy sends registry and Pyxis requests through the proxy at proxyURL, except
+// for requests to the hosts in noProxy, a comma-separated list in the same format as
+// NO_PROXY. By default, the proxy is read from the environment.
+func WithProxy(proxyURL, noProxy string) Option {
+	return func(cc *containerCheck) {
+		cc.proxy = proxy.Config{URL: proxyURL, NoProxy: noProxy}
+	}
+}
+
+// WithCABundle trusts the PEM encoded certificate authorities in the file at path,
+// in addition to the system's, when connecting to registries and Pyxis.
+func WithCABundle(path string) Option {
+	return func(cc *containerCheck) {
+		cc.caBundle = path
+	}
+}
+
+// WithRegistryCredentials authenticates with the registry of the image under test using
+// username and password, or a bearer token, instead of a docker config file.
+func WithRegistryCredentials(username, password, token string) Option {
+	return func(cc *containerCheck) {
+		cc.registryCredentials = authn.Credentials{Username: username, Password: password, Token: token}
+	}
+}
+
+// WithRegistryMirror pulls images in source, a registry, namespace, or repository, from
+// mirrors, in order, before falling back to source. Source may be prefixed with "*." to
+// match all subdomains of a registry.
+func WithRegistryMirror(source string, mirrors ...string) Option {
+	return func(cc *containerCheck) {
+		cc.mirrors = append(cc.mirrors, mirror.Mirror{Source: source, Mirrors: mirrors})
+	}
+}
+
+// WithMirrorConfigFile pulls images from the mirrors configured by the OpenShift
+// ImageDigestMirrorSet, ImageTagMirrorSet, and ImageContentSourcePolicy resources in
+// the YAML file at path, as a cluster with those resources would.
+func WithMirrorConfigFile(path string) Option {
+	return func(cc *containerCheck) {
+		cc.mirrorConfig = path
+	}
+}
+
+// WithLockfile fails the check if the digest of the image differs from the digest it
+// is pinned to by the lockfile at path, if it is not pinned by it, or if its digest is
+// denied by it. Images referenced by digest are not checked against the lockfile.
+func WithLockfile(path string) Option {
+	return func(cc *containerCheck) {
+		cc.lockfile = path
+	}
+}
+
+// WithLockfileUpdate pins the image to its digest in the lockfile configured by
+// WithLockfile, rather than failing the check when it differs or is not pinned. The
+// lockfile is created if it does not exist.
+func WithLockfileUpdate() Option {
+	return func(cc *containerCheck) {
+		cc.updateLockfile = true
+	}
+}
+
+// WithExpectedDigest fails the check, before any check is executed, if the manifest
+// digest of the image that is pulled is not digest, e.g. sha256:..., so that an image
+// referenced by a tag that was moved after it was built is not checked in its place.
+// The digest of the image is recorded in the results either way.
+func WithExpectedDigest(digest string) Option {
+	return func(cc *containerCheck) {
+		cc.expectedDigest = digest
+	}
+}
+
+// WithApprovedBaseImages additionally checks that the image is built on one of images,
+// the base images approved by an organization. Each is either a repository, approving
+// any image in it, or an image referenced by digest.
+func WithApprovedBaseImages(images ...string) Option {
+	return func(cc *containerCheck) {
+		cc.approvedBaseImages = append(cc.approvedBaseImages, images...)
+	}
+}
+
+// WithDecryptionKeys decrypts the layers of the image that are encrypted with ocicrypt
+// for a JWE recipient with one of the RSA or EC private keys at paths, in PEM or DER
+// form, so that their contents are checked. Without keys, encrypted layers are skipped.
+// The check fails if a key cannot be read, or if none decrypts an encrypted layer.
+func WithDecryptionKeys(paths ...string) Option {
+	return func(cc *containerCheck) {
+		cc.decryptionKeys = append(cc.decryptionKeys, paths...)
+	}
+}
+
+// WithRerunFailed executes only the checks that did not pass in the previous execution
+// whose results, in the default JSON format, are at path, e.g. artifacts/results.json,
+// and merges the recorded outcomes of those that did into the results. The results
+// must be of the same image.
+func WithRerunFailed(path string) Option {
+	return func(cc *containerCheck) {
+		cc.rerunFailed = path
+	}
+}
+
+// WithLayerCache caches the layers of the image in dir, rather than in a temporary
+// directory, so that later checks of the image, or of images sharing its layers, do not
+// pull them again.
+func WithLayerCache(dir string) Option {
+	return func(cc *containerCheck) {
+		cc.layerCache = dir
+	}
+}
+
+// WithScratchDir extracts the image to dir, rather than to the default temporary
+// directory. The check fails before the image is extracted if dir does not have the free
+// space the image needs.
+func WithScratchDir(dir string) Option {
+	return func(cc *containerCheck) {
+		cc.scratchDir = dir
+	}
+}
+
 type containerCheck struct {
 	image                  string
 	dockerconfigjson       string
@@ -152,4 +637,27 @@ type containerCheck struct {
 	pyxisHost              string
 	platform               string
 	insecure               bool
+	clock                  clock.Clock
+	traceOnFailure         bool
+	failFast               bool
+	timeouts               check.Timeouts
+	egressAllowed          []string
+	denyEgress             bool
+	probeServices          bool
+	proxy                  proxy.Config
+	caBundle               string
+	mirrors                []mirror.Mirror
+	mirrorConfig           string
+	lockfile               string
+	updateLockfile         bool
+	expectedDigest         string
+	registryCredentials    authn.Credentials
+	approvedBaseImages     []string
+	decryptionKeys         []string
+	rerunFailed            string
+	layerCache             string
+	scratchDir             string
+	artifactsDir           string
+	configErr              error
+	resolvers              []resolve.Resolver
 }
@@ -2,13 +2,19 @@ package container
 
 import (
 	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/certification"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/clock"
 	preflighterr "github.com/redhat-openshift-ecosystem/openshift-preflight/errors"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/runtime"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/resolve"
 )
 
 var _ = Describe("Container Check initialization", func() {
@@ -27,6 +33,15 @@ var _ = Describe("Container Check initialization", func() {
 				WithPyxisHost(pyxishost),
 				WithPlatform(platform),
 				WithInsecureConnection(),
+				WithDeterministicTimes(),
+				WithTraceOnFailure(),
+				WithFailFast(),
+				WithCheckTimeout(10*time.Minute),
+				WithCheckTimeoutFor("HasLicense", time.Minute),
+				WithEgressPolicy("HasUniqueTag"),
+				WithServiceProbes(),
+				WithProxy("http://proxy.example.com:3128", ".example.com"),
+				WithCABundle("/etc/pki/ca.pem"),
 			)
 
 			Expect(c.image).To(Equal(img))
@@ -36,6 +51,94 @@ var _ = Describe("Container Check initialization", func() {
 			Expect(c.pyxisHost).To(Equal(pyxishost))
 			Expect(c.platform).To(Equal(platform))
 			Expect(c.insecure).To(Equal(insecure))
+			Expect(c.clock).To(Equal(clock.Deterministic()))
+			Expect(c.traceOnFailure).To(BeTrue())
+			Expect(c.failFast).To(BeTrue())
+			Expect(c.timeouts.For("RunAsNonRoot")).To(Equal(10 * time.Minute))
+			Expect(c.timeouts.For("HasLicense")).To(Equal(time.Minute))
+			Expect(c.denyEgress).To(BeTrue())
+			Expect(c.egressAllowed).To(ConsistOf("HasUniqueTag"))
+			Expect(c.probeServices).To(BeTrue())
+			Expect(c.proxy.URL).To(Equal("http://proxy.example.com:3128"))
+			Expect(c.proxy.NoProxy).To(Equal(".example.com"))
+			Expect(c.caBundle).To(Equal("/etc/pki/ca.pem"))
+		})
+		Context("with a runtime config", func() {
+			It("should store the options the config sets", func() {
+				cfg := runtime.NewConfig().
+					WithArtifactsDir("/tmp/artifacts").
+					WithPlatform("s390x").
+					WithDockerConfig("dockerconfig.json").
+					WithCertificationProject("certproject", "token").
+					WithPyxisEnv("qa").
+					WithInsecureConnection().
+					WithRegistryMirrors("registry.redhat.io=mirror.example.com/redhat").
+					WithLockfile("preflight.lock").
+					WithUpdateLockfile().
+					WithTmpdir("/var/tmp").
+					WithCheckTimeout("10m").
+					WithCheckTimeouts("HasLicense=1m").
+					WithDeniedCheckEgress("HasUniqueTag")
+				cfg.RerunFailed = "artifacts/results.json"
+				c := NewCheck("placeholder", WithConfig(cfg))
+
+				Expect(c.artifactsDir).To(Equal("/tmp/artifacts"))
+				Expect(c.platform).To(Equal("s390x"))
+				Expect(c.dockerconfigjson).To(Equal("dockerconfig.json"))
+				Expect(c.certificationProjectID).To(Equal("certproject"))
+				Expect(c.pyxisToken).To(Equal("token"))
+				Expect(c.pyxisHost).To(Equal(runtime.PyxisHostLookup("qa", "")))
+				Expect(c.insecure).To(BeTrue())
+				Expect(c.mirrors).To(HaveLen(1))
+				Expect(c.mirrors[0].Source).To(Equal("registry.redhat.io"))
+				Expect(c.lockfile).To(Equal("preflight.lock"))
+				Expect(c.updateLockfile).To(BeTrue())
+				Expect(c.rerunFailed).To(Equal("artifacts/results.json"))
+				Expect(c.layerCache).ToNot(BeEmpty())
+				Expect(c.scratchDir).To(Equal("/var/tmp"))
+				Expect(c.timeouts.For("RunAsNonRoot")).To(Equal(10 * time.Minute))
+				Expect(c.timeouts.For("HasLicense")).To(Equal(time.Minute))
+				Expect(c.denyEgress).To(BeTrue())
+				Expect(c.egressAllowed).To(ConsistOf("HasUniqueTag"))
+			})
+
+			It("should be overridden by later options", func() {
+				c := NewCheck("placeholder", WithConfig(runtime.NewConfig()), WithPlatform("arm64"))
+				Expect(c.platform).To(Equal("arm64"))
+			})
+
+			It("should throw an error if a registry mirror is invalid", func() {
+				_, err := NewCheck("placeholder", WithConfig(runtime.NewConfig().WithRegistryMirrors("registry.redhat.io"))).Run(context.TODO())
+				Expect(err).To(MatchError(ContainSubstring("invalid registry mirror")))
+			})
+
+			It("should throw an error if a check timeout is invalid", func() {
+				_, err := NewCheck("placeholder", WithConfig(runtime.NewConfig().WithCheckTimeout("forever"))).Run(context.TODO())
+				Expect(err).To(MatchError(ContainSubstring(`invalid check timeout "forever"`)))
+			})
+
+			It("should throw an error if the previous results do not exist", func() {
+				_, err := NewCheck("placeholder", WithRerunFailed(filepath.Join(GinkgoT().TempDir(), "results.json"))).Run(context.TODO())
+				Expect(err).To(MatchError(ContainSubstring("could not read results")))
+			})
+
+			It("should throw an error if the previous results are of another image", func() {
+				path := filepath.Join(GinkgoT().TempDir(), "results.json")
+				Expect(os.WriteFile(path, []byte(`{"image": "quay.io/example/other:1", "results": {}}`), 0o644)).To(Succeed())
+				_, err := NewCheck("placeholder", WithRerunFailed(path)).Run(context.TODO())
+				Expect(err).To(MatchError(ContainSubstring("are the results of quay.io/example/other:1, not of placeholder")))
+			})
+
+			It("should throw an error if the lockfile does not exist", func() {
+				_, err := NewCheck("placeholder", WithLockfile(filepath.Join(GinkgoT().TempDir(), "preflight.lock"))).Run(context.TODO())
+				Expect(err).To(MatchError(ContainSubstring("could not read lockfile")))
+			})
+		})
+		Context("with the clock option", func() {
+			It("should store the provided clock", func() {
+				c := NewCheck("placeholder", WithClock(clock.Real()))
+				Expect(c.clock).To(Equal(clock.Real()))
+			})
 		})
 		Context("with the pyxisenv option", func() {
 			var env string
@@ -88,5 +191,13 @@ var _ = Describe("Container Check Execution", func() {
 			_, err := chk.Run(context.TODO())
 			Expect(err).To(MatchError(preflighterr.ErrCannotResolvePolicyException))
 		})
+
+		It("should fail if the image cannot be resolved", func() {
+			chk := NewCheck("artifact:team/app:1.2.3", WithImageResolver(resolve.Func(func(context.Context, string) (string, error) {
+				return "", errors.New("unknown artifact")
+			})))
+			_, err := chk.Run(context.TODO())
+			Expect(err).To(MatchError(ContainSubstring("could not resolve image artifact:team/app:1.2.3: unknown artifact")))
+		})
 	})
 })
@@ -0,0 +1,69 @@
+// Command scan-webhook is an example "scan on push" service. It receives
+// a webhook containing an image reference when an image is pushed to a
+// registry, checks the image against the Container Policy, and responds
+// with the results.
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/funcr"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/scan"
+)
+
+// pushEvent is the webhook payload. Adapt it to the payload sent by your registry.
+type pushEvent struct {
+	// Image should be referenced by digest, so that results can be cached.
+	Image string `json:"image"`
+}
+
+func main() {
+	logger := funcr.New(func(prefix, args string) { log.Println(prefix, args) }, funcr.Options{})
+
+	svc, err := scan.NewService(
+		scan.WithDockerConfigJSONFromFile(os.Getenv("DOCKER_CONFIG_JSON")),
+		scan.WithMaxConcurrentScans(2),
+		scan.WithCacheTTL(24*time.Hour),
+	)
+	if err != nil {
+		logger.Error(err, "unable to configure the scan service")
+		os.Exit(1)
+	}
+
+	http.HandleFunc("/scan", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var event pushEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil || event.Image == "" {
+			http.Error(w, "an image is required", http.StatusBadRequest)
+			return
+		}
+
+		ctx := logr.NewContext(r.Context(), logger.WithValues("image", event.Image))
+		result, err := svc.Scan(ctx, event.Image)
+		if err != nil {
+			logger.Error(err, "scan failed", "image", event.Image)
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		logger.Info("scanned image", "image", event.Image, "passed", result.Results.PassedOverall, "cached", result.Cached)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(result.Formatted)
+	})
+
+	logger.Info("listening", "address", ":8080")
+	if err := http.ListenAndServe(":8080", nil); err != nil {
+		logger.Error(err, "server stopped")
+		os.Exit(1)
+	}
+}
@@ -9,4 +9,6 @@ var (
 	ErrImageEmpty                   = errors.New("image is empty")
 	ErrCannotResolvePolicyException = errors.New("cannot resolve policy exception")
 	ErrCannotInitializeChecks       = errors.New("unable to initialize checks")
+	ErrServicesNotReady             = errors.New("services that checks depend on are not ready")
+	ErrClusterNotProvided           = errors.New("no cluster was provided to check the operator on")
 )
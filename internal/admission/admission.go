@@ -0,0 +1,132 @@
+// Package admission exports the container checks that can be evaluated at admission,
+// from the config and manifest of the images of a pod, or from the pod itself, as
+// Kyverno or Gatekeeper policies, so that clusters can enforce the same rules as the
+// checks.
+package admission
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/version"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Format is the admission controller policies are exported for.
+type Format = string
+
+const (
+	FormatKyverno    Format = "kyverno"
+	FormatGatekeeper Format = "gatekeeper"
+)
+
+// versionAnnotation records the version of preflight the policies were exported by.
+const versionAnnotation = "preflight.openshift.io/version"
+
+type Option = func(*exporter)
+
+// WithAudit only reports the resources that violate the policies, rather than denying
+// their admission.
+func WithAudit() Option {
+	return func(e *exporter) {
+		e.audit = true
+	}
+}
+
+// WithName sets the name of the exported policies, which prefixes the names of the
+// Gatekeeper constraints. Defaults to preflight.
+func WithName(name string) Option {
+	return func(e *exporter) {
+		e.name = name
+	}
+}
+
+type exporter struct {
+	name  string
+	audit bool
+}
+
+// Bundle is the admission policies exported for checks.
+type Bundle struct {
+	// Manifests are the resources of the policies, as a stream of YAML documents.
+	Manifests []byte
+	// Exported are the names of the checks the policies enforce.
+	Exported []string
+	// Unsupported are the names of the checks that cannot be evaluated at admission
+	// in the format, and are not enforced by the policies.
+	Unsupported []string
+}
+
+// Export returns the policies of format that enforce the checks that can be evaluated
+// at admission, e.g. the container checks of engine.PolicyChecks.
+func Export(format Format, checks []check.Check, opts ...Option) (Bundle, error) {
+	e := exporter{name: "preflight"}
+	for _, opt := range opts {
+		opt(&e)
+	}
+
+	var (
+		resources []interface{}
+		bundle    Bundle
+		err       error
+	)
+	switch format {
+	case FormatKyverno:
+		resources, bundle.Exported, bundle.Unsupported = e.kyverno(checks)
+	case FormatGatekeeper:
+		resources, bundle.Exported, bundle.Unsupported = e.gatekeeper(checks)
+	default:
+		return Bundle{}, fmt.Errorf("unknown admission policy format %q: must be %s or %s", format, FormatKyverno, FormatGatekeeper)
+	}
+
+	if bundle.Manifests, err = marshalDocuments(resources); err != nil {
+		return Bundle{}, err
+	}
+
+	return bundle, nil
+}
+
+// marshalDocuments returns resources as a stream of YAML documents.
+func marshalDocuments(resources []interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	for i, r := range resources {
+		doc, err := yaml.Marshal(r)
+		if err != nil {
+			return nil, fmt.Errorf("could not marshal the admission policy: %w", err)
+		}
+		if i > 0 {
+			b.WriteString("---\n")
+		}
+		b.Write(doc)
+	}
+
+	return b.Bytes(), nil
+}
+
+// annotations returns the annotations of the resources of the policies.
+func annotations() map[string]string {
+	return map[string]string{versionAnnotation: version.Version.Version}
+}
+
+// violationMessage describes the violation of chk.
+func violationMessage(chk check.Check) string {
+	return fmt.Sprintf("The image does not pass the preflight check %s: %s", chk.Name(), chk.Help().Suggestion)
+}
+
+// kebabCase returns name, e.g. RunAsNonRoot, in kebab case, e.g. run-as-non-root, so
+// that it can be used in the names of resources.
+func kebabCase(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) && i > 0 && !unicode.IsUpper(rune(name[i-1])) {
+			b.WriteByte('-')
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+
+	return b.String()
+}
@@ -0,0 +1,117 @@
+package admission
+
+import (
+	"strings"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/policy/container"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/yaml"
+)
+
+// documents unmarshals each YAML document of manifests.
+func documents(manifests []byte) []map[string]interface{} {
+	var docs []map[string]interface{}
+	for _, doc := range strings.Split(string(manifests), "---\n") {
+		var m map[string]interface{}
+		Expect(yaml.Unmarshal([]byte(doc), &m)).To(Succeed())
+		docs = append(docs, m)
+	}
+	return docs
+}
+
+var _ = Describe("Admission", func() {
+	checks := []check.Check{
+		&container.HasLicenseCheck{},
+		&container.MaxLayersCheck{},
+		&container.HasRequiredLabelsCheck{},
+		&container.RunAsNonRootCheck{},
+	}
+
+	Context("when exporting Kyverno policies", func() {
+		It("should export a rule for each check evaluated from the images", func() {
+			bundle, err := Export(FormatKyverno, checks)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(bundle.Exported).To(Equal([]string{"LayerCountAcceptable", "HasRequiredLabel", "RunAsNonRoot"}))
+			Expect(bundle.Unsupported).To(Equal([]string{"HasLicense"}))
+
+			docs := documents(bundle.Manifests)
+			Expect(docs).To(HaveLen(1))
+			Expect(docs[0]).To(HaveKeyWithValue("kind", "ClusterPolicy"))
+			Expect(docs[0]).To(HaveKeyWithValue("metadata", HaveKeyWithValue("name", "preflight")))
+			spec := docs[0]["spec"].(map[string]interface{})
+			Expect(spec).To(HaveKeyWithValue("validationFailureAction", "Enforce"))
+			Expect(spec["rules"]).To(ConsistOf(
+				HaveKeyWithValue("name", "layer-count-acceptable"),
+				HaveKeyWithValue("name", "has-required-label"),
+				HaveKeyWithValue("name", "run-as-non-root"),
+			))
+		})
+
+		It("should evaluate every required label, root user, and the maximum number of layers", func() {
+			bundle, err := Export(FormatKyverno, checks)
+			Expect(err).ToNot(HaveOccurred())
+			manifests := string(bundle.Manifests)
+			for _, label := range container.RequiredLabels() {
+				Expect(manifests).To(ContainSubstring(`imageData.configData.config.Labels."` + label + `"`))
+			}
+			Expect(manifests).To(ContainSubstring("imageData.configData.config.User"))
+			Expect(manifests).To(ContainSubstring("value: 40"))
+		})
+
+		It("should only audit violations with WithAudit", func() {
+			bundle, err := Export(FormatKyverno, checks, WithAudit(), WithName("certification"))
+			Expect(err).ToNot(HaveOccurred())
+			docs := documents(bundle.Manifests)
+			Expect(docs[0]).To(HaveKeyWithValue("metadata", HaveKeyWithValue("name", "certification")))
+			Expect(docs[0]).To(HaveKeyWithValue("spec", HaveKeyWithValue("validationFailureAction", "Audit")))
+		})
+
+		It("should export nothing if no check can be evaluated", func() {
+			bundle, err := Export(FormatKyverno, []check.Check{&container.HasLicenseCheck{}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(bundle.Exported).To(BeEmpty())
+			Expect(bundle.Manifests).To(BeEmpty())
+		})
+	})
+
+	Context("when exporting Gatekeeper policies", func() {
+		It("should export a template and constraint for each check evaluated from pods", func() {
+			bundle, err := Export(FormatGatekeeper, checks)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(bundle.Exported).To(Equal([]string{"RunAsNonRoot"}))
+			Expect(bundle.Unsupported).To(Equal([]string{"HasLicense", "LayerCountAcceptable", "HasRequiredLabel"}))
+
+			docs := documents(bundle.Manifests)
+			Expect(docs).To(HaveLen(2))
+			Expect(docs[0]).To(HaveKeyWithValue("kind", "ConstraintTemplate"))
+			Expect(docs[0]).To(HaveKeyWithValue("metadata", HaveKeyWithValue("name", "preflightrunasnonroot")))
+			Expect(string(bundle.Manifests)).To(ContainSubstring("package preflightrunasnonroot"))
+			Expect(docs[1]).To(HaveKeyWithValue("kind", "PreflightRunAsNonRoot"))
+			Expect(docs[1]).To(HaveKeyWithValue("metadata", HaveKeyWithValue("name", "preflight-run-as-non-root")))
+			Expect(docs[1]).To(HaveKeyWithValue("spec", HaveKeyWithValue("enforcementAction", "deny")))
+		})
+
+		It("should only audit violations with WithAudit", func() {
+			bundle, err := Export(FormatGatekeeper, checks, WithAudit())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(documents(bundle.Manifests)[1]).To(HaveKeyWithValue("spec", HaveKeyWithValue("enforcementAction", "dryrun")))
+		})
+	})
+
+	It("should reject an unknown format", func() {
+		_, err := Export("opa", checks)
+		Expect(err).To(MatchError(ContainSubstring("unknown admission policy format")))
+	})
+
+	DescribeTable("kebabCase",
+		func(name, expected string) {
+			Expect(kebabCase(name)).To(Equal(expected))
+		},
+		Entry("a single word", "Layer", "layer"),
+		Entry("several words", "RunAsNonRoot", "run-as-non-root"),
+		Entry("an acronym", "BasedOnUBI", "based-on-ubi"),
+	)
+})
@@ -0,0 +1,126 @@
+package admission
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/policy/container"
+)
+
+// gatekeeperRunAsNonRoot is the Rego of the constraint template of RunAsNonRootCheck.
+// Gatekeeper cannot read the USER of an image, so the containers of a pod must run as
+// a non-root user instead: either a non-zero runAsUser, or runAsNonRoot without one,
+// for which the kubelet refuses to run images whose USER is root.
+const gatekeeperRunAsNonRoot = `package %[1]s
+
+violation[{"msg": msg}] {
+  container := input_containers[_]
+  not non_root(container)
+  msg := sprintf("container %%v must set runAsNonRoot, or a non-zero runAsUser. %%v", [container.name, input.parameters.message])
+}
+
+non_root(container) {
+  effective(container, "runAsUser") > 0
+}
+
+non_root(container) {
+  effective(container, "runAsNonRoot") == true
+  not has_user(container)
+}
+
+has_user(container) {
+  effective(container, "runAsUser") != null
+}
+
+effective(container, field) = value {
+  value := container.securityContext[field]
+} else = value {
+  value := input.review.object.spec.securityContext[field]
+}
+
+input_containers[c] {
+  c := input.review.object.spec.containers[_]
+}
+
+input_containers[c] {
+  c := input.review.object.spec.initContainers[_]
+}
+
+input_containers[c] {
+  c := input.review.object.spec.ephemeralContainers[_]
+}
+`
+
+// gatekeeper returns a Gatekeeper ConstraintTemplate, and a Constraint of it, for each
+// check that can be evaluated from a pod.
+func (e exporter) gatekeeper(checks []check.Check) (resources []interface{}, exported, unsupported []string) {
+	action := "deny"
+	if e.audit {
+		action = "dryrun"
+	}
+
+	for _, chk := range checks {
+		var rego string
+		kind := "Preflight" + chk.Name()
+		switch chk.(type) {
+		case *container.RunAsNonRootCheck:
+			rego = fmt.Sprintf(gatekeeperRunAsNonRoot, strings.ToLower(kind))
+		default:
+			unsupported = append(unsupported, chk.Name())
+			continue
+		}
+
+		exported = append(exported, chk.Name())
+		resources = append(resources,
+			map[string]interface{}{
+				"apiVersion": "templates.gatekeeper.sh/v1",
+				"kind":       "ConstraintTemplate",
+				"metadata": map[string]interface{}{
+					"name":        strings.ToLower(kind),
+					"annotations": annotations(),
+				},
+				"spec": map[string]interface{}{
+					"crd": map[string]interface{}{
+						"spec": map[string]interface{}{
+							"names": map[string]interface{}{"kind": kind},
+							"validation": map[string]interface{}{
+								"openAPIV3Schema": map[string]interface{}{
+									"type": "object",
+									"properties": map[string]interface{}{
+										"message": map[string]interface{}{"type": "string"},
+									},
+								},
+							},
+						},
+					},
+					"targets": []interface{}{
+						map[string]interface{}{
+							"target": "admission.k8s.gatekeeper.sh",
+							"rego":   rego,
+						},
+					},
+				},
+			},
+			map[string]interface{}{
+				"apiVersion": "constraints.gatekeeper.sh/v1beta1",
+				"kind":       kind,
+				"metadata": map[string]interface{}{
+					"name":        e.name + "-" + kebabCase(chk.Name()),
+					"annotations": annotations(),
+				},
+				"spec": map[string]interface{}{
+					"enforcementAction": action,
+					"match": map[string]interface{}{
+						"kinds": []interface{}{
+							map[string]interface{}{"apiGroups": []string{""}, "kinds": []string{"Pod"}},
+						},
+					},
+					"parameters": map[string]interface{}{"message": violationMessage(chk)},
+				},
+			},
+		)
+	}
+
+	return resources, exported, unsupported
+}
@@ -0,0 +1,108 @@
+package admission
+
+import (
+	"fmt"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/policy/container"
+)
+
+// kyvernoContainers lists every container of the pod under review.
+const kyvernoContainers = "request.object.spec.[ephemeralContainers, initContainers, containers][]"
+
+// kyvernoCondition is a condition of a Kyverno rule.
+type kyvernoCondition struct {
+	Key      string      `json:"key"`
+	Operator string      `json:"operator"`
+	Value    interface{} `json:"value"`
+}
+
+// kyverno returns a Kyverno ClusterPolicy with a rule for each check that can be
+// evaluated from the config and manifest of the images of a pod. Kyverno reads them
+// from the registry with the imageRegistry context.
+func (e exporter) kyverno(checks []check.Check) (resources []interface{}, exported, unsupported []string) {
+	rules := []interface{}{}
+	for _, chk := range checks {
+		var conditions []kyvernoCondition
+		switch chk.(type) {
+		case *container.HasRequiredLabelsCheck:
+			for _, label := range container.RequiredLabels() {
+				conditions = append(conditions, kyvernoCondition{
+					Key:      fmt.Sprintf("{{ imageData.configData.config.Labels.%q || '' }}", label),
+					Operator: "Equals",
+					Value:    "",
+				})
+			}
+		case *container.RunAsNonRootCheck:
+			conditions = []kyvernoCondition{{
+				Key:      "{{ imageData.configData.config.User || '' }}",
+				Operator: "AnyIn",
+				Value:    container.RootUsers(),
+			}}
+		case *container.MaxLayersCheck:
+			conditions = []kyvernoCondition{{
+				Key:      "{{ length(imageData.manifest.layers) }}",
+				Operator: "GreaterThan",
+				Value:    container.MaxLayers(),
+			}}
+		default:
+			unsupported = append(unsupported, chk.Name())
+			continue
+		}
+
+		exported = append(exported, chk.Name())
+		rules = append(rules, map[string]interface{}{
+			"name": kebabCase(chk.Name()),
+			"match": map[string]interface{}{
+				"any": []interface{}{
+					map[string]interface{}{
+						"resources": map[string]interface{}{
+							"kinds":      []string{"Pod"},
+							"operations": []string{"CREATE", "UPDATE"},
+						},
+					},
+				},
+			},
+			"validate": map[string]interface{}{
+				"message": violationMessage(chk),
+				"foreach": []interface{}{
+					map[string]interface{}{
+						"list": kyvernoContainers,
+						"context": []interface{}{
+							map[string]interface{}{
+								"name":          "imageData",
+								"imageRegistry": map[string]interface{}{"reference": "{{ element.image }}"},
+							},
+						},
+						"deny": map[string]interface{}{
+							"conditions": map[string]interface{}{"any": conditions},
+						},
+					},
+				},
+			},
+		})
+	}
+
+	if len(exported) == 0 {
+		return nil, nil, unsupported
+	}
+
+	action := "Enforce"
+	if e.audit {
+		action = "Audit"
+	}
+	ann := annotations()
+	ann["policies.kyverno.io/title"] = "Red Hat Certification"
+	ann["policies.kyverno.io/description"] = "The images of pods must pass the preflight checks that can be evaluated at admission."
+
+	return []interface{}{map[string]interface{}{
+		"apiVersion": "kyverno.io/v1",
+		"kind":       "ClusterPolicy",
+		"metadata":   map[string]interface{}{"name": e.name, "annotations": ann},
+		"spec": map[string]interface{}{
+			"validationFailureAction": action,
+			"background":              true,
+			"rules":                   rules,
+		},
+	}}, exported, unsupported
+}
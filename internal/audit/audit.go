@@ -0,0 +1,282 @@
+// Package audit re-evaluates the results of a previous execution under the current
+// policy, from the results and policy manifest it wrote, without checking the image
+// again. This answers whether an image that was checked in the past would pass today.
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/artifacts"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/certification"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/formatters"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/image"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/log"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/policy"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/runtime"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/version"
+
+	"github.com/go-logr/logr"
+)
+
+// PolicyManifestFilename is the name of the artifact recording the policy that an
+// execution evaluated.
+const PolicyManifestFilename = "policy.json"
+
+// levelOptional is the level of checks that are executed, but not enforced.
+const levelOptional = "optional"
+
+// PolicyManifest records the policy, and the checks in it, that an execution evaluated.
+type PolicyManifest struct {
+	Policy      policy.Policy          `json:"policy"`
+	LibraryInfo version.VersionContext `json:"test_library"`
+	Checks      []PolicyManifestCheck  `json:"checks"`
+}
+
+// PolicyManifestCheck is a check in a PolicyManifest.
+type PolicyManifestCheck struct {
+	Name        string `json:"name"`
+	Level       string `json:"level"`
+	Description string `json:"description,omitempty"`
+}
+
+// NewPolicyManifest returns the manifest of policy p, consisting of checks.
+func NewPolicyManifest(p policy.Policy, checks []check.Check) PolicyManifest {
+	m := PolicyManifest{Policy: p, LibraryInfo: version.Version, Checks: make([]PolicyManifestCheck, 0, len(checks))}
+	for _, c := range checks {
+		m.Checks = append(m.Checks, PolicyManifestCheck{
+			Name:        c.Name(),
+			Level:       c.Metadata().Level,
+			Description: c.Metadata().Description,
+		})
+	}
+
+	return m
+}
+
+// WritePolicyManifest writes the manifest of policy p, consisting of checks, using the
+// ArtifactWriter configured in ctx, if any.
+func WritePolicyManifest(ctx context.Context, p policy.Policy, checks []check.Check) error {
+	logger := logr.FromContextOrDiscard(ctx)
+
+	artifactWriter := artifacts.WriterFromContext(ctx)
+	if artifactWriter == nil {
+		return nil
+	}
+
+	manifestJSON, err := json.MarshalIndent(NewPolicyManifest(p, checks), "", "    ")
+	if err != nil {
+		return fmt.Errorf("could not marshal policy manifest: %w", err)
+	}
+
+	fileName, err := artifactWriter.WriteFile(PolicyManifestFilename, bytes.NewReader(manifestJSON))
+	if err != nil {
+		return fmt.Errorf("failed to save file to artifacts directory: %w", err)
+	}
+
+	logger.V(log.TRC).Info("policy manifest written to disk", "filename", fileName)
+
+	return nil
+}
+
+// LoadPolicyManifest returns the policy manifest at path.
+func LoadPolicyManifest(path string) (PolicyManifest, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return PolicyManifest{}, fmt.Errorf("could not read policy manifest: %w", err)
+	}
+
+	var m PolicyManifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return PolicyManifest{}, fmt.Errorf("could not parse policy manifest: %w", err)
+	}
+	if m.Policy == "" {
+		return PolicyManifest{}, fmt.Errorf("%s is not a policy manifest", path)
+	}
+
+	return m, nil
+}
+
+// LoadResults returns the results at path, written by a previous execution in the
+// default JSON format.
+func LoadResults(path string) (formatters.UserResponse, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return formatters.UserResponse{}, fmt.Errorf("could not read results: %w", err)
+	}
+
+	var results formatters.UserResponse
+	if err := json.Unmarshal(b, &results); err != nil {
+		return formatters.UserResponse{}, fmt.Errorf("could not parse results: %w", err)
+	}
+	if results.Image == "" {
+		return formatters.UserResponse{}, fmt.Errorf("%s does not contain preflight results", path)
+	}
+
+	return results, nil
+}
+
+// Changes describes how the current policy differs from the one a previous execution
+// evaluated.
+type Changes struct {
+	// Added are the checks that are enforced by the current policy, but were not by
+	// the previous execution's.
+	Added []string
+	// Removed are the checks that the previous execution enforced, but the current
+	// policy does not. Their outcomes no longer count.
+	Removed []string
+	// NotEvaluated are the checks that are enforced by the current policy, but that
+	// the previous execution has no outcome for. They are reported as errors, since
+	// there is no evidence that the image passes them.
+	NotEvaluated []string
+}
+
+// Reevaluate returns the results of the previous execution recorded in results and
+// manifest, evaluated under the current policy, consisting of current, and how the
+// policy changed. The outcome of each check is the one recorded, and its description
+// and help are those of the current check.
+func Reevaluate(results formatters.UserResponse, manifest PolicyManifest, current []check.Check) (certification.Results, Changes) {
+	type recorded struct {
+		status  certification.Status
+		started time.Time
+		elapsed time.Duration
+	}
+	outcomes := make(map[string]recorded)
+	record := func(name string, started *time.Time, elapsedMillis float64, status certification.Status) {
+		r := recorded{status: status, elapsed: time.Duration(elapsedMillis) * time.Millisecond}
+		if started != nil {
+			r.started = *started
+		}
+		outcomes[name] = r
+	}
+	for _, c := range results.Results.Passed {
+		record(c.Name, c.StartTime, c.ElapsedTime, certification.StatusPassed)
+	}
+	for _, c := range results.Results.Failed {
+		record(c.Name, c.StartTime, c.ElapsedTime, certification.StatusFailed)
+	}
+	for _, c := range results.Results.Errors {
+		record(c.Name, c.StartTime, c.ElapsedTime, certification.StatusErrored)
+	}
+
+	enforcedBefore := make(map[string]bool, len(manifest.Checks))
+	for _, c := range manifest.Checks {
+		if c.Level != levelOptional {
+			enforcedBefore[c.Name] = true
+		}
+	}
+
+	r := certification.Results{
+		TestedImage:       results.Image,
+		ImageDigest:       results.ImageDigest,
+		TestedOn:          runtime.UnknownOpenshiftClusterVersion(),
+		CertificationHash: results.CertificationHash,
+	}
+	for _, layer := range results.SkippedLayers {
+		r.SkippedLayers = append(r.SkippedLayers, certification.SkippedLayer{
+			Digest:    layer.Digest,
+			MediaType: layer.MediaType,
+			Reason:    layer.Reason,
+		})
+	}
+	for _, conn := range results.DeniedConnections {
+		r.DeniedConnections = append(r.DeniedConnections, certification.DeniedConnection{
+			Check:   conn.Check,
+			Address: conn.Address,
+		})
+	}
+
+	var changes Changes
+	enforcedNow := make(map[string]bool, len(current))
+	for _, c := range current {
+		if c.Metadata().Level == levelOptional {
+			continue
+		}
+		enforcedNow[c.Name()] = true
+		if !enforcedBefore[c.Name()] {
+			changes.Added = append(changes.Added, c.Name())
+		}
+
+		outcome, ok := outcomes[c.Name()]
+		if !ok {
+			changes.NotEvaluated = append(changes.NotEvaluated, c.Name())
+			r.Errors = append(r.Errors, certification.Result{Check: notEvaluatedCheck{c}})
+			continue
+		}
+
+		result := certification.Result{Check: c, StartTime: outcome.started, ElapsedTime: outcome.elapsed}
+		switch outcome.status {
+		case certification.StatusPassed:
+			r.Passed = append(r.Passed, result)
+		case certification.StatusFailed:
+			r.Failed = append(r.Failed, result)
+		default:
+			r.Errors = append(r.Errors, result)
+		}
+	}
+
+	for _, c := range manifest.Checks {
+		if enforcedBefore[c.Name] && !enforcedNow[c.Name] {
+			changes.Removed = append(changes.Removed, c.Name)
+		}
+	}
+
+	r.PassedOverall = len(r.Failed) == 0 && len(r.Errors) == 0
+
+	return r, changes
+}
+
+// notEvaluatedCheck is a check in the current policy that a previous execution has
+// no outcome for.
+type notEvaluatedCheck struct {
+	check.Check
+}
+
+func (c notEvaluatedCheck) Validate(context.Context, image.ImageReference) (bool, error) {
+	return false, fmt.Errorf("check %s was not evaluated by the previous execution", c.Name())
+}
+
+func (c notEvaluatedCheck) Help() check.HelpText {
+	return check.HelpText{
+		Message:    fmt.Sprintf("Check %s was not evaluated by the previous execution, so there is no evidence that the image passes it.", c.Name()),
+		Suggestion: "Check the image again with the current version of preflight.",
+	}
+}
+
+// Rerun splits checks into the checks that did not pass in the previous execution
+// recorded in results, which must be executed again, and the results of those that
+// did, with their recorded outcomes. Checks that the previous execution has no outcome
+// for are executed again as well, and optional checks are not, since their outcomes are
+// not recorded.
+func Rerun(results formatters.UserResponse, checks []check.Check) (rerun []check.Check, passed []certification.Result) {
+	outcomes := make(map[string]certification.Result, len(results.Results.Passed))
+	for _, c := range results.Results.Passed {
+		r := certification.Result{ElapsedTime: time.Duration(c.ElapsedTime) * time.Millisecond, Attempts: c.Attempts}
+		if c.StartTime != nil {
+			r.StartTime = *c.StartTime
+		}
+		outcomes[c.Name] = r
+	}
+
+	for _, c := range checks {
+		if c.Metadata().Level == levelOptional {
+			continue
+		}
+
+		outcome, ok := outcomes[c.Name()]
+		if !ok {
+			rerun = append(rerun, c)
+			continue
+		}
+
+		outcome.Check = c
+		passed = append(passed, outcome)
+	}
+
+	return rerun, passed
+}
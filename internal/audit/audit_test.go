@@ -0,0 +1,183 @@
+package audit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/artifacts"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/certification"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/formatters"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/image"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/policy"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func newCheck(name, level, description string) check.Check {
+	return check.NewGenericCheck(
+		name,
+		func(context.Context, image.ImageReference) (bool, error) { return true, nil },
+		check.Metadata{Level: level, Description: description},
+		check.HelpText{Message: "help for " + name},
+	)
+}
+
+func names(results []certification.Result) []string {
+	n := make([]string, 0, len(results))
+	for _, r := range results {
+		n = append(n, r.Name())
+	}
+	return n
+}
+
+var _ = Describe("Audit", func() {
+	Describe("Writing and loading a policy manifest", func() {
+		It("should record the policy and its checks", func() {
+			aw, err := artifacts.NewFilesystemWriter(artifacts.WithDirectory(GinkgoT().TempDir()))
+			Expect(err).ToNot(HaveOccurred())
+			ctx := artifacts.ContextWithWriter(context.Background(), aw)
+
+			checks := []check.Check{newCheck("HasLicense", "best", "license"), newCheck("Optional", "optional", "")}
+			Expect(WritePolicyManifest(ctx, policy.PolicyContainer, checks)).To(Succeed())
+
+			m, err := LoadPolicyManifest(filepath.Join(aw.Path(), PolicyManifestFilename))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(m.Policy).To(Equal(policy.PolicyContainer))
+			Expect(m.Checks).To(Equal([]PolicyManifestCheck{
+				{Name: "HasLicense", Level: "best", Description: "license"},
+				{Name: "Optional", Level: "optional"},
+			}))
+		})
+
+		It("should not write a manifest without an artifact writer", func() {
+			Expect(WritePolicyManifest(context.Background(), policy.PolicyContainer, nil)).To(Succeed())
+		})
+
+		It("should reject a file that is not a policy manifest", func() {
+			path := filepath.Join(GinkgoT().TempDir(), "policy.json")
+			Expect(os.WriteFile(path, []byte(`{"image": "quay.io/example/image:1"}`), 0o644)).To(Succeed())
+			_, err := LoadPolicyManifest(path)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Loading results", func() {
+		It("should reject a file that does not contain results", func() {
+			path := filepath.Join(GinkgoT().TempDir(), "results.json")
+			Expect(os.WriteFile(path, []byte(`{"policy": "container"}`), 0o644)).To(Succeed())
+			_, err := LoadResults(path)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Re-evaluating results", func() {
+		var results formatters.UserResponse
+		var manifest PolicyManifest
+
+		BeforeEach(func() {
+			path := filepath.Join(GinkgoT().TempDir(), "results.json")
+			Expect(os.WriteFile(path, []byte(`{
+				"image": "quay.io/example/image:1",
+				"passed": false,
+				"results": {
+					"passed": [{"name": "HasLicense", "elapsed_time": 12}, {"name": "Removed"}],
+					"failed": [{"name": "RunAsNonRoot", "description": "an old description"}],
+					"errors": []
+				}
+			}`), 0o644)).To(Succeed())
+
+			var err error
+			results, err = LoadResults(path)
+			Expect(err).ToNot(HaveOccurred())
+
+			manifest = PolicyManifest{Policy: policy.PolicyContainer, Checks: []PolicyManifestCheck{
+				{Name: "HasLicense", Level: "best"},
+				{Name: "Removed", Level: "best"},
+				{Name: "RunAsNonRoot", Level: "best"},
+				{Name: "WasOptional", Level: "optional"},
+			}}
+		})
+
+		It("should use the recorded outcomes of the checks in the current policy", func() {
+			current := []check.Check{
+				newCheck("HasLicense", "best", "license"),
+				newCheck("RunAsNonRoot", "best", "the current description"),
+			}
+
+			r, changes := Reevaluate(results, manifest, current)
+			Expect(r.TestedImage).To(Equal("quay.io/example/image:1"))
+			Expect(names(r.Passed)).To(Equal([]string{"HasLicense"}))
+			Expect(r.Passed[0].ElapsedTime.Milliseconds()).To(BeEquivalentTo(12))
+			Expect(names(r.Failed)).To(Equal([]string{"RunAsNonRoot"}))
+			Expect(r.Failed[0].Metadata().Description).To(Equal("the current description"))
+			Expect(r.Errors).To(BeEmpty())
+			Expect(r.PassedOverall).To(BeFalse())
+			Expect(changes.Removed).To(Equal([]string{"Removed"}))
+			Expect(changes.Added).To(BeEmpty())
+		})
+
+		It("should pass if the checks that failed are no longer enforced", func() {
+			current := []check.Check{
+				newCheck("HasLicense", "best", "license"),
+				newCheck("RunAsNonRoot", "optional", ""),
+			}
+
+			r, changes := Reevaluate(results, manifest, current)
+			Expect(r.PassedOverall).To(BeTrue())
+			Expect(changes.Removed).To(ConsistOf("Removed", "RunAsNonRoot"))
+		})
+
+		It("should report checks that were not evaluated as errors", func() {
+			current := []check.Check{
+				newCheck("HasLicense", "best", "license"),
+				newCheck("WasOptional", "best", ""),
+				newCheck("New", "best", ""),
+			}
+
+			r, changes := Reevaluate(results, manifest, current)
+			Expect(r.PassedOverall).To(BeFalse())
+			Expect(names(r.Errors)).To(Equal([]string{"WasOptional", "New"}))
+			Expect(r.Errors[0].Help().Message).To(ContainSubstring("was not evaluated"))
+			Expect(changes.Added).To(Equal([]string{"WasOptional", "New"}))
+			Expect(changes.NotEvaluated).To(Equal([]string{"WasOptional", "New"}))
+		})
+	})
+
+	Describe("Rerunning the checks that did not pass", func() {
+		It("should only rerun the checks without a recorded pass", func() {
+			path := filepath.Join(GinkgoT().TempDir(), "results.json")
+			Expect(os.WriteFile(path, []byte(`{
+				"image": "quay.io/example/image:1",
+				"passed": false,
+				"results": {
+					"passed": [{"name": "HasLicense", "elapsed_time": 12, "attempts": 2}],
+					"failed": [{"name": "RunAsNonRoot"}],
+					"errors": [{"name": "HasNoProhibitedPackages"}]
+				}
+			}`), 0o644)).To(Succeed())
+			results, err := LoadResults(path)
+			Expect(err).ToNot(HaveOccurred())
+
+			checks := []check.Check{
+				newCheck("HasLicense", "best", "license"),
+				newCheck("RunAsNonRoot", "best", ""),
+				newCheck("HasNoProhibitedPackages", "best", ""),
+				newCheck("New", "best", ""),
+				newCheck("Optional", "optional", ""),
+			}
+
+			rerun, passed := Rerun(results, checks)
+			Expect(rerun).To(HaveLen(3))
+			Expect(rerun[0].Name()).To(Equal("RunAsNonRoot"))
+			Expect(rerun[1].Name()).To(Equal("HasNoProhibitedPackages"))
+			Expect(rerun[2].Name()).To(Equal("New"))
+			Expect(names(passed)).To(Equal([]string{"HasLicense"}))
+			Expect(passed[0].Metadata().Description).To(Equal("license"))
+			Expect(passed[0].ElapsedTime.Milliseconds()).To(BeEquivalentTo(12))
+			Expect(passed[0].Attempts).To(Equal(2))
+		})
+	})
+})
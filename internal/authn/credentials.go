@@ -0,0 +1,83 @@
+package authn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	craneauthn "github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// Credentials authenticate with a single registry, without a docker config file.
+type Credentials struct {
+	// Registry is the registry the credentials are used for, e.g. quay.io.
+	Registry string
+	Username string
+	Password string
+	// Token is a registry bearer token, used instead of Username and Password.
+	Token string
+}
+
+// Validate returns an error if c does not contain exactly one of a username
+// and password, or a token.
+func (c Credentials) Validate() error {
+	switch {
+	case c.Token != "" && (c.Username != "" || c.Password != ""):
+		return errors.New("a registry token cannot be used with a registry username or password")
+	case c.Token == "" && (c.Username == "") != (c.Password == ""):
+		return errors.New("a registry username and password must be used together")
+	}
+
+	return nil
+}
+
+// IsSet returns true if c contains a username and password, or a token.
+func (c Credentials) IsSet() bool {
+	return c.Token != "" || c.Username != ""
+}
+
+// ForImage returns a copy of c that is used for the registry of image.
+func (c Credentials) ForImage(image string) (Credentials, error) {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("image uri could not be parsed: %w", err)
+	}
+
+	c.Registry = ref.Context().RegistryStr()
+	return c, nil
+}
+
+// matches returns true if c should be used to authenticate with target.
+func (c Credentials) matches(target craneauthn.Resource) bool {
+	registry, err := name.NewRegistry(c.Registry)
+	if err != nil {
+		return false
+	}
+
+	return registry.RegistryStr() == target.RegistryStr()
+}
+
+func (c Credentials) authenticator() craneauthn.Authenticator {
+	if c.Token != "" {
+		return craneauthn.FromConfig(craneauthn.AuthConfig{RegistryToken: c.Token})
+	}
+
+	return craneauthn.FromConfig(craneauthn.AuthConfig{Username: c.Username, Password: c.Password})
+}
+
+type contextKey string
+
+const credentialsContextKey contextKey = "RegistryCredentials"
+
+// ContextWithCredentials returns a copy of ctx in which c are used to authenticate with
+// c.Registry, in preference to any docker config file.
+func ContextWithCredentials(ctx context.Context, c Credentials) context.Context {
+	return context.WithValue(ctx, credentialsContextKey, c)
+}
+
+// CredentialsFromContext returns the Credentials in ctx, and whether there were any.
+func CredentialsFromContext(ctx context.Context) (Credentials, bool) {
+	c, ok := ctx.Value(credentialsContextKey).(Credentials)
+	return c, ok && c.IsSet()
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/docker/cli/cli/config"
@@ -18,6 +19,8 @@ import (
 type preflightKeychain struct {
 	dockercfg string
 	ctx       context.Context
+	// ambient resolves credentials for registries that dockercfg has none for.
+	ambient craneauthn.Keychain
 }
 
 type PreflightKeychainOption func(*preflightKeychain)
@@ -31,50 +34,125 @@ func WithDockerConfig(dockercfg string) PreflightKeychainOption {
 	}
 }
 
+// keychain is the default configuration of the keychains returned by PreflightKeychain.
 var keychain = preflightKeychain{
-	ctx: context.Background(), // Initialize here, but can be overridden with PreflightKeychain func
+	ctx:     context.Background(), // Initialize here, but can be overridden with PreflightKeychain func
+	ambient: AmbientKeychain(),
 }
 
-// PreflightKeychain will return the preflight keychain as a craneauthn.Keychain.
-// This operates as a singleton. If provided an option, that option overwrites
-// the single instance of PreflightKeychain. If provided no option, the keychain
-// is returned as already configured.
+// PreflightKeychain will return a preflight keychain, configured with opts, as a
+// craneauthn.Keychain. Each call returns a new keychain, so that checks executed
+// concurrently with different docker configs or credentials do not share them.
 func PreflightKeychain(ctx context.Context, opts ...PreflightKeychainOption) craneauthn.Keychain {
+	k := keychain
 	for _, opt := range opts {
-		opt(&keychain)
+		opt(&k)
 	}
 
-	keychain.ctx = ctx
+	k.ctx = ctx
 
-	return &keychain
+	return &k
 }
 
 // Resolve returns an Authenticator with credentials, or Anonymous if no suitable credentials
 // are found for the target. This implements the Keychain interface from go-containerregistry,
 // and will be passed to crane,.
 //
-// If the dockerConfig value is empty, assume Anonymous.
-// If the file cannot be found or read, that constitutes an error.
+// Credentials in the context for the target's registry take precedence. Otherwise,
+// credentials are read from the dockerConfig file, if one is configured, including from the
+// credential helpers it configures. If it has none for the target, the ambient credentials
+// configured for docker and podman are used.
+// If the dockerConfig file cannot be found or read, that constitutes an error.
 // Can return os.IsNotExist.
 func (k *preflightKeychain) Resolve(target craneauthn.Resource) (craneauthn.Authenticator, error) {
 	logger := logr.FromContextOrDiscard(k.ctx)
 
 	logger.V(log.TRC).Info("entering preflight keychain Resolve")
 
-	if k.dockercfg == "" {
-		// No file specified. No auth expected
+	if creds, ok := CredentialsFromContext(k.ctx); ok && creds.matches(target) {
+		return creds.authenticator(), nil
+	}
+
+	if k.dockercfg != "" {
+		auth, err := resolveFromFile(k.dockercfg, target)
+		if err != nil {
+			return nil, err
+		}
+		if auth != craneauthn.Anonymous {
+			return auth, nil
+		}
+	}
+
+	if k.ambient == nil {
 		return craneauthn.Anonymous, nil
 	}
 
-	r, err := os.Open(k.dockercfg)
+	// Ambient credentials are a convenience, so problems reading them are not
+	// fatal; the registry may well allow anonymous pulls.
+	auth, err := k.ambient.Resolve(target)
+	if err != nil {
+		logger.Info("warning: unable to read ambient registry credentials", "registry", target.RegistryStr(), "reason", err.Error())
+		return craneauthn.Anonymous, nil
+	}
+
+	return auth, nil
+}
+
+// AmbientKeychain returns a Keychain resolving credentials from the locations docker and
+// podman store them, in order: $REGISTRY_AUTH_FILE, docker's config.json (in $HOME/.docker
+// or $DOCKER_CONFIG), $XDG_RUNTIME_DIR/containers/auth.json, and
+// $XDG_CONFIG_HOME/containers/auth.json. The credential helpers configured in each,
+// such as ecr-login or gcloud, are used. The environment is read on each Resolve.
+func AmbientKeychain() craneauthn.Keychain {
+	return ambientKeychain{}
+}
+
+type ambientKeychain struct{}
+
+func (ambientKeychain) Resolve(target craneauthn.Resource) (craneauthn.Authenticator, error) {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			configHome = filepath.Join(home, ".config")
+		}
+	}
+
+	keychains := []craneauthn.Keychain{}
+	if authFile := os.Getenv("REGISTRY_AUTH_FILE"); authFile != "" {
+		keychains = append(keychains, authFileKeychain(authFile))
+	}
+	keychains = append(keychains, craneauthn.DefaultKeychain)
+	if configHome != "" {
+		keychains = append(keychains, authFileKeychain(filepath.Join(configHome, "containers", "auth.json")))
+	}
+
+	return craneauthn.NewMultiKeychain(keychains...).Resolve(target)
+}
+
+// authFileKeychain is a Keychain resolving credentials from the docker config or podman
+// auth.json file at its path. It resolves Anonymous if the file does not exist.
+type authFileKeychain string
+
+func (path authFileKeychain) Resolve(target craneauthn.Resource) (craneauthn.Authenticator, error) {
+	if _, err := os.Stat(string(path)); os.IsNotExist(err) {
+		return craneauthn.Anonymous, nil
+	}
+
+	return resolveFromFile(string(path), target)
+}
+
+// resolveFromFile returns an Authenticator with the credentials for target in the docker
+// config file at path, or Anonymous if it has none.
+func resolveFromFile(path string, target craneauthn.Resource) (craneauthn.Authenticator, error) {
+	r, err := os.Open(path)
 	if os.IsNotExist(err) {
-		return nil, fmt.Errorf("could not find authfile: %s: %w", k.dockercfg, err)
+		return nil, fmt.Errorf("could not find authfile: %s: %w", path, err)
 	}
 	if err != nil {
-		return nil, fmt.Errorf("could not open authfile: %s: %v", k.dockercfg, err)
+		return nil, fmt.Errorf("could not open authfile: %s: %v", path, err)
 	}
-
 	defer r.Close()
+
 	cf, err := config.LoadFromReader(r)
 	if err != nil {
 		return nil, fmt.Errorf("could not load authfile from reader: %v", err)
@@ -107,10 +185,15 @@ func (k *preflightKeychain) Resolve(target craneauthn.Resource) (craneauthn.Auth
 		if err != nil {
 			return nil, fmt.Errorf("could not get auth config: %v", err)
 		}
+		// Credential helpers set ServerAddress even when they have no
+		// credentials for key, and it is not used, so clear it before
+		// checking whether any credentials were found.
+		cfg.ServerAddress = ""
 		if cfg != empty {
 			break
 		}
 	}
+
 	if cfg == empty {
 		return craneauthn.Anonymous, nil
 	}
@@ -200,3 +200,246 @@ func TestVariousPaths(t *testing.T) {
 		})
 	}
 }
+
+// isolateAmbientConfig points the locations ambient credentials are read from
+// at an empty directory, and returns it.
+func isolateAmbientConfig(t *testing.T) string {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	// The docker config directory derived from HOME is cached, so set it explicitly.
+	t.Setenv("DOCKER_CONFIG", filepath.Join(dir, ".docker"))
+	t.Setenv("XDG_RUNTIME_DIR", dir)
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("REGISTRY_AUTH_FILE", "")
+	return dir
+}
+
+func writeFile(t *testing.T, path, content string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir %q: %v", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o700); err != nil {
+		t.Fatalf("write %q: %v", path, err)
+	}
+}
+
+func resolveUser(t *testing.T, target craneauthn.Resource) string {
+	auth, err := keychain.Resolve(target)
+	if err != nil {
+		t.Fatalf("Resolve() = %v", err)
+	}
+	cfg, err := auth.Authorization()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg.Username
+}
+
+func TestAmbientCredentials(t *testing.T) {
+	tests := []struct {
+		desc string
+		path func(dir string) string
+	}{{
+		desc: "REGISTRY_AUTH_FILE",
+		path: func(dir string) string {
+			path := filepath.Join(dir, "registry-auth.json")
+			t.Setenv("REGISTRY_AUTH_FILE", path)
+			return path
+		},
+	}, {
+		desc: "docker config.json",
+		path: func(dir string) string { return filepath.Join(dir, ".docker", "config.json") },
+	}, {
+		desc: "podman auth.json in XDG_RUNTIME_DIR",
+		path: func(dir string) string { return filepath.Join(dir, "containers", "auth.json") },
+	}}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			dir := isolateAmbientConfig(t)
+			writeFile(t, test.path(dir), fmt.Sprintf(`{"auths": {"test.io": {"auth": %q}}}`, encode("ambient", "bar")))
+			keychain.dockercfg = ""
+			keychain.ctx = context.TODO()
+
+			if user := resolveUser(t, testRegistry); user != "ambient" {
+				t.Errorf("got user %q, want %q", user, "ambient")
+			}
+		})
+	}
+}
+
+func TestDockerConfigTakesPrecedenceOverAmbientCredentials(t *testing.T) {
+	dir := isolateAmbientConfig(t)
+	writeFile(t, filepath.Join(dir, ".docker", "config.json"), fmt.Sprintf(`{"auths": {"test.io": {"auth": %q}}}`, encode("ambient", "bar")))
+	cd := setupConfigFile(t, fmt.Sprintf(`{"auths": {"test.io": {"auth": %q}}}`, encode("foo", "bar")))
+	defer os.RemoveAll(filepath.Dir(cd))
+
+	if user := resolveUser(t, testRegistry); user != "foo" {
+		t.Errorf("got user %q, want %q", user, "foo")
+	}
+
+	// Registries the docker config has no credentials for use the ambient credentials.
+	other, _ := name.NewRegistry("other.io", name.WeakValidation)
+	writeFile(t, filepath.Join(dir, ".docker", "config.json"), fmt.Sprintf(`{"auths": {"other.io": {"auth": %q}}}`, encode("ambient", "bar")))
+	if user := resolveUser(t, other); user != "ambient" {
+		t.Errorf("got user %q, want %q", user, "ambient")
+	}
+}
+
+func TestInvalidAmbientCredentialsAreAnonymous(t *testing.T) {
+	dir := isolateAmbientConfig(t)
+	writeFile(t, filepath.Join(dir, ".docker", "config.json"), `}{`)
+	keychain.dockercfg = ""
+	keychain.ctx = context.TODO()
+
+	auth, err := keychain.Resolve(testRegistry)
+	if err != nil {
+		t.Fatalf("Resolve() = %v", err)
+	}
+	if auth != craneauthn.Anonymous {
+		t.Errorf("expected Anonymous, got %v", auth)
+	}
+}
+
+func TestCredentialHelper(t *testing.T) {
+	dir := isolateAmbientConfig(t)
+
+	// A credential helper returning credentials for test.io only.
+	helperDir := filepath.Join(dir, "bin")
+	writeFile(t, filepath.Join(helperDir, "docker-credential-preflight-test"), `#!/bin/sh
+read server
+if [ "$server" = "test.io" ]; then
+  echo '{"ServerURL": "test.io", "Username": "helper", "Secret": "bar"}'
+else
+  echo 'credentials not found in native keychain'
+  exit 1
+fi
+`)
+	t.Setenv("PATH", helperDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	cd := setupConfigFile(t, `{"credHelpers": {"test.io": "preflight-test", "other.io": "preflight-test"}}`)
+	defer os.RemoveAll(filepath.Dir(cd))
+
+	if user := resolveUser(t, testRegistry); user != "helper" {
+		t.Errorf("got user %q, want %q", user, "helper")
+	}
+
+	other, _ := name.NewRegistry("other.io", name.WeakValidation)
+	auth, err := keychain.Resolve(other)
+	if err != nil {
+		t.Fatalf("Resolve() = %v", err)
+	}
+	if auth != craneauthn.Anonymous {
+		t.Errorf("expected Anonymous, got %v", auth)
+	}
+}
+
+func TestContextCredentials(t *testing.T) {
+	isolateAmbientConfig(t)
+	cd := setupConfigFile(t, fmt.Sprintf(`{"auths": {"test.io": {"auth": %q}}}`, encode("foo", "bar")))
+	defer os.RemoveAll(filepath.Dir(cd))
+
+	creds, err := Credentials{Username: "ephemeral", Password: "secret"}.ForImage("test.io/my-repo:latest")
+	if err != nil {
+		t.Fatalf("ForImage() = %v", err)
+	}
+	keychain.ctx = ContextWithCredentials(context.TODO(), creds)
+	t.Cleanup(func() { keychain.ctx = context.TODO() })
+
+	// The credentials take precedence over the docker config for their registry.
+	if user := resolveUser(t, testRegistry); user != "ephemeral" {
+		t.Errorf("got user %q, want %q", user, "ephemeral")
+	}
+
+	// Other registries are not sent the credentials.
+	other, _ := name.NewRegistry("other.io", name.WeakValidation)
+	auth, err := keychain.Resolve(other)
+	if err != nil {
+		t.Fatalf("Resolve() = %v", err)
+	}
+	if auth != craneauthn.Anonymous {
+		t.Errorf("expected Anonymous, got %v", auth)
+	}
+}
+
+func TestContextCredentialsToken(t *testing.T) {
+	isolateAmbientConfig(t)
+	keychain.dockercfg = ""
+
+	creds, err := Credentials{Token: "token"}.ForImage("test.io/my-repo:latest")
+	if err != nil {
+		t.Fatalf("ForImage() = %v", err)
+	}
+	keychain.ctx = ContextWithCredentials(context.TODO(), creds)
+	t.Cleanup(func() { keychain.ctx = context.TODO() })
+
+	auth, err := keychain.Resolve(testRepo)
+	if err != nil {
+		t.Fatalf("Resolve() = %v", err)
+	}
+	cfg, err := auth.Authorization()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := cfg, (&craneauthn.AuthConfig{RegistryToken: "token"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestCredentialsValidate(t *testing.T) {
+	tests := []struct {
+		desc    string
+		creds   Credentials
+		wantErr bool
+	}{
+		{desc: "none", creds: Credentials{}},
+		{desc: "username and password", creds: Credentials{Username: "foo", Password: "bar"}},
+		{desc: "token", creds: Credentials{Token: "token"}},
+		{desc: "username only", creds: Credentials{Username: "foo"}, wantErr: true},
+		{desc: "password only", creds: Credentials{Password: "bar"}, wantErr: true},
+		{desc: "token and username", creds: Credentials{Username: "foo", Password: "bar", Token: "token"}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			if err := test.creds.Validate(); (err != nil) != test.wantErr {
+				t.Errorf("Validate() = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestPreflightKeychainsAreIndependent(t *testing.T) {
+	dir := isolateAmbientConfig(t)
+	keychain.dockercfg = ""
+	keychain.ctx = context.TODO()
+
+	tenantA := filepath.Join(dir, "a.json")
+	writeFile(t, tenantA, fmt.Sprintf(`{"auths": {"test.io": {"auth": %q}}}`, encode("tenant-a", "secret")))
+
+	configured := PreflightKeychain(context.TODO(), WithDockerConfig(tenantA))
+	unconfigured := PreflightKeychain(context.TODO())
+
+	auth, err := configured.Resolve(testRegistry)
+	if err != nil {
+		t.Fatalf("Resolve() = %v", err)
+	}
+	cfg, err := auth.Authorization()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Username != "tenant-a" {
+		t.Errorf("got user %q, want %q", cfg.Username, "tenant-a")
+	}
+
+	auth, err = unconfigured.Resolve(testRegistry)
+	if err != nil {
+		t.Fatalf("Resolve() = %v", err)
+	}
+	if auth != craneauthn.Anonymous {
+		t.Errorf("expected Anonymous, got %v", auth)
+	}
+	if keychain.dockercfg != "" {
+		t.Errorf("the default keychain was configured with %q", keychain.dockercfg)
+	}
+}
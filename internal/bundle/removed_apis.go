@@ -0,0 +1,287 @@
+package bundle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// RemovedAPI is an API used by a resource of a bundle that was removed in an OpenShift
+// version the bundle targets.
+type RemovedAPI struct {
+	// File is the path of the manifest of the resource, relative to the bundle.
+	File string `json:"file"`
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// API is the group, version, and kind of the API, e.g. apiextensions.k8s.io/v1beta1
+	// CustomResourceDefinition. The resource either is of the API, or its RBAC rules
+	// grant access to it.
+	API string `json:"api"`
+	// RemovedIn is the OpenShift version the API was removed in, e.g. 4.9.
+	RemovedIn string `json:"removedIn"`
+	// Replacement is the API to use instead. It is empty if the API has no replacement.
+	Replacement string `json:"replacement,omitempty"`
+}
+
+func (r RemovedAPI) String() string {
+	s := fmt.Sprintf("%s: %s %s uses %s, which was removed in OpenShift %s", r.File, r.Kind, r.Name, r.API, r.RemovedIn)
+	if r.Replacement != "" {
+		s += fmt.Sprintf(", use %s instead", r.Replacement)
+	}
+
+	return s
+}
+
+// removal is an API removed in an OpenShift version.
+type removal struct {
+	groupVersion string
+	kind         string
+	removedIn    string
+	replacement  string
+}
+
+// removals are the APIs removed from Kubernetes, by the OpenShift version that ships the
+// Kubernetes version that removed them. See
+// https://kubernetes.io/docs/reference/using-api/deprecation-guide/.
+var removals = []removal{
+	// Kubernetes 1.16
+	{"extensions/v1beta1", "DaemonSet", "4.3", "apps/v1 DaemonSet"},
+	{"extensions/v1beta1", "Deployment", "4.3", "apps/v1 Deployment"},
+	{"extensions/v1beta1", "NetworkPolicy", "4.3", "networking.k8s.io/v1 NetworkPolicy"},
+	{"extensions/v1beta1", "PodSecurityPolicy", "4.3", ""},
+	{"extensions/v1beta1", "ReplicaSet", "4.3", "apps/v1 ReplicaSet"},
+	{"apps/v1beta1", "Deployment", "4.3", "apps/v1 Deployment"},
+	{"apps/v1beta1", "StatefulSet", "4.3", "apps/v1 StatefulSet"},
+	{"apps/v1beta2", "DaemonSet", "4.3", "apps/v1 DaemonSet"},
+	{"apps/v1beta2", "Deployment", "4.3", "apps/v1 Deployment"},
+	{"apps/v1beta2", "ReplicaSet", "4.3", "apps/v1 ReplicaSet"},
+	{"apps/v1beta2", "StatefulSet", "4.3", "apps/v1 StatefulSet"},
+	// Kubernetes 1.22
+	{"admissionregistration.k8s.io/v1beta1", "MutatingWebhookConfiguration", "4.9", "admissionregistration.k8s.io/v1 MutatingWebhookConfiguration"},
+	{"admissionregistration.k8s.io/v1beta1", "ValidatingWebhookConfiguration", "4.9", "admissionregistration.k8s.io/v1 ValidatingWebhookConfiguration"},
+	{"apiextensions.k8s.io/v1beta1", "CustomResourceDefinition", "4.9", "apiextensions.k8s.io/v1 CustomResourceDefinition"},
+	{"apiregistration.k8s.io/v1beta1", "APIService", "4.9", "apiregistration.k8s.io/v1 APIService"},
+	{"certificates.k8s.io/v1beta1", "CertificateSigningRequest", "4.9", "certificates.k8s.io/v1 CertificateSigningRequest"},
+	{"coordination.k8s.io/v1beta1", "Lease", "4.9", "coordination.k8s.io/v1 Lease"},
+	{"extensions/v1beta1", "Ingress", "4.9", "networking.k8s.io/v1 Ingress"},
+	{"networking.k8s.io/v1beta1", "Ingress", "4.9", "networking.k8s.io/v1 Ingress"},
+	{"networking.k8s.io/v1beta1", "IngressClass", "4.9", "networking.k8s.io/v1 IngressClass"},
+	{"rbac.authorization.k8s.io/v1beta1", "ClusterRole", "4.9", "rbac.authorization.k8s.io/v1 ClusterRole"},
+	{"rbac.authorization.k8s.io/v1beta1", "ClusterRoleBinding", "4.9", "rbac.authorization.k8s.io/v1 ClusterRoleBinding"},
+	{"rbac.authorization.k8s.io/v1beta1", "Role", "4.9", "rbac.authorization.k8s.io/v1 Role"},
+	{"rbac.authorization.k8s.io/v1beta1", "RoleBinding", "4.9", "rbac.authorization.k8s.io/v1 RoleBinding"},
+	{"scheduling.k8s.io/v1beta1", "PriorityClass", "4.9", "scheduling.k8s.io/v1 PriorityClass"},
+	{"storage.k8s.io/v1beta1", "CSIDriver", "4.9", "storage.k8s.io/v1 CSIDriver"},
+	{"storage.k8s.io/v1beta1", "CSINode", "4.9", "storage.k8s.io/v1 CSINode"},
+	{"storage.k8s.io/v1beta1", "StorageClass", "4.9", "storage.k8s.io/v1 StorageClass"},
+	{"storage.k8s.io/v1beta1", "VolumeAttachment", "4.9", "storage.k8s.io/v1 VolumeAttachment"},
+	// Kubernetes 1.25
+	{"autoscaling/v2beta1", "HorizontalPodAutoscaler", "4.12", "autoscaling/v2 HorizontalPodAutoscaler"},
+	{"batch/v1beta1", "CronJob", "4.12", "batch/v1 CronJob"},
+	{"discovery.k8s.io/v1beta1", "EndpointSlice", "4.12", "discovery.k8s.io/v1 EndpointSlice"},
+	{"events.k8s.io/v1beta1", "Event", "4.12", "events.k8s.io/v1 Event"},
+	{"node.k8s.io/v1beta1", "RuntimeClass", "4.12", "node.k8s.io/v1 RuntimeClass"},
+	{"policy/v1beta1", "PodDisruptionBudget", "4.12", "policy/v1 PodDisruptionBudget"},
+	{"policy/v1beta1", "PodSecurityPolicy", "4.12", ""},
+	// Kubernetes 1.26
+	{"autoscaling/v2beta2", "HorizontalPodAutoscaler", "4.13", "autoscaling/v2 HorizontalPodAutoscaler"},
+	{"flowcontrol.apiserver.k8s.io/v1beta1", "FlowSchema", "4.13", "flowcontrol.apiserver.k8s.io/v1 FlowSchema"},
+	{"flowcontrol.apiserver.k8s.io/v1beta1", "PriorityLevelConfiguration", "4.13", "flowcontrol.apiserver.k8s.io/v1 PriorityLevelConfiguration"},
+	// Kubernetes 1.27
+	{"storage.k8s.io/v1beta1", "CSIStorageCapacity", "4.14", "storage.k8s.io/v1 CSIStorageCapacity"},
+	// Kubernetes 1.29
+	{"flowcontrol.apiserver.k8s.io/v1beta2", "FlowSchema", "4.16", "flowcontrol.apiserver.k8s.io/v1 FlowSchema"},
+	{"flowcontrol.apiserver.k8s.io/v1beta2", "PriorityLevelConfiguration", "4.16", "flowcontrol.apiserver.k8s.io/v1 PriorityLevelConfiguration"},
+}
+
+// podSecurityPolicies is the removal of PodSecurityPolicies, which RBAC rules must not
+// grant access to either.
+var podSecurityPolicies = removal{"policy/v1beta1", "PodSecurityPolicy", "4.12", ""}
+
+// manifest is the part of a manifest needed to find the APIs it uses.
+type manifest struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	// Rules are those of Roles and ClusterRoles.
+	Rules []rbacv1.PolicyRule `json:"rules"`
+}
+
+// GetRemovedAPIs returns the APIs used by the manifests of the bundle at bundlePath that
+// were removed in an OpenShift version the bundle targets, per its
+// com.redhat.openshift.versions annotation. A bundle without the annotation targets
+// every version.
+func GetRemovedAPIs(ctx context.Context, bundlePath string) ([]RemovedAPI, error) {
+	upTo, err := targetedVersionsUpTo(ctx, bundlePath)
+	if err != nil {
+		return nil, err
+	}
+
+	manifestsPath := filepath.Join(bundlePath, "manifests")
+	var removed []RemovedAPI
+	err = filepath.WalkDir(manifestsPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml", ".json":
+		default:
+			return nil
+		}
+
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("could not read manifest: %w", err)
+		}
+		file, _ := filepath.Rel(bundlePath, path)
+		found, err := removedAPIsOf(file, b)
+		if err != nil {
+			return err
+		}
+		for _, r := range found {
+			if targets(upTo, r.RemovedIn) {
+				removed = append(removed, r)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not read the manifests of the bundle: %w", err)
+	}
+
+	sort.SliceStable(removed, func(i, j int) bool {
+		return removed[i].File < removed[j].File
+	})
+
+	return removed, nil
+}
+
+// removedAPIsOf returns the removed APIs used by the manifest in file, regardless of
+// the OpenShift versions targeted.
+func removedAPIsOf(file string, b []byte) ([]RemovedAPI, error) {
+	var m manifest
+	if err := yaml.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("could not parse manifest %s: %w", file, err)
+	}
+
+	var removed []RemovedAPI
+	found := func(r removal) {
+		removed = append(removed, RemovedAPI{
+			File:        file,
+			Kind:        m.Kind,
+			Name:        m.Metadata.Name,
+			API:         r.groupVersion + " " + r.kind,
+			RemovedIn:   r.removedIn,
+			Replacement: r.replacement,
+		})
+	}
+
+	for _, r := range removals {
+		if m.APIVersion == r.groupVersion && m.Kind == r.kind {
+			found(r)
+		}
+	}
+
+	rules := m.Rules
+	if m.Kind == operatorsv1alpha1.ClusterServiceVersionKind {
+		var csv operatorsv1alpha1.ClusterServiceVersion
+		if err := yaml.Unmarshal(b, &csv); err != nil {
+			return nil, fmt.Errorf("could not parse manifest %s: %w", file, err)
+		}
+		spec := csv.Spec.InstallStrategy.StrategySpec
+		for _, permissions := range [][]operatorsv1alpha1.StrategyDeploymentPermissions{spec.Permissions, spec.ClusterPermissions} {
+			for _, p := range permissions {
+				rules = append(rules, p.Rules...)
+			}
+		}
+	}
+	for _, rule := range rules {
+		if grantsPodSecurityPolicies(rule) {
+			found(podSecurityPolicies)
+			break
+		}
+	}
+
+	return removed, nil
+}
+
+// grantsPodSecurityPolicies returns true if rule grants access to PodSecurityPolicies.
+func grantsPodSecurityPolicies(rule rbacv1.PolicyRule) bool {
+	var group, resource bool
+	for _, g := range rule.APIGroups {
+		group = group || g == "policy" || g == "extensions"
+	}
+	for _, r := range rule.Resources {
+		resource = resource || r == "podsecuritypolicies"
+	}
+
+	return group && resource
+}
+
+// targetedVersionsUpTo returns the latest OpenShift version targeted by the bundle at
+// bundlePath, or nil if it targets every version from some version on.
+func targetedVersionsUpTo(ctx context.Context, bundlePath string) (*semver.Version, error) {
+	annotationsFile, err := os.Open(filepath.Join(bundlePath, "metadata", "annotations.yaml"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not open annotations.yaml: %w", err)
+	}
+	defer annotationsFile.Close()
+
+	annotations, err := LoadAnnotations(ctx, annotationsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return versionsUpTo(annotations.OpenshiftVersions)
+}
+
+// versionsUpTo returns the latest OpenShift version in versions, the value of a
+// com.redhat.openshift.versions annotation, or nil if there is none. E.g. =v4.10 and
+// v4.8-v4.10 are up to 4.10, and v4.8 is all versions from 4.8 on.
+func versionsUpTo(versions string) (*semver.Version, error) {
+	versions = cleanStringToGetTheVersionToParse(strings.TrimSpace(versions))
+	var upTo string
+	switch {
+	case versions == "" || strings.Contains(versions, ","):
+		// Lists of versions are all versions from the first one on.
+		return nil, nil
+	case strings.HasPrefix(versions, "="):
+		upTo = strings.TrimPrefix(versions, "=")
+	case strings.Contains(versions, "-"):
+		_, upTo, _ = strings.Cut(versions, "-")
+	default:
+		return nil, nil
+	}
+
+	v, err := semver.ParseTolerant(upTo)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse the version: %v", err)
+	}
+
+	return &v, nil
+}
+
+// targets returns true if versions up to upTo include the OpenShift version removedIn.
+func targets(upTo *semver.Version, removedIn string) bool {
+	if upTo == nil {
+		return true
+	}
+
+	return upTo.GTE(semver.MustParse(removedIn + ".0"))
+}
@@ -0,0 +1,150 @@
+package bundle
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/ginkgo/v2/dsl/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Removed APIs", func() {
+	const (
+		v1beta1CRD = `apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: memcacheds.cache.example.com
+`
+		v1CRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: memcacheds.cache.example.com
+`
+		cronJob = `{"apiVersion": "batch/v1beta1", "kind": "CronJob", "metadata": {"name": "cleanup"}}`
+		csv     = `apiVersion: operators.coreos.com/v1alpha1
+kind: ClusterServiceVersion
+metadata:
+  name: memcached-operator.v0.0.1
+spec:
+  install:
+    strategy: deployment
+    spec:
+      clusterPermissions:
+      - serviceAccountName: memcached-operator
+        rules:
+        - apiGroups: ["policy"]
+          resources: ["podsecuritypolicies"]
+          verbs: ["use"]
+`
+	)
+
+	var bundlePath string
+
+	// writeBundle writes a bundle targeting openshiftVersions, if not empty, with the
+	// manifests by file name.
+	writeBundle := func(openshiftVersions string, manifests map[string]string) {
+		Expect(os.MkdirAll(filepath.Join(bundlePath, "manifests"), 0o755)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(bundlePath, "metadata"), 0o755)).To(Succeed())
+		annotations := "annotations:\n  operators.operatorframework.io.bundle.package.v1: memcached\n"
+		if openshiftVersions != "" {
+			annotations += "  com.redhat.openshift.versions: " + openshiftVersions + "\n"
+		}
+		Expect(os.WriteFile(filepath.Join(bundlePath, "metadata", "annotations.yaml"), []byte(annotations), 0o644)).To(Succeed())
+		for name, contents := range manifests {
+			Expect(os.WriteFile(filepath.Join(bundlePath, "manifests", name), []byte(contents), 0o644)).To(Succeed())
+		}
+	}
+
+	BeforeEach(func() {
+		bundlePath = GinkgoT().TempDir()
+	})
+
+	It("should find the resources that use APIs removed in the versions the bundle targets", func() {
+		writeBundle(`"v4.8-v4.12"`, map[string]string{
+			"crd.yaml":     v1beta1CRD,
+			"cronjob.json": cronJob,
+			"csv.yaml":     csv,
+		})
+		removed, err := GetRemovedAPIs(context.TODO(), bundlePath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(removed).To(Equal([]RemovedAPI{
+			{
+				File:        "manifests/crd.yaml",
+				Kind:        "CustomResourceDefinition",
+				Name:        "memcacheds.cache.example.com",
+				API:         "apiextensions.k8s.io/v1beta1 CustomResourceDefinition",
+				RemovedIn:   "4.9",
+				Replacement: "apiextensions.k8s.io/v1 CustomResourceDefinition",
+			},
+			{
+				File:        "manifests/cronjob.json",
+				Kind:        "CronJob",
+				Name:        "cleanup",
+				API:         "batch/v1beta1 CronJob",
+				RemovedIn:   "4.12",
+				Replacement: "batch/v1 CronJob",
+			},
+			{
+				File:      "manifests/csv.yaml",
+				Kind:      "ClusterServiceVersion",
+				Name:      "memcached-operator.v0.0.1",
+				API:       "policy/v1beta1 PodSecurityPolicy",
+				RemovedIn: "4.12",
+			},
+		}))
+		Expect(removed[0].String()).To(Equal("manifests/crd.yaml: CustomResourceDefinition memcacheds.cache.example.com uses " +
+			"apiextensions.k8s.io/v1beta1 CustomResourceDefinition, which was removed in OpenShift 4.9, " +
+			"use apiextensions.k8s.io/v1 CustomResourceDefinition instead"))
+	})
+
+	It("should ignore APIs removed after the versions the bundle targets", func() {
+		writeBundle(`"=v4.11"`, map[string]string{
+			"crd.yaml":     v1beta1CRD,
+			"cronjob.json": cronJob,
+		})
+		removed, err := GetRemovedAPIs(context.TODO(), bundlePath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(removed).To(HaveLen(1))
+		Expect(removed[0].Kind).To(Equal("CustomResourceDefinition"))
+	})
+
+	It("should consider every version targeted by a bundle without the annotation", func() {
+		writeBundle("", map[string]string{"cronjob.json": cronJob})
+		removed, err := GetRemovedAPIs(context.TODO(), bundlePath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(removed).To(HaveLen(1))
+	})
+
+	It("should not find removed APIs in a bundle that uses none", func() {
+		writeBundle(`"v4.8"`, map[string]string{"crd.yaml": v1CRD})
+		removed, err := GetRemovedAPIs(context.TODO(), bundlePath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(removed).To(BeEmpty())
+	})
+
+	It("should throw an error if a manifest is malformed", func() {
+		writeBundle(`"v4.8"`, map[string]string{"crd.yaml": "apiVersion: [v1"})
+		_, err := GetRemovedAPIs(context.TODO(), bundlePath)
+		Expect(err).To(MatchError(ContainSubstring("could not parse manifest manifests/crd.yaml")))
+	})
+
+	DescribeTable("the latest OpenShift version targeted",
+		func(versions string, expected string) {
+			upTo, err := versionsUpTo(versions)
+			Expect(err).ToNot(HaveOccurred())
+			if expected == "" {
+				Expect(upTo).To(BeNil())
+				return
+			}
+			Expect(upTo.String()).To(Equal(expected))
+		},
+		Entry("a single version", "=v4.10", "4.10.0"),
+		Entry("a range", "v4.8-v4.10", "4.10.0"),
+		Entry("a quoted range", `"v4.8-v4.10"`, "4.10.0"),
+		Entry("every version from a version on", "v4.8", ""),
+		Entry("a list of versions", "v4.5,v4.6", ""),
+		Entry("no versions", "", ""),
+	)
+})
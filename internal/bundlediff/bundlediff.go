@@ -0,0 +1,395 @@
+// Package bundlediff compares two versions of an operator bundle: the fields of their
+// ClusterServiceVersions, the RBAC permissions they grant, and the images they reference,
+// so that reviewers can verify that an update changes no more than it should.
+package bundlediff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	mimage "github.com/operator-framework/operator-manifest-tools/pkg/image"
+	"github.com/operator-framework/operator-manifest-tools/pkg/pullspec"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// Bundle is the content of an operator bundle that is compared.
+type Bundle struct {
+	Source
+	csv map[string]interface{}
+	// permissions are the RBAC permissions granted by the bundle.
+	permissions []Permission
+	images      []string
+}
+
+// Source identifies a bundle.
+type Source struct {
+	// Image is the bundle image, or the directory, the bundle was read from.
+	Image string `json:"image"`
+	// Digest is the digest of the bundle image. It is empty for directories.
+	Digest string `json:"digest,omitempty"`
+	// CSV is the name of the ClusterServiceVersion of the bundle.
+	CSV string `json:"csv"`
+}
+
+// Permission is a single permission granted by the RBAC rules of a bundle, to a
+// resource, or to a non-resource URL.
+type Permission struct {
+	// Scope is "cluster", or "namespace".
+	Scope string `json:"scope"`
+	// Subject is the service account the CSV grants the permission to, or the kind and
+	// name of the Role or ClusterRole of the bundle that grants it.
+	Subject        string `json:"subject"`
+	APIGroup       string `json:"apiGroup,omitempty"`
+	Resource       string `json:"resource,omitempty"`
+	ResourceName   string `json:"resourceName,omitempty"`
+	NonResourceURL string `json:"nonResourceURL,omitempty"`
+	Verb           string `json:"verb"`
+}
+
+func (p Permission) String() string {
+	target := p.NonResourceURL
+	if target == "" {
+		target = p.Resource
+		if p.APIGroup != "" {
+			target = p.APIGroup + "/" + p.Resource
+		}
+		if p.ResourceName != "" {
+			target += "/" + p.ResourceName
+		}
+	}
+
+	return fmt.Sprintf("%s %s: %s %s", p.Scope, p.Subject, p.Verb, target)
+}
+
+// Report is the difference between two versions of a bundle.
+type Report struct {
+	Old Source `json:"old"`
+	New Source `json:"new"`
+	// CSV are the fields of the ClusterServiceVersion that changed, except for its
+	// permissions, which are in RBAC.
+	CSV    []FieldChange `json:"csv"`
+	RBAC   RBACChanges   `json:"rbac"`
+	Images ImageChanges  `json:"images"`
+}
+
+// Unchanged returns true if the bundles have the same content.
+func (r Report) Unchanged() bool {
+	return len(r.CSV) == 0 &&
+		len(r.RBAC.Added) == 0 && len(r.RBAC.Removed) == 0 &&
+		len(r.Images.Added) == 0 && len(r.Images.Removed) == 0 && len(r.Images.Changed) == 0
+}
+
+// FieldChange is a field of the ClusterServiceVersion that was added, removed, or changed.
+// Old is nil if the field was added, and New if it was removed.
+type FieldChange struct {
+	// Path is the path of the field, e.g. spec.installModes[0].supported.
+	Path string      `json:"path"`
+	Old  interface{} `json:"old"`
+	New  interface{} `json:"new"`
+}
+
+// RBACChanges are the permissions granted only by the new, or the old, bundle.
+type RBACChanges struct {
+	Added   []Permission `json:"added"`
+	Removed []Permission `json:"removed"`
+}
+
+// ImageChanges are the image references of the new bundle, compared to the old one.
+type ImageChanges struct {
+	Added   []string      `json:"added"`
+	Removed []string      `json:"removed"`
+	Changed []ImageChange `json:"changed"`
+}
+
+// ImageChange is a repository referenced by both bundles with a different tag or digest.
+type ImageChange struct {
+	Repository string `json:"repository"`
+	Old        string `json:"old"`
+	New        string `json:"new"`
+}
+
+// Load reads the bundle in dir, which contains its manifests directory.
+func Load(dir string) (*Bundle, error) {
+	manifestsDir := filepath.Join(dir, "manifests")
+	b := &Bundle{Source: Source{Image: dir}}
+
+	err := filepath.WalkDir(manifestsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml", ".json":
+		default:
+			return nil
+		}
+
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		return b.add(filepath.Base(path), contents)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not read the manifests of the bundle in %s: %w", dir, err)
+	}
+	if b.csv == nil {
+		return nil, fmt.Errorf("the bundle in %s does not have a ClusterServiceVersion", dir)
+	}
+
+	operatorManifests, err := pullspec.FromDirectory(manifestsDir, pullspec.DefaultHeuristic)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the manifests of the bundle in %s: %w", dir, err)
+	}
+	if b.images, err = mimage.Extract(operatorManifests); err != nil {
+		return nil, fmt.Errorf("could not find the images of the bundle in %s: %w", dir, err)
+	}
+
+	return b, nil
+}
+
+// add adds the content of the manifest in file to b.
+func (b *Bundle) add(file string, contents []byte) error {
+	var m struct {
+		Kind     string `json:"kind"`
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Rules []rbacv1.PolicyRule `json:"rules"`
+	}
+	if err := yaml.Unmarshal(contents, &m); err != nil {
+		return fmt.Errorf("could not parse manifest %s: %w", file, err)
+	}
+
+	switch m.Kind {
+	case operatorsv1alpha1.ClusterServiceVersionKind:
+		var csv operatorsv1alpha1.ClusterServiceVersion
+		if err := yaml.Unmarshal(contents, &csv); err != nil {
+			return fmt.Errorf("could not parse manifest %s: %w", file, err)
+		}
+		spec := csv.Spec.InstallStrategy.StrategySpec
+		for _, p := range spec.ClusterPermissions {
+			b.permissions = append(b.permissions, permissions("cluster", p.ServiceAccountName, p.Rules)...)
+		}
+		for _, p := range spec.Permissions {
+			b.permissions = append(b.permissions, permissions("namespace", p.ServiceAccountName, p.Rules)...)
+		}
+
+		// The permissions are compared on their own.
+		if err := yaml.Unmarshal(contents, &b.csv); err != nil {
+			return fmt.Errorf("could not parse manifest %s: %w", file, err)
+		}
+		if installSpec, ok := nested(b.csv, "spec", "install", "spec"); ok {
+			delete(installSpec, "permissions")
+			delete(installSpec, "clusterPermissions")
+		}
+		b.CSV = csv.Name
+	case "ClusterRole":
+		b.permissions = append(b.permissions, permissions("cluster", "ClusterRole "+m.Metadata.Name, m.Rules)...)
+	case "Role":
+		b.permissions = append(b.permissions, permissions("namespace", "Role "+m.Metadata.Name, m.Rules)...)
+	}
+
+	return nil
+}
+
+// nested returns the map at path in m, if there is one.
+func nested(m map[string]interface{}, path ...string) (map[string]interface{}, bool) {
+	for _, key := range path {
+		next, ok := m[key].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		m = next
+	}
+
+	return m, true
+}
+
+// permissions returns each permission granted by rules.
+func permissions(scope, subject string, rules []rbacv1.PolicyRule) []Permission {
+	var perms []Permission
+	for _, rule := range rules {
+		for _, verb := range rule.Verbs {
+			for _, url := range rule.NonResourceURLs {
+				perms = append(perms, Permission{Scope: scope, Subject: subject, NonResourceURL: url, Verb: verb})
+			}
+			for _, group := range rule.APIGroups {
+				for _, resource := range rule.Resources {
+					if len(rule.ResourceNames) == 0 {
+						perms = append(perms, Permission{Scope: scope, Subject: subject, APIGroup: group, Resource: resource, Verb: verb})
+					}
+					for _, resourceName := range rule.ResourceNames {
+						perms = append(perms, Permission{Scope: scope, Subject: subject, APIGroup: group, Resource: resource, ResourceName: resourceName, Verb: verb})
+					}
+				}
+			}
+		}
+	}
+
+	return perms
+}
+
+// Diff returns the difference between the old and new versions of a bundle.
+func Diff(old, new *Bundle) Report {
+	report := Report{
+		Old: old.Source,
+		New: new.Source,
+		CSV: []FieldChange{},
+		RBAC: RBACChanges{
+			Added:   subtractPermissions(new.permissions, old.permissions),
+			Removed: subtractPermissions(old.permissions, new.permissions),
+		},
+		Images: diffImages(old.images, new.images),
+	}
+
+	oldFields, newFields := map[string]interface{}{}, map[string]interface{}{}
+	flatten("", old.csv, oldFields)
+	flatten("", new.csv, newFields)
+	for path, oldValue := range oldFields {
+		newValue, ok := newFields[path]
+		switch {
+		case !ok:
+			report.CSV = append(report.CSV, FieldChange{Path: path, Old: oldValue})
+		case !equal(oldValue, newValue):
+			report.CSV = append(report.CSV, FieldChange{Path: path, Old: oldValue, New: newValue})
+		}
+	}
+	for path, newValue := range newFields {
+		if _, ok := oldFields[path]; !ok {
+			report.CSV = append(report.CSV, FieldChange{Path: path, New: newValue})
+		}
+	}
+	sort.Slice(report.CSV, func(i, j int) bool {
+		return report.CSV[i].Path < report.CSV[j].Path
+	})
+
+	return report
+}
+
+// flatten adds the leaves of value to fields, by their path under prefix.
+func flatten(prefix string, value interface{}, fields map[string]interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			path := key
+			if prefix != "" {
+				path = prefix + "." + key
+			}
+			flatten(path, child, fields)
+		}
+	case []interface{}:
+		for i, child := range v {
+			flatten(fmt.Sprintf("%s[%d]", prefix, i), child, fields)
+		}
+	default:
+		fields[prefix] = v
+	}
+}
+
+// equal returns true if a and b, leaves of a manifest, are the same.
+func equal(a, b interface{}) bool {
+	aj, _ := json.Marshal(a)
+	bj, _ := json.Marshal(b)
+	return string(aj) == string(bj)
+}
+
+// subtractPermissions returns the permissions in a that are not in b, sorted.
+func subtractPermissions(a, b []Permission) []Permission {
+	inB := make(map[Permission]bool, len(b))
+	for _, p := range b {
+		inB[p] = true
+	}
+
+	diff := []Permission{}
+	seen := map[Permission]bool{}
+	for _, p := range a {
+		if !inB[p] && !seen[p] {
+			diff = append(diff, p)
+			seen[p] = true
+		}
+	}
+	sort.Slice(diff, func(i, j int) bool {
+		return diff[i].String() < diff[j].String()
+	})
+
+	return diff
+}
+
+// diffImages returns the images in new compared to old. Images of the same repository
+// with a different tag or digest are changed, if each bundle references the repository
+// once.
+func diffImages(old, new []string) ImageChanges {
+	changes := ImageChanges{Added: []string{}, Removed: []string{}, Changed: []ImageChange{}}
+	oldByRepo, newByRepo := byRepository(old), byRepository(new)
+
+	for repo, oldImages := range oldByRepo {
+		newImages := newByRepo[repo]
+		if len(oldImages) == 1 && len(newImages) == 1 {
+			if oldImages[0] != newImages[0] {
+				changes.Changed = append(changes.Changed, ImageChange{Repository: repo, Old: oldImages[0], New: newImages[0]})
+			}
+			continue
+		}
+		changes.Removed = append(changes.Removed, subtract(oldImages, newImages)...)
+		changes.Added = append(changes.Added, subtract(newImages, oldImages)...)
+	}
+	for repo, newImages := range newByRepo {
+		if _, ok := oldByRepo[repo]; !ok {
+			changes.Added = append(changes.Added, newImages...)
+		}
+	}
+
+	sort.Strings(changes.Added)
+	sort.Strings(changes.Removed)
+	sort.Slice(changes.Changed, func(i, j int) bool {
+		return changes.Changed[i].Repository < changes.Changed[j].Repository
+	})
+
+	return changes
+}
+
+// byRepository returns the unique images by their repository.
+func byRepository(images []string) map[string][]string {
+	repos := map[string][]string{}
+	seen := map[string]bool{}
+	for _, image := range images {
+		if seen[image] {
+			continue
+		}
+		seen[image] = true
+
+		repo := image
+		if ref, err := name.ParseReference(image); err == nil {
+			repo = ref.Context().Name()
+		}
+		repos[repo] = append(repos[repo], image)
+	}
+
+	return repos
+}
+
+// subtract returns the strings in a that are not in b.
+func subtract(a, b []string) []string {
+	inB := make(map[string]bool, len(b))
+	for _, s := range b {
+		inB[s] = true
+	}
+
+	var diff []string
+	for _, s := range a {
+		if !inB[s] {
+			diff = append(diff, s)
+		}
+	}
+
+	return diff
+}
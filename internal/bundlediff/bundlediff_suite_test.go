@@ -0,0 +1,13 @@
+package bundlediff
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+func TestBundleDiff(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Bundle Diff Suite")
+}
@@ -0,0 +1,175 @@
+package bundlediff
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+const csvTemplate = `apiVersion: operators.coreos.com/v1alpha1
+kind: ClusterServiceVersion
+metadata:
+  name: example-operator.VERSION
+spec:
+  version: VERSION
+  install:
+    strategy: deployment
+    spec:
+      clusterPermissions:
+      - serviceAccountName: example-operator
+        rules:
+        - apiGroups: [""]
+          resources: [CLUSTERRESOURCE]
+          verbs: [get, list]
+      deployments:
+      - name: example-operator
+        spec:
+          template:
+            spec:
+              containers:
+              - name: manager
+                image: IMAGE
+`
+
+const role = `apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: example-metrics
+rules:
+- apiGroups: [""]
+  resources: [services]
+  verbs: [get]
+`
+
+// writeBundle writes a bundle with the version, cluster permission, and operator image to a
+// temporary directory.
+func writeBundle(version, clusterResource, image string, extra ...string) string {
+	dir, err := os.MkdirTemp("", "bundle-diff-*")
+	Expect(err).ToNot(HaveOccurred())
+	DeferCleanup(os.RemoveAll, dir)
+
+	csv := strings.NewReplacer("VERSION", version, "CLUSTERRESOURCE", clusterResource, "IMAGE", image).Replace(csvTemplate)
+	Expect(os.MkdirAll(filepath.Join(dir, "manifests"), 0o755)).To(Succeed())
+	Expect(os.WriteFile(filepath.Join(dir, "manifests", "example.clusterserviceversion.yaml"), []byte(csv), 0o644)).To(Succeed())
+	for i, manifest := range extra {
+		Expect(os.WriteFile(filepath.Join(dir, "manifests", string(rune('a'+i))+".yaml"), []byte(manifest), 0o644)).To(Succeed())
+	}
+
+	return dir
+}
+
+var _ = Describe("Bundle diff", func() {
+	var oldDir, newDir string
+
+	BeforeEach(func() {
+		oldDir = writeBundle("1.0.0", "nodes", "quay.io/example/operator:v1.0.0", role)
+		newDir = writeBundle("1.1.0", "secrets", "quay.io/example/operator:v1.1.0")
+	})
+
+	Context("when loading a bundle", func() {
+		It("should read its CSV, permissions, and images", func() {
+			b, err := Load(oldDir)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(b.CSV).To(Equal("example-operator.1.0.0"))
+			Expect(b.permissions).To(ContainElement(Permission{Scope: "namespace", Subject: "Role example-metrics", Resource: "services", Verb: "get"}))
+			Expect(b.images).To(ContainElement("quay.io/example/operator:v1.0.0"))
+		})
+
+		It("should fail without a CSV", func() {
+			dir, err := os.MkdirTemp("", "bundle-diff-*")
+			Expect(err).ToNot(HaveOccurred())
+			DeferCleanup(os.RemoveAll, dir)
+			Expect(os.MkdirAll(filepath.Join(dir, "manifests"), 0o755)).To(Succeed())
+
+			_, err = Load(dir)
+			Expect(err).To(MatchError(ContainSubstring("does not have a ClusterServiceVersion")))
+		})
+	})
+
+	Context("when comparing two bundles", func() {
+		var report Report
+
+		BeforeEach(func() {
+			old, err := Load(oldDir)
+			Expect(err).ToNot(HaveOccurred())
+			new, err := Load(newDir)
+			Expect(err).ToNot(HaveOccurred())
+			report = Diff(old, new)
+		})
+
+		It("should report the changed CSV fields, without the permissions", func() {
+			Expect(report.CSV).To(ContainElement(FieldChange{Path: "spec.version", Old: "1.0.0", New: "1.1.0"}))
+			for _, c := range report.CSV {
+				Expect(c.Path).ToNot(ContainSubstring("clusterPermissions"))
+			}
+		})
+
+		It("should report the RBAC deltas", func() {
+			Expect(report.RBAC.Added).To(ConsistOf(
+				Permission{Scope: "cluster", Subject: "example-operator", Resource: "secrets", Verb: "get"},
+				Permission{Scope: "cluster", Subject: "example-operator", Resource: "secrets", Verb: "list"},
+			))
+			Expect(report.RBAC.Removed).To(ContainElement(Permission{Scope: "namespace", Subject: "Role example-metrics", Resource: "services", Verb: "get"}))
+		})
+
+		It("should report the changed images by repository", func() {
+			Expect(report.Images.Changed).To(ConsistOf(ImageChange{
+				Repository: "quay.io/example/operator",
+				Old:        "quay.io/example/operator:v1.0.0",
+				New:        "quay.io/example/operator:v1.1.0",
+			}))
+			Expect(report.Images.Added).To(BeEmpty())
+			Expect(report.Images.Removed).To(BeEmpty())
+		})
+
+		It("should write the report as text", func() {
+			var buf bytes.Buffer
+			Expect(WriteText(&buf, report)).To(Succeed())
+			Expect(buf.String()).To(ContainSubstring(`~ spec.version: "1.0.0" -> "1.1.0"`))
+			Expect(buf.String()).To(ContainSubstring("+ cluster example-operator: get secrets"))
+			Expect(buf.String()).To(ContainSubstring("~ quay.io/example/operator:v1.0.0 -> quay.io/example/operator:v1.1.0"))
+		})
+	})
+
+	Context("when comparing a bundle to itself", func() {
+		It("should report no changes", func() {
+			b, err := Load(oldDir)
+			Expect(err).ToNot(HaveOccurred())
+			report := Diff(b, b)
+			Expect(report.Unchanged()).To(BeTrue())
+
+			var buf bytes.Buffer
+			Expect(WriteText(&buf, report)).To(Succeed())
+			Expect(buf.String()).To(ContainSubstring("The bundles have the same content."))
+		})
+	})
+
+	Context("when extracting a bundle image", func() {
+		It("should only write the bundle directories, inside the destination", func() {
+			var buf bytes.Buffer
+			tw := tar.NewWriter(&buf)
+			for _, name := range []string{"manifests/csv.yaml", "metadata/annotations.yaml", "etc/passwd", "../manifests/escape.yaml"} {
+				Expect(tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0o644, Size: 2})).To(Succeed())
+				_, err := tw.Write([]byte("{}"))
+				Expect(err).ToNot(HaveOccurred())
+			}
+			Expect(tw.Close()).To(Succeed())
+
+			dir, err := os.MkdirTemp("", "bundle-diff-*")
+			Expect(err).ToNot(HaveOccurred())
+			DeferCleanup(os.RemoveAll, dir)
+
+			Expect(extract(&buf, filepath.Join(dir, "bundle"))).To(Succeed())
+			Expect(filepath.Join(dir, "bundle", "manifests", "csv.yaml")).To(BeAnExistingFile())
+			Expect(filepath.Join(dir, "bundle", "metadata", "annotations.yaml")).To(BeAnExistingFile())
+			Expect(filepath.Join(dir, "bundle", "manifests", "escape.yaml")).To(BeAnExistingFile())
+			Expect(filepath.Join(dir, "bundle", "etc")).ToNot(BeAnExistingFile())
+			Expect(filepath.Join(dir, "manifests")).ToNot(BeAnExistingFile())
+		})
+	})
+})
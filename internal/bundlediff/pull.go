@@ -0,0 +1,101 @@
+package bundlediff
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+)
+
+// bundleDirs are the directories of a bundle image that are extracted.
+var bundleDirs = []string{"manifests", "metadata"}
+
+// Pull pulls the bundle image and loads it. The bundle is extracted to dir.
+func Pull(ctx context.Context, image, dir string, options ...crane.Option) (*Bundle, error) {
+	options = append([]crane.Option{crane.WithContext(ctx)}, options...)
+	img, err := crane.Pull(image, options...)
+	if err != nil {
+		return nil, fmt.Errorf("could not pull bundle image %s: %w", image, err)
+	}
+	digest, err := img.Digest()
+	if err != nil {
+		return nil, fmt.Errorf("could not get the digest of bundle image %s: %w", image, err)
+	}
+
+	r, w := io.Pipe()
+	go func() {
+		w.CloseWithError(crane.Export(img, w))
+	}()
+	err = extract(r, dir)
+	if err == nil {
+		// The archive may be followed by data that must be read for the export to finish.
+		_, err = io.Copy(io.Discard, r)
+	}
+	if err != nil {
+		_ = r.CloseWithError(err)
+		return nil, fmt.Errorf("could not extract bundle image %s: %w", image, err)
+	}
+
+	b, err := Load(dir)
+	if err != nil {
+		return nil, err
+	}
+	b.Image = image
+	b.Digest = digest.String()
+
+	return b, nil
+}
+
+// extract writes the regular files in the bundle directories of the tar archive r to dst.
+func extract(r io.Reader, dst string) error {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		// Names are cleaned so that files cannot be written outside of dst.
+		name := strings.TrimPrefix(path.Clean("/"+header.Name), "/")
+		if !inBundleDir(name) {
+			continue
+		}
+
+		target := filepath.Join(dst, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(f, tr)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// inBundleDir returns true if name is in one of the bundle directories.
+func inBundleDir(name string) bool {
+	for _, dir := range bundleDirs {
+		if strings.HasPrefix(name, dir+"/") {
+			return true
+		}
+	}
+
+	return false
+}
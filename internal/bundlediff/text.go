@@ -0,0 +1,101 @@
+package bundlediff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxValueLength is the length that values, e.g. base64 encoded icons, are truncated to
+// in the text report.
+const maxValueLength = 80
+
+// WriteText writes r to w for people to read. Added entries are prefixed with +,
+// removed ones with -, and changed ones with ~.
+func WriteText(w io.Writer, r Report) error {
+	ew := &errWriter{w: w}
+
+	ew.printf("Comparing %s to %s\n", describe(r.Old), describe(r.New))
+	if r.Unchanged() {
+		ew.printf("\nThe bundles have the same content.\n")
+		return ew.err
+	}
+
+	if len(r.CSV) > 0 {
+		ew.printf("\nClusterServiceVersion:\n")
+		for _, c := range r.CSV {
+			switch {
+			case c.Old == nil:
+				ew.printf("  + %s: %s\n", c.Path, formatValue(c.New))
+			case c.New == nil:
+				ew.printf("  - %s: %s\n", c.Path, formatValue(c.Old))
+			default:
+				ew.printf("  ~ %s: %s -> %s\n", c.Path, formatValue(c.Old), formatValue(c.New))
+			}
+		}
+	}
+
+	if len(r.RBAC.Added) > 0 || len(r.RBAC.Removed) > 0 {
+		ew.printf("\nRBAC:\n")
+		for _, p := range r.RBAC.Added {
+			ew.printf("  + %s\n", p)
+		}
+		for _, p := range r.RBAC.Removed {
+			ew.printf("  - %s\n", p)
+		}
+	}
+
+	if len(r.Images.Added) > 0 || len(r.Images.Removed) > 0 || len(r.Images.Changed) > 0 {
+		ew.printf("\nImages:\n")
+		for _, image := range r.Images.Added {
+			ew.printf("  + %s\n", image)
+		}
+		for _, image := range r.Images.Removed {
+			ew.printf("  - %s\n", image)
+		}
+		for _, c := range r.Images.Changed {
+			ew.printf("  ~ %s -> %s\n", c.Old, c.New)
+		}
+	}
+
+	return ew.err
+}
+
+// describe returns the CSV of s, and where it was read from.
+func describe(s Source) string {
+	from := s.Image
+	if s.Digest != "" && !strings.Contains(s.Image, "@") {
+		from += "@" + s.Digest
+	}
+
+	return fmt.Sprintf("%s (%s)", s.CSV, from)
+}
+
+// formatValue returns v as JSON, truncated to maxValueLength.
+func formatValue(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+
+	s := []rune(string(b))
+	if len(s) > maxValueLength {
+		return fmt.Sprintf("%s... (%d characters)", string(s[:maxValueLength]), len(s))
+	}
+
+	return string(s)
+}
+
+// errWriter keeps the first error writing to w, and writes nothing after it.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) printf(format string, args ...interface{}) {
+	if ew.err != nil {
+		return
+	}
+	_, ew.err = fmt.Fprintf(ew.w, format, args...)
+}
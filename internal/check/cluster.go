@@ -0,0 +1,14 @@
+package check
+
+// ClusterCheck is implemented by checks that are executed against a cluster, so that
+// they cannot complete once the connection to the cluster is lost.
+type ClusterCheck interface {
+	// RequiresCluster returns true if the check is executed against a cluster.
+	RequiresCluster() bool
+}
+
+// RequiresCluster returns true if c is executed against a cluster.
+func RequiresCluster(c Check) bool {
+	cc, ok := c.(ClusterCheck)
+	return ok && cc.RequiresCluster()
+}
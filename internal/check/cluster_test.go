@@ -0,0 +1,24 @@
+package check
+
+import (
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+type clusterCheck struct {
+	Check
+	requiresCluster bool
+}
+
+func (c clusterCheck) RequiresCluster() bool {
+	return c.requiresCluster
+}
+
+var _ = Describe("Checks that require a cluster", func() {
+	It("should only require a cluster for checks that declare it", func() {
+		generic := NewGenericCheck("generic", nil, Metadata{}, HelpText{})
+		Expect(RequiresCluster(generic)).To(BeFalse())
+		Expect(RequiresCluster(clusterCheck{Check: generic, requiresCluster: false})).To(BeFalse())
+		Expect(RequiresCluster(clusterCheck{Check: generic, requiresCluster: true})).To(BeTrue())
+	})
+})
@@ -0,0 +1,32 @@
+package check
+
+// Explanation details what a check evaluates, and why it usually fails, so that users
+// can understand a failure without reading the check's source.
+type Explanation struct {
+	// Criteria are the conditions the asset must meet to pass the check.
+	Criteria []string `json:"criteria"`
+	// CommonCauses are the usual reasons the check fails.
+	CommonCauses []string `json:"common_causes,omitempty"`
+	// Remediation are the steps to take for the check to pass.
+	Remediation []string `json:"remediation"`
+}
+
+// Explainable is implemented by checks that explain their criteria, and how to remedy
+// their failures, in more detail than their Metadata and HelpText.
+type Explainable interface {
+	// Explain returns the explanation of the check.
+	Explain() Explanation
+}
+
+// Explain returns the explanation of c. If c is not Explainable, it is derived from
+// the description and suggestion of c.
+func Explain(c Check) Explanation {
+	if e, ok := c.(Explainable); ok {
+		return e.Explain()
+	}
+
+	return Explanation{
+		Criteria:    []string{c.Metadata().Description},
+		Remediation: []string{c.Help().Suggestion},
+	}
+}
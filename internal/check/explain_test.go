@@ -0,0 +1,32 @@
+package check
+
+import (
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+type explainedCheck struct {
+	Check
+}
+
+func (explainedCheck) Explain() Explanation {
+	return Explanation{Criteria: []string{"criteria"}, CommonCauses: []string{"cause"}, Remediation: []string{"fix"}}
+}
+
+var _ = Describe("Explain", func() {
+	It("should return the explanation of an Explainable check", func() {
+		Expect(Explain(explainedCheck{})).To(Equal(Explanation{
+			Criteria:     []string{"criteria"},
+			CommonCauses: []string{"cause"},
+			Remediation:  []string{"fix"},
+		}))
+	})
+
+	It("should derive the explanation of other checks from their description and suggestion", func() {
+		c := NewGenericCheck("generic", nil, Metadata{Description: "Checking something"}, HelpText{Suggestion: "Do something"})
+		Expect(Explain(c)).To(Equal(Explanation{
+			Criteria:    []string{"Checking something"},
+			Remediation: []string{"Do something"},
+		}))
+	})
+})
@@ -0,0 +1,53 @@
+package check
+
+import (
+	"path"
+	"sort"
+)
+
+// FilesystemReader is implemented by checks that only read some paths of the extracted
+// filesystem of the image, so that the rest of it need not be extracted.
+type FilesystemReader interface {
+	// FilesystemPaths returns the absolute paths in the filesystem of the image that the
+	// check reads, e.g. /var/lib/rpm. Directories are extracted with everything under
+	// them.
+	FilesystemPaths() []string
+}
+
+// FilesystemPaths returns the paths of the filesystem of the image that checks read,
+// sorted, and true, or false if any of checks may read any path, so that the whole
+// filesystem must be extracted. Checks that are Sourced, but do not consult the
+// filesystem, read no path.
+func FilesystemPaths(checks []Check) ([]string, bool) {
+	seen := map[string]bool{}
+	paths := []string{}
+	for _, c := range checks {
+		if r, ok := c.(FilesystemReader); ok {
+			for _, p := range r.FilesystemPaths() {
+				p = path.Clean("/" + p)
+				if !seen[p] {
+					seen[p] = true
+					paths = append(paths, p)
+				}
+			}
+			continue
+		}
+
+		if _, ok := c.(Sourced); !ok || readsFilesystem(ProvenanceOf(c)) {
+			return nil, false
+		}
+	}
+
+	sort.Strings(paths)
+	return paths, true
+}
+
+func readsFilesystem(p Provenance) bool {
+	for _, source := range p.DataSources {
+		if source == DataSourceFilesystem {
+			return true
+		}
+	}
+
+	return false
+}
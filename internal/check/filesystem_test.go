@@ -0,0 +1,51 @@
+package check
+
+import (
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+type filesystemCheck struct {
+	Check
+	paths []string
+}
+
+func (c filesystemCheck) FilesystemPaths() []string {
+	return c.paths
+}
+
+type filesystemSourcedCheck struct {
+	Check
+}
+
+func (c filesystemSourcedCheck) Provenance() Provenance {
+	return Provenance{DataSources: []DataSource{DataSourceFilesystem}}
+}
+
+var _ = Describe("Filesystem paths", func() {
+	generic := NewGenericCheck("generic", nil, Metadata{}, HelpText{})
+
+	It("should be the union of the paths the checks read, cleaned and sorted", func() {
+		paths, ok := FilesystemPaths([]Check{
+			filesystemCheck{Check: generic, paths: []string{"/var/lib/rpm", "licenses/"}},
+			filesystemCheck{Check: generic, paths: []string{"/var/lib/rpm"}},
+			sourcedCheck{Check: generic},
+		})
+		Expect(ok).To(BeTrue())
+		Expect(paths).To(Equal([]string{"/licenses", "/var/lib/rpm"}))
+	})
+
+	It("should be empty if no check reads the filesystem", func() {
+		paths, ok := FilesystemPaths([]Check{sourcedCheck{Check: generic}})
+		Expect(ok).To(BeTrue())
+		Expect(paths).To(BeEmpty())
+	})
+
+	It("should be the whole filesystem if a check may read any path", func() {
+		_, ok := FilesystemPaths([]Check{filesystemCheck{Check: generic, paths: []string{"/licenses"}}, generic})
+		Expect(ok).To(BeFalse())
+
+		_, ok = FilesystemPaths([]Check{filesystemSourcedCheck{Check: generic}})
+		Expect(ok).To(BeFalse())
+	})
+})
@@ -0,0 +1,53 @@
+package check
+
+// DataSource is a source of the data that a check evaluates.
+type DataSource string
+
+const (
+	// DataSourceManifest is the manifest and configuration of the image, e.g. its
+	// labels and layers, or the manifests of an operator bundle, e.g. its CSV.
+	DataSourceManifest DataSource = "manifest"
+	// DataSourceFilesystem is the filesystem of the image, e.g. its RPM database.
+	DataSourceFilesystem DataSource = "filesystem"
+	// DataSourceRegistry is the registry of the image, or of the images it references.
+	DataSourceRegistry DataSource = "registry"
+	// DataSourcePyxis is the Red Hat certification API.
+	DataSourcePyxis DataSource = "pyxis"
+	// DataSourceCluster is the cluster that operators are tested on.
+	DataSourceCluster DataSource = "cluster"
+)
+
+// Confidence is how confident a check that uses heuristics is in its result.
+type Confidence string
+
+const (
+	ConfidenceHigh   Confidence = "high"
+	ConfidenceMedium Confidence = "medium"
+	ConfidenceLow    Confidence = "low"
+)
+
+// Provenance describes the data sources a check consults, and how confident it is in
+// its result, so that reviewers can weigh its findings.
+type Provenance struct {
+	// DataSources are the sources of the data the check evaluates.
+	DataSources []DataSource `json:"data_sources"`
+	// Confidence is the confidence of a check that uses heuristics, e.g. conventions
+	// that assets are not required to follow. It is empty for checks that do not.
+	Confidence Confidence `json:"confidence,omitempty"`
+}
+
+// Sourced is implemented by checks that describe the provenance of their results.
+type Sourced interface {
+	// Provenance returns the provenance of the results of the check.
+	Provenance() Provenance
+}
+
+// ProvenanceOf returns the provenance of the results of c, or an empty Provenance if c
+// is not Sourced.
+func ProvenanceOf(c Check) Provenance {
+	if s, ok := c.(Sourced); ok {
+		return s.Provenance()
+	}
+
+	return Provenance{}
+}
@@ -0,0 +1,25 @@
+package check
+
+import (
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+type sourcedCheck struct {
+	Check
+}
+
+func (c sourcedCheck) Provenance() Provenance {
+	return Provenance{DataSources: []DataSource{DataSourceManifest}, Confidence: ConfidenceMedium}
+}
+
+var _ = Describe("Check provenance", func() {
+	It("should be described by the checks that are sourced", func() {
+		c := sourcedCheck{Check: NewGenericCheck("sourced", nil, Metadata{}, HelpText{})}
+		Expect(ProvenanceOf(c)).To(Equal(Provenance{DataSources: []DataSource{DataSourceManifest}, Confidence: ConfidenceMedium}))
+	})
+
+	It("should be empty for the checks that are not", func() {
+		Expect(ProvenanceOf(NewGenericCheck("unsourced", nil, Metadata{}, HelpText{}))).To(BeZero())
+	})
+})
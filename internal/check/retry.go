@@ -0,0 +1,16 @@
+package check
+
+// Retryable is implemented by checks whose result depends on the environment they are
+// executed in, such as a cluster, and not only on the asset, so that a failure may be
+// a flake. Retryable checks that fail or error are executed again, up to the number of
+// attempts the engine is configured with.
+type Retryable interface {
+	// Retryable returns true if the check may be executed again after it fails or errors.
+	Retryable() bool
+}
+
+// IsRetryable returns true if c may be executed again after it fails or errors.
+func IsRetryable(c Check) bool {
+	r, ok := c.(Retryable)
+	return ok && r.Retryable()
+}
@@ -0,0 +1,24 @@
+package check
+
+import (
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+type retryableCheck struct {
+	Check
+	retryable bool
+}
+
+func (c retryableCheck) Retryable() bool {
+	return c.retryable
+}
+
+var _ = Describe("Retrying checks", func() {
+	It("should only retry checks that are retryable", func() {
+		generic := NewGenericCheck("generic", nil, Metadata{}, HelpText{})
+		Expect(IsRetryable(generic)).To(BeFalse())
+		Expect(IsRetryable(retryableCheck{Check: generic, retryable: false})).To(BeFalse())
+		Expect(IsRetryable(retryableCheck{Check: generic, retryable: true})).To(BeTrue())
+	})
+})
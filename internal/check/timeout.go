@@ -0,0 +1,66 @@
+package check
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Timeouts are the budgets within which checks must complete, so that a check that
+// hangs, e.g. on a slow registry, errors rather than stalling the execution.
+type Timeouts struct {
+	// Default is the budget of the checks without one of their own. Zero is no budget.
+	Default time.Duration
+	// Checks are the budgets of individual checks, by name, which override Default.
+	Checks map[string]time.Duration
+}
+
+// ParseTimeouts returns the Timeouts with the default budget def, e.g. 10m, and the
+// budgets of individual checks in overrides, each in the form CheckName=duration, e.g.
+// DeployableByOLM=30m. An empty def is no default budget.
+func ParseTimeouts(def string, overrides []string) (Timeouts, error) {
+	var t Timeouts
+	if def != "" {
+		d, err := parseTimeout(def)
+		if err != nil {
+			return Timeouts{}, fmt.Errorf("invalid check timeout %q: %w", def, err)
+		}
+		t.Default = d
+	}
+
+	for _, o := range overrides {
+		name, value, ok := strings.Cut(o, "=")
+		if !ok || name == "" {
+			return Timeouts{}, fmt.Errorf("invalid check timeout %q: must be in the form CheckName=duration", o)
+		}
+		d, err := parseTimeout(value)
+		if err != nil {
+			return Timeouts{}, fmt.Errorf("invalid check timeout %q: %w", o, err)
+		}
+		if t.Checks == nil {
+			t.Checks = map[string]time.Duration{}
+		}
+		t.Checks[name] = d
+	}
+
+	return t, nil
+}
+
+// parseTimeout returns the duration s, which must be positive.
+func parseTimeout(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("must be a positive duration, e.g. 10m")
+	}
+
+	return d, nil
+}
+
+// For returns the budget of the check named name, or zero if it has none.
+func (t Timeouts) For(name string) time.Duration {
+	if d, ok := t.Checks[name]; ok {
+		return d
+	}
+
+	return t.Default
+}
@@ -0,0 +1,39 @@
+package check
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Check timeouts", func() {
+	It("should have no budget if none is configured", func() {
+		Expect(Timeouts{}.For("HasLicense")).To(BeZero())
+	})
+
+	It("should override the default budget for individual checks", func() {
+		t, err := ParseTimeouts("10m", []string{"DeployableByOLM=30m"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(t.For("HasLicense")).To(Equal(10 * time.Minute))
+		Expect(t.For("DeployableByOLM")).To(Equal(30 * time.Minute))
+	})
+
+	It("should only budget the checks with overrides if there is no default", func() {
+		t, err := ParseTimeouts("", []string{"DeployableByOLM=30m"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(t.For("HasLicense")).To(BeZero())
+		Expect(t.For("DeployableByOLM")).To(Equal(30 * time.Minute))
+	})
+
+	It("should reject invalid budgets", func() {
+		_, err := ParseTimeouts("forever", nil)
+		Expect(err).To(MatchError(ContainSubstring(`invalid check timeout "forever"`)))
+
+		_, err = ParseTimeouts("", []string{"DeployableByOLM"})
+		Expect(err).To(MatchError(ContainSubstring("must be in the form CheckName=duration")))
+
+		_, err = ParseTimeouts("", []string{"DeployableByOLM=-1m"})
+		Expect(err).To(MatchError(ContainSubstring("must be a positive duration")))
+	})
+})
@@ -0,0 +1,67 @@
+// Package ci detects the CI system preflight is executing in, so that results
+// can be reported in the formats it ingests natively.
+package ci
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// System is a CI system that preflight can report results to.
+type System string
+
+const (
+	// None reports results without any CI-specific output.
+	None System = ""
+	// Auto detects the System from the environment.
+	Auto        System = "auto"
+	AzureDevOps System = "azure"
+	CircleCI    System = "circleci"
+	GitLab      System = "gitlab"
+)
+
+// The environment variables set by each System.
+const (
+	azureEnv    = "TF_BUILD"
+	circleCIEnv = "CIRCLECI"
+	gitLabEnv   = "GITLAB_CI"
+)
+
+// Systems are the values accepted by Parse, other than Auto.
+var Systems = []System{AzureDevOps, CircleCI, GitLab}
+
+// Detect returns the System whose well-known environment variable is set,
+// reading the environment with getenv, or None.
+func Detect(getenv func(string) string) System {
+	switch {
+	case strings.EqualFold(getenv(azureEnv), "true"):
+		return AzureDevOps
+	case getenv(circleCIEnv) == "true":
+		return CircleCI
+	case getenv(gitLabEnv) == "true":
+		return GitLab
+	}
+
+	return None
+}
+
+// Parse returns the System named s. Auto is resolved from the process
+// environment using Detect.
+func Parse(s string) (System, error) {
+	system := System(strings.ToLower(strings.TrimSpace(s)))
+	switch system {
+	case None:
+		return None, nil
+	case Auto:
+		return Detect(os.Getenv), nil
+	}
+
+	for _, known := range Systems {
+		if system == known {
+			return system, nil
+		}
+	}
+
+	return None, fmt.Errorf("unknown CI system %q: must be one of auto, %s, %s, or %s", s, AzureDevOps, CircleCI, GitLab)
+}
@@ -0,0 +1,13 @@
+package ci
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestCI(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CI Suite")
+}
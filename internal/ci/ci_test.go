@@ -0,0 +1,44 @@
+package ci
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CI", func() {
+	env := func(vars map[string]string) func(string) string {
+		return func(key string) string { return vars[key] }
+	}
+
+	Context("When detecting the CI system", func() {
+		It("should detect Azure DevOps", func() {
+			Expect(Detect(env(map[string]string{"TF_BUILD": "True"}))).To(Equal(AzureDevOps))
+		})
+		It("should detect CircleCI", func() {
+			Expect(Detect(env(map[string]string{"CIRCLECI": "true"}))).To(Equal(CircleCI))
+		})
+		It("should detect GitLab", func() {
+			Expect(Detect(env(map[string]string{"GITLAB_CI": "true"}))).To(Equal(GitLab))
+		})
+		It("should detect nothing outside of CI", func() {
+			Expect(Detect(env(map[string]string{"CI": "true"}))).To(Equal(None))
+		})
+	})
+
+	Context("When parsing the CI system", func() {
+		It("should accept known systems regardless of case", func() {
+			system, err := Parse("CircleCI")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(system).To(Equal(CircleCI))
+		})
+		It("should accept an empty value", func() {
+			system, err := Parse("")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(system).To(Equal(None))
+		})
+		It("should reject unknown systems", func() {
+			_, err := Parse("jenkins")
+			Expect(err).To(MatchError(ContainSubstring("unknown CI system")))
+		})
+	})
+})
@@ -7,20 +7,103 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/artifacts"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/certification"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/clock"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/ci"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/compare"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/events"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/exceptions"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/formatters"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/interrupt"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/knownissues"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/lib"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/log"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/metrics"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/tracing"
 
 	"github.com/go-logr/logr"
 )
 
+// ChecklistFilename is the name of the certification checklist artifact.
+const ChecklistFilename = "checklist.md"
+
+// CodeQualityFilename is the name of the GitLab Code Quality report artifact.
+const CodeQualityFilename = "gl-code-quality-report.json"
+
+// JUnitFilename is the name of the JUnit results artifact, written when no
+// JUnit path is configured.
+const JUnitFilename = "results-junit.xml"
+
+// NUnitFilename is the name of the NUnit results artifact, written for Azure DevOps.
+const NUnitFilename = "results-nunit.xml"
+
+// PostRunLogFilename is the name of the artifact the output of the post-run command
+// is written to.
+const PostRunLogFilename = "post-run.log"
+
+// CircleCIJUnitPath is where JUnit results are written for CircleCI when no JUnit
+// path is configured. Its directory is meant to be passed to store_test_results.
+const CircleCIJUnitPath = "test-results/preflight/results-junit.xml"
+
 type CheckConfig struct {
 	IncludeJUnitResults bool
-	SubmitResults       bool
+	// JUnitPath is where JUnit results are written. If empty, and
+	// IncludeJUnitResults is set, they are written as an artifact.
+	JUnitPath     string
+	SubmitResults bool
+	// IncludeChecklist writes a checklist mapping each certification
+	// requirement to the checks covering it as an artifact.
+	IncludeChecklist bool
+	// IncludeCodeQuality writes failed and errored checks as a GitLab
+	// Code Quality report artifact.
+	IncludeCodeQuality bool
+	// EventsFile is where newline-delimited JSON events are written
+	// as checks execute. "-" writes to stdout, in which case the formatted
+	// results are only written to the results file. Empty disables events.
+	EventsFile string
+	// Deterministic zeroes all timestamps and durations in results.
+	Deterministic bool
+	// Progress reports the current check, phase, and elapsed time to
+	// stderr as checks execute.
+	Progress bool
+	// Quiet prints only the overall result and the path to the results
+	// file to stdout, instead of the formatted results.
+	Quiet bool
+	// Summary prints one line per check to stdout, followed by the
+	// overall result and the path to the results file, instead of the
+	// formatted results.
+	Summary bool
+	// Watch renders the status of each check, and the tail of the log in
+	// the context, to stderr as checks execute, when stderr is a terminal.
+	Watch bool
+	// CI writes the reports, and prints the output, that the CI system
+	// ingests natively, in addition to those otherwise configured.
+	CI ci.System
+	// CompareTo returns the outcomes of a reference image. If set, the
+	// execution fails, and results are not submitted, if any check that the
+	// reference passed does not pass.
+	CompareTo func(context.Context) (compare.Outcomes, error)
+	// Exceptions waive the failures of the checks they apply to. Waived
+	// checks are recorded in the results, and do not fail them overall.
+	Exceptions *exceptions.List
+	// KnownIssues annotate the failures and errors of the checks they
+	// affect, without changing the results otherwise.
+	KnownIssues *knownissues.List
+	// PostRunCommand is the path to a command executed once results are
+	// written and submitted, with the path to the results file and the exit status of
+	// preflight as its arguments. Its output is written as an artifact.
+	PostRunCommand string
+	// ExitCodes are the codes of the outcomes of the run. If set, and the outcome
+	// of the checks has a non-zero code, an ExitError is returned once results are
+	// written and submitted. If nil, results never cause an error.
+	ExitCodes *ExitCodes
 }
 
 // RunPreflight executes checks, writes logs, results, and submits results if requested.
@@ -31,13 +114,19 @@ func RunPreflight(
 	formatter formatters.ResponseFormatter,
 	rw lib.ResultWriter,
 	rs lib.ResultSubmitter,
-) error {
+) (err error) {
 	logger := logr.FromContextOrDiscard(ctx)
 
+	ctx, span := tracing.Start(ctx, "preflight")
+	defer func() {
+		tracing.End(span, err)
+	}()
+
+	cfg = withCIDefaults(cfg)
+
 	// Configure artifact writing if not already configured. For CLI
 	// executions, we default to writing to the filesystem.
 	artifactsWriter := artifacts.WriterFromContext(ctx)
-	var err error
 	if artifactsWriter == nil {
 		return errors.New("no artifact writer was configured")
 	}
@@ -47,19 +136,117 @@ func RunPreflight(
 		return err
 	}
 
+	if cfg.PostRunCommand != "" {
+		// The command runs last, once the results file is closed, so that it sees
+		// everything preflight wrote, and the status preflight exits with.
+		defer func() {
+			codes := DefaultExitCodes()
+			if cfg.ExitCodes != nil {
+				codes = *cfg.ExitCodes
+			}
+			// The command runs even if preflight was interrupted, so that it sees the
+			// partial results.
+			if hookErr := runPostRunCommand(interrupt.Detach(ctx), cfg.PostRunCommand, resultsFilePath, codes.ForError(err)); hookErr != nil && err == nil {
+				err = hookErr
+			}
+		}()
+	}
+
 	resultsFile, err := rw.OpenFile(resultsFilePath)
 	if err != nil {
 		return err
 	}
 
 	defer resultsFile.Close()
-	resultsOutputTarget := io.MultiWriter(os.Stdout, resultsFile)
+	// Events written to stdout must not be interleaved with any other
+	// output, so that stdout remains a valid stream of events.
+	var stdout io.Writer = os.Stdout
+	if cfg.EventsFile == "-" {
+		stdout = io.Discard
+	}
+
+	resultsOutputTarget := io.MultiWriter(stdout, resultsFile)
+	if cfg.Quiet || cfg.Summary {
+		resultsOutputTarget = resultsFile
+	}
+
+	// Exceptions expire in real time, even if timestamps are zeroed.
+	started := clock.FromContext(ctx).Now()
+	if cfg.Deterministic {
+		ctx = clock.ContextWithClock(ctx, clock.Deterministic())
+	}
+
+	// The reference is checked first, so that its checks are not reported
+	// as those of the image under test.
+	var reference compare.Outcomes
+	if cfg.CompareTo != nil {
+		reference, err = cfg.CompareTo(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Optionally stream events and progress as checks execute.
+	var listeners []events.Listener
+	if cfg.EventsFile != "" {
+		eventsOutput, err := openEventsFile(cfg.EventsFile)
+		if err != nil {
+			return fmt.Errorf("could not open events file: %w", err)
+		}
+		defer eventsOutput.Close()
+
+		eventsWriter := events.NewNDJSONWriter(eventsOutput)
+		defer func() {
+			if err := eventsWriter.Err(); err != nil {
+				logger.Error(err, "unable to write events", "eventsFile", cfg.EventsFile)
+			}
+		}()
+		listeners = append(listeners, eventsWriter)
+	}
+
+	// The watch view is redrawn in place, so it must be stopped before
+	// anything else is written to the terminal.
+	stopWatching := func() {}
+	switch {
+	case cfg.Watch && events.IsTerminal(os.Stderr):
+		watcher := events.NewWatcher(os.Stderr, events.LogTailFromContext(ctx), events.TerminalWidth(os.Stderr))
+		watcher.Start(time.Second)
+		defer watcher.Stop()
+		stopWatching = watcher.Stop
+		listeners = append(listeners, watcher)
+	case cfg.Watch, cfg.Progress:
+		listeners = append(listeners, events.NewProgressWriter(os.Stderr, events.IsTerminal(os.Stderr)))
+	}
+
+	if m := metrics.FromContext(ctx); m != nil {
+		listeners = append(listeners, m)
+	}
+
+	if len(listeners) > 0 {
+		ctx = events.ContextWithListener(ctx, events.MultiListener(listeners...))
+	}
+
+	events.Emit(ctx, events.Event{Type: events.TypeRunStarted})
 
 	// Execute Checks.
 	results, err := runChecks(ctx)
 	if err != nil {
+		events.Emit(ctx, events.Event{Type: events.TypeRunSummary, Result: string(certification.StatusErrored), Error: err.Error()})
 		return err
 	}
+	stopWatching()
+
+	// The results of an interrupted run are written, but they are partial, so they are
+	// neither compared nor submitted.
+	interrupted := interrupt.Interrupted(ctx)
+
+	if cfg.Exceptions != nil {
+		results = cfg.Exceptions.Apply(ctx, results, started)
+	}
+
+	if cfg.KnownIssues != nil {
+		results = cfg.KnownIssues.Annotate(ctx, results, started)
+	}
 
 	// Format and write the results.
 	formattedResults, err := formatter.Format(ctx, results)
@@ -69,29 +256,136 @@ func RunPreflight(
 
 	fmt.Fprintln(resultsOutputTarget, string(formattedResults))
 
+	switch {
+	case cfg.Summary:
+		writeSummary(stdout, results, resultsFilePath)
+	case cfg.Quiet:
+		writeResult(stdout, results, resultsFilePath)
+	}
+
+	events.Emit(ctx, events.Event{
+		Type:        events.TypeRunSummary,
+		Image:       results.TestedImage,
+		ImageDigest: results.ImageDigest,
+		Result:      convertPassedOverall(results.PassedOverall),
+		Passed:      len(results.Passed),
+		Failed:      len(results.Failed),
+		Errors:      len(results.Errors),
+		Waived:      len(results.Waived),
+		Skipped:     len(results.Skipped),
+		ResultsFile: resultsFilePath,
+	})
+
 	// Optionally write the JUnit results alongside the regular results.
-	if cfg.IncludeJUnitResults {
-		if err := writeJUnit(ctx, results); err != nil {
+	if cfg.IncludeJUnitResults || cfg.JUnitPath != "" {
+		if err := writeJUnit(ctx, results, cfg.JUnitPath); err != nil {
 			return err
 		}
 	}
 
-	if cfg.SubmitResults {
-		if err := rs.Submit(ctx); err != nil {
+	// Optionally write the certification checklist alongside the regular results.
+	if cfg.IncludeChecklist {
+		checklistPath, err := writeFormattedArtifact(ctx, results, "checklist", ChecklistFilename)
+		if err != nil {
+			return err
+		}
+		if cfg.CI == ci.AzureDevOps {
+			publishAzureSummary(stdout, checklistPath)
+		}
+	}
+
+	// Optionally write the GitLab Code Quality report alongside the regular results.
+	if cfg.IncludeCodeQuality {
+		if _, err := writeFormattedArtifact(ctx, results, "gitlab-codequality", CodeQualityFilename); err != nil {
+			return err
+		}
+	}
+
+	// Azure DevOps ingests NUnit results natively.
+	if cfg.CI == ci.AzureDevOps {
+		nunitPath, err := writeFormattedArtifact(ctx, results, "nunitxml", NUnitFilename)
+		if err != nil {
+			return err
+		}
+		publishAzureTestResults(stdout, nunitPath)
+	}
+
+	if cfg.CompareTo != nil && !interrupted {
+		if err := compare.RegressionError(reference, compare.FromResults(results)); err != nil {
+			return err
+		}
+	}
+
+	if cfg.SubmitResults && !interrupted {
+		err := rs.Submit(ctx)
+		metrics.FromContext(ctx).ObserveSubmission(err)
+		if err != nil {
 			return err
 		}
 	}
 
 	logger.Info(fmt.Sprintf("Preflight result: %s", convertPassedOverall(results.PassedOverall)))
 
+	if interrupted {
+		return fmt.Errorf("%w: the results of the %d checks that did not complete are skipped", interrupt.ErrInterrupted, len(results.Skipped))
+	}
+
+	if cfg.ExitCodes != nil {
+		if code := cfg.ExitCodes.ForResults(results); code != 0 {
+			return &ExitError{Code: code, Result: certification.OverallStatus(results.PassedOverall)}
+		}
+	}
+
 	return nil
 }
 
-// writeJUnit will write JUnit results as an artifact using the ArtifactWriter configured
-// in ctx.
-func writeJUnit(ctx context.Context, results certification.Results) error {
-	logger := logr.FromContextOrDiscard(ctx)
+// withCIDefaults returns cfg with the reports that cfg.CI ingests natively enabled.
+func withCIDefaults(cfg CheckConfig) CheckConfig {
+	switch cfg.CI {
+	case ci.AzureDevOps:
+		// The checklist is uploaded as the build summary.
+		cfg.IncludeChecklist = true
+	case ci.CircleCI:
+		if cfg.JUnitPath == "" {
+			cfg.JUnitPath = CircleCIJUnitPath
+		}
+	case ci.GitLab:
+		cfg.IncludeJUnitResults = true
+		cfg.IncludeCodeQuality = true
+	}
 
+	return cfg
+}
+
+// publishAzureTestResults writes the Azure DevOps logging command publishing the NUnit
+// results at path to w.
+func publishAzureTestResults(w io.Writer, path string) {
+	if path == "" {
+		return
+	}
+	fmt.Fprintf(w, "##vso[results.publish type=NUnit;runTitle=Preflight;resultFiles=%s]\n", absPath(path))
+}
+
+// publishAzureSummary writes the Azure DevOps logging command uploading the markdown
+// at path as a build summary to w.
+func publishAzureSummary(w io.Writer, path string) {
+	if path == "" {
+		return
+	}
+	fmt.Fprintf(w, "##vso[task.uploadsummary]%s\n", absPath(path))
+}
+
+// absPath returns the absolute path of path, or path if it cannot be determined.
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// writeJUnit will write JUnit results to path, or as an artifact using the ArtifactWriter
+// configured in ctx if path is empty.
+func writeJUnit(ctx context.Context, results certification.Results, path string) error {
 	junitformatter, err := formatters.NewByName("junitxml")
 	if err != nil {
 		return err
@@ -102,8 +396,27 @@ func writeJUnit(ctx context.Context, results certification.Results) error {
 		return err
 	}
 
+	return WriteJUnitReport(ctx, junitResults, path)
+}
+
+// WriteJUnitReport writes report to path, creating its directory if needed. If path is
+// empty, report is written as an artifact using the ArtifactWriter configured in ctx.
+func WriteJUnitReport(ctx context.Context, report []byte, path string) error {
+	logger := logr.FromContextOrDiscard(ctx)
+
+	if path != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return fmt.Errorf("could not create JUnit results directory: %w", err)
+		}
+		if err := os.WriteFile(path, report, 0o644); err != nil {
+			return fmt.Errorf("could not write JUnit results: %w", err)
+		}
+		logger.V(log.TRC).Info("JUnitXML filename", "filename", path)
+		return nil
+	}
+
 	if aw := artifacts.WriterFromContext(ctx); aw != nil {
-		junitFilename, err := aw.WriteFile("results-junit.xml", bytes.NewReader((junitResults)))
+		junitFilename, err := aw.WriteFile(JUnitFilename, bytes.NewReader(report))
 		if err != nil {
 			return err
 		}
@@ -113,12 +426,117 @@ func writeJUnit(ctx context.Context, results certification.Results) error {
 	return nil
 }
 
-func convertPassedOverall(passedOverall bool) string {
-	if passedOverall {
-		return "PASSED"
+// runPostRunCommand executes the command at path with the absolute path to the results
+// file, and the exit status of preflight, as its arguments, and writes its output with
+// the ArtifactWriter configured in ctx.
+func runPostRunCommand(ctx context.Context, path, resultsFilePath string, status int) error {
+	logger := logr.FromContextOrDiscard(ctx)
+
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, absPath(resultsFilePath), strconv.Itoa(status))
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	logger.Info("executing post-run command", "command", path, "status", status)
+	err := cmd.Run()
+
+	if aw := artifacts.WriterFromContext(ctx); aw != nil {
+		if outputPath, writeErr := aw.WriteFile(PostRunLogFilename, &output); writeErr != nil {
+			logger.Error(writeErr, "could not write the output of the post-run command")
+		} else {
+			logger.V(log.TRC).Info("post-run command output", "filename", outputPath)
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("post-run command %s failed: %w", path, err)
+	}
+
+	return nil
+}
+
+// openEventsFile returns the destination for events. The special name "-"
+// refers to stdout, which is never closed.
+func openEventsFile(name string) (io.WriteCloser, error) {
+	if name == "-" {
+		return nopWriteCloser{os.Stdout}, nil
 	}
 
-	return "FAILED"
+	return os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// writeFormattedArtifact will write results formatted with the formatter named
+// formatterName as the artifact filename, using the ArtifactWriter configured in ctx,
+// and returns the path it was written to, if any.
+func writeFormattedArtifact(ctx context.Context, results certification.Results, formatterName, filename string) (string, error) {
+	logger := logr.FromContextOrDiscard(ctx)
+
+	formatter, err := formatters.NewByName(formatterName)
+	if err != nil {
+		return "", err
+	}
+
+	formatted, err := formatter.Format(ctx, results)
+	if err != nil {
+		return "", err
+	}
+
+	aw := artifacts.WriterFromContext(ctx)
+	if aw == nil {
+		return "", nil
+	}
+
+	artifactFilename, err := aw.WriteFile(filename, bytes.NewReader(formatted))
+	if err != nil {
+		return "", err
+	}
+	logger.V(log.TRC).Info("formatted artifact filename", "formatter", formatterName, "filename", artifactFilename)
+
+	return artifactFilename, nil
+}
+
+// writeSummary writes one line per executed check to w, followed by the
+// overall result.
+func writeSummary(w io.Writer, results certification.Results, resultsFilePath string) {
+	if results.ImageDigest != "" {
+		fmt.Fprintf(w, "%-6s %s\n", "DIGEST", results.ImageDigest)
+	}
+
+	for _, group := range []struct {
+		status  certification.Status
+		results []certification.Result
+	}{
+		{certification.StatusPassed, results.Passed},
+		{certification.StatusFailed, results.Failed},
+		{certification.StatusErrored, results.Errors},
+		{certification.StatusWaived, results.Waived},
+		{certification.StatusSkipped, results.Skipped},
+	} {
+		for _, r := range group.results {
+			fmt.Fprintf(w, "%-6s %s", group.status, r.Name())
+			for _, issue := range r.KnownIssues {
+				fmt.Fprintf(w, " (known issue %s: %s)", issue.ID, issue.Title)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+
+	writeResult(w, results, resultsFilePath)
+}
+
+// writeResult writes the overall result and the path to the results file to w.
+func writeResult(w io.Writer, results certification.Results, resultsFilePath string) {
+	fmt.Fprintf(w, "%s %s\n", convertPassedOverall(results.PassedOverall), resultsFilePath)
+}
+
+func convertPassedOverall(passedOverall bool) string {
+	return string(certification.OverallStatus(passedOverall))
 }
 
 func ResultsFilenameWithExtension(ext string) string {
@@ -3,16 +3,25 @@ package cli
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/artifacts"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/certification"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/ci"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/compare"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/events"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/exceptions"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/formatters"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/image"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/interrupt"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/knownissues"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/lib"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/log"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/runtime"
@@ -100,6 +109,250 @@ var _ = Describe("CLI Library function", func() {
 				})
 			})
 
+			When("quiet or summary output is requested", func() {
+				var stdout *os.File
+				runChecks := func(ctx context.Context) (certification.Results, error) {
+					return certification.Results{
+						TestedImage:   "testQuiet",
+						PassedOverall: false,
+						Passed: []certification.Result{
+							{Check: check.NewGenericCheck("HasLicense", nil, check.Metadata{}, check.HelpText{})},
+						},
+						Failed: []certification.Result{
+							{Check: check.NewGenericCheck("RunAsNonRoot", nil, check.Metadata{}, check.HelpText{})},
+						},
+						Errors: []certification.Result{},
+					}, nil
+				}
+
+				BeforeEach(func() {
+					var err error
+					stdout, err = os.CreateTemp(artifactWriter.Path(), "stdout-*")
+					Expect(err).ToNot(HaveOccurred())
+					DeferCleanup(stdout.Close)
+
+					originalStdout := os.Stdout
+					os.Stdout = stdout
+					DeferCleanup(func() { os.Stdout = originalStdout })
+				})
+
+				It("Should only print the result and the results file when quiet", func() {
+					err := RunPreflight(testcontext, runChecks, CheckConfig{Quiet: true}, testFormatter, &runtime.ResultWriterFile{}, nil)
+					Expect(err).ToNot(HaveOccurred())
+
+					resultsFile := filepath.Join(artifactWriter.Path(), ResultsFilenameWithExtension(testFormatter.FileExtension()))
+					contents, err := os.ReadFile(stdout.Name())
+					Expect(err).ToNot(HaveOccurred())
+					Expect(string(contents)).To(Equal("FAILED " + resultsFile + "\n"))
+
+					results, err := os.ReadFile(resultsFile)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(string(results)).To(ContainSubstring("testQuiet"))
+				})
+
+				It("Should print one line per check when summary is requested", func() {
+					err := RunPreflight(testcontext, runChecks, CheckConfig{Summary: true}, testFormatter, &runtime.ResultWriterFile{}, nil)
+					Expect(err).ToNot(HaveOccurred())
+
+					resultsFile := filepath.Join(artifactWriter.Path(), ResultsFilenameWithExtension(testFormatter.FileExtension()))
+					contents, err := os.ReadFile(stdout.Name())
+					Expect(err).ToNot(HaveOccurred())
+					Expect(strings.Split(strings.TrimSpace(string(contents)), "\n")).To(Equal([]string{
+						"PASSED HasLicense",
+						"FAILED RunAsNonRoot",
+						"FAILED " + resultsFile,
+					}))
+				})
+
+				It("Should print the digest of the image in the summary", func() {
+					withDigest := func(ctx context.Context) (certification.Results, error) {
+						results, err := runChecks(ctx)
+						results.ImageDigest = "sha256:0123"
+						return results, err
+					}
+					err := RunPreflight(testcontext, withDigest, CheckConfig{Summary: true}, testFormatter, &runtime.ResultWriterFile{}, nil)
+					Expect(err).ToNot(HaveOccurred())
+
+					contents, err := os.ReadFile(stdout.Name())
+					Expect(err).ToNot(HaveOccurred())
+					Expect(strings.Split(string(contents), "\n")[0]).To(Equal("DIGEST sha256:0123"))
+				})
+			})
+
+			When("the certification checklist is requested", func() {
+				It("Should write the checklist as an artifact", func() {
+					err := RunPreflight(testcontext, func(ctx context.Context) (certification.Results, error) {
+						return certification.Results{
+							TestedImage:   "testChecklist",
+							PassedOverall: true,
+							Passed: []certification.Result{
+								{
+									Check: check.NewGenericCheck(
+										"HasLicense",
+										func(ctx context.Context, ir image.ImageReference) (bool, error) { return true, nil },
+										check.Metadata{},
+										check.HelpText{},
+									),
+									ElapsedTime: 1,
+								},
+							},
+							Failed: []certification.Result{},
+							Errors: []certification.Result{},
+						}, nil
+					}, CheckConfig{IncludeChecklist: true}, testFormatter, &runtime.ResultWriterFile{}, nil)
+					Expect(err).ToNot(HaveOccurred())
+
+					contents, err := os.ReadFile(filepath.Join(artifactWriter.Path(), ChecklistFilename))
+					Expect(err).ToNot(HaveOccurred())
+					Expect(string(contents)).To(ContainSubstring("|Met|HasLicense|PASSED|"))
+				})
+			})
+
+			When("the GitLab Code Quality report is requested", func() {
+				It("Should write the failed checks as an artifact", func() {
+					err := RunPreflight(testcontext, func(ctx context.Context) (certification.Results, error) {
+						return certification.Results{
+							TestedImage:   "testCodeQuality",
+							PassedOverall: false,
+							Failed: []certification.Result{
+								{
+									Check: check.NewGenericCheck(
+										"RunAsNonRoot",
+										func(ctx context.Context, ir image.ImageReference) (bool, error) { return false, nil },
+										check.Metadata{},
+										check.HelpText{},
+									),
+									ElapsedTime: 1,
+								},
+							},
+						}, nil
+					}, CheckConfig{IncludeCodeQuality: true}, testFormatter, &runtime.ResultWriterFile{}, nil)
+					Expect(err).ToNot(HaveOccurred())
+
+					contents, err := os.ReadFile(filepath.Join(artifactWriter.Path(), CodeQualityFilename))
+					Expect(err).ToNot(HaveOccurred())
+					Expect(string(contents)).To(ContainSubstring(`"check_name": "RunAsNonRoot"`))
+				})
+			})
+
+			When("reporting to Azure DevOps", func() {
+				It("Should write NUnit results and the checklist as artifacts", func() {
+					err := RunPreflight(testcontext, func(ctx context.Context) (certification.Results, error) {
+						return certification.Results{TestedImage: "testAzure", PassedOverall: true}, nil
+					}, CheckConfig{CI: ci.AzureDevOps}, testFormatter, &runtime.ResultWriterFile{}, nil)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(filepath.Join(artifactWriter.Path(), NUnitFilename)).To(BeAnExistingFile())
+					Expect(filepath.Join(artifactWriter.Path(), ChecklistFilename)).To(BeAnExistingFile())
+				})
+			})
+
+			When("reporting to CircleCI", func() {
+				It("Should default the JUnit path", func() {
+					Expect(withCIDefaults(CheckConfig{CI: ci.CircleCI}).JUnitPath).To(Equal(CircleCIJUnitPath))
+				})
+				It("Should not override a configured JUnit path", func() {
+					Expect(withCIDefaults(CheckConfig{CI: ci.CircleCI, JUnitPath: "out.xml"}).JUnitPath).To(Equal("out.xml"))
+				})
+			})
+
+			When("reporting to GitLab", func() {
+				It("Should write JUnit results and the Code Quality report", func() {
+					cfg := withCIDefaults(CheckConfig{CI: ci.GitLab})
+					Expect(cfg.IncludeJUnitResults).To(BeTrue())
+					Expect(cfg.IncludeCodeQuality).To(BeTrue())
+				})
+			})
+
+			When("an events file is requested", func() {
+				It("Should write the run events as newline-delimited JSON", func() {
+					eventsFile := filepath.Join(artifactWriter.Path(), "events.ndjson")
+					c := CheckConfig{
+						EventsFile: eventsFile,
+					}
+
+					err := RunPreflight(testcontext, func(ctx context.Context) (certification.Results, error) {
+						return certification.Results{
+							TestedImage:   "testEvents",
+							PassedOverall: true,
+							Passed: []certification.Result{
+								{
+									Check: check.NewGenericCheck(
+										"testEvents",
+										func(ctx context.Context, ir image.ImageReference) (bool, error) { return true, nil },
+										check.Metadata{},
+										check.HelpText{},
+									),
+									ElapsedTime: 1,
+								},
+							},
+							Failed: []certification.Result{},
+							Errors: []certification.Result{},
+						}, nil
+					}, c, testFormatter, &runtime.ResultWriterFile{}, nil)
+					Expect(err).ToNot(HaveOccurred())
+
+					contents, err := os.ReadFile(eventsFile)
+					Expect(err).ToNot(HaveOccurred())
+					lines := strings.Split(strings.TrimSpace(string(contents)), "\n")
+					Expect(lines).To(HaveLen(2))
+
+					var summary events.Event
+					Expect(json.Unmarshal([]byte(lines[1]), &summary)).To(Succeed())
+					Expect(summary.Type).To(Equal(events.TypeRunSummary))
+					Expect(summary.Image).To(Equal("testEvents"))
+					Expect(summary.Result).To(Equal("PASSED"))
+					Expect(summary.Passed).To(Equal(1))
+				})
+
+				It("Should only write events to stdout when events are written to stdout", func() {
+					stdout, err := os.CreateTemp(artifactWriter.Path(), "stdout-*")
+					Expect(err).ToNot(HaveOccurred())
+					defer stdout.Close()
+
+					originalStdout := os.Stdout
+					os.Stdout = stdout
+					DeferCleanup(func() { os.Stdout = originalStdout })
+
+					err = RunPreflight(testcontext, func(ctx context.Context) (certification.Results, error) {
+						return certification.Results{
+							TestedImage:   "testEventsStdout",
+							PassedOverall: true,
+							Passed:        []certification.Result{},
+							Failed:        []certification.Result{},
+							Errors:        []certification.Result{},
+						}, nil
+					}, CheckConfig{EventsFile: "-"}, testFormatter, &runtime.ResultWriterFile{}, nil)
+					os.Stdout = originalStdout
+					Expect(err).ToNot(HaveOccurred())
+
+					contents, err := os.ReadFile(stdout.Name())
+					Expect(err).ToNot(HaveOccurred())
+					lines := strings.Split(strings.TrimSpace(string(contents)), "\n")
+					Expect(lines).To(HaveLen(2))
+					for _, line := range lines {
+						var e events.Event
+						Expect(json.Unmarshal([]byte(line), &e)).To(Succeed())
+					}
+
+					results, err := os.ReadFile(filepath.Join(artifactWriter.Path(), ResultsFilenameWithExtension(testFormatter.FileExtension())))
+					Expect(err).ToNot(HaveOccurred())
+					Expect(string(results)).To(ContainSubstring("testEventsStdout"))
+				})
+
+				It("Should return an error if the events file cannot be opened", func() {
+					c := CheckConfig{
+						EventsFile: filepath.Join(artifactWriter.Path(), "does", "not", "exist", "events.ndjson"),
+					}
+
+					err := RunPreflight(testcontext, func(ctx context.Context) (certification.Results, error) {
+						return certification.Results{}, nil
+					}, c, testFormatter, &runtime.ResultWriterFile{}, nil)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("could not open events file"))
+				})
+			})
+
 			When("Submission is requested", func() {
 				It("Should call the submitter", func() {
 					c := CheckConfig{
@@ -166,10 +419,279 @@ var _ = Describe("CLI Library function", func() {
 					Expect(err.Error()).To(ContainSubstring(submissionError))
 				})
 			})
+
+			When("Comparison to a reference image is requested", func() {
+				var runChecks func(context.Context) (certification.Results, error)
+
+				BeforeEach(func() {
+					runChecks = func(ctx context.Context) (certification.Results, error) {
+						return certification.Results{
+							TestedImage: "candidate",
+							Passed: []certification.Result{
+								{Check: check.NewGenericCheck("stillPassing", nil, check.Metadata{}, check.HelpText{})},
+							},
+							Failed: []certification.Result{
+								{Check: check.NewGenericCheck("regressed", nil, check.Metadata{}, check.HelpText{})},
+							},
+						}, nil
+					}
+				})
+
+				It("Should fail without submitting if a check the reference passed does not pass", func() {
+					c := CheckConfig{
+						SubmitResults: true,
+						CompareTo: func(context.Context) (compare.Outcomes, error) {
+							return compare.Outcomes{Image: "reference", Checks: map[string]certification.Status{
+								"stillPassing": certification.StatusPassed,
+								"regressed":    certification.StatusPassed,
+							}}, nil
+						},
+					}
+
+					err := RunPreflight(testcontext, runChecks, c, testFormatter, &runtime.ResultWriterFile{}, &badResultSubmitter{"should not submit"})
+					Expect(err).To(MatchError(ContainSubstring("regressed (FAILED)")))
+					Expect(err).ToNot(MatchError(ContainSubstring("should not submit")))
+
+					_, err = os.Stat(filepath.Join(artifactWriter.Path(), ResultsFilenameWithExtension(testFormatter.FileExtension())))
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("Should succeed if no check the reference passed regressed", func() {
+					c := CheckConfig{
+						CompareTo: func(context.Context) (compare.Outcomes, error) {
+							return compare.Outcomes{Image: "reference", Checks: map[string]certification.Status{
+								"stillPassing": certification.StatusPassed,
+								"regressed":    certification.StatusFailed,
+							}}, nil
+						},
+					}
+
+					Expect(RunPreflight(testcontext, runChecks, c, testFormatter, &runtime.ResultWriterFile{}, nil)).To(Succeed())
+				})
+
+				It("Should fail if the reference cannot be checked", func() {
+					c := CheckConfig{
+						CompareTo: func(context.Context) (compare.Outcomes, error) {
+							return compare.Outcomes{}, errors.New("could not check reference image")
+						},
+					}
+
+					err := RunPreflight(testcontext, runChecks, c, testFormatter, &runtime.ResultWriterFile{}, nil)
+					Expect(err).To(MatchError(ContainSubstring("could not check reference image")))
+				})
+			})
+
+			When("policy exceptions are configured", func() {
+				runChecks := func(ctx context.Context) (certification.Results, error) {
+					return certification.Results{
+						TestedImage: "quay.io/example/image:mytag",
+						Failed: []certification.Result{
+							{Check: check.NewGenericCheck("RunAsNonRoot", nil, check.Metadata{}, check.HelpText{})},
+						},
+					}, nil
+				}
+
+				It("Should write the waived failures to the results", func() {
+					l, err := exceptions.New(exceptions.Exception{
+						Check:         "RunAsNonRoot",
+						Image:         "quay.io/example/*",
+						Justification: "CERT-1",
+						Expires:       "2999-12-31",
+					})
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(RunPreflight(testcontext, runChecks, CheckConfig{Exceptions: l}, testFormatter, &runtime.ResultWriterFile{}, nil)).To(Succeed())
+
+					contents, err := os.ReadFile(filepath.Join(artifactWriter.Path(), ResultsFilenameWithExtension(testFormatter.FileExtension())))
+					Expect(err).ToNot(HaveOccurred())
+					Expect(string(contents)).To(ContainSubstring(`"waived"`))
+					Expect(string(contents)).To(ContainSubstring("CERT-1"))
+				})
+
+				It("Should not waive failures whose exceptions have expired", func() {
+					l, err := exceptions.New(exceptions.Exception{
+						Check:         "RunAsNonRoot",
+						Image:         "quay.io/example/*",
+						Justification: "CERT-1",
+						Expires:       "2000-01-01",
+					})
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(RunPreflight(testcontext, runChecks, CheckConfig{Exceptions: l}, testFormatter, &runtime.ResultWriterFile{}, nil)).To(Succeed())
+
+					contents, err := os.ReadFile(filepath.Join(artifactWriter.Path(), ResultsFilenameWithExtension(testFormatter.FileExtension())))
+					Expect(err).ToNot(HaveOccurred())
+					Expect(string(contents)).ToNot(ContainSubstring(`"waived"`))
+				})
+			})
+
+			When("known issues are configured", func() {
+				It("Should annotate the failures they affect in the results", func() {
+					runChecks := func(ctx context.Context) (certification.Results, error) {
+						return certification.Results{
+							TestedImage: "quay.io/example/image:mytag",
+							Failed: []certification.Result{
+								{Check: check.NewGenericCheck("BasedOnUbi", nil, check.Metadata{}, check.HelpText{})},
+							},
+						}, nil
+					}
+					l, err := knownissues.New(knownissues.Issue{ID: "123", Title: "Pyxis is returning errors", Checks: []string{"BasedOnUbi"}})
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(RunPreflight(testcontext, runChecks, CheckConfig{KnownIssues: l}, testFormatter, &runtime.ResultWriterFile{}, nil)).To(Succeed())
+
+					contents, err := os.ReadFile(filepath.Join(artifactWriter.Path(), ResultsFilenameWithExtension(testFormatter.FileExtension())))
+					Expect(err).ToNot(HaveOccurred())
+					Expect(string(contents)).To(ContainSubstring(`"known_issues"`))
+					Expect(string(contents)).To(ContainSubstring("Pyxis is returning errors"))
+				})
+			})
+
+			When("a post-run command is configured", func() {
+				var command string
+				BeforeEach(func() {
+					command = filepath.Join(GinkgoT().TempDir(), "post-run.sh")
+					Expect(os.WriteFile(command, []byte("#!/bin/sh\necho \"results=$1 status=$2\"\n"), 0o755)).To(Succeed())
+				})
+
+				It("Should execute it with the results path and exit status, and write its output", func() {
+					runChecks := func(context.Context) (certification.Results, error) {
+						return certification.Results{}, nil
+					}
+					Expect(RunPreflight(testcontext, runChecks, CheckConfig{PostRunCommand: command}, testFormatter, &runtime.ResultWriterFile{}, nil)).To(Succeed())
+
+					output, err := os.ReadFile(filepath.Join(artifactWriter.Path(), PostRunLogFilename))
+					Expect(err).ToNot(HaveOccurred())
+					Expect(string(output)).To(ContainSubstring("results=" + filepath.Join(artifactWriter.Path(), ResultsFilenameWithExtension(testFormatter.FileExtension()))))
+					Expect(string(output)).To(ContainSubstring("status=0"))
+				})
+
+				It("Should pass a non-zero exit status if the execution failed", func() {
+					runChecks := func(context.Context) (certification.Results, error) {
+						return certification.Results{}, errors.New("the checks could not be executed")
+					}
+					Expect(RunPreflight(testcontext, runChecks, CheckConfig{PostRunCommand: command}, testFormatter, &runtime.ResultWriterFile{}, nil)).ToNot(Succeed())
+
+					output, err := os.ReadFile(filepath.Join(artifactWriter.Path(), PostRunLogFilename))
+					Expect(err).ToNot(HaveOccurred())
+					Expect(string(output)).To(ContainSubstring("status=1"))
+				})
+
+				It("Should throw an error if the command fails", func() {
+					Expect(os.WriteFile(command, []byte("#!/bin/sh\necho broken\nexit 3\n"), 0o755)).To(Succeed())
+					runChecks := func(context.Context) (certification.Results, error) {
+						return certification.Results{}, nil
+					}
+					err := RunPreflight(testcontext, runChecks, CheckConfig{PostRunCommand: command}, testFormatter, &runtime.ResultWriterFile{}, nil)
+					Expect(err).To(MatchError(ContainSubstring("post-run command " + command + " failed")))
+
+					output, err := os.ReadFile(filepath.Join(artifactWriter.Path(), PostRunLogFilename))
+					Expect(err).ToNot(HaveOccurred())
+					Expect(string(output)).To(Equal("broken\n"))
+				})
+			})
+
+			When("preflight is interrupted", func() {
+				It("Should write the partial results without submitting them, and error", func() {
+					ctx, cancel := interrupt.WithInterrupt(testcontext)
+					defer cancel()
+					runChecks := func(context.Context) (certification.Results, error) {
+						cancel()
+						return certification.Results{
+							Passed: []certification.Result{
+								{Check: check.NewGenericCheck("HasLicense", nil, check.Metadata{}, check.HelpText{})},
+							},
+							Skipped: []certification.Result{
+								{Check: check.NewGenericCheck("RunAsNonRoot", nil, check.Metadata{}, check.HelpText{}), Reason: interrupt.ErrInterrupted.Error()},
+							},
+						}, nil
+					}
+
+					err := RunPreflight(ctx, runChecks, CheckConfig{SubmitResults: true}, testFormatter, &runtime.ResultWriterFile{}, &badResultSubmitter{"should not submit"})
+					Expect(err).To(MatchError(interrupt.ErrInterrupted))
+					Expect(err).ToNot(MatchError(ContainSubstring("should not submit")))
+
+					contents, err := os.ReadFile(filepath.Join(artifactWriter.Path(), ResultsFilenameWithExtension(testFormatter.FileExtension())))
+					Expect(err).ToNot(HaveOccurred())
+					Expect(string(contents)).To(ContainSubstring("RunAsNonRoot"))
+				})
+			})
+
+			When("exit codes are configured", func() {
+				codes := &ExitCodes{Failed: 2, Error: 3, Warning: 4}
+				failedChecks := func(context.Context) (certification.Results, error) {
+					return certification.Results{
+						TestedImage: "quay.io/example/image:mytag",
+						Failed: []certification.Result{
+							{Check: check.NewGenericCheck("RunAsNonRoot", nil, check.Metadata{}, check.HelpText{})},
+						},
+					}, nil
+				}
+
+				It("Should return the exit code of failed checks once the results are written", func() {
+					err := RunPreflight(testcontext, failedChecks, CheckConfig{ExitCodes: codes}, testFormatter, &runtime.ResultWriterFile{}, nil)
+					Expect(err).To(MatchError(&ExitError{Code: 2, Result: certification.StatusFailed}))
+					Expect(filepath.Join(artifactWriter.Path(), ResultsFilenameWithExtension(testFormatter.FileExtension()))).To(BeAnExistingFile())
+				})
+
+				It("Should return the exit code of warnings if failures were waived", func() {
+					l, err := exceptions.New(exceptions.Exception{
+						Check:         "RunAsNonRoot",
+						Image:         "quay.io/example/*",
+						Justification: "CERT-1",
+						Expires:       "2999-12-31",
+					})
+					Expect(err).ToNot(HaveOccurred())
+
+					err = RunPreflight(testcontext, failedChecks, CheckConfig{ExitCodes: codes, Exceptions: l}, testFormatter, &runtime.ResultWriterFile{}, nil)
+					Expect(err).To(MatchError(&ExitError{Code: 4, Result: certification.StatusPassed}))
+				})
+
+				It("Should not return an error if the checks passed", func() {
+					runChecks := func(context.Context) (certification.Results, error) {
+						return certification.Results{PassedOverall: true}, nil
+					}
+					Expect(RunPreflight(testcontext, runChecks, CheckConfig{ExitCodes: codes}, testFormatter, &runtime.ResultWriterFile{}, nil)).To(Succeed())
+				})
+
+				It("Should pass the exit code to the post-run command", func() {
+					command := filepath.Join(GinkgoT().TempDir(), "post-run.sh")
+					Expect(os.WriteFile(command, []byte("#!/bin/sh\necho \"status=$2\"\n"), 0o755)).To(Succeed())
+
+					err := RunPreflight(testcontext, failedChecks, CheckConfig{ExitCodes: codes, PostRunCommand: command}, testFormatter, &runtime.ResultWriterFile{}, nil)
+					Expect(err).To(HaveOccurred())
+
+					output, err := os.ReadFile(filepath.Join(artifactWriter.Path(), PostRunLogFilename))
+					Expect(err).ToNot(HaveOccurred())
+					Expect(string(output)).To(ContainSubstring("status=2"))
+				})
+			})
 		})
 	})
 })
 
+var _ = Describe("Exit codes", func() {
+	codes := ExitCodes{Failed: 2, Error: 3, Warning: 4}
+
+	It("should map the outcome of the results to their codes", func() {
+		Expect(codes.ForResults(certification.Results{PassedOverall: true})).To(Equal(0))
+		Expect(codes.ForResults(certification.Results{PassedOverall: false})).To(Equal(2))
+		Expect(codes.ForResults(certification.Results{PassedOverall: true, Waived: []certification.Result{{}}})).To(Equal(4))
+		Expect(codes.ForResults(certification.Results{PassedOverall: true, Passed: []certification.Result{{Attempts: 2}}})).To(Equal(4))
+	})
+
+	It("should map errors to their codes", func() {
+		Expect(codes.ForError(nil)).To(Equal(0))
+		Expect(codes.ForError(errors.New("the image could not be pulled"))).To(Equal(3))
+		Expect(codes.ForError(fmt.Errorf("wrapped: %w", &ExitError{Code: 2}))).To(Equal(2))
+	})
+
+	It("should only exit with a non-zero code for errors by default", func() {
+		Expect(DefaultExitCodes().ForResults(certification.Results{PassedOverall: false})).To(Equal(0))
+		Expect(DefaultExitCodes().ForError(errors.New("the image could not be pulled"))).To(Equal(1))
+	})
+})
+
 var _ = Describe("JUnit", func() {
 	var results *certification.Results
 	var junitfile string
@@ -198,11 +720,24 @@ var _ = Describe("JUnit", func() {
 
 	When("The additional JUnitXML results file is requested", func() {
 		It("should be written to the artifacts directory without error", func() {
-			Expect(writeJUnit(testcontext, *results)).To(Succeed())
+			Expect(writeJUnit(testcontext, *results, "")).To(Succeed())
 			_, err := os.Stat(junitfile)
 			Expect(err).ToNot(HaveOccurred())
 		})
 	})
+
+	When("a JUnit path is provided", func() {
+		It("should be written to the path, creating its directory", func() {
+			path := filepath.Join(GinkgoT().TempDir(), "reports", "preflight.xml")
+			Expect(writeJUnit(testcontext, *results, path)).To(Succeed())
+			Expect(path).To(BeAnExistingFile())
+			Expect(junitfile).ToNot(BeAnExistingFile())
+
+			contents, err := os.ReadFile(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring(`name="Red Hat Certification"`))
+		})
+	})
 })
 
 var _ = DescribeTable("Checking overall pass/fail",
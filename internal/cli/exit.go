@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/certification"
+)
+
+// ExitCodes are the codes preflight exits with for each outcome of a run, so that CI
+// systems can distinguish checks that did not pass from preflight itself erroring
+// without parsing its output.
+type ExitCodes struct {
+	// Failed is the code of runs in which checks failed or errored.
+	Failed int
+	// Error is the code of runs that preflight could not complete.
+	Error int
+	// Warning is the code of runs in which checks passed, but failures were waived, or
+	// checks only passed after they were executed again.
+	Warning int
+}
+
+// DefaultExitCodes returns the codes preflight exits with unless configured otherwise:
+// 1 if preflight errors, and 0 otherwise, regardless of the results of the checks.
+func DefaultExitCodes() ExitCodes {
+	return ExitCodes{Error: 1}
+}
+
+// ForResults returns the code of the outcome of results.
+func (c ExitCodes) ForResults(results certification.Results) int {
+	if !results.PassedOverall {
+		return c.Failed
+	}
+
+	if len(results.Waived) > 0 {
+		return c.Warning
+	}
+	for _, r := range results.Passed {
+		if r.Retried() {
+			return c.Warning
+		}
+	}
+
+	return 0
+}
+
+// ForError returns the code of a run that returned err: that of the outcome of its
+// checks if err is an ExitError, the code of errors if err is another error, and 0 if
+// err is nil.
+func (c ExitCodes) ForError(err error) int {
+	var exitErr *ExitError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &exitErr):
+		return exitErr.Code
+	}
+
+	return c.Error
+}
+
+// ExitError is returned by runs that completed, but whose outcome has a non-zero exit
+// code, e.g. because checks failed.
+type ExitError struct {
+	// Code is the code preflight exits with.
+	Code int
+	// Result is the overall result of the run.
+	Result certification.Status
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("preflight result: %s (exit code %d)", e.Result, e.Code)
+}
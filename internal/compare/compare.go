@@ -0,0 +1,286 @@
+// Package compare gates the results of an image on those of a reference image, e.g.
+// a previously certified release, so that an image is only promoted if no check that
+// the reference passed regresses.
+package compare
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/artifacts"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/certification"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/formatters"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/version"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// StatusNotRun is the status of a check that the reference executed, but the
+// candidate did not.
+const StatusNotRun certification.Status = "NOT RUN"
+
+// Outcomes are the statuses of the checks executed for an image.
+type Outcomes struct {
+	Image  string                          `json:"image"`
+	Checks map[string]certification.Status `json:"checks"`
+}
+
+// FromResults returns the outcomes of results.
+func FromResults(results certification.Results) Outcomes {
+	o := Outcomes{Image: results.TestedImage, Checks: map[string]certification.Status{}}
+	for _, r := range results.Passed {
+		o.Checks[r.Name()] = certification.StatusPassed
+	}
+	for _, r := range results.Failed {
+		o.Checks[r.Name()] = certification.StatusFailed
+	}
+	for _, r := range results.Errors {
+		o.Checks[r.Name()] = certification.StatusErrored
+	}
+
+	return o
+}
+
+// LoadResults returns the outcomes in the results file at path, written by a
+// previous execution in the default JSON format.
+func LoadResults(path string) (Outcomes, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return Outcomes{}, fmt.Errorf("could not read results: %w", err)
+	}
+
+	var results formatters.UserResponse
+	if err := json.Unmarshal(b, &results); err != nil {
+		return Outcomes{}, fmt.Errorf("could not parse results: %w", err)
+	}
+	if results.Image == "" {
+		return Outcomes{}, fmt.Errorf("%s does not contain preflight results", path)
+	}
+
+	o := Outcomes{Image: results.Image, Checks: map[string]certification.Status{}}
+	for _, c := range results.Results.Passed {
+		o.Checks[c.Name] = certification.StatusPassed
+	}
+	for _, c := range results.Results.Failed {
+		o.Checks[c.Name] = certification.StatusFailed
+	}
+	for _, c := range results.Results.Errors {
+		o.Checks[c.Name] = certification.StatusErrored
+	}
+
+	return o, nil
+}
+
+// Regression is a check that the reference passed, and the candidate did not.
+type Regression struct {
+	Check string
+	// Status is the status of the check for the candidate.
+	Status certification.Status
+}
+
+func (r Regression) String() string {
+	return fmt.Sprintf("%s (%s)", r.Check, r.Status)
+}
+
+// Regressions returns the checks that reference passed and candidate did not,
+// ordered by name. Checks the reference failed or errored are not compared.
+func Regressions(reference, candidate Outcomes) []Regression {
+	var regressions []Regression
+	for check, status := range reference.Checks {
+		if status != certification.StatusPassed {
+			continue
+		}
+
+		candidateStatus, ok := candidate.Checks[check]
+		if !ok {
+			candidateStatus = StatusNotRun
+		}
+		if candidateStatus != certification.StatusPassed {
+			regressions = append(regressions, Regression{Check: check, Status: candidateStatus})
+		}
+	}
+
+	sort.Slice(regressions, func(i, j int) bool { return regressions[i].Check < regressions[j].Check })
+
+	return regressions
+}
+
+// RegressionError returns an error describing regressions of candidate from
+// reference, or nil if there are none.
+func RegressionError(reference, candidate Outcomes) error {
+	regressions := Regressions(reference, candidate)
+	if len(regressions) == 0 {
+		return nil
+	}
+
+	described := make([]string, 0, len(regressions))
+	for _, r := range regressions {
+		described = append(described, r.String())
+	}
+
+	return fmt.Errorf("%d check(s) passed by %s did not pass: %s", len(regressions), reference.Image, strings.Join(described, ", "))
+}
+
+// RunFunc executes the checks for image.
+type RunFunc func(ctx context.Context, image string) (certification.Results, error)
+
+type resolveOptions struct {
+	cache   *Cache
+	variant []string
+}
+
+// Option configures Resolve.
+type Option = func(*resolveOptions)
+
+// WithCache caches the outcomes of reference images in c, and uses them rather than
+// executing checks again. Only references by digest are cached, since a tag may refer
+// to a different image later. The variant, e.g. the policy and platform, distinguishes
+// the outcomes of the same image checked differently.
+func WithCache(c *Cache, variant ...string) Option {
+	return func(o *resolveOptions) {
+		o.cache = c
+		o.variant = variant
+	}
+}
+
+// Resolve returns the outcomes for reference, which is either the path to a results
+// file written by a previous execution, or an image whose checks are executed with run.
+// The artifacts written while checking the image are discarded.
+func Resolve(ctx context.Context, reference string, run RunFunc, opts ...Option) (Outcomes, error) {
+	logger := logr.FromContextOrDiscard(ctx)
+
+	o := resolveOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if info, err := os.Stat(reference); err == nil && !info.IsDir() {
+		logger.Info("comparing to previous results", "results", reference)
+		return LoadResults(reference)
+	}
+
+	key, cacheable := CacheKey(reference, o.variant...)
+	cacheable = cacheable && o.cache != nil
+	if cacheable {
+		outcomes, ok, err := o.cache.Load(key)
+		if err != nil {
+			logger.Error(err, "unable to read cached reference results", "image", reference)
+		}
+		if ok {
+			logger.Info("comparing to cached results", "image", reference)
+			return outcomes, nil
+		}
+	}
+
+	tmpdir, err := os.MkdirTemp(os.TempDir(), "preflight-compare-*")
+	if err != nil {
+		return Outcomes{}, fmt.Errorf("could not create temporary directory for reference artifacts: %w", err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	aw, err := artifacts.NewFilesystemWriter(artifacts.WithDirectory(tmpdir))
+	if err != nil {
+		return Outcomes{}, err
+	}
+
+	logger.Info("checking reference image", "image", reference)
+	results, err := run(artifacts.ContextWithWriter(ctx, aw), reference)
+	if err != nil {
+		return Outcomes{}, fmt.Errorf("could not check reference image %s: %w", reference, err)
+	}
+
+	outcomes := FromResults(results)
+	if cacheable {
+		if err := o.cache.Store(key, outcomes); err != nil {
+			logger.Error(err, "unable to cache reference results", "image", reference)
+		}
+	}
+
+	return outcomes, nil
+}
+
+// CacheKey returns the key under which the outcomes for image, checked as described
+// by variant, are cached, and whether they can be cached at all. The outcomes of
+// images referenced by tag are not cached, and neither are those of a different
+// version of preflight, which may execute different checks.
+func CacheKey(image string, variant ...string) (string, bool) {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return "", false
+	}
+	if _, ok := ref.(name.Digest); !ok {
+		return "", false
+	}
+
+	parts := append([]string{version.Version.Version, version.Version.Commit, ref.Name()}, variant...)
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+
+	return hex.EncodeToString(sum[:]), true
+}
+
+// Cache stores the outcomes of reference images on disk.
+type Cache struct {
+	dir string
+}
+
+// NewCache returns a Cache storing outcomes in dir.
+func NewCache(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+// DefaultCacheDir returns the directory in the user's cache directory where outcomes
+// are cached, e.g. ~/.cache/preflight/compare.
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "preflight", "compare"), nil
+}
+
+// Load returns the outcomes cached under key, and whether any were.
+func (c *Cache) Load(key string) (Outcomes, bool, error) {
+	b, err := os.ReadFile(c.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return Outcomes{}, false, nil
+	}
+	if err != nil {
+		return Outcomes{}, false, err
+	}
+
+	var o Outcomes
+	if err := json.Unmarshal(b, &o); err != nil {
+		return Outcomes{}, false, fmt.Errorf("could not parse cached results: %w", err)
+	}
+
+	return o, true, nil
+}
+
+// Store caches o under key.
+func (c *Cache) Store(key string, o Outcomes) error {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return fmt.Errorf("could not create cache directory: %w", err)
+	}
+
+	return os.WriteFile(c.path(key), b, 0o644)
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
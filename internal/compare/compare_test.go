@@ -0,0 +1,176 @@
+package compare
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/artifacts"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/certification"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
+)
+
+const referenceImage = "quay.io/example/image@sha256:b05b6ac0d3c9ef1ac4bc0fd8d8b62ba6ad9ba7ba1d8b0e3ba4d3c9d3ea1ee9e1"
+
+func result(name string) certification.Result {
+	return certification.Result{Check: check.NewGenericCheck(name, nil, check.Metadata{}, check.HelpText{})}
+}
+
+var _ = Describe("Compare", func() {
+	reference := Outcomes{
+		Image: referenceImage,
+		Checks: map[string]certification.Status{
+			"HasLicense":              certification.StatusPassed,
+			"RunAsNonRoot":            certification.StatusPassed,
+			"LayerCountAcceptable":    certification.StatusPassed,
+			"HasNoProhibitedPackages": certification.StatusFailed,
+		},
+	}
+
+	Context("When building outcomes from results", func() {
+		It("should record the status of each check", func() {
+			o := FromResults(certification.Results{
+				TestedImage: "quay.io/example/image:candidate",
+				Passed:      []certification.Result{result("HasLicense")},
+				Failed:      []certification.Result{result("RunAsNonRoot")},
+				Errors:      []certification.Result{result("LayerCountAcceptable")},
+			})
+			Expect(o.Image).To(Equal("quay.io/example/image:candidate"))
+			Expect(o.Checks).To(Equal(map[string]certification.Status{
+				"HasLicense":           certification.StatusPassed,
+				"RunAsNonRoot":         certification.StatusFailed,
+				"LayerCountAcceptable": certification.StatusErrored,
+			}))
+		})
+	})
+
+	Context("When comparing outcomes", func() {
+		It("should report checks the reference passed that the candidate did not, by name", func() {
+			candidate := Outcomes{Checks: map[string]certification.Status{
+				"HasLicense":   certification.StatusPassed,
+				"RunAsNonRoot": certification.StatusErrored,
+			}}
+			Expect(Regressions(reference, candidate)).To(Equal([]Regression{
+				{Check: "LayerCountAcceptable", Status: StatusNotRun},
+				{Check: "RunAsNonRoot", Status: certification.StatusErrored},
+			}))
+			Expect(RegressionError(reference, candidate)).To(MatchError(ContainSubstring("2 check(s) passed by " + referenceImage + " did not pass: LayerCountAcceptable (NOT RUN), RunAsNonRoot (ERROR)")))
+		})
+		It("should not report checks the reference did not pass", func() {
+			candidate := Outcomes{Checks: map[string]certification.Status{
+				"HasLicense":              certification.StatusPassed,
+				"RunAsNonRoot":            certification.StatusPassed,
+				"LayerCountAcceptable":    certification.StatusPassed,
+				"HasNoProhibitedPackages": certification.StatusErrored,
+			}}
+			Expect(Regressions(reference, candidate)).To(BeEmpty())
+			Expect(RegressionError(reference, candidate)).To(Succeed())
+		})
+	})
+
+	Context("When loading results", func() {
+		It("should read the results file written by a previous execution", func() {
+			path := filepath.Join(GinkgoT().TempDir(), "results.json")
+			Expect(os.WriteFile(path, []byte(`{
+				"image": "quay.io/example/image:1.0",
+				"passed": false,
+				"results": {
+					"passed": [{"name": "HasLicense"}],
+					"failed": [{"name": "RunAsNonRoot"}],
+					"errors": [{"name": "LayerCountAcceptable"}]
+				}
+			}`), 0o644)).To(Succeed())
+
+			o, err := LoadResults(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(o.Image).To(Equal("quay.io/example/image:1.0"))
+			Expect(o.Checks).To(HaveKeyWithValue("HasLicense", certification.StatusPassed))
+			Expect(o.Checks).To(HaveKeyWithValue("RunAsNonRoot", certification.StatusFailed))
+			Expect(o.Checks).To(HaveKeyWithValue("LayerCountAcceptable", certification.StatusErrored))
+		})
+		It("should reject files that are not results", func() {
+			path := filepath.Join(GinkgoT().TempDir(), "results.json")
+			Expect(os.WriteFile(path, []byte(`{}`), 0o644)).To(Succeed())
+
+			_, err := LoadResults(path)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("When computing cache keys", func() {
+		It("should only cache references by digest", func() {
+			_, ok := CacheKey("quay.io/example/image:latest", "container")
+			Expect(ok).To(BeFalse())
+
+			key, ok := CacheKey(referenceImage, "container")
+			Expect(ok).To(BeTrue())
+			Expect(key).ToNot(BeEmpty())
+		})
+		It("should distinguish variants", func() {
+			container, _ := CacheKey(referenceImage, "container", "amd64")
+			arm, _ := CacheKey(referenceImage, "container", "arm64")
+			Expect(container).ToNot(Equal(arm))
+		})
+	})
+
+	Context("When resolving the reference", func() {
+		var runs int
+		var run RunFunc
+
+		BeforeEach(func() {
+			runs = 0
+			run = func(ctx context.Context, image string) (certification.Results, error) {
+				runs++
+				Expect(artifacts.WriterFromContext(ctx)).ToNot(BeNil())
+				return certification.Results{
+					TestedImage: image,
+					Passed:      []certification.Result{result("HasLicense")},
+				}, nil
+			}
+		})
+
+		It("should run the checks for an image, and cache the outcomes of a digest", func() {
+			cache := NewCache(GinkgoT().TempDir())
+
+			o, err := Resolve(context.TODO(), referenceImage, run, WithCache(cache, "container"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(o.Checks).To(HaveKeyWithValue("HasLicense", certification.StatusPassed))
+
+			o, err = Resolve(context.TODO(), referenceImage, run, WithCache(cache, "container"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(o.Image).To(Equal(referenceImage))
+			Expect(runs).To(Equal(1))
+
+			_, err = Resolve(context.TODO(), referenceImage, run, WithCache(cache, "operator"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runs).To(Equal(2))
+		})
+		It("should always run the checks for a tag", func() {
+			cache := NewCache(GinkgoT().TempDir())
+			for i := 0; i < 2; i++ {
+				_, err := Resolve(context.TODO(), "quay.io/example/image:latest", run, WithCache(cache, "container"))
+				Expect(err).ToNot(HaveOccurred())
+			}
+			Expect(runs).To(Equal(2))
+		})
+		It("should load a results file without running checks", func() {
+			path := filepath.Join(GinkgoT().TempDir(), "results.json")
+			Expect(os.WriteFile(path, []byte(`{"image": "quay.io/example/image:1.0", "results": {"passed": [{"name": "HasLicense"}]}}`), 0o644)).To(Succeed())
+
+			o, err := Resolve(context.TODO(), path, run)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(o.Image).To(Equal("quay.io/example/image:1.0"))
+			Expect(runs).To(Equal(0))
+		})
+		It("should fail if the reference cannot be checked", func() {
+			_, err := Resolve(context.TODO(), referenceImage, func(context.Context, string) (certification.Results, error) {
+				return certification.Results{}, errors.New("pull failed")
+			})
+			Expect(err).To(MatchError(ContainSubstring("could not check reference image")))
+		})
+	})
+})
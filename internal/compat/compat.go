@@ -0,0 +1,63 @@
+// Package compat pins the behavior of preflight that pipelines depend on, e.g. the
+// format of its results, to that of a previous minor release, so that pipelines can be
+// migrated after preflight is upgraded, rather than when it is.
+package compat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Version is a minor release of preflight whose behavior can be pinned.
+type Version string
+
+// V1_5 is the 1.5 release. Its results only include the outcome of each check, and
+// preflight exits with 0 whatever the outcome of the checks.
+const V1_5 Version = "1.5"
+
+// ErrUnsupportedVersion is the error of a version whose behavior cannot be pinned.
+var ErrUnsupportedVersion = errors.New("unsupported compatibility version")
+
+// Supported returns the versions whose behavior can be pinned, oldest first.
+func Supported() []Version {
+	return []Version{V1_5}
+}
+
+// Parse returns the minor release of version, e.g. 1.5 for v1.5.2, if its behavior can be
+// pinned.
+func Parse(version string) (Version, error) {
+	parts := strings.SplitN(strings.TrimPrefix(version, "v"), ".", 3)
+	if len(parts) >= 2 {
+		minor := Version(parts[0] + "." + parts[1])
+		for _, v := range Supported() {
+			if v == minor {
+				return v, nil
+			}
+		}
+	}
+
+	supported := make([]string, 0, len(Supported()))
+	for _, v := range Supported() {
+		supported = append(supported, string(v))
+	}
+	return "", fmt.Errorf("%w %q: must be one of %s", ErrUnsupportedVersion, version, strings.Join(supported, ", "))
+}
+
+type contextKey string
+
+const versionContextKey contextKey = "Version"
+
+// ContextWithVersion returns a copy of ctx in which the behavior of preflight is pinned
+// to that of v.
+func ContextWithVersion(ctx context.Context, v Version) context.Context {
+	return context.WithValue(ctx, versionContextKey, v)
+}
+
+// FromContext returns the version whose behavior is pinned in ctx, or "" if the
+// behavior is that of this version of preflight.
+func FromContext(ctx context.Context) Version {
+	v, _ := ctx.Value(versionContextKey).(Version)
+	return v
+}
@@ -0,0 +1,13 @@
+package compat
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestCompat(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Compat Suite")
+}
@@ -0,0 +1,37 @@
+package compat
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Compatibility versions", func() {
+	DescribeTable("parsing supported versions",
+		func(version string) {
+			v, err := Parse(version)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v).To(Equal(V1_5))
+		},
+		Entry("a minor release", "1.5"),
+		Entry("a patch release", "1.5.2"),
+		Entry("a release with a v prefix", "v1.5.0"),
+	)
+
+	DescribeTable("parsing unsupported versions",
+		func(version string) {
+			_, err := Parse(version)
+			Expect(err).To(MatchError(ErrUnsupportedVersion))
+			Expect(err).To(MatchError(ContainSubstring("must be one of 1.5")))
+		},
+		Entry("an unknown release", "1.4"),
+		Entry("a major release", "1"),
+		Entry("an empty version", ""),
+	)
+
+	It("should pin the version in the context", func() {
+		Expect(FromContext(context.Background())).To(BeEmpty())
+		Expect(FromContext(ContextWithVersion(context.Background(), V1_5))).To(Equal(V1_5))
+	})
+})
@@ -18,6 +18,77 @@ type commonConfig interface {
 	LogFile() string
 	Artifacts() string
 	WriteJUnit() bool
+	WriteChecklist() bool
+	EventsFile() string
+	Deterministic() bool
+	Progress() bool
+	Quiet() bool
+	Summary() bool
+	TraceOnFailure() bool
+	JUnitPath() string
+	Proxy() string
+	NoProxy() string
+	CABundle() string
+	WriteCodeQuality() bool
+	CI() string
+	RegistryMirrors() []string
+	MirrorConfig() string
+	Watch() bool
+	RegistryUsername() string
+	RegistryPassword() string
+	RegistryToken() string
+	CompareTo() string
+	VaultAddress() string
+	VaultNamespace() string
+	VaultToken() string
+	VaultRoleID() string
+	VaultSecretID() string
+	VaultAppRoleMount() string
+	VaultPath() string
+	DockerConfigSecret() string
+	PyxisTokenSecret() string
+	ArtifactsQuota() string
+	PyxisOIDCToken() string
+	PyxisOIDCTokenFile() string
+	PyxisOIDCClientID() string
+	PyxisOIDCTokenURL() string
+	SubmitOffline() bool
+	SubmitToURL() string
+	SubmitToURLSecret() string
+	ProbeServices() bool
+	ArtifactArchive() string
+	MarkSubmitted() string
+	OpenSearchURL() string
+	OpenSearchAPIKey() string
+	IssueTrackerConfig() string
+	ExceptionsFile() string
+	ImageResolver() string
+	PostRunCommand() string
+	KnownIssuesFeed() string
+	OperabilityCheck() string
+	ClusterProvider() string
+	ClusterProviderURL() string
+	ClusterProviderToken() string
+	OCMToken() string
+	ManagedClusterProduct() string
+	ManagedClusterRegion() string
+	ManagedClusterVersion() string
+	ManagedClusterAWSAccountID() string
+	ClusterProxy() string
+	Lockfile() string
+	UpdateLockfile() bool
+	Tmpdir() string
+	RerunFailed() string
+	FailFast() bool
+	CheckTimeout() string
+	CheckTimeouts() []string
+	DenyCheckEgress() bool
+	AllowCheckEgress() []string
+	ExitCodeFailed() int
+	ExitCodeError() int
+	ExitCodeWarning() int
+	ExpectedDigest() string
+	Compat() string
 	DockerConfig() string
 }
 
@@ -26,11 +97,17 @@ type commonConfig interface {
 type containerConfig interface {
 	IsScratch() bool
 	CertificationProjectID() string
+	PyxisEnv() string
 	PyxisHost() string
 	PyxisAPIToken() string
+	PyxisMaxQPS() float64
 	Submit() bool
+	SubmitDryRun() bool
 	Platform() string
 	Insecure() bool
+	AttachResults() bool
+	ApprovedBaseImages() []string
+	DecryptionKeys() []string
 }
 
 // operatorConfig are configurables relevant to
@@ -44,4 +121,5 @@ type operatorConfig interface {
 	Channel() string
 	Kubeconfig() string
 	IndexImage() string
+	CheckAttempts() int
 }
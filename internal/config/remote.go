@@ -0,0 +1,234 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// maxRemoteConfigSize is the largest config file that is fetched from a URL.
+const maxRemoteConfigSize = 10 << 20
+
+// remoteConfigTimeout is how long fetching a config file from a URL may take.
+const remoteConfigTimeout = 30 * time.Second
+
+// RemoteOptions configure how config files are fetched from URLs.
+type RemoteOptions struct {
+	// CABundle is the path to PEM encoded certificate authorities trusted in addition
+	// to the system's.
+	CABundle string
+	// ClientCert and ClientKey are the paths to the PEM encoded certificate and key
+	// presented to servers that require mutual TLS.
+	ClientCert, ClientKey string
+	// CacheDir is the directory fetched config files are cached in. If it is empty,
+	// DefaultRemoteCacheDir is used.
+	CacheDir string
+}
+
+// IsRemote returns true if location is the URL of a config file, rather than a path.
+func IsRemote(location string) bool {
+	return strings.HasPrefix(location, "https://") || strings.HasPrefix(location, "http://")
+}
+
+// DefaultRemoteCacheDir returns the directory config files fetched from URLs are cached
+// in by default, in the user's cache directory.
+func DefaultRemoteCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "preflight", "config"), nil
+}
+
+// FetchRemote fetches the config file at rawURL, and returns the path it is cached at,
+// with the extension of the URL so that its format is known. The ETag of the cached
+// copy is sent with the request, so that it is only downloaded again if it changed.
+func FetchRemote(ctx context.Context, rawURL string, opts RemoteOptions) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("invalid config URL %s", rawURL)
+	}
+	// The config file configures commands that preflight executes, and where results
+	// and credentials are sent, so it is only fetched from servers that are verified.
+	if u.Scheme != "https" {
+		return "", fmt.Errorf("config URL %s must use https", rawURL)
+	}
+
+	dir := opts.CacheDir
+	if dir == "" {
+		if dir, err = DefaultRemoteCacheDir(); err != nil {
+			return "", fmt.Errorf("could not determine the config cache directory: %w", err)
+		}
+	}
+	sum := sha256.Sum256([]byte(rawURL))
+	dir = filepath.Join(dir, hex.EncodeToString(sum[:8]))
+	cached := filepath.Join(dir, "config"+remoteConfigExt(u))
+	etagPath := filepath.Join(dir, "etag")
+
+	client, err := remoteConfigClient(opts)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, remoteConfigTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("invalid config URL %s: %w", rawURL, err)
+	}
+	if etag, err := os.ReadFile(etagPath); err == nil && fileExists(cached) {
+		req.Header.Set("If-None-Match", string(etag))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("could not fetch config file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return cached, nil
+	case http.StatusOK:
+	default:
+		return "", fmt.Errorf("could not fetch config file %s: %s", rawURL, resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteConfigSize+1))
+	if err != nil {
+		return "", fmt.Errorf("could not fetch config file %s: %w", rawURL, err)
+	}
+	if len(body) > maxRemoteConfigSize {
+		return "", fmt.Errorf("config file %s is larger than %d bytes", rawURL, maxRemoteConfigSize)
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("could not cache config file: %w", err)
+	}
+	if err := writeFileAtomic(cached, body); err != nil {
+		return "", fmt.Errorf("could not cache config file: %w", err)
+	}
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		err = writeFileAtomic(etagPath, []byte(etag))
+	} else if err = os.Remove(etagPath); errors.Is(err, fs.ErrNotExist) {
+		err = nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("could not cache config file: %w", err)
+	}
+
+	return cached, nil
+}
+
+// ExecSettings returns the keys of settings, those of a config file, that are commands
+// preflight executes, including those of its profiles, e.g. profiles.staging.post_run_cmd,
+// sorted. Config files fetched from URLs must not set them.
+func ExecSettings(settings map[string]interface{}) []string {
+	var keys []string
+	for _, name := range sortedKeys(settings) {
+		if strings.EqualFold(name, ProfilesKey) {
+			profiles, _ := settings[name].(map[string]interface{})
+			for _, profile := range sortedKeys(profiles) {
+				profileSettings, _ := profiles[profile].(map[string]interface{})
+				for _, key := range ExecSettings(profileSettings) {
+					keys = append(keys, name+"."+profile+"."+key)
+				}
+			}
+			continue
+		}
+		if k, ok := LookupKey(name); ok && k.Exec {
+			keys = append(keys, name)
+		}
+	}
+
+	return keys
+}
+
+// remoteConfigExt returns the extension of the config file at u, which is .yaml if it
+// has none that viper reads.
+func remoteConfigExt(u *url.URL) string {
+	switch ext := path.Ext(u.Path); ext {
+	case ".yaml", ".yml", ".json", ".toml":
+		return ext
+	default:
+		return ".yaml"
+	}
+}
+
+// remoteConfigClient returns the client that fetches config files as configured by
+// opts, through the proxy configured in the environment.
+func remoteConfigClient(opts RemoteOptions) (*http.Client, error) {
+	rt := http.DefaultTransport.(*http.Transport).Clone()
+	if opts.CABundle == "" && opts.ClientCert == "" && opts.ClientKey == "" {
+		return &http.Client{Transport: rt}, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if opts.CABundle != "" {
+		pem, err := os.ReadFile(opts.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("could not read config CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("could not read config CA bundle: %s does not contain any PEM encoded certificates", opts.CABundle)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if opts.ClientCert != "" || opts.ClientKey != "" {
+		if opts.ClientCert == "" || opts.ClientKey == "" {
+			return nil, fmt.Errorf("both a client certificate and key are required to fetch config files with mutual TLS")
+		}
+		cert, err := tls.LoadX509KeyPair(opts.ClientCert, opts.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("could not read config client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	rt.TLSClientConfig = tlsConfig
+
+	return &http.Client{Transport: rt}, nil
+}
+
+// writeFileAtomic writes data to the file at path, replacing it only once data is
+// written, so that concurrent executions never read a partial file.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+// fileExists returns true if there is a file at path.
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Remote config files", func() {
+	var (
+		server   *httptest.Server
+		requests int
+		body     string
+		opts     RemoteOptions
+	)
+
+	BeforeEach(func() {
+		requests = 0
+		body = "pyxis_env: qa\n"
+		server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			etag := `"` + body + `"`
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			if r.URL.Path == "/missing.yaml" {
+				http.NotFound(w, r)
+				return
+			}
+			w.Header().Set("ETag", etag)
+			_, _ = w.Write([]byte(body))
+		}))
+		DeferCleanup(server.Close)
+		caBundle := filepath.Join(GinkgoT().TempDir(), "ca.pem")
+		Expect(os.WriteFile(caBundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600)).To(Succeed())
+		opts = RemoteOptions{CacheDir: GinkgoT().TempDir(), CABundle: caBundle}
+	})
+
+	It("should only treat http and https URLs as remote", func() {
+		Expect(IsRemote("https://example.com/config.yaml")).To(BeTrue())
+		Expect(IsRemote("http://example.com/config.yaml")).To(BeTrue())
+		Expect(IsRemote("config.yaml")).To(BeFalse())
+		Expect(IsRemote("")).To(BeFalse())
+	})
+
+	It("should cache the config file with the extension of the URL", func() {
+		path, err := FetchRemote(context.TODO(), server.URL+"/preflight/policy.json", opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(filepath.Ext(path)).To(Equal(".json"))
+		Expect(os.ReadFile(path)).To(Equal([]byte(body)))
+	})
+
+	It("should not download the config file again if it has not changed", func() {
+		first, err := FetchRemote(context.TODO(), server.URL+"/policy.yaml", opts)
+		Expect(err).ToNot(HaveOccurred())
+		second, err := FetchRemote(context.TODO(), server.URL+"/policy.yaml", opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(second).To(Equal(first))
+		Expect(requests).To(Equal(2))
+		Expect(os.ReadFile(second)).To(Equal([]byte(body)))
+	})
+
+	It("should download the config file again if it has changed", func() {
+		_, err := FetchRemote(context.TODO(), server.URL+"/policy.yaml", opts)
+		Expect(err).ToNot(HaveOccurred())
+		body = "pyxis_env: prod\n"
+		path, err := FetchRemote(context.TODO(), server.URL+"/policy.yaml", opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.ReadFile(path)).To(Equal([]byte("pyxis_env: prod\n")))
+	})
+
+	It("should throw an error if the config file cannot be fetched", func() {
+		_, err := FetchRemote(context.TODO(), server.URL+"/missing.yaml", opts)
+		Expect(err).To(MatchError(ContainSubstring("404 Not Found")))
+	})
+
+	It("should throw an error if the URL is not https", func() {
+		_, err := FetchRemote(context.TODO(), "http://example.com/policy.yaml", opts)
+		Expect(err).To(MatchError(ContainSubstring("must use https")))
+	})
+
+	It("should throw an error if the server is not trusted", func() {
+		opts.CABundle = ""
+		_, err := FetchRemote(context.TODO(), server.URL+"/policy.yaml", opts)
+		Expect(err).To(MatchError(ContainSubstring("could not fetch config file")))
+	})
+
+	It("should throw an error if only a client certificate is configured", func() {
+		opts.ClientCert = "client.crt"
+		_, err := FetchRemote(context.TODO(), server.URL+"/policy.yaml", opts)
+		Expect(err).To(MatchError(ContainSubstring("both a client certificate and key are required")))
+	})
+
+	It("should list the keys that execute commands, including those of profiles", func() {
+		Expect(ExecSettings(map[string]interface{}{
+			"post_run_cmd": "notify.sh",
+			"pyxis_env":    "qa",
+			"profiles": map[string]interface{}{
+				"staging": map[string]interface{}{"image_resolver": "resolve.sh", "artifacts": "staging"},
+				"prod":    map[string]interface{}{"artifacts": "prod"},
+			},
+		})).To(Equal([]string{"post_run_cmd", "profiles.staging.image_resolver"}))
+		Expect(ExecSettings(map[string]interface{}{"pyxis_env": "qa"})).To(BeEmpty())
+	})
+})
@@ -0,0 +1,426 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// EnvPrefix is the prefix of the environment variables that configure preflight.
+const EnvPrefix = "PFLT_"
+
+// Masked replaces the values of secrets in the effective configuration.
+const Masked = "<redacted>"
+
+// KeyType is the type of the value of a configuration key, as named by JSON Schema.
+type KeyType string
+
+const (
+	TypeString  KeyType = "string"
+	TypeBoolean KeyType = "boolean"
+	TypeInteger KeyType = "integer"
+	TypeNumber  KeyType = "number"
+	// TypeStringList is a list of strings, or a string of them separated by spaces, as
+	// they are set in the environment.
+	TypeStringList KeyType = "array"
+)
+
+// Key is a configuration key, which may be set in the config file, in the environment
+// variable named by EnvPrefix and the key in upper case, or by a flag.
+type Key struct {
+	Name string
+	Type KeyType
+	// Secret is true if the value must not be displayed.
+	Secret bool
+	// Exec is true if the value is a command that preflight executes. It is not read
+	// from config files fetched from URLs.
+	Exec bool
+}
+
+// ProfilesKey is the key of the profiles in the config file, each a map of the settings
+// that are used when it is selected by the profile key.
+const ProfilesKey = "profiles"
+
+// Keys are the configuration keys preflight reads, sorted by name.
+var Keys = []Key{
+	{Name: "allow_check_egress", Type: TypeStringList},
+	{Name: "approved_base_images", Type: TypeStringList},
+	{Name: "artifact_archive", Type: TypeString},
+	{Name: "artifacts", Type: TypeString},
+	{Name: "artifacts_quota", Type: TypeString},
+	{Name: "attach_results", Type: TypeBoolean},
+	{Name: "ca_bundle", Type: TypeString},
+	{Name: "certification_project_id", Type: TypeString},
+	{Name: "channel", Type: TypeString},
+	{Name: "check_timeout", Type: TypeString},
+	{Name: "check_timeouts", Type: TypeStringList},
+	{Name: "checklist", Type: TypeBoolean},
+	{Name: "ci", Type: TypeString},
+	{Name: "cluster_check_attempts", Type: TypeInteger},
+	{Name: "cluster_provider", Type: TypeString},
+	{Name: "cluster_provider_token", Type: TypeString, Secret: true},
+	{Name: "cluster_provider_url", Type: TypeString},
+	{Name: "cluster_proxy", Type: TypeString},
+	{Name: "compare_to", Type: TypeString},
+	{Name: "compat", Type: TypeString},
+	{Name: "config", Type: TypeString},
+	{Name: "config_ca_bundle", Type: TypeString},
+	{Name: "config_client_cert", Type: TypeString},
+	{Name: "config_client_key", Type: TypeString},
+	{Name: "decryption_keys", Type: TypeStringList},
+	{Name: "deny_check_egress", Type: TypeBoolean},
+	{Name: "deterministic", Type: TypeBoolean},
+	{Name: "dockerConfig", Type: TypeString},
+	{Name: "docker_config_secret", Type: TypeString},
+	{Name: "events_file", Type: TypeString},
+	{Name: "exceptions_file", Type: TypeString},
+	{Name: "exit_code_error", Type: TypeInteger},
+	{Name: "exit_code_failed", Type: TypeInteger},
+	{Name: "exit_code_warning", Type: TypeInteger},
+	{Name: "expected_digest", Type: TypeString},
+	{Name: "fail_fast", Type: TypeBoolean},
+	{Name: "gitlab_codequality", Type: TypeBoolean},
+	{Name: "https_proxy", Type: TypeString},
+	{Name: "image_resolver", Type: TypeString, Exec: true},
+	{Name: "indeximage", Type: TypeString},
+	{Name: "insecure", Type: TypeBoolean},
+	{Name: "issue_tracker_config", Type: TypeString},
+	{Name: "junit", Type: TypeBoolean},
+	{Name: "junit_path", Type: TypeString},
+	{Name: "known_issues_feed", Type: TypeString},
+	{Name: "lockfile", Type: TypeString},
+	{Name: "log_format", Type: TypeString},
+	{Name: "logfile", Type: TypeString},
+	{Name: "logfile_max_age", Type: TypeString},
+	{Name: "logfile_max_backups", Type: TypeInteger},
+	{Name: "logfile_max_size", Type: TypeString},
+	{Name: "loglevel", Type: TypeString},
+	{Name: "managed_cluster_aws_account_id", Type: TypeString},
+	{Name: "managed_cluster_product", Type: TypeString},
+	{Name: "managed_cluster_region", Type: TypeString},
+	{Name: "managed_cluster_version", Type: TypeString},
+	{Name: "mark_submitted", Type: TypeString},
+	{Name: "metrics_addr", Type: TypeString},
+	{Name: "mirror_config", Type: TypeString},
+	{Name: "namespace", Type: TypeString},
+	{Name: "no_proxy", Type: TypeString},
+	{Name: "ocm_token", Type: TypeString, Secret: true},
+	{Name: "opensearch_api_key", Type: TypeString, Secret: true},
+	{Name: "opensearch_api_key_file", Type: TypeString},
+	{Name: "opensearch_url", Type: TypeString},
+	{Name: "operability_check", Type: TypeString},
+	{Name: "otel_endpoint", Type: TypeString},
+	{Name: "platform", Type: TypeString},
+	{Name: "post_run_cmd", Type: TypeString, Exec: true},
+	{Name: "probe_services", Type: TypeBoolean},
+	{Name: "profile", Type: TypeString},
+	{Name: "progress", Type: TypeBoolean},
+	{Name: "pyxis_api_token", Type: TypeString, Secret: true},
+	{Name: "pyxis_api_token_file", Type: TypeString},
+	{Name: "pyxis_env", Type: TypeString},
+	{Name: "pyxis_host", Type: TypeString},
+	{Name: "pyxis_max_qps", Type: TypeNumber},
+	{Name: "pyxis_oidc_client_id", Type: TypeString},
+	{Name: "pyxis_oidc_token", Type: TypeString, Secret: true},
+	{Name: "pyxis_oidc_token_file", Type: TypeString},
+	{Name: "pyxis_oidc_token_url", Type: TypeString},
+	{Name: "pyxis_token_secret", Type: TypeString},
+	{Name: "quiet", Type: TypeBoolean},
+	{Name: "registry_mirrors", Type: TypeStringList},
+	{Name: "registry_password", Type: TypeString, Secret: true},
+	{Name: "registry_password_file", Type: TypeString},
+	{Name: "registry_token", Type: TypeString, Secret: true},
+	{Name: "registry_token_file", Type: TypeString},
+	{Name: "registry_username", Type: TypeString},
+	{Name: "rerun_failed", Type: TypeString},
+	{Name: "scorecard_image", Type: TypeString},
+	{Name: "scorecard_wait_time", Type: TypeInteger},
+	{Name: "serviceaccount", Type: TypeString},
+	{Name: "submit", Type: TypeBoolean},
+	{Name: "submit_dry_run", Type: TypeBoolean},
+	{Name: "submit_offline", Type: TypeBoolean},
+	{Name: "submit_to_url", Type: TypeString},
+	{Name: "submit_to_url_secret", Type: TypeString, Secret: true},
+	{Name: "submit_to_url_secret_file", Type: TypeString},
+	{Name: "summary", Type: TypeBoolean},
+	{Name: "tmpdir", Type: TypeString},
+	{Name: "trace_on_failure", Type: TypeBoolean},
+	{Name: "update_lockfile", Type: TypeBoolean},
+	{Name: "vault_addr", Type: TypeString},
+	{Name: "vault_approle_mount", Type: TypeString},
+	{Name: "vault_namespace", Type: TypeString},
+	{Name: "vault_path", Type: TypeString},
+	{Name: "vault_role_id", Type: TypeString},
+	{Name: "vault_secret_id", Type: TypeString, Secret: true},
+	{Name: "vault_secret_id_file", Type: TypeString},
+	{Name: "vault_token", Type: TypeString, Secret: true},
+	{Name: "vault_token_file", Type: TypeString},
+	{Name: "watch", Type: TypeBoolean},
+}
+
+// LookupKey returns the key named name, ignoring case as viper does.
+func LookupKey(name string) (Key, bool) {
+	for _, k := range Keys {
+		if strings.EqualFold(k.Name, name) {
+			return k, true
+		}
+	}
+
+	return Key{}, false
+}
+
+// Schema returns the JSON Schema of the config file.
+func Schema() ([]byte, error) {
+	properties := make(map[string]interface{}, len(Keys)+1)
+	profileProperties := make(map[string]interface{}, len(Keys))
+	for _, k := range Keys {
+		property := map[string]interface{}{"type": k.Type}
+		if k.Type == TypeStringList {
+			property["type"] = []KeyType{TypeStringList, TypeString}
+			property["items"] = map[string]interface{}{"type": TypeString}
+		}
+		if k.Secret {
+			property["writeOnly"] = true
+		}
+		properties[k.Name] = property
+		if k.Name != "profile" {
+			profileProperties[k.Name] = property
+		}
+	}
+	properties[ProfilesKey] = map[string]interface{}{
+		"type": "object",
+		"additionalProperties": map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": false,
+			"properties":           profileProperties,
+		},
+	}
+
+	b, err := json.MarshalIndent(map[string]interface{}{
+		"$schema":              "http://json-schema.org/draft-07/schema#",
+		"title":                "Preflight configuration",
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties":           properties,
+	}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("could not encode the configuration schema: %w", err)
+	}
+
+	return append(b, '\n'), nil
+}
+
+// Problem is a configuration key that is unknown, or whose value is not of its type.
+type Problem struct {
+	// Source is where the key was set, e.g. the path of the config file.
+	Source  string
+	Key     string
+	Message string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %s: %s", p.Source, p.Key, p.Message)
+}
+
+// ValidateSettings returns the problems with settings, the contents of the config file
+// at source as read by viper, including those of its profiles, sorted by key.
+func ValidateSettings(source string, settings map[string]interface{}) []Problem {
+	return validateSettings(source, "", settings)
+}
+
+// validateSettings returns the problems with settings, naming keys with prefix. The
+// profiles key is only known if prefix is empty.
+func validateSettings(source, prefix string, settings map[string]interface{}) []Problem {
+	var problems []Problem
+	for _, name := range sortedKeys(settings) {
+		if prefix == "" && strings.EqualFold(name, ProfilesKey) {
+			problems = append(problems, validateProfiles(source, name, settings[name])...)
+			continue
+		}
+
+		key, ok := LookupKey(name)
+		if !ok || (prefix != "" && key.Name == "profile") {
+			problems = append(problems, Problem{Source: source, Key: prefix + name, Message: "unknown key"})
+			continue
+		}
+		if !hasType(settings[name], key.Type) {
+			problems = append(problems, Problem{Source: source, Key: prefix + name, Message: fmt.Sprintf("must be %s, not %s", typeName(key.Type), valueType(settings[name]))})
+		}
+	}
+
+	return problems
+}
+
+// validateProfiles returns the problems with profiles, the value of the key name.
+func validateProfiles(source, name string, profiles interface{}) []Problem {
+	if profiles == nil {
+		return nil
+	}
+	m, ok := profiles.(map[string]interface{})
+	if !ok {
+		return []Problem{{Source: source, Key: name, Message: fmt.Sprintf("must be a map of profiles, not %s", valueType(profiles))}}
+	}
+
+	var problems []Problem
+	for _, profile := range sortedKeys(m) {
+		prefix := name + "." + profile + "."
+		settings, ok := m[profile].(map[string]interface{})
+		if !ok && m[profile] != nil {
+			problems = append(problems, Problem{Source: source, Key: strings.TrimSuffix(prefix, "."), Message: fmt.Sprintf("must be a map of settings, not %s", valueType(m[profile]))})
+			continue
+		}
+		problems = append(problems, validateSettings(source, prefix, settings)...)
+	}
+
+	return problems
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// ValidateEnvironment returns the problems with the variables in environ, in the form
+// "key=value", that start with EnvPrefix, sorted by variable.
+func ValidateEnvironment(environ []string) []Problem {
+	var problems []Problem
+	for _, kv := range environ {
+		variable, value, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(variable, EnvPrefix) {
+			continue
+		}
+
+		// Viper only reads variables named by the key in upper case, and ignores
+		// those that are empty.
+		key, ok := LookupKey(strings.TrimPrefix(variable, EnvPrefix))
+		if !ok || variable != EnvPrefix+strings.ToUpper(key.Name) {
+			problems = append(problems, Problem{Source: "environment", Key: variable, Message: "unknown variable"})
+			continue
+		}
+		if value == "" {
+			continue
+		}
+
+		var err error
+		switch key.Type {
+		case TypeBoolean:
+			_, err = strconv.ParseBool(value)
+		case TypeInteger:
+			_, err = strconv.Atoi(value)
+		case TypeNumber:
+			_, err = strconv.ParseFloat(value, 64)
+		}
+		if err != nil {
+			problems = append(problems, Problem{Source: "environment", Key: variable, Message: fmt.Sprintf("must be %s, not %q", typeName(key.Type), value)})
+		}
+	}
+
+	sort.Slice(problems, func(i, j int) bool { return problems[i].Key < problems[j].Key })
+
+	return problems
+}
+
+// Effective returns the value of each key set in vcfg, from a flag, the environment, the
+// config file, or a default, with the values of secrets replaced by Masked.
+func Effective(vcfg *viper.Viper) map[string]interface{} {
+	effective := map[string]interface{}{}
+	for _, k := range Keys {
+		if !vcfg.IsSet(k.Name) {
+			continue
+		}
+
+		var value interface{}
+		switch k.Type {
+		case TypeBoolean:
+			value = vcfg.GetBool(k.Name)
+		case TypeInteger:
+			value = vcfg.GetInt(k.Name)
+		case TypeNumber:
+			value = vcfg.GetFloat64(k.Name)
+		case TypeStringList:
+			value = vcfg.GetStringSlice(k.Name)
+		default:
+			s := vcfg.GetString(k.Name)
+			if k.Secret && s != "" {
+				s = Masked
+			}
+			value = s
+		}
+		effective[k.Name] = value
+	}
+
+	return effective
+}
+
+// hasType returns true if value, as read by viper, is of type t.
+func hasType(value interface{}, t KeyType) bool {
+	switch v := value.(type) {
+	case nil:
+		// An empty key is unset.
+		return true
+	case string:
+		return t == TypeString || t == TypeStringList
+	case bool:
+		return t == TypeBoolean
+	case int, int64, uint64:
+		return t == TypeInteger || t == TypeNumber
+	case float64:
+		return t == TypeNumber || (t == TypeInteger && v == float64(int64(v)))
+	case []interface{}:
+		if t != TypeStringList {
+			return false
+		}
+		for _, item := range v {
+			if _, ok := item.(string); !ok {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+func typeName(t KeyType) string {
+	switch t {
+	case TypeBoolean:
+		return "a boolean"
+	case TypeInteger:
+		return "an integer"
+	case TypeNumber:
+		return "a number"
+	case TypeStringList:
+		return "a list of strings"
+	default:
+		return "a string"
+	}
+}
+
+func valueType(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	case int, int64, uint64:
+		return "an integer"
+	case float64:
+		if v == float64(int64(v)) {
+			return "an integer"
+		}
+		return "a number"
+	case []interface{}:
+		return "a list"
+	default:
+		return "an object"
+	}
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+)
+
+var _ = Describe("Configuration schema", func() {
+	It("should list the keys sorted by name", func() {
+		Expect(sort.SliceIsSorted(Keys, func(i, j int) bool { return Keys[i].Name < Keys[j].Name })).To(BeTrue())
+	})
+
+	It("should match the published schema", func() {
+		published, err := os.ReadFile(filepath.Join("..", "..", "docs", "config.schema.json"))
+		Expect(err).ToNot(HaveOccurred())
+		schema, err := Schema()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(schema)).To(Equal(string(published)), "regenerate docs/config.schema.json from config.Schema()")
+	})
+
+	Context("when validating the config file", func() {
+		It("should accept values of the type of their keys", func() {
+			Expect(ValidateSettings("config.yaml", map[string]interface{}{
+				"dockerconfig":           "path/to/config.json",
+				"submit":                 true,
+				"cluster_check_attempts": 3,
+				"pyxis_max_qps":          2.5,
+				"registry_mirrors":       []interface{}{"mirror.example.com"},
+				"approved_base_images":   "registry.access.redhat.com/ubi9/ubi",
+				"channel":                nil,
+			})).To(BeEmpty())
+		})
+
+		It("should report unknown keys and values of the wrong type", func() {
+			Expect(ValidateSettings("config.yaml", map[string]interface{}{
+				"sumbit":                 true,
+				"submit":                 "yes",
+				"cluster_check_attempts": 1.5,
+				"registry_mirrors":       []interface{}{1},
+				"pyxis":                  map[string]interface{}{"host": "pyxis.example.com"},
+			})).To(Equal([]Problem{
+				{Source: "config.yaml", Key: "cluster_check_attempts", Message: "must be an integer, not a number"},
+				{Source: "config.yaml", Key: "pyxis", Message: "unknown key"},
+				{Source: "config.yaml", Key: "registry_mirrors", Message: "must be a list of strings, not a list"},
+				{Source: "config.yaml", Key: "submit", Message: "must be a boolean, not a string"},
+				{Source: "config.yaml", Key: "sumbit", Message: "unknown key"},
+			}))
+		})
+	})
+
+	Context("when validating the profiles of the config file", func() {
+		It("should report unknown keys and values of the wrong type in each profile", func() {
+			Expect(ValidateSettings("config.yaml", map[string]interface{}{
+				"profile": "staging",
+				"profiles": map[string]interface{}{
+					"staging": map[string]interface{}{
+						"pyxis_env": "qa",
+						"profile":   "prod",
+						"junit":     "sometimes",
+					},
+					"prod": "pyxis_env=prod",
+				},
+			})).To(Equal([]Problem{
+				{Source: "config.yaml", Key: "profiles.prod", Message: "must be a map of settings, not a string"},
+				{Source: "config.yaml", Key: "profiles.staging.junit", Message: "must be a boolean, not a string"},
+				{Source: "config.yaml", Key: "profiles.staging.profile", Message: "unknown key"},
+			}))
+		})
+
+		It("should report profiles that are not a map", func() {
+			Expect(ValidateSettings("config.yaml", map[string]interface{}{
+				"profiles": []interface{}{"staging"},
+			})).To(Equal([]Problem{
+				{Source: "config.yaml", Key: "profiles", Message: "must be a map of profiles, not a list"},
+			}))
+		})
+	})
+
+	Context("when validating the environment", func() {
+		It("should report unknown variables and values that cannot be parsed", func() {
+			Expect(ValidateEnvironment([]string{
+				"HOME=/root",
+				"PFLT_SUBMIT=true",
+				"PFLT_JUNIT=",
+				"PFLT_DOCKERCONFIG=path/to/config.json",
+				"PFLT_PYXIS_MAX_QPS=fast",
+				"PFLT_SUBMT=true",
+				"PFLT_pyxis_host=pyxis.example.com",
+			})).To(Equal([]Problem{
+				{Source: "environment", Key: "PFLT_PYXIS_MAX_QPS", Message: `must be a number, not "fast"`},
+				{Source: "environment", Key: "PFLT_SUBMT", Message: "unknown variable"},
+				{Source: "environment", Key: "PFLT_pyxis_host", Message: "unknown variable"},
+			}))
+		})
+	})
+
+	Context("when printing the effective configuration", func() {
+		It("should include the keys that are set, with secrets masked", func() {
+			v := viper.New()
+			v.Set("submit", "true")
+			v.Set("pyxis_api_token", "secret")
+			v.Set("pyxis_api_token_file", "")
+			v.Set("registry_mirrors", "a.example.com b.example.com")
+			v.SetDefault("scorecard_wait_time", "240")
+
+			Expect(Effective(v)).To(Equal(map[string]interface{}{
+				"submit":               true,
+				"pyxis_api_token":      Masked,
+				"pyxis_api_token_file": "",
+				"registry_mirrors":     []string{"a.example.com", "b.example.com"},
+				"scorecard_wait_time":  240,
+			}))
+		})
+	})
+})
@@ -0,0 +1,246 @@
+// Package containerized runs preflight in the official preflight container image, with
+// the mounts and environment it needs, so that it behaves consistently on hosts that
+// preflight does not support natively.
+package containerized
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/version"
+)
+
+// The container engines that preflight can be run with.
+const (
+	EnginePodman = "podman"
+	EngineDocker = "docker"
+)
+
+// ImageRepository is the repository of the official preflight container image.
+const ImageRepository = "quay.io/opdev/preflight"
+
+// mountRoot is where files and directories from the host are mounted in the container.
+const mountRoot = "/preflight"
+
+// DefaultImage returns the official preflight container image of this version of
+// preflight, or the stable image if this is not a released version.
+func DefaultImage() string {
+	v := version.Version.Version
+	if v == "" || v == "unknown" {
+		return ImageRepository + ":stable"
+	}
+
+	return ImageRepository + ":" + v
+}
+
+// ParseEngine returns the container engine named s.
+func ParseEngine(s string) (string, error) {
+	switch s {
+	case EnginePodman, EngineDocker:
+		return s, nil
+	}
+
+	return "", fmt.Errorf("unsupported container engine %s, must be one of %s or %s", s, EnginePodman, EngineDocker)
+}
+
+// Mount is a file or directory on the host mounted in the container.
+type Mount struct {
+	Source   string
+	Target   string
+	ReadOnly bool
+}
+
+// Invocation describes how preflight is run in a container.
+type Invocation struct {
+	Engine string
+	Image  string
+	// Args are the arguments of preflight in the container.
+	Args []string
+	// Env is the environment of preflight in the container. It is passed to the engine in
+	// its own environment, rather than its arguments, so that secrets are not visible in
+	// the process list.
+	Env    map[string]string
+	Mounts []Mount
+
+	// outputDirs are where the directories on the host already mounted for output are
+	// mounted, by their path on the host.
+	outputDirs map[string]string
+}
+
+// NewInvocation returns an Invocation of preflight with args, in image, with engine.
+func NewInvocation(engine, image string, args ...string) *Invocation {
+	return &Invocation{
+		Engine:     engine,
+		Image:      image,
+		Args:       args,
+		Env:        map[string]string{},
+		outputDirs: map[string]string{},
+	}
+}
+
+// SetEnv sets the environment variable key to value in the container.
+func (i *Invocation) SetEnv(key, value string) {
+	i.Env[key] = value
+}
+
+// MountFile mounts the file at hostPath read-only in the container, and sets the
+// environment variable key to where it is mounted.
+func (i *Invocation) MountFile(key, hostPath string) error {
+	target, err := i.mountFile(hostPath, filepath.Base)
+	if err != nil {
+		return err
+	}
+	i.SetEnv(key, target)
+
+	return nil
+}
+
+// MountFiles mounts each of the files at hostPaths read-only in the container, and sets
+// the environment variable key to where they are mounted, separated by spaces, which is
+// how preflight reads a list from its environment. The files are mounted at paths without
+// whitespace, so that the list is read back as it was, whatever the paths on the host.
+func (i *Invocation) MountFiles(key string, hostPaths []string) error {
+	name := func(abs string) string {
+		return strings.Join(strings.Fields(filepath.Base(abs)), "_")
+	}
+
+	targets := make([]string, 0, len(hostPaths))
+	for _, hostPath := range hostPaths {
+		target, err := i.mountFile(hostPath, name)
+		if err != nil {
+			return err
+		}
+		targets = append(targets, target)
+	}
+	i.SetEnv(key, strings.Join(targets, " "))
+
+	return nil
+}
+
+// mountFile mounts the file at hostPath read-only in the container, with the name that
+// name returns for its absolute path, and returns where it is mounted.
+func (i *Invocation) mountFile(hostPath string, name func(abs string) string) (string, error) {
+	abs, err := filepath.Abs(hostPath)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(abs); err != nil {
+		return "", fmt.Errorf("could not mount %s in the container: %w", hostPath, err)
+	}
+
+	target := path.Join(mountRoot, "in", fmt.Sprint(len(i.Mounts)), name(abs))
+	i.Mounts = append(i.Mounts, Mount{Source: abs, Target: target, ReadOnly: true})
+
+	return target, nil
+}
+
+// MountOutputDir mounts the directory at hostPath in the container, creating it if it does
+// not exist, and sets the environment variable key to where it is mounted.
+func (i *Invocation) MountOutputDir(key, hostPath string) error {
+	target, err := i.mountOutputDir(hostPath)
+	if err != nil {
+		return err
+	}
+	i.SetEnv(key, target)
+
+	return nil
+}
+
+// MountOutputFile mounts the directory of the file at hostPath in the container, creating it
+// if it does not exist, and sets the environment variable key to where the file will be.
+func (i *Invocation) MountOutputFile(key, hostPath string) error {
+	target, err := i.mountOutputDir(filepath.Dir(hostPath))
+	if err != nil {
+		return err
+	}
+	i.SetEnv(key, path.Join(target, filepath.Base(hostPath)))
+
+	return nil
+}
+
+// mountOutputDir mounts the directory at hostPath in the container, unless it is already,
+// and returns where it is mounted.
+func (i *Invocation) mountOutputDir(hostPath string) (string, error) {
+	abs, err := filepath.Abs(hostPath)
+	if err != nil {
+		return "", err
+	}
+	if target, ok := i.outputDirs[abs]; ok {
+		return target, nil
+	}
+	if err := os.MkdirAll(abs, 0o755); err != nil {
+		return "", fmt.Errorf("could not create %s to mount in the container: %w", hostPath, err)
+	}
+
+	target := path.Join(mountRoot, "out", fmt.Sprint(len(i.Mounts)))
+	i.Mounts = append(i.Mounts, Mount{Source: abs, Target: target})
+	i.outputDirs[abs] = target
+
+	return target, nil
+}
+
+// EngineArgs returns the arguments of the engine that run preflight in the container.
+func (i *Invocation) EngineArgs() []string {
+	args := []string{"run", "--rm"}
+	if i.Engine == EngineDocker {
+		// Unlike rootless podman, docker would otherwise write output owned by root.
+		args = append(args, "--user", fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid()))
+	}
+
+	keys := make([]string, 0, len(i.Env))
+	for key := range i.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		// Only the name is passed, so that the value is read from the engine's environment.
+		args = append(args, "--env", key)
+	}
+
+	for _, m := range i.Mounts {
+		// On hosts that enforce SELinux, the mounts are relabeled so that the container
+		// can access them, without disabling its separation. They are labeled as shared,
+		// rather than private with Z, as the directories on the host, e.g. the working
+		// directory of the logfile, may be used by other containers.
+		options := "z"
+		if m.ReadOnly {
+			options = "ro," + options
+		}
+		args = append(args, "--volume", m.Source+":"+m.Target+":"+options)
+	}
+
+	args = append(args, i.Image)
+
+	return append(args, i.Args...)
+}
+
+// Run runs preflight in the container, connecting its standard output and error to stdout
+// and stderr, and returns an error if it does not succeed.
+func (i *Invocation) Run(ctx context.Context, stdout, stderr io.Writer) error {
+	engine, err := exec.LookPath(i.Engine)
+	if err != nil {
+		return fmt.Errorf("could not find %s: %w", i.Engine, err)
+	}
+
+	cmd := exec.CommandContext(ctx, engine, i.EngineArgs()...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	// Values in Env take precedence over those of the same name in the host's environment.
+	cmd.Env = os.Environ()
+	for key, value := range i.Env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("preflight did not succeed in %s: %w", i.Image, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,13 @@
+package containerized
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestContainerized(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Containerized Suite")
+}
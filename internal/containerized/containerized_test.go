@@ -0,0 +1,84 @@
+package containerized
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Running preflight in a container", func() {
+	var (
+		tempdir string
+		inv     *Invocation
+	)
+
+	BeforeEach(func() {
+		tempdir = GinkgoT().TempDir()
+		inv = NewInvocation(EnginePodman, "quay.io/opdev/preflight:stable", "check", "container", "quay.io/example/image:latest")
+	})
+
+	It("should only support podman and docker", func() {
+		Expect(ParseEngine("podman")).To(Equal(EnginePodman))
+		Expect(ParseEngine("docker")).To(Equal(EngineDocker))
+		_, err := ParseEngine("containerd")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should default to the stable image for unreleased versions", func() {
+		Expect(DefaultImage()).To(Equal(ImageRepository + ":stable"))
+	})
+
+	It("should mount files read-only", func() {
+		path := filepath.Join(tempdir, "config.json")
+		Expect(os.WriteFile(path, []byte("{}"), 0o600)).To(Succeed())
+
+		Expect(inv.MountFile("PFLT_DOCKERCONFIG", path)).To(Succeed())
+		Expect(inv.Mounts).To(Equal([]Mount{{Source: path, Target: "/preflight/in/0/config.json", ReadOnly: true}}))
+		Expect(inv.Env).To(HaveKeyWithValue("PFLT_DOCKERCONFIG", "/preflight/in/0/config.json"))
+	})
+
+	It("should fail to mount files that do not exist", func() {
+		Expect(inv.MountFile("PFLT_DOCKERCONFIG", filepath.Join(tempdir, "missing.json"))).ToNot(Succeed())
+	})
+
+	It("should create and mount output directories once", func() {
+		artifacts := filepath.Join(tempdir, "artifacts")
+		Expect(inv.MountOutputDir("PFLT_ARTIFACTS", artifacts)).To(Succeed())
+		Expect(inv.MountOutputFile("PFLT_LOGFILE", filepath.Join(artifacts, "preflight.log"))).To(Succeed())
+
+		Expect(artifacts).To(BeADirectory())
+		Expect(inv.Mounts).To(Equal([]Mount{{Source: artifacts, Target: "/preflight/out/0"}}))
+		Expect(inv.Env).To(HaveKeyWithValue("PFLT_ARTIFACTS", "/preflight/out/0"))
+		Expect(inv.Env).To(HaveKeyWithValue("PFLT_LOGFILE", "/preflight/out/0/preflight.log"))
+	})
+
+	It("should pass the environment by name only", func() {
+		inv.SetEnv("PFLT_PYXIS_API_TOKEN", "secret")
+		Expect(inv.MountOutputDir("PFLT_ARTIFACTS", tempdir)).To(Succeed())
+
+		Expect(inv.EngineArgs()).To(Equal([]string{
+			"run", "--rm",
+			"--env", "PFLT_ARTIFACTS",
+			"--env", "PFLT_PYXIS_API_TOKEN",
+			"--volume", tempdir + ":/preflight/out/0:z",
+			"quay.io/opdev/preflight:stable", "check", "container", "quay.io/example/image:latest",
+		}))
+	})
+
+	It("should relabel the mounts instead of disabling SELinux separation", func() {
+		dockerconfig := filepath.Join(tempdir, "config.json")
+		Expect(os.WriteFile(dockerconfig, []byte("{}"), 0o600)).To(Succeed())
+		Expect(inv.MountFile("PFLT_DOCKERCONFIG", dockerconfig)).To(Succeed())
+		Expect(inv.MountOutputDir("PFLT_ARTIFACTS", filepath.Join(tempdir, "artifacts"))).To(Succeed())
+
+		args := inv.EngineArgs()
+		Expect(args).ToNot(ContainElement("--security-opt"))
+		Expect(args).ToNot(ContainElement(ContainSubstring("label=disable")))
+		Expect(args).To(ContainElements(
+			dockerconfig+":/preflight/in/0/config.json:ro,z",
+			filepath.Join(tempdir, "artifacts")+":/preflight/out/1:z",
+		))
+	})
+})
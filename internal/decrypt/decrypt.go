@@ -0,0 +1,286 @@
+// Package decrypt decrypts the layers of images encrypted with ocicrypt, e.g. by
+// skopeo copy --encryption-key, so that their contents can be checked.
+//
+// Layers are decrypted with the private key of a JWE recipient, either an RSA or an EC
+// key. Layers encrypted only for PGP, PKCS #7, or PKCS #11 recipients cannot be
+// decrypted.
+package decrypt
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/containers/ocicrypt"
+	"github.com/containers/ocicrypt/blockcipher"
+	"github.com/containers/ocicrypt/config"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/partial"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	digest "github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// encryptedSuffix is the suffix of the media types of encrypted layers, e.g.
+// application/vnd.oci.image.layer.v1.tar+gzip+encrypted.
+const encryptedSuffix = "+encrypted"
+
+// ErrNotDecryptable is the error of a layer that none of the keys can decrypt.
+var ErrNotDecryptable = errors.New("layer cannot be decrypted with the decryption keys")
+
+// errNotAuthentic is the error of a layer whose HMAC differs from the one it was
+// encrypted with, e.g. because it was tampered with.
+var errNotAuthentic = errors.New("the decrypted layer is not authentic: its HMAC differs from the expected HMAC")
+
+// IsEncrypted returns true if mt is the media type of an encrypted layer.
+func IsEncrypted(mt types.MediaType) bool {
+	return strings.HasSuffix(string(mt), encryptedSuffix)
+}
+
+// Image returns img with its encrypted layers replaced by layers that decrypt them with
+// keys as they are read. It returns an error wrapping ErrNotDecryptable if none of keys
+// decrypts a layer. The HMAC of each layer is verified, by reading the whole layer,
+// before any of it is decrypted, so that readers that stop before its end, e.g. at the
+// end of a tar archive, never read plaintext that is not authentic. Layers are read
+// twice, so img should cache them.
+func Image(img v1.Image, keys []crypto.PrivateKey) (v1.Image, error) {
+	manifest, err := img.Manifest()
+	if err != nil {
+		return nil, fmt.Errorf("could not get image manifest: %w", err)
+	}
+
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, fmt.Errorf("could not get image layers: %w", err)
+	}
+	if len(layers) != len(manifest.Layers) {
+		return nil, fmt.Errorf("the image has %d layers, but its manifest %d", len(layers), len(manifest.Layers))
+	}
+
+	dc, err := decryptConfig(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	decrypted := make([]v1.Layer, 0, len(layers))
+	encrypted := false
+	for i, desc := range manifest.Layers {
+		if !IsEncrypted(desc.MediaType) {
+			decrypted = append(decrypted, layers[i])
+			continue
+		}
+		encrypted = true
+
+		layer, err := decryptLayer(layers[i], desc, dc)
+		if err != nil {
+			return nil, fmt.Errorf("layer %s: %w", desc.Digest, err)
+		}
+		decrypted = append(decrypted, layer)
+	}
+
+	if !encrypted {
+		return img, nil
+	}
+
+	return &decryptedImage{Image: img, layers: decrypted}, nil
+}
+
+// decryptConfig returns the ocicrypt configuration that decrypts layers with keys, as
+// a JWE recipient.
+func decryptConfig(keys []crypto.PrivateKey) (*config.DecryptConfig, error) {
+	privKeys := make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid decryption key: %w", err)
+		}
+		privKeys = append(privKeys, der)
+	}
+
+	cc, err := config.DecryptWithPrivKeys(privKeys, make([][]byte, len(privKeys)))
+	if err != nil {
+		return nil, err
+	}
+
+	return cc.DecryptConfig, nil
+}
+
+// decryptLayer returns layer, described by desc, decrypted with the first of the keys
+// of dc that unwraps its symmetric key.
+func decryptLayer(layer v1.Layer, desc v1.Descriptor, dc *config.DecryptConfig) (v1.Layer, error) {
+	ociDesc := ocispec.Descriptor{
+		MediaType:   string(desc.MediaType),
+		Digest:      digest.Digest(desc.Digest.String()),
+		Size:        desc.Size,
+		Annotations: desc.Annotations,
+	}
+
+	// Nothing is read from the layer until it is decrypted, but its symmetric key is
+	// unwrapped, and the digest of the decrypted layer read, from its annotations.
+	opts, err := unwrapOptions(dc, ociDesc)
+	if err != nil {
+		return nil, err
+	}
+
+	decryptedDigest, err := v1.NewHash(opts.Digest.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid digest of the decrypted layer: %w", err)
+	}
+
+	return partial.CompressedToLayer(&decryptedLayer{
+		encrypted: layer,
+		desc:      ociDesc,
+		dc:        dc,
+		mediaType: types.MediaType(strings.TrimSuffix(string(desc.MediaType), encryptedSuffix)),
+		digest:    decryptedDigest,
+	})
+}
+
+// unwrapOptions returns the private options of the layer described by desc, unwrapped
+// by the first of the key wrappers of ocicrypt that one of the keys of dc is a recipient
+// of, or an error wrapping ErrNotDecryptable if none is.
+func unwrapOptions(dc *config.DecryptConfig, desc ocispec.Descriptor) (blockcipher.PrivateLayerBlockCipherOptions, error) {
+	var opts blockcipher.PrivateLayerBlockCipherOptions
+	var errs []string
+	for scheme, b64Annotations := range ocicrypt.GetWrappedKeysMap(desc) {
+		keywrapper := ocicrypt.GetKeyWrapper(scheme)
+		if keywrapper.NoPossibleKeys(dc.Parameters) {
+			continue
+		}
+
+		for _, b64Annotation := range strings.Split(b64Annotations, ",") {
+			annotation, err := base64.StdEncoding.DecodeString(b64Annotation)
+			if err != nil {
+				return opts, fmt.Errorf("could not decode the %s keys of the layer: %w", scheme, err)
+			}
+
+			data, err := keywrapper.UnwrapKey(dc, annotation)
+			if err != nil {
+				errs = append(errs, err.Error())
+				continue
+			}
+
+			if err := json.Unmarshal(data, &opts); err != nil {
+				return opts, fmt.Errorf("could not parse the private options of the layer: %w", err)
+			}
+			return opts, nil
+		}
+	}
+
+	if len(errs) == 0 {
+		return opts, fmt.Errorf("%w: the layer is not encrypted for any JWE recipient", ErrNotDecryptable)
+	}
+
+	return opts, fmt.Errorf("%w: %s", ErrNotDecryptable, strings.Join(errs, "; "))
+}
+
+type decryptedImage struct {
+	v1.Image
+	layers []v1.Layer
+}
+
+func (i *decryptedImage) Layers() ([]v1.Layer, error) {
+	return i.layers, nil
+}
+
+// decryptedLayer is an encrypted layer, decrypted as it is read.
+type decryptedLayer struct {
+	encrypted v1.Layer
+	desc      ocispec.Descriptor
+	dc        *config.DecryptConfig
+	mediaType types.MediaType
+	digest    v1.Hash
+}
+
+func (l *decryptedLayer) Digest() (v1.Hash, error) {
+	return l.digest, nil
+}
+
+// Size returns the size of the decrypted layer, which is that of the encrypted layer,
+// since it is encrypted with a stream cipher.
+func (l *decryptedLayer) Size() (int64, error) {
+	return l.encrypted.Size()
+}
+
+func (l *decryptedLayer) MediaType() (types.MediaType, error) {
+	return l.mediaType, nil
+}
+
+func (l *decryptedLayer) Compressed() (io.ReadCloser, error) {
+	if err := l.verify(); err != nil {
+		return nil, err
+	}
+
+	return l.decrypt()
+}
+
+// verify returns errNotAuthentic if the HMAC of the layer differs from the one it was
+// encrypted with, discarding what it decrypts.
+func (l *decryptedLayer) verify() error {
+	rc, err := l.decrypt()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	_, err = io.Copy(io.Discard, rc)
+	return err
+}
+
+// decrypt returns the layer decrypted as it is read, whose HMAC is verified when it is
+// read to the end.
+func (l *decryptedLayer) decrypt() (io.ReadCloser, error) {
+	rc, err := l.encrypted.Compressed()
+	if err != nil {
+		return nil, err
+	}
+
+	source := &sourceReader{r: rc}
+	r, _, err := ocicrypt.DecryptLayer(l.dc, source, l.desc, false)
+	if err != nil {
+		rc.Close()
+		return nil, err
+	}
+
+	return &decryptingReader{r: r, source: source, rc: rc}, nil
+}
+
+// sourceReader records the error of the encrypted layer it reads, so that it can be
+// told apart from an error decrypting it.
+type sourceReader struct {
+	r   io.Reader
+	err error
+}
+
+func (r *sourceReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.err = err
+	return n, err
+}
+
+// decryptingReader decrypts the layer it reads, and verifies its HMAC when it is read
+// to the end.
+type decryptingReader struct {
+	r      io.Reader
+	source *sourceReader
+	rc     io.ReadCloser
+}
+
+func (r *decryptingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	// Any other error is that of ocicrypt, which only fails as it verifies the HMAC.
+	if err != nil && !errors.Is(err, io.EOF) && err != r.source.err {
+		return n, errNotAuthentic
+	}
+
+	return n, err
+}
+
+func (r *decryptingReader) Close() error {
+	return r.rc.Close()
+}
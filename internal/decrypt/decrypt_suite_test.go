@@ -0,0 +1,13 @@
+package decrypt
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestDecrypt(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Decrypt Suite")
+}
@@ -0,0 +1,236 @@
+package decrypt
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/containers/ocicrypt"
+	"github.com/containers/ocicrypt/config"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	godigest "github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Decrypting images", func() {
+	var (
+		rsaKey    *rsa.PrivateKey
+		ecKey     *ecdsa.PrivateKey
+		plaintext []byte
+	)
+
+	BeforeEach(func() {
+		var err error
+		rsaKey, err = rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).ToNot(HaveOccurred())
+		ecKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		contents := []byte("This is a license")
+		Expect(tw.WriteHeader(&tar.Header{Name: "licenses/LICENSE", Mode: 0o644, Size: int64(len(contents))})).To(Succeed())
+		_, err = tw.Write(contents)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tw.Close()).To(Succeed())
+		plaintext = buf.Bytes()
+	})
+
+	// readLayer returns the uncompressed contents of the only layer of img.
+	readLayer := func(img v1.Image) ([]byte, error) {
+		layers, err := img.Layers()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(layers).To(HaveLen(1))
+
+		rc, err := layers[0].Uncompressed()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+
+	It("should decrypt layers encrypted for an RSA recipient", func() {
+		img := encryptedImage(plaintext, &rsaKey.PublicKey)
+
+		decrypted, err := Image(img, []crypto.PrivateKey{ecKey, rsaKey})
+		Expect(err).ToNot(HaveOccurred())
+
+		contents, err := readLayer(decrypted)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(contents).To(Equal(plaintext))
+
+		layers, err := decrypted.Layers()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(layers[0].MediaType()).To(Equal(types.OCIUncompressedLayer))
+	})
+
+	It("should decrypt layers encrypted for an EC recipient", func() {
+		img := encryptedImage(plaintext, &rsaKey.PublicKey, &ecKey.PublicKey)
+
+		decrypted, err := Image(img, []crypto.PrivateKey{ecKey})
+		Expect(err).ToNot(HaveOccurred())
+
+		contents, err := readLayer(decrypted)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(contents).To(Equal(plaintext))
+	})
+
+	It("should leave images without encrypted layers unchanged", func() {
+		img, err := mutate.AppendLayers(empty.Image, static.NewLayer(plaintext, types.OCIUncompressedLayer))
+		Expect(err).ToNot(HaveOccurred())
+
+		decrypted, err := Image(img, []crypto.PrivateKey{rsaKey})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(decrypted).To(BeIdenticalTo(img))
+	})
+
+	It("should fail if none of the keys is a recipient", func() {
+		other, err := rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).ToNot(HaveOccurred())
+		img := encryptedImage(plaintext, &other.PublicKey)
+
+		_, err = Image(img, []crypto.PrivateKey{rsaKey, ecKey})
+		Expect(err).To(MatchError(ErrNotDecryptable))
+	})
+
+	It("should fail to read a layer that was tampered with", func() {
+		img := encryptedImage(plaintext, &rsaKey.PublicKey)
+		img = tamper(img)
+
+		decrypted, err := Image(img, []crypto.PrivateKey{rsaKey})
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = readLayer(decrypted)
+		Expect(err).To(MatchError(ContainSubstring("not authentic")))
+	})
+
+	It("should fail before releasing any plaintext of a layer that was tampered with", func() {
+		large := bytes.Repeat([]byte("a"), 1<<20)
+		img := tamper(encryptedImage(large, &rsaKey.PublicKey))
+
+		decrypted, err := Image(img, []crypto.PrivateKey{rsaKey})
+		Expect(err).ToNot(HaveOccurred())
+		layers, err := decrypted.Layers()
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = layers[0].Compressed()
+		Expect(err).To(MatchError(errNotAuthentic))
+	})
+})
+
+var _ = Describe("Loading keys", func() {
+	var dir string
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+	})
+
+	It("should load PEM and DER encoded keys", func() {
+		rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).ToNot(HaveOccurred())
+		ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+
+		pkcs8, err := x509.MarshalPKCS8PrivateKey(rsaKey)
+		Expect(err).ToNot(HaveOccurred())
+		sec1, err := x509.MarshalECPrivateKey(ecKey)
+		Expect(err).ToNot(HaveOccurred())
+
+		pemPath := filepath.Join(dir, "rsa.pem")
+		Expect(os.WriteFile(pemPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}), 0o600)).To(Succeed())
+		derPath := filepath.Join(dir, "ec.der")
+		Expect(os.WriteFile(derPath, sec1, 0o600)).To(Succeed())
+
+		keys, err := LoadKeys(pemPath, derPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(keys).To(HaveLen(2))
+		Expect(keys[0]).To(BeAssignableToTypeOf(&rsa.PrivateKey{}))
+		Expect(keys[1]).To(BeAssignableToTypeOf(&ecdsa.PrivateKey{}))
+	})
+
+	It("should fail for password-protected keys", func() {
+		path := filepath.Join(dir, "encrypted.pem")
+		Expect(os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: []byte("key")}), 0o600)).To(Succeed())
+
+		_, err := LoadKeys(path)
+		Expect(err).To(MatchError(errPasswordProtected))
+	})
+
+	It("should fail for files that are not keys", func() {
+		path := filepath.Join(dir, "key.pem")
+		Expect(os.WriteFile(path, []byte("not a key"), 0o600)).To(Succeed())
+
+		_, err := LoadKeys(path)
+		Expect(err).To(MatchError(ContainSubstring("invalid decryption key")))
+	})
+})
+
+// encryptedImage returns an image with a single layer, plaintext encrypted by ocicrypt
+// for the JWE recipients pubKeys.
+func encryptedImage(plaintext []byte, pubKeys ...crypto.PublicKey) v1.Image {
+	recipients := make([][]byte, 0, len(pubKeys))
+	for _, pub := range pubKeys {
+		der, err := x509.MarshalPKIXPublicKey(pub)
+		Expect(err).ToNot(HaveOccurred())
+		recipients = append(recipients, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	}
+	cc, err := config.EncryptWithJwe(recipients)
+	Expect(err).ToNot(HaveOccurred())
+
+	digest, _, err := v1.SHA256(bytes.NewReader(plaintext))
+	Expect(err).ToNot(HaveOccurred())
+	desc := ocispec.Descriptor{
+		MediaType: string(types.OCIUncompressedLayer),
+		Digest:    godigest.Digest(digest.String()),
+		Size:      int64(len(plaintext)),
+	}
+	r, finalize, err := ocicrypt.EncryptLayer(cc.EncryptConfig, bytes.NewReader(plaintext), desc)
+	Expect(err).ToNot(HaveOccurred())
+	ciphertext, err := io.ReadAll(r)
+	Expect(err).ToNot(HaveOccurred())
+	annotations, err := finalize()
+	Expect(err).ToNot(HaveOccurred())
+
+	img, err := mutate.Append(empty.Image, mutate.Addendum{
+		Layer:       static.NewLayer(ciphertext, types.MediaType(string(types.OCIUncompressedLayer)+encryptedSuffix)),
+		Annotations: annotations,
+	})
+	Expect(err).ToNot(HaveOccurred())
+	return img
+}
+
+// tamper returns img with the first byte of its only layer changed.
+func tamper(img v1.Image) v1.Image {
+	manifest, err := img.Manifest()
+	Expect(err).ToNot(HaveOccurred())
+	layers, err := img.Layers()
+	Expect(err).ToNot(HaveOccurred())
+	rc, err := layers[0].Compressed()
+	Expect(err).ToNot(HaveOccurred())
+	ciphertext, err := io.ReadAll(rc)
+	Expect(err).ToNot(HaveOccurred())
+	ciphertext[0] ^= 0xff
+
+	tampered, err := mutate.Append(empty.Image, mutate.Addendum{
+		Layer:       static.NewLayer(ciphertext, manifest.Layers[0].MediaType),
+		Annotations: manifest.Layers[0].Annotations,
+	})
+	Expect(err).ToNot(HaveOccurred())
+	return tampered
+}
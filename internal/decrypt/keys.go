@@ -0,0 +1,63 @@
+package decrypt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// errPasswordProtected is the error of a key that is encrypted with a password.
+var errPasswordProtected = errors.New("password-protected keys are not supported")
+
+// LoadKeys returns the private keys in the PEM or DER encoded files at paths.
+func LoadKeys(paths ...string) ([]crypto.PrivateKey, error) {
+	keys := make([]crypto.PrivateKey, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not read decryption key: %w", err)
+		}
+
+		key, err := ParseKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid decryption key %s: %w", path, err)
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// ParseKey returns the RSA or EC private key in data, either PEM or DER encoded, in
+// PKCS #1, PKCS #8, or SEC 1 form.
+func ParseKey(data []byte) (crypto.PrivateKey, error) {
+	der := data
+	if block, _ := pem.Decode(data); block != nil {
+		if block.Type == "ENCRYPTED PRIVATE KEY" || block.Headers["Proc-Type"] == "4,ENCRYPTED" {
+			return nil, errPasswordProtected
+		}
+		der = block.Bytes
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		switch key := key.(type) {
+		case *rsa.PrivateKey, *ecdsa.PrivateKey:
+			return key, nil
+		default:
+			return nil, fmt.Errorf("unsupported key type %T: must be an RSA or EC key", key)
+		}
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("not an RSA or EC private key")
+}
@@ -4,9 +4,11 @@ import (
 	"archive/tar"
 	"bytes"
 	"context"
+	"crypto"
 	"crypto/md5"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
@@ -16,26 +18,38 @@ import (
 	"path"
 	"path/filepath"
 	"regexp"
+	"runtime/debug"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/funcr"
 
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/artifacts"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/certification"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/clock"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/authn"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/decrypt"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/events"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/image"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/interrupt"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/lockfile"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/log"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/mirror"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/openshift"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/operatorsdk"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/policy"
 	containerpol "github.com/redhat-openshift-ecosystem/openshift-preflight/internal/policy/container"
 	operatorpol "github.com/redhat-openshift-ecosystem/openshift-preflight/internal/policy/operator"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/pyxis"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/readiness"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/rpm"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/runtime"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/scratch"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/tracing"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/transport"
 
 	"github.com/google/go-containerregistry/pkg/crane"
 	"github.com/google/go-containerregistry/pkg/name"
@@ -58,7 +72,8 @@ type CraneEngine struct {
 	// Checks is an array of all checks to be executed against
 	// the image provided.
 	Checks []check.Check
-	// Platform is the container platform to use. E.g. amd64.
+	// Platform is the container platform to use, either an architecture, e.g. amd64,
+	// or a full platform, e.g. linux/arm/v7.
 	Platform string
 
 	// IsBundle is an indicator that the asset is a bundle.
@@ -71,36 +86,123 @@ type CraneEngine struct {
 	// the registry crane connects with.
 	Insecure bool
 
+	// FailFast stops the execution of checks after the first check that fails or
+	// errors. The checks after it are not executed, and are not included in the results.
+	FailFast bool
+
+	// ExpectedDigest is the manifest digest the image must have. If the image that is
+	// pulled has another, e.g. because its tag was moved to another image, the execution
+	// fails with ErrDigestMismatch before any check is executed.
+	ExpectedDigest string
+
+	// Lockfile checks the digest of the image that is pulled, if set.
+	Lockfile *lockfile.Lockfile
+
+	// Mirrors are the registry mirrors the image is pulled from, in order, before the
+	// registry of the image itself.
+	Mirrors []mirror.Mirror
+
+	// DecryptionKeys decrypt the encrypted layers of the image, so that their contents
+	// are checked. Without keys, encrypted layers are skipped.
+	DecryptionKeys []crypto.PrivateKey
+
+	// LayerCache is the directory the layers of the image are cached in, so that
+	// executions after the first do not pull the layers they share again. Layers are
+	// cached in a temporary directory if it is empty.
+	LayerCache string
+
+	// ScratchDir is the directory the image is extracted to. Defaults to the default
+	// temporary directory, e.g. /tmp or $TMPDIR.
+	ScratchDir string
+
+	// Timeouts are the budgets within which checks must complete.
+	Timeouts check.Timeouts
+
+	// Attempts is the number of times Retryable checks are executed until they pass.
+	Attempts int
+
+	// TraceOnFailure executes the checks that fail again with trace logging, and
+	// captures the log.
+	TraceOnFailure bool
+
 	imageRef image.ImageReference
 	results  certification.Results
+
+	// clusterErr is the error with which the connection to the cluster was lost, if it
+	// was, so that the remaining checks that require the cluster are not executed.
+	clusterErr error
+	// probeCluster returns nil once the cluster is reachable again. It probes the API
+	// server with the Kubeconfig if nil.
+	probeCluster func(context.Context) error
 }
 
+// ErrCheckTimedOut is the error of a check that does not complete within its budget.
+var ErrCheckTimedOut = errors.New("check timed out")
+
+// ErrClusterUnreachable is the error of the checks that require the cluster once the
+// connection to it is lost, and cannot be restored.
+var ErrClusterUnreachable = errors.New("lost the connection to the cluster")
+
+// DefaultLayerCacheDir returns the directory in the user's cache directory that layers
+// are cached in, e.g. ~/.cache/preflight/layers.
+func DefaultLayerCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "preflight", "layers"), nil
+}
+
+// ErrDigestMismatch is the error of an image whose manifest digest is not the one it
+// was expected to have.
+var ErrDigestMismatch = errors.New("image digest differs from the expected digest")
+
+// ErrOSMismatch is the error of an execution in which a Windows image was pulled for a
+// Linux platform, or a Linux image for a Windows platform. Windows images are checked
+// under another policy, which is selected by the platform.
+var ErrOSMismatch = errors.New("image operating system differs from the platform")
+
+// reconnectBackoff are the delays before each probe of the cluster after a check loses
+// the connection to it.
+var reconnectBackoff = []time.Duration{5 * time.Second, 10 * time.Second, 20 * time.Second, 40 * time.Second}
+
+// timeoutGracePeriod is how long a check that does not complete within its budget is
+// waited on, once its context is cancelled, to return.
+var timeoutGracePeriod = 30 * time.Second
+
 func (c *CraneEngine) ExecuteChecks(ctx context.Context) error {
 	logger := logr.FromContextOrDiscard(ctx)
 	logger.V(log.DBG).Info("target image", "image", c.Image)
 
+	platform := &cranev1.Platform{OS: image.DefaultOS, Architecture: c.Platform}
+	if c.Platform != "" {
+		var err error
+		if platform, err = image.ParsePlatform(c.Platform); err != nil {
+			return err
+		}
+	}
+
 	// prepare crane runtime options, if necessary
 	options := []crane.Option{
 		crane.WithContext(ctx),
 		crane.WithAuthFromKeychain(
 			authn.PreflightKeychain(
 				ctx,
-				// We configure the Preflight Keychain here.
-				// In theory, we should not require further configuration
-				// downstream because the PreflightKeychain is a singleton.
-				// However, as long as we pass this same DockerConfig
-				// value downstream, it shouldn't matter if the
-				// keychain is reconfigured downstream.
+				// Each PreflightKeychain is configured independently,
+				// so checks that pull images downstream must pass
+				// this same DockerConfig to their own keychain.
 				authn.WithDockerConfig(c.DockerConfig),
 			),
 		),
-		crane.WithPlatform(&cranev1.Platform{
-			OS:           "linux",
-			Architecture: c.Platform,
-		}),
+		crane.WithPlatform(platform),
 		retryOnceAfter(5 * time.Second),
 	}
 
+	if transport.IsConfigured(ctx) {
+		options = append(options, crane.WithTransport(transport.Transport(ctx, remote.DefaultTransport.(*http.Transport))))
+	}
+
 	if c.Insecure {
 		// Adding WithTransport opt is a workaround to allow for access to HTTPS
 		// container registries with self-signed or non-trusted certificates.
@@ -108,7 +210,7 @@ func (c *CraneEngine) ExecuteChecks(ctx context.Context) error {
 		// See https://github.com/google/go-containerregistry/issues/1553 for more context. If this issue
 		// is resolved, then this workaround can likely be removed or adjusted to use new features in the
 		// go-containerregistry project.
-		rt := remote.DefaultTransport.(*http.Transport).Clone()
+		rt := transport.Transport(ctx, remote.DefaultTransport.(*http.Transport)).Clone()
 		rt.TLSClientConfig = &tls.Config{
 			InsecureSkipVerify: true, //nolint: gosec
 		}
@@ -118,13 +220,59 @@ func (c *CraneEngine) ExecuteChecks(ctx context.Context) error {
 
 	// pull the image and save to fs
 	logger.V(log.DBG).Info("pulling image from target registry")
-	img, err := crane.Pull(c.Image, options...)
+	events.EmitPhase(ctx, "", "pulling image")
+	pullCtx, pullSpan := tracing.Start(ctx, "pull image", tracing.ImageKey.String(c.Image))
+	img, err := pullImage(pullCtx, c.Image, c.Mirrors, options...)
+	tracing.End(pullSpan, err)
 	if err != nil {
 		return fmt.Errorf("failed to pull remote container: %v", err)
 	}
 
+	digest, err := img.Digest()
+	if err != nil {
+		return fmt.Errorf("could not get image digest: %v", err)
+	}
+	c.results.ImageDigest = digest.String()
+	logger.Info("resolved image digest", "image", c.Image, "digest", c.results.ImageDigest)
+
+	// The digest is verified before anything is extracted, so that content other than
+	// the expected is never checked.
+	if expected := c.ExpectedDigest; expected != "" && expected != c.results.ImageDigest {
+		return fmt.Errorf("%w: %s has digest %s, but %s was expected", ErrDigestMismatch, c.Image, c.results.ImageDigest, expected)
+	}
+
+	if c.Lockfile != nil {
+		if err := c.Lockfile.Check(c.Image, c.results.ImageDigest); err != nil {
+			return err
+		}
+	}
+
+	// Images that are not multi-platform are pulled whatever their platform.
+	configFile, err := img.ConfigFile()
+	if err != nil {
+		return fmt.Errorf("could not get image config: %v", err)
+	}
+	if (configFile.OS == image.OSWindows) != (platform.OS == image.OSWindows) {
+		return fmt.Errorf("%w: %s is a %s image, but was pulled for %s; set the platform to %s/%s",
+			ErrOSMismatch, c.Image, configFile.OS, platform.OS, configFile.OS, configFile.Architecture)
+	}
+
+	// Fail before anything is written, rather than once extraction fills the disk.
+	scratchDir := c.ScratchDir
+	if scratchDir == "" {
+		scratchDir = os.TempDir()
+	}
+	needed, err := c.scratchSpace(ctx, img)
+	if err != nil {
+		return err
+	}
+	logger.V(log.DBG).Info("checking free space in scratch directory", "path", scratchDir, "needed", needed)
+	if err := scratch.EnsureSpace(scratchDir, needed); err != nil {
+		return err
+	}
+
 	// create tmpdir to receive extracted fs
-	tmpdir, err := os.MkdirTemp(os.TempDir(), "preflight-*")
+	tmpdir, err := os.MkdirTemp(scratchDir, "preflight-*")
 	if err != nil {
 		return fmt.Errorf("failed to create temporary directory: %v", err)
 	}
@@ -136,39 +284,58 @@ func (c *CraneEngine) ExecuteChecks(ctx context.Context) error {
 	}()
 
 	imageTarPath := path.Join(tmpdir, "cache")
-	if err := os.Mkdir(imageTarPath, 0o755); err != nil {
+	if c.LayerCache != "" {
+		imageTarPath = c.LayerCache
+	}
+	if err := os.MkdirAll(imageTarPath, 0o755); err != nil {
 		return fmt.Errorf("failed to create cache directory: %s: %v", imageTarPath, err)
 	}
 
 	img = cache.Image(img, cache.NewFilesystemCache(imageTarPath))
 
+	// Layers are cached encrypted, and only decrypted as they are extracted.
+	if len(c.DecryptionKeys) > 0 {
+		if img, err = decrypt.Image(img, c.DecryptionKeys); err != nil {
+			return fmt.Errorf("could not decrypt image: %w", err)
+		}
+	}
+
+	// Layers that cannot be extracted, such as foreign layers, are skipped
+	// rather than failing the whole execution.
+	exportImg, err := c.skipUnsupportedLayers(ctx, img)
+	if err != nil {
+		return err
+	}
+
 	containerFSPath := path.Join(tmpdir, "fs")
 	if err := os.Mkdir(containerFSPath, 0o755); err != nil {
 		return fmt.Errorf("failed to create container expansion directory: %s: %v", containerFSPath, err)
 	}
 
+	// Checks that declare the paths of the filesystem they read only need those
+	// extracted, so that the scratch space needed is not that of the whole filesystem.
+	// Every entry is still recorded, in the same pass, for checks that only read their
+	// paths, modes, or sizes.
+	paths := c.extractedPaths()
+	if paths != nil {
+		logger.V(log.DBG).Info("extracting only the paths read by checks", "paths", paths)
+	}
+
 	// export/flatten, and extract
 	logger.V(log.DBG).Info("exporting and flattening image")
-	r, w := io.Pipe()
-	go func() {
-		logger.V(log.DBG).Info("writing container filesystem", "outputDirectory", containerFSPath)
-
-		// Close the writer with any errors encountered during
-		// extraction. These errors will be returned by the reader end
-		// on subsequent reads. If err == nil, the reader will return
-		// EOF.
-		w.CloseWithError(crane.Export(img, w))
-	}()
-
-	logger.V(log.DBG).Info("extracting container filesystem", "path", containerFSPath)
-	if err := untar(ctx, containerFSPath, r); err != nil {
-		return fmt.Errorf("failed to extract tarball: %v", err)
+	events.EmitPhase(ctx, "", "extracting image")
+	// Layers are downloaded as they are exported, so this includes pulling them.
+	_, extractSpan := tracing.Start(ctx, "extract image", tracing.ImageKey.String(c.Image))
+	files, missed, err := extract(ctx, exportImg, containerFSPath, paths)
+	if err == nil && len(missed) > 0 {
+		// The targets of links are extracted as the links are read, but entries that
+		// precede the links to them are only extracted in a second pass, from the cache.
+		logger.V(log.DBG).Info("extracting the targets of links", "paths", missed)
+		_, _, err = extract(ctx, exportImg, containerFSPath, missed)
 	}
-
-	// explicitly discarding from the reader for cases where there is data in the reader after it sends an EOF
-	_, err = io.Copy(io.Discard, r)
+	tracing.End(extractSpan, err)
 	if err != nil {
-		return fmt.Errorf("failed to drain io reader: %v", err)
+		return err
 	}
 
 	reference, err := name.ParseReference(c.Image)
@@ -184,13 +351,16 @@ func (c *CraneEngine) ExecuteChecks(ctx context.Context) error {
 		ImageRegistry:   reference.Context().RegistryStr(),
 		ImageRepository: reference.Context().RepositoryStr(),
 		ImageTagOrSha:   reference.Identifier(),
+		ImageOS:         configFile.OS,
+		Files:           files,
 	}
 
 	if err := writeCertImage(ctx, c.imageRef); err != nil {
 		return fmt.Errorf("could not write cert image: %v", err)
 	}
 
-	if !c.IsScratch {
+	// Neither scratch nor Windows images have an RPM database.
+	if !c.IsScratch && !c.imageRef.IsWindows() {
 		if err := writeRPMManifest(ctx, containerFSPath); err != nil {
 			return fmt.Errorf("could not write rpm manifest: %v", err)
 		}
@@ -198,6 +368,7 @@ func (c *CraneEngine) ExecuteChecks(ctx context.Context) error {
 
 	if c.IsBundle {
 		// Record test cluster version
+		events.EmitPhase(ctx, "", "determining cluster version")
 		version, err := openshift.GetOpenshiftClusterVersion(ctx, c.Kubeconfig)
 		if err != nil {
 			logger.Error(err, "could not determine test cluster version")
@@ -210,7 +381,20 @@ func (c *CraneEngine) ExecuteChecks(ctx context.Context) error {
 
 	// execute checks
 	logger.V(log.DBG).Info("executing checks")
-	for _, check := range c.Checks {
+	clk := clock.FromContext(ctx)
+	c.clusterErr = nil
+	failFast := c.FailFast
+	for i, check := range c.Checks {
+		if failFast && (len(c.results.Failed) > 0 || len(c.results.Errors) > 0) {
+			logger.Info("stopping at the first check that did not pass", "skipped", len(c.Checks)-i)
+			break
+		}
+
+		if interrupt.Interrupted(ctx) {
+			c.skipInterrupted(ctx, c.Checks[i:])
+			break
+		}
+
 		c.results.TestedImage = c.Image
 
 		logger.V(log.DBG).Info("running check", "check", check.Name())
@@ -218,33 +402,71 @@ func (c *CraneEngine) ExecuteChecks(ctx context.Context) error {
 			logger.Info(fmt.Sprintf("Check %s is not currently being enforced.", check.Name()))
 		}
 
+		if check.Metadata().Level != "optional" {
+			events.Emit(ctx, events.Event{Type: events.TypeCheckStarted, Image: c.Image, Check: check.Name()})
+		}
+
 		// run the validation
-		checkStartTime := time.Now()
-		checkPassed, err := check.Validate(ctx, c.imageRef)
-		checkElapsedTime := time.Since(checkStartTime)
+		checkCtx, checkSpan := tracing.Start(ctx, "check "+check.Name(), tracing.CheckKey.String(check.Name()))
+		checkStartTime := clk.Now()
+		checkPassed, attempts, err := c.validateOnCluster(checkCtx, check)
+		checkElapsedTime := clk.Since(checkStartTime)
+		checkSpan.SetAttributes(tracing.ResultKey.String(string(checkStatus(checkPassed, err))), tracing.AttemptsKey.Int(attempts))
+		tracing.End(checkSpan, err)
+
+		// A check stopped because preflight was interrupted has no result.
+		if err != nil && interrupt.Interrupted(ctx) {
+			c.skipInterrupted(ctx, c.Checks[i:])
+			break
+		}
 
 		if err != nil {
 			logger.WithValues("result", "ERROR", "err", err.Error()).Info("check completed", "check", check.Name())
-			c.results.Errors = appendUnlessOptional(c.results.Errors, certification.Result{Check: check, ElapsedTime: checkElapsedTime})
+			result := certification.Result{Check: check, StartTime: checkStartTime, ElapsedTime: checkElapsedTime, Attempts: attempts}
+			if errors.Is(err, ErrClusterUnreachable) || errors.Is(err, ErrCheckTimedOut) || errors.Is(err, transport.ErrEgressDenied) {
+				result.Reason = err.Error()
+			}
+			c.results.Errors = appendUnlessOptional(c.results.Errors, result)
+			reportStepResult(ctx, c.Image, certification.StepResult{Result: result, Status: certification.StatusErrored, Err: err})
 			continue
 		}
 
 		if !checkPassed {
 			logger.WithValues("result", "FAILED").Info("check completed", "check", check.Name())
-			c.results.Failed = appendUnlessOptional(c.results.Failed, certification.Result{Check: check, ElapsedTime: checkElapsedTime})
+			result := certification.Result{Check: check, StartTime: checkStartTime, ElapsedTime: checkElapsedTime, Attempts: attempts}
+			c.results.Failed = appendUnlessOptional(c.results.Failed, result)
+			reportStepResult(ctx, c.Image, certification.StepResult{Result: result, Status: certification.StatusFailed})
 			continue
 		}
 
 		logger.WithValues("result", "PASSED").Info("check completed", "check", check.Name())
-		c.results.Passed = appendUnlessOptional(c.results.Passed, certification.Result{Check: check, ElapsedTime: checkElapsedTime})
+		result := certification.Result{Check: check, StartTime: checkStartTime, ElapsedTime: checkElapsedTime, Attempts: attempts}
+		c.results.Passed = appendUnlessOptional(c.results.Passed, result)
+		reportStepResult(ctx, c.Image, certification.StepResult{Result: result, Status: certification.StatusPassed})
 	}
 
-	if len(c.results.Errors) > 0 || len(c.results.Failed) > 0 {
+	if len(c.results.Errors) > 0 || len(c.results.Failed) > 0 || len(c.results.Skipped) > 0 {
 		c.results.PassedOverall = false
 	} else {
 		c.results.PassedOverall = true
 	}
 
+	// The connections denied when failures are traced are not reported, as the results
+	// are those of the first execution.
+	if egress := transport.EgressPolicyFromContext(ctx); egress != nil {
+		for _, denied := range egress.Denied() {
+			logger.Info("denied a check access to the network", "check", denied.Check, "address", denied.Address)
+			c.results.DeniedConnections = append(c.results.DeniedConnections, certification.DeniedConnection{
+				Check:   denied.Check,
+				Address: denied.Address,
+			})
+		}
+	}
+
+	if c.TraceOnFailure && !interrupt.Interrupted(ctx) {
+		c.traceFailures(ctx)
+	}
+
 	if c.IsBundle { // for operators:
 		// hash the contents of the bundle.
 		md5sum, err := generateBundleHash(ctx, c.imageRef.ImageFSPath)
@@ -271,6 +493,310 @@ func (c *CraneEngine) ExecuteChecks(ctx context.Context) error {
 	return nil
 }
 
+// skipInterrupted records checks, which did not complete because preflight was
+// interrupted, as skipped.
+func (c *CraneEngine) skipInterrupted(ctx context.Context, checks []check.Check) {
+	logger := logr.FromContextOrDiscard(ctx)
+	logger.Info("preflight was interrupted, skipping the remaining checks", "skipped", len(checks))
+
+	for _, chk := range checks {
+		result := certification.Result{Check: chk, Reason: interrupt.ErrInterrupted.Error()}
+		c.results.Skipped = appendUnlessOptional(c.results.Skipped, result)
+		reportStepResult(ctx, c.Image, certification.StepResult{Result: result, Status: certification.StatusSkipped})
+	}
+}
+
+// TraceFilenameSuffix is appended to a check's name to name the artifact containing
+// the trace log of executing the check again after it failed.
+const TraceFilenameSuffix = "-trace.log"
+
+// traceFailures executes the checks that failed or errored again with trace logging,
+// and writes the log of each as an artifact. The results are not changed.
+func (c *CraneEngine) traceFailures(ctx context.Context) {
+	logger := logr.FromContextOrDiscard(ctx)
+
+	aw := artifacts.WriterFromContext(ctx)
+	if aw == nil {
+		logger.V(log.DBG).Info("no artifacts writer configured, not tracing failed checks")
+		return
+	}
+
+	failures := make([]certification.Result, 0, len(c.results.Failed)+len(c.results.Errors))
+	failures = append(failures, c.results.Failed...)
+	failures = append(failures, c.results.Errors...)
+
+	for _, failure := range failures {
+		logger.V(log.DBG).Info("running check again with trace logging", "check", failure.Name())
+
+		var buf bytes.Buffer
+		traceLogger := funcr.New(func(prefix, args string) {
+			if prefix != "" {
+				fmt.Fprintf(&buf, "%s: ", prefix)
+			}
+			fmt.Fprintln(&buf, args)
+		}, funcr.Options{Verbosity: log.TRC})
+
+		// The execution is only for diagnostics, so it is not reported as events.
+		traceCtx := logr.NewContext(events.ContextWithListener(ctx, nil), traceLogger)
+		passed, err := c.validateOnce(traceCtx, failure.Check)
+		switch {
+		case err != nil:
+			traceLogger.Info("check completed", "check", failure.Name(), "result", "ERROR", "err", err.Error())
+		case !passed:
+			traceLogger.Info("check completed", "check", failure.Name(), "result", "FAILED")
+		default:
+			traceLogger.Info("check completed", "check", failure.Name(), "result", "PASSED")
+		}
+
+		filename, err := aw.WriteFile(failure.Name()+TraceFilenameSuffix, &buf)
+		if err != nil {
+			logger.Error(err, "unable to write trace log", "check", failure.Name())
+			continue
+		}
+		logger.Info("trace log of failed check written", "check", failure.Name(), "filename", filename)
+	}
+}
+
+// validateOnCluster executes chk like validate. If chk requires the cluster, and errors
+// because the connection to the cluster was lost, the cluster is probed with backoff, and
+// chk is executed again once it is reachable. Otherwise, chk, and the remaining checks
+// that require the cluster, error with ErrClusterUnreachable, the latter without being
+// executed, so that the results are still complete.
+func (c *CraneEngine) validateOnCluster(ctx context.Context, chk check.Check) (bool, int, error) {
+	if !check.RequiresCluster(chk) {
+		return c.validate(ctx, chk)
+	}
+	if c.clusterErr != nil {
+		return false, 0, fmt.Errorf("%w: %v", ErrClusterUnreachable, c.clusterErr)
+	}
+
+	passed, attempts, err := c.validate(ctx, chk)
+	if !openshift.IsConnectionError(err) || ctx.Err() != nil {
+		return passed, attempts, err
+	}
+
+	logger := logr.FromContextOrDiscard(ctx)
+	logger.Info("lost the connection to the cluster, waiting for it to be reachable", "check", chk.Name(), "err", err.Error())
+	if probeErr := c.waitForCluster(ctx); probeErr != nil {
+		logger.Error(probeErr, "the cluster is unreachable, not executing the remaining checks that require it")
+		c.clusterErr = err
+		return false, attempts, fmt.Errorf("%w: %v", ErrClusterUnreachable, err)
+	}
+
+	logger.Info("the cluster is reachable, executing the check again", "check", chk.Name())
+	passed, retried, err := c.validate(ctx, chk)
+	attempts += retried
+	if openshift.IsConnectionError(err) {
+		c.clusterErr = err
+		return false, attempts, fmt.Errorf("%w: %v", ErrClusterUnreachable, err)
+	}
+
+	return passed, attempts, err
+}
+
+// waitForCluster probes the cluster after each delay of reconnectBackoff, until it is
+// reachable. It returns the error of the last probe if the cluster is not reachable.
+func (c *CraneEngine) waitForCluster(ctx context.Context) error {
+	probe := c.probeCluster
+	if probe == nil {
+		probe = func(ctx context.Context) error {
+			return readiness.Run(ctx, readiness.DefaultTimeout, readiness.Cluster(c.Kubeconfig))[0].Err
+		}
+	}
+
+	logger := logr.FromContextOrDiscard(ctx)
+	err := ErrClusterUnreachable
+	for attempt, delay := range reconnectBackoff {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+
+		if err = probe(ctx); err == nil {
+			return nil
+		}
+		logger.V(log.DBG).Info("the cluster is not reachable", "attempt", attempt+1, "attempts", len(reconnectBackoff), "err", err.Error())
+	}
+
+	return err
+}
+
+// validate executes chk against the image. If chk is Retryable, and does not pass, it
+// is executed again, up to c.Attempts times. The artifacts of each attempt
+// are kept in memory, so that only those of the last attempt are written. It returns
+// the outcome of the last attempt, and the number of attempts.
+func (c *CraneEngine) validate(ctx context.Context, chk check.Check) (bool, int, error) {
+	maxAttempts := 1
+	if check.IsRetryable(chk) && c.Attempts > 1 {
+		maxAttempts = c.Attempts
+	}
+
+	if maxAttempts == 1 {
+		passed, err := c.validateOnce(ctx, chk)
+		return passed, 1, err
+	}
+
+	logger := logr.FromContextOrDiscard(ctx)
+	aw := artifacts.WriterFromContext(ctx)
+
+	for attempt := 1; ; attempt++ {
+		attemptCtx := ctx
+		var mw *artifacts.MapWriter
+		if aw != nil {
+			mw, _ = artifacts.NewMapWriter()
+			attemptCtx = artifacts.ContextWithWriter(ctx, mw)
+		}
+
+		passed, err := c.validateOnce(attemptCtx, chk)
+		if (passed && err == nil) || attempt == maxAttempts || ctx.Err() != nil {
+			if mw != nil {
+				if writeErr := copyArtifacts(aw, mw); writeErr != nil && err == nil {
+					err = writeErr
+				}
+			}
+			if passed && err == nil && attempt > 1 {
+				logger.Info(fmt.Sprintf("Warning: check %s passed after %d attempts, and may be flaky", chk.Name(), attempt))
+			}
+			return passed, attempt, err
+		}
+
+		switch {
+		case err != nil:
+			logger.Info("check errored, executing it again", "check", chk.Name(), "attempt", attempt, "attempts", maxAttempts, "err", err.Error())
+		default:
+			logger.Info("check failed, executing it again", "check", chk.Name(), "attempt", attempt, "attempts", maxAttempts)
+		}
+	}
+}
+
+// validateOnce executes chk against the image once, within its budget, if any.
+// A check that does not complete within its budget errors with ErrCheckTimedOut. Its
+// context is cancelled, and it is waited on for timeoutGracePeriod to return, so that it
+// stops what it started. Checks cannot be preempted, so one that does not return then is
+// left running, and its error says so. Its connections are denied unless the egress
+// policy in ctx, if any, allows chk to access the network.
+func (c *CraneEngine) validateOnce(ctx context.Context, chk check.Check) (bool, error) {
+	ctx = transport.ContextWithCheck(ctx, chk.Name())
+	timeout := c.Timeouts.For(chk.Name())
+	if timeout <= 0 {
+		return c.validateRecovered(ctx, chk)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type outcome struct {
+		passed bool
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		passed, err := c.validateRecovered(ctx, chk)
+		done <- outcome{passed: passed, err: err}
+	}()
+
+	select {
+	case o := <-done:
+		if o.err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return false, fmt.Errorf("%w: %s did not complete within %s: %v", ErrCheckTimedOut, chk.Name(), timeout, o.err)
+		}
+		return o.passed, o.err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			cancel()
+			select {
+			case <-done:
+				return false, fmt.Errorf("%w: %s did not complete within %s", ErrCheckTimedOut, chk.Name(), timeout)
+			case <-time.After(timeoutGracePeriod):
+				logr.FromContextOrDiscard(ctx).Info("Warning: check did not stop once it timed out, and is still running", "check", chk.Name(), "gracePeriod", timeoutGracePeriod)
+				return false, fmt.Errorf("%w: %s did not complete within %s, and is still running, as it did not stop within %s of being cancelled",
+					ErrCheckTimedOut, chk.Name(), timeout, timeoutGracePeriod)
+			}
+		}
+		// An interrupted check is waited on, so that it removes what it created, e.g.
+		// on the cluster, before preflight exits.
+		<-done
+		return false, ctx.Err()
+	}
+}
+
+// validateRecovered executes chk against the image. A check that panics, e.g. on a
+// response it did not expect from a cluster it lost the connection to, errors instead.
+func (c *CraneEngine) validateRecovered(ctx context.Context, chk check.Check) (passed bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logr.FromContextOrDiscard(ctx).V(log.DBG).Info("check panicked", "check", chk.Name(), "stack", string(debug.Stack()))
+			passed, err = false, fmt.Errorf("check %s panicked: %v", chk.Name(), r)
+		}
+	}()
+
+	return chk.Validate(ctx, c.imageRef)
+}
+
+// copyArtifacts writes each artifact in src to dst.
+func copyArtifacts(dst artifacts.ArtifactWriter, src *artifacts.MapWriter) error {
+	for _, filename := range src.Filenames() {
+		b, err := src.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+		if _, err := dst.WriteFile(filename, bytes.NewReader(b)); err != nil {
+			return fmt.Errorf("could not write artifact %s: %w", filename, err)
+		}
+	}
+
+	return nil
+}
+
+// skipUnsupportedLayers records the layers of img whose contents cannot be extracted
+// in the results, and returns img with only the layers that can be extracted.
+func (c *CraneEngine) skipUnsupportedLayers(ctx context.Context, img cranev1.Image) (cranev1.Image, error) {
+	logger := logr.FromContextOrDiscard(ctx)
+
+	supported, unsupported, err := image.SupportedLayers(img)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect image layers: %v", err)
+	}
+
+	if len(unsupported) == 0 {
+		return img, nil
+	}
+
+	if len(supported) == 0 {
+		return nil, fmt.Errorf("image has no layers with a supported media type")
+	}
+
+	c.results.SkippedLayers = make([]certification.SkippedLayer, 0, len(unsupported))
+	for _, layer := range unsupported {
+		digest, err := layer.Digest()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get layer digest: %v", err)
+		}
+
+		logger.Info(fmt.Sprintf("Warning: layer %s (%s) was %s, and its contents will not be checked", digest, layer.MediaType, layer.Reason))
+		c.results.SkippedLayers = append(c.results.SkippedLayers, certification.SkippedLayer{
+			Digest:    digest.String(),
+			MediaType: string(layer.MediaType),
+			Reason:    layer.Reason,
+		})
+	}
+
+	return image.WithLayers(img, supported), nil
+}
+
+// checkStatus returns the status of a check that passed, or failed with err.
+func checkStatus(passed bool, err error) certification.Status {
+	switch {
+	case err != nil:
+		return certification.StatusErrored
+	case !passed:
+		return certification.StatusFailed
+	default:
+		return certification.StatusPassed
+	}
+}
+
 func appendUnlessOptional(results []certification.Result, result certification.Result) []certification.Result {
 	if result.Check.Metadata().Level == "optional" {
 		return results
@@ -278,6 +804,37 @@ func appendUnlessOptional(results []certification.Result, result certification.R
 	return append(results, result)
 }
 
+// reportStepResult emits a check finished event for r, and sends r to the step
+// results channel found in ctx, if any. Optional checks are not reported, as they
+// are not included in the final results either.
+func reportStepResult(ctx context.Context, img string, r certification.StepResult) {
+	if r.Check.Metadata().Level == "optional" {
+		return
+	}
+
+	e := events.Event{
+		Type:        events.TypeCheckFinished,
+		Image:       img,
+		Check:       r.Check.Name(),
+		Result:      string(r.Status),
+		ElapsedTime: float64(r.ElapsedTime / time.Millisecond),
+	}
+	if r.Err != nil {
+		e.Error = r.Err.Error()
+	}
+	events.Emit(ctx, e)
+
+	ch := certification.StepResultsFromContext(ctx)
+	if ch == nil {
+		return
+	}
+
+	select {
+	case ch <- r:
+	case <-ctx.Done():
+	}
+}
+
 // tagDigestBindingInfo emits a log line describing tag and digest binding semantics.
 // The providedIdentifer is the tag or digest of the image as the user gave it at the commandline.
 // resolvedDigest
@@ -357,29 +914,146 @@ func (c *CraneEngine) Results(ctx context.Context) certification.Results {
 	return c.results
 }
 
+// extractedPaths returns the paths of the filesystem of the image to extract, or nil if
+// all of it must be extracted. Bundles are always extracted whole, as they are hashed.
+func (c *CraneEngine) extractedPaths() []string {
+	if c.IsBundle {
+		return nil
+	}
+
+	paths, ok := check.FilesystemPaths(c.Checks)
+	if !ok {
+		return nil
+	}
+
+	// The RPM manifest is written from the RPM database.
+	if !c.IsScratch {
+		paths = append(paths, rpm.DatabaseDir)
+	}
+	// The database is usually a link to where it is on newer images, which is extracted
+	// with it, so that the link need not be resolved in a second pass.
+	if underAny(rpm.DatabaseDir, paths) {
+		paths = append(paths, rpm.SysimageDatabaseDir)
+	}
+
+	return paths
+}
+
+// scratchSpace returns the least free space, in bytes, that the scratch directory needs
+// for img: that of its compressed layers, unless they are cached elsewhere, and, if its
+// whole filesystem is extracted, that again, as the filesystem is at least as large.
+// It is a lower bound: the paths that checks read, e.g. the RPM database, are not
+// counted when only they are extracted, as their size is not known until they are.
+func (c *CraneEngine) scratchSpace(ctx context.Context, img cranev1.Image) (int64, error) {
+	layers, err := img.Layers()
+	if err != nil {
+		return 0, fmt.Errorf("could not get image layers: %v", err)
+	}
+
+	var compressed int64
+	for _, layer := range layers {
+		size, err := layer.Size()
+		if err != nil {
+			return 0, fmt.Errorf("could not get layer size: %v", err)
+		}
+		compressed += size
+	}
+
+	var needed int64
+	if c.LayerCache == "" {
+		needed += compressed
+	}
+	if c.extractedPaths() == nil {
+		needed += compressed
+	}
+
+	return needed, nil
+}
+
+// extract flattens img, and extracts the entries that are, or are under, one of paths,
+// or the targets of links among them, to dst, or every entry if paths is nil. It returns
+// every entry, whether it was extracted or not, and the targets of links whose entries
+// were not extracted, as they preceded the links.
+func extract(ctx context.Context, img cranev1.Image, dst string, paths []string) ([]image.File, []string, error) {
+	logger := logr.FromContextOrDiscard(ctx)
+
+	r, w := io.Pipe()
+	go func() {
+		logger.V(log.DBG).Info("writing container filesystem", "outputDirectory", dst)
+
+		// Close the writer with any errors encountered during
+		// extraction. These errors will be returned by the reader end
+		// on subsequent reads. If err == nil, the reader will return
+		// EOF.
+		w.CloseWithError(crane.Export(img, w))
+	}()
+
+	logger.V(log.DBG).Info("extracting container filesystem", "path", dst)
+	files, missed, err := untar(ctx, dst, r, paths)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to extract tarball: %v", err)
+	}
+
+	// explicitly discarding from the reader for cases where there is data in the reader after it sends an EOF
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return nil, nil, fmt.Errorf("failed to drain io reader: %v", err)
+	}
+
+	return files, missed, nil
+}
+
 // Untar takes a destination path and a reader; a tar reader loops over the tarfile
-// creating the file structure at 'dst' along the way, and writing any files
-func untar(ctx context.Context, dst string, r io.Reader) error {
+// creating the file structure at 'dst' along the way, and writing any files that are,
+// or are under, one of paths, or the targets of links among them, or every file if paths
+// is nil. It returns every entry of the tarfile, whether it was written or not, and the
+// targets of links whose entries were not written, as they preceded the links.
+func untar(ctx context.Context, dst string, r io.Reader, paths []string) ([]image.File, []string, error) {
 	logger := logr.FromContextOrDiscard(ctx)
 	tr := tar.NewReader(r)
 
+	files := []image.File{}
+	wanted := append([]string{}, paths...)
+	extracted := map[string]bool{}
 	for {
 		header, err := tr.Next()
 
 		switch {
 		// if no more files are found return
 		case err == io.EOF:
-			return nil
+			return files, missedTargets(files, paths, extracted), nil
 
 		// return any other error
 		case err != nil:
-			return err
+			return nil, nil, err
 
 		// if the header is nil, just skip it (not sure how this happens)
 		case header == nil:
 			continue
 		}
 
+		name := path.Clean("/" + header.Name)
+		file := image.File{
+			Path:     name,
+			Mode:     header.FileInfo().Mode(),
+			Size:     header.Size,
+			Linkname: header.Linkname,
+		}
+		files = append(files, file)
+		if paths != nil {
+			// The targets of links among the paths are extracted from here on.
+			if file.Mode&fs.ModeSymlink != 0 {
+				for _, target := range linkTargets([]image.File{file}, wanted) {
+					if !underAny(target, wanted) {
+						wanted = append(wanted, target)
+					}
+				}
+			}
+			if !underAny(name, wanted) {
+				continue
+			}
+		}
+		extracted[name] = true
+
 		// the target location where the dir/file should be created
 		target := filepath.Join(dst, header.Name)
 
@@ -387,26 +1061,35 @@ func untar(ctx context.Context, dst string, r io.Reader) error {
 		// a benefit of using one vs. the other.
 		// fi := header.FileInfo()
 
+		// the parent directories are not extracted with the paths under them
+		if header.Typeflag == tar.TypeReg || header.Typeflag == tar.TypeSymlink {
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return nil, nil, err
+			}
+		}
+
 		// check the file type
 		switch header.Typeflag {
 		// if its a dir and it doesn't exist create it
 		case tar.TypeDir:
 			if _, err := os.Stat(target); err != nil {
 				if err := os.MkdirAll(target, 0o755); err != nil {
-					return err
+					return nil, nil, err
 				}
 			}
 
 		// if it's a file create it
 		case tar.TypeReg:
-			f, err := os.OpenFile(target, os.O_CREATE|os.O_RDWR, os.FileMode(header.Mode))
+			// files extracted in a previous pass are overwritten
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_RDWR|os.O_TRUNC, os.FileMode(header.Mode))
 			if err != nil {
-				return err
+				return nil, nil, err
 			}
 
 			// copy over contents
 			if _, err := io.Copy(f, tr); err != nil {
-				return err
+				f.Close()
+				return nil, nil, err
 			}
 
 			// manually close here after each file operation; defering would cause each file close
@@ -424,6 +1107,103 @@ func untar(ctx context.Context, dst string, r io.Reader) error {
 	}
 }
 
+// maxLinkHops is the number of links that are followed to a path, as by Linux.
+const maxLinkHops = 40
+
+// linkTargets returns the paths that links among files point to, for the links that are,
+// or are under, one of paths, or are the parent directory of one of them, with the
+// links themselves in the latter case, so that paths can be read through them once they
+// are extracted. Paths under paths are not returned, as they are extracted already.
+func linkTargets(files []image.File, paths []string) []string {
+	if paths == nil {
+		return nil
+	}
+
+	wanted := append([]string{}, paths...)
+	var targets, links []string
+	add := func(p string) {
+		if !underAny(p, wanted) {
+			wanted = append(wanted, p)
+			targets = append(targets, p)
+		}
+	}
+	// The links that are parent directories are extracted, but not what they point to
+	// as a whole.
+	addLink := func(p string) {
+		if underAny(p, wanted) {
+			return
+		}
+		for _, link := range links {
+			if link == p {
+				return
+			}
+		}
+		links = append(links, p)
+	}
+
+	for hop := 0; hop < maxLinkHops; hop++ {
+		found := len(targets)
+		for _, f := range files {
+			if f.Mode&fs.ModeSymlink == 0 {
+				continue
+			}
+
+			target := f.Linkname
+			if !path.IsAbs(target) {
+				target = path.Join(path.Dir(f.Path), target)
+			}
+			target = path.Clean(target)
+
+			for _, p := range wanted {
+				switch {
+				case under(f.Path, p):
+					add(target)
+				case under(p, f.Path):
+					addLink(f.Path)
+					add(path.Join(target, strings.TrimPrefix(p, f.Path)))
+				}
+			}
+		}
+		if len(targets) == found {
+			break
+		}
+	}
+
+	return append(targets, links...)
+}
+
+// missedTargets returns the targets of links among files, for the links that are, or are
+// under, one of paths, that have entries in files that were not extracted.
+func missedTargets(files []image.File, paths []string, extracted map[string]bool) []string {
+	var missed []string
+	for _, target := range linkTargets(files, paths) {
+		for _, f := range files {
+			if under(f.Path, target) && !extracted[f.Path] {
+				missed = append(missed, target)
+				break
+			}
+		}
+	}
+
+	return missed
+}
+
+// under returns true if p is root, or is under it.
+func under(p, root string) bool {
+	return root == "/" || p == root || strings.HasPrefix(p, root+"/")
+}
+
+// underAny returns true if p is, or is under, any of roots.
+func underAny(p string, roots []string) bool {
+	for _, root := range roots {
+		if under(p, root) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // writeCertImage takes imageRef and writes it to disk as JSON representing a pyxis.CertImage
 // struct. The file is written at path certification.DefaultCertImageFilename.
 //
@@ -464,6 +1244,21 @@ func writeCertImage(ctx context.Context, imageRef image.ImageReference) error {
 			return fmt.Errorf("could not get layer by diff id: %w", err)
 		}
 
+		reason, err := image.UnsupportedLayerReason(layer)
+		if err != nil {
+			return err
+		}
+		if reason != "" {
+			// The contents of unsupported layers are not retrieved, so
+			// the size declared in the manifest is used instead.
+			size, err := layer.Size()
+			if err != nil {
+				return fmt.Errorf("could not get layer size: %w", err)
+			}
+			layerSizes = append(layerSizes, pyxis.Layer{LayerID: diffid.String(), Size: size})
+			continue
+		}
+
 		uncompressed, err := layer.Uncompressed()
 		if err != nil {
 			return fmt.Errorf("could not get uncompressed layer: %w", err)
@@ -487,7 +1282,7 @@ func writeCertImage(ctx context.Context, imageRef image.ImageReference) error {
 
 	sumLayersSizeBytes := sumLayerSizeBytes(layerSizes)
 
-	addedDate := time.Now().UTC().Format(time.RFC3339)
+	addedDate := clock.FromContext(ctx).Now().UTC().Format(time.RFC3339)
 
 	tags := make([]pyxis.Tag, 0, 1)
 	tags = append(tags, pyxis.Tag{
@@ -642,6 +1437,35 @@ func convertLabels(imageLabels map[string]string) []pyxis.Label {
 	return pyxisLabels
 }
 
+// pullImage pulls image from the first of mirrors that it can be pulled from, or from
+// image itself. The error pulling from the last candidate is returned if none can be
+// pulled from.
+func pullImage(ctx context.Context, image string, mirrors []mirror.Mirror, options ...crane.Option) (cranev1.Image, error) {
+	logger := log.FromContext(ctx, log.ComponentCrane)
+
+	candidates, err := mirror.Candidates(image, mirrors)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, candidate := range candidates {
+		if candidate != image {
+			logger.V(log.DBG).Info("pulling image from mirror", "image", image, "mirror", candidate)
+		}
+
+		img, err := crane.Pull(candidate, options...)
+		if err == nil {
+			return img, nil
+		}
+		if i == len(candidates)-1 {
+			return nil, err
+		}
+		logger.V(log.DBG).Info("unable to pull image, trying next mirror", "image", candidate, "reason", err.Error())
+	}
+
+	return nil, fmt.Errorf("no mirror of %s can be pulled from", image)
+}
+
 // retryOnceAfter is a crane option that retries once after t duration.
 func retryOnceAfter(t time.Duration) crane.Option {
 	return func(o *crane.Options) {
@@ -675,8 +1499,9 @@ func New(ctx context.Context,
 	isScratch bool,
 	insecure bool,
 	platform string,
+	opts ...Option,
 ) (CheckEngine, error) {
-	return &CraneEngine{
+	c := &CraneEngine{
 		Kubeconfig:   kubeconfig,
 		DockerConfig: dockerconfig,
 		Image:        image,
@@ -685,7 +1510,89 @@ func New(ctx context.Context,
 		IsScratch:    isScratch,
 		Platform:     platform,
 		Insecure:     insecure,
-	}, nil
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+type Option = func(*CraneEngine)
+
+// WithFailFast stops the execution of checks after the first check that fails or
+// errors.
+func WithFailFast() Option {
+	return func(c *CraneEngine) {
+		c.FailFast = true
+	}
+}
+
+// WithExpectedDigest fails the execution with ErrDigestMismatch, before any check is
+// executed, if the manifest digest of the image that is pulled is not digest.
+func WithExpectedDigest(digest string) Option {
+	return func(c *CraneEngine) {
+		c.ExpectedDigest = digest
+	}
+}
+
+// WithLockfile checks the digest of the image that is pulled with l.
+func WithLockfile(l *lockfile.Lockfile) Option {
+	return func(c *CraneEngine) {
+		c.Lockfile = l
+	}
+}
+
+// WithMirrors pulls the image from mirrors.
+func WithMirrors(mirrors []mirror.Mirror) Option {
+	return func(c *CraneEngine) {
+		c.Mirrors = mirrors
+	}
+}
+
+// WithDecryptionKeys decrypts the encrypted layers of the image with keys.
+func WithDecryptionKeys(keys []crypto.PrivateKey) Option {
+	return func(c *CraneEngine) {
+		c.DecryptionKeys = keys
+	}
+}
+
+// WithLayerCache caches the layers of the image in dir, rather than in a temporary
+// directory.
+func WithLayerCache(dir string) Option {
+	return func(c *CraneEngine) {
+		c.LayerCache = dir
+	}
+}
+
+// WithScratchDir extracts the image to dir, rather than to the default temporary
+// directory.
+func WithScratchDir(dir string) Option {
+	return func(c *CraneEngine) {
+		c.ScratchDir = dir
+	}
+}
+
+// WithTimeouts executes checks within the budgets of t.
+func WithTimeouts(t check.Timeouts) Option {
+	return func(c *CraneEngine) {
+		c.Timeouts = t
+	}
+}
+
+// WithAttempts executes Retryable checks up to attempts times, until they pass.
+func WithAttempts(attempts int) Option {
+	return func(c *CraneEngine) {
+		c.Attempts = attempts
+	}
+}
+
+// WithTraceOnFailure executes the checks that fail again with trace logging.
+func WithTraceOnFailure() Option {
+	return func(c *CraneEngine) {
+		c.TraceOnFailure = true
+	}
 }
 
 // OperatorCheckConfig contains configuration relevant to an individual check's execution.
@@ -693,27 +1600,37 @@ type OperatorCheckConfig struct {
 	ScorecardImage, ScorecardWaitTime, ScorecardNamespace, ScorecardServiceAccount string
 	IndexImage, DockerConfig, Channel                                              string
 	Kubeconfig                                                                     []byte
+	// BasicOperability replaces the scorecard checks with BasicOperabilityCheck, for
+	// clusters that cannot run the scorecard pod.
+	BasicOperability bool
 }
 
 // InitializeOperatorChecks returns opeartor checks for policy p give cfg.
 func InitializeOperatorChecks(ctx context.Context, p policy.Policy, cfg OperatorCheckConfig) ([]check.Check, error) {
 	switch p {
 	case policy.PolicyOperator:
-		return []check.Check{
+		operabilityChecks := []check.Check{
 			operatorpol.NewScorecardBasicSpecCheck(operatorsdk.New(cfg.ScorecardImage, exec.Command), cfg.ScorecardNamespace, cfg.ScorecardServiceAccount, cfg.Kubeconfig, cfg.ScorecardWaitTime),
 			operatorpol.NewScorecardOlmSuiteCheck(operatorsdk.New(cfg.ScorecardImage, exec.Command), cfg.ScorecardNamespace, cfg.ScorecardServiceAccount, cfg.Kubeconfig, cfg.ScorecardWaitTime),
-			operatorpol.NewDeployableByOlmCheck(cfg.IndexImage, cfg.DockerConfig, cfg.Channel),
+		}
+		if cfg.BasicOperability {
+			operabilityChecks = []check.Check{operatorpol.NewBasicOperabilityCheck(cfg.ScorecardNamespace, cfg.Kubeconfig)}
+		}
+
+		return append(operabilityChecks,
+			operatorpol.NewDeployableByOlmCheck(cfg.IndexImage, cfg.DockerConfig, cfg.Channel, cfg.Kubeconfig),
 			operatorpol.NewValidateOperatorBundleCheck(),
+			operatorpol.NewSupportedAPIsCheck(),
 			operatorpol.NewCertifiedImagesCheck(pyxis.NewPyxisClient(
 				check.DefaultPyxisHost,
 				"",
 				"",
-				&http.Client{Timeout: 60 * time.Second}),
+				transport.HTTPClient(ctx, 60*time.Second)),
 			),
 			operatorpol.NewSecurityContextConstraintsCheck(),
 			&operatorpol.RelatedImagesCheck{},
 			operatorpol.FollowsRestrictedNetworkEnablementGuidelines{},
-		}, nil
+		), nil
 	}
 
 	return nil, fmt.Errorf("provided operator policy %s is unknown", p)
@@ -722,10 +1639,28 @@ func InitializeOperatorChecks(ctx context.Context, p policy.Policy, cfg Operator
 // ContainerCheckConfig contains configuration relevant to an individual check's execution.
 type ContainerCheckConfig struct {
 	DockerConfig, PyxisAPIToken, CertificationProjectID string
+	// ApprovedBaseImages are the base images an organization approves building
+	// images on. If set, BasedOnApprovedBaseImage is executed in addition to the
+	// checks in the policy.
+	ApprovedBaseImages []string
 }
 
 // InitializeContainerChecks returns the appropriate checks for policy p given cfg.
 func InitializeContainerChecks(ctx context.Context, p policy.Policy, cfg ContainerCheckConfig) ([]check.Check, error) {
+	checks, err := containerPolicyChecks(ctx, p, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cfg.ApprovedBaseImages) > 0 {
+		checks = append(checks, containerpol.NewBasedOnApprovedBaseImageCheck(cfg.ApprovedBaseImages, cfg.DockerConfig))
+	}
+
+	return checks, nil
+}
+
+// containerPolicyChecks returns the checks in policy p given cfg.
+func containerPolicyChecks(ctx context.Context, p policy.Policy, cfg ContainerCheckConfig) ([]check.Check, error) {
 	switch p {
 	case policy.PolicyContainer:
 		return []check.Check{
@@ -736,11 +1671,12 @@ func InitializeContainerChecks(ctx context.Context, p policy.Policy, cfg Contain
 			&containerpol.HasRequiredLabelsCheck{},
 			&containerpol.RunAsNonRootCheck{},
 			&containerpol.HasModifiedFilesCheck{},
+			&containerpol.HasNoCaseCollidingFilesCheck{},
 			containerpol.NewBasedOnUbiCheck(pyxis.NewPyxisClient(
 				check.DefaultPyxisHost,
 				cfg.PyxisAPIToken,
 				cfg.CertificationProjectID,
-				&http.Client{Timeout: 60 * time.Second})),
+				transport.HTTPClient(ctx, 60*time.Second))),
 		}, nil
 	case policy.PolicyRoot:
 		return []check.Check{
@@ -750,11 +1686,12 @@ func InitializeContainerChecks(ctx context.Context, p policy.Policy, cfg Contain
 			&containerpol.HasNoProhibitedPackagesCheck{},
 			&containerpol.HasRequiredLabelsCheck{},
 			&containerpol.HasModifiedFilesCheck{},
+			&containerpol.HasNoCaseCollidingFilesCheck{},
 			containerpol.NewBasedOnUbiCheck(pyxis.NewPyxisClient(
 				check.DefaultPyxisHost,
 				cfg.PyxisAPIToken,
 				cfg.CertificationProjectID,
-				&http.Client{Timeout: 60 * time.Second})),
+				transport.HTTPClient(ctx, 60*time.Second))),
 		}, nil
 	case policy.PolicyScratch:
 		return []check.Check{
@@ -763,6 +1700,16 @@ func InitializeContainerChecks(ctx context.Context, p policy.Policy, cfg Contain
 			&containerpol.MaxLayersCheck{},
 			&containerpol.HasRequiredLabelsCheck{},
 			&containerpol.RunAsNonRootCheck{},
+			&containerpol.HasNoCaseCollidingFilesCheck{},
+		}, nil
+	case policy.PolicyWindows:
+		// Windows images have neither RPMs nor a UBI base image, and their users are
+		// not identified by UIDs.
+		return []check.Check{
+			&containerpol.HasLicenseCheck{},
+			containerpol.NewHasUniqueTagCheck(cfg.DockerConfig),
+			&containerpol.MaxLayersCheck{},
+			&containerpol.HasRequiredLabelsCheck{},
 		}, nil
 	}
 
@@ -780,15 +1727,61 @@ func makeCheckList(checks []check.Check) []string {
 	return checkNames
 }
 
-// checkNamesFor produces a slice of names for checks in the requested policy.
-func checkNamesFor(ctx context.Context, p policy.Policy) []string {
-	var c []check.Check
+// PolicyChecks returns the checks in policy p, with the default configuration. They
+// describe the policy, and are not meant to be executed.
+func PolicyChecks(ctx context.Context, p policy.Policy) ([]check.Check, error) {
 	switch p {
-	case policy.PolicyContainer, policy.PolicyRoot, policy.PolicyScratch:
-		c, _ = InitializeContainerChecks(ctx, p, ContainerCheckConfig{})
+	case policy.PolicyContainer, policy.PolicyRoot, policy.PolicyScratch, policy.PolicyWindows:
+		return InitializeContainerChecks(ctx, p, ContainerCheckConfig{})
 	case policy.PolicyOperator:
-		c, _ = InitializeOperatorChecks(ctx, p, OperatorCheckConfig{})
-	default:
+		return InitializeOperatorChecks(ctx, p, OperatorCheckConfig{})
+	}
+
+	return nil, fmt.Errorf("provided policy %s is unknown", p)
+}
+
+// VersionUnreleased is the version that added the checks that are not part of a
+// release of preflight yet.
+const VersionUnreleased = "unreleased"
+
+// versionsAdded are the versions of preflight that added the checks of the policies,
+// by name. Checks that are added to a policy must be added here too, and their version
+// set when they are released.
+var versionsAdded = map[string]string{
+	"HasLicense":                                   "1.0.0",
+	"HasUniqueTag":                                 "1.0.0",
+	"LayerCountAcceptable":                         "1.0.0",
+	"HasNoProhibitedPackages":                      "1.0.0",
+	"HasRequiredLabel":                             "1.0.0",
+	"RunAsNonRoot":                                 "1.0.0",
+	"HasModifiedFiles":                             "1.0.0",
+	"BasedOnUbi":                                   "1.0.0",
+	"ScorecardBasicSpecCheck":                      "1.0.0",
+	"ScorecardOlmSuiteCheck":                       "1.0.0",
+	"DeployableByOLM":                              "1.0.0",
+	"ValidateOperatorBundle":                       "1.0.0",
+	"BundleImageRefsAreCertified":                  "1.1.0",
+	"SecurityContextConstraintsInCSV":              "1.3.0",
+	"AllImageRefsInRelatedImages":                  "1.4.0",
+	"FollowsRestrictedNetworkEnablementGuidelines": "1.5.0",
+	"BundleUsesSupportedAPIs":                      VersionUnreleased,
+	"HasNoCaseCollidingFiles":                      VersionUnreleased,
+}
+
+// VersionAdded returns the version of preflight that added the check named name to
+// its policies, or VersionUnreleased if it is not part of a release yet.
+func VersionAdded(name string) string {
+	if v, ok := versionsAdded[name]; ok {
+		return v
+	}
+
+	return VersionUnreleased
+}
+
+// checkNamesFor produces a slice of names for checks in the requested policy.
+func checkNamesFor(ctx context.Context, p policy.Policy) []string {
+	c, err := PolicyChecks(ctx, p)
+	if err != nil {
 		return []string{}
 	}
 
@@ -816,3 +1809,9 @@ func ScratchContainerPolicy(ctx context.Context) []string {
 func RootExceptionContainerPolicy(ctx context.Context) []string {
 	return checkNamesFor(ctx, policy.PolicyRoot)
 }
+
+// WindowsContainerPolicy returns the names of checks in the
+// container policy for Windows images.
+func WindowsContainerPolicy(ctx context.Context) []string {
+	return checkNamesFor(ctx, policy.PolicyWindows)
+}
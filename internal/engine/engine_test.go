@@ -8,19 +8,34 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"path/filepath"
 	goruntime "runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/artifacts"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/certification"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/clock"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/events"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/image"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/interrupt"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/lockfile"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/mirror"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/policy"
+	containerpol "github.com/redhat-openshift-ecosystem/openshift-preflight/internal/policy/container"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/runtime"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/transport"
 
 	"github.com/google/go-containerregistry/pkg/crane"
 	"github.com/google/go-containerregistry/pkg/registry"
+	cranev1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/empty"
 	"github.com/google/go-containerregistry/pkg/v1/mutate"
 	"github.com/google/go-containerregistry/pkg/v1/random"
@@ -133,6 +148,504 @@ var _ = Describe("Execute Checks tests", func() {
 			Expect(engine.results.Errors).To(HaveLen(1))
 			Expect(engine.results.CertificationHash).To(BeEmpty())
 		})
+		It("should record the digest the image resolved to", func() {
+			err := engine.ExecuteChecks(testcontext)
+			Expect(err).ToNot(HaveOccurred())
+
+			digest, err := crane.Digest(src)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(engine.results.ImageDigest).To(Equal(digest))
+		})
+		It("should fail before pulling the image if the platform is invalid", func() {
+			engine.Platform = "linux/arm/v7/extra"
+			err := engine.ExecuteChecks(testcontext)
+			Expect(err).To(MatchError(ContainSubstring(`invalid platform "linux/arm/v7/extra"`)))
+			Expect(engine.results.ImageDigest).To(BeEmpty())
+		})
+		Context("with an expected digest", func() {
+			It("should execute the checks if the image has the digest", func() {
+				digest, err := crane.Digest(src)
+				Expect(err).ToNot(HaveOccurred())
+
+				engine.ExpectedDigest = digest
+				err = engine.ExecuteChecks(testcontext)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(engine.results.Passed).To(HaveLen(1))
+			})
+
+			It("should fail before executing checks if the image has another digest", func() {
+				engine.ExpectedDigest = "sha256:0000000000000000000000000000000000000000000000000000000000000001"
+				err := engine.ExecuteChecks(testcontext)
+				Expect(err).To(MatchError(ErrDigestMismatch))
+				Expect(engine.results.Passed).To(BeEmpty())
+			})
+		})
+		Context("with a Windows image", func() {
+			var windowsSrc string
+			BeforeEach(func() {
+				img, err := random.Image(1024, 2)
+				Expect(err).ToNot(HaveOccurred())
+				cfg, err := img.ConfigFile()
+				Expect(err).ToNot(HaveOccurred())
+				cfg.OS = image.OSWindows
+				cfg.Architecture = "amd64"
+				img, err = mutate.ConfigFile(img, cfg)
+				Expect(err).ToNot(HaveOccurred())
+
+				windowsSrc = fmt.Sprintf("%s/test/windows", u.Host)
+				Expect(crane.Push(img, windowsSrc)).To(Succeed())
+			})
+
+			It("should execute the checks against the image if it is pulled for a Windows platform", func() {
+				var imgRef image.ImageReference
+				engine.Image = windowsSrc
+				engine.Platform = "windows/amd64"
+				engine.Checks = []check.Check{check.NewGenericCheck(
+					"windowsCheck",
+					func(_ context.Context, ref image.ImageReference) (bool, error) {
+						imgRef = ref
+						return true, nil
+					},
+					check.Metadata{},
+					check.HelpText{},
+				)}
+
+				err := engine.ExecuteChecks(testcontext)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(engine.results.Passed).To(HaveLen(1))
+				Expect(imgRef.IsWindows()).To(BeTrue())
+			})
+
+			It("should fail before executing checks if it is pulled for a Linux platform", func() {
+				engine.Image = windowsSrc
+				err := engine.ExecuteChecks(testcontext)
+				Expect(err).To(MatchError(ErrOSMismatch))
+				Expect(err).To(MatchError(ContainSubstring("set the platform to windows/amd64")))
+				Expect(engine.results.Passed).To(BeEmpty())
+			})
+
+			It("should fail before executing checks if a Linux image is pulled for a Windows platform", func() {
+				engine.Platform = "windows/amd64"
+				err := engine.ExecuteChecks(testcontext)
+				Expect(err).To(MatchError(ErrOSMismatch))
+				Expect(engine.results.Passed).To(BeEmpty())
+			})
+		})
+		Context("with a step results channel in the context", func() {
+			It("should send each non-optional check result as it completes", func() {
+				steps := make(chan certification.StepResult, len(engine.Checks))
+				err := engine.ExecuteChecks(certification.ContextWithStepResults(testcontext, steps))
+				Expect(err).ToNot(HaveOccurred())
+				close(steps)
+
+				statuses := map[string]certification.Status{}
+				for step := range steps {
+					statuses[step.Name()] = step.Status
+				}
+				Expect(statuses).To(Equal(map[string]certification.Status{
+					"testcheck":   certification.StatusPassed,
+					"errorCheck":  certification.StatusErrored,
+					"failedCheck": certification.StatusFailed,
+				}))
+			})
+		})
+		Context("with the deterministic clock in the context", func() {
+			It("should record zero durations and start times for every check", func() {
+				err := engine.ExecuteChecks(clock.ContextWithClock(testcontext, clock.Deterministic()))
+				Expect(err).ToNot(HaveOccurred())
+				results := engine.Results(testcontext)
+				for _, r := range append(append(results.Passed, results.Failed...), results.Errors...) {
+					Expect(r.ElapsedTime).To(BeZero())
+					Expect(r.StartTime).To(BeZero())
+				}
+			})
+		})
+		It("should record when each check started", func() {
+			before := time.Now()
+			err := engine.ExecuteChecks(testcontext)
+			Expect(err).ToNot(HaveOccurred())
+			results := engine.Results(testcontext)
+			for _, r := range append(append(results.Passed, results.Failed...), results.Errors...) {
+				Expect(r.StartTime).To(BeTemporally(">=", before))
+				Expect(r.StartTime).To(BeTemporally("<=", time.Now()))
+			}
+		})
+		Context("with a lockfile in the context", func() {
+			var lockPath string
+			BeforeEach(func() {
+				lockPath = filepath.Join(GinkgoT().TempDir(), "preflight.lock")
+			})
+
+			It("should fail before executing checks if the digest of the image differs from its pin", func() {
+				Expect(os.WriteFile(lockPath, []byte("images:\n  "+src+":\n    digest: sha256:0000000000000000000000000000000000000000000000000000000000000001\n"), 0o644)).To(Succeed())
+				lock, err := lockfile.Load(lockPath)
+				Expect(err).ToNot(HaveOccurred())
+
+				engine.Lockfile = lock
+				err = engine.ExecuteChecks(testcontext)
+				Expect(err).To(MatchError(lockfile.ErrDigestChanged))
+				Expect(engine.Results(testcontext).Passed).To(BeEmpty())
+			})
+
+			It("should pin the digest of the image when the lockfile is updated", func() {
+				lock, err := lockfile.Load(lockPath, lockfile.WithUpdate())
+				Expect(err).ToNot(HaveOccurred())
+
+				engine.Lockfile = lock
+				err = engine.ExecuteChecks(testcontext)
+				Expect(err).ToNot(HaveOccurred())
+
+				digest, err := crane.Digest(src)
+				Expect(err).ToNot(HaveOccurred())
+				written, err := lockfile.Load(lockPath)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(written.Images).To(HaveKeyWithValue(src, lockfile.Pin{Digest: digest}))
+			})
+		})
+		Context("with fail fast", func() {
+			It("should stop executing checks after the first check that does not pass", func() {
+				engine.FailFast = true
+				err := engine.ExecuteChecks(testcontext)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(engine.results.Passed).To(HaveLen(1))
+				Expect(engine.results.Errors).To(HaveLen(1))
+				Expect(engine.results.Failed).To(BeEmpty())
+				Expect(engine.results.PassedOverall).To(BeFalse())
+			})
+		})
+		Context("when preflight is interrupted", func() {
+			It("should skip the check that was interrupted and the remaining checks", func() {
+				ctx, cancel := interrupt.WithInterrupt(testcontext)
+				defer cancel()
+				interrupting := check.NewGenericCheck(
+					"interruptingCheck",
+					func(ctx context.Context, _ image.ImageReference) (bool, error) {
+						cancel()
+						return false, ctx.Err()
+					},
+					check.Metadata{},
+					check.HelpText{},
+				)
+				engine.Checks = append([]check.Check{engine.Checks[0], interrupting}, engine.Checks[1:]...)
+
+				err := engine.ExecuteChecks(ctx)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(engine.results.Passed).To(HaveLen(1))
+				Expect(engine.results.Errors).To(BeEmpty())
+				Expect(engine.results.Failed).To(BeEmpty())
+				Expect(engine.results.Skipped).To(HaveLen(3))
+				Expect(engine.results.Skipped).To(HaveEach(HaveField("Reason", interrupt.ErrInterrupted.Error())))
+				Expect(engine.results.Skipped[0].Name()).To(Equal("interruptingCheck"))
+				Expect(engine.results.PassedOverall).To(BeFalse())
+			})
+		})
+		Context("with check timeouts in the context", func() {
+			var hanging *hangingCheck
+
+			BeforeEach(func() {
+				hanging = &hangingCheck{Check: check.NewGenericCheck("hangingCheck", nil, check.Metadata{}, check.HelpText{}), release: make(chan struct{})}
+				DeferCleanup(func() { close(hanging.release) })
+				engine.Checks = append(engine.Checks, hanging)
+
+				grace := timeoutGracePeriod
+				timeoutGracePeriod = 10 * time.Millisecond
+				DeferCleanup(func() { timeoutGracePeriod = grace })
+			})
+
+			It("should error a check that does not complete within its timeout, and report it is still running", func() {
+				engine.Timeouts = check.Timeouts{Checks: map[string]time.Duration{"hangingCheck": 10 * time.Millisecond}}
+				err := engine.ExecuteChecks(testcontext)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(engine.results.Errors).To(ContainElement(And(
+					HaveField("Check", hanging),
+					HaveField("Reason", And(
+						ContainSubstring("hangingCheck did not complete within 10ms"),
+						ContainSubstring("is still running"),
+					)),
+				)))
+				Expect(engine.results.Passed).To(HaveLen(1))
+			})
+
+			It("should cancel a check that times out, and wait for it to stop", func() {
+				timeoutGracePeriod = time.Minute
+				cancelled := &cancellableCheck{Check: check.NewGenericCheck("cancellableCheck", nil, check.Metadata{}, check.HelpText{})}
+				engine.Checks = []check.Check{cancelled}
+
+				engine.Timeouts = check.Timeouts{Default: 10 * time.Millisecond}
+				err := engine.ExecuteChecks(testcontext)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cancelled.stopped).To(BeTrue())
+				Expect(engine.results.Errors).To(ConsistOf(HaveField("Reason", And(
+					ContainSubstring("cancellableCheck did not complete within 10ms"),
+					Not(ContainSubstring("is still running")),
+				))))
+			})
+
+			It("should not limit the checks with a timeout they complete within", func() {
+				engine.Timeouts = check.Timeouts{Default: time.Minute, Checks: map[string]time.Duration{"hangingCheck": 10 * time.Millisecond}}
+				err := engine.ExecuteChecks(testcontext)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(engine.results.Passed).To(ContainElement(HaveField("Check.Name()", "testcheck")))
+				Expect(engine.results.Failed).To(ContainElement(HaveField("Check.Name()", "failedCheck")))
+			})
+		})
+		Context("with an egress policy in the context", func() {
+			var networked *networkCheck
+
+			BeforeEach(func() {
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusOK)
+				}))
+				DeferCleanup(server.Close)
+
+				networked = &networkCheck{Check: check.NewGenericCheck("networkCheck", nil, check.Metadata{}, check.HelpText{}), url: server.URL}
+				engine.Checks = append(engine.Checks, networked)
+			})
+
+			It("should error the checks that are denied access to the network, and report their connections", func() {
+				err := engine.ExecuteChecks(transport.ContextWithEgressPolicy(testcontext, transport.NewEgressPolicy()))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(engine.results.Errors).To(ContainElement(And(
+					HaveField("Check", networked),
+					HaveField("Reason", ContainSubstring("check networkCheck may not connect to")),
+				)))
+				Expect(engine.results.DeniedConnections).To(ConsistOf(HaveField("Check", "networkCheck")))
+			})
+
+			It("should not deny the checks that are allowed to access the network", func() {
+				err := engine.ExecuteChecks(transport.ContextWithEgressPolicy(testcontext, transport.NewEgressPolicy("networkCheck")))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(engine.results.Passed).To(ContainElement(HaveField("Check", networked)))
+				Expect(engine.results.DeniedConnections).To(BeEmpty())
+			})
+		})
+		Context("with a layer cache", func() {
+			It("should keep the layers of the image in the cache", func() {
+				dir := filepath.Join(GinkgoT().TempDir(), "layers")
+				engine.LayerCache = dir
+				err := engine.ExecuteChecks(testcontext)
+				Expect(err).ToNot(HaveOccurred())
+
+				entries, err := os.ReadDir(dir)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(entries).ToNot(BeEmpty())
+			})
+		})
+		Context("with a scratch directory", func() {
+			It("should extract the image to it, and remove what it extracted", func() {
+				dir := GinkgoT().TempDir()
+				engine.ScratchDir = dir
+				err := engine.ExecuteChecks(testcontext)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(engine.imageRef.ImageFSPath).To(HavePrefix(dir))
+
+				entries, err := os.ReadDir(dir)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(entries).To(BeEmpty())
+			})
+
+			It("should fail before extracting the image if the directory does not exist", func() {
+				dir := filepath.Join(GinkgoT().TempDir(), "missing")
+				engine.ScratchDir = dir
+				err := engine.ExecuteChecks(testcontext)
+				Expect(err).To(MatchError(ContainSubstring("could not read the free space of " + dir)))
+				Expect(engine.results.Passed).To(BeEmpty())
+			})
+		})
+		Context("when estimating the scratch space needed", func() {
+			var img cranev1.Image
+			var compressed int64
+			BeforeEach(func() {
+				var err error
+				img, err = random.Image(1024, 2)
+				Expect(err).ToNot(HaveOccurred())
+				layers, err := img.Layers()
+				Expect(err).ToNot(HaveOccurred())
+				compressed = 0
+				for _, layer := range layers {
+					size, err := layer.Size()
+					Expect(err).ToNot(HaveOccurred())
+					compressed += size
+				}
+			})
+
+			It("should need twice the layers if the whole filesystem is extracted", func() {
+				needed, err := engine.scratchSpace(testcontext, img)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(needed).To(Equal(2 * compressed))
+			})
+
+			It("should only need the layers if checks only read some paths", func() {
+				engine.Checks = []check.Check{&filesystemCheck{Check: engine.Checks[0], paths: []string{"/licenses"}}}
+				needed, err := engine.scratchSpace(testcontext, img)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(needed).To(Equal(compressed))
+			})
+
+			It("should not need the layers if they are cached elsewhere", func() {
+				engine.LayerCache = GinkgoT().TempDir()
+				needed, err := engine.scratchSpace(testcontext, img)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(needed).To(Equal(compressed))
+			})
+		})
+		Context("with an event listener in the context", func() {
+			It("should emit started and finished events for every non-optional check", func() {
+				listener := &recordingListener{}
+				err := engine.ExecuteChecks(events.ContextWithListener(testcontext, listener))
+				Expect(err).ToNot(HaveOccurred())
+
+				started := map[string]bool{}
+				finished := map[string]string{}
+				for _, e := range listener.events {
+					switch e.Type {
+					case events.TypeCheckStarted:
+						started[e.Check] = true
+					case events.TypeCheckFinished:
+						finished[e.Check] = e.Result
+					}
+				}
+				Expect(started).To(HaveLen(3))
+				Expect(finished).To(HaveKeyWithValue("testcheck", "PASSED"))
+				Expect(finished).To(HaveKeyWithValue("errorCheck", "ERROR"))
+				Expect(finished).To(HaveKeyWithValue("failedCheck", "FAILED"))
+				Expect(started).ToNot(HaveKey("optionalCheckFailing"))
+				Expect(finished).ToNot(HaveKey("optionalCheckPassing"))
+				Expect(finished).ToNot(HaveKey("optionalCheckFailing"))
+			})
+		})
+		Context("with trace on failure", func() {
+			It("should write a trace log for every failed and errored check", func() {
+				listener := &recordingListener{}
+				engine.TraceOnFailure = true
+				ctx := events.ContextWithListener(testcontext, listener)
+				err := engine.ExecuteChecks(ctx)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(engine.results.Failed).To(HaveLen(1))
+				Expect(engine.results.Errors).To(HaveLen(1))
+
+				aw, ok := artifacts.WriterFromContext(testcontext).(*artifacts.FilesystemWriter)
+				Expect(ok).To(BeTrue())
+
+				contents, err := os.ReadFile(filepath.Join(aw.Path(), "errorCheck"+TraceFilenameSuffix))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(contents)).To(ContainSubstring(`"result"="ERROR"`))
+
+				contents, err = os.ReadFile(filepath.Join(aw.Path(), "failedCheck"+TraceFilenameSuffix))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(contents)).To(ContainSubstring(`"result"="FAILED"`))
+
+				Expect(filepath.Join(aw.Path(), "testcheck"+TraceFilenameSuffix)).ToNot(BeAnExistingFile())
+
+				finished := 0
+				for _, e := range listener.events {
+					if e.Type == events.TypeCheckFinished {
+						finished++
+					}
+				}
+				Expect(finished).To(Equal(3))
+			})
+		})
+		Context("with check attempts", func() {
+			var ctx context.Context
+
+			BeforeEach(func() {
+				ctx = testcontext
+				engine.Attempts = 3
+			})
+
+			It("should execute a retryable check again until it passes, and only write the artifacts of the last attempt", func() {
+				flaky := &flakyCheck{Check: check.NewGenericCheck("flakyCheck", nil, check.Metadata{}, check.HelpText{}), passAfter: 2}
+				engine.Checks = append(engine.Checks, flaky)
+				err := engine.ExecuteChecks(ctx)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(flaky.executed).To(Equal(2))
+				Expect(engine.results.Passed).To(ContainElement(And(HaveField("Check", flaky), HaveField("Attempts", 2))))
+
+				aw, ok := artifacts.WriterFromContext(testcontext).(*artifacts.FilesystemWriter)
+				Expect(ok).To(BeTrue())
+				Expect(os.ReadFile(filepath.Join(aw.Path(), "flakyCheck.txt"))).To(Equal([]byte("2")))
+			})
+
+			It("should report a retryable check that does not pass on any attempt", func() {
+				flaky := &flakyCheck{Check: check.NewGenericCheck("flakyCheck", nil, check.Metadata{}, check.HelpText{}), passAfter: 4}
+				engine.Checks = append(engine.Checks, flaky)
+				err := engine.ExecuteChecks(ctx)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(flaky.executed).To(Equal(3))
+				Expect(engine.results.Failed).To(ContainElement(And(HaveField("Check", flaky), HaveField("Attempts", 3))))
+			})
+
+			It("should not execute checks that are not retryable again", func() {
+				err := engine.ExecuteChecks(ctx)
+				Expect(err).ToNot(HaveOccurred())
+				for _, r := range append(append(engine.results.Passed, engine.results.Failed...), engine.results.Errors...) {
+					Expect(r.Attempts).To(Equal(1))
+				}
+			})
+		})
+		Context("with checks that lose the connection to the cluster", func() {
+			var lost, next *clusterCheck
+
+			BeforeEach(func() {
+				backoff := reconnectBackoff
+				reconnectBackoff = []time.Duration{0, 0}
+				DeferCleanup(func() { reconnectBackoff = backoff })
+
+				lost = &clusterCheck{Check: check.NewGenericCheck("lostCheck", nil, check.Metadata{}, check.HelpText{}), failures: 1}
+				next = &clusterCheck{Check: check.NewGenericCheck("nextCheck", nil, check.Metadata{}, check.HelpText{})}
+				engine.Checks = append(engine.Checks, lost, next)
+			})
+
+			It("should execute the check again once the cluster is reachable", func() {
+				probes := 0
+				engine.probeCluster = func(context.Context) error {
+					probes++
+					if probes < 2 {
+						return syscall.ECONNREFUSED
+					}
+					return nil
+				}
+				err := engine.ExecuteChecks(testcontext)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(probes).To(Equal(2))
+				Expect(engine.results.Passed).To(ContainElement(And(HaveField("Check", lost), HaveField("Attempts", 2))))
+				Expect(engine.results.Passed).To(ContainElement(HaveField("Check", next)))
+			})
+
+			It("should error the remaining checks that require the cluster if it stays unreachable", func() {
+				engine.probeCluster = func(context.Context) error {
+					return syscall.ECONNREFUSED
+				}
+				err := engine.ExecuteChecks(testcontext)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lost.executed).To(Equal(1))
+				Expect(next.executed).To(Equal(0))
+				Expect(engine.results.Errors).To(ContainElements(
+					And(HaveField("Check", lost), HaveField("Reason", ContainSubstring("lost the connection to the cluster"))),
+					And(HaveField("Check", next), HaveField("Reason", ContainSubstring("connection refused"))),
+				))
+				Expect(engine.results.Passed).To(ContainElement(HaveField("Check.Name()", "testcheck")))
+			})
+		})
+		Context("with a check that panics", func() {
+			It("should error the check, and execute the remaining checks", func() {
+				panicking := check.NewGenericCheck(
+					"panickingCheck",
+					func(context.Context, image.ImageReference) (bool, error) {
+						var ref *image.ImageReference
+						return ref.ImageURI != "", nil
+					},
+					check.Metadata{},
+					check.HelpText{},
+				)
+				engine.Checks = append([]check.Check{panicking}, engine.Checks...)
+				err := engine.ExecuteChecks(testcontext)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(engine.results.Errors).To(ContainElement(HaveField("Check", panicking)))
+				Expect(engine.results.Passed).To(ContainElement(HaveField("Check.Name()", "testcheck")))
+			})
+		})
 		Context("it is a bundle", func() {
 			It("should succeed and generate a bundle hash", func() {
 				engine.IsBundle = true
@@ -141,6 +654,150 @@ var _ = Describe("Execute Checks tests", func() {
 				Expect(engine.results.CertificationHash).ToNot(BeEmpty())
 			})
 		})
+		Context("the image has a foreign layer", func() {
+			BeforeEach(func() {
+				var buf bytes.Buffer
+
+				err := writeTarball(&buf, []byte("mycontent"), "myfile", 10)
+				Expect(err).ToNot(HaveOccurred())
+
+				foreign := static.NewLayer([]byte("foreign"), types.DockerForeignLayer)
+				layer := static.NewLayer(buf.Bytes(), types.DockerUncompressedLayer)
+				img, err := mutate.AppendLayers(empty.Image, foreign, layer)
+				Expect(err).ToNot(HaveOccurred())
+
+				err = crane.Push(img, src)
+				Expect(err).ToNot(HaveOccurred())
+			})
+			It("should skip the foreign layer and record it in the results", func() {
+				err := engine.ExecuteChecks(testcontext)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(engine.results.Passed).To(HaveLen(1))
+				Expect(engine.results.SkippedLayers).To(HaveLen(1))
+				Expect(engine.results.SkippedLayers[0].MediaType).To(Equal(string(types.DockerForeignLayer)))
+				Expect(engine.results.SkippedLayers[0].Reason).To(Equal(image.ReasonForeignLayer))
+			})
+		})
+		Context("the image only has unsupported layers", func() {
+			BeforeEach(func() {
+				foreign := static.NewLayer([]byte("foreign"), types.DockerForeignLayer)
+				img, err := mutate.AppendLayers(empty.Image, foreign)
+				Expect(err).ToNot(HaveOccurred())
+
+				err = crane.Push(img, src)
+				Expect(err).ToNot(HaveOccurred())
+			})
+			It("should return an error", func() {
+				err := engine.ExecuteChecks(testcontext)
+				Expect(err).To(MatchError(ContainSubstring("no layers with a supported media type")))
+			})
+		})
+		Context("with checks that declare the paths of the filesystem they read", func() {
+			var fsCheck *filesystemCheck
+			BeforeEach(func() {
+				var buf bytes.Buffer
+				tw := tar.NewWriter(&buf)
+				for _, f := range []struct {
+					name, linkname string
+					contents       []byte
+				}{
+					{name: "licenses/LICENSE", contents: []byte("license")},
+					{name: "opt/app/data", contents: bytes.Repeat([]byte("a"), 1024)},
+					{name: "usr/lib/sysimage/rpm/Packages", contents: []byte("packages")},
+					{name: "var/lib/rpm", linkname: "../../usr/lib/sysimage/rpm"},
+				} {
+					header := &tar.Header{Typeflag: tar.TypeReg, Name: f.name, Size: int64(len(f.contents)), Mode: 0o644}
+					if f.linkname != "" {
+						header = &tar.Header{Typeflag: tar.TypeSymlink, Name: f.name, Linkname: f.linkname, Mode: 0o777}
+					}
+					Expect(tw.WriteHeader(header)).To(Succeed())
+					_, err := tw.Write(f.contents)
+					Expect(err).ToNot(HaveOccurred())
+				}
+				Expect(tw.Close()).To(Succeed())
+
+				img, err := mutate.AppendLayers(empty.Image, static.NewLayer(buf.Bytes(), types.DockerUncompressedLayer))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(crane.Push(img, src)).To(Succeed())
+
+				fsCheck = &filesystemCheck{
+					Check:  check.NewGenericCheck("filesystemCheck", nil, check.Metadata{}, check.HelpText{}),
+					paths:  []string{"/licenses", "/var/lib/rpm"},
+					probes: []string{"licenses/LICENSE", "opt/app/data", "var/lib/rpm/Packages"},
+				}
+				// The RPM database of the image is not a valid one.
+				engine.IsScratch = true
+			})
+
+			It("should only extract those paths, through links, and record every entry", func() {
+				engine.Checks = []check.Check{fsCheck}
+				err := engine.ExecuteChecks(testcontext)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(engine.results.Passed).To(HaveLen(1))
+				Expect(fsCheck.extracted).To(Equal(map[string]bool{
+					"licenses/LICENSE":     true,
+					"opt/app/data":         false,
+					"var/lib/rpm/Packages": true,
+				}))
+				Expect(fsCheck.files).To(ContainElement(image.File{Path: "/opt/app/data", Mode: 0o644, Size: 1024}))
+				Expect(fsCheck.files).To(ContainElement(image.File{Path: "/var/lib/rpm", Mode: os.ModeSymlink | 0o777, Linkname: "../../usr/lib/sysimage/rpm"}))
+			})
+
+			It("should extract the whole filesystem if another check may read any path", func() {
+				engine.Checks = []check.Check{fsCheck, engine.Checks[0]}
+				err := engine.ExecuteChecks(testcontext)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(fsCheck.extracted).To(HaveKeyWithValue("opt/app/data", true))
+			})
+		})
+		Context("with an image whose paths differ only by case", func() {
+			BeforeEach(func() {
+				var buf bytes.Buffer
+				tw := tar.NewWriter(&buf)
+				for _, name := range []string{"opt/app/Config", "opt/app/config"} {
+					Expect(tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Size: 6, Mode: 0o644})).To(Succeed())
+					_, err := tw.Write([]byte("config"))
+					Expect(err).ToNot(HaveOccurred())
+				}
+				Expect(tw.Close()).To(Succeed())
+
+				img, err := mutate.AppendLayers(empty.Image, static.NewLayer(buf.Bytes(), types.DockerUncompressedLayer))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(crane.Push(img, src)).To(Succeed())
+				engine.IsScratch = true
+			})
+
+			It("should report the collision without failing certification", func() {
+				collisions := &outcomeCheck{Check: &containerpol.HasNoCaseCollidingFilesCheck{}}
+				engine.Checks = []check.Check{collisions}
+				err := engine.ExecuteChecks(testcontext)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(collisions.executed).To(BeTrue())
+				Expect(collisions.passed).To(BeFalse())
+				Expect(engine.results.Failed).To(BeEmpty())
+				Expect(engine.results.PassedOverall).To(BeTrue())
+			})
+		})
+		Context("with registry mirrors", func() {
+			It("should pull the image from the mirror", func() {
+				engine.Image = "registry.example.com/test/crane:latest"
+				engine.Mirrors = []mirror.Mirror{
+					{Source: "registry.example.com", Mirrors: []string{u.Host}},
+				}
+				err := engine.ExecuteChecks(testcontext)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(engine.results.TestedImage).To(Equal("registry.example.com/test/crane:latest"))
+				Expect(engine.results.Passed).To(HaveLen(1))
+			})
+			It("should not pull the image from the source if it is never contacted", func() {
+				engine.Image = src
+				engine.Mirrors = []mirror.Mirror{
+					{Source: u.Host, NeverContactSource: true},
+				}
+				err := engine.ExecuteChecks(testcontext)
+				Expect(err).To(MatchError(ContainSubstring("no mirror of")))
+			})
+		})
 		Context("the image is invalid", func() {
 			It("should throw a crane error on pull", func() {
 				engine.Image = "does.not/exist/anywhere:ever"
@@ -220,6 +877,91 @@ var _ = Describe("Execute Checks tests", func() {
 	})
 })
 
+var _ = Describe("Extracting the paths of a tarball", func() {
+	type entry struct {
+		name, linkname, contents string
+	}
+	tarball := func(entries ...entry) io.Reader {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		for _, e := range entries {
+			header := &tar.Header{Typeflag: tar.TypeReg, Name: e.name, Size: int64(len(e.contents)), Mode: 0o644}
+			if e.linkname != "" {
+				header = &tar.Header{Typeflag: tar.TypeSymlink, Name: e.name, Linkname: e.linkname, Mode: 0o777}
+			}
+			Expect(tw.WriteHeader(header)).To(Succeed())
+			_, err := tw.Write([]byte(e.contents))
+			Expect(err).ToNot(HaveOccurred())
+		}
+		Expect(tw.Close()).To(Succeed())
+		return &buf
+	}
+
+	It("should extract the targets of links that precede them in one pass", func() {
+		dst := GinkgoT().TempDir()
+		_, missed, err := untar(context.Background(), dst, tarball(
+			entry{name: "var/lib/rpm", linkname: "../../usr/lib/sysimage/rpm"},
+			entry{name: "usr/lib/sysimage/rpm/Packages", contents: "packages"},
+		), []string{"/var/lib/rpm"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(missed).To(BeEmpty())
+		Expect(filepath.Join(dst, "var/lib/rpm/Packages")).To(BeARegularFile())
+	})
+
+	It("should return the targets of links that follow them", func() {
+		dst := GinkgoT().TempDir()
+		files, missed, err := untar(context.Background(), dst, tarball(
+			entry{name: "usr/lib/sysimage/rpm/Packages", contents: "packages"},
+			entry{name: "var/lib/rpm", linkname: "../../usr/lib/sysimage/rpm"},
+		), []string{"/var/lib/rpm"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(files).To(HaveLen(2))
+		Expect(missed).To(Equal([]string{"/usr/lib/sysimage/rpm"}))
+		Expect(filepath.Join(dst, "usr/lib/sysimage/rpm/Packages")).ToNot(BeAnExistingFile())
+	})
+
+	It("should overwrite the files extracted in a previous pass", func() {
+		dst := GinkgoT().TempDir()
+		_, _, err := untar(context.Background(), dst, tarball(entry{name: "licenses/LICENSE", contents: "a longer license"}), nil)
+		Expect(err).ToNot(HaveOccurred())
+		_, _, err = untar(context.Background(), dst, tarball(entry{name: "licenses/LICENSE", contents: "license"}), nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		contents, err := os.ReadFile(filepath.Join(dst, "licenses/LICENSE"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).To(Equal("license"))
+	})
+})
+
+var _ = Describe("Targets of links", func() {
+	It("should be the targets of the links that are, or are under, the paths", func() {
+		files := []image.File{
+			{Path: "/licenses/LICENSE", Mode: os.ModeSymlink, Linkname: "/usr/share/licenses/app/LICENSE"},
+			{Path: "/var/lib/rpm", Mode: os.ModeSymlink, Linkname: "../../usr/lib/sysimage/rpm"},
+			{Path: "/usr/lib/sysimage/rpm", Mode: os.ModeDir},
+			{Path: "/opt/app/link", Mode: os.ModeSymlink, Linkname: "/etc/passwd"},
+		}
+		Expect(linkTargets(files, []string{"/licenses", "/var/lib/rpm"})).To(Equal([]string{"/usr/share/licenses/app/LICENSE", "/usr/lib/sysimage/rpm"}))
+	})
+
+	It("should be the links that are parent directories of the paths, and the paths through them", func() {
+		files := []image.File{
+			{Path: "/var/lib", Mode: os.ModeSymlink, Linkname: "/usr/lib"},
+			{Path: "/usr/lib/rpm", Mode: os.ModeSymlink, Linkname: "sysimage/rpm"},
+		}
+		Expect(linkTargets(files, []string{"/var/lib/rpm"})).To(Equal([]string{"/usr/lib/rpm", "/usr/lib/sysimage/rpm", "/var/lib"}))
+	})
+
+	It("should be empty if the links point under the paths, or the whole filesystem is extracted", func() {
+		files := []image.File{
+			{Path: "/var/lib/rpm/Packages", Mode: os.ModeSymlink, Linkname: "Packages.db"},
+			{Path: "/var/lib/rpm/loop", Mode: os.ModeSymlink, Linkname: "loop"},
+		}
+		Expect(linkTargets(files, []string{"/var/lib/rpm"})).To(BeEmpty())
+		Expect(linkTargets(files, nil)).To(BeEmpty())
+	})
+})
+
 var _ = Describe("Source RPM name function", func() {
 	Context("With a source rpm name", func() {
 		Context("And a normal source rpm name", func() {
@@ -290,6 +1032,17 @@ var _ = Describe("Check Initialization", func() {
 			_, err := InitializeContainerChecks(context.TODO(), policy.Policy("foo"), ContainerCheckConfig{})
 			Expect(err).To(HaveOccurred())
 		})
+		It("should only add the approved base image check when approved base images are configured", func() {
+			checks, err := InitializeContainerChecks(context.TODO(), policy.PolicyContainer, ContainerCheckConfig{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(makeCheckList(checks)).ToNot(ContainElement("BasedOnApprovedBaseImage"))
+
+			checks, err = InitializeContainerChecks(context.TODO(), policy.PolicyContainer, ContainerCheckConfig{
+				ApprovedBaseImages: []string{"registry.access.redhat.com/ubi9/ubi"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(makeCheckList(checks)).To(ContainElement("BasedOnApprovedBaseImage"))
+		})
 	})
 
 	When("initializing operator checks", func() {
@@ -301,6 +1054,13 @@ var _ = Describe("Check Initialization", func() {
 			_, err := InitializeOperatorChecks(context.TODO(), policy.Policy("bar"), OperatorCheckConfig{})
 			Expect(err).To(HaveOccurred())
 		})
+		It("should replace the scorecard checks when basic operability is configured", func() {
+			checks, err := InitializeOperatorChecks(context.TODO(), policy.PolicyOperator, OperatorCheckConfig{BasicOperability: true})
+			Expect(err).ToNot(HaveOccurred())
+			names := makeCheckList(checks)
+			Expect(names).To(ContainElement("BasicOperabilityCheck"))
+			Expect(names).ToNot(ContainElements("ScorecardBasicSpecCheck", "ScorecardOlmSuiteCheck"))
+		})
 	})
 })
 
@@ -319,6 +1079,7 @@ var _ = Describe("Check Name Queries", func() {
 			"HasRequiredLabel",
 			"RunAsNonRoot",
 			"HasModifiedFiles",
+			"HasNoCaseCollidingFiles",
 			"BasedOnUbi",
 		}),
 		Entry("default operator policy", OperatorPolicy, []string{
@@ -326,6 +1087,7 @@ var _ = Describe("Check Name Queries", func() {
 			"ScorecardOlmSuiteCheck",
 			"DeployableByOLM",
 			"ValidateOperatorBundle",
+			"BundleUsesSupportedAPIs",
 			"BundleImageRefsAreCertified",
 			"SecurityContextConstraintsInCSV",
 			"AllImageRefsInRelatedImages",
@@ -337,6 +1099,13 @@ var _ = Describe("Check Name Queries", func() {
 			"LayerCountAcceptable",
 			"HasRequiredLabel",
 			"RunAsNonRoot",
+			"HasNoCaseCollidingFiles",
+		}),
+		Entry("windows container policy", WindowsContainerPolicy, []string{
+			"HasLicense",
+			"HasUniqueTag",
+			"LayerCountAcceptable",
+			"HasRequiredLabel",
 		}),
 		Entry("root container policy", RootExceptionContainerPolicy, []string{
 			"HasLicense",
@@ -345,6 +1114,7 @@ var _ = Describe("Check Name Queries", func() {
 			"HasNoProhibitedPackages",
 			"HasRequiredLabel",
 			"HasModifiedFiles",
+			"HasNoCaseCollidingFiles",
 			"BasedOnUbi",
 		}),
 	)
@@ -355,6 +1125,16 @@ var _ = Describe("Check Name Queries", func() {
 			Expect(c).To(Equal([]string{}))
 		})
 	})
+
+	It("should know the version that added every check of the policies", func() {
+		for _, p := range []policy.Policy{policy.PolicyOperator, policy.PolicyContainer, policy.PolicyRoot, policy.PolicyScratch, policy.PolicyWindows} {
+			for _, name := range checkNamesFor(context.TODO(), p) {
+				Expect(versionsAdded).To(HaveKey(name))
+			}
+		}
+		Expect(VersionAdded("HasLicense")).To(Equal("1.0.0"))
+		Expect(VersionAdded("NotACheck")).To(Equal(VersionUnreleased))
+	})
 })
 
 // writeTarball writes a tar archive to out with filename containing contents at the base path
@@ -392,3 +1172,185 @@ func writeTarball(out io.Writer, contents []byte, filename string, extraBytes ui
 
 	return nil
 }
+
+type recordingListener struct {
+	mu     sync.Mutex
+	events []events.Event
+}
+
+func (l *recordingListener) OnEvent(e events.Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, e)
+}
+
+// filesystemCheck is a check that reads paths of the filesystem, and records which of
+// probes were extracted, and the entries of the image, as it passes.
+type filesystemCheck struct {
+	check.Check
+	paths     []string
+	probes    []string
+	extracted map[string]bool
+	files     []image.File
+}
+
+func (c *filesystemCheck) Validate(_ context.Context, imgRef image.ImageReference) (bool, error) {
+	c.extracted = map[string]bool{}
+	for _, probe := range c.probes {
+		_, err := os.Stat(filepath.Join(imgRef.ImageFSPath, probe))
+		c.extracted[probe] = err == nil
+	}
+	c.files = imgRef.Files
+	return true, nil
+}
+
+func (c *filesystemCheck) FilesystemPaths() []string {
+	return c.paths
+}
+
+// outcomeCheck records the outcome of the check it wraps.
+type outcomeCheck struct {
+	check.Check
+	executed bool
+	passed   bool
+}
+
+func (c *outcomeCheck) Validate(ctx context.Context, imgRef image.ImageReference) (bool, error) {
+	c.executed = true
+	passed, err := c.Check.Validate(ctx, imgRef)
+	c.passed = passed
+	return passed, err
+}
+
+// flakyCheck is a Retryable check that fails until it is executed passAfter times. Every
+// execution writes the number of executions so far as an artifact.
+type flakyCheck struct {
+	check.Check
+	passAfter int
+	executed  int
+}
+
+func (c *flakyCheck) Validate(ctx context.Context, _ image.ImageReference) (bool, error) {
+	c.executed++
+	if _, err := artifacts.WriterFromContext(ctx).WriteFile(c.Name()+".txt", strings.NewReader(fmt.Sprint(c.executed))); err != nil {
+		return false, err
+	}
+	return c.executed >= c.passAfter, nil
+}
+
+func (c *flakyCheck) Retryable() bool {
+	return true
+}
+
+// hangingCheck is a check that does not complete until it is released, regardless of
+// its context.
+type hangingCheck struct {
+	check.Check
+	release chan struct{}
+}
+
+func (c *hangingCheck) Validate(context.Context, image.ImageReference) (bool, error) {
+	<-c.release
+	return true, nil
+}
+
+// cancellableCheck is a check that does not complete until its context is done, and
+// records that it stopped before it returns.
+type cancellableCheck struct {
+	check.Check
+	stopped bool
+}
+
+func (c *cancellableCheck) Validate(ctx context.Context, _ image.ImageReference) (bool, error) {
+	<-ctx.Done()
+	time.Sleep(10 * time.Millisecond)
+	c.stopped = true
+	return false, ctx.Err()
+}
+
+// networkCheck is a check that passes if it can request url.
+type networkCheck struct {
+	check.Check
+	url string
+}
+
+func (c *networkCheck) Validate(ctx context.Context, _ image.ImageReference) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := transport.HTTPClient(ctx, time.Minute).Do(req)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK, nil
+}
+
+// clusterCheck is a check that requires the cluster, and loses the connection to it the
+// first failures times it is executed.
+type clusterCheck struct {
+	check.Check
+	failures int
+	executed int
+}
+
+func (c *clusterCheck) Validate(context.Context, image.ImageReference) (bool, error) {
+	c.executed++
+	if c.executed <= c.failures {
+		return false, &url.Error{Op: "Get", URL: "https://api.example.com:6443/api", Err: syscall.ECONNREFUSED}
+	}
+	return true, nil
+}
+
+func (c *clusterCheck) RequiresCluster() bool {
+	return true
+}
+
+var _ = Describe("Check provenance", func() {
+	It("should be described by every check in every policy", func() {
+		for _, p := range []policy.Policy{policy.PolicyContainer, policy.PolicyRoot, policy.PolicyScratch, policy.PolicyWindows, policy.PolicyOperator} {
+			checks, err := PolicyChecks(context.TODO(), p)
+			Expect(err).ToNot(HaveOccurred())
+			for _, c := range checks {
+				Expect(check.ProvenanceOf(c).DataSources).ToNot(BeEmpty(), "check %s does not describe its data sources", c.Name())
+			}
+		}
+	})
+})
+
+var _ = Describe("Filesystem paths of policies", func() {
+	It("should be declared by every check in every container policy", func() {
+		for _, p := range []policy.Policy{policy.PolicyContainer, policy.PolicyRoot, policy.PolicyScratch, policy.PolicyWindows} {
+			checks, err := PolicyChecks(context.TODO(), p)
+			Expect(err).ToNot(HaveOccurred())
+			paths, ok := check.FilesystemPaths(checks)
+			Expect(ok).To(BeTrue(), "the %s policy extracts the whole filesystem", p)
+			Expect(paths).ToNot(ContainElement("/"))
+		}
+	})
+})
+
+var _ = Describe("Certification requirements", func() {
+	It("should cover every check in every policy", func() {
+		covered := map[string]bool{}
+		for _, req := range policy.Requirements() {
+			for _, name := range req.Checks {
+				covered[name] = true
+			}
+		}
+
+		ctx := context.TODO()
+		for _, names := range [][]string{
+			OperatorPolicy(ctx),
+			ContainerPolicy(ctx),
+			ScratchContainerPolicy(ctx),
+			RootExceptionContainerPolicy(ctx),
+			WindowsContainerPolicy(ctx),
+		} {
+			for _, name := range names {
+				Expect(covered).To(HaveKey(name))
+			}
+		}
+	})
+})
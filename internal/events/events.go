@@ -0,0 +1,146 @@
+// Package events provides structured events describing the progress of a
+// preflight run, and writers rendering them as newline-delimited JSON or
+// as human-readable progress.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/clock"
+)
+
+// Type identifies the kind of an Event.
+type Type string
+
+const (
+	TypeRunStarted    Type = "run_started"
+	TypeCheckStarted  Type = "check_started"
+	TypeCheckFinished Type = "check_finished"
+	TypePhase         Type = "phase"
+	TypeRunSummary    Type = "run_summary"
+)
+
+// Event is a single occurrence during a preflight run. Fields not relevant to
+// the event's Type are omitted when serialized.
+type Event struct {
+	Type  Type      `json:"type"`
+	Time  time.Time `json:"time"`
+	Image string    `json:"image,omitempty"`
+	// ImageDigest is the manifest digest the image resolved to, for TypeRunSummary.
+	ImageDigest string `json:"image_digest,omitempty"`
+	Check       string `json:"check,omitempty"`
+	// Phase describes what is happening for TypePhase, e.g. "pulling image".
+	Phase string `json:"phase,omitempty"`
+	// Result is the outcome of a check for TypeCheckFinished, or of the
+	// run as a whole for TypeRunSummary. E.g. PASSED, FAILED, ERROR.
+	Result string `json:"result,omitempty"`
+	// ElapsedTime is in milliseconds, matching the results file.
+	ElapsedTime float64 `json:"elapsed_time,omitempty"`
+	Error       string  `json:"error,omitempty"`
+	// Passed, Failed, Errors, Waived, and Skipped are check counts for TypeRunSummary.
+	Passed      int    `json:"passed,omitempty"`
+	Failed      int    `json:"failed,omitempty"`
+	Errors      int    `json:"errors,omitempty"`
+	Waived      int    `json:"waived,omitempty"`
+	Skipped     int    `json:"skipped,omitempty"`
+	ResultsFile string `json:"results_file,omitempty"`
+}
+
+// Listener receives events as they happen. Implementations must be safe
+// for concurrent use.
+type Listener interface {
+	OnEvent(Event)
+}
+
+// contextKey is a key used to store/retrieve a Listener in/from context.Context.
+type contextKey string
+
+const listenerContextKey contextKey = "EventListener"
+
+// ContextWithListener adds Listener l to the context ctx.
+func ContextWithListener(ctx context.Context, l Listener) context.Context {
+	return context.WithValue(ctx, listenerContextKey, l)
+}
+
+// ListenerFromContext returns the listener from the context, or nil.
+func ListenerFromContext(ctx context.Context) Listener {
+	if l, ok := ctx.Value(listenerContextKey).(Listener); ok {
+		return l
+	}
+
+	return nil
+}
+
+// Emit sends e to the Listener in ctx, if one is present. The event's
+// Time is set from the Clock in ctx if it has not been set already.
+func Emit(ctx context.Context, e Event) {
+	l := ListenerFromContext(ctx)
+	if l == nil {
+		return
+	}
+
+	if e.Time.IsZero() {
+		e.Time = clock.FromContext(ctx).Now().UTC()
+	}
+
+	l.OnEvent(e)
+}
+
+// EmitPhase emits a TypePhase event describing what is currently happening,
+// optionally on behalf of a check.
+func EmitPhase(ctx context.Context, check, phase string) {
+	Emit(ctx, Event{Type: TypePhase, Check: check, Phase: phase})
+}
+
+// MultiListener returns a Listener that sends each event to all listeners.
+func MultiListener(listeners ...Listener) Listener {
+	return multiListener(listeners)
+}
+
+type multiListener []Listener
+
+func (ml multiListener) OnEvent(e Event) {
+	for _, l := range ml {
+		l.OnEvent(e)
+	}
+}
+
+// NDJSONWriter is a Listener that writes each event to an io.Writer as a
+// single line of JSON.
+type NDJSONWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+// NewNDJSONWriter returns a Listener writing events to w.
+func NewNDJSONWriter(w io.Writer) *NDJSONWriter {
+	return &NDJSONWriter{
+		enc: json.NewEncoder(w),
+	}
+}
+
+// OnEvent writes e as a line of JSON. Write errors are retained and
+// available from Err, and no further events are written after one occurs.
+func (w *NDJSONWriter) OnEvent(e Event) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err != nil {
+		return
+	}
+
+	w.err = w.enc.Encode(e)
+}
+
+// Err returns the first error encountered writing events, if any.
+func (w *NDJSONWriter) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.err
+}
@@ -0,0 +1,65 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+var _ = Describe("Events", func() {
+	When("no listener is in the context", func() {
+		It("should not panic when emitting", func() {
+			Expect(func() { Emit(context.Background(), Event{Type: TypeRunStarted}) }).ToNot(Panic())
+		})
+	})
+
+	When("an NDJSON writer is in the context", func() {
+		var buf *bytes.Buffer
+		var ctx context.Context
+
+		BeforeEach(func() {
+			buf = &bytes.Buffer{}
+			ctx = ContextWithListener(context.Background(), NewNDJSONWriter(buf))
+		})
+
+		It("should write one JSON document per line", func() {
+			Emit(ctx, Event{Type: TypeCheckStarted, Check: "HasLicense"})
+			Emit(ctx, Event{Type: TypeCheckFinished, Check: "HasLicense", Result: "PASSED", ElapsedTime: 12})
+
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			Expect(lines).To(HaveLen(2))
+
+			var e Event
+			Expect(json.Unmarshal([]byte(lines[1]), &e)).To(Succeed())
+			Expect(e.Type).To(Equal(TypeCheckFinished))
+			Expect(e.Check).To(Equal("HasLicense"))
+			Expect(e.Result).To(Equal("PASSED"))
+			Expect(e.Time.IsZero()).To(BeFalse())
+		})
+
+		It("should omit fields that are not relevant to the event", func() {
+			Emit(ctx, Event{Type: TypeRunStarted, Image: "example.com/image:tag"})
+			Expect(buf.String()).ToNot(ContainSubstring("check"))
+			Expect(buf.String()).ToNot(ContainSubstring("results_file"))
+		})
+	})
+
+	When("the NDJSON writer fails to write", func() {
+		It("should retain the error", func() {
+			w := NewNDJSONWriter(failingWriter{})
+			w.OnEvent(Event{Type: TypeRunStarted})
+			Expect(w.Err()).To(HaveOccurred())
+		})
+	})
+})
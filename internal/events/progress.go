@@ -0,0 +1,135 @@
+package events
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// ProgressWriter is a Listener that renders events as human-readable
+// progress, including the current check, the current phase, and the
+// time elapsed since the run started.
+//
+// When writing to a terminal, the current check and phase are shown on a
+// single line that is updated in place, and only finished checks are kept.
+// Otherwise, every event is written on its own line.
+type ProgressWriter struct {
+	mu    sync.Mutex
+	w     io.Writer
+	tty   bool
+	start time.Time
+	// now is the time of the event being written.
+	now time.Time
+	// check is the name of the running check, if any.
+	check string
+	// pending is true when a line that will be replaced has been written
+	// to a terminal.
+	pending bool
+}
+
+// NewProgressWriter returns a Listener writing progress to w. If tty is true,
+// progress is updated in place.
+func NewProgressWriter(w io.Writer, tty bool) *ProgressWriter {
+	return &ProgressWriter{
+		w:   w,
+		tty: tty,
+	}
+}
+
+// IsTerminal returns true if f is a terminal.
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// OnEvent writes progress for e.
+func (p *ProgressWriter) OnEvent(e Event) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.start.IsZero() || e.Type == TypeRunStarted {
+		p.start = e.Time
+	}
+
+	p.now = e.Time
+
+	switch e.Type {
+	case TypeRunStarted:
+		p.transient("starting preflight")
+	case TypeCheckStarted:
+		p.check = e.Check
+		p.transient(fmt.Sprintf("%s: running", e.Check))
+	case TypePhase:
+		// Phases emitted without a check name belong to the running check.
+		check := e.Check
+		if check == "" {
+			check = p.check
+		}
+		if check != "" {
+			p.transient(fmt.Sprintf("%s: %s", check, e.Phase))
+		} else {
+			p.transient(e.Phase)
+		}
+	case TypeCheckFinished:
+		p.check = ""
+		msg := fmt.Sprintf("%s: %s (%s)", e.Check, e.Result, time.Duration(e.ElapsedTime)*time.Millisecond)
+		if e.Error != "" {
+			msg += ": " + e.Error
+		}
+		p.permanent(msg)
+	case TypeRunSummary:
+		msg := fmt.Sprintf("preflight %s: %d passed, %d failed, %d errors", e.Result, e.Passed, e.Failed, e.Errors)
+		if e.Error != "" {
+			msg = fmt.Sprintf("preflight %s: %s", e.Result, e.Error)
+		}
+		p.permanent(msg)
+	}
+}
+
+// transient writes msg so that it is replaced by the next message
+// when writing to a terminal.
+func (p *ProgressWriter) transient(msg string) {
+	p.write(msg, p.tty)
+}
+
+// permanent writes msg so that it is not replaced.
+func (p *ProgressWriter) permanent(msg string) {
+	p.write(msg, false)
+}
+
+func (p *ProgressWriter) write(msg string, replaceable bool) {
+	line := fmt.Sprintf("[%s] %s", p.elapsed(), msg)
+
+	if p.pending {
+		// Return to the start of the line and clear it.
+		fmt.Fprint(p.w, "\r\033[K")
+	}
+
+	if replaceable {
+		fmt.Fprint(p.w, line)
+	} else {
+		fmt.Fprintln(p.w, line)
+	}
+	p.pending = replaceable
+}
+
+// elapsed returns the time between the start of the run and the event
+// being written as mm:ss.
+func (p *ProgressWriter) elapsed() string {
+	return formatElapsed(p.now.Sub(p.start))
+}
+
+func formatElapsed(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	d = d.Round(time.Second)
+
+	return fmt.Sprintf("%02d:%02d", int(d/time.Minute), int(d%time.Minute/time.Second))
+}
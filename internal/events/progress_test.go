@@ -0,0 +1,58 @@
+package events
+
+import (
+	"bytes"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ProgressWriter", func() {
+	var buf *bytes.Buffer
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	emitRun := func(p *ProgressWriter) {
+		p.OnEvent(Event{Type: TypeRunStarted, Time: start})
+		p.OnEvent(Event{Type: TypeCheckStarted, Time: start.Add(time.Second), Check: "DeployableByOLM"})
+		p.OnEvent(Event{Type: TypePhase, Time: start.Add(65 * time.Second), Phase: "waiting on OLM"})
+		p.OnEvent(Event{Type: TypeCheckFinished, Time: start.Add(90 * time.Second), Check: "DeployableByOLM", Result: "PASSED", ElapsedTime: 89000})
+		p.OnEvent(Event{Type: TypeRunSummary, Time: start.Add(91 * time.Second), Result: "PASSED", Passed: 1})
+	}
+
+	BeforeEach(func() {
+		buf = &bytes.Buffer{}
+	})
+
+	When("not writing to a terminal", func() {
+		It("should write every event on its own line with the elapsed time", func() {
+			emitRun(NewProgressWriter(buf, false))
+			Expect(buf.String()).To(Equal("[00:00] starting preflight\n" +
+				"[00:01] DeployableByOLM: running\n" +
+				"[01:05] DeployableByOLM: waiting on OLM\n" +
+				"[01:30] DeployableByOLM: PASSED (1m29s)\n" +
+				"[01:31] preflight PASSED: 1 passed, 0 failed, 0 errors\n"))
+		})
+	})
+
+	When("writing to a terminal", func() {
+		It("should update the current check and phase in place", func() {
+			emitRun(NewProgressWriter(buf, true))
+			Expect(buf.String()).To(Equal("[00:00] starting preflight" +
+				"\r\033[K[00:01] DeployableByOLM: running" +
+				"\r\033[K[01:05] DeployableByOLM: waiting on OLM" +
+				"\r\033[K[01:30] DeployableByOLM: PASSED (1m29s)\n" +
+				"[01:31] preflight PASSED: 1 passed, 0 failed, 0 errors\n"))
+		})
+	})
+})
+
+var _ = Describe("MultiListener", func() {
+	It("should send events to every listener", func() {
+		first, second := &bytes.Buffer{}, &bytes.Buffer{}
+		l := MultiListener(NewNDJSONWriter(first), NewNDJSONWriter(second))
+		l.OnEvent(Event{Type: TypeRunStarted})
+		Expect(first.String()).ToNot(BeEmpty())
+		Expect(first.String()).To(Equal(second.String()))
+	})
+})
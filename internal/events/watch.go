@@ -0,0 +1,320 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// DefaultLogTailLines is the number of log lines shown by a Watcher.
+const DefaultLogTailLines = 8
+
+// LogTail is an io.Writer that retains the last lines written to it, e.g.
+// by a logger, so that a Watcher can show them.
+type LogTail struct {
+	mu    sync.Mutex
+	max   int
+	lines []string
+	// partial is a line that has not been terminated yet.
+	partial string
+}
+
+// NewLogTail returns a LogTail retaining the last n lines written to it.
+func NewLogTail(n int) *LogTail {
+	return &LogTail{max: n}
+}
+
+// Write retains the complete lines in p.
+func (t *LogTail) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	lines := strings.Split(t.partial+string(p), "\n")
+	t.partial = lines[len(lines)-1]
+	t.lines = append(t.lines, lines[:len(lines)-1]...)
+	if len(t.lines) > t.max {
+		t.lines = t.lines[len(t.lines)-t.max:]
+	}
+
+	return len(p), nil
+}
+
+// Lines returns the retained lines, oldest first.
+func (t *LogTail) Lines() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]string{}, t.lines...)
+}
+
+const logTailContextKey contextKey = "LogTail"
+
+// ContextWithLogTail adds LogTail t to the context ctx.
+func ContextWithLogTail(ctx context.Context, t *LogTail) context.Context {
+	return context.WithValue(ctx, logTailContextKey, t)
+}
+
+// LogTailFromContext returns the LogTail from the context, or nil.
+func LogTailFromContext(ctx context.Context) *LogTail {
+	t, _ := ctx.Value(logTailContextKey).(*LogTail)
+	return t
+}
+
+// TerminalWidth returns the width of the terminal f, or 0 if it is not a terminal.
+func TerminalWidth(f *os.File) int {
+	width, _, err := term.GetSize(int(f.Fd()))
+	if err != nil {
+		return 0
+	}
+
+	return width
+}
+
+// Watcher is a Listener that renders the status and elapsed time of each check,
+// and the tail of the log, to a terminal. The view is redrawn in place on every
+// event, and periodically once started, so that elapsed times stay current.
+type Watcher struct {
+	mu    sync.Mutex
+	w     io.Writer
+	tail  *LogTail
+	width int
+	// now returns the current time. Elapsed times are measured with the wall
+	// clock, rather than event times, which may be deterministic.
+	now     func() time.Time
+	start   time.Time
+	image   string
+	checks  []*watchedCheck
+	phase   string
+	summary string
+	// drawn is the number of lines drawn by the last redraw.
+	drawn   int
+	stop    chan struct{}
+	stopped bool
+}
+
+type watchedCheck struct {
+	name    string
+	phase   string
+	result  string
+	err     string
+	started time.Time
+	elapsed time.Duration
+}
+
+// NewWatcher returns a Watcher drawing to w, which should be a terminal width
+// columns wide. Lines are not truncated if width is 0. If tail is not nil, its
+// lines are shown below the checks.
+func NewWatcher(w io.Writer, tail *LogTail, width int) *Watcher {
+	return &Watcher{
+		w:     w,
+		tail:  tail,
+		width: width,
+		now:   time.Now,
+	}
+}
+
+// Start redraws the view every interval until Stop is called.
+func (wt *Watcher) Start(interval time.Duration) {
+	wt.mu.Lock()
+	defer wt.mu.Unlock()
+
+	if wt.stop != nil || wt.stopped {
+		return
+	}
+	wt.stop = make(chan struct{})
+
+	go func(stop <-chan struct{}) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				wt.mu.Lock()
+				if !wt.stopped {
+					wt.redraw()
+				}
+				wt.mu.Unlock()
+			}
+		}
+	}(wt.stop)
+}
+
+// Stop draws the view a final time, and stops redrawing it, so that further
+// output to the terminal is not overwritten. Events received after Stop are
+// ignored. It is safe to call Stop more than once.
+func (wt *Watcher) Stop() {
+	wt.mu.Lock()
+	defer wt.mu.Unlock()
+
+	if wt.stopped {
+		return
+	}
+	if wt.stop != nil {
+		close(wt.stop)
+	}
+
+	if wt.summary == "" {
+		counts := map[string]int{}
+		for _, c := range wt.checks {
+			counts[c.result]++
+		}
+		wt.summary = fmt.Sprintf("finished: %d passed, %d failed, %d errors", counts["PASSED"], counts["FAILED"], counts["ERROR"])
+	}
+
+	wt.redraw()
+	wt.stopped = true
+}
+
+// OnEvent updates the view for e, and redraws it.
+func (wt *Watcher) OnEvent(e Event) {
+	wt.mu.Lock()
+	defer wt.mu.Unlock()
+
+	if wt.stopped {
+		return
+	}
+
+	now := wt.now()
+	if wt.start.IsZero() || e.Type == TypeRunStarted {
+		wt.start = now
+	}
+	if e.Image != "" {
+		wt.image = e.Image
+	}
+
+	switch e.Type {
+	case TypeCheckStarted:
+		wt.phase = ""
+		wt.checks = append(wt.checks, &watchedCheck{name: e.Check, started: now})
+	case TypePhase:
+		// Phases emitted without a check name belong to the running check.
+		if c := wt.check(e.Check); c != nil {
+			c.phase = e.Phase
+		} else {
+			wt.phase = e.Phase
+		}
+	case TypeCheckFinished:
+		if c := wt.check(e.Check); c != nil {
+			c.result = e.Result
+			c.err = e.Error
+			c.elapsed = now.Sub(c.started)
+		}
+	case TypeRunSummary:
+		wt.summary = fmt.Sprintf("%s: %d passed, %d failed, %d errors", e.Result, e.Passed, e.Failed, e.Errors)
+		if e.Error != "" {
+			wt.summary = fmt.Sprintf("%s: %s", e.Result, e.Error)
+		}
+	}
+
+	wt.redraw()
+}
+
+// check returns the check named name, or the running check if name is empty.
+func (wt *Watcher) check(name string) *watchedCheck {
+	for i := len(wt.checks) - 1; i >= 0; i-- {
+		c := wt.checks[i]
+		if c.name == name || (name == "" && c.result == "") {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// redraw replaces the last view drawn with the current one.
+func (wt *Watcher) redraw() {
+	lines := wt.view()
+
+	var b strings.Builder
+	if wt.drawn > 0 {
+		// Move to the start of the first line drawn, and clear to the end of the screen.
+		fmt.Fprintf(&b, "\033[%dF\033[J", wt.drawn)
+	}
+	for _, line := range lines {
+		b.WriteString(wt.truncate(line))
+		b.WriteString("\n")
+	}
+	wt.drawn = len(lines)
+
+	fmt.Fprint(wt.w, b.String())
+}
+
+// view returns the lines of the current view.
+func (wt *Watcher) view() []string {
+	now := wt.now()
+	if wt.start.IsZero() {
+		wt.start = now
+	}
+
+	status := "running"
+	if wt.summary != "" {
+		status = wt.summary
+	} else if wt.phase != "" {
+		status = wt.phase
+	}
+
+	header := fmt.Sprintf("preflight [%s] %s", formatElapsed(now.Sub(wt.start)), status)
+	if wt.image != "" {
+		header = fmt.Sprintf("preflight %s [%s] %s", wt.image, formatElapsed(now.Sub(wt.start)), status)
+	}
+
+	width := 0
+	for _, c := range wt.checks {
+		if len(c.name) > width {
+			width = len(c.name)
+		}
+	}
+
+	lines := []string{header}
+	for _, c := range wt.checks {
+		if c.result == "" {
+			line := fmt.Sprintf("  %-7s %-*s  %s", "RUNNING", width, c.name, formatElapsed(now.Sub(c.started)))
+			if c.phase != "" {
+				line += "  " + c.phase
+			}
+			lines = append(lines, line)
+			continue
+		}
+
+		line := fmt.Sprintf("  %-7s %-*s  %s", c.result, width, c.name, formatElapsed(c.elapsed))
+		if c.err != "" {
+			line += "  " + c.err
+		}
+		lines = append(lines, line)
+	}
+
+	if wt.tail != nil {
+		if tail := wt.tail.Lines(); len(tail) > 0 {
+			lines = append(lines, "", "log:")
+			for _, l := range tail {
+				lines = append(lines, "  "+l)
+			}
+		}
+	}
+
+	return lines
+}
+
+// truncate shortens line to the width of the terminal, so that each line
+// occupies a single row and the view can be redrawn in place.
+func (wt *Watcher) truncate(line string) string {
+	line = strings.ReplaceAll(line, "\t", " ")
+	if wt.width <= 0 {
+		return line
+	}
+
+	runes := []rune(line)
+	if len(runes) < wt.width {
+		return line
+	}
+
+	return string(runes[:wt.width-1])
+}
@@ -0,0 +1,86 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Watcher", func() {
+	var buf *bytes.Buffer
+	var tail *LogTail
+	var w *Watcher
+	var now time.Time
+
+	BeforeEach(func() {
+		buf = &bytes.Buffer{}
+		tail = NewLogTail(2)
+		now = time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+		w = NewWatcher(buf, tail, 0)
+		w.now = func() time.Time { return now }
+	})
+
+	// lastFrame returns the last view drawn.
+	lastFrame := func() string {
+		frames := bytes.Split(buf.Bytes(), []byte("\033[J"))
+		return string(frames[len(frames)-1])
+	}
+
+	It("should show the status and elapsed time of each check", func() {
+		w.OnEvent(Event{Type: TypeRunStarted})
+		w.OnEvent(Event{Type: TypeCheckStarted, Image: "quay.io/repo/image:tag", Check: "HasLicense"})
+		now = now.Add(2 * time.Second)
+		w.OnEvent(Event{Type: TypeCheckFinished, Check: "HasLicense", Result: "PASSED"})
+		w.OnEvent(Event{Type: TypeCheckStarted, Check: "DeployableByOLM"})
+		now = now.Add(65 * time.Second)
+		w.OnEvent(Event{Type: TypePhase, Phase: "waiting on OLM"})
+
+		Expect(lastFrame()).To(Equal("preflight quay.io/repo/image:tag [01:07] running\n" +
+			"  PASSED  HasLicense       00:02\n" +
+			"  RUNNING DeployableByOLM  01:05  waiting on OLM\n"))
+	})
+
+	It("should redraw the view in place", func() {
+		w.OnEvent(Event{Type: TypeRunStarted})
+		w.OnEvent(Event{Type: TypeCheckStarted, Check: "HasLicense"})
+		Expect(buf.String()).To(HaveSuffix("\033[1F\033[J" + "preflight [00:00] running\n" +
+			"  RUNNING HasLicense  00:00\n"))
+	})
+
+	It("should show the tail of the log", func() {
+		fmt.Fprint(tail, "first\nsecond\nthird\npart")
+		w.OnEvent(Event{Type: TypeRunStarted})
+		Expect(lastFrame()).To(Equal("preflight [00:00] running\n\nlog:\n  second\n  third\n"))
+	})
+
+	It("should summarize the run when stopped, and ignore later events", func() {
+		w.OnEvent(Event{Type: TypeCheckStarted, Check: "HasLicense"})
+		w.OnEvent(Event{Type: TypeCheckFinished, Check: "HasLicense", Result: "FAILED", Error: "no licenses"})
+		w.Stop()
+		w.Stop()
+		written := buf.Len()
+		w.OnEvent(Event{Type: TypeCheckStarted, Check: "HasUniqueTag"})
+
+		Expect(buf.Len()).To(Equal(written))
+		Expect(lastFrame()).To(Equal("preflight [00:00] finished: 0 passed, 1 failed, 0 errors\n" +
+			"  FAILED  HasLicense  00:00  no licenses\n"))
+	})
+
+	It("should truncate lines to the width of the terminal", func() {
+		w.width = 10
+		w.OnEvent(Event{Type: TypeRunStarted})
+		Expect(lastFrame()).To(Equal("preflight\n"))
+	})
+})
+
+var _ = Describe("LogTail", func() {
+	It("should be available from the context", func() {
+		tail := NewLogTail(1)
+		Expect(LogTailFromContext(ContextWithLogTail(context.Background(), tail))).To(BeIdenticalTo(tail))
+		Expect(LogTailFromContext(context.Background())).To(BeNil())
+	})
+})
@@ -0,0 +1,192 @@
+// Package exceptions waives the failures of checks that an organization has accepted
+// for an image, with a justification and until an expiry, so that they are recorded in
+// the results as waivers instead of the checks being skipped.
+package exceptions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/certification"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-containerregistry/pkg/name"
+	"sigs.k8s.io/yaml"
+)
+
+// dateLayout is the layout of expiries that are dates, which expire at the end of the
+// day in UTC.
+const dateLayout = "2006-01-02"
+
+// Exception waives the failure of a check for the images matching a pattern.
+type Exception struct {
+	// Check is the name of the check, e.g. RunAsNonRoot.
+	Check string `json:"check"`
+	// Image is a pattern, as matched by path.Match, of the repository of the image,
+	// e.g. quay.io/example/*, or of the image with its tag or digest. The pattern *
+	// matches every image.
+	Image string `json:"image"`
+	// Justification is why the failure is accepted, e.g. the ticket approving it.
+	Justification string `json:"justification"`
+	// Expires is a date, e.g. 2024-12-31, after which the exception no longer applies,
+	// or an RFC 3339 time at which it expires.
+	Expires string `json:"expires"`
+
+	expires time.Time
+}
+
+// File is a file of exceptions.
+type File struct {
+	Exceptions []Exception `json:"exceptions"`
+}
+
+// List is a list of valid exceptions.
+type List struct {
+	exceptions []Exception
+}
+
+// Load returns the exceptions in the file at path.
+func Load(path string) (*List, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read exceptions file: %w", err)
+	}
+
+	var f File
+	if err := yaml.UnmarshalStrict(b, &f); err != nil {
+		return nil, fmt.Errorf("could not parse exceptions file %s: %w", path, err)
+	}
+
+	l, err := New(f.Exceptions...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exceptions file %s: %w", path, err)
+	}
+
+	return l, nil
+}
+
+// New returns a List of exceptions, or an error if any of them is not valid. Every
+// exception must name a check and an image pattern, and have a justification and
+// an expiry.
+func New(exceptions ...Exception) (*List, error) {
+	l := &List{exceptions: make([]Exception, 0, len(exceptions))}
+	for i, e := range exceptions {
+		switch {
+		case e.Check == "":
+			return nil, fmt.Errorf("exception %d must name a check", i+1)
+		case e.Image == "":
+			return nil, fmt.Errorf("exception %d for %s must have an image pattern", i+1, e.Check)
+		case e.Justification == "":
+			return nil, fmt.Errorf("exception %d for %s must have a justification", i+1, e.Check)
+		case e.Expires == "":
+			return nil, fmt.Errorf("exception %d for %s must expire", i+1, e.Check)
+		}
+		if _, err := path.Match(e.Image, ""); err != nil {
+			return nil, fmt.Errorf("exception %d for %s has an invalid image pattern %q: %w", i+1, e.Check, e.Image, err)
+		}
+
+		expires, err := parseExpiry(e.Expires)
+		if err != nil {
+			return nil, fmt.Errorf("exception %d for %s: %w", i+1, e.Check, err)
+		}
+		e.expires = expires
+
+		l.exceptions = append(l.exceptions, e)
+	}
+
+	return l, nil
+}
+
+// parseExpiry returns the time at which an exception expiring at s expires.
+func parseExpiry(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(dateLayout, s); err == nil {
+		return t.AddDate(0, 0, 1), nil
+	}
+
+	return time.Time{}, fmt.Errorf("the expiry %q must be a date, e.g. 2024-12-31, or an RFC 3339 time", s)
+}
+
+// Apply returns results with the failures of checks that an exception applies to as
+// of now moved to the waived results, and whether the results passed overall updated.
+// Failures whose exceptions have expired are not waived. Errors are never waived,
+// since the check could not determine whether the image meets its requirement.
+func (l *List) Apply(ctx context.Context, results certification.Results, now time.Time) certification.Results {
+	logger := logr.FromContextOrDiscard(ctx)
+
+	failed := make([]certification.Result, 0, len(results.Failed))
+	for _, result := range results.Failed {
+		e, err := l.match(result.Name(), results.TestedImage, now)
+		if err != nil {
+			logger.Info(fmt.Sprintf("the failure of %s is not waived: %s", result.Name(), err), "check", result.Name(), "image", e.Image)
+			failed = append(failed, result)
+			continue
+		}
+		if e == nil {
+			failed = append(failed, result)
+			continue
+		}
+
+		logger.Info(fmt.Sprintf("the failure of %s is waived until %s", result.Name(), e.expires.Format(time.RFC3339)),
+			"check", result.Name(), "image", e.Image, "justification", e.Justification)
+		result.Waiver = &certification.Waiver{Image: e.Image, Justification: e.Justification, Expires: e.expires}
+		results.Waived = append(results.Waived, result)
+	}
+
+	results.Failed = failed
+	results.PassedOverall = len(results.Failed) == 0 && len(results.Errors) == 0
+
+	return results
+}
+
+// errExpired is returned by match if the only exceptions for a check have expired.
+var errExpired = errors.New("its exception has expired")
+
+// match returns the exception for check that applies to image as of now, or nil if
+// there is none. If every exception for check that matches image has expired, the last
+// to expire is returned with an error.
+func (l *List) match(check, image string, now time.Time) (*Exception, error) {
+	var expired *Exception
+	for i, e := range l.exceptions {
+		if e.Check != check || !matchImage(e.Image, image) {
+			continue
+		}
+		if now.Before(e.expires) {
+			return &l.exceptions[i], nil
+		}
+		if expired == nil || e.expires.After(expired.expires) {
+			expired = &l.exceptions[i]
+		}
+	}
+
+	if expired != nil {
+		return expired, fmt.Errorf("%w on %s", errExpired, expired.expires.Format(time.RFC3339))
+	}
+
+	return nil, nil
+}
+
+// matchImage returns true if pattern matches image, or its repository.
+func matchImage(pattern, image string) bool {
+	// A * in path.Match does not match the / of repositories.
+	if pattern == "*" {
+		return true
+	}
+	if ok, _ := path.Match(pattern, image); ok {
+		return true
+	}
+
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return false
+	}
+	ok, _ := path.Match(pattern, ref.Context().Name())
+
+	return ok
+}
@@ -0,0 +1,136 @@
+package exceptions
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/certification"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/image"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Policy exceptions", func() {
+	var (
+		now     time.Time
+		results certification.Results
+	)
+
+	result := func(name string) certification.Result {
+		return certification.Result{Check: check.NewGenericCheck(
+			name,
+			func(context.Context, image.ImageReference) (bool, error) { return false, nil },
+			check.Metadata{},
+			check.HelpText{},
+		)}
+	}
+
+	BeforeEach(func() {
+		now = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+		results = certification.Results{
+			TestedImage: "quay.io/example/app:1.0",
+			Passed:      []certification.Result{result("HasLicense")},
+			Failed:      []certification.Result{result("RunAsNonRoot"), result("HasUniqueTag")},
+			Errors:      []certification.Result{},
+		}
+	})
+
+	It("should waive the failures that exceptions apply to", func() {
+		l, err := New(
+			Exception{Check: "RunAsNonRoot", Image: "quay.io/example/*", Justification: "approved in CERT-1", Expires: "2024-06-01"},
+			Exception{Check: "HasUniqueTag", Image: "quay.io/example/app:1.0", Justification: "approved in CERT-2", Expires: "2024-07-01T00:00:00Z"},
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		waived := l.Apply(context.Background(), results, now)
+		Expect(waived.PassedOverall).To(BeTrue())
+		Expect(waived.Failed).To(BeEmpty())
+		Expect(waived.Waived).To(HaveLen(2))
+		Expect(waived.Waived[0].Name()).To(Equal("RunAsNonRoot"))
+		Expect(waived.Waived[0].Waiver).To(Equal(&certification.Waiver{
+			Image:         "quay.io/example/*",
+			Justification: "approved in CERT-1",
+			Expires:       time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC),
+		}))
+		Expect(waived.Waived[1].Waiver.Expires).To(Equal(time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)))
+	})
+
+	It("should not waive failures whose exceptions have expired", func() {
+		l, err := New(
+			Exception{Check: "RunAsNonRoot", Image: "quay.io/example/app", Justification: "approved in CERT-1", Expires: "2024-05-31"},
+			Exception{Check: "HasUniqueTag", Image: "quay.io/example/app", Justification: "approved in CERT-2", Expires: "2024-12-31"},
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		waived := l.Apply(context.Background(), results, now)
+		Expect(waived.PassedOverall).To(BeFalse())
+		Expect(waived.Failed).To(HaveLen(1))
+		Expect(waived.Failed[0].Name()).To(Equal("RunAsNonRoot"))
+		Expect(waived.Waived).To(HaveLen(1))
+		Expect(waived.Waived[0].Name()).To(Equal("HasUniqueTag"))
+	})
+
+	It("should not waive failures of other checks or images", func() {
+		l, err := New(
+			Exception{Check: "RunAsNonRoot", Image: "quay.io/other/*", Justification: "approved", Expires: "2024-12-31"},
+			Exception{Check: "HasLicense", Image: "*", Justification: "approved", Expires: "2024-12-31"},
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		waived := l.Apply(context.Background(), results, now)
+		Expect(waived.Failed).To(HaveLen(2))
+		Expect(waived.Waived).To(BeEmpty())
+		Expect(waived.PassedOverall).To(BeFalse())
+	})
+
+	It("should not pass overall if a check errored", func() {
+		results.Errors = []certification.Result{result("HasNoProhibitedPackages")}
+		l, err := New(
+			Exception{Check: "RunAsNonRoot", Image: "*", Justification: "approved", Expires: "2024-12-31"},
+			Exception{Check: "HasUniqueTag", Image: "*", Justification: "approved", Expires: "2024-12-31"},
+			Exception{Check: "HasNoProhibitedPackages", Image: "*", Justification: "approved", Expires: "2024-12-31"},
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		waived := l.Apply(context.Background(), results, now)
+		Expect(waived.Waived).To(HaveLen(2))
+		Expect(waived.Errors).To(HaveLen(1))
+		Expect(waived.PassedOverall).To(BeFalse())
+	})
+
+	DescribeTable("should reject invalid exceptions",
+		func(e Exception, expected string) {
+			_, err := New(e)
+			Expect(err).To(MatchError(ContainSubstring(expected)))
+		},
+		Entry("without a check", Exception{Image: "*", Justification: "approved", Expires: "2024-12-31"}, "must name a check"),
+		Entry("without an image", Exception{Check: "RunAsNonRoot", Justification: "approved", Expires: "2024-12-31"}, "must have an image pattern"),
+		Entry("without a justification", Exception{Check: "RunAsNonRoot", Image: "*", Expires: "2024-12-31"}, "must have a justification"),
+		Entry("without an expiry", Exception{Check: "RunAsNonRoot", Image: "*", Justification: "approved"}, "must expire"),
+		Entry("with an invalid expiry", Exception{Check: "RunAsNonRoot", Image: "*", Justification: "approved", Expires: "next year"}, "must be a date"),
+		Entry("with an invalid image pattern", Exception{Check: "RunAsNonRoot", Image: "[", Justification: "approved", Expires: "2024-12-31"}, "invalid image pattern"),
+	)
+
+	It("should load exceptions from a file", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "exceptions.yaml")
+		Expect(os.WriteFile(path, []byte(`
+exceptions:
+- check: RunAsNonRoot
+  image: quay.io/example/*
+  justification: approved in CERT-1
+  expires: 2024-12-31
+`), 0o644)).To(Succeed())
+
+		l, err := Load(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(l.Apply(context.Background(), results, now).Waived).To(HaveLen(1))
+
+		Expect(os.WriteFile(path, []byte("exceptions:\n- check: RunAsNonRoot\n  reason: typo\n"), 0o644)).To(Succeed())
+		_, err = Load(path)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,129 @@
+package formatters
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/certification"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/policy"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/version"
+)
+
+const (
+	requirementMet          = "Met"
+	requirementNotMet       = "Not met"
+	requirementNotEvaluated = "Not evaluated"
+	// requirementWaived labels requirements that are met but for the
+	// failures of checks that were waived by a policy exception.
+	requirementWaived = "Waived"
+	// requirementOther labels executed checks that do not map to a
+	// known requirement.
+	requirementOther = "Other"
+)
+
+// checklistFormatter is a FormatterFunc that formats results as a Markdown
+// checklist, mapping each certification requirement to the checks that
+// verify it and their outcomes.
+func checklistFormatter(ctx context.Context, r certification.Results) ([]byte, error) {
+	outcomes := make(map[string]certification.Status, len(r.Passed)+len(r.Failed)+len(r.Errors)+len(r.Waived))
+	references := make(map[string]string, len(outcomes))
+	var executed []string
+	record := func(results []certification.Result, outcome certification.Status) {
+		for _, result := range results {
+			outcomes[result.Name()] = outcome
+			references[result.Name()] = result.Metadata().CheckURL
+			executed = append(executed, result.Name())
+		}
+	}
+	record(r.Passed, certification.StatusPassed)
+	record(r.Failed, certification.StatusFailed)
+	record(r.Errors, certification.StatusErrored)
+	record(r.Waived, certification.StatusWaived)
+
+	var b bytes.Buffer
+	fmt.Fprintln(&b, "# Certification Checklist")
+	fmt.Fprintln(&b)
+	fmt.Fprintf(&b, "- Image: `%s`\n", r.TestedImage)
+	if r.ImageDigest != "" {
+		fmt.Fprintf(&b, "- Digest: `%s`\n", r.ImageDigest)
+	}
+	fmt.Fprintf(&b, "- Result: %s\n", certification.OverallStatus(r.PassedOverall))
+	if r.TestedOn.Name != "" {
+		fmt.Fprintf(&b, "- Tested on: %s %s\n", r.TestedOn.Name, r.TestedOn.Version)
+	}
+	fmt.Fprintf(&b, "- Preflight: %s (%s)\n", version.Version.Version, version.Version.Commit)
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "|Requirement|Status|Check|Outcome|Reference|")
+	fmt.Fprintln(&b, "|--|--|--|--|--|")
+
+	row := func(requirement, status, check, outcome, reference string) {
+		fmt.Fprintf(&b, "|%s|%s|%s|%s|%s|\n",
+			escapeCell(requirement), escapeCell(status), escapeCell(check), escapeCell(outcome), escapeCell(reference))
+	}
+
+	covered := make(map[string]bool, len(outcomes))
+	for _, req := range policy.Requirements() {
+		// Only include requirements for the policy that was executed.
+		if !anyExecuted(req.Checks, outcomes) {
+			continue
+		}
+
+		status := requirementMet
+		for _, name := range req.Checks {
+			covered[name] = true
+			outcome, ok := outcomes[name]
+			switch {
+			case !ok && status == requirementMet:
+				status = requirementNotEvaluated
+			case ok && outcome == certification.StatusWaived:
+				if status == requirementMet {
+					status = requirementWaived
+				}
+			case ok && outcome != certification.StatusPassed:
+				status = requirementNotMet
+			}
+		}
+
+		// The requirement and its status are only listed on the first row.
+		for i, name := range req.Checks {
+			description, reqStatus := "", ""
+			if i == 0 {
+				description, reqStatus = req.Description, status
+			}
+			outcome := requirementNotEvaluated
+			if o, ok := outcomes[name]; ok {
+				outcome = string(o)
+			}
+			row(description, reqStatus, name, outcome, references[name])
+		}
+	}
+
+	// Checks that do not map to a known requirement are still listed so that
+	// every executed check is traceable.
+	for _, name := range executed {
+		if !covered[name] {
+			row(requirementOther, "", name, string(outcomes[name]), references[name])
+		}
+	}
+
+	return b.Bytes(), nil
+}
+
+// anyExecuted returns true if any of the named checks has an outcome.
+func anyExecuted(names []string, outcomes map[string]certification.Status) bool {
+	for _, name := range names {
+		if _, ok := outcomes[name]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// escapeCell escapes s for use in a Markdown table cell.
+func escapeCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", " ")
+}
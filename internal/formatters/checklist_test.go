@@ -0,0 +1,86 @@
+package formatters
+
+import (
+	"context"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/certification"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/image"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Checklist Formatter", func() {
+	newCheck := func(name string) check.Check {
+		return check.NewGenericCheck(
+			name,
+			func(ctx context.Context, ir image.ImageReference) (bool, error) { return true, nil },
+			check.Metadata{CheckURL: "https://example.com/" + name},
+			check.HelpText{},
+		)
+	}
+
+	var results certification.Results
+	BeforeEach(func() {
+		results = certification.Results{
+			TestedImage:   "example.com/repo/image:tag",
+			PassedOverall: false,
+			Passed: []certification.Result{
+				{Check: newCheck("HasLicense")},
+				{Check: newCheck("ValidateOperatorBundle")},
+			},
+			Failed: []certification.Result{
+				{Check: newCheck("RunAsNonRoot")},
+			},
+			Errors: []certification.Result{
+				{Check: newCheck("Some|UnmappedCheck")},
+			},
+		}
+	})
+
+	It("should include a summary of the execution", func() {
+		out, err := checklistFormatter(context.TODO(), results)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(out)).To(ContainSubstring("- Image: `example.com/repo/image:tag`"))
+		Expect(string(out)).To(ContainSubstring("- Result: FAILED"))
+		Expect(string(out)).ToNot(ContainSubstring("- Tested on:"))
+	})
+
+	It("should mark requirements whose checks all passed as met", func() {
+		out, err := checklistFormatter(context.TODO(), results)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(out)).To(ContainSubstring("|Met|HasLicense|PASSED|https://example.com/HasLicense|"))
+	})
+
+	It("should mark requirements with a failed check as not met", func() {
+		out, err := checklistFormatter(context.TODO(), results)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(out)).To(ContainSubstring("|Not met|RunAsNonRoot|FAILED|https://example.com/RunAsNonRoot|"))
+	})
+
+	It("should mark requirements as not evaluated when some of their checks did not run", func() {
+		out, err := checklistFormatter(context.TODO(), results)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(out)).To(ContainSubstring("|Not evaluated|ValidateOperatorBundle|PASSED|"))
+		Expect(string(out)).To(ContainSubstring("|||ScorecardBasicSpecCheck|Not evaluated||"))
+	})
+
+	It("should omit requirements for which no checks ran", func() {
+		out, err := checklistFormatter(context.TODO(), results)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(out)).ToNot(ContainSubstring("DeployableByOLM"))
+	})
+
+	It("should list executed checks that are not mapped to a requirement, escaping every cell", func() {
+		out, err := checklistFormatter(context.TODO(), results)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(out)).To(ContainSubstring(`|Other||Some\|UnmappedCheck|ERROR|https://example.com/Some\|UnmappedCheck|`))
+	})
+
+	It("should be available by name", func() {
+		f, err := NewByName("checklist")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(f.FileExtension()).To(Equal("md"))
+	})
+})
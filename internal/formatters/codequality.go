@@ -0,0 +1,79 @@
+package formatters
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/certification"
+)
+
+const (
+	// codeQualitySeverityFailed is the severity of a failed check, which
+	// prevents certification.
+	codeQualitySeverityFailed = "critical"
+	// codeQualitySeverityErrored is the severity of a check that could not
+	// be executed.
+	codeQualitySeverityErrored = "major"
+)
+
+// codeQualityIssue is an issue in GitLab's Code Quality report format.
+// See https://docs.gitlab.com/ee/ci/testing/code_quality.html#implement-a-custom-tool
+type codeQualityIssue struct {
+	Description string              `json:"description"`
+	CheckName   string              `json:"check_name"`
+	Fingerprint string              `json:"fingerprint"`
+	Severity    string              `json:"severity"`
+	Location    codeQualityLocation `json:"location"`
+}
+
+type codeQualityLocation struct {
+	Path  string           `json:"path"`
+	Lines codeQualityLines `json:"lines"`
+}
+
+type codeQualityLines struct {
+	Begin int `json:"begin"`
+}
+
+// codeQualityFormatter is a FormatterFunc that formats failed and errored checks as
+// issues in GitLab's Code Quality report format, so that they are shown in merge
+// requests. Passed checks are not reported. Each issue is located at the tested image,
+// since checks do not relate to a file in the repository.
+func codeQualityFormatter(ctx context.Context, r certification.Results) ([]byte, error) {
+	issues := make([]codeQualityIssue, 0, len(r.Failed)+len(r.Errors))
+	add := func(results []certification.Result, status certification.Status, severity string) {
+		for _, result := range results {
+			description := fmt.Sprintf("%s %s", result.Name(), status)
+			if help := result.Help(); help.Message != "" {
+				description = fmt.Sprintf("%s: %s Suggested Fix: %s", description, help.Message, help.Suggestion)
+			}
+
+			// The fingerprint must be stable across executions, so that GitLab can
+			// compare the issues of a merge request with those of its target branch.
+			sum := sha256.Sum256([]byte(fmt.Sprintf("preflight/%s/%s", result.Name(), status)))
+
+			issues = append(issues, codeQualityIssue{
+				Description: description,
+				CheckName:   result.Name(),
+				Fingerprint: hex.EncodeToString(sum[:]),
+				Severity:    severity,
+				Location: codeQualityLocation{
+					Path:  r.TestedImage,
+					Lines: codeQualityLines{Begin: 1},
+				},
+			})
+		}
+	}
+	add(r.Failed, certification.StatusFailed, codeQualitySeverityFailed)
+	add(r.Errors, certification.StatusErrored, codeQualitySeverityErrored)
+
+	b, err := json.MarshalIndent(issues, "", "    ")
+	if err != nil {
+		return nil, fmt.Errorf("error formatting results with formatter %s: %w", "gitlab-codequality", err)
+	}
+
+	return b, nil
+}
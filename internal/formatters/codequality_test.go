@@ -0,0 +1,87 @@
+package formatters
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/certification"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/image"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GitLab Code Quality Formatter", func() {
+	newCheck := func(name string, help check.HelpText) check.Check {
+		return check.NewGenericCheck(
+			name,
+			func(ctx context.Context, ir image.ImageReference) (bool, error) { return true, nil },
+			check.Metadata{},
+			help,
+		)
+	}
+
+	var results certification.Results
+	BeforeEach(func() {
+		results = certification.Results{
+			TestedImage:   "example.com/repo/image:tag",
+			PassedOverall: false,
+			Passed: []certification.Result{
+				{Check: newCheck("HasLicense", check.HelpText{})},
+			},
+			Failed: []certification.Result{
+				{Check: newCheck("RunAsNonRoot", check.HelpText{Message: "Check failed.", Suggestion: "Set USER."})},
+			},
+			Errors: []certification.Result{
+				{Check: newCheck("HasUniqueTag", check.HelpText{})},
+			},
+		}
+	})
+
+	format := func() []codeQualityIssue {
+		out, err := codeQualityFormatter(context.TODO(), results)
+		Expect(err).ToNot(HaveOccurred())
+
+		var issues []codeQualityIssue
+		Expect(json.Unmarshal(out, &issues)).To(Succeed())
+		return issues
+	}
+
+	It("should report failed and errored checks, but not passed checks", func() {
+		issues := format()
+		Expect(issues).To(HaveLen(2))
+
+		Expect(issues[0].CheckName).To(Equal("RunAsNonRoot"))
+		Expect(issues[0].Severity).To(Equal("critical"))
+		Expect(issues[0].Description).To(Equal("RunAsNonRoot FAILED: Check failed. Suggested Fix: Set USER."))
+		Expect(issues[0].Location.Path).To(Equal("example.com/repo/image:tag"))
+		Expect(issues[0].Location.Lines.Begin).To(Equal(1))
+
+		Expect(issues[1].CheckName).To(Equal("HasUniqueTag"))
+		Expect(issues[1].Severity).To(Equal("major"))
+		Expect(issues[1].Description).To(Equal("HasUniqueTag ERROR"))
+	})
+
+	It("should use fingerprints that are unique, and stable across images", func() {
+		issues := format()
+		Expect(issues[0].Fingerprint).ToNot(Equal(issues[1].Fingerprint))
+
+		results.TestedImage = "example.com/repo/image:other"
+		Expect(format()[0].Fingerprint).To(Equal(issues[0].Fingerprint))
+	})
+
+	It("should write an empty list if all checks passed", func() {
+		results.Failed = nil
+		results.Errors = nil
+		out, err := codeQualityFormatter(context.TODO(), results)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(out)).To(Equal("[]"))
+	})
+
+	It("should be available by name", func() {
+		f, err := NewByName("gitlab-codequality")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(f.FileExtension()).To(Equal("json"))
+	})
+})
@@ -0,0 +1,155 @@
+package formatters
+
+import (
+	"encoding/xml"
+	"fmt"
+	"time"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/certification"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/version"
+)
+
+// userResponseV1_5 is the user-facing response of preflight 1.5, which only includes
+// the outcome of each check.
+type userResponseV1_5 struct {
+	XMLName           xml.Name               `json:"-" xml:"UserResponse"`
+	Image             string                 `json:"image" xml:"image"`
+	Passed            bool                   `json:"passed" xml:"passed"`
+	CertificationHash string                 `json:"certification_hash,omitempty" xml:"certification_hash,omitempty"`
+	LibraryInfo       version.VersionContext `json:"test_library" xml:"test_library"`
+	Results           resultsTextV1_5        `json:"results" xml:"results"`
+}
+
+type resultsTextV1_5 struct {
+	Passed []checkExecutionInfoV1_5 `json:"passed" xml:"passed"`
+	Failed []checkExecutionInfoV1_5 `json:"failed" xml:"failed"`
+	Errors []checkExecutionInfoV1_5 `json:"errors" xml:"errors"`
+}
+
+type checkExecutionInfoV1_5 struct {
+	Name             string  `json:"name,omitempty" xml:"name,omitempty"`
+	ElapsedTime      float64 `json:"elapsed_time" xml:"elapsed_time"`
+	Description      string  `json:"description,omitempty" xml:"description,omitempty"`
+	Help             string  `json:"help,omitempty" xml:"help,omitempty"`
+	Suggestion       string  `json:"suggestion,omitempty" xml:"suggestion,omitempty"`
+	KnowledgeBaseURL string  `json:"knowledgebase_url,omitempty" xml:"knowledgebase_url,omitempty"`
+	CheckURL         string  `json:"check_url,omitempty" xml:"check_url,omitempty"`
+}
+
+// getResponseV1_5 returns r as preflight 1.5 formatted it. Waived and skipped checks,
+// which 1.5 did not have, are not included.
+func getResponseV1_5(r certification.Results) userResponseV1_5 {
+	passedChecks := make([]checkExecutionInfoV1_5, 0, len(r.Passed))
+	for _, check := range r.Passed {
+		passedChecks = append(passedChecks, checkExecutionInfoV1_5{
+			Name:        check.Name(),
+			ElapsedTime: float64(check.ElapsedTime.Milliseconds()),
+			Description: check.Metadata().Description,
+		})
+	}
+
+	failedChecks := make([]checkExecutionInfoV1_5, 0, len(r.Failed))
+	for _, check := range r.Failed {
+		failedChecks = append(failedChecks, checkExecutionInfoV1_5{
+			Name:             check.Name(),
+			ElapsedTime:      float64(check.ElapsedTime.Milliseconds()),
+			Description:      check.Metadata().Description,
+			Help:             check.Help().Message,
+			Suggestion:       check.Help().Suggestion,
+			KnowledgeBaseURL: check.Metadata().KnowledgeBaseURL,
+			CheckURL:         check.Metadata().CheckURL,
+		})
+	}
+
+	erroredChecks := make([]checkExecutionInfoV1_5, 0, len(r.Errors))
+	for _, check := range r.Errors {
+		erroredChecks = append(erroredChecks, checkExecutionInfoV1_5{
+			Name:        check.Name(),
+			ElapsedTime: float64(check.ElapsedTime.Milliseconds()),
+			Description: check.Metadata().Description,
+			Help:        check.Help().Message,
+		})
+	}
+
+	return userResponseV1_5{
+		Image:             r.TestedImage,
+		Passed:            r.PassedOverall,
+		LibraryInfo:       version.Version,
+		CertificationHash: r.CertificationHash,
+		Results: resultsTextV1_5{
+			Passed: passedChecks,
+			Failed: failedChecks,
+			Errors: erroredChecks,
+		},
+	}
+}
+
+// junitTestSuitesV1_5 is the JUnit report of preflight 1.5, which had a single test
+// suite, without properties, in which errored checks are failures.
+type junitTestSuitesV1_5 struct {
+	XMLName xml.Name             `xml:"testsuites"`
+	Suites  []junitTestSuiteV1_5 `xml:"testsuite"`
+}
+
+type junitTestSuiteV1_5 struct {
+	XMLName    xml.Name            `xml:"testsuite"`
+	Tests      int                 `xml:"tests,attr"`
+	Failures   int                 `xml:"failures,attr"`
+	Time       string              `xml:"time,attr"`
+	Name       string              `xml:"name,attr"`
+	Properties []JUnitProperty     `xml:"properties>property,omitempty"`
+	TestCases  []junitTestCaseV1_5 `xml:"testcase"`
+}
+
+type junitTestCaseV1_5 struct {
+	XMLName   xml.Name      `xml:"testcase"`
+	Classname string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *JUnitFailure `xml:"failure,omitempty"`
+	Message   string        `xml:",chardata"`
+}
+
+// junitXMLFormatterV1_5 formats r as the JUnit report of preflight 1.5.
+func junitXMLFormatterV1_5(r certification.Results) ([]byte, error) {
+	testsuite := junitTestSuiteV1_5{
+		Tests:     len(r.Errors) + len(r.Failed) + len(r.Passed),
+		Failures:  len(r.Errors) + len(r.Failed),
+		Name:      DefaultJUnitTestSuiteName,
+		TestCases: []junitTestCaseV1_5{},
+	}
+
+	totalDuration := time.Duration(0)
+	for _, result := range r.Passed {
+		testsuite.TestCases = append(testsuite.TestCases, junitTestCaseV1_5{
+			Classname: r.TestedImage,
+			Name:      result.Name(),
+			Time:      fmt.Sprintf("%f", result.ElapsedTime.Seconds()),
+			Message:   result.Metadata().Description,
+		})
+		totalDuration += result.ElapsedTime
+	}
+
+	for _, result := range append(append([]certification.Result{}, r.Errors...), r.Failed...) {
+		testsuite.TestCases = append(testsuite.TestCases, junitTestCaseV1_5{
+			Classname: r.TestedImage,
+			Name:      result.Name(),
+			// 1.5 formatted the time of failures as a duration, e.g. 1.5s.
+			Time: result.ElapsedTime.String(),
+			Failure: &JUnitFailure{
+				Message:  "Failed",
+				Contents: fmt.Sprintf("%s: Suggested Fix: %s", result.Help().Message, result.Help().Suggestion),
+			},
+		})
+		totalDuration += result.ElapsedTime
+	}
+
+	testsuite.Time = fmt.Sprintf("%f", totalDuration.Seconds())
+
+	bytes, err := xml.MarshalIndent(junitTestSuitesV1_5{Suites: []junitTestSuiteV1_5{testsuite}}, "", "\t")
+	if err != nil {
+		return nil, fmt.Errorf("error formatting results with formatter %s: %v", "junitxml", err)
+	}
+
+	return bytes, nil
+}
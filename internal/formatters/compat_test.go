@@ -0,0 +1,87 @@
+package formatters
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"time"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/certification"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/compat"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Formatting results pinned to a previous release", func() {
+	var (
+		ctx     context.Context
+		results certification.Results
+	)
+
+	BeforeEach(func() {
+		ctx = compat.ContextWithVersion(context.Background(), compat.V1_5)
+		results = certification.Results{
+			TestedImage:   "example.com/image:tag",
+			ImageDigest:   "sha256:0123",
+			PassedOverall: false,
+			Passed: []certification.Result{
+				{Check: check.NewGenericCheck("HasLicense", nil, check.Metadata{Description: "license"}, check.HelpText{}), ElapsedTime: time.Second, Attempts: 2},
+			},
+			Failed: []certification.Result{
+				{Check: check.NewGenericCheck("RunAsNonRoot", nil, check.Metadata{}, check.HelpText{Message: "root", Suggestion: "USER"}), ElapsedTime: 1500 * time.Millisecond},
+			},
+			Errors: []certification.Result{
+				{Check: check.NewGenericCheck("HasUniqueTag", nil, check.Metadata{}, check.HelpText{Message: "tag"}), Reason: "timed out"},
+			},
+			Skipped: []certification.Result{
+				{Check: check.NewGenericCheck("BasedOnUbi", nil, check.Metadata{}, check.HelpText{}), Reason: "preflight was interrupted"},
+			},
+		}
+	})
+
+	It("should only include the fields of 1.5 in the JSON results", func() {
+		b, err := genericJSONFormatter(ctx, results)
+		Expect(err).ToNot(HaveOccurred())
+
+		var response map[string]any
+		Expect(json.Unmarshal(b, &response)).To(Succeed())
+		Expect(response).To(HaveLen(4))
+		Expect(response).To(HaveKey("image"))
+		Expect(response).To(HaveKey("passed"))
+		Expect(response).To(HaveKey("test_library"))
+		Expect(response).To(HaveKey("results"))
+		Expect(response["results"]).To(HaveLen(3))
+
+		passed := response["results"].(map[string]any)["passed"].([]any)
+		Expect(passed).To(ConsistOf(map[string]any{"name": "HasLicense", "elapsed_time": float64(1000), "description": "license"}))
+		errored := response["results"].(map[string]any)["errors"].([]any)
+		Expect(errored).To(ConsistOf(map[string]any{"name": "HasUniqueTag", "elapsed_time": float64(0), "help": "tag"}))
+	})
+
+	It("should format the XML results with the same root element", func() {
+		b, err := genericXMLFormatter(ctx, results)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(b)).To(HavePrefix("<UserResponse>"))
+		Expect(string(b)).ToNot(ContainSubstring("image_digest"))
+	})
+
+	It("should report errored checks as failures in the JUnit results", func() {
+		b, err := junitXMLFormatter(ctx, results)
+		Expect(err).ToNot(HaveOccurred())
+
+		var suites junitTestSuitesV1_5
+		Expect(xml.Unmarshal(b, &suites)).To(Succeed())
+		Expect(suites.Suites).To(HaveLen(1))
+		suite := suites.Suites[0]
+		Expect(suite.Tests).To(Equal(3))
+		Expect(suite.Failures).To(Equal(2))
+		Expect(suite.Properties).To(BeEmpty())
+		Expect(suite.TestCases).To(HaveLen(3))
+		Expect(suite.TestCases[2].Name).To(Equal("RunAsNonRoot"))
+		Expect(suite.TestCases[2].Time).To(Equal("1.5s"))
+		Expect(suite.TestCases[2].Failure.Contents).To(Equal("root: Suggested Fix: USER"))
+		Expect(string(b)).ToNot(ContainSubstring(`errors="`))
+	})
+})
@@ -88,7 +88,10 @@ func (f *genericFormatter) FileExtension() string {
 // availableFormatters maps configuration-friendly values to pretty representations
 // of the same value, and their corresponding Formatter included with this library.
 var availableFormatters = map[string]ResponseFormatter{
-	"json":     &genericFormatter{"Generic JSON", "json", genericJSONFormatter},
-	"xml":      &genericFormatter{"Generic XML", "xml", genericXMLFormatter},
-	"junitxml": &genericFormatter{"JUnit XML", "xml", junitXMLFormatter},
+	"json":               &genericFormatter{"Generic JSON", "json", genericJSONFormatter},
+	"xml":                &genericFormatter{"Generic XML", "xml", genericXMLFormatter},
+	"junitxml":           &genericFormatter{"JUnit XML", "xml", junitXMLFormatter},
+	"nunitxml":           &genericFormatter{"NUnit XML", "xml", nunitXMLFormatter},
+	"checklist":          &genericFormatter{"Certification Checklist", "md", checklistFormatter},
+	"gitlab-codequality": &genericFormatter{"GitLab Code Quality", "json", codeQualityFormatter},
 }
@@ -7,6 +7,7 @@ import (
 	"fmt"
 
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/certification"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/compat"
 )
 
 var (
@@ -16,7 +17,10 @@ var (
 
 // genericJSONFormatter is a FormatterFunc that formats results as JSON
 func genericJSONFormatter(ctx context.Context, r certification.Results) ([]byte, error) {
-	response := getResponse(r)
+	var response any = getResponse(r)
+	if compat.FromContext(ctx) == compat.V1_5 {
+		response = getResponseV1_5(r)
+	}
 
 	responseJSON, err := jsonMarshalIndent(response, "", "    ")
 	if err != nil {
@@ -33,7 +37,10 @@ func genericJSONFormatter(ctx context.Context, r certification.Results) ([]byte,
 
 // genericXMLFormatter is a FormatterFunc that formats results as XML
 func genericXMLFormatter(ctx context.Context, r certification.Results) ([]byte, error) {
-	response := getResponse(r)
+	var response any = getResponse(r)
+	if compat.FromContext(ctx) == compat.V1_5 {
+		response = getResponseV1_5(r)
+	}
 
 	responseXML, err := xmlMarshalIndent(response, "", "    ")
 	if err != nil {
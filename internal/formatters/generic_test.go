@@ -166,3 +166,215 @@ func TestGenericXMLFormatter(t *testing.T) {
 		}
 	}
 }
+
+func TestGenericJSONFormatterSkippedLayers(t *testing.T) {
+	jsonMarshalIndent = json.MarshalIndent
+
+	results := certification.Results{TestedImage: "image1", PassedOverall: true}
+
+	funcOutput, err := genericJSONFormatter(context.TODO(), results)
+	assert.NilError(t, err)
+	assert.Assert(t, !strings.Contains(string(funcOutput), "skipped_layers"))
+
+	results.SkippedLayers = []certification.SkippedLayer{
+		{
+			Digest:    "sha256:0123",
+			MediaType: "application/vnd.docker.image.rootfs.foreign.diff.tar.gzip",
+			Reason:    "skipped: unsupported foreign layer",
+		},
+	}
+
+	funcOutput, err = genericJSONFormatter(context.TODO(), results)
+	assert.NilError(t, err)
+
+	var testResponseObj UserResponse
+	assert.NilError(t, json.Unmarshal(funcOutput, &testResponseObj))
+	assert.Equal(t, len(testResponseObj.SkippedLayers), 1)
+	assert.Equal(t, testResponseObj.SkippedLayers[0].Digest, "sha256:0123")
+	assert.Equal(t, testResponseObj.SkippedLayers[0].MediaType, "application/vnd.docker.image.rootfs.foreign.diff.tar.gzip")
+	assert.Equal(t, testResponseObj.SkippedLayers[0].Reason, "skipped: unsupported foreign layer")
+}
+
+func TestGenericJSONFormatterFlakes(t *testing.T) {
+	jsonMarshalIndent = json.MarshalIndent
+
+	passed := check.NewGenericCheck("passed", nil, check.Metadata{}, check.HelpText{})
+	flaky := check.NewGenericCheck("flaky", nil, check.Metadata{}, check.HelpText{})
+	failed := check.NewGenericCheck("failed", nil, check.Metadata{}, check.HelpText{})
+	results := certification.Results{
+		TestedImage: "image1",
+		Passed: []certification.Result{
+			{Check: passed, Attempts: 1},
+			{Check: flaky, Attempts: 2},
+		},
+	}
+
+	funcOutput, err := genericJSONFormatter(context.TODO(), results)
+	assert.NilError(t, err)
+
+	var testResponseObj UserResponse
+	assert.NilError(t, json.Unmarshal(funcOutput, &testResponseObj))
+	assert.Equal(t, testResponseObj.Results.Passed[0].Attempts, 0)
+	assert.Equal(t, testResponseObj.Results.Passed[0].PassedAfterRetry, false)
+	assert.Equal(t, testResponseObj.Results.Passed[1].Attempts, 2)
+	assert.Equal(t, testResponseObj.Results.Passed[1].PassedAfterRetry, true)
+	assert.DeepEqual(t, testResponseObj.Flakes, &flakeStatistics{RetriedChecks: 1, PassedAfterRetry: 1})
+
+	results.Failed = []certification.Result{{Check: failed, Attempts: 3}}
+	funcOutput, err = genericJSONFormatter(context.TODO(), results)
+	assert.NilError(t, err)
+
+	testResponseObj = UserResponse{}
+	assert.NilError(t, json.Unmarshal(funcOutput, &testResponseObj))
+	assert.Equal(t, testResponseObj.Results.Failed[0].Attempts, 3)
+	assert.Equal(t, testResponseObj.Results.Failed[0].PassedAfterRetry, false)
+	assert.DeepEqual(t, testResponseObj.Flakes, &flakeStatistics{RetriedChecks: 2, PassedAfterRetry: 1})
+
+	results.Passed = results.Passed[:1]
+	results.Failed = nil
+	funcOutput, err = genericJSONFormatter(context.TODO(), results)
+	assert.NilError(t, err)
+	assert.Assert(t, !strings.Contains(string(funcOutput), "flakes"))
+}
+
+func TestGenericJSONFormatterStartTime(t *testing.T) {
+	jsonMarshalIndent = json.MarshalIndent
+
+	started := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	results := certification.Results{
+		TestedImage: "image1",
+		Passed: []certification.Result{
+			{Check: check.NewGenericCheck("started", nil, check.Metadata{}, check.HelpText{}), StartTime: started, ElapsedTime: 1500 * time.Millisecond},
+			{Check: check.NewGenericCheck("unknown", nil, check.Metadata{}, check.HelpText{})},
+		},
+	}
+
+	funcOutput, err := genericJSONFormatter(context.TODO(), results)
+	assert.NilError(t, err)
+	assert.Assert(t, strings.Contains(string(funcOutput), `"start_time": "2024-05-01T12:30:00Z"`))
+
+	var testResponseObj UserResponse
+	assert.NilError(t, json.Unmarshal(funcOutput, &testResponseObj))
+	assert.Assert(t, testResponseObj.Results.Passed[0].StartTime != nil)
+	assert.Assert(t, testResponseObj.Results.Passed[0].StartTime.Equal(started))
+	assert.Equal(t, testResponseObj.Results.Passed[0].ElapsedTime, float64(1500))
+	assert.Assert(t, testResponseObj.Results.Passed[1].StartTime == nil)
+}
+
+func TestGenericJSONFormatterRemediation(t *testing.T) {
+	jsonMarshalIndent = json.MarshalIndent
+
+	metadata := check.Metadata{Description: "description", KnowledgeBaseURL: "https://kb.example.com", CheckURL: "https://docs.example.com"}
+	help := check.HelpText{Message: "Check failed.", Suggestion: "Fix it."}
+	results := certification.Results{
+		TestedImage: "image1",
+		Failed:      []certification.Result{{Check: check.NewGenericCheck("failed", nil, metadata, help)}},
+		Errors:      []certification.Result{{Check: check.NewGenericCheck("errored", nil, metadata, help)}},
+	}
+
+	funcOutput, err := genericJSONFormatter(context.TODO(), results)
+	assert.NilError(t, err)
+
+	var testResponseObj UserResponse
+	assert.NilError(t, json.Unmarshal(funcOutput, &testResponseObj))
+	for _, info := range []checkExecutionInfo{testResponseObj.Results.Failed[0], testResponseObj.Results.Errors[0]} {
+		assert.Equal(t, info.Description, "description")
+		assert.Equal(t, info.Help, "Check failed.")
+		assert.Equal(t, info.Suggestion, "Fix it.")
+		assert.Equal(t, info.KnowledgeBaseURL, "https://kb.example.com")
+		assert.Equal(t, info.CheckURL, "https://docs.example.com")
+	}
+}
+
+func TestGenericJSONFormatterDeniedConnections(t *testing.T) {
+	jsonMarshalIndent = json.MarshalIndent
+
+	results := certification.Results{TestedImage: "image1", PassedOverall: true}
+
+	funcOutput, err := genericJSONFormatter(context.TODO(), results)
+	assert.NilError(t, err)
+	assert.Assert(t, !strings.Contains(string(funcOutput), "denied_connections"))
+
+	results.DeniedConnections = []certification.DeniedConnection{
+		{Check: "HasUniqueTag", Address: "quay.io:443"},
+	}
+
+	funcOutput, err = genericJSONFormatter(context.TODO(), results)
+	assert.NilError(t, err)
+
+	var testResponseObj UserResponse
+	assert.NilError(t, json.Unmarshal(funcOutput, &testResponseObj))
+	assert.Equal(t, len(testResponseObj.DeniedConnections), 1)
+	assert.Equal(t, testResponseObj.DeniedConnections[0].Check, "HasUniqueTag")
+	assert.Equal(t, testResponseObj.DeniedConnections[0].Address, "quay.io:443")
+}
+
+// sourcedCheck is a check that describes the provenance of its results.
+type sourcedCheck struct {
+	check.Check
+}
+
+func (c sourcedCheck) Provenance() check.Provenance {
+	return check.Provenance{DataSources: []check.DataSource{check.DataSourceManifest, check.DataSourceCluster}, Confidence: check.ConfidenceMedium}
+}
+
+func TestGenericJSONFormatterSkippedChecks(t *testing.T) {
+	jsonMarshalIndent = json.MarshalIndent
+
+	results := certification.Results{
+		TestedImage: "image1",
+		Passed: []certification.Result{
+			{Check: check.NewGenericCheck("HasLicense", nil, check.Metadata{}, check.HelpText{})},
+		},
+		Skipped: []certification.Result{
+			{Check: check.NewGenericCheck("RunAsNonRoot", nil, check.Metadata{}, check.HelpText{}), Reason: "preflight was interrupted"},
+		},
+	}
+
+	funcOutput, err := genericJSONFormatter(context.TODO(), results)
+	assert.NilError(t, err)
+
+	var testResponseObj UserResponse
+	assert.NilError(t, json.Unmarshal(funcOutput, &testResponseObj))
+	assert.Equal(t, len(testResponseObj.Results.Skipped), 1)
+	assert.Equal(t, testResponseObj.Results.Skipped[0].Name, "RunAsNonRoot")
+	assert.Equal(t, testResponseObj.Results.Skipped[0].Reason, "preflight was interrupted")
+}
+
+func TestGenericJSONFormatterImageDigest(t *testing.T) {
+	jsonMarshalIndent = json.MarshalIndent
+
+	results := certification.Results{
+		TestedImage: "image1",
+		ImageDigest: "sha256:0123",
+	}
+
+	funcOutput, err := genericJSONFormatter(context.TODO(), results)
+	assert.NilError(t, err)
+
+	var testResponseObj UserResponse
+	assert.NilError(t, json.Unmarshal(funcOutput, &testResponseObj))
+	assert.Equal(t, testResponseObj.ImageDigest, "sha256:0123")
+}
+
+func TestGenericJSONFormatterProvenance(t *testing.T) {
+	jsonMarshalIndent = json.MarshalIndent
+
+	results := certification.Results{
+		TestedImage: "image1",
+		Failed: []certification.Result{
+			{Check: sourcedCheck{Check: check.NewGenericCheck("sourced", nil, check.Metadata{}, check.HelpText{})}},
+			{Check: check.NewGenericCheck("unsourced", nil, check.Metadata{}, check.HelpText{})},
+		},
+	}
+
+	funcOutput, err := genericJSONFormatter(context.TODO(), results)
+	assert.NilError(t, err)
+
+	var testResponseObj UserResponse
+	assert.NilError(t, json.Unmarshal(funcOutput, &testResponseObj))
+	assert.DeepEqual(t, testResponseObj.Results.Failed[0].DataSources, []check.DataSource{check.DataSourceManifest, check.DataSourceCluster})
+	assert.Equal(t, testResponseObj.Results.Failed[0].Confidence, check.ConfidenceMedium)
+	assert.Equal(t, len(testResponseObj.Results.Failed[1].DataSources), 0)
+	assert.Equal(t, testResponseObj.Results.Failed[1].Confidence, check.Confidence(""))
+}
@@ -4,9 +4,14 @@ import (
 	"context"
 	"encoding/xml"
 	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/artifacts"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/certification"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/compat"
 )
 
 type JUnitTestSuites struct {
@@ -18,10 +23,13 @@ type JUnitTestSuite struct {
 	XMLName    xml.Name        `xml:"testsuite"`
 	Tests      int             `xml:"tests,attr"`
 	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr"`
 	Time       string          `xml:"time,attr"`
+	Timestamp  string          `xml:"timestamp,attr,omitempty"`
 	Name       string          `xml:"name,attr"`
 	Properties []JUnitProperty `xml:"properties>property,omitempty"`
 	TestCases  []JUnitTestCase `xml:"testcase"`
+	SystemOut  string          `xml:"system-out,omitempty"`
 }
 
 type JUnitTestCase struct {
@@ -29,8 +37,11 @@ type JUnitTestCase struct {
 	Classname   string            `xml:"classname,attr"`
 	Name        string            `xml:"name,attr"`
 	Time        string            `xml:"time,attr"`
+	Timestamp   string            `xml:"timestamp,attr,omitempty"`
+	Properties  []JUnitProperty   `xml:"properties>property,omitempty"`
 	SkipMessage *JUnitSkipMessage `xml:"skipped,omitempty"`
 	Failure     *JUnitFailure     `xml:"failure,omitempty"`
+	Error       *JUnitFailure     `xml:"error,omitempty"`
 	SystemOut   string            `xml:"system-out,omitempty"`
 	Message     string            `xml:",chardata"`
 }
@@ -50,58 +61,254 @@ type JUnitFailure struct {
 	Contents string `xml:",chardata"`
 }
 
+// DefaultJUnitTestSuiteName is the name of the test suite when a single
+// asset is checked.
+const DefaultJUnitTestSuiteName = "Red Hat Certification"
+
 func junitXMLFormatter(ctx context.Context, r certification.Results) ([]byte, error) {
+	if compat.FromContext(ctx) == compat.V1_5 {
+		return junitXMLFormatterV1_5(r)
+	}
+
+	return MarshalJUnit(NewJUnitTestSuite(ctx, DefaultJUnitTestSuiteName, r))
+}
+
+// MarshalJUnit returns suites as a JUnit XML report.
+func MarshalJUnit(suites ...JUnitTestSuite) ([]byte, error) {
+	bytes, err := xml.MarshalIndent(JUnitTestSuites{Suites: suites}, "", "\t")
+	if err != nil {
+		o := fmt.Errorf("error formatting results with formatter %s: %v",
+			"junitxml",
+			err,
+		)
+
+		return nil, o
+	}
+
+	return bytes, nil
+}
+
+// NewJUnitTestSuite returns r as a JUnit test suite named name, with a test case
+// for each check.
+func NewJUnitTestSuite(ctx context.Context, name string, r certification.Results) JUnitTestSuite {
 	response := getResponse(r)
-	suites := JUnitTestSuites{}
 	testsuite := JUnitTestSuite{
-		Tests:      len(r.Errors) + len(r.Failed) + len(r.Passed),
-		Failures:   len(r.Errors) + len(r.Failed),
-		Time:       "0s",
-		Name:       "Red Hat Certification",
-		Properties: []JUnitProperty{},
-		TestCases:  []JUnitTestCase{},
+		Tests:    len(r.Errors) + len(r.Failed) + len(r.Passed) + len(r.Waived) + len(r.Skipped),
+		Failures: len(r.Failed),
+		Errors:   len(r.Errors),
+		Time:     "0s",
+		Name:     name,
+		Properties: []JUnitProperty{
+			{Name: "image", Value: response.Image},
+			{Name: "passed", Value: strconv.FormatBool(response.Passed)},
+			{Name: "library_version", Value: response.LibraryInfo.Version},
+			{Name: "library_commit", Value: response.LibraryInfo.Commit},
+		},
+		TestCases: []JUnitTestCase{},
+		SystemOut: artifactAttachments(ctx),
+	}
+
+	if r.TestedOn.Name != "" {
+		testsuite.Properties = append(testsuite.Properties, JUnitProperty{Name: "tested_on", Value: fmt.Sprintf("%s %s", r.TestedOn.Name, r.TestedOn.Version)})
+	}
+
+	if response.CertificationHash != "" {
+		testsuite.Properties = append(testsuite.Properties, JUnitProperty{Name: "certification_hash", Value: response.CertificationHash})
 	}
 
 	totalDuration := time.Duration(0)
 	for _, result := range r.Passed {
 		testCase := JUnitTestCase{
-			Classname: response.Image,
-			Name:      result.Name(),
-			Time:      fmt.Sprintf("%f", result.ElapsedTime.Seconds()),
-			Failure:   nil,
-			Message:   result.Metadata().Description,
+			Classname:  response.Image,
+			Name:       result.Name(),
+			Time:       junitSeconds(result.ElapsedTime),
+			Timestamp:  junitTimestamp(result.StartTime),
+			Properties: checkProperties(result),
+			Failure:    nil,
+			Message:    result.Metadata().Description,
 		}
 		testsuite.TestCases = append(testsuite.TestCases, testCase)
 		totalDuration += result.ElapsedTime
 	}
 
-	for _, result := range append(r.Errors, r.Failed...) {
+	for _, result := range r.Failed {
 		testCase := JUnitTestCase{
-			Classname: response.Image,
-			Name:      result.Name(),
-			Time:      result.ElapsedTime.String(),
+			Classname:  response.Image,
+			Name:       result.Name(),
+			Time:       junitSeconds(result.ElapsedTime),
+			Timestamp:  junitTimestamp(result.StartTime),
+			Properties: checkProperties(result),
 			Failure: &JUnitFailure{
-				Message:  "Failed",
+				Message:  failureMessage(result),
 				Type:     "",
-				Contents: fmt.Sprintf("%s: Suggested Fix: %s", result.Help().Message, result.Help().Suggestion),
+				Contents: remediation(result),
 			},
 		}
 		testsuite.TestCases = append(testsuite.TestCases, testCase)
 		totalDuration += result.ElapsedTime
 	}
 
-	testsuite.Time = fmt.Sprintf("%f", totalDuration.Seconds())
-	suites.Suites = append(suites.Suites, testsuite)
+	for _, result := range r.Errors {
+		message := "Errored"
+		if result.Reason != "" {
+			message += ": " + result.Reason
+		}
+		testCase := JUnitTestCase{
+			Classname:  response.Image,
+			Name:       result.Name(),
+			Time:       junitSeconds(result.ElapsedTime),
+			Timestamp:  junitTimestamp(result.StartTime),
+			Properties: checkProperties(result),
+			Error: &JUnitFailure{
+				Message:  message,
+				Type:     "",
+				Contents: remediation(result),
+			},
+		}
+		testsuite.TestCases = append(testsuite.TestCases, testCase)
+		totalDuration += result.ElapsedTime
+	}
 
-	bytes, err := xml.MarshalIndent(suites, "", "\t")
-	if err != nil {
-		o := fmt.Errorf("error formatting results with formatter %s: %v",
-			"junitxml",
-			err,
-		)
+	// Waived checks are skipped, so that they neither pass nor fail the suite.
+	for _, result := range r.Waived {
+		testCase := JUnitTestCase{
+			Classname:  response.Image,
+			Name:       result.Name(),
+			Time:       junitSeconds(result.ElapsedTime),
+			Timestamp:  junitTimestamp(result.StartTime),
+			Properties: checkProperties(result),
+			SkipMessage: &JUnitSkipMessage{
+				Message: waiverMessage(result),
+			},
+		}
+		testsuite.TestCases = append(testsuite.TestCases, testCase)
+		totalDuration += result.ElapsedTime
+	}
 
-		return nil, o
+	// Checks that did not complete because preflight was interrupted are skipped.
+	for _, result := range r.Skipped {
+		testsuite.TestCases = append(testsuite.TestCases, JUnitTestCase{
+			Classname:  response.Image,
+			Name:       result.Name(),
+			Time:       junitSeconds(result.ElapsedTime),
+			Properties: checkProperties(result),
+			SkipMessage: &JUnitSkipMessage{
+				Message: "Skipped: " + result.Reason,
+			},
+		})
 	}
 
-	return bytes, nil
+	testsuite.Time = junitSeconds(totalDuration)
+	testsuite.Timestamp = junitTimestamp(earliestStartTime(r))
+
+	return testsuite
+}
+
+// failureMessage summarizes the failure of result, with its help message, if any.
+func failureMessage(result certification.Result) string {
+	if result.Help().Message == "" {
+		return "Failed"
+	}
+	return "Failed: " + result.Help().Message
+}
+
+// remediation describes how to fix the failure or error of result, followed by the
+// links to its documentation, if any, one per line.
+func remediation(result certification.Result) string {
+	lines := []string{fmt.Sprintf("%s: Suggested Fix: %s", result.Help().Message, result.Help().Suggestion)}
+	if url := result.Metadata().KnowledgeBaseURL; url != "" {
+		lines = append(lines, "Knowledge Base: "+url)
+	}
+	if url := result.Metadata().CheckURL; url != "" {
+		lines = append(lines, "Check Documentation: "+url)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// waiverMessage describes the waiver of the failure of result.
+func waiverMessage(result certification.Result) string {
+	if result.Waiver == nil {
+		return "Waived"
+	}
+	return fmt.Sprintf("Waived until %s: %s", result.Waiver.Expires.Format(time.RFC3339), result.Waiver.Justification)
+}
+
+// junitSeconds represents d as fractional seconds, as expected by JUnit consumers.
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%f", d.Seconds())
+}
+
+// junitTimestamp represents t in the ISO 8601 format expected by JUnit consumers, or
+// returns an empty string if t is zero, so that it is omitted.
+func junitTimestamp(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format("2006-01-02T15:04:05")
+}
+
+// earliestStartTime returns when the first of the checks in r started executing, or the
+// zero time if none of them recorded it.
+func earliestStartTime(r certification.Results) time.Time {
+	var earliest time.Time
+	for _, results := range [][]certification.Result{r.Passed, r.Failed, r.Errors, r.Waived} {
+		for _, result := range results {
+			if !result.StartTime.IsZero() && (earliest.IsZero() || result.StartTime.Before(earliest)) {
+				earliest = result.StartTime
+			}
+		}
+	}
+
+	return earliest
+}
+
+// checkProperties returns the metadata of the check in result as JUnit properties.
+// Empty values are omitted.
+func checkProperties(result certification.Result) []JUnitProperty {
+	md := result.Metadata()
+	props := []JUnitProperty{}
+	for _, p := range []JUnitProperty{
+		{Name: "description", Value: md.Description},
+		{Name: "level", Value: md.Level},
+		{Name: "knowledge_base_url", Value: md.KnowledgeBaseURL},
+		{Name: "check_url", Value: md.CheckURL},
+	} {
+		if p.Value != "" {
+			props = append(props, p)
+		}
+	}
+
+	return props
+}
+
+// artifactAttachments returns a [[ATTACHMENT|path]] reference for each file written
+// by the filesystem artifact writer configured in ctx during this execution, one per
+// line. CI systems such as Jenkins and Allure use these references to attach files to
+// the report. Files left in the artifacts directory by previous executions, and the
+// results reports themselves, are not attached. An empty string is returned if
+// artifacts are not being written to the filesystem.
+func artifactAttachments(ctx context.Context) string {
+	aw, ok := artifacts.WriterFromContext(ctx).(*artifacts.FilesystemWriter)
+	if !ok || aw == nil {
+		return ""
+	}
+
+	attachments := []string{}
+	for _, f := range aw.WrittenFiles() {
+		if isResultsReport(f) {
+			continue
+		}
+		attachments = append(attachments, fmt.Sprintf("[[ATTACHMENT|%s]]", f))
+	}
+
+	return strings.Join(attachments, "\n")
+}
+
+// isResultsReport returns true if path is one of the results files written by
+// preflight, e.g. results.json or results-junit.xml. These may still be in the
+// process of being written when the JUnit report is formatted.
+func isResultsReport(path string) bool {
+	base := filepath.Base(path)
+	return strings.HasPrefix(base, "results.") || strings.HasPrefix(base, "results-")
 }
@@ -2,8 +2,15 @@ package formatters
 
 import (
 	"context"
+	"encoding/xml"
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/artifacts"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/certification"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/image"
@@ -87,5 +94,111 @@ var _ = Describe("JUnitXML Formatter", func() {
 			Expect(string(out)).To(ContainSubstring("FailedCheck"))
 			Expect(string(out)).To(ContainSubstring("ErroredCheck"))
 		})
+
+		It("should separate failures from errors and include check metadata as properties", func() {
+			out, err := junitXMLFormatter(context.TODO(), response)
+			Expect(err).ToNot(HaveOccurred())
+
+			var suites JUnitTestSuites
+			Expect(xml.Unmarshal(out, &suites)).To(Succeed())
+			Expect(suites.Suites).To(HaveLen(1))
+			Expect(suites.Suites[0].Failures).To(Equal(1))
+			Expect(suites.Suites[0].Errors).To(Equal(1))
+			Expect(suites.Suites[0].Properties).To(ContainElement(JUnitProperty{Name: "image", Value: "example.com/repo/image:tag"}))
+			Expect(suites.Suites[0].Properties).To(ContainElement(JUnitProperty{Name: "tested_on", Value: "ClusterName Clusterversion"}))
+			Expect(suites.Suites[0].TestCases).To(ContainElement(And(
+				HaveField("Name", "FailedCheck"),
+				HaveField("Failure", Equal(&JUnitFailure{
+					Message:  "Failed: helptext",
+					Contents: "helptext: Suggested Fix: suggestion\nKnowledge Base: kburl\nCheck Documentation: checkurl",
+				})),
+			)))
+			Expect(suites.Suites[0].TestCases).To(ContainElement(And(
+				HaveField("Name", "ErroredCheck"),
+				HaveField("Error.Contents", ContainSubstring("Knowledge Base: kburl")),
+			)))
+			for _, tc := range suites.Suites[0].TestCases {
+				Expect(tc.Time).To(Equal("0.000000"))
+				Expect(tc.Properties).To(ContainElement(JUnitProperty{Name: "knowledge_base_url", Value: "kburl"}))
+				Expect(tc.Properties).To(ContainElement(JUnitProperty{Name: "check_url", Value: "checkurl"}))
+			}
+		})
+
+		It("should skip waived checks with the justification of their waiver", func() {
+			waived := response.Failed[0]
+			waived.Waiver = &certification.Waiver{Image: "example.com/repo/*", Justification: "approved in CERT-1", Expires: time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)}
+			response.Waived = []certification.Result{waived}
+			response.Failed = nil
+
+			out, err := junitXMLFormatter(context.TODO(), response)
+			Expect(err).ToNot(HaveOccurred())
+
+			var suites JUnitTestSuites
+			Expect(xml.Unmarshal(out, &suites)).To(Succeed())
+			Expect(suites.Suites[0].Tests).To(Equal(3))
+			Expect(suites.Suites[0].Failures).To(Equal(0))
+			Expect(suites.Suites[0].TestCases).To(ContainElement(And(
+				HaveField("Name", "FailedCheck"),
+				HaveField("Failure", BeNil()),
+				HaveField("SkipMessage", Equal(&JUnitSkipMessage{Message: "Waived until 2024-12-31T00:00:00Z: approved in CERT-1"})),
+			)))
+		})
+
+		It("should record when each check started, and when the first of them started", func() {
+			response.Passed[0].StartTime = time.Date(2024, 5, 1, 12, 30, 5, 0, time.UTC)
+			response.Failed[0].StartTime = time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+
+			out, err := junitXMLFormatter(context.TODO(), response)
+			Expect(err).ToNot(HaveOccurred())
+
+			var suites JUnitTestSuites
+			Expect(xml.Unmarshal(out, &suites)).To(Succeed())
+			Expect(suites.Suites[0].Timestamp).To(Equal("2024-05-01T12:30:00"))
+			Expect(suites.Suites[0].TestCases).To(ContainElement(And(HaveField("Name", "PassedCheck"), HaveField("Timestamp", "2024-05-01T12:30:05"))))
+			Expect(suites.Suites[0].TestCases).To(ContainElement(And(HaveField("Name", "FailedCheck"), HaveField("Timestamp", "2024-05-01T12:30:00"))))
+			Expect(suites.Suites[0].TestCases).To(ContainElement(And(HaveField("Name", "ErroredCheck"), HaveField("Timestamp", ""))))
+		})
+
+		It("should not include a tested_on property when no cluster was used", func() {
+			response.TestedOn = runtime.OpenshiftClusterVersion{}
+			out, err := junitXMLFormatter(context.TODO(), response)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(out)).ToNot(ContainSubstring("tested_on"))
+		})
+
+		Context("and a filesystem artifact writer in the context", func() {
+			var ctx context.Context
+			var aw *artifacts.FilesystemWriter
+
+			BeforeEach(func() {
+				tmpDir, err := os.MkdirTemp("", "junit-attachments-*")
+				Expect(err).ToNot(HaveOccurred())
+				DeferCleanup(os.RemoveAll, tmpDir)
+				aw, err = artifacts.NewFilesystemWriter(artifacts.WithDirectory(tmpDir))
+				Expect(err).ToNot(HaveOccurred())
+				ctx = artifacts.ContextWithWriter(context.Background(), aw)
+			})
+
+			It("should reference written artifacts as attachments", func() {
+				fullpath, err := aw.WriteFile("cert-image.json", strings.NewReader("{}"))
+				Expect(err).ToNot(HaveOccurred())
+
+				out, err := junitXMLFormatter(ctx, response)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(out)).To(ContainSubstring(fmt.Sprintf("[[ATTACHMENT|%s]]", fullpath)))
+			})
+
+			It("should not reference stale artifacts or the results reports", func() {
+				stale := filepath.Join(aw.Path(), "stale.json")
+				Expect(os.WriteFile(stale, []byte("{}"), 0o644)).To(Succeed())
+				resultsFile, err := aw.WriteFile("results.json", strings.NewReader(""))
+				Expect(err).ToNot(HaveOccurred())
+
+				out, err := junitXMLFormatter(ctx, response)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(out)).ToNot(ContainSubstring(stale))
+				Expect(string(out)).ToNot(ContainSubstring(resultsFile))
+			})
+		})
 	})
 })
@@ -0,0 +1,161 @@
+package formatters
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/certification"
+)
+
+const (
+	nunitResultPassed  = "Passed"
+	nunitResultFailed  = "Failed"
+	nunitResultSkipped = "Skipped"
+	// nunitLabelError distinguishes errored checks from failed checks, which
+	// both have the Failed result.
+	nunitLabelError = "Error"
+	// nunitLabelWaived labels failed checks whose failures were waived, which
+	// are skipped.
+	nunitLabelWaived = "Waived"
+	// nunitLabelInterrupted labels checks that did not complete because preflight
+	// was interrupted, which are skipped.
+	nunitLabelInterrupted = "Interrupted"
+)
+
+// NUnitTestRun is the root element of an NUnit 3 test results report.
+type NUnitTestRun struct {
+	XMLName       xml.Name       `xml:"test-run"`
+	ID            string         `xml:"id,attr"`
+	Name          string         `xml:"name,attr"`
+	TestCaseCount int            `xml:"testcasecount,attr"`
+	Result        string         `xml:"result,attr"`
+	Total         int            `xml:"total,attr"`
+	Passed        int            `xml:"passed,attr"`
+	Failed        int            `xml:"failed,attr"`
+	Inconclusive  int            `xml:"inconclusive,attr"`
+	Skipped       int            `xml:"skipped,attr"`
+	Duration      string         `xml:"duration,attr"`
+	TestSuite     NUnitTestSuite `xml:"test-suite"`
+}
+
+type NUnitTestSuite struct {
+	Type          string          `xml:"type,attr"`
+	ID            string          `xml:"id,attr"`
+	Name          string          `xml:"name,attr"`
+	FullName      string          `xml:"fullname,attr"`
+	TestCaseCount int             `xml:"testcasecount,attr"`
+	Result        string          `xml:"result,attr"`
+	Total         int             `xml:"total,attr"`
+	Passed        int             `xml:"passed,attr"`
+	Failed        int             `xml:"failed,attr"`
+	Duration      string          `xml:"duration,attr"`
+	Properties    []NUnitProperty `xml:"properties>property,omitempty"`
+	TestCases     []NUnitTestCase `xml:"test-case"`
+}
+
+type NUnitTestCase struct {
+	ID       string        `xml:"id,attr"`
+	Name     string        `xml:"name,attr"`
+	FullName string        `xml:"fullname,attr"`
+	Result   string        `xml:"result,attr"`
+	Label    string        `xml:"label,attr,omitempty"`
+	Duration string        `xml:"duration,attr"`
+	Failure  *NUnitFailure `xml:"failure,omitempty"`
+	Reason   *NUnitReason  `xml:"reason,omitempty"`
+}
+
+type NUnitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type NUnitFailure struct {
+	Message string `xml:"message"`
+}
+
+// NUnitReason is why a test case was skipped.
+type NUnitReason struct {
+	Message string `xml:"message"`
+}
+
+// nunitXMLFormatter is a FormatterFunc that formats results as an NUnit 3 test
+// results report, which is ingested natively by e.g. Azure DevOps. Errored checks
+// are reported as failed test cases labelled Error.
+func nunitXMLFormatter(ctx context.Context, r certification.Results) ([]byte, error) {
+	response := getResponse(r)
+	suite := NUnitTestSuite{
+		Type:          "TestSuite",
+		ID:            "1",
+		Name:          DefaultJUnitTestSuiteName,
+		FullName:      response.Image,
+		TestCaseCount: len(r.Passed) + len(r.Failed) + len(r.Errors) + len(r.Waived) + len(r.Skipped),
+		Result:        nunitResultPassed,
+		Passed:        len(r.Passed),
+		Failed:        len(r.Failed) + len(r.Errors),
+		Properties: []NUnitProperty{
+			{Name: "image", Value: response.Image},
+			{Name: "passed", Value: strconv.FormatBool(response.Passed)},
+			{Name: "library_version", Value: response.LibraryInfo.Version},
+			{Name: "library_commit", Value: response.LibraryInfo.Commit},
+		},
+		TestCases: []NUnitTestCase{},
+	}
+	suite.Total = suite.TestCaseCount
+	if !r.PassedOverall {
+		suite.Result = nunitResultFailed
+	}
+
+	totalDuration := time.Duration(0)
+	add := func(results []certification.Result, result, label string, failure func(certification.Result) *NUnitFailure) {
+		for _, check := range results {
+			suite.TestCases = append(suite.TestCases, NUnitTestCase{
+				ID:       fmt.Sprintf("1-%d", len(suite.TestCases)+1),
+				Name:     check.Name(),
+				FullName: fmt.Sprintf("%s.%s", response.Image, check.Name()),
+				Result:   result,
+				Label:    label,
+				Duration: junitSeconds(check.ElapsedTime),
+				Failure:  failure(check),
+			})
+			totalDuration += check.ElapsedTime
+		}
+	}
+	failureFor := func(check certification.Result) *NUnitFailure {
+		return &NUnitFailure{Message: fmt.Sprintf("%s: Suggested Fix: %s", check.Help().Message, check.Help().Suggestion)}
+	}
+	add(r.Passed, nunitResultPassed, "", func(certification.Result) *NUnitFailure { return nil })
+	add(r.Failed, nunitResultFailed, "", failureFor)
+	add(r.Errors, nunitResultFailed, nunitLabelError, failureFor)
+	add(r.Waived, nunitResultSkipped, nunitLabelWaived, func(certification.Result) *NUnitFailure { return nil })
+	for i, check := range r.Waived {
+		suite.TestCases[len(suite.TestCases)-len(r.Waived)+i].Reason = &NUnitReason{Message: waiverMessage(check)}
+	}
+	add(r.Skipped, nunitResultSkipped, nunitLabelInterrupted, func(certification.Result) *NUnitFailure { return nil })
+	for i, check := range r.Skipped {
+		suite.TestCases[len(suite.TestCases)-len(r.Skipped)+i].Reason = &NUnitReason{Message: check.Reason}
+	}
+	suite.Duration = junitSeconds(totalDuration)
+
+	run := NUnitTestRun{
+		ID:            "0",
+		Name:          "Preflight",
+		TestCaseCount: suite.TestCaseCount,
+		Result:        suite.Result,
+		Total:         suite.Total,
+		Passed:        suite.Passed,
+		Failed:        suite.Failed,
+		Skipped:       len(r.Waived) + len(r.Skipped),
+		Duration:      suite.Duration,
+		TestSuite:     suite,
+	}
+
+	bytes, err := xml.MarshalIndent(run, "", "\t")
+	if err != nil {
+		return nil, fmt.Errorf("error formatting results with formatter %s: %v", "nunitxml", err)
+	}
+
+	return bytes, nil
+}
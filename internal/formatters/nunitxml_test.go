@@ -0,0 +1,80 @@
+package formatters
+
+import (
+	"context"
+	"encoding/xml"
+	"time"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/certification"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/image"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NUnit XML Formatter", func() {
+	newCheck := func(name string) check.Check {
+		return check.NewGenericCheck(
+			name,
+			func(ctx context.Context, ir image.ImageReference) (bool, error) { return true, nil },
+			check.Metadata{},
+			check.HelpText{Message: "Check failed.", Suggestion: "Fix it."},
+		)
+	}
+
+	var results certification.Results
+	BeforeEach(func() {
+		results = certification.Results{
+			TestedImage:   "example.com/repo/image:tag",
+			PassedOverall: false,
+			Passed:        []certification.Result{{Check: newCheck("HasLicense"), ElapsedTime: time.Second}},
+			Failed:        []certification.Result{{Check: newCheck("RunAsNonRoot"), ElapsedTime: time.Second}},
+			Errors:        []certification.Result{{Check: newCheck("HasUniqueTag")}},
+		}
+	})
+
+	format := func() NUnitTestRun {
+		out, err := nunitXMLFormatter(context.TODO(), results)
+		Expect(err).ToNot(HaveOccurred())
+
+		var run NUnitTestRun
+		Expect(xml.Unmarshal(out, &run)).To(Succeed())
+		return run
+	}
+
+	It("should count errored checks as failed", func() {
+		run := format()
+		Expect(run.Result).To(Equal("Failed"))
+		Expect(run.Total).To(Equal(3))
+		Expect(run.Passed).To(Equal(1))
+		Expect(run.Failed).To(Equal(2))
+		Expect(run.Duration).To(Equal("2.000000"))
+		Expect(run.TestSuite.FullName).To(Equal("example.com/repo/image:tag"))
+	})
+
+	It("should report each check as a test case", func() {
+		cases := format().TestSuite.TestCases
+		Expect(cases).To(HaveLen(3))
+
+		Expect(cases[0].Name).To(Equal("HasLicense"))
+		Expect(cases[0].Result).To(Equal("Passed"))
+		Expect(cases[0].Failure).To(BeNil())
+
+		Expect(cases[1].Name).To(Equal("RunAsNonRoot"))
+		Expect(cases[1].Result).To(Equal("Failed"))
+		Expect(cases[1].Label).To(BeEmpty())
+		Expect(cases[1].Failure.Message).To(Equal("Check failed.: Suggested Fix: Fix it."))
+
+		Expect(cases[2].Name).To(Equal("HasUniqueTag"))
+		Expect(cases[2].Result).To(Equal("Failed"))
+		Expect(cases[2].Label).To(Equal("Error"))
+	})
+
+	It("should pass when all checks pass", func() {
+		results.PassedOverall = true
+		results.Failed = nil
+		results.Errors = nil
+		Expect(format().Result).To(Equal("Passed"))
+	})
+})
@@ -1,7 +1,10 @@
 package formatters
 
 import (
+	"time"
+
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/certification"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/version"
 )
 
@@ -15,9 +18,14 @@ func getResponse(r certification.Results) UserResponse {
 	if len(r.Passed) > 0 {
 		for _, check := range r.Passed {
 			passedChecks = append(passedChecks, checkExecutionInfo{
-				Name:        check.Name(),
-				ElapsedTime: float64(check.ElapsedTime.Milliseconds()),
-				Description: check.Metadata().Description,
+				Name:             check.Name(),
+				StartTime:        startTime(check),
+				ElapsedTime:      float64(check.ElapsedTime.Milliseconds()),
+				Description:      check.Metadata().Description,
+				Attempts:         retriedAttempts(check),
+				DataSources:      provenance(check).DataSources,
+				Confidence:       provenance(check).Confidence,
+				PassedAfterRetry: check.Retried(),
 			})
 		}
 	}
@@ -26,12 +34,17 @@ func getResponse(r certification.Results) UserResponse {
 		for _, check := range r.Failed {
 			failedChecks = append(failedChecks, checkExecutionInfo{
 				Name:             check.Name(),
+				StartTime:        startTime(check),
 				ElapsedTime:      float64(check.ElapsedTime.Milliseconds()),
 				Description:      check.Metadata().Description,
 				Help:             check.Help().Message,
 				Suggestion:       check.Help().Suggestion,
 				KnowledgeBaseURL: check.Metadata().KnowledgeBaseURL,
 				CheckURL:         check.Metadata().CheckURL,
+				Attempts:         retriedAttempts(check),
+				DataSources:      provenance(check).DataSources,
+				Confidence:       provenance(check).Confidence,
+				KnownIssues:      knownIssues(check),
 			})
 		}
 	}
@@ -39,36 +52,186 @@ func getResponse(r certification.Results) UserResponse {
 	if len(r.Errors) > 0 {
 		for _, check := range r.Errors {
 			erroredChecks = append(erroredChecks, checkExecutionInfo{
-				Name:        check.Name(),
-				ElapsedTime: float64(check.ElapsedTime.Milliseconds()),
-				Description: check.Metadata().Description,
-				Help:        check.Help().Message,
+				Name:             check.Name(),
+				StartTime:        startTime(check),
+				ElapsedTime:      float64(check.ElapsedTime.Milliseconds()),
+				Description:      check.Metadata().Description,
+				Help:             check.Help().Message,
+				Suggestion:       check.Help().Suggestion,
+				KnowledgeBaseURL: check.Metadata().KnowledgeBaseURL,
+				CheckURL:         check.Metadata().CheckURL,
+				Attempts:         retriedAttempts(check),
+				DataSources:      provenance(check).DataSources,
+				Confidence:       provenance(check).Confidence,
+				KnownIssues:      knownIssues(check),
+				Reason:           check.Reason,
 			})
 		}
 	}
 
+	var waivedChecks []checkExecutionInfo
+	for _, check := range r.Waived {
+		info := checkExecutionInfo{
+			Name:             check.Name(),
+			StartTime:        startTime(check),
+			ElapsedTime:      float64(check.ElapsedTime.Milliseconds()),
+			Description:      check.Metadata().Description,
+			Help:             check.Help().Message,
+			Suggestion:       check.Help().Suggestion,
+			KnowledgeBaseURL: check.Metadata().KnowledgeBaseURL,
+			CheckURL:         check.Metadata().CheckURL,
+			Attempts:         retriedAttempts(check),
+			DataSources:      provenance(check).DataSources,
+			Confidence:       provenance(check).Confidence,
+		}
+		if check.Waiver != nil {
+			info.Waiver = &waiverInfo{
+				Image:         check.Waiver.Image,
+				Justification: check.Waiver.Justification,
+				Expires:       check.Waiver.Expires,
+			}
+		}
+		waivedChecks = append(waivedChecks, info)
+	}
+
+	var skippedChecks []checkExecutionInfo
+	for _, check := range r.Skipped {
+		skippedChecks = append(skippedChecks, checkExecutionInfo{
+			Name:             check.Name(),
+			Description:      check.Metadata().Description,
+			KnowledgeBaseURL: check.Metadata().KnowledgeBaseURL,
+			CheckURL:         check.Metadata().CheckURL,
+			Reason:           check.Reason,
+		})
+	}
+
+	var skippedLayers []skippedLayerInfo
+	for _, layer := range r.SkippedLayers {
+		skippedLayers = append(skippedLayers, skippedLayerInfo{
+			Digest:    layer.Digest,
+			MediaType: layer.MediaType,
+			Reason:    layer.Reason,
+		})
+	}
+
+	var deniedConnections []deniedConnectionInfo
+	for _, conn := range r.DeniedConnections {
+		deniedConnections = append(deniedConnections, deniedConnectionInfo{
+			Check:   conn.Check,
+			Address: conn.Address,
+		})
+	}
+
 	response := UserResponse{
 		Image:             r.TestedImage,
+		ImageDigest:       r.ImageDigest,
 		Passed:            r.PassedOverall,
 		LibraryInfo:       version.Version,
 		CertificationHash: r.CertificationHash,
 		Results: resultsText{
-			Passed: passedChecks,
-			Failed: failedChecks,
-			Errors: erroredChecks,
+			Passed:  passedChecks,
+			Failed:  failedChecks,
+			Errors:  erroredChecks,
+			Waived:  waivedChecks,
+			Skipped: skippedChecks,
 		},
+		SkippedLayers:     skippedLayers,
+		DeniedConnections: deniedConnections,
+		Flakes:            getFlakes(r),
 	}
 
 	return response
 }
 
+// provenance returns the provenance of the results of r.
+func provenance(r certification.Result) check.Provenance {
+	return check.ProvenanceOf(r.Check)
+}
+
+// knownIssues returns the known issues that may explain the failure or error of r.
+func knownIssues(r certification.Result) []knownIssueInfo {
+	var known []knownIssueInfo
+	for _, issue := range r.KnownIssues {
+		known = append(known, knownIssueInfo{ID: issue.ID, Title: issue.Title, URL: issue.URL})
+	}
+
+	return known
+}
+
+// startTime returns when r started executing, or nil if that is unknown, so that it
+// is omitted.
+func startTime(r certification.Result) *time.Time {
+	if r.StartTime.IsZero() {
+		return nil
+	}
+	t := r.StartTime
+	return &t
+}
+
+// retriedAttempts returns the number of times r was executed, if it was executed more
+// than once, so that it is omitted otherwise.
+func retriedAttempts(r certification.Result) int {
+	if !r.Retried() {
+		return 0
+	}
+	return r.Attempts
+}
+
+// getFlakes summarizes the checks of r that were executed more than once, or returns
+// nil if there are none.
+func getFlakes(r certification.Results) *flakeStatistics {
+	var flakes flakeStatistics
+	for _, results := range [][]certification.Result{r.Passed, r.Failed, r.Errors} {
+		for _, result := range results {
+			if result.Retried() {
+				flakes.RetriedChecks++
+			}
+		}
+	}
+	for _, result := range r.Passed {
+		if result.Retried() {
+			flakes.PassedAfterRetry++
+		}
+	}
+
+	if flakes.RetriedChecks == 0 {
+		return nil
+	}
+	return &flakes
+}
+
 // UserResponse is the standard user-facing response.
 type UserResponse struct {
 	Image             string                 `json:"image" xml:"image"`
+	ImageDigest       string                 `json:"image_digest,omitempty" xml:"image_digest,omitempty"`
 	Passed            bool                   `json:"passed" xml:"passed"`
 	CertificationHash string                 `json:"certification_hash,omitempty" xml:"certification_hash,omitempty"`
 	LibraryInfo       version.VersionContext `json:"test_library" xml:"test_library"`
 	Results           resultsText            `json:"results" xml:"results"`
+	SkippedLayers     []skippedLayerInfo     `json:"skipped_layers,omitempty" xml:"skipped_layers,omitempty"`
+	DeniedConnections []deniedConnectionInfo `json:"denied_connections,omitempty" xml:"denied_connections,omitempty"`
+	Flakes            *flakeStatistics       `json:"flakes,omitempty" xml:"flakes,omitempty"`
+}
+
+// flakeStatistics counts the checks that were executed more than once because they did
+// not pass at first, and those of them that passed eventually, which are likely flakes
+// of the environment they were executed in.
+type flakeStatistics struct {
+	RetriedChecks    int `json:"retried_checks" xml:"retried_checks"`
+	PassedAfterRetry int `json:"passed_after_retry" xml:"passed_after_retry"`
+}
+
+// skippedLayerInfo describes a layer whose contents were not checked.
+type skippedLayerInfo struct {
+	Digest    string `json:"digest" xml:"digest"`
+	MediaType string `json:"media_type" xml:"media_type"`
+	Reason    string `json:"reason" xml:"reason"`
+}
+
+// deniedConnectionInfo describes a connection that the egress policy denied a check.
+type deniedConnectionInfo struct {
+	Check   string `json:"check" xml:"check"`
+	Address string `json:"address" xml:"address"`
 }
 
 // resultsText represents the results of check execution against the asset.
@@ -76,16 +239,42 @@ type resultsText struct {
 	Passed []checkExecutionInfo `json:"passed" xml:"passed"`
 	Failed []checkExecutionInfo `json:"failed" xml:"failed"`
 	Errors []checkExecutionInfo `json:"errors" xml:"errors"`
+	// Waived are the failed checks whose failures were waived by a policy exception.
+	Waived []checkExecutionInfo `json:"waived,omitempty" xml:"waived,omitempty"`
+	// Skipped are the checks that did not complete because preflight was interrupted.
+	Skipped []checkExecutionInfo `json:"skipped,omitempty" xml:"skipped,omitempty"`
 }
 
 // checkExecutionInfo contains all possible output fields that a user might see in their result.
 // Empty fields will be omitted.
 type checkExecutionInfo struct {
-	Name             string  `json:"name,omitempty" xml:"name,omitempty"`
-	ElapsedTime      float64 `json:"elapsed_time" xml:"elapsed_time"`
-	Description      string  `json:"description,omitempty" xml:"description,omitempty"`
-	Help             string  `json:"help,omitempty" xml:"help,omitempty"`
-	Suggestion       string  `json:"suggestion,omitempty" xml:"suggestion,omitempty"`
-	KnowledgeBaseURL string  `json:"knowledgebase_url,omitempty" xml:"knowledgebase_url,omitempty"`
-	CheckURL         string  `json:"check_url,omitempty" xml:"check_url,omitempty"`
+	Name             string             `json:"name,omitempty" xml:"name,omitempty"`
+	StartTime        *time.Time         `json:"start_time,omitempty" xml:"start_time,omitempty"`
+	ElapsedTime      float64            `json:"elapsed_time" xml:"elapsed_time"`
+	Description      string             `json:"description,omitempty" xml:"description,omitempty"`
+	Help             string             `json:"help,omitempty" xml:"help,omitempty"`
+	Suggestion       string             `json:"suggestion,omitempty" xml:"suggestion,omitempty"`
+	KnowledgeBaseURL string             `json:"knowledgebase_url,omitempty" xml:"knowledgebase_url,omitempty"`
+	CheckURL         string             `json:"check_url,omitempty" xml:"check_url,omitempty"`
+	Attempts         int                `json:"attempts,omitempty" xml:"attempts,omitempty"`
+	PassedAfterRetry bool               `json:"passed_after_retry,omitempty" xml:"passed_after_retry,omitempty"`
+	Waiver           *waiverInfo        `json:"waiver,omitempty" xml:"waiver,omitempty"`
+	KnownIssues      []knownIssueInfo   `json:"known_issues,omitempty" xml:"known_issues,omitempty"`
+	DataSources      []check.DataSource `json:"data_sources,omitempty" xml:"data_sources,omitempty"`
+	Confidence       check.Confidence   `json:"confidence,omitempty" xml:"confidence,omitempty"`
+	Reason           string             `json:"reason,omitempty" xml:"reason,omitempty"`
+}
+
+// knownIssueInfo describes a known issue that may explain the failure or error of a check.
+type knownIssueInfo struct {
+	ID    string `json:"id" xml:"id"`
+	Title string `json:"title" xml:"title"`
+	URL   string `json:"url,omitempty" xml:"url,omitempty"`
+}
+
+// waiverInfo describes the policy exception that waived the failure of a check.
+type waiverInfo struct {
+	Image         string    `json:"image" xml:"image"`
+	Justification string    `json:"justification" xml:"justification"`
+	Expires       time.Time `json:"expires" xml:"expires"`
 }
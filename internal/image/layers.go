@@ -0,0 +1,94 @@
+package image
+
+import (
+	"fmt"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/decrypt"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+const (
+	// ReasonForeignLayer notes that a layer was skipped because it is a
+	// foreign or non-distributable layer, e.g. a Windows base layer.
+	ReasonForeignLayer = "skipped: unsupported foreign layer"
+	// ReasonUnsupportedMediaType notes that a layer was skipped because its
+	// media type is not a known layer media type.
+	ReasonUnsupportedMediaType = "skipped: unsupported media type"
+	// ReasonEncryptedLayer notes that a layer was skipped because it is
+	// encrypted, and was not decrypted.
+	ReasonEncryptedLayer = "skipped: encrypted layer, not decrypted"
+)
+
+// UnsupportedLayer is a layer whose contents cannot be extracted.
+type UnsupportedLayer struct {
+	v1.Layer
+	MediaType types.MediaType
+	Reason    string
+}
+
+// UnsupportedLayerReason returns why the contents of layer cannot be
+// extracted, or an empty string if they can.
+func UnsupportedLayerReason(layer v1.Layer) (string, error) {
+	mt, err := layer.MediaType()
+	if err != nil {
+		return "", fmt.Errorf("could not get layer media type: %w", err)
+	}
+
+	switch mt {
+	case types.OCILayer, types.OCILayerZStd, types.OCIUncompressedLayer, types.DockerLayer, types.DockerUncompressedLayer:
+		return "", nil
+	case types.DockerForeignLayer, types.OCIRestrictedLayer, types.OCIUncompressedRestrictedLayer:
+		return ReasonForeignLayer, nil
+	default:
+		if decrypt.IsEncrypted(mt) {
+			return ReasonEncryptedLayer, nil
+		}
+		return ReasonUnsupportedMediaType, nil
+	}
+}
+
+// SupportedLayers returns the layers of img whose contents can be extracted,
+// and the layers whose contents cannot, in the order they appear in img.
+func SupportedLayers(img v1.Image) ([]v1.Layer, []UnsupportedLayer, error) {
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not get image layers: %w", err)
+	}
+
+	supported := make([]v1.Layer, 0, len(layers))
+	var unsupported []UnsupportedLayer
+	for _, layer := range layers {
+		reason, err := UnsupportedLayerReason(layer)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if reason == "" {
+			supported = append(supported, layer)
+			continue
+		}
+
+		// The media type was already retrieved successfully above.
+		mt, _ := layer.MediaType()
+		unsupported = append(unsupported, UnsupportedLayer{Layer: layer, MediaType: mt, Reason: reason})
+	}
+
+	return supported, unsupported, nil
+}
+
+// WithLayers returns img with its layers replaced by layers, e.g. to extract
+// only the supported layers of img. All other image metadata is that of img.
+func WithLayers(img v1.Image, layers []v1.Layer) v1.Image {
+	return &layerSubset{Image: img, layers: layers}
+}
+
+type layerSubset struct {
+	v1.Image
+	layers []v1.Layer
+}
+
+func (i *layerSubset) Layers() ([]v1.Layer, error) {
+	return i.layers, nil
+}
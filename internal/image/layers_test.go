@@ -0,0 +1,81 @@
+package image
+
+import (
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Layers", func() {
+	var (
+		img       v1.Image
+		supported v1.Layer
+		foreign   v1.Layer
+		unknown   v1.Layer
+	)
+
+	BeforeEach(func() {
+		supported = static.NewLayer([]byte("supported"), types.OCIUncompressedLayer)
+		foreign = static.NewLayer([]byte("foreign"), types.DockerForeignLayer)
+		unknown = static.NewLayer([]byte("unknown"), types.MediaType("application/vnd.example.unknown"))
+
+		var err error
+		img, err = mutate.AppendLayers(empty.Image, foreign, supported, unknown)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	DescribeTable("UnsupportedLayerReason",
+		func(mt types.MediaType, expected string) {
+			reason, err := UnsupportedLayerReason(static.NewLayer([]byte("layer"), mt))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(reason).To(Equal(expected))
+		},
+		Entry("OCI layer", types.OCILayer, ""),
+		Entry("OCI zstd layer", types.OCILayerZStd, ""),
+		Entry("OCI uncompressed layer", types.OCIUncompressedLayer, ""),
+		Entry("Docker layer", types.DockerLayer, ""),
+		Entry("Docker uncompressed layer", types.DockerUncompressedLayer, ""),
+		Entry("Docker foreign layer", types.DockerForeignLayer, ReasonForeignLayer),
+		Entry("OCI restricted layer", types.OCIRestrictedLayer, ReasonForeignLayer),
+		Entry("OCI uncompressed restricted layer", types.OCIUncompressedRestrictedLayer, ReasonForeignLayer),
+		Entry("encrypted layer", types.MediaType("application/vnd.oci.image.layer.v1.tar+gzip+encrypted"), ReasonEncryptedLayer),
+		Entry("unknown media type", types.MediaType("application/vnd.example.unknown"), ReasonUnsupportedMediaType),
+	)
+
+	Context("SupportedLayers", func() {
+		It("should partition the layers in order", func() {
+			layers, unsupported, err := SupportedLayers(img)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(layers).To(HaveLen(1))
+			Expect(layers[0]).To(Equal(supported))
+
+			Expect(unsupported).To(HaveLen(2))
+			Expect(unsupported[0].Layer).To(Equal(foreign))
+			Expect(unsupported[0].MediaType).To(Equal(types.DockerForeignLayer))
+			Expect(unsupported[0].Reason).To(Equal(ReasonForeignLayer))
+			Expect(unsupported[1].Layer).To(Equal(unknown))
+			Expect(unsupported[1].Reason).To(Equal(ReasonUnsupportedMediaType))
+		})
+	})
+
+	Context("WithLayers", func() {
+		It("should only replace the layers", func() {
+			subset := WithLayers(img, []v1.Layer{supported})
+
+			layers, err := subset.Layers()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(layers).To(Equal([]v1.Layer{supported}))
+
+			expected, err := img.ConfigFile()
+			Expect(err).ToNot(HaveOccurred())
+			actual, err := subset.ConfigFile()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actual).To(Equal(expected))
+		})
+	})
+})
@@ -0,0 +1,49 @@
+package image
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// DefaultOS is the operating system of the images that are pulled, unless the platform
+// names another.
+const DefaultOS = "linux"
+
+// OSWindows is the operating system of Windows images.
+const OSWindows = "windows"
+
+// windowsFilesDir is the directory of the layers of Windows images that holds their
+// filesystem, i.e. C:\. The registry hives are in Hives, alongside it.
+const windowsFilesDir = "Files"
+
+// WindowsPath returns the path in the layers of a Windows image of p, a path of its
+// filesystem, e.g. /Files/licenses for /licenses.
+func WindowsPath(p string) string {
+	return path.Join("/", windowsFilesDir, p)
+}
+
+// ParsePlatform returns the platform described by platform, either an architecture,
+// e.g. arm64, or a full platform in the form os/arch[/variant], e.g. linux/arm/v7. The
+// operating system of an architecture is DefaultOS.
+func ParsePlatform(platform string) (*v1.Platform, error) {
+	if platform == "" {
+		return nil, fmt.Errorf("platform is empty")
+	}
+
+	if !strings.Contains(platform, "/") {
+		return &v1.Platform{OS: DefaultOS, Architecture: platform}, nil
+	}
+
+	p, err := v1.ParsePlatform(platform)
+	if err != nil {
+		return nil, fmt.Errorf("invalid platform %q: %w", platform, err)
+	}
+	if p.OS == "" || p.Architecture == "" {
+		return nil, fmt.Errorf("invalid platform %q: expected os/arch[/variant], e.g. linux/arm/v7", platform)
+	}
+
+	return p, nil
+}
@@ -0,0 +1,49 @@
+package image
+
+import (
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Platform", func() {
+	DescribeTable("parsing valid platforms",
+		func(platform string, expected v1.Platform) {
+			p, err := ParsePlatform(platform)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*p).To(Equal(expected))
+		},
+		Entry("an architecture", "arm64", v1.Platform{OS: "linux", Architecture: "arm64"}),
+		Entry("an os and architecture", "linux/s390x", v1.Platform{OS: "linux", Architecture: "s390x"}),
+		Entry("an os, architecture, and variant", "linux/arm/v7", v1.Platform{OS: "linux", Architecture: "arm", Variant: "v7"}),
+	)
+
+	DescribeTable("parsing invalid platforms",
+		func(platform string) {
+			_, err := ParsePlatform(platform)
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("an empty platform", ""),
+		Entry("a platform without an architecture", "linux/"),
+		Entry("a platform with too many parts", "linux/arm/v7/extra"),
+	)
+})
+
+var _ = Describe("Image filesystem", func() {
+	It("should be rooted at the filesystem of Linux images", func() {
+		r := ImageReference{ImageFSPath: "/tmp/fs", ImageOS: DefaultOS}
+		Expect(r.IsWindows()).To(BeFalse())
+		Expect(r.RootPath()).To(Equal("/tmp/fs"))
+	})
+
+	It("should be rooted at the Files directory of Windows images", func() {
+		r := ImageReference{ImageFSPath: "/tmp/fs", ImageOS: OSWindows}
+		Expect(r.IsWindows()).To(BeTrue())
+		Expect(r.RootPath()).To(Equal("/tmp/fs/Files"))
+	})
+
+	It("should map the paths of Windows images to their layers", func() {
+		Expect(WindowsPath("/licenses")).To(Equal("/Files/licenses"))
+	})
+})
@@ -1,6 +1,11 @@
 package image
 
-import v1 "github.com/google/go-containerregistry/pkg/v1"
+import (
+	"io/fs"
+	"path/filepath"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
 
 // ImageReference holds all things image-related
 type ImageReference struct {
@@ -10,4 +15,38 @@ type ImageReference struct {
 	ImageRepository string
 	ImageRegistry   string
 	ImageTagOrSha   string
+	// ImageOS is the operating system of the image, e.g. linux or windows, from its
+	// config.
+	ImageOS string
+	// Files are the entries of the filesystem of the image, as they were read from its
+	// layers, whether or not they were extracted to ImageFSPath. It is nil if the
+	// filesystem was not read from the layers.
+	Files []File
+}
+
+// File is an entry of the filesystem of an image: a file, a directory, or a link.
+type File struct {
+	// Path is the absolute path of the entry, e.g. /licenses/LICENSE.
+	Path string
+	// Mode is the type and permissions of the entry.
+	Mode fs.FileMode
+	// Size is the size of the contents of a file.
+	Size int64
+	// Linkname is the target of a link.
+	Linkname string
+}
+
+// IsWindows returns true if the image is a Windows image.
+func (r ImageReference) IsWindows() bool {
+	return r.ImageOS == OSWindows
+}
+
+// RootPath returns the path of the root of the image's filesystem, i.e. / for Linux
+// images, and C:\ for Windows images, whose layers hold it in a Files directory.
+func (r ImageReference) RootPath() string {
+	if r.IsWindows() {
+		return filepath.Join(r.ImageFSPath, windowsFilesDir)
+	}
+
+	return r.ImageFSPath
 }
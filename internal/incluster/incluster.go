@@ -0,0 +1,139 @@
+// Package incluster reads credentials from Kubernetes Secrets with the service account
+// of the pod preflight is running in, so that they do not have to be mounted as files.
+package incluster
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/openshift"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/rest"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DockerConfigKey is the key of the docker config in a kubernetes.io/dockerconfigjson Secret.
+	DockerConfigKey = corev1.DockerConfigJsonKey
+	// DefaultPyxisTokenKey is the key of the Pyxis API token in a Secret, unless another is referenced.
+	DefaultPyxisTokenKey = "token"
+)
+
+// namespaceFile is where the namespace of the pod's service account is mounted.
+var namespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// SecretReference refers to a key of a Secret.
+type SecretReference struct {
+	// Namespace is the namespace of the Secret. If empty, the pod's namespace is used.
+	Namespace string
+	Name      string
+	Key       string
+}
+
+// ParseSecretReference returns the SecretReference described by s, in the form
+// [namespace/]name[:key]. If s does not include a key, defaultKey is used.
+func ParseSecretReference(s, defaultKey string) (SecretReference, error) {
+	ref := SecretReference{Key: defaultKey}
+	invalid := func(reason string) error {
+		return fmt.Errorf("invalid secret reference %q, in the form [namespace/]name[:key]: %s", s, reason)
+	}
+
+	if i := strings.LastIndex(s, ":"); i >= 0 {
+		s, ref.Key = s[:i], s[i+1:]
+	}
+	if i := strings.Index(s, "/"); i >= 0 {
+		ref.Namespace, s = s[:i], s[i+1:]
+	}
+	ref.Name = s
+
+	if errs := validation.IsDNS1123Subdomain(ref.Name); len(errs) > 0 {
+		return SecretReference{}, invalid("the name " + strings.Join(errs, ", "))
+	}
+	if ref.Namespace != "" {
+		if errs := validation.IsDNS1123Label(ref.Namespace); len(errs) > 0 {
+			return SecretReference{}, invalid("the namespace " + strings.Join(errs, ", "))
+		}
+	}
+	if errs := validation.IsConfigMapKey(ref.Key); len(errs) > 0 {
+		return SecretReference{}, invalid("the key " + strings.Join(errs, ", "))
+	}
+
+	return ref, nil
+}
+
+func (r SecretReference) String() string {
+	s := r.Name + ":" + r.Key
+	if r.Namespace != "" {
+		s = r.Namespace + "/" + s
+	}
+	return s
+}
+
+// Namespace returns the namespace of the pod preflight is running in.
+func Namespace() (string, error) {
+	b, err := os.ReadFile(namespaceFile)
+	if err != nil {
+		return "", fmt.Errorf("could not determine the namespace of the pod: %w", err)
+	}
+
+	return strings.TrimSpace(string(b)), nil
+}
+
+// SecretReader reads keys of Secrets.
+type SecretReader struct {
+	client    openshift.Client
+	namespace string
+}
+
+// NewSecretReader returns a SecretReader using the service account of the pod preflight
+// is running in. Secrets are read from the pod's namespace, unless another is referenced.
+func NewSecretReader() (*SecretReader, error) {
+	cfg, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, fmt.Errorf("could not get in-cluster configuration: %w", err)
+	}
+
+	client, err := crclient.New(cfg, crclient.Options{})
+	if err != nil {
+		return nil, fmt.Errorf("could not get controller-runtime client: %w", err)
+	}
+
+	// The Secret may be in another namespace, so it is not an error if the pod's
+	// namespace cannot be determined.
+	namespace, _ := Namespace()
+
+	return NewSecretReaderForClient(client, namespace), nil
+}
+
+// NewSecretReaderForClient returns a SecretReader using client, reading Secrets from
+// namespace unless another is referenced.
+func NewSecretReaderForClient(client crclient.Client, namespace string) *SecretReader {
+	return &SecretReader{client: openshift.NewClient(client), namespace: namespace}
+}
+
+// Read returns the value of the key of the Secret referenced by ref.
+func (r *SecretReader) Read(ctx context.Context, ref SecretReference) ([]byte, error) {
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = r.namespace
+	}
+	if namespace == "" {
+		return nil, fmt.Errorf("the namespace of secret %s is required, since the namespace of the pod could not be determined", ref.Name)
+	}
+
+	secret, err := r.client.GetSecret(ctx, ref.Name, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	value, ok := secret.Data[ref.Key]
+	if !ok || len(value) == 0 {
+		return nil, fmt.Errorf("secret %s/%s does not contain %s", namespace, ref.Name, ref.Key)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,13 @@
+package incluster
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestInCluster(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "InCluster Suite")
+}
@@ -0,0 +1,101 @@
+package incluster
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("InCluster", func() {
+	Describe("Parsing a secret reference", func() {
+		DescribeTable("valid references",
+			func(s string, expected SecretReference) {
+				ref, err := ParseSecretReference(s, DefaultPyxisTokenKey)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ref).To(Equal(expected))
+			},
+			Entry("a name", "pyxis", SecretReference{Name: "pyxis", Key: "token"}),
+			Entry("a namespace and name", "ci/pyxis", SecretReference{Namespace: "ci", Name: "pyxis", Key: "token"}),
+			Entry("a name and key", "pyxis:api-token", SecretReference{Name: "pyxis", Key: "api-token"}),
+			Entry("a namespace, name, and key", "ci/registry:.dockerconfigjson", SecretReference{Namespace: "ci", Name: "registry", Key: ".dockerconfigjson"}),
+		)
+
+		DescribeTable("invalid references",
+			func(s string) {
+				_, err := ParseSecretReference(s, DefaultPyxisTokenKey)
+				Expect(err).To(HaveOccurred())
+			},
+			Entry("empty", ""),
+			Entry("an invalid name", "Pyxis_Token"),
+			Entry("an invalid namespace", "a/b/c"),
+			Entry("an empty key", "pyxis:"),
+		)
+	})
+
+	Describe("Determining the namespace of the pod", func() {
+		BeforeEach(func() {
+			original := namespaceFile
+			DeferCleanup(func() { namespaceFile = original })
+		})
+
+		It("should read the service account's namespace", func() {
+			namespaceFile = filepath.Join(GinkgoT().TempDir(), "namespace")
+			Expect(os.WriteFile(namespaceFile, []byte("ci\n"), 0o644)).To(Succeed())
+			Expect(Namespace()).To(Equal("ci"))
+		})
+
+		It("should fail outside of a pod", func() {
+			namespaceFile = filepath.Join(GinkgoT().TempDir(), "namespace")
+			_, err := Namespace()
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Reading a secret", func() {
+		var reader *SecretReader
+
+		BeforeEach(func() {
+			client := fake.NewClientBuilder().WithObjects(
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "pyxis", Namespace: "ci"},
+					Data:       map[string][]byte{"token": []byte("secrettoken")},
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "registry", Namespace: "shared"},
+					Data:       map[string][]byte{DockerConfigKey: []byte(`{"auths": {}}`)},
+				},
+			).Build()
+			reader = NewSecretReaderForClient(client, "ci")
+		})
+
+		It("should read a secret in the pod's namespace", func() {
+			Expect(reader.Read(context.TODO(), SecretReference{Name: "pyxis", Key: "token"})).To(Equal([]byte("secrettoken")))
+		})
+
+		It("should read a secret in another namespace", func() {
+			Expect(reader.Read(context.TODO(), SecretReference{Namespace: "shared", Name: "registry", Key: DockerConfigKey})).To(Equal([]byte(`{"auths": {}}`)))
+		})
+
+		It("should fail if the secret does not contain the key", func() {
+			_, err := reader.Read(context.TODO(), SecretReference{Name: "pyxis", Key: "other"})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should fail if the secret does not exist", func() {
+			_, err := reader.Read(context.TODO(), SecretReference{Name: "missing", Key: "token"})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should fail without a namespace", func() {
+			_, err := NewSecretReaderForClient(fake.NewClientBuilder().Build(), "").Read(context.TODO(), SecretReference{Name: "pyxis", Key: "token"})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,91 @@
+// Package interrupt stops preflight gracefully when it is interrupted, e.g. with Ctrl+C or
+// by a CI system cancelling its job, so that the results of the checks that completed are
+// still written, and the resources that checks created on the cluster are removed.
+package interrupt
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// ErrInterrupted is the error of an execution that was interrupted.
+var ErrInterrupted = errors.New("preflight was interrupted")
+
+type interruptedKey struct{}
+
+// NotifyContext returns a copy of ctx that is interrupted when preflight receives SIGINT
+// or SIGTERM, and a function that stops relaying the signals, which must be called once
+// ctx is no longer used. Once ctx is interrupted, the signals are no longer relayed, so
+// that a second signal terminates preflight immediately.
+func NotifyContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	ctx, cancel := interruptOn(ctx, signals)
+
+	return ctx, func() {
+		signal.Stop(signals)
+		cancel()
+	}
+}
+
+// interruptOn returns a copy of ctx that is interrupted on the first of signals, and a
+// function that cancels it.
+func interruptOn(ctx context.Context, signals chan os.Signal) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	ctx, interrupt := WithInterrupt(ctx)
+	go func() {
+		select {
+		case <-signals:
+			interrupt()
+		case <-ctx.Done():
+		}
+		signal.Stop(signals)
+	}()
+
+	return ctx, cancel
+}
+
+// WithInterrupt returns a copy of ctx, and a function that interrupts it: it cancels it,
+// and records that it was interrupted, so that Interrupted tells it from a context that
+// is cancelled for another reason, e.g. a check that timed out.
+func WithInterrupt(ctx context.Context) (context.Context, context.CancelFunc) {
+	interrupted := &atomic.Bool{}
+	ctx, cancel := context.WithCancel(context.WithValue(ctx, interruptedKey{}, interrupted))
+
+	return ctx, func() {
+		interrupted.Store(true)
+		cancel()
+	}
+}
+
+// Interrupted returns true if ctx is done because it, or the context it was derived
+// from, was interrupted, e.g. because preflight received SIGINT, rather than because it
+// was cancelled for another reason, or a deadline was exceeded.
+func Interrupted(ctx context.Context) bool {
+	interrupted, _ := ctx.Value(interruptedKey{}).(*atomic.Bool)
+	return interrupted != nil && interrupted.Load() && ctx.Err() != nil
+}
+
+// Detach returns a context with the values of ctx that is never cancelled, so that work
+// that must complete even if ctx is cancelled, like removing the resources created on a
+// cluster, can still use the logger and artifacts writer of ctx.
+func Detach(ctx context.Context) context.Context {
+	return detachedContext{parent: ctx}
+}
+
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+func (c detachedContext) Value(key any) any {
+	return c.parent.Value(key)
+}
@@ -0,0 +1,73 @@
+package interrupt
+
+import (
+	"context"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type contextKey string
+
+var _ = Describe("Interrupting preflight", func() {
+	It("should consider an interrupted context, and those derived from it, interrupted", func() {
+		ctx, interrupt := WithInterrupt(context.Background())
+		derived, cancel := context.WithCancel(ctx)
+		defer cancel()
+		Expect(Interrupted(ctx)).To(BeFalse())
+		Expect(Interrupted(derived)).To(BeFalse())
+
+		interrupt()
+		Expect(Interrupted(ctx)).To(BeTrue())
+		Expect(Interrupted(derived)).To(BeTrue())
+	})
+
+	It("should not consider a context cancelled for another reason an interruption", func() {
+		ctx, interrupt := WithInterrupt(context.Background())
+		defer interrupt()
+		derived, cancel := context.WithCancel(ctx)
+		cancel()
+
+		Expect(derived.Err()).To(MatchError(context.Canceled))
+		Expect(Interrupted(derived)).To(BeFalse())
+		Expect(Interrupted(ctx)).To(BeFalse())
+	})
+
+	It("should interrupt the context when preflight receives a signal", func() {
+		signals := make(chan os.Signal, 1)
+		ctx, cancel := interruptOn(context.Background(), signals)
+		defer cancel()
+
+		signals <- os.Interrupt
+		Eventually(ctx.Done()).Should(BeClosed())
+		Expect(Interrupted(ctx)).To(BeTrue())
+	})
+
+	It("should not consider a context whose signals are no longer relayed interrupted", func() {
+		ctx, stop := NotifyContext(context.Background())
+		stop()
+
+		Expect(ctx.Err()).To(MatchError(context.Canceled))
+		Expect(Interrupted(ctx)).To(BeFalse())
+	})
+
+	It("should not consider an exceeded deadline an interruption", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+		defer cancel()
+		<-ctx.Done()
+
+		Expect(Interrupted(ctx)).To(BeFalse())
+	})
+
+	It("should keep the values of a detached context, but never cancel it", func() {
+		ctx, cancel := context.WithCancel(context.WithValue(context.Background(), contextKey("key"), "value"))
+		cancel()
+
+		detached := Detach(ctx)
+		Expect(detached.Err()).ToNot(HaveOccurred())
+		Expect(detached.Done()).To(BeNil())
+		Expect(detached.Value(contextKey("key"))).To(Equal("value"))
+	})
+})
@@ -0,0 +1,208 @@
+// Package knownissues annotates the failures and errors of checks with the known issues
+// that may explain them, e.g. incidents of Red Hat's services, as published in a feed,
+// so that time is not spent debugging failures that are not caused by the image.
+package knownissues
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/certification"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/transport"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/yaml"
+)
+
+// fetchTimeout is how long fetching a feed from a URL may take.
+const fetchTimeout = 30 * time.Second
+
+// maxFeedSize is the largest feed that is read.
+const maxFeedSize = 10 << 20
+
+// Issue is a known issue affecting checks, e.g. an incident of a service they query.
+type Issue struct {
+	// ID identifies the issue, e.g. the number of the incident.
+	ID string `json:"id"`
+	// Title describes the issue, e.g. "Pyxis is returning errors".
+	Title string `json:"title"`
+	// URL links to more information about the issue, e.g. its status page.
+	URL string `json:"url,omitempty"`
+	// Checks are patterns, as matched by path.Match, of the names of the checks the
+	// issue affects, e.g. BasedOnUbi, or * for every check.
+	Checks []string `json:"checks"`
+	// Since and Until are the RFC 3339 times the issue started and was resolved. An
+	// issue without Since affects checks executed at any time before Until, and one
+	// without Until has not been resolved.
+	Since string `json:"since,omitempty"`
+	Until string `json:"until,omitempty"`
+
+	since, until time.Time
+}
+
+// Feed is a feed of known issues.
+type Feed struct {
+	Issues []Issue `json:"issues"`
+}
+
+// List is a list of valid known issues.
+type List struct {
+	issues []Issue
+}
+
+// Load returns the known issues in the feed at location, a path or an http(s) URL. Feeds
+// are fetched through the proxy, and trusting the certificate authorities, configured
+// in ctx.
+func Load(ctx context.Context, location string) (*List, error) {
+	b, err := read(ctx, location)
+	if err != nil {
+		return nil, fmt.Errorf("could not read known issues feed: %w", err)
+	}
+
+	// Fields that are not known are ignored, so that feeds may add them.
+	var f Feed
+	if err := yaml.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("could not parse known issues feed %s: %w", location, err)
+	}
+
+	l, err := New(f.Issues...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid known issues feed %s: %w", location, err)
+	}
+
+	return l, nil
+}
+
+// read returns the contents of the feed at location.
+func read(ctx context.Context, location string) ([]byte, error) {
+	if !strings.HasPrefix(location, "https://") && !strings.HasPrefix(location, "http://") {
+		return os.ReadFile(location)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := transport.HTTPClient(ctx, fetchTimeout).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", location, resp.Status)
+	}
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxFeedSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > maxFeedSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", location, maxFeedSize)
+	}
+
+	return b, nil
+}
+
+// New returns a List of known issues, or an error if any of them is not valid. Every
+// issue must have an ID and a title, and affect at least one check.
+func New(issues ...Issue) (*List, error) {
+	l := &List{issues: make([]Issue, 0, len(issues))}
+	for i, issue := range issues {
+		switch {
+		case issue.ID == "":
+			return nil, fmt.Errorf("known issue %d must have an id", i+1)
+		case issue.Title == "":
+			return nil, fmt.Errorf("known issue %s must have a title", issue.ID)
+		case len(issue.Checks) == 0:
+			return nil, fmt.Errorf("known issue %s must affect at least one check", issue.ID)
+		}
+		for _, pattern := range issue.Checks {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("known issue %s has an invalid check pattern %q: %w", issue.ID, pattern, err)
+			}
+		}
+
+		var err error
+		if issue.Since != "" {
+			if issue.since, err = time.Parse(time.RFC3339, issue.Since); err != nil {
+				return nil, fmt.Errorf("known issue %s: since must be an RFC 3339 time: %w", issue.ID, err)
+			}
+		}
+		if issue.Until != "" {
+			if issue.until, err = time.Parse(time.RFC3339, issue.Until); err != nil {
+				return nil, fmt.Errorf("known issue %s: until must be an RFC 3339 time: %w", issue.ID, err)
+			}
+		}
+		if !issue.since.IsZero() && !issue.until.IsZero() && issue.until.Before(issue.since) {
+			return nil, fmt.Errorf("known issue %s was resolved before it started", issue.ID)
+		}
+
+		l.issues = append(l.issues, issue)
+	}
+
+	return l, nil
+}
+
+// Annotate returns results with the failures and errors of checks annotated with the
+// known issues that affected them at started, the time the checks started. Known issues
+// only explain results, so whether the results passed overall is unchanged.
+func (l *List) Annotate(ctx context.Context, results certification.Results, started time.Time) certification.Results {
+	results.Failed = l.annotate(ctx, "failure", results.Failed, started)
+	results.Errors = l.annotate(ctx, "error", results.Errors, started)
+
+	return results
+}
+
+// annotate returns a copy of results, of the kind of outcome named outcome, each with
+// the known issues that affected its check at started.
+func (l *List) annotate(ctx context.Context, outcome string, results []certification.Result, started time.Time) []certification.Result {
+	if len(results) == 0 {
+		return results
+	}
+
+	logger := logr.FromContextOrDiscard(ctx)
+	annotated := make([]certification.Result, 0, len(results))
+	for _, result := range results {
+		var known []certification.KnownIssue
+		for _, issue := range l.issues {
+			if !issue.affects(result.Name(), started) {
+				continue
+			}
+
+			logger.Info(fmt.Sprintf("the %s of %s may be caused by known issue %s: %s", outcome, result.Name(), issue.ID, issue.Title),
+				"check", result.Name(), "issue", issue.ID, "url", issue.URL)
+			known = append(known, certification.KnownIssue{ID: issue.ID, Title: issue.Title, URL: issue.URL})
+		}
+		if len(known) != 0 {
+			result.KnownIssues = known
+		}
+		annotated = append(annotated, result)
+	}
+
+	return annotated
+}
+
+// affects returns true if the issue affected the check named check at t.
+func (i Issue) affects(check string, t time.Time) bool {
+	if !i.since.IsZero() && t.Before(i.since) {
+		return false
+	}
+	if !i.until.IsZero() && !t.Before(i.until) {
+		return false
+	}
+
+	for _, pattern := range i.Checks {
+		if ok, _ := path.Match(pattern, check); ok {
+			return true
+		}
+	}
+
+	return false
+}
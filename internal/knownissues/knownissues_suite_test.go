@@ -0,0 +1,13 @@
+package knownissues
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestKnownIssues(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Known Issues Suite")
+}
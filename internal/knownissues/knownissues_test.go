@@ -0,0 +1,125 @@
+package knownissues
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/certification"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/image"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Known issues", func() {
+	var (
+		started time.Time
+		results certification.Results
+	)
+
+	result := func(name string) certification.Result {
+		return certification.Result{Check: check.NewGenericCheck(
+			name,
+			func(context.Context, image.ImageReference) (bool, error) { return false, nil },
+			check.Metadata{},
+			check.HelpText{},
+		)}
+	}
+
+	BeforeEach(func() {
+		started = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+		results = certification.Results{
+			TestedImage: "quay.io/example/app:1.0",
+			Passed:      []certification.Result{result("HasLicense")},
+			Failed:      []certification.Result{result("BasedOnUbi"), result("RunAsNonRoot")},
+			Errors:      []certification.Result{result("HasUniqueTag")},
+		}
+	})
+
+	It("should annotate the failures and errors of the checks that issues affect", func() {
+		l, err := New(
+			Issue{ID: "123", Title: "Pyxis is returning errors", URL: "https://status.example.com/123", Checks: []string{"BasedOnUbi"}},
+			Issue{ID: "124", Title: "The registry is unavailable", Checks: []string{"*"}, Since: "2024-06-01T11:00:00Z"},
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		annotated := l.Annotate(context.Background(), results, started)
+		Expect(annotated.Failed[0].KnownIssues).To(Equal([]certification.KnownIssue{
+			{ID: "123", Title: "Pyxis is returning errors", URL: "https://status.example.com/123"},
+			{ID: "124", Title: "The registry is unavailable"},
+		}))
+		Expect(annotated.Failed[1].KnownIssues).To(Equal([]certification.KnownIssue{{ID: "124", Title: "The registry is unavailable"}}))
+		Expect(annotated.Errors[0].KnownIssues).To(HaveLen(1))
+		Expect(annotated.Passed[0].KnownIssues).To(BeEmpty())
+		Expect(annotated.PassedOverall).To(Equal(results.PassedOverall))
+	})
+
+	It("should not annotate results with issues that were not ongoing when the checks started", func() {
+		l, err := New(
+			Issue{ID: "122", Title: "Resolved", Checks: []string{"*"}, Until: "2024-06-01T12:00:00Z"},
+			Issue{ID: "125", Title: "Upcoming", Checks: []string{"*"}, Since: "2024-06-01T13:00:00Z"},
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		annotated := l.Annotate(context.Background(), results, started)
+		Expect(annotated.Failed[0].KnownIssues).To(BeEmpty())
+		Expect(annotated.Errors[0].KnownIssues).To(BeEmpty())
+	})
+
+	DescribeTable("should reject issues that are not valid",
+		func(issue Issue, message string) {
+			_, err := New(issue)
+			Expect(err).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("without an id", Issue{Title: "t", Checks: []string{"*"}}, "must have an id"),
+		Entry("without a title", Issue{ID: "1", Checks: []string{"*"}}, "must have a title"),
+		Entry("without checks", Issue{ID: "1", Title: "t"}, "must affect at least one check"),
+		Entry("with an invalid pattern", Issue{ID: "1", Title: "t", Checks: []string{"["}}, "invalid check pattern"),
+		Entry("with an invalid time", Issue{ID: "1", Title: "t", Checks: []string{"*"}, Since: "yesterday"}, "since must be an RFC 3339 time"),
+		Entry("resolved before it started", Issue{ID: "1", Title: "t", Checks: []string{"*"}, Since: "2024-06-02T00:00:00Z", Until: "2024-06-01T00:00:00Z"}, "resolved before it started"),
+	)
+
+	Context("when loading a feed", func() {
+		feed := `
+issues:
+  - id: "123"
+    title: Pyxis is returning errors
+    checks: [BasedOnUbi]
+    severity: major
+`
+
+		It("should read a feed from a file", func() {
+			path := filepath.Join(GinkgoT().TempDir(), "known-issues.yaml")
+			Expect(os.WriteFile(path, []byte(feed), 0o644)).To(Succeed())
+
+			l, err := Load(context.Background(), path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(l.issues).To(HaveLen(1))
+		})
+
+		It("should fetch a feed from a URL", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(feed))
+			}))
+			DeferCleanup(server.Close)
+
+			l, err := Load(context.Background(), server.URL+"/known-issues.yaml")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(l.issues).To(HaveLen(1))
+			Expect(l.issues[0].ID).To(Equal("123"))
+		})
+
+		It("should throw an error if the feed cannot be fetched", func() {
+			server := httptest.NewServer(http.NotFoundHandler())
+			DeferCleanup(server.Close)
+
+			_, err := Load(context.Background(), server.URL)
+			Expect(err).To(MatchError(ContainSubstring("404 Not Found")))
+		})
+	})
+})
@@ -0,0 +1,130 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/clock"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/authn"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/transport"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/version"
+)
+
+// ResultsArtifactType is the media type of the config of the artifact attaching the
+// results, the log, and the artifacts of a check to the checked image.
+const ResultsArtifactType = "application/vnd.redhat.preflight.results.v1+json"
+
+// The annotations of the results artifact and its layers.
+const (
+	// AnnotationCreated is when the results were attached, in RFC 3339 format.
+	AnnotationCreated = "org.opencontainers.image.created"
+	// AnnotationTitle is the name of the file a layer contains, relative to the
+	// artifacts directory.
+	AnnotationTitle = "org.opencontainers.image.title"
+	// AnnotationVersion is the version of preflight that pushed the artifact.
+	AnnotationVersion = "com.redhat.preflight.version"
+)
+
+// AttachedFile is a file attached to the checked image.
+type AttachedFile struct {
+	// Name is the name of the file in the artifact, e.g. results.json.
+	Name string
+	// Path is where the file is read from.
+	Path string
+}
+
+// ResultsAttacher attaches the results of a check to the checked image in its registry,
+// as an artifact referring to the image, so that they can be found from the image
+// without access to where preflight was executed.
+type ResultsAttacher struct {
+	// DockerConfig contains the credentials to push to the repository of the image.
+	// Credentials in the context take precedence.
+	DockerConfig string
+}
+
+// Attach pushes an artifact with a layer for each of files to the repository of image,
+// referring to the digest image resolves to, and returns its reference. The artifact is
+// listed by the OCI referrers API, or by its fallback tag on registries that do not
+// support it.
+func (a *ResultsAttacher) Attach(ctx context.Context, image name.Reference, files []AttachedFile) (name.Reference, error) {
+	logger := logr.FromContextOrDiscard(ctx)
+
+	if len(files) == 0 {
+		return nil, errors.New("there are no results to attach")
+	}
+
+	options := []remote.Option{
+		remote.WithContext(ctx),
+		remote.WithAuthFromKeychain(authn.PreflightKeychain(ctx, authn.WithDockerConfig(a.DockerConfig))),
+	}
+	if transport.IsConfigured(ctx) {
+		options = append(options, remote.WithTransport(transport.Transport(ctx, remote.DefaultTransport.(*http.Transport))))
+	}
+
+	desc, err := remote.Head(image, options...)
+	if err != nil {
+		return nil, fmt.Errorf("could not find the checked image %s: %w", image, err)
+	}
+
+	artifact := mutate.MediaType(empty.Image, types.OCIManifestSchema1)
+	artifact = mutate.ConfigMediaType(artifact, ResultsArtifactType)
+	for _, f := range files {
+		contents, err := os.ReadFile(f.Path)
+		if err != nil {
+			return nil, fmt.Errorf("could not read %s to attach it to the image: %w", f.Path, err)
+		}
+
+		artifact, err = mutate.Append(artifact, mutate.Addendum{
+			Layer:       static.NewLayer(contents, attachedMediaType(f.Name)),
+			Annotations: map[string]string{AnnotationTitle: f.Name},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not attach %s to the image: %w", f.Name, err)
+		}
+	}
+	artifact = mutate.Annotations(artifact, map[string]string{
+		AnnotationCreated: clock.FromContext(ctx).Now().UTC().Format(time.RFC3339),
+		AnnotationVersion: version.Version.Version,
+	}).(v1.Image)
+	artifact = mutate.Subject(artifact, *desc).(v1.Image)
+
+	digest, err := artifact.Digest()
+	if err != nil {
+		return nil, fmt.Errorf("could not compute the digest of the results artifact: %w", err)
+	}
+	subject := image.Context().Digest(desc.Digest.String())
+	ref := image.Context().Digest(digest.String())
+
+	if err := remote.Write(ref, artifact, options...); err != nil {
+		return nil, fmt.Errorf("could not push the results artifact to %s: %w", ref, err)
+	}
+
+	logger.Info("attached the results to the image", "image", subject.String(), "artifact", ref.String(), "count", len(files))
+
+	return ref, nil
+}
+
+// attachedMediaType returns the media type of the layer containing the file name, by its
+// extension.
+func attachedMediaType(name string) types.MediaType {
+	if t := mime.TypeByExtension(path.Ext(name)); t != "" {
+		return types.MediaType(t)
+	}
+
+	return "application/octet-stream"
+}
@@ -0,0 +1,143 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/clock"
+)
+
+var _ = Describe("Results Attacher", func() {
+	var (
+		ctx   context.Context
+		image name.Digest
+		files []AttachedFile
+	)
+
+	push := func(opts ...registry.Option) {
+		s := httptest.NewServer(registry.New(append(opts, registry.Logger(log.New(io.Discard, "", log.Ldate)))...))
+		DeferCleanup(s.Close)
+		u, err := url.Parse(s.URL)
+		Expect(err).ToNot(HaveOccurred())
+
+		img, err := random.Image(1024, 1)
+		Expect(err).ToNot(HaveOccurred())
+		digest, err := img.Digest()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(crane.Push(img, fmt.Sprintf("%s/test/preflight:latest", u.Host))).To(Succeed())
+		image, err = name.NewDigest(fmt.Sprintf("%s/test/preflight@%s", u.Host, digest))
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	BeforeEach(func() {
+		ctx = clock.ContextWithClock(context.Background(), clock.Deterministic())
+
+		dir := GinkgoT().TempDir()
+		files = []AttachedFile{
+			{Name: "results.json", Path: filepath.Join(dir, "results.json")},
+			{Name: "artifacts/rpm-list.txt", Path: filepath.Join(dir, "rpm-list.txt")},
+			{Name: "preflight.log", Path: filepath.Join(dir, "preflight.log")},
+		}
+		for _, f := range files {
+			Expect(os.WriteFile(f.Path, []byte(f.Name), 0o600)).To(Succeed())
+		}
+	})
+
+	expectAttached := func(ref name.Reference) {
+		manifest, err := remote.Referrers(image)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(manifest.Manifests).To(HaveLen(1))
+		Expect(manifest.Manifests[0].ArtifactType).To(Equal(ResultsArtifactType))
+		Expect(manifest.Manifests[0].Digest.String()).To(Equal(ref.Identifier()))
+
+		artifact, err := remote.Image(ref)
+		Expect(err).ToNot(HaveOccurred())
+		m, err := artifact.Manifest()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(m.Subject).ToNot(BeNil())
+		Expect(m.Subject.Digest.String()).To(Equal(image.DigestStr()))
+		Expect(m.Annotations).To(HaveKeyWithValue(AnnotationCreated, "0001-01-01T00:00:00Z"))
+		Expect(m.Annotations).To(HaveKey(AnnotationVersion))
+		Expect(m.Layers).To(HaveLen(3))
+		Expect(m.Layers[0].MediaType).To(BeEquivalentTo("application/json"))
+		for i, f := range files {
+			Expect(m.Layers[i].Annotations).To(HaveKeyWithValue(AnnotationTitle, f.Name))
+		}
+
+		layers, err := artifact.Layers()
+		Expect(err).ToNot(HaveOccurred())
+		rc, err := layers[1].Uncompressed()
+		Expect(err).ToNot(HaveOccurred())
+		defer rc.Close()
+		contents, err := io.ReadAll(rc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).To(Equal("artifacts/rpm-list.txt"))
+	}
+
+	Context("when the registry supports the referrers API", func() {
+		BeforeEach(func() {
+			push(registry.WithReferrersSupport(true))
+		})
+
+		It("should push an artifact referring to the image", func() {
+			ref, err := (&ResultsAttacher{}).Attach(ctx, image, files)
+			Expect(err).ToNot(HaveOccurred())
+			expectAttached(ref)
+		})
+	})
+
+	Context("when the registry does not support the referrers API", func() {
+		BeforeEach(func() {
+			push()
+		})
+
+		It("should push an artifact listed by the fallback tag", func() {
+			ref, err := (&ResultsAttacher{}).Attach(ctx, image, files)
+			Expect(err).ToNot(HaveOccurred())
+			expectAttached(ref)
+		})
+
+		It("should attach to the digest a tag resolves to", func() {
+			tag, err := name.NewTag(fmt.Sprintf("%s/test/preflight:latest", image.RegistryStr()))
+			Expect(err).ToNot(HaveOccurred())
+
+			ref, err := (&ResultsAttacher{}).Attach(ctx, tag, files)
+			Expect(err).ToNot(HaveOccurred())
+			expectAttached(ref)
+		})
+
+		It("should fail if the image does not exist", func() {
+			missing, err := name.NewDigest(fmt.Sprintf("%s/test/missing@%s", image.RegistryStr(), image.DigestStr()))
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = (&ResultsAttacher{}).Attach(ctx, missing, files)
+			Expect(err).To(MatchError(ContainSubstring("could not find the checked image")))
+		})
+
+		It("should fail if a file cannot be read", func() {
+			files = append(files, AttachedFile{Name: "missing.txt", Path: filepath.Join(GinkgoT().TempDir(), "missing.txt")})
+
+			_, err := (&ResultsAttacher{}).Attach(ctx, image, files)
+			Expect(err).To(MatchError(ContainSubstring("could not read")))
+		})
+
+		It("should fail without files", func() {
+			_, err := (&ResultsAttacher{}).Attach(ctx, image, nil)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
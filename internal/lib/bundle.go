@@ -0,0 +1,265 @@
+package lib
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/artifacts"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/formatters"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/pyxis"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/version"
+)
+
+// SubmissionBundleFilename is the name of the artifact containing the submission bundle,
+// when results are submitted offline.
+const SubmissionBundleFilename = "submission-bundle.tar.gz"
+
+// submissionBundleVersion is the version of the format of submission bundles.
+const submissionBundleVersion = 1
+
+// submissionBundleManifestFilename is the name of the manifest in a submission bundle.
+const submissionBundleManifestFilename = "manifest.json"
+
+// maxSubmissionBundleFileSize bounds the size of a file read from a submission bundle.
+const maxSubmissionBundleFileSize = 512 << 20
+
+// SubmissionBundleManifest describes the results in a submission bundle.
+type SubmissionBundleManifest struct {
+	Version                int                    `json:"version"`
+	CertificationProjectID string                 `json:"certification_project_id"`
+	Image                  string                 `json:"image"`
+	CreatedAt              time.Time              `json:"created_at"`
+	LibraryInfo            version.VersionContext `json:"test_library"`
+	// Logfile is the name of the execution log in the bundle.
+	Logfile string `json:"logfile"`
+}
+
+// SubmissionBundle contains everything that is submitted to Pyxis for a check container
+// execution, except the certification project, which is looked up when it is submitted.
+// It does not contain credentials, so it can be moved from a disconnected host to a
+// connected one.
+type SubmissionBundle struct {
+	Manifest SubmissionBundleManifest
+	files    map[string][]byte
+}
+
+// OfflineSubmitter writes the results that would be submitted to Pyxis to a submission
+// bundle in the artifacts directory, to be submitted later from a connected host. It
+// implements a ResultSubmitter.
+type OfflineSubmitter struct {
+	CertificationProjectID string
+	PreflightLogFile       string
+}
+
+var _ ResultSubmitter = &OfflineSubmitter{}
+
+func (s *OfflineSubmitter) Submit(ctx context.Context) error {
+	logger := logr.FromContextOrDiscard(ctx)
+
+	artifactWriter, ok := artifacts.WriterFromContext(ctx).(*artifacts.FilesystemWriter)
+	if artifactWriter == nil || !ok {
+		return errors.New("the artifact writer was either missing or was not supported, so results cannot be bundled")
+	}
+
+	bundle := SubmissionBundle{
+		Manifest: SubmissionBundleManifest{
+			Version:                submissionBundleVersion,
+			CertificationProjectID: s.CertificationProjectID,
+			CreatedAt:              time.Now().UTC(),
+			LibraryInfo:            version.Version,
+			Logfile:                filepath.Base(s.PreflightLogFile),
+		},
+		files: map[string][]byte{},
+	}
+
+	for _, filename := range []string{check.DefaultCertImageFilename, check.DefaultTestResultsFilename, check.DefaultRPMManifestFilename} {
+		b, err := os.ReadFile(filepath.Join(artifactWriter.Path(), filename))
+		if err != nil {
+			return fmt.Errorf("could not open file for submission: %s: %w", filename, err)
+		}
+		bundle.files[filename] = b
+	}
+
+	logfile, err := os.ReadFile(s.PreflightLogFile)
+	if err != nil {
+		return fmt.Errorf("could not open file for submission: %s: %w", s.PreflightLogFile, err)
+	}
+	bundle.files[bundle.Manifest.Logfile] = logfile
+
+	var results formatters.UserResponse
+	if err := json.Unmarshal(bundle.files[check.DefaultTestResultsFilename], &results); err != nil {
+		return fmt.Errorf("could not parse results: %w", err)
+	}
+	bundle.Manifest.Image = results.Image
+
+	var buf bytes.Buffer
+	if err := bundle.write(&buf); err != nil {
+		return err
+	}
+
+	filename, err := artifactWriter.WriteFile(SubmissionBundleFilename, &buf)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Offline: results were not submitted to Red Hat. Submit the bundle from a connected host with preflight submit-bundle.", "bundle", filename)
+
+	return nil
+}
+
+// write writes b to w as a gzipped tar archive.
+func (b *SubmissionBundle) write(w io.Writer) error {
+	manifest, err := json.MarshalIndent(b.Manifest, "", "    ")
+	if err != nil {
+		return fmt.Errorf("could not marshal submission bundle manifest: %w", err)
+	}
+
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	add := func(name string, contents []byte) error {
+		hdr := &tar.Header{
+			Name:    name,
+			Mode:    0o600,
+			Size:    int64(len(contents)),
+			ModTime: b.Manifest.CreatedAt,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := tw.Write(contents)
+		return err
+	}
+
+	if err := add(submissionBundleManifestFilename, manifest); err != nil {
+		return fmt.Errorf("could not write submission bundle: %w", err)
+	}
+	for _, name := range []string{check.DefaultCertImageFilename, check.DefaultTestResultsFilename, check.DefaultRPMManifestFilename, b.Manifest.Logfile} {
+		if err := add(name, b.files[name]); err != nil {
+			return fmt.Errorf("could not write submission bundle: %w", err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("could not write submission bundle: %w", err)
+	}
+
+	return gw.Close()
+}
+
+// ReadSubmissionBundle returns the submission bundle at path, written by an OfflineSubmitter.
+func ReadSubmissionBundle(path string) (*SubmissionBundle, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open submission bundle: %w", err)
+	}
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a submission bundle: %w", path, err)
+	}
+	defer gr.Close()
+
+	files := map[string][]byte{}
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read submission bundle: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		b, err := io.ReadAll(io.LimitReader(tr, maxSubmissionBundleFileSize+1))
+		if err != nil {
+			return nil, fmt.Errorf("could not read submission bundle: %w", err)
+		}
+		if len(b) > maxSubmissionBundleFileSize {
+			return nil, fmt.Errorf("%s in the submission bundle is too large", hdr.Name)
+		}
+		files[hdr.Name] = b
+	}
+
+	return newSubmissionBundle(path, files)
+}
+
+// newSubmissionBundle returns the submission bundle consisting of files, read from path.
+func newSubmissionBundle(bundlePath string, files map[string][]byte) (*SubmissionBundle, error) {
+	manifest, ok := files[submissionBundleManifestFilename]
+	if !ok {
+		return nil, fmt.Errorf("%s is not a submission bundle: it does not contain %s", bundlePath, submissionBundleManifestFilename)
+	}
+
+	bundle := &SubmissionBundle{files: files}
+	if err := json.Unmarshal(manifest, &bundle.Manifest); err != nil {
+		return nil, fmt.Errorf("could not parse submission bundle manifest: %w", err)
+	}
+	if bundle.Manifest.Version != submissionBundleVersion {
+		return nil, fmt.Errorf("submission bundle version %d is not supported by this version of preflight", bundle.Manifest.Version)
+	}
+
+	// The logfile is the base name of a file in the bundle, not a path.
+	if bundle.Manifest.Logfile == "" || path.Base(bundle.Manifest.Logfile) != bundle.Manifest.Logfile {
+		return nil, fmt.Errorf("the submission bundle manifest names an invalid logfile %q", bundle.Manifest.Logfile)
+	}
+
+	for _, name := range []string{check.DefaultCertImageFilename, check.DefaultTestResultsFilename, check.DefaultRPMManifestFilename, bundle.Manifest.Logfile} {
+		if _, ok := files[name]; !ok {
+			return nil, fmt.Errorf("the submission bundle does not contain %s", name)
+		}
+	}
+
+	return bundle, nil
+}
+
+// certificationInput returns the input submitted to Pyxis for the results in b, under
+// certProject.
+func (b *SubmissionBundle) certificationInput(ctx context.Context, certProject *pyxis.CertProject) (*pyxis.CertificationInput, error) {
+	return pyxis.NewCertificationInput(ctx, certProject,
+		pyxis.WithCertImage(bytes.NewReader(b.files[check.DefaultCertImageFilename])),
+		pyxis.WithPreflightResults(bytes.NewReader(b.files[check.DefaultTestResultsFilename])),
+		pyxis.WithRPMManifest(bytes.NewReader(b.files[check.DefaultRPMManifestFilename])),
+		pyxis.WithArtifact(bytes.NewReader(b.files[b.Manifest.Logfile]), b.Manifest.Logfile),
+	)
+}
+
+// SubmitBundle submits the results in bundle, written by an OfflineSubmitter, to the
+// certification project they were bundled for.
+func (s *ContainerCertificationSubmitter) SubmitBundle(ctx context.Context, bundle *SubmissionBundle) error {
+	logger := logr.FromContextOrDiscard(ctx)
+	logger.Info("preparing bundled results that will be submitted to Red Hat", "image", bundle.Manifest.Image, "bundledAt", bundle.Manifest.CreatedAt)
+
+	if bundle.Manifest.CertificationProjectID != s.CertificationProjectID {
+		return fmt.Errorf("the results were bundled for certification project %s, not %s", bundle.Manifest.CertificationProjectID, s.CertificationProjectID)
+	}
+
+	certProject, err := s.certProject(ctx)
+	if err != nil {
+		return err
+	}
+
+	submission, err := bundle.certificationInput(ctx, certProject)
+	if err != nil {
+		return fmt.Errorf("unable to finalize data that would be sent to pyxis: %w", err)
+	}
+
+	return s.submit(ctx, submission)
+}
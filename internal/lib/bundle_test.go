@@ -0,0 +1,169 @@
+package lib
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"os"
+	"path"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/artifacts"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/formatters"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/pyxis"
+)
+
+var _ = Describe("Submission Bundles", func() {
+	var aw *artifacts.FilesystemWriter
+	var testcontext context.Context
+	var offline *OfflineSubmitter
+	var fakePC *FakePyxisClient
+	var sbmt *ContainerCertificationSubmitter
+
+	BeforeEach(func() {
+		var err error
+		aw, err = artifacts.NewFilesystemWriter(artifacts.WithDirectory(GinkgoT().TempDir()))
+		Expect(err).ToNot(HaveOccurred())
+		testcontext = artifacts.ContextWithWriter(context.Background(), aw)
+
+		fakePC = NewFakePyxisClientNoop()
+		fakePC.setGPFuncReturnBaseProject("")
+		projectID := fakePC.baseProject("").ID
+
+		offline = &OfflineSubmitter{
+			CertificationProjectID: projectID,
+			PreflightLogFile:       path.Join(aw.Path(), "preflight.log"),
+		}
+		sbmt = &ContainerCertificationSubmitter{
+			CertificationProjectID: projectID,
+			Pyxis:                  fakePC,
+		}
+
+		certImageJSONBytes, err := json.Marshal(pyxis.CertImage{ID: "111111111111"})
+		Expect(err).ToNot(HaveOccurred())
+		resultsJSONBytes, err := json.Marshal(formatters.UserResponse{Image: "quay.io/example/image:mytag", Passed: true})
+		Expect(err).ToNot(HaveOccurred())
+		rpmManifestJSONBytes, err := json.Marshal(pyxis.RPMManifest{ID: "foo", ImageID: "foo"})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(aw.WriteFile("preflight.log", strings.NewReader("preflight log")))
+		Expect(aw.WriteFile("dockerconfig.json", strings.NewReader("dockerconfig")))
+		Expect(aw.WriteFile(check.DefaultCertImageFilename, bytes.NewReader(certImageJSONBytes)))
+		Expect(aw.WriteFile(check.DefaultTestResultsFilename, bytes.NewReader(resultsJSONBytes)))
+		Expect(aw.WriteFile(check.DefaultRPMManifestFilename, bytes.NewReader(rpmManifestJSONBytes)))
+	})
+
+	Context("When submitting offline", func() {
+		It("should write a bundle of the results to the artifacts directory", func() {
+			Expect(offline.Submit(testcontext)).To(Succeed())
+
+			bundle, err := ReadSubmissionBundle(path.Join(aw.Path(), SubmissionBundleFilename))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(bundle.Manifest.CertificationProjectID).To(Equal(offline.CertificationProjectID))
+			Expect(bundle.Manifest.Image).To(Equal("quay.io/example/image:mytag"))
+			Expect(bundle.Manifest.Logfile).To(Equal("preflight.log"))
+			Expect(bundle.files).To(HaveKey(check.DefaultCertImageFilename))
+			Expect(bundle.files).To(HaveKey(check.DefaultTestResultsFilename))
+			Expect(bundle.files).To(HaveKey(check.DefaultRPMManifestFilename))
+			Expect(bundle.files).To(HaveKeyWithValue("preflight.log", []byte("preflight log")))
+		})
+
+		It("should not bundle the docker config", func() {
+			Expect(offline.Submit(testcontext)).To(Succeed())
+
+			bundle, err := ReadSubmissionBundle(path.Join(aw.Path(), SubmissionBundleFilename))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(bundle.files).ToNot(HaveKey("dockerconfig.json"))
+		})
+
+		It("should throw an error if the results are missing", func() {
+			Expect(os.Remove(path.Join(aw.Path(), check.DefaultTestResultsFilename))).To(Succeed())
+			Expect(offline.Submit(testcontext)).To(MatchError(ContainSubstring("could not open file for submission")))
+		})
+
+		It("should throw an error if there is no artifacts writer", func() {
+			Expect(offline.Submit(context.Background())).To(MatchError(ContainSubstring("cannot be bundled")))
+		})
+	})
+
+	Context("When reading a submission bundle", func() {
+		It("should throw an error if the file is not a bundle", func() {
+			notBundle := path.Join(aw.Path(), "results.json")
+			_, err := ReadSubmissionBundle(notBundle)
+			Expect(err).To(MatchError(ContainSubstring("is not a submission bundle")))
+		})
+
+		It("should throw an error if the bundle is missing results", func() {
+			var buf bytes.Buffer
+			gw := gzip.NewWriter(&buf)
+			tw := tar.NewWriter(gw)
+			manifest := []byte(`{"version": 1, "logfile": "preflight.log"}`)
+			Expect(tw.WriteHeader(&tar.Header{Name: submissionBundleManifestFilename, Mode: 0o600, Size: int64(len(manifest))})).To(Succeed())
+			_, err := tw.Write(manifest)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tw.Close()).To(Succeed())
+			Expect(gw.Close()).To(Succeed())
+
+			bundlePath := path.Join(aw.Path(), SubmissionBundleFilename)
+			Expect(os.WriteFile(bundlePath, buf.Bytes(), 0o600)).To(Succeed())
+			_, err = ReadSubmissionBundle(bundlePath)
+			Expect(err).To(MatchError(ContainSubstring("does not contain")))
+		})
+
+		It("should reject a logfile that is not in the bundle", func() {
+			_, err := newSubmissionBundle("bundle", map[string][]byte{
+				submissionBundleManifestFilename: []byte(`{"version": 1, "logfile": "../preflight.log"}`),
+			})
+			Expect(err).To(MatchError(ContainSubstring("invalid logfile")))
+		})
+
+		It("should reject unsupported versions", func() {
+			_, err := newSubmissionBundle("bundle", map[string][]byte{
+				submissionBundleManifestFilename: []byte(`{"version": 2, "logfile": "preflight.log"}`),
+			})
+			Expect(err).To(MatchError(ContainSubstring("version 2 is not supported")))
+		})
+	})
+
+	Context("When submitting a submission bundle", func() {
+		var bundle *SubmissionBundle
+
+		BeforeEach(func() {
+			Expect(offline.Submit(testcontext)).To(Succeed())
+
+			var err error
+			bundle, err = ReadSubmissionBundle(path.Join(aw.Path(), SubmissionBundleFilename))
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should submit the bundled results", func() {
+			var submitted *pyxis.CertificationInput
+			fakePC.submitResultsFunc = func(_ context.Context, ci *pyxis.CertificationInput) (*pyxis.CertificationResults, error) {
+				submitted = ci
+				results := fakePC.successfulCertResults("", "111111111111")
+				return &results, nil
+			}
+
+			Expect(sbmt.SubmitBundle(testcontext, bundle)).To(Succeed())
+			Expect(submitted).ToNot(BeNil())
+			Expect(submitted.CertImage.ID).To(Equal("111111111111"))
+			Expect(submitted.Artifacts).To(HaveLen(1))
+		})
+
+		It("should throw an error if the bundle is for another certification project", func() {
+			sbmt.CertificationProjectID = "999999999999"
+			Expect(sbmt.SubmitBundle(testcontext, bundle)).To(MatchError(ContainSubstring("bundled for certification project")))
+		})
+
+		It("should throw an error if the project cannot be obtained from the API", func() {
+			fakePC.getProjectsFunc = gpFuncReturnError
+			Expect(sbmt.SubmitBundle(testcontext, bundle)).To(HaveOccurred())
+		})
+	})
+})
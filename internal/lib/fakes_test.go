@@ -20,6 +20,7 @@ type (
 	fibdFunc func(ctx context.Context, digests []string) ([]pyxis.CertImage, error)
 	gpFunc   func(context.Context) (*pyxis.CertProject, error)
 	srFunc   func(context.Context, *pyxis.CertificationInput) (*pyxis.CertificationResults, error)
+	psFunc   func(context.Context, *pyxis.CertificationInput) (*pyxis.SubmissionPlan, error)
 )
 
 func NewFakePyxisClientNoop() *FakePyxisClient {
@@ -27,6 +28,7 @@ func NewFakePyxisClientNoop() *FakePyxisClient {
 		findImagesByDigestFunc: fidbFuncNoop,
 		getProjectsFunc:        gpFuncNoop,
 		submitResultsFunc:      srFuncNoop,
+		planSubmissionFunc:     psFuncNoop,
 	}
 }
 
@@ -36,6 +38,7 @@ type FakePyxisClient struct {
 	findImagesByDigestFunc fibdFunc
 	getProjectsFunc        gpFunc
 	submitResultsFunc      srFunc
+	planSubmissionFunc     psFunc
 }
 
 // baseProject returns a pyxis.CertProject with an id of projectID, or a base value
@@ -103,6 +106,10 @@ func (pc *FakePyxisClient) SubmitResults(ctx context.Context, ci *pyxis.Certific
 	return pc.submitResultsFunc(ctx, ci)
 }
 
+func (pc *FakePyxisClient) PlanSubmission(ctx context.Context, ci *pyxis.CertificationInput) (*pyxis.SubmissionPlan, error) {
+	return pc.planSubmissionFunc(ctx, ci)
+}
+
 // gpFuncReturnError implements gpFunc but returns an error.
 func gpFuncReturnError(ctx context.Context) (*pyxis.CertProject, error) {
 	return nil, errors.New("some error returned from the api")
@@ -169,6 +176,22 @@ func srFuncNoop(ctx context.Context, ci *pyxis.CertificationInput) (*pyxis.Certi
 	return nil, nil
 }
 
+// psFuncNoop implements a psFunc, best to use while instantiating FakePyxisClient.
+func psFuncNoop(ctx context.Context, ci *pyxis.CertificationInput) (*pyxis.SubmissionPlan, error) {
+	return nil, nil
+}
+
+// psFuncPlanCreate implements a psFunc that plans to create everything in ci.
+func psFuncPlanCreate(ctx context.Context, ci *pyxis.CertificationInput) (*pyxis.SubmissionPlan, error) {
+	return &pyxis.SubmissionPlan{
+		ProjectUpdate:     ci.CertProject,
+		ImageCreate:       ci.CertImage,
+		RPMManifestCreate: ci.RpmManifest,
+		ArtifactsCreate:   ci.Artifacts,
+		TestResultsCreate: ci.TestResults,
+	}, nil
+}
+
 // fakeCheckEngine implements a certification.CheckEngine with configurables for use in tests.
 type fakeCheckEngine struct {
 	image              string
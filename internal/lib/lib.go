@@ -13,10 +13,11 @@ import (
 // ResolveSubmitter will build out a ResultSubmitter if the provided pyxisClient, pc, is not nil.
 // The pyxisClient is a required component of the submitter. If pc is nil, then a noop submitter
 // is returned instead, which does nothing.
-func ResolveSubmitter(pc PyxisClient, projectID, dockerconfig, logfile string) ResultSubmitter {
+func ResolveSubmitter(pc PyxisClient, pyxisEnv, projectID, dockerconfig, logfile string) ResultSubmitter {
 	if pc != nil {
 		return &ContainerCertificationSubmitter{
 			CertificationProjectID: projectID,
+			PyxisEnv:               pyxisEnv,
 			Pyxis:                  pc,
 			DockerConfig:           dockerconfig,
 			PreflightLogFile:       logfile,
@@ -0,0 +1,145 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/clock"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/authn"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/pyxis"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/transport"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/version"
+)
+
+const (
+	// MarkByReferrer marks an image with an artifact referring to it, which is listed by
+	// the OCI referrers API, or by its fallback tag on registries that do not support it.
+	MarkByReferrer = "referrer"
+	// MarkByTag marks an image with an artifact tagged sha256-<digest>.preflight in the
+	// repository of the image, which is replaced each time the image is submitted.
+	MarkByTag = "tag"
+)
+
+// SubmittedArtifactType is the media type of the config of the artifact marking an image
+// as submitted.
+const SubmittedArtifactType = "application/vnd.redhat.preflight.submitted.v1+json"
+
+// The annotations of the artifact marking an image as submitted.
+const (
+	// AnnotationSubmitted is when the image was submitted, in RFC 3339 format.
+	AnnotationSubmitted = "org.opencontainers.image.created"
+	// AnnotationTestResultsID is the ID of the test results submitted to Pyxis, which
+	// identifies the run of preflight.
+	AnnotationTestResultsID = "com.redhat.preflight.test-results-id"
+	// AnnotationImageID is the ID of the image in Pyxis.
+	AnnotationImageID = "com.redhat.preflight.image-id"
+	// AnnotationCertificationProjectID is the ID of the certification project.
+	AnnotationCertificationProjectID = "com.redhat.preflight.certification-project-id"
+)
+
+// markTagSuffix is appended to the digest of the image to tag the artifact marking it,
+// when marking by tag.
+const markTagSuffix = ".preflight"
+
+// ValidateMarkMode returns an error if mode is not a supported way to mark an image.
+func ValidateMarkMode(mode string) error {
+	switch mode {
+	case MarkByReferrer, MarkByTag:
+		return nil
+	}
+
+	return fmt.Errorf("unsupported mode to mark submitted images: %q, must be one of %s or %s", mode, MarkByReferrer, MarkByTag)
+}
+
+// SubmissionMarker marks an image in its registry as submitted for certification, so
+// that later stages of a pipeline can verify that it was without access to Pyxis.
+type SubmissionMarker struct {
+	// Mode is how the image is marked, either MarkByReferrer or MarkByTag.
+	Mode string
+	// DockerConfig contains the credentials to push to the repository of the image.
+	// Credentials in the context take precedence.
+	DockerConfig string
+}
+
+// Mark pushes an artifact to the repository of the image in submission, annotated with
+// the IDs in results and the current time, and returns its reference.
+func (m *SubmissionMarker) Mark(ctx context.Context, submission *pyxis.CertificationInput, results *pyxis.CertificationResults) (name.Reference, error) {
+	logger := logr.FromContextOrDiscard(ctx)
+
+	if err := ValidateMarkMode(m.Mode); err != nil {
+		return nil, err
+	}
+
+	if submission.CertImage == nil || len(submission.CertImage.Repositories) == 0 {
+		return nil, errors.New("the submitted image does not contain its repository")
+	}
+	repository := submission.CertImage.Repositories[0]
+	subject, err := name.NewDigest(fmt.Sprintf("%s/%s@%s", repository.Registry, repository.Repository, submission.CertImage.DockerImageDigest))
+	if err != nil {
+		return nil, fmt.Errorf("image uri could not be parsed: %w", err)
+	}
+
+	options := []remote.Option{
+		remote.WithContext(ctx),
+		remote.WithAuthFromKeychain(authn.PreflightKeychain(ctx, authn.WithDockerConfig(m.DockerConfig))),
+	}
+	if transport.IsConfigured(ctx) {
+		options = append(options, remote.WithTransport(transport.Transport(ctx, remote.DefaultTransport.(*http.Transport))))
+	}
+
+	annotations := map[string]string{
+		AnnotationSubmitted: clock.FromContext(ctx).Now().UTC().Format(time.RFC3339),
+		AnnotationVersion:   version.Version.Version,
+	}
+	if submission.CertProject != nil && submission.CertProject.ID != "" {
+		annotations[AnnotationCertificationProjectID] = submission.CertProject.ID
+	}
+	if results.TestResults != nil && results.TestResults.ID != "" {
+		annotations[AnnotationTestResultsID] = results.TestResults.ID
+	}
+	if results.CertImage != nil && results.CertImage.ID != "" {
+		annotations[AnnotationImageID] = results.CertImage.ID
+	}
+
+	artifact := mutate.MediaType(empty.Image, types.OCIManifestSchema1)
+	artifact = mutate.ConfigMediaType(artifact, SubmittedArtifactType)
+	artifact = mutate.Annotations(artifact, annotations).(v1.Image)
+
+	var ref name.Reference
+	switch m.Mode {
+	case MarkByReferrer:
+		desc, err := remote.Head(subject, options...)
+		if err != nil {
+			return nil, fmt.Errorf("could not find the submitted image %s: %w", subject, err)
+		}
+		artifact = mutate.Subject(artifact, *desc).(v1.Image)
+
+		digest, err := artifact.Digest()
+		if err != nil {
+			return nil, fmt.Errorf("could not compute the digest of the artifact marking the image: %w", err)
+		}
+		ref = subject.Context().Digest(digest.String())
+	case MarkByTag:
+		ref = subject.Context().Tag(strings.Replace(subject.DigestStr(), ":", "-", 1) + markTagSuffix)
+	}
+
+	if err := remote.Write(ref, artifact, options...); err != nil {
+		return nil, fmt.Errorf("could not push the artifact marking the image as submitted to %s: %w", ref, err)
+	}
+
+	logger.Info("marked the image as submitted", "image", subject.String(), "artifact", ref.String())
+
+	return ref, nil
+}
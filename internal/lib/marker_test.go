@@ -0,0 +1,110 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/clock"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/pyxis"
+)
+
+var _ = Describe("Submission Marker", func() {
+	var (
+		ctx        context.Context
+		image      name.Digest
+		submission *pyxis.CertificationInput
+		results    *pyxis.CertificationResults
+	)
+
+	BeforeEach(func() {
+		s := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", log.Ldate))))
+		DeferCleanup(s.Close)
+		u, err := url.Parse(s.URL)
+		Expect(err).ToNot(HaveOccurred())
+
+		img, err := random.Image(1024, 1)
+		Expect(err).ToNot(HaveOccurred())
+		digest, err := img.Digest()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(crane.Push(img, fmt.Sprintf("%s/test/preflight:latest", u.Host))).To(Succeed())
+		image, err = name.NewDigest(fmt.Sprintf("%s/test/preflight@%s", u.Host, digest))
+		Expect(err).ToNot(HaveOccurred())
+
+		ctx = clock.ContextWithClock(context.Background(), clock.Deterministic())
+		submission = &pyxis.CertificationInput{
+			CertProject: &pyxis.CertProject{ID: "000000000000"},
+			CertImage: &pyxis.CertImage{
+				DockerImageDigest: digest.String(),
+				Repositories:      []pyxis.Repository{{Registry: u.Host, Repository: "test/preflight"}},
+			},
+		}
+		results = &pyxis.CertificationResults{
+			CertImage:   &pyxis.CertImage{ID: "111111111111"},
+			TestResults: &pyxis.TestResults{ID: "222222222222"},
+		}
+	})
+
+	Context("when marking by referrer", func() {
+		It("should push an artifact referring to the image", func() {
+			ref, err := (&SubmissionMarker{Mode: MarkByReferrer}).Mark(ctx, submission, results)
+			Expect(err).ToNot(HaveOccurred())
+
+			manifest, err := remote.Referrers(image)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(manifest.Manifests).To(HaveLen(1))
+			Expect(manifest.Manifests[0].ArtifactType).To(Equal(SubmittedArtifactType))
+			Expect(manifest.Manifests[0].Digest.String()).To(Equal(ref.Identifier()))
+		})
+
+		It("should fail if the image does not exist", func() {
+			submission.CertImage.Repositories[0].Repository = "test/missing"
+
+			_, err := (&SubmissionMarker{Mode: MarkByReferrer}).Mark(ctx, submission, results)
+			Expect(err).To(MatchError(ContainSubstring("could not find the submitted image")))
+		})
+	})
+
+	Context("when marking by tag", func() {
+		It("should tag an artifact annotated with the submission", func() {
+			ref, err := (&SubmissionMarker{Mode: MarkByTag}).Mark(ctx, submission, results)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ref.Identifier()).To(Equal(strings.Replace(image.DigestStr(), ":", "-", 1) + ".preflight"))
+
+			artifact, err := remote.Image(ref)
+			Expect(err).ToNot(HaveOccurred())
+			manifest, err := artifact.Manifest()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(manifest.Config.MediaType).To(BeEquivalentTo(SubmittedArtifactType))
+			Expect(manifest.Annotations).To(HaveKeyWithValue(AnnotationSubmitted, "0001-01-01T00:00:00Z"))
+			Expect(manifest.Annotations).To(HaveKeyWithValue(AnnotationTestResultsID, "222222222222"))
+			Expect(manifest.Annotations).To(HaveKeyWithValue(AnnotationImageID, "111111111111"))
+			Expect(manifest.Annotations).To(HaveKeyWithValue(AnnotationCertificationProjectID, "000000000000"))
+			Expect(manifest.Annotations).To(HaveKey(AnnotationVersion))
+		})
+	})
+
+	It("should fail if the submitted image does not contain its repository", func() {
+		submission.CertImage.Repositories = nil
+
+		_, err := (&SubmissionMarker{Mode: MarkByTag}).Mark(ctx, submission, results)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should fail with an unsupported mode", func() {
+		_, err := (&SubmissionMarker{Mode: "label"}).Mark(ctx, submission, results)
+		Expect(err).To(HaveOccurred())
+	})
+})
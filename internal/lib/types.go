@@ -1,11 +1,12 @@
 package lib
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"path"
 	"path/filepath"
@@ -17,7 +18,7 @@ import (
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/log"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/pyxis"
-	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/viper"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/transport"
 )
 
 // ResultWriter defines methods associated with writing check results.
@@ -36,13 +37,16 @@ type PyxisClient interface {
 	FindImagesByDigest(ctx context.Context, digests []string) ([]pyxis.CertImage, error)
 	GetProject(context.Context) (*pyxis.CertProject, error)
 	SubmitResults(context.Context, *pyxis.CertificationInput) (*pyxis.CertificationResults, error)
+	PlanSubmission(context.Context, *pyxis.CertificationInput) (*pyxis.SubmissionPlan, error)
 }
 
 // NewPyxisClient initializes a pyxisClient with relevant information from cfg.
 // If the the CertificationProjectID, PyxisAPIToken, or PyxisHost are empty, then nil is returned.
+// The PyxisAPIToken may be empty if ctx contains a pyxis.AccessTokenSource instead.
 // Callers should treat a nil pyxis client as an indicator that pyxis calls should not be made.
-func NewPyxisClient(ctx context.Context, projectID, token, host string) PyxisClient {
-	if projectID == "" || token == "" || host == "" {
+func NewPyxisClient(ctx context.Context, projectID, token, host string, opts ...pyxis.Option) PyxisClient {
+	_, hasAccessTokenSource := pyxis.AccessTokenSourceFromContext(ctx)
+	if projectID == "" || (token == "" && !hasAccessTokenSource) || host == "" {
 		return nil
 	}
 
@@ -50,66 +54,47 @@ func NewPyxisClient(ctx context.Context, projectID, token, host string) PyxisCli
 		host,
 		token,
 		projectID,
-		&http.Client{Timeout: 60 * time.Second},
+		transport.HTTPClient(ctx, 60*time.Second),
+		opts...,
 	)
 }
 
+// SubmissionDryRunFilename is the name of the artifact containing the payloads
+// that would have been submitted, when DryRun is set.
+const SubmissionDryRunFilename = "submission-dry-run.json"
+
+// redactedDockerConfig replaces the docker config in dry run output, since it
+// contains credentials.
+const redactedDockerConfig = "<redacted>"
+
 // ContainerCertificationSubmitter submits container results to Pyxis, and implements
 // a ResultSubmitter.
 type ContainerCertificationSubmitter struct {
 	CertificationProjectID string
-	Pyxis                  PyxisClient
-	DockerConfig           string
-	PreflightLogFile       string
+	// PyxisEnv is the Pyxis environment, e.g. qa, that results are submitted to. It
+	// determines the Red Hat Partner Connect URLs that are logged. Defaults to prod.
+	PyxisEnv         string
+	Pyxis            PyxisClient
+	DockerConfig     string
+	PreflightLogFile string
+	// DryRun prepares the submission and looks up existing entries in Pyxis,
+	// but only reports what would have been created or updated instead of
+	// submitting. The report is written to DryRunOutput and as an artifact.
+	DryRun bool
+	// DryRunOutput is where the dry run report is written. Defaults to stderr.
+	DryRunOutput io.Writer
+	// Marker, if set, marks the image in its registry as submitted, after the results
+	// are submitted.
+	Marker *SubmissionMarker
 }
 
 func (s *ContainerCertificationSubmitter) Submit(ctx context.Context) error {
 	logger := logr.FromContextOrDiscard(ctx)
 	logger.Info("preparing results that will be submitted to Red Hat")
 
-	// get the project info from pyxis
-	certProject, err := s.Pyxis.GetProject(ctx)
+	certProject, err := s.certProject(ctx)
 	if err != nil {
-		return fmt.Errorf("could not retrieve project: %w", err)
-	}
-
-	// Ensure that a certProject was returned. In theory we would expect pyxis
-	// to throw an error if no project is returned, but in the event that it doesn't
-	// we need to confirm before we proceed in order to prevent a runtime panic
-	// setting the DockerConfigJSON below.
-	if certProject == nil {
-		return fmt.Errorf("no certification project was returned from pyxis")
-	}
-
-	logger.V(log.TRC).Info("certification project id", "project", certProject)
-
-	// only read the dockerfile if the user provides a location for the file
-	// at this point in the flow, if `cfg.DockerConfig` is empty we know the repo is public and can continue the submission flow
-	if s.DockerConfig != "" {
-		dockerConfigJSONBytes, err := os.ReadFile(s.DockerConfig)
-		if err != nil {
-			return fmt.Errorf("could not open file for submission: %s: %w",
-				s.DockerConfig,
-				err,
-			)
-		}
-
-		certProject.Container.DockerConfigJSON = string(dockerConfigJSONBytes)
-	}
-
-	// the below code is for the edge case where a partner has a DockerConfig in pyxis, but does not send one to preflight.
-	// when we call pyxis's GetProject API, we get back the DockerConfig as a PGP encrypted string and not JSON,
-	// if we were to send what pyixs just sent us in a update call, pyxis would throw a validation error saying it's not valid json
-	// the below code aims to set the DockerConfigJSON to an empty string, and since this field is `omitempty` when we marshall it
-	// we will not get a validation error
-	if s.DockerConfig == "" {
-		certProject.Container.DockerConfigJSON = ""
-	}
-
-	// no longer set DockerConfigJSON for registries which Red Hat hosts, this prevents the user from sending an invalid
-	// docker file that systems like clair and registry-proxy cannot use to pull the image
-	if certProject.Container.HostedRegistry {
-		certProject.Container.DockerConfigJSON = ""
+		return err
 	}
 
 	// We need to get the artifact writer to know where our artifacts were written. We also need the
@@ -177,6 +162,76 @@ func (s *ContainerCertificationSubmitter) Submit(ctx context.Context) error {
 		return fmt.Errorf("unable to finalize data that would be sent to pyxis: %w", err)
 	}
 
+	return s.submit(ctx, submission)
+}
+
+// certProject returns the certification project from Pyxis, with the docker config that
+// will be submitted with it.
+func (s *ContainerCertificationSubmitter) certProject(ctx context.Context) (*pyxis.CertProject, error) {
+	logger := logr.FromContextOrDiscard(ctx)
+
+	// get the project info from pyxis
+	certProject, err := s.Pyxis.GetProject(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve project: %w", err)
+	}
+
+	// Ensure that a certProject was returned. In theory we would expect pyxis
+	// to throw an error if no project is returned, but in the event that it doesn't
+	// we need to confirm before we proceed in order to prevent a runtime panic
+	// setting the DockerConfigJSON below.
+	if certProject == nil {
+		return nil, fmt.Errorf("no certification project was returned from pyxis")
+	}
+
+	logger.V(log.TRC).Info("certification project id", "project", certProject)
+
+	// only read the dockerfile if the user provides a location for the file
+	// at this point in the flow, if `cfg.DockerConfig` is empty we know the repo is public and can continue the submission flow
+	if s.DockerConfig != "" {
+		dockerConfigJSONBytes, err := os.ReadFile(s.DockerConfig)
+		if err != nil {
+			return nil, fmt.Errorf("could not open file for submission: %s: %w",
+				s.DockerConfig,
+				err,
+			)
+		}
+
+		certProject.Container.DockerConfigJSON = string(dockerConfigJSONBytes)
+	}
+
+	// the below code is for the edge case where a partner has a DockerConfig in pyxis, but does not send one to preflight.
+	// when we call pyxis's GetProject API, we get back the DockerConfig as a PGP encrypted string and not JSON,
+	// if we were to send what pyixs just sent us in a update call, pyxis would throw a validation error saying it's not valid json
+	// the below code aims to set the DockerConfigJSON to an empty string, and since this field is `omitempty` when we marshall it
+	// we will not get a validation error
+	if s.DockerConfig == "" {
+		certProject.Container.DockerConfigJSON = ""
+	}
+
+	// no longer set DockerConfigJSON for registries which Red Hat hosts, this prevents the user from sending an invalid
+	// docker file that systems like clair and registry-proxy cannot use to pull the image
+	if certProject.Container.HostedRegistry {
+		certProject.Container.DockerConfigJSON = ""
+	}
+
+	return certProject, nil
+}
+
+// submit submits submission to Pyxis, or reports what would be submitted if DryRun is set.
+func (s *ContainerCertificationSubmitter) submit(ctx context.Context, submission *pyxis.CertificationInput) error {
+	logger := logr.FromContextOrDiscard(ctx)
+
+	// Policy exceptions are an organization's own, and are not honored by Red Hat.
+	if submission.TestResults != nil && len(submission.TestResults.Results.Waived) != 0 {
+		return fmt.Errorf("results with waived checks cannot be submitted to Red Hat: %d checks failed, but their failures were waived by policy exceptions",
+			len(submission.TestResults.Results.Waived))
+	}
+
+	if s.DryRun {
+		return s.reportDryRun(ctx, submission)
+	}
+
 	certResults, err := s.Pyxis.SubmitResults(ctx, submission)
 	if err != nil {
 		return fmt.Errorf("could not submit to pyxis: %w", err)
@@ -185,8 +240,55 @@ func (s *ContainerCertificationSubmitter) Submit(ctx context.Context) error {
 	logger.Info("Test results have been submitted to Red Hat.")
 	logger.Info("These results will be reviewed by Red Hat for final certification.")
 	logger.Info(fmt.Sprintf("The container's image id is: %s.", certResults.CertImage.ID))
-	logger.Info(fmt.Sprintf("Please check %s to view scan results.", BuildScanResultsURL(s.CertificationProjectID, certResults.CertImage.ID)))
-	logger.Info(fmt.Sprintf("Please check %s to monitor the progress.", BuildOverviewURL(s.CertificationProjectID)))
+	logger.Info(fmt.Sprintf("Please check %s to view scan results.", BuildScanResultsURL(s.PyxisEnv, s.CertificationProjectID, certResults.CertImage.ID)))
+	logger.Info(fmt.Sprintf("Please check %s to monitor the progress.", BuildOverviewURL(s.PyxisEnv, s.CertificationProjectID)))
+
+	if s.Marker != nil {
+		if _, err := s.Marker.Mark(ctx, submission, certResults); err != nil {
+			return fmt.Errorf("results were submitted, but the image could not be marked as submitted: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// reportDryRun writes the payloads that would be sent to Pyxis for submission
+// to s.DryRunOutput, and as an artifact.
+func (s *ContainerCertificationSubmitter) reportDryRun(ctx context.Context, submission *pyxis.CertificationInput) error {
+	logger := logr.FromContextOrDiscard(ctx)
+
+	plan, err := s.Pyxis.PlanSubmission(ctx, submission)
+	if err != nil {
+		return fmt.Errorf("could not plan submission to pyxis: %w", err)
+	}
+
+	if plan.ProjectUpdate != nil && plan.ProjectUpdate.Container.DockerConfigJSON != "" {
+		plan.ProjectUpdate.Container.DockerConfigJSON = redactedDockerConfig
+	}
+
+	// HTML is not escaped, so that the redacted docker config reads as such.
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "    ")
+	if err := enc.Encode(plan); err != nil {
+		return fmt.Errorf("could not format submission plan: %w", err)
+	}
+	b := buf.Bytes()
+
+	out := s.DryRunOutput
+	if out == nil {
+		out = os.Stderr
+	}
+	fmt.Fprint(out, string(b))
+
+	if aw := artifacts.WriterFromContext(ctx); aw != nil {
+		filename, err := aw.WriteFile(SubmissionDryRunFilename, bytes.NewReader(b))
+		if err != nil {
+			return err
+		}
+		logger.Info("Dry run: results were not submitted to Red Hat.", "plan", filename)
+	}
 
 	return nil
 }
@@ -229,21 +331,22 @@ func (s *NoopSubmitter) SetReason(reason string) {
 	s.reason = reason
 }
 
-func BuildConnectURL(projectID string) string {
+// BuildConnectURL returns the Red Hat Partner Connect URL of projectID in the Pyxis
+// environment pyxisEnv. An empty pyxisEnv is prod.
+func BuildConnectURL(pyxisEnv, projectID string) string {
 	connectURL := fmt.Sprintf("https://connect.redhat.com/projects/%s", projectID)
 
-	pyxisEnv := viper.Instance().GetString("pyxis_env")
 	if len(pyxisEnv) > 0 && pyxisEnv != "prod" {
-		connectURL = fmt.Sprintf("https://connect.%s.redhat.com/projects/%s", viper.Instance().GetString("pyxis_env"), projectID)
+		connectURL = fmt.Sprintf("https://connect.%s.redhat.com/projects/%s", pyxisEnv, projectID)
 	}
 
 	return connectURL
 }
 
-func BuildOverviewURL(projectID string) string {
-	return fmt.Sprintf("%s/overview", BuildConnectURL(projectID))
+func BuildOverviewURL(pyxisEnv, projectID string) string {
+	return fmt.Sprintf("%s/overview", BuildConnectURL(pyxisEnv, projectID))
 }
 
-func BuildScanResultsURL(projectID string, imageID string) string {
-	return fmt.Sprintf("%s/images/%s/scan-results", BuildConnectURL(projectID), imageID)
+func BuildScanResultsURL(pyxisEnv, projectID string, imageID string) string {
+	return fmt.Sprintf("%s/images/%s/scan-results", BuildConnectURL(pyxisEnv, projectID), imageID)
 }
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"os"
 	"path"
 	"strings"
@@ -101,16 +102,17 @@ var _ = Describe("Submitter Resolution", func() {
 			Expect(pc).ToNot(BeNil())
 
 			It("should return a containerCertificationSubmitter", func() {
-				submitter := ResolveSubmitter(pc, "projectID", "dockerconfig", "logfile")
+				submitter := ResolveSubmitter(pc, "prod", "projectID", "dockerconfig", "logfile")
 				typed, ok := submitter.(*ContainerCertificationSubmitter)
 				Expect(typed).ToNot(BeNil())
 				Expect(ok).To(BeTrue())
+				Expect(typed.PyxisEnv).To(Equal("prod"))
 			})
 		})
 
 		Context("With no pyxis client", func() {
 			It("should return a no-op submitter", func() {
-				submitter := ResolveSubmitter(nil, "", "", "")
+				submitter := ResolveSubmitter(nil, "", "", "", "")
 				typed, ok := submitter.(*NoopSubmitter)
 				Expect(typed).ToNot(BeNil())
 				Expect(ok).To(BeTrue())
@@ -119,6 +121,30 @@ var _ = Describe("Submitter Resolution", func() {
 	})
 })
 
+var _ = Describe("Connect URL builders", func() {
+	const (
+		projectID = "this-is-my-project-id"
+		imageID   = "my-image-id"
+	)
+
+	It("should return a URL with just a project ID for prod", func() {
+		Expect(BuildConnectURL("", projectID)).To(Equal("https://connect.redhat.com/projects/this-is-my-project-id"))
+		Expect(BuildConnectURL("prod", projectID)).To(Equal("https://connect.redhat.com/projects/this-is-my-project-id"))
+	})
+
+	It("should return a URL for QA", func() {
+		Expect(BuildConnectURL("qa", projectID)).To(Equal("https://connect.qa.redhat.com/projects/this-is-my-project-id"))
+	})
+
+	It("should return a scan results URL for UAT", func() {
+		Expect(BuildScanResultsURL("uat", projectID, imageID)).To(Equal("https://connect.uat.redhat.com/projects/this-is-my-project-id/images/my-image-id/scan-results"))
+	})
+
+	It("should return an overview URL for QA", func() {
+		Expect(BuildOverviewURL("qa", projectID)).To(Equal("https://connect.qa.redhat.com/projects/this-is-my-project-id/overview"))
+	})
+})
+
 var _ = Describe("The NoopSubmitter", func() {
 	Context("When using the noop submitter", func() {
 		var bf *bytes.Buffer
@@ -296,6 +322,19 @@ var _ = Describe("Container Certification Submitter", func() {
 			})
 		})
 
+		Context("and the preflight results contain waived checks", func() {
+			It("should throw an error", func() {
+				Expect(aw.WriteFile(check.DefaultTestResultsFilename, strings.NewReader(`{
+					"image": "foo",
+					"passed": true,
+					"results": {"passed": [], "failed": [], "errors": [], "waived": [{"name": "RunAsNonRoot"}]}
+				}`)))
+
+				err := sbmt.Submit(testcontext)
+				Expect(err).To(MatchError(ContainSubstring("results with waived checks cannot be submitted to Red Hat")))
+			})
+		})
+
 		Context("and the rpmManifest cannot be read from disk", func() {
 			It("should throw an error", func() {
 				err := os.Remove(path.Join(aw.Path(), check.DefaultRPMManifestFilename))
@@ -318,6 +357,49 @@ var _ = Describe("Container Certification Submitter", func() {
 			})
 		})
 
+		Context("and a dry run is requested", func() {
+			var out *bytes.Buffer
+			BeforeEach(func() {
+				out = &bytes.Buffer{}
+				sbmt.DryRun = true
+				sbmt.DryRunOutput = out
+				fakePC.planSubmissionFunc = psFuncPlanCreate
+				fakePC.submitResultsFunc = srFuncReturnError
+			})
+
+			It("should report what would be submitted without submitting", func() {
+				err := sbmt.Submit(testcontext)
+				Expect(err).ToNot(HaveOccurred())
+
+				var plan pyxis.SubmissionPlan
+				Expect(json.Unmarshal(out.Bytes(), &plan)).To(Succeed())
+				Expect(plan.ImageCreate).ToNot(BeNil())
+				Expect(plan.ImageCreate.ID).To(Equal("111111111111"))
+				Expect(plan.RPMManifestCreate).ToNot(BeNil())
+				Expect(plan.ArtifactsCreate).To(HaveLen(1))
+				Expect(plan.TestResultsCreate).ToNot(BeNil())
+
+				written, err := os.ReadFile(path.Join(aw.Path(), SubmissionDryRunFilename))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(written).To(MatchJSON(out.Bytes()))
+			})
+
+			It("should not report the docker config", func() {
+				err := sbmt.Submit(testcontext)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(out.String()).ToNot(ContainSubstring(`"dockerconfig"`))
+				Expect(out.String()).To(ContainSubstring(redactedDockerConfig))
+			})
+
+			It("should throw an error if the submission cannot be planned", func() {
+				fakePC.planSubmissionFunc = func(context.Context, *pyxis.CertificationInput) (*pyxis.SubmissionPlan, error) {
+					return nil, errors.New("unauthorized")
+				}
+				err := sbmt.Submit(testcontext)
+				Expect(err).To(MatchError(ContainSubstring("could not plan submission")))
+			})
+		})
+
 		Context("and the submission fails", func() {
 			BeforeEach(func() {
 				fakePC.submitResultsFunc = srFuncReturnError
@@ -0,0 +1,161 @@
+// Package lockfile pins the images preflight checks to the digests they are expected to
+// have, so that certification pipelines detect when an image changes unexpectedly, e.g.
+// when its tag is pushed again upstream.
+package lockfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	// ErrNotLocked is returned for an image that is not pinned by the lockfile.
+	ErrNotLocked = errors.New("image is not pinned by the lockfile")
+	// ErrDigestChanged is returned for an image whose digest differs from the digest
+	// it is pinned to.
+	ErrDigestChanged = errors.New("image digest differs from the digest pinned by the lockfile")
+	// ErrDigestDenied is returned for an image whose digest is denied by the lockfile.
+	ErrDigestDenied = errors.New("image digest is denied by the lockfile")
+)
+
+// Pin pins an image to its digest.
+type Pin struct {
+	// Digest is the digest the image is expected to have.
+	Digest string `json:"digest,omitempty"`
+	// Denied are digests the image must never have, e.g. those of builds known to be
+	// bad. They are denied even when the lockfile is updated.
+	Denied []string `json:"denied,omitempty"`
+}
+
+type Option = func(*Lockfile)
+
+// WithUpdate records the digests of images that are not pinned, or whose digest
+// differs from the digest they are pinned to, rather than rejecting them, and writes
+// the lockfile when it changes. The lockfile is created if it does not exist.
+func WithUpdate() Option {
+	return func(l *Lockfile) {
+		l.update = true
+	}
+}
+
+// Lockfile pins images, by reference, to the digests they are expected to have.
+type Lockfile struct {
+	// Images are the pins of the lockfile, by image reference.
+	Images map[string]Pin `json:"images"`
+
+	path   string
+	update bool
+	mu     sync.Mutex
+}
+
+// Load reads the lockfile at path.
+func Load(path string, opts ...Option) (*Lockfile, error) {
+	l := &Lockfile{path: path}
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	b, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist) && l.update:
+	case err != nil:
+		return nil, fmt.Errorf("could not read lockfile: %w", err)
+	default:
+		if err := yaml.UnmarshalStrict(b, l); err != nil {
+			return nil, fmt.Errorf("could not parse lockfile %s: %w", path, err)
+		}
+	}
+
+	if l.Images == nil {
+		l.Images = map[string]Pin{}
+	}
+
+	return l, nil
+}
+
+// Check ensures that image, whose digest is digest, is pinned to digest, and that
+// digest is not denied. Images referenced by digest are pinned by their reference,
+// and are not checked. If the lockfile is updated, image is pinned to digest instead,
+// and the lockfile is written.
+func (l *Lockfile) Check(image, digest string) error {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return fmt.Errorf("image uri could not be parsed: %w", err)
+	}
+	if _, ok := ref.(name.Digest); ok {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key := l.key(ref)
+	pin, ok := l.Images[key]
+	for _, denied := range pin.Denied {
+		if denied == digest {
+			return fmt.Errorf("%w: %s has digest %s", ErrDigestDenied, image, digest)
+		}
+	}
+
+	switch {
+	case ok && pin.Digest == digest:
+		return nil
+	case !l.update && !ok:
+		return fmt.Errorf("%w: %s has digest %s", ErrNotLocked, image, digest)
+	case !l.update:
+		return fmt.Errorf("%w: %s has digest %s, but is pinned to %s", ErrDigestChanged, image, digest, pin.Digest)
+	}
+
+	if !ok {
+		key = image
+	}
+	pin.Digest = digest
+	l.Images[key] = pin
+
+	return l.write()
+}
+
+// key returns the key of the pin of the image ref in the lockfile, which is the
+// reference as it is written in the lockfile, e.g. without the default registry,
+// or ref itself if it is not pinned.
+func (l *Lockfile) key(ref name.Reference) string {
+	if _, ok := l.Images[ref.String()]; ok {
+		return ref.String()
+	}
+
+	for image := range l.Images {
+		if pinned, err := name.ParseReference(image); err == nil && pinned.Name() == ref.Name() {
+			return image
+		}
+	}
+
+	return ref.String()
+}
+
+// write writes the lockfile to its path. It is written to a temporary file first, so
+// that an interruption never leaves a partial lockfile behind.
+func (l *Lockfile) write() error {
+	b, err := yaml.Marshal(l)
+	if err != nil {
+		return fmt.Errorf("could not format lockfile: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o755); err != nil {
+		return fmt.Errorf("could not write lockfile: %w", err)
+	}
+	tmp := l.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return fmt.Errorf("could not write lockfile: %w", err)
+	}
+	if err := os.Rename(tmp, l.path); err != nil {
+		return fmt.Errorf("could not write lockfile: %w", err)
+	}
+
+	return nil
+}
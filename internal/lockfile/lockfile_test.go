@@ -0,0 +1,109 @@
+package lockfile
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	digest1 = "sha256:0000000000000000000000000000000000000000000000000000000000000001"
+	digest2 = "sha256:0000000000000000000000000000000000000000000000000000000000000002"
+)
+
+var _ = Describe("Lockfile", func() {
+	var path string
+	BeforeEach(func() {
+		path = filepath.Join(GinkgoT().TempDir(), "preflight.lock")
+		Expect(os.WriteFile(path, []byte(`images:
+  quay.io/example/operand:v1:
+    digest: `+digest1+`
+  ubi9:latest:
+    digest: `+digest1+`
+  quay.io/example/bad:v1:
+    digest: `+digest1+`
+    denied:
+    - `+digest2+`
+`), 0o644)).To(Succeed())
+	})
+
+	Context("When loading a lockfile", func() {
+		It("should read its entries", func() {
+			l, err := Load(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(l.Images).To(HaveLen(3))
+			Expect(l.Images["quay.io/example/bad:v1"].Denied).To(Equal([]string{digest2}))
+		})
+
+		It("should reject unknown fields", func() {
+			Expect(os.WriteFile(path, []byte("images: {}\nunknown: true\n"), 0o644)).To(Succeed())
+			_, err := Load(path)
+			Expect(err).To(MatchError(ContainSubstring("could not parse lockfile")))
+		})
+
+		It("should fail if it does not exist", func() {
+			_, err := Load(filepath.Join(GinkgoT().TempDir(), "preflight.lock"))
+			Expect(err).To(MatchError(ContainSubstring("could not read lockfile")))
+		})
+
+		It("should start from an empty lockfile if it does not exist, and is updated", func() {
+			l, err := Load(filepath.Join(GinkgoT().TempDir(), "preflight.lock"), WithUpdate())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(l.Images).To(BeEmpty())
+		})
+	})
+
+	Context("When checking digests", func() {
+		var l *Lockfile
+		BeforeEach(func() {
+			var err error
+			l, err = Load(path)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should accept the pinned digest", func() {
+			Expect(l.Check("quay.io/example/operand:v1", digest1)).To(Succeed())
+		})
+
+		It("should match references regardless of the default registry", func() {
+			Expect(l.Check("docker.io/library/ubi9:latest", digest1)).To(Succeed())
+		})
+
+		It("should reject a digest that changed", func() {
+			Expect(l.Check("quay.io/example/operand:v1", digest2)).To(MatchError(ErrDigestChanged))
+		})
+
+		It("should reject images that are not pinned", func() {
+			Expect(l.Check("quay.io/example/other:v1", digest1)).To(MatchError(ErrNotLocked))
+		})
+
+		It("should not check images referenced by digest", func() {
+			Expect(l.Check("quay.io/example/other@"+digest1, digest1)).To(Succeed())
+		})
+	})
+
+	Context("When updating the lockfile", func() {
+		var l *Lockfile
+		BeforeEach(func() {
+			var err error
+			l, err = Load(path, WithUpdate())
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should pin the digest of images that changed, or were not pinned, and write the lockfile", func() {
+			Expect(l.Check("quay.io/example/operand:v1", digest2)).To(Succeed())
+			Expect(l.Check("quay.io/example/other:v1", digest1)).To(Succeed())
+
+			written, err := Load(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(written.Images).To(HaveKeyWithValue("quay.io/example/operand:v1", Pin{Digest: digest2}))
+			Expect(written.Images).To(HaveKeyWithValue("quay.io/example/other:v1", Pin{Digest: digest1}))
+		})
+
+		It("should still reject denied digests", func() {
+			Expect(l.Check("quay.io/example/bad:v1", digest2)).To(MatchError(ErrDigestDenied))
+		})
+	})
+})
@@ -0,0 +1,152 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/sirupsen/logrus"
+)
+
+// Components of preflight whose log verbosity can be set on their own, e.g. with
+// --loglevel info,pyxis=debug,crane=warn.
+const (
+	// ComponentCrane logs the interactions with container registries.
+	ComponentCrane = "crane"
+	// ComponentOLM logs the interactions with the cluster to install operators.
+	ComponentOLM = "olm"
+	// ComponentPyxis logs the interactions with Pyxis.
+	ComponentPyxis = "pyxis"
+	// ComponentScorecard logs the executions of operator-sdk.
+	ComponentScorecard = "scorecard"
+)
+
+// Components are the components of preflight whose log verbosity can be set on their own.
+var Components = []string{ComponentCrane, ComponentOLM, ComponentPyxis, ComponentScorecard}
+
+// FromContext returns the logger in ctx, named for component, so that it logs at the
+// verbosity of component. A logger that discards all messages is returned if ctx has
+// no logger.
+func FromContext(ctx context.Context, component string) logr.Logger {
+	return logr.FromContextOrDiscard(ctx).WithName(component)
+}
+
+// Levels are the verbosity of the log, and of the components that log at a verbosity
+// of their own.
+type Levels struct {
+	Default    logrus.Level
+	Components map[string]logrus.Level
+}
+
+// ParseLevels parses a comma-separated list of the verbosity of the log, and of the
+// components that log at a verbosity of their own, e.g. info,pyxis=debug,crane=warn.
+// The verbosity of the log is info if it is not in the list. Only the verbosity of the
+// log, at info, is returned with an error if s cannot be parsed.
+func ParseLevels(s string) (Levels, error) {
+	levels := Levels{Default: logrus.InfoLevel, Components: map[string]logrus.Level{}}
+	invalid := Levels{Default: logrus.InfoLevel}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		component, level, named := strings.Cut(entry, "=")
+		if !named {
+			level = component
+		}
+		ll, err := logrus.ParseLevel(strings.TrimSpace(level))
+		if err != nil {
+			return invalid, fmt.Errorf("invalid log level %q: %w", entry, err)
+		}
+		if !named {
+			levels.Default = ll
+			continue
+		}
+
+		component = strings.TrimSpace(component)
+		if !isComponent(component) {
+			return invalid, fmt.Errorf("invalid log level %q: unknown component %s, expected one of %s", entry, component, strings.Join(Components, ", "))
+		}
+		levels.Components[component] = ll
+	}
+
+	return levels, nil
+}
+
+// Max returns the most verbose of the levels, which the logrus logger that the log is
+// written with must be set to.
+func (l Levels) Max() logrus.Level {
+	max := l.Default
+	for _, ll := range l.Components {
+		if ll > max {
+			max = ll
+		}
+	}
+
+	return max
+}
+
+// NewLevelSink returns a sink that writes the messages enabled by levels to sink, which
+// is expected to be a logrusr sink whose logrus logger is set to levels.Max(). Messages
+// are logged at the verbosity of the component a logger was last named for with
+// FromContext, or at levels.Default if it was not.
+func NewLevelSink(sink logr.LogSink, levels Levels) logr.LogSink {
+	return levelSink{sink: sink, levels: levels, level: levels.Default}
+}
+
+type levelSink struct {
+	sink   logr.LogSink
+	levels Levels
+	level  logrus.Level
+}
+
+var _ logr.LogSink = levelSink{}
+
+// logrusDiffToInfo is the difference between the verbosity of logr, where info is 0,
+// and the levels of logrus, as mapped by logrusr.
+const logrusDiffToInfo = int(logrus.InfoLevel)
+
+func (s levelSink) Init(info logr.RuntimeInfo) {
+	s.sink.Init(info)
+}
+
+func (s levelSink) Enabled(level int) bool {
+	return logrus.Level(level+logrusDiffToInfo) <= s.level && s.sink.Enabled(level)
+}
+
+func (s levelSink) Info(level int, msg string, keysAndValues ...interface{}) {
+	if s.Enabled(level) {
+		s.sink.Info(level, msg, keysAndValues...)
+	}
+}
+
+func (s levelSink) Error(err error, msg string, keysAndValues ...interface{}) {
+	if s.level >= logrus.ErrorLevel {
+		s.sink.Error(err, msg, keysAndValues...)
+	}
+}
+
+func (s levelSink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	s.sink = s.sink.WithValues(keysAndValues...)
+	return s
+}
+
+func (s levelSink) WithName(name string) logr.LogSink {
+	s.sink = s.sink.WithName(name)
+	if ll, ok := s.levels.Components[name]; ok {
+		s.level = ll
+	}
+	return s
+}
+
+func isComponent(name string) bool {
+	for _, component := range Components {
+		if component == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,92 @@
+package log
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/bombsimon/logrusr/v4"
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+)
+
+var _ = Describe("Log levels", func() {
+	Context("when parsing levels", func() {
+		It("should log at info by default", func() {
+			levels, err := ParseLevels("")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(levels.Default).To(Equal(logrus.InfoLevel))
+			Expect(levels.Components).To(BeEmpty())
+		})
+		It("should parse the level of the log and of its components", func() {
+			levels, err := ParseLevels("warn, pyxis=debug,crane=error")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(levels.Default).To(Equal(logrus.WarnLevel))
+			Expect(levels.Components).To(Equal(map[string]logrus.Level{
+				ComponentPyxis: logrus.DebugLevel,
+				ComponentCrane: logrus.ErrorLevel,
+			}))
+			Expect(levels.Max()).To(Equal(logrus.DebugLevel))
+		})
+		It("should keep the default level when only components are set", func() {
+			levels, err := ParseLevels("olm=trace")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(levels.Default).To(Equal(logrus.InfoLevel))
+			Expect(levels.Max()).To(Equal(logrus.TraceLevel))
+		})
+		DescribeTable("should throw an error for invalid levels",
+			func(s, message string) {
+				levels, err := ParseLevels(s)
+				Expect(err).To(MatchError(ContainSubstring(message)))
+				Expect(levels.Default).To(Equal(logrus.InfoLevel))
+				Expect(levels.Components).To(BeEmpty())
+			},
+			Entry("unknown level", "loud", `invalid log level "loud"`),
+			Entry("unknown component level", "pyxis=loud", `invalid log level "pyxis=loud"`),
+			Entry("unknown component", "debug,registry=debug", "unknown component registry"),
+		)
+	})
+
+	Context("when logging with levels", func() {
+		var (
+			buf    bytes.Buffer
+			logger logr.Logger
+		)
+		BeforeEach(func() {
+			buf.Reset()
+			levels, err := ParseLevels("info,pyxis=debug,crane=error")
+			Expect(err).ToNot(HaveOccurred())
+
+			l := logrus.New()
+			l.SetOutput(&buf)
+			l.SetLevel(levels.Max())
+			logger = logr.New(NewLevelSink(logrusr.New(l).GetSink(), levels))
+		})
+		It("should log at the default level outside of components", func() {
+			logger.Info("info message")
+			logger.V(DBG).Info("debug message")
+			Expect(buf.String()).To(ContainSubstring("info message"))
+			Expect(buf.String()).ToNot(ContainSubstring("debug message"))
+		})
+		It("should log at the level of a component", func() {
+			ctx := logr.NewContext(context.Background(), logger)
+			FromContext(ctx, ComponentPyxis).V(DBG).Info("pyxis debug message")
+			FromContext(ctx, ComponentPyxis).V(TRC).Info("pyxis trace message")
+			FromContext(ctx, ComponentCrane).Info("crane info message")
+			FromContext(ctx, ComponentCrane).Error(nil, "crane error message")
+			Expect(buf.String()).To(ContainSubstring("pyxis debug message"))
+			Expect(buf.String()).To(ContainSubstring("logger=pyxis"))
+			Expect(buf.String()).ToNot(ContainSubstring("pyxis trace message"))
+			Expect(buf.String()).ToNot(ContainSubstring("crane info message"))
+			Expect(buf.String()).To(ContainSubstring("crane error message"))
+		})
+		It("should log at the default level for components without a level", func() {
+			ctx := logr.NewContext(context.Background(), logger)
+			FromContext(ctx, ComponentOLM).Info("olm info message")
+			FromContext(ctx, ComponentOLM).V(DBG).Info("olm debug message")
+			Expect(buf.String()).To(ContainSubstring("olm info message"))
+			Expect(buf.String()).ToNot(ContainSubstring("olm debug message"))
+		})
+	})
+})
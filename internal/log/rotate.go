@@ -0,0 +1,200 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rotatedTimeFormat is the format of the time a log file was rotated at, in the name
+// of the file it was rotated to.
+const rotatedTimeFormat = "2006-01-02T15-04-05.000"
+
+// RotateOptions configure when a log file is rotated, and how many of the files it was
+// rotated to are kept.
+type RotateOptions struct {
+	// MaxSize is the size in bytes a log file may grow to before it is rotated. If it
+	// is 0, log files are not rotated by size.
+	MaxSize int64
+	// MaxAge is how long a log file is written to before it is rotated. If it is 0,
+	// log files are not rotated by age.
+	MaxAge time.Duration
+	// MaxBackups is how many rotated log files are kept. The oldest are removed. If it
+	// is 0, all of them are kept.
+	MaxBackups int
+}
+
+// Enabled returns true if opts rotate log files.
+func (opts RotateOptions) Enabled() bool {
+	return opts.MaxSize > 0 || opts.MaxAge > 0
+}
+
+// RotatingFile is a log file that is rotated, as configured by its RotateOptions, by
+// renaming it to a file with the time it was rotated at in its name, e.g.
+// preflight-2024-06-01T12-00-00.000.log, and writing to a new file.
+type RotatingFile struct {
+	path string
+	opts RotateOptions
+	now  func() time.Time
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	opened time.Time
+}
+
+// OpenRotatingFile opens the log file at path, appending to it if it exists. A file
+// that has not been written to for longer than opts.MaxAge is rotated first.
+func OpenRotatingFile(path string, opts RotateOptions) (*RotatingFile, error) {
+	return openRotatingFile(path, opts, time.Now)
+}
+
+func openRotatingFile(path string, opts RotateOptions, now func() time.Time) (*RotatingFile, error) {
+	f := &RotatingFile{path: path, opts: opts, now: now}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	if opts.MaxAge > 0 && f.size > 0 {
+		if info, err := f.file.Stat(); err == nil && f.opened.Sub(info.ModTime()) >= opts.MaxAge {
+			if err := f.rotate(); err != nil {
+				f.file.Close()
+				return nil, err
+			}
+		}
+	}
+
+	return f, nil
+}
+
+// Write writes p to the log file, rotating it first if p would make it larger than
+// MaxSize, or it has been written to for longer than MaxAge.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.size > 0 && f.shouldRotate(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+
+	return n, err
+}
+
+// Close closes the log file.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.file.Close()
+}
+
+// shouldRotate returns true if writing n bytes would make the log file larger than
+// MaxSize, or it has been written to for longer than MaxAge.
+func (f *RotatingFile) shouldRotate(n int64) bool {
+	if f.opts.MaxSize > 0 && f.size+n > f.opts.MaxSize {
+		return true
+	}
+
+	return f.opts.MaxAge > 0 && f.now().Sub(f.opened) >= f.opts.MaxAge
+}
+
+// open opens the log file for appending.
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		file.Close()
+		return fmt.Errorf("could not rotate log file %s: not a regular file", f.path)
+	}
+
+	f.file, f.size, f.opened = file, info.Size(), f.now()
+	return nil
+}
+
+// rotate renames the log file to a file with the current time in its name, opens a new
+// one, and removes the rotated files that are not kept.
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("could not close log file %s: %w", f.path, err)
+	}
+
+	prefix, ext := f.rotatedNameParts()
+	if err := os.Rename(f.path, prefix+f.now().UTC().Format(rotatedTimeFormat)+ext); err != nil {
+		return fmt.Errorf("could not rotate log file %s: %w", f.path, err)
+	}
+	if err := f.open(); err != nil {
+		return fmt.Errorf("could not rotate log file %s: %w", f.path, err)
+	}
+
+	return f.removeOldBackups()
+}
+
+// removeOldBackups removes the oldest rotated log files, so that only MaxBackups are
+// kept.
+func (f *RotatingFile) removeOldBackups() error {
+	if f.opts.MaxBackups <= 0 {
+		return nil
+	}
+
+	backups, err := f.backups()
+	if err != nil {
+		return fmt.Errorf("could not list rotated log files of %s: %w", f.path, err)
+	}
+	for len(backups) > f.opts.MaxBackups {
+		if err := os.Remove(backups[0]); err != nil {
+			return fmt.Errorf("could not remove rotated log file: %w", err)
+		}
+		backups = backups[1:]
+	}
+
+	return nil
+}
+
+// backups returns the paths of the rotated log files, oldest first.
+func (f *RotatingFile) backups() ([]string, error) {
+	prefix, ext := f.rotatedNameParts()
+	entries, err := os.ReadDir(filepath.Dir(f.path))
+	if err != nil {
+		return nil, err
+	}
+
+	base := filepath.Base(prefix)
+	var backups []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, base) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		if _, err := time.Parse(rotatedTimeFormat, strings.TrimSuffix(strings.TrimPrefix(name, base), ext)); err != nil {
+			continue
+		}
+		backups = append(backups, filepath.Join(filepath.Dir(f.path), name))
+	}
+	// The times in the names sort chronologically.
+	sort.Strings(backups)
+
+	return backups, nil
+}
+
+// rotatedNameParts returns the path that the names of rotated log files start with, and
+// the extension they end with.
+func (f *RotatingFile) rotatedNameParts() (string, string) {
+	ext := filepath.Ext(f.path)
+	return strings.TrimSuffix(f.path, ext) + "-", ext
+}
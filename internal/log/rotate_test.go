@@ -0,0 +1,113 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Rotating log files", func() {
+	var (
+		dir  string
+		path string
+		now  time.Time
+	)
+
+	clock := func() time.Time { return now }
+
+	rotated := func() []string {
+		matches, err := filepath.Glob(filepath.Join(dir, "preflight-*.log"))
+		Expect(err).ToNot(HaveOccurred())
+		return matches
+	}
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		path = filepath.Join(dir, "preflight.log")
+		now = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	})
+
+	It("should only be enabled by a size or an age", func() {
+		Expect(RotateOptions{}.Enabled()).To(BeFalse())
+		Expect(RotateOptions{MaxBackups: 3}.Enabled()).To(BeFalse())
+		Expect(RotateOptions{MaxSize: 1024}.Enabled()).To(BeTrue())
+		Expect(RotateOptions{MaxAge: time.Hour}.Enabled()).To(BeTrue())
+	})
+
+	It("should append to an existing log file", func() {
+		Expect(os.WriteFile(path, []byte("previous\n"), 0o600)).To(Succeed())
+		f, err := openRotatingFile(path, RotateOptions{MaxSize: 1024}, clock)
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(f.Close)
+
+		_, err = f.Write([]byte("next\n"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.ReadFile(path)).To(Equal([]byte("previous\nnext\n")))
+		Expect(rotated()).To(BeEmpty())
+	})
+
+	It("should rotate the log file when it would grow larger than the maximum size", func() {
+		f, err := openRotatingFile(path, RotateOptions{MaxSize: 10}, clock)
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(f.Close)
+
+		_, err = f.Write([]byte("12345678\n"))
+		Expect(err).ToNot(HaveOccurred())
+		_, err = f.Write([]byte("abc\n"))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(os.ReadFile(path)).To(Equal([]byte("abc\n")))
+		Expect(rotated()).To(ConsistOf(filepath.Join(dir, "preflight-2024-06-01T12-00-00.000.log")))
+	})
+
+	It("should rotate the log file once it is older than the maximum age", func() {
+		f, err := openRotatingFile(path, RotateOptions{MaxAge: time.Hour}, clock)
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(f.Close)
+
+		_, err = f.Write([]byte("first\n"))
+		Expect(err).ToNot(HaveOccurred())
+		now = now.Add(30 * time.Minute)
+		_, err = f.Write([]byte("second\n"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rotated()).To(BeEmpty())
+
+		now = now.Add(30 * time.Minute)
+		_, err = f.Write([]byte("third\n"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.ReadFile(path)).To(Equal([]byte("third\n")))
+		Expect(rotated()).To(HaveLen(1))
+	})
+
+	It("should rotate a log file that has not been written to for longer than the maximum age when it is opened", func() {
+		Expect(os.WriteFile(path, []byte("stale\n"), 0o600)).To(Succeed())
+		Expect(os.Chtimes(path, now.Add(-2*time.Hour), now.Add(-2*time.Hour))).To(Succeed())
+
+		f, err := openRotatingFile(path, RotateOptions{MaxAge: time.Hour}, clock)
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(f.Close)
+
+		Expect(os.ReadFile(path)).To(BeEmpty())
+		Expect(rotated()).To(HaveLen(1))
+	})
+
+	It("should only keep the maximum number of rotated log files", func() {
+		f, err := openRotatingFile(path, RotateOptions{MaxSize: 1, MaxBackups: 2}, clock)
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(f.Close)
+
+		for i := 0; i < 5; i++ {
+			now = now.Add(time.Second)
+			_, err = f.Write([]byte("line\n"))
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		Expect(rotated()).To(ConsistOf(
+			filepath.Join(dir, "preflight-2024-06-01T12-00-04.000.log"),
+			filepath.Join(dir, "preflight-2024-06-01T12-00-05.000.log"),
+		))
+	})
+})
@@ -0,0 +1,126 @@
+// Package metrics exposes Prometheus metrics of preflight runs, such as how many
+// runs passed, how long each check takes, and how many submissions to Pyxis failed,
+// so that pipelines certifying many images can be monitored.
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/events"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Path is the path metrics are served at.
+const Path = "/metrics"
+
+// Results of submissions.
+const (
+	SubmissionSucceeded = "success"
+	SubmissionFailed    = "failure"
+)
+
+// Metrics records the metrics of preflight runs. It is an events.Listener, that
+// records runs and checks from their events. A nil *Metrics records nothing.
+type Metrics struct {
+	registry      *prometheus.Registry
+	runs          *prometheus.CounterVec
+	checkDuration *prometheus.HistogramVec
+	submissions   *prometheus.CounterVec
+}
+
+// New returns Metrics registered with a registry of their own.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		runs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "preflight_runs_total",
+			Help: "The runs of preflight, by result: PASSED, FAILED, or ERROR.",
+		}, []string{"result"}),
+		checkDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "preflight_check_duration_seconds",
+			Help: "How long each check took, by check and result.",
+			// Checks take from milliseconds to the better part of an hour, for
+			// those that install an operator.
+			Buckets: prometheus.ExponentialBuckets(0.1, 4, 9),
+		}, []string{"check", "result"}),
+		submissions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "preflight_submissions_total",
+			Help: "The submissions of results to Pyxis, by result: success or failure.",
+		}, []string{"result"}),
+	}
+	m.registry.MustRegister(m.runs, m.checkDuration, m.submissions)
+
+	return m
+}
+
+// OnEvent records the result of runs, and the duration of checks.
+func (m *Metrics) OnEvent(e events.Event) {
+	if m == nil {
+		return
+	}
+
+	switch e.Type {
+	case events.TypeRunSummary:
+		m.runs.WithLabelValues(e.Result).Inc()
+	case events.TypeCheckFinished:
+		// The elapsed time of events is in milliseconds.
+		m.checkDuration.WithLabelValues(e.Check, e.Result).Observe(e.ElapsedTime / 1000)
+	}
+}
+
+// ObserveSubmission records a submission to Pyxis that failed with err, if not nil.
+func (m *Metrics) ObserveSubmission(err error) {
+	if m == nil {
+		return
+	}
+
+	result := SubmissionSucceeded
+	if err != nil {
+		result = SubmissionFailed
+	}
+	m.submissions.WithLabelValues(result).Inc()
+}
+
+// Handler returns an http.Handler serving the metrics in the Prometheus format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Serve serves the metrics at Path on addr, e.g. :9090, until the returned function
+// is called.
+func (m *Metrics) Serve(addr string) (func(context.Context) error, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("could not serve metrics on %s: %w", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(Path, m.Handler())
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	// Serve returns http.ErrServerClosed once the server is shut down.
+	go func() { _ = server.Serve(listener) }()
+
+	return server.Shutdown, nil
+}
+
+// contextKey is a key used to store/retrieve Metrics in/from context.Context.
+type contextKey string
+
+const metricsContextKey contextKey = "Metrics"
+
+// ContextWithMetrics adds Metrics m to the context ctx.
+func ContextWithMetrics(ctx context.Context, m *Metrics) context.Context {
+	return context.WithValue(ctx, metricsContextKey, m)
+}
+
+// FromContext returns the Metrics in ctx, or nil, which records nothing.
+func FromContext(ctx context.Context) *Metrics {
+	m, _ := ctx.Value(metricsContextKey).(*Metrics)
+	return m
+}
@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/events"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+var _ = Describe("Metrics", func() {
+	var m *Metrics
+
+	BeforeEach(func() {
+		m = New()
+	})
+
+	Context("when recording events", func() {
+		It("should count runs by result", func() {
+			m.OnEvent(events.Event{Type: events.TypeRunStarted})
+			m.OnEvent(events.Event{Type: events.TypeRunSummary, Result: "PASSED"})
+			m.OnEvent(events.Event{Type: events.TypeRunSummary, Result: "PASSED"})
+			m.OnEvent(events.Event{Type: events.TypeRunSummary, Result: "ERROR"})
+			Expect(testutil.ToFloat64(m.runs.WithLabelValues("PASSED"))).To(Equal(2.0))
+			Expect(testutil.ToFloat64(m.runs.WithLabelValues("ERROR"))).To(Equal(1.0))
+		})
+		It("should observe the duration of checks in seconds", func() {
+			m.OnEvent(events.Event{Type: events.TypeCheckStarted, Check: "HasLicense"})
+			m.OnEvent(events.Event{Type: events.TypeCheckFinished, Check: "HasLicense", Result: "PASSED", ElapsedTime: 1500})
+			m.OnEvent(events.Event{Type: events.TypeCheckFinished, Check: "DeployableByOLM", Result: "FAILED", ElapsedTime: 600000})
+			Expect(testutil.CollectAndCount(m.checkDuration)).To(Equal(2))
+		})
+	})
+
+	Context("when recording submissions", func() {
+		It("should count submissions by result", func() {
+			m.ObserveSubmission(nil)
+			m.ObserveSubmission(errors.New("status code 500"))
+			m.ObserveSubmission(nil)
+			Expect(testutil.ToFloat64(m.submissions.WithLabelValues(SubmissionSucceeded))).To(Equal(2.0))
+			Expect(testutil.ToFloat64(m.submissions.WithLabelValues(SubmissionFailed))).To(Equal(1.0))
+		})
+	})
+
+	Context("when there are no metrics", func() {
+		It("should record nothing", func() {
+			m := FromContext(context.TODO())
+			Expect(m).To(BeNil())
+			m.OnEvent(events.Event{Type: events.TypeRunSummary, Result: "PASSED"})
+			m.ObserveSubmission(nil)
+		})
+	})
+
+	Context("when serving metrics", func() {
+		It("should serve the metrics at the metrics path", func() {
+			m.OnEvent(events.Event{Type: events.TypeRunSummary, Result: "FAILED"})
+
+			// Find a free port to serve on.
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).ToNot(HaveOccurred())
+			addr := listener.Addr().String()
+			Expect(listener.Close()).To(Succeed())
+
+			stop, err := m.Serve(addr)
+			Expect(err).ToNot(HaveOccurred())
+			DeferCleanup(stop, context.TODO())
+
+			resp, err := http.Get("http://" + addr + Path)
+			Expect(err).ToNot(HaveOccurred())
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(body)).To(ContainSubstring(`preflight_runs_total{result="FAILED"} 1`))
+		})
+		It("should throw an error if the address cannot be listened on", func() {
+			_, err := m.Serve("not an address")
+			Expect(err).To(MatchError(ContainSubstring("could not serve metrics on not an address")))
+		})
+	})
+})
@@ -0,0 +1,259 @@
+// Package mirror redirects image pulls to registry mirrors, e.g. in disconnected
+// environments, as configured by OpenShift's ImageDigestMirrorSet, ImageTagMirrorSet,
+// and ImageContentSourcePolicy resources.
+package mirror
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1alpha1 "github.com/openshift/api/operator/v1alpha1"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+// References are the kinds of image references a Mirror applies to.
+type References int
+
+const (
+	// AnyReference applies a Mirror to references by tag and by digest.
+	AnyReference References = iota
+	// DigestReference applies a Mirror only to references by digest, as for an
+	// ImageDigestMirrorSet or ImageContentSourcePolicy.
+	DigestReference
+	// TagReference applies a Mirror only to references by tag, as for an
+	// ImageTagMirrorSet.
+	TagReference
+)
+
+const dockerHub = "docker.io"
+
+// Mirror redirects pulls of images in Source to its Mirrors.
+type Mirror struct {
+	// Source is a registry host, optionally prefixed with "*." to match its
+	// subdomains, followed by an optional namespace or repository, e.g.
+	// registry.redhat.io/rhel9.
+	Source string
+	// Mirrors are the locations that replace Source, in order of preference.
+	Mirrors []string
+	// References are the kinds of references the Mirror applies to.
+	References References
+	// NeverContactSource prevents pulling from Source when no mirror can be
+	// pulled from.
+	NeverContactSource bool
+}
+
+// Parse returns the Mirror described by s, in the form source=mirror, which applies
+// to all references.
+func Parse(s string) (Mirror, error) {
+	source, mirror, ok := strings.Cut(s, "=")
+	source, mirror = strings.TrimSpace(source), strings.TrimSpace(mirror)
+	if !ok || source == "" || mirror == "" {
+		return Mirror{}, fmt.Errorf("invalid registry mirror %q: must be in the form source=mirror", s)
+	}
+
+	return Mirror{Source: source, Mirrors: []string{mirror}}, nil
+}
+
+// LoadFile returns the mirrors configured by the ImageDigestMirrorSet, ImageTagMirrorSet,
+// and ImageContentSourcePolicy resources in the YAML or JSON file at path. The file may
+// contain multiple documents, and lists of resources, such as the output of
+// `oc get imagedigestmirrorset -o yaml`.
+func LoadFile(path string) ([]Mirror, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open mirror configuration: %w", err)
+	}
+	defer f.Close()
+
+	var mirrors []Mirror
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(f))
+	for {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read mirror configuration: %w", err)
+		}
+
+		docMirrors, err := fromDocument(doc)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse mirror configuration %s: %w", path, err)
+		}
+		mirrors = append(mirrors, docMirrors...)
+	}
+
+	return mirrors, nil
+}
+
+// fromDocument returns the mirrors configured by the resource, or list of resources, in doc.
+// Resources of other kinds are ignored.
+func fromDocument(doc []byte) ([]Mirror, error) {
+	if len(bytes.TrimSpace(doc)) == 0 {
+		return nil, nil
+	}
+
+	var object struct {
+		Kind  string            `json:"kind"`
+		Items []json.RawMessage `json:"items"`
+	}
+	if err := yaml.Unmarshal(doc, &object); err != nil {
+		return nil, err
+	}
+
+	var mirrors []Mirror
+	switch object.Kind {
+	case "List", "ImageDigestMirrorSetList", "ImageTagMirrorSetList", "ImageContentSourcePolicyList":
+		for _, item := range object.Items {
+			itemMirrors, err := fromDocument(item)
+			if err != nil {
+				return nil, err
+			}
+			mirrors = append(mirrors, itemMirrors...)
+		}
+	case "ImageDigestMirrorSet":
+		var idms configv1.ImageDigestMirrorSet
+		if err := yaml.Unmarshal(doc, &idms); err != nil {
+			return nil, err
+		}
+		for _, m := range idms.Spec.ImageDigestMirrors {
+			mirrors = append(mirrors, Mirror{
+				Source:             m.Source,
+				Mirrors:            imageMirrors(m.Mirrors),
+				References:         DigestReference,
+				NeverContactSource: m.MirrorSourcePolicy == configv1.NeverContactSource,
+			})
+		}
+	case "ImageTagMirrorSet":
+		var itms configv1.ImageTagMirrorSet
+		if err := yaml.Unmarshal(doc, &itms); err != nil {
+			return nil, err
+		}
+		for _, m := range itms.Spec.ImageTagMirrors {
+			mirrors = append(mirrors, Mirror{
+				Source:             m.Source,
+				Mirrors:            imageMirrors(m.Mirrors),
+				References:         TagReference,
+				NeverContactSource: m.MirrorSourcePolicy == configv1.NeverContactSource,
+			})
+		}
+	case "ImageContentSourcePolicy":
+		var icsp operatorv1alpha1.ImageContentSourcePolicy
+		if err := yaml.Unmarshal(doc, &icsp); err != nil {
+			return nil, err
+		}
+		for _, m := range icsp.Spec.RepositoryDigestMirrors {
+			mirrors = append(mirrors, Mirror{
+				Source:     m.Source,
+				Mirrors:    m.Mirrors,
+				References: DigestReference,
+			})
+		}
+	}
+
+	return mirrors, nil
+}
+
+func imageMirrors(in []configv1.ImageMirror) []string {
+	out := make([]string, 0, len(in))
+	for _, m := range in {
+		out = append(out, string(m))
+	}
+	return out
+}
+
+// Candidates returns the references from which image should be pulled, in order of
+// preference. The mirrors of the most specific Source matching image are tried first,
+// followed by image itself unless a matching Mirror never contacts its source.
+func Candidates(image string, mirrors []Mirror) ([]string, error) {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return nil, fmt.Errorf("image uri could not be parsed: %w", err)
+	}
+
+	references := TagReference
+	if _, ok := ref.(name.Digest); ok {
+		references = DigestReference
+	}
+
+	repository := ref.Context().Name()
+	registry := ref.Context().RegistryStr()
+	// Sources refer to Docker Hub as docker.io, rather than its API host.
+	if registry == name.DefaultRegistry {
+		registry = dockerHub
+		repository = dockerHub + strings.TrimPrefix(repository, name.DefaultRegistry)
+	}
+
+	// The most specific source is the longest, and all mirrors for it are
+	// tried in the order they are configured.
+	var matched string
+	var rest string
+	var candidates []string
+	neverContactSource := false
+	for _, m := range mirrors {
+		if m.References != AnyReference && m.References != references {
+			continue
+		}
+
+		mRest, ok := match(m.Source, repository, registry)
+		if !ok || len(m.Source) < len(matched) {
+			continue
+		}
+		if len(m.Source) > len(matched) {
+			matched, rest, candidates, neverContactSource = m.Source, mRest, nil, false
+		}
+
+		for _, mirror := range m.Mirrors {
+			candidates = appendUnique(candidates, strings.TrimSuffix(mirror, "/")+rest+identifier(ref))
+		}
+		neverContactSource = neverContactSource || m.NeverContactSource
+	}
+
+	if !neverContactSource {
+		candidates = appendUnique(candidates, image)
+	}
+
+	return candidates, nil
+}
+
+// match returns the remainder of repository after source, if source matches it.
+func match(source, repository, registry string) (string, bool) {
+	if strings.HasPrefix(source, "*.") {
+		if strings.HasSuffix(registry, source[1:]) {
+			return strings.TrimPrefix(repository, registry), true
+		}
+		return "", false
+	}
+
+	if repository == source || strings.HasPrefix(repository, source+"/") {
+		return strings.TrimPrefix(repository, source), true
+	}
+
+	return "", false
+}
+
+// identifier returns the tag or digest of ref, including its separator.
+func identifier(ref name.Reference) string {
+	if d, ok := ref.(name.Digest); ok {
+		return "@" + d.DigestStr()
+	}
+	return ":" + ref.Identifier()
+}
+
+func appendUnique(s []string, v string) []string {
+	for _, existing := range s {
+		if existing == v {
+			return s
+		}
+	}
+	return append(s, v)
+}
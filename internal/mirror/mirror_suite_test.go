@@ -0,0 +1,13 @@
+package mirror
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestMirror(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Mirror Suite")
+}
@@ -0,0 +1,112 @@
+package mirror
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const digest = "@sha256:0000000000000000000000000000000000000000000000000000000000000001"
+
+var _ = Describe("Mirror", func() {
+	Context("When parsing a registry mirror", func() {
+		It("should apply to all references", func() {
+			m, err := Parse("registry.redhat.io=mirror.example.com/redhat")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(m).To(Equal(Mirror{Source: "registry.redhat.io", Mirrors: []string{"mirror.example.com/redhat"}}))
+		})
+		It("should reject values without a mirror", func() {
+			_, err := Parse("registry.redhat.io")
+			Expect(err).To(MatchError(ContainSubstring("must be in the form source=mirror")))
+		})
+	})
+
+	Context("When loading mirror configuration", func() {
+		var path string
+		BeforeEach(func() {
+			path = filepath.Join(GinkgoT().TempDir(), "mirrors.yaml")
+			Expect(os.WriteFile(path, []byte(`apiVersion: config.openshift.io/v1
+kind: ImageDigestMirrorSet
+metadata:
+  name: redhat
+spec:
+  imageDigestMirrors:
+  - source: registry.redhat.io/rhel9
+    mirrors:
+    - mirror.example.com/rhel9
+    mirrorSourcePolicy: NeverContactSource
+---
+apiVersion: v1
+kind: List
+items:
+- apiVersion: operator.openshift.io/v1alpha1
+  kind: ImageContentSourcePolicy
+  metadata:
+    name: hub
+  spec:
+    repositoryDigestMirrors:
+    - source: docker.io/library
+      mirrors:
+      - mirror.example.com/hub
+- apiVersion: config.openshift.io/v1
+  kind: ImageTagMirrorSet
+  metadata:
+    name: quay
+  spec:
+    imageTagMirrors:
+    - source: quay.io/org
+      mirrors:
+      - mirror.example.com/quay
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ignored
+`), 0o644)).To(Succeed())
+		})
+		It("should read each kind of resource", func() {
+			mirrors, err := LoadFile(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mirrors).To(Equal([]Mirror{
+				{Source: "registry.redhat.io/rhel9", Mirrors: []string{"mirror.example.com/rhel9"}, References: DigestReference, NeverContactSource: true},
+				{Source: "docker.io/library", Mirrors: []string{"mirror.example.com/hub"}, References: DigestReference},
+				{Source: "quay.io/org", Mirrors: []string{"mirror.example.com/quay"}, References: TagReference},
+			}))
+		})
+		It("should return an error if the file does not exist", func() {
+			_, err := LoadFile(filepath.Join(filepath.Dir(path), "missing.yaml"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("When resolving the candidates for an image", func() {
+		mirrors := []Mirror{
+			{Source: "registry.redhat.io/rhel9", Mirrors: []string{"mirror.example.com/rhel9"}, References: DigestReference, NeverContactSource: true},
+			{Source: "*.redhat.io", Mirrors: []string{"mirror.example.com/redhat"}, References: DigestReference},
+			{Source: "docker.io/library", Mirrors: []string{"mirror.example.com/hub"}},
+			{Source: "quay.io/org", Mirrors: []string{"mirror.example.com/quay"}, References: TagReference},
+		}
+
+		DescribeTable("should try the mirrors of the most specific source first",
+			func(image string, expected []string) {
+				candidates, err := Candidates(image, mirrors)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(candidates).To(Equal(expected))
+			},
+			Entry("a repository that never contacts its source", "registry.redhat.io/rhel9/ubi"+digest,
+				[]string{"mirror.example.com/rhel9/ubi" + digest}),
+			Entry("a wildcard registry", "registry.redhat.io/product/repo"+digest,
+				[]string{"mirror.example.com/redhat/product/repo" + digest, "registry.redhat.io/product/repo" + digest}),
+			Entry("a digest mirror for a tag", "registry.redhat.io/product/repo:v1",
+				[]string{"registry.redhat.io/product/repo:v1"}),
+			Entry("a Docker Hub image", "busybox:latest",
+				[]string{"mirror.example.com/hub/busybox:latest", "busybox:latest"}),
+			Entry("a tag mirror for a digest", "quay.io/org/repo"+digest,
+				[]string{"quay.io/org/repo" + digest}),
+			Entry("an image without a mirror", "example.com/repo:v1",
+				[]string{"example.com/repo:v1"}),
+		)
+	})
+})
@@ -0,0 +1,80 @@
+package ocm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// challengingClientID is the OAuth client of OpenShift that issues tokens for basic
+// authentication challenges, as oc login does.
+const challengingClientID = "openshift-challenging-client"
+
+// oauthMetadata is the OAuth authorization server metadata of a cluster.
+type oauthMetadata struct {
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+}
+
+// Login returns an access token for the user with username and password, from the
+// OAuth server of the cluster whose API server is at apiURL. Identity providers that
+// were just added to a cluster take a few minutes to be rolled out, and the login fails
+// until they are.
+func Login(ctx context.Context, client *http.Client, apiURL, username, password string) (string, error) {
+	metadataReq, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(apiURL, "/")+"/.well-known/oauth-authorization-server", nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(metadataReq)
+	if err != nil {
+		return "", fmt.Errorf("could not discover the OAuth server of %s: %w", apiURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("could not discover the OAuth server of %s: status code %d", apiURL, resp.StatusCode)
+	}
+	var metadata oauthMetadata
+	if err := json.NewDecoder(resp.Body).Decode(&metadata); err != nil || metadata.AuthorizationEndpoint == "" {
+		return "", fmt.Errorf("could not discover the OAuth server of %s: invalid metadata", apiURL)
+	}
+
+	authorizeURL, err := url.Parse(metadata.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid OAuth authorization endpoint %s: %w", metadata.AuthorizationEndpoint, err)
+	}
+	authorizeURL.RawQuery = url.Values{"client_id": {challengingClientID}, "response_type": {"token"}}.Encode()
+
+	authorizeReq, err := http.NewRequestWithContext(ctx, http.MethodGet, authorizeURL.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	authorizeReq.SetBasicAuth(username, password)
+	authorizeReq.Header.Set("X-CSRF-Token", "1")
+
+	// The token is in the fragment of the URL the server redirects to.
+	noRedirect := *client
+	noRedirect.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	authorizeResp, err := noRedirect.Do(authorizeReq)
+	if err != nil {
+		return "", fmt.Errorf("could not log in to %s: %w", apiURL, err)
+	}
+	defer authorizeResp.Body.Close()
+	if authorizeResp.StatusCode != http.StatusFound {
+		return "", fmt.Errorf("could not log in to %s as %s: status code %d", apiURL, username, authorizeResp.StatusCode)
+	}
+
+	location, err := url.Parse(authorizeResp.Header.Get("Location"))
+	if err != nil {
+		return "", fmt.Errorf("could not log in to %s: invalid redirect: %w", apiURL, err)
+	}
+	fragment, err := url.ParseQuery(location.Fragment)
+	if err != nil || fragment.Get("access_token") == "" {
+		return "", fmt.Errorf("could not log in to %s: the redirect has no access token", apiURL)
+	}
+
+	return fragment.Get("access_token"), nil
+}
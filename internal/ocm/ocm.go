@@ -0,0 +1,383 @@
+// Package ocm provisions managed OpenShift clusters, ROSA and OSD, with the OpenShift
+// Cluster Manager API, authenticating with an OCM offline token.
+package ocm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/log"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/oidc"
+)
+
+const (
+	// DefaultURL is the URL of the OpenShift Cluster Manager API.
+	DefaultURL = "https://api.openshift.com"
+	// DefaultTokenURL is the token endpoint that offline tokens are exchanged with.
+	DefaultTokenURL = oidc.DefaultTokenURL
+	// DefaultClientID is the client that OCM offline tokens are issued to.
+	DefaultClientID = "cloud-services"
+)
+
+// Products of managed OpenShift clusters.
+const (
+	ProductROSA = "rosa"
+	ProductOSD  = "osd"
+)
+
+// Cluster states, as reported by OCM.
+const (
+	StateReady = "ready"
+	StateError = "error"
+)
+
+// expiryMargin is how long before it expires that a new access token is requested, so
+// that it does not expire while a request is in flight.
+const expiryMargin = 30 * time.Second
+
+// clustersPath is the path of the clusters collection of the API.
+const clustersPath = "/api/clusters_mgmt/v1/clusters"
+
+// HTTPClient sends requests to OCM and the token endpoint.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Client is a client of the OpenShift Cluster Manager API. It is safe for concurrent use.
+type Client struct {
+	url          string
+	tokenURL     string
+	offlineToken string
+	client       HTTPClient
+	now          func() time.Time
+
+	mu          sync.Mutex
+	accessToken string
+	expiry      time.Time
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithURL sends requests to the OCM API at u instead of DefaultURL.
+func WithURL(u string) Option {
+	return func(c *Client) {
+		c.url = strings.TrimSuffix(u, "/")
+	}
+}
+
+// WithTokenURL exchanges the offline token with the token endpoint at u instead of
+// DefaultTokenURL.
+func WithTokenURL(u string) Option {
+	return func(c *Client) {
+		c.tokenURL = u
+	}
+}
+
+// NewClient returns a Client authenticating with offlineToken, which sends requests
+// with httpClient.
+func NewClient(offlineToken string, httpClient HTTPClient, opts ...Option) *Client {
+	c := &Client{
+		url:          DefaultURL,
+		tokenURL:     DefaultTokenURL,
+		offlineToken: offlineToken,
+		client:       httpClient,
+		now:          time.Now,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// AWSAccount is the AWS account of customer cloud subscription clusters, which are
+// provisioned in the account of the customer.
+type AWSAccount struct {
+	AccountID       string
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// ClusterSpec specifies a cluster to provision.
+type ClusterSpec struct {
+	Name string
+	// Product is ProductROSA or ProductOSD.
+	Product string
+	Region  string
+	// Version is the OpenShift version, e.g. 4.14.8. The default version of OCM is
+	// used if it is empty.
+	Version      string
+	ComputeNodes int
+	// Expiration is when OCM deletes the cluster, if it was not deleted before.
+	Expiration time.Time
+	// AWS is the account the cluster is provisioned in. It is required for ROSA.
+	AWS *AWSAccount
+}
+
+// Validate returns an error if spec cannot be provisioned.
+func (spec ClusterSpec) Validate() error {
+	switch {
+	case spec.Name == "":
+		return errors.New("a cluster name is required")
+	case spec.Product != ProductROSA && spec.Product != ProductOSD:
+		return fmt.Errorf("unknown product %q: must be %s or %s", spec.Product, ProductROSA, ProductOSD)
+	case spec.Region == "":
+		return errors.New("a region is required")
+	case spec.Product == ProductROSA && spec.AWS == nil:
+		return errors.New("an AWS account is required to provision a ROSA cluster")
+	}
+
+	return nil
+}
+
+// Cluster is a cluster managed by OCM.
+type Cluster struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	State string `json:"state"`
+	API   struct {
+		URL string `json:"url"`
+	} `json:"api"`
+}
+
+// idRef is a reference to an OCM resource by its id.
+type idRef struct {
+	ID string `json:"id"`
+}
+
+// clusterRequest is the body of the request to create a cluster.
+type clusterRequest struct {
+	Kind                string     `json:"kind"`
+	Name                string     `json:"name"`
+	Product             idRef      `json:"product"`
+	CloudProvider       idRef      `json:"cloud_provider"`
+	Region              idRef      `json:"region"`
+	Version             *idRef     `json:"version,omitempty"`
+	Nodes               *nodes     `json:"nodes,omitempty"`
+	MultiAZ             bool       `json:"multi_az"`
+	ExpirationTimestamp string     `json:"expiration_timestamp,omitempty"`
+	CCS                 *ccs       `json:"ccs,omitempty"`
+	AWS                 *awsConfig `json:"aws,omitempty"`
+}
+
+type nodes struct {
+	Compute int `json:"compute"`
+}
+
+type ccs struct {
+	Enabled bool `json:"enabled"`
+}
+
+type awsConfig struct {
+	AccountID       string `json:"account_id"`
+	AccessKeyID     string `json:"access_key_id"`
+	SecretAccessKey string `json:"secret_access_key"`
+}
+
+// CreateCluster starts provisioning the cluster specified by spec, and returns it. The
+// cluster is ready once its state is StateReady.
+func (c *Client) CreateCluster(ctx context.Context, spec ClusterSpec) (Cluster, error) {
+	if err := spec.Validate(); err != nil {
+		return Cluster{}, fmt.Errorf("invalid cluster: %w", err)
+	}
+
+	body := clusterRequest{
+		Kind:          "Cluster",
+		Name:          spec.Name,
+		Product:       idRef{ID: spec.Product},
+		CloudProvider: idRef{ID: "aws"},
+		Region:        idRef{ID: spec.Region},
+	}
+	if spec.Version != "" {
+		body.Version = &idRef{ID: "openshift-v" + strings.TrimPrefix(spec.Version, "openshift-v")}
+	}
+	if spec.ComputeNodes > 0 {
+		body.Nodes = &nodes{Compute: spec.ComputeNodes}
+	}
+	if !spec.Expiration.IsZero() {
+		body.ExpirationTimestamp = spec.Expiration.UTC().Format(time.RFC3339)
+	}
+	if spec.AWS != nil {
+		body.CCS = &ccs{Enabled: true}
+		body.AWS = &awsConfig{AccountID: spec.AWS.AccountID, AccessKeyID: spec.AWS.AccessKeyID, SecretAccessKey: spec.AWS.SecretAccessKey}
+	}
+
+	var cluster Cluster
+	if err := c.do(ctx, http.MethodPost, clustersPath, body, &cluster); err != nil {
+		return Cluster{}, fmt.Errorf("could not create cluster %s: %w", spec.Name, err)
+	}
+
+	return cluster, nil
+}
+
+// GetCluster returns the cluster with id.
+func (c *Client) GetCluster(ctx context.Context, id string) (Cluster, error) {
+	var cluster Cluster
+	if err := c.do(ctx, http.MethodGet, clustersPath+"/"+url.PathEscape(id), nil, &cluster); err != nil {
+		return Cluster{}, fmt.Errorf("could not get cluster %s: %w", id, err)
+	}
+
+	return cluster, nil
+}
+
+// DeleteCluster deprovisions the cluster with id.
+func (c *Client) DeleteCluster(ctx context.Context, id string) error {
+	if err := c.do(ctx, http.MethodDelete, clustersPath+"/"+url.PathEscape(id), nil, nil); err != nil {
+		return fmt.Errorf("could not delete cluster %s: %w", id, err)
+	}
+
+	return nil
+}
+
+// AddClusterAdmin adds an htpasswd identity provider with a user named username, with
+// password, to the cluster with id, and makes the user a cluster admin.
+func (c *Client) AddClusterAdmin(ctx context.Context, id, username, password string) error {
+	idp := map[string]interface{}{
+		"kind": "IdentityProvider",
+		"name": "preflight",
+		"type": "HTPasswdIdentityProvider",
+		"htpasswd": map[string]interface{}{
+			"users": map[string]interface{}{
+				"items": []map[string]string{{"username": username, "password": password}},
+			},
+		},
+	}
+	clusterPath := clustersPath + "/" + url.PathEscape(id)
+	if err := c.do(ctx, http.MethodPost, clusterPath+"/identity_providers", idp, nil); err != nil {
+		return fmt.Errorf("could not add an identity provider to cluster %s: %w", id, err)
+	}
+	if err := c.do(ctx, http.MethodPost, clusterPath+"/groups/cluster-admins/users", idRef{ID: username}, nil); err != nil {
+		return fmt.Errorf("could not make %s a cluster admin of cluster %s: %w", username, id, err)
+	}
+
+	return nil
+}
+
+// apiError is the body of the responses of the OCM API to failed requests.
+type apiError struct {
+	Code   string `json:"code"`
+	Reason string `json:"reason"`
+}
+
+// do sends a request with the JSON encoding of body, if it is not nil, to path, and
+// decodes the JSON it responds with into v, if v is not nil.
+func (c *Client) do(ctx context.Context, method, path string, body, v interface{}) error {
+	token, err := c.token(ctx)
+	if err != nil {
+		return err
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("could not marshal request: %w", err)
+		}
+		reqBody = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.url+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("could not read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var e apiError
+		if err := json.Unmarshal(respBody, &e); err == nil && e.Reason != "" {
+			return fmt.Errorf("status code %d: %s: %s", resp.StatusCode, e.Code, e.Reason)
+		}
+		return fmt.Errorf("status code %d", resp.StatusCode)
+	}
+	if v == nil {
+		return nil
+	}
+
+	if err := json.Unmarshal(respBody, v); err != nil {
+		return fmt.Errorf("could not parse response: %w", err)
+	}
+	return nil
+}
+
+// tokenResponse is the response of the token endpoint.
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// token returns an access token for the offline token, requesting a new one if there
+// is none, or the last one is about to expire.
+func (c *Client) token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.accessToken != "" && c.now().Add(expiryMargin).Before(c.expiry) {
+		return c.accessToken, nil
+	}
+
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"client_id":     {DefaultClientID},
+		"refresh_token": {c.offlineToken},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("could not request an OCM access token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var tr tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tr); err != nil && resp.StatusCode == http.StatusOK {
+		return "", fmt.Errorf("could not parse token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if tr.Error != "" {
+			return "", fmt.Errorf("could not request an OCM access token: %s: %s", tr.Error, tr.ErrorDescription)
+		}
+		return "", fmt.Errorf("could not request an OCM access token: status code: %d", resp.StatusCode)
+	}
+	if tr.AccessToken == "" {
+		return "", errors.New("the token response does not contain an access token")
+	}
+
+	c.accessToken = tr.AccessToken
+	c.expiry = c.now().Add(time.Duration(tr.ExpiresIn) * time.Second)
+	logr.FromContextOrDiscard(ctx).V(log.DBG).Info("requested an OCM access token", "tokenURL", c.tokenURL, "expiry", c.expiry)
+
+	return c.accessToken, nil
+}
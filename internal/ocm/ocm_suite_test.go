@@ -0,0 +1,13 @@
+package ocm
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestOCM(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "OCM Suite")
+}
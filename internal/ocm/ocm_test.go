@@ -0,0 +1,189 @@
+package ocm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OCM", func() {
+	var (
+		server        *httptest.Server
+		client        *Client
+		tokenRequests int
+		requests      []string
+		bodies        []map[string]interface{}
+	)
+
+	BeforeEach(func() {
+		tokenRequests = 0
+		requests = nil
+		bodies = nil
+		mux := http.NewServeMux()
+		mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.ParseForm()).To(Succeed())
+			if r.Form.Get("refresh_token") != "offlinetoken" || r.Form.Get("grant_type") != "refresh_token" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error": "invalid_grant", "error_description": "Invalid refresh token"}`))
+				return
+			}
+			tokenRequests++
+			_, _ = w.Write([]byte(`{"access_token": "accesstoken", "expires_in": 900}`))
+		})
+		mux.HandleFunc("/api/clusters_mgmt/v1/", func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer accesstoken" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			requests = append(requests, r.Method+" "+r.URL.Path)
+			if r.Body != nil && r.Method == http.MethodPost {
+				var body map[string]interface{}
+				Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+				bodies = append(bodies, body)
+			}
+			switch {
+			case r.URL.Path == "/api/clusters_mgmt/v1/clusters/missing":
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"kind": "Error", "code": "CLUSTERS-MGMT-404", "reason": "Cluster 'missing' not found"}`))
+			case r.Method == http.MethodPost && r.URL.Path == "/api/clusters_mgmt/v1/clusters":
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte(`{"id": "abc123", "name": "pflt-1234", "state": "pending"}`))
+			case r.Method == http.MethodGet:
+				_, _ = w.Write([]byte(`{"id": "abc123", "name": "pflt-1234", "state": "ready", "api": {"url": "https://api.pflt-1234.example.com:6443"}}`))
+			default:
+				w.WriteHeader(http.StatusNoContent)
+			}
+		})
+		server = httptest.NewServer(mux)
+		DeferCleanup(server.Close)
+
+		client = NewClient("offlinetoken", server.Client(), WithURL(server.URL+"/"), WithTokenURL(server.URL+"/token"))
+	})
+
+	Context("when provisioning clusters", func() {
+		It("should create a cluster", func() {
+			expiration := time.Date(2024, 6, 1, 16, 0, 0, 0, time.UTC)
+			cluster, err := client.CreateCluster(context.TODO(), ClusterSpec{
+				Name:       "pflt-1234",
+				Product:    ProductOSD,
+				Region:     "us-east-1",
+				Version:    "4.14.8",
+				Expiration: expiration,
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cluster.ID).To(Equal("abc123"))
+			Expect(requests).To(Equal([]string{"POST /api/clusters_mgmt/v1/clusters"}))
+			Expect(bodies[0]).To(HaveKeyWithValue("name", "pflt-1234"))
+			Expect(bodies[0]).To(HaveKeyWithValue("product", map[string]interface{}{"id": "osd"}))
+			Expect(bodies[0]).To(HaveKeyWithValue("region", map[string]interface{}{"id": "us-east-1"}))
+			Expect(bodies[0]).To(HaveKeyWithValue("version", map[string]interface{}{"id": "openshift-v4.14.8"}))
+			Expect(bodies[0]).To(HaveKeyWithValue("expiration_timestamp", "2024-06-01T16:00:00Z"))
+			Expect(bodies[0]).ToNot(HaveKey("aws"))
+		})
+		It("should create a cluster in an AWS account", func() {
+			_, err := client.CreateCluster(context.TODO(), ClusterSpec{
+				Name:    "pflt-1234",
+				Product: ProductROSA,
+				Region:  "us-east-1",
+				AWS:     &AWSAccount{AccountID: "123456789012", AccessKeyID: "AKIA", SecretAccessKey: "secret"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(bodies[0]).To(HaveKeyWithValue("ccs", map[string]interface{}{"enabled": true}))
+			Expect(bodies[0]).To(HaveKeyWithValue("aws", map[string]interface{}{
+				"account_id":        "123456789012",
+				"access_key_id":     "AKIA",
+				"secret_access_key": "secret",
+			}))
+		})
+		It("should not create a ROSA cluster without an AWS account", func() {
+			_, err := client.CreateCluster(context.TODO(), ClusterSpec{Name: "pflt-1234", Product: ProductROSA, Region: "us-east-1"})
+			Expect(err).To(MatchError(ContainSubstring("an AWS account is required")))
+			Expect(requests).To(BeEmpty())
+		})
+		It("should get a cluster", func() {
+			cluster, err := client.GetCluster(context.TODO(), "abc123")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cluster.State).To(Equal(StateReady))
+			Expect(cluster.API.URL).To(Equal("https://api.pflt-1234.example.com:6443"))
+		})
+		It("should throw an error with the reason of the API", func() {
+			_, err := client.GetCluster(context.TODO(), "missing")
+			Expect(err).To(MatchError(ContainSubstring("status code 404: CLUSTERS-MGMT-404: Cluster 'missing' not found")))
+		})
+		It("should add a cluster admin", func() {
+			Expect(client.AddClusterAdmin(context.TODO(), "abc123", "preflight-admin", "Pf-password")).To(Succeed())
+			Expect(requests).To(Equal([]string{
+				"POST /api/clusters_mgmt/v1/clusters/abc123/identity_providers",
+				"POST /api/clusters_mgmt/v1/clusters/abc123/groups/cluster-admins/users",
+			}))
+			Expect(bodies[0]).To(HaveKeyWithValue("type", "HTPasswdIdentityProvider"))
+			Expect(bodies[1]).To(Equal(map[string]interface{}{"id": "preflight-admin"}))
+		})
+		It("should delete a cluster", func() {
+			Expect(client.DeleteCluster(context.TODO(), "abc123")).To(Succeed())
+			Expect(requests).To(Equal([]string{"DELETE /api/clusters_mgmt/v1/clusters/abc123"}))
+		})
+	})
+
+	Context("when authenticating", func() {
+		It("should reuse the access token until it is about to expire", func() {
+			now := time.Now()
+			client.now = func() time.Time { return now }
+			_, err := client.GetCluster(context.TODO(), "abc123")
+			Expect(err).ToNot(HaveOccurred())
+			_, err = client.GetCluster(context.TODO(), "abc123")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tokenRequests).To(Equal(1))
+
+			now = now.Add(15 * time.Minute)
+			_, err = client.GetCluster(context.TODO(), "abc123")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tokenRequests).To(Equal(2))
+		})
+		It("should throw an error if the offline token is invalid", func() {
+			client = NewClient("expired", server.Client(), WithURL(server.URL), WithTokenURL(server.URL+"/token"))
+			_, err := client.GetCluster(context.TODO(), "abc123")
+			Expect(err).To(MatchError(ContainSubstring("invalid_grant: Invalid refresh token")))
+		})
+	})
+
+	Context("when logging in to a cluster", func() {
+		var (
+			oauth    *httptest.Server
+			attempts int
+		)
+		BeforeEach(func() {
+			attempts = 0
+			mux := http.NewServeMux()
+			mux.HandleFunc("/.well-known/oauth-authorization-server", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"authorization_endpoint": "` + oauth.URL + `/oauth/authorize"}`))
+			})
+			mux.HandleFunc("/oauth/authorize", func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				username, password, _ := r.BasicAuth()
+				if username != "preflight-admin" || password != "Pf-password" || r.URL.Query().Get("client_id") != "openshift-challenging-client" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				http.Redirect(w, r, oauth.URL+"/oauth/token/implicit#access_token=sha256~token&expires_in=86400&token_type=Bearer", http.StatusFound)
+			})
+			oauth = httptest.NewServer(mux)
+			DeferCleanup(oauth.Close)
+		})
+		It("should return the access token of the user", func() {
+			token, err := Login(context.TODO(), oauth.Client(), oauth.URL, "preflight-admin", "Pf-password")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(token).To(Equal("sha256~token"))
+			Expect(attempts).To(Equal(1))
+		})
+		It("should throw an error if the user cannot log in", func() {
+			_, err := Login(context.TODO(), oauth.Client(), oauth.URL, "preflight-admin", "wrong")
+			Expect(err).To(MatchError(ContainSubstring("status code 401")))
+		})
+	})
+})
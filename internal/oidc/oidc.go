@@ -0,0 +1,222 @@
+// Package oidc exchanges short-lived OIDC tokens, e.g. the ID tokens issued to CI jobs or
+// projected service account tokens, for access tokens that authenticate with Pyxis, so
+// that long-lived API tokens do not have to be stored.
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/log"
+)
+
+// DefaultTokenURL is the token endpoint of Red Hat SSO, which OIDC tokens are exchanged
+// with by default.
+const DefaultTokenURL = "https://sso.redhat.com/auth/realms/redhat-external/protocol/openid-connect/token"
+
+// The parameters of an OAuth 2.0 Token Exchange request, as in RFC 8693.
+const (
+	grantTypeTokenExchange = "urn:ietf:params:oauth:grant-type:token-exchange"
+	tokenTypeJWT           = "urn:ietf:params:oauth:token-type:jwt"
+	tokenTypeAccessToken   = "urn:ietf:params:oauth:token-type:access_token"
+)
+
+// expiryMargin is how long before it expires that an access token is exchanged for a new
+// one, so that it does not expire while a request is in flight.
+const expiryMargin = 30 * time.Second
+
+// Config configures which OIDC token is exchanged, and with which token endpoint.
+type Config struct {
+	// TokenURL is the token endpoint that the OIDC token is exchanged with. Defaults to
+	// DefaultTokenURL.
+	TokenURL string
+	// ClientID is the client that the token endpoint issues access tokens to.
+	ClientID string
+	// Token is the OIDC token.
+	Token string
+	// TokenFile is a file containing the OIDC token, used instead of Token. It is read
+	// again whenever the token is exchanged, so that tokens that are rotated, such as
+	// projected service account tokens, are not used after they expire.
+	TokenFile string
+}
+
+// IsSet returns true if c configures an OIDC token.
+func (c Config) IsSet() bool {
+	return c.Token != "" || c.TokenFile != ""
+}
+
+// withDefaults returns c with the defaults applied.
+func (c Config) withDefaults() Config {
+	if c.TokenURL == "" {
+		c.TokenURL = DefaultTokenURL
+	}
+
+	return c
+}
+
+// Validate returns an error if c cannot be used to exchange an OIDC token.
+func (c Config) Validate() error {
+	switch {
+	case c.Token != "" && c.TokenFile != "":
+		return errors.New("only one of an OIDC token and an OIDC token file can be set")
+	case !c.IsSet():
+		return errors.New("an OIDC token or OIDC token file is required")
+	case c.ClientID == "":
+		return errors.New("an OIDC client id is required")
+	}
+
+	u, err := url.Parse(c.withDefaults().TokenURL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("the OIDC token url %s must be an https URL", c.TokenURL)
+	}
+
+	return nil
+}
+
+// HTTPClient sends requests to the token endpoint.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// TokenSource returns access tokens exchanged for an OIDC token, exchanging it again
+// before the access token expires. It is safe for concurrent use.
+type TokenSource struct {
+	cfg    Config
+	client HTTPClient
+	now    func() time.Time
+
+	mu          sync.Mutex
+	accessToken string
+	expiry      time.Time
+}
+
+// NewTokenSource returns a TokenSource exchanging the OIDC token configured by cfg, with
+// httpClient.
+func NewTokenSource(cfg Config, httpClient HTTPClient) (*TokenSource, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &TokenSource{cfg: cfg.withDefaults(), client: httpClient, now: time.Now}, nil
+}
+
+// AccessToken returns an access token, exchanging the OIDC token for one if there is
+// none, or the last one is about to expire.
+func (s *TokenSource) AccessToken(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.accessToken != "" && s.now().Add(expiryMargin).Before(s.expiry) {
+		return s.accessToken, nil
+	}
+
+	subjectToken, err := s.subjectToken()
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := s.exchange(ctx, subjectToken)
+	if err != nil {
+		return "", err
+	}
+
+	s.accessToken = resp.AccessToken
+	s.expiry = s.now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+
+	logr.FromContextOrDiscard(ctx).V(log.DBG).Info("exchanged OIDC token for an access token", "tokenURL", s.cfg.TokenURL, "expiry", s.expiry)
+
+	return s.accessToken, nil
+}
+
+// subjectToken returns the OIDC token.
+func (s *TokenSource) subjectToken() (string, error) {
+	if s.cfg.TokenFile == "" {
+		return s.cfg.Token, nil
+	}
+
+	b, err := os.ReadFile(s.cfg.TokenFile)
+	if err != nil {
+		return "", fmt.Errorf("could not read OIDC token: %w", err)
+	}
+
+	token := strings.TrimSpace(string(b))
+	if token == "" {
+		return "", fmt.Errorf("OIDC token file %s is empty", s.cfg.TokenFile)
+	}
+
+	return token, nil
+}
+
+// tokenResponse is the response of the token endpoint to a successful exchange.
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// errorResponse is the response of the token endpoint to a failed exchange.
+type errorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// exchange exchanges subjectToken for an access token.
+func (s *TokenSource) exchange(ctx context.Context, subjectToken string) (tokenResponse, error) {
+	form := url.Values{
+		"grant_type":           {grantTypeTokenExchange},
+		"client_id":            {s.cfg.ClientID},
+		"subject_token":        {subjectToken},
+		"subject_token_type":   {tokenTypeJWT},
+		"requested_token_type": {tokenTypeAccessToken},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return tokenResponse{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return tokenResponse{}, fmt.Errorf("could not exchange OIDC token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return tokenResponse{}, fmt.Errorf("could not read token response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var e errorResponse
+		if err := json.Unmarshal(body, &e); err == nil && e.Error != "" {
+			return tokenResponse{}, fmt.Errorf("could not exchange OIDC token: %s: %s", e.Error, e.ErrorDescription)
+		}
+		return tokenResponse{}, fmt.Errorf("could not exchange OIDC token: status code: %d", resp.StatusCode)
+	}
+
+	var tr tokenResponse
+	if err := json.Unmarshal(body, &tr); err != nil {
+		return tokenResponse{}, fmt.Errorf("could not parse token response: %w", err)
+	}
+	if tr.AccessToken == "" {
+		return tokenResponse{}, errors.New("the token response does not contain an access token")
+	}
+	if tr.TokenType != "" && !strings.EqualFold(tr.TokenType, "bearer") {
+		return tokenResponse{}, fmt.Errorf("the token response contains an unsupported token type %s", tr.TokenType)
+	}
+
+	return tr, nil
+}
@@ -0,0 +1,128 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeTokenEndpoint exchanges the OIDC token "oidc-token" for client "preflight", and
+// counts the exchanges.
+func fakeTokenEndpoint(exchanges *int) *httptest.Server {
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		if r.PostForm.Get("grant_type") != grantTypeTokenExchange ||
+			r.PostForm.Get("subject_token_type") != tokenTypeJWT ||
+			r.PostForm.Get("client_id") != "preflight" ||
+			r.PostForm.Get("subject_token") != "oidc-token" {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(errorResponse{Error: "invalid_grant", ErrorDescription: "invalid subject token"})
+			return
+		}
+
+		*exchanges++
+		_ = json.NewEncoder(w).Encode(tokenResponse{AccessToken: "access-token", TokenType: "Bearer", ExpiresIn: 300})
+	}))
+}
+
+var _ = Describe("OIDC token exchange", func() {
+	var (
+		server    *httptest.Server
+		exchanges int
+		cfg       Config
+	)
+
+	BeforeEach(func() {
+		exchanges = 0
+		server = fakeTokenEndpoint(&exchanges)
+		DeferCleanup(server.Close)
+
+		cfg = Config{TokenURL: server.URL, ClientID: "preflight", Token: "oidc-token"}
+	})
+
+	Context("Validating the configuration", func() {
+		It("should require a token", func() {
+			Expect(Config{ClientID: "preflight"}.Validate()).ToNot(Succeed())
+		})
+
+		It("should not allow both a token and a token file", func() {
+			cfg.TokenFile = "token"
+			Expect(cfg.Validate()).ToNot(Succeed())
+		})
+
+		It("should require a client id", func() {
+			cfg.ClientID = ""
+			Expect(cfg.Validate()).ToNot(Succeed())
+		})
+
+		It("should require an https token url", func() {
+			cfg.TokenURL = "http://sso.example.com/token"
+			Expect(cfg.Validate()).ToNot(Succeed())
+		})
+
+		It("should default the token url", func() {
+			cfg.TokenURL = ""
+			Expect(cfg.Validate()).To(Succeed())
+			Expect(cfg.withDefaults().TokenURL).To(Equal(DefaultTokenURL))
+		})
+	})
+
+	Context("Exchanging a token", func() {
+		It("should return the access token", func() {
+			source, err := NewTokenSource(cfg, server.Client())
+			Expect(err).ToNot(HaveOccurred())
+
+			token, err := source.AccessToken(context.TODO())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(token).To(Equal("access-token"))
+		})
+
+		It("should read the token from a file", func() {
+			cfg.Token = ""
+			cfg.TokenFile = filepath.Join(GinkgoT().TempDir(), "token")
+			Expect(os.WriteFile(cfg.TokenFile, []byte("oidc-token\n"), 0o600)).To(Succeed())
+
+			source, err := NewTokenSource(cfg, server.Client())
+			Expect(err).ToNot(HaveOccurred())
+
+			token, err := source.AccessToken(context.TODO())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(token).To(Equal("access-token"))
+		})
+
+		It("should reuse the access token until it is about to expire", func() {
+			source, err := NewTokenSource(cfg, server.Client())
+			Expect(err).ToNot(HaveOccurred())
+
+			now := time.Now()
+			source.now = func() time.Time { return now }
+
+			_, err = source.AccessToken(context.TODO())
+			Expect(err).ToNot(HaveOccurred())
+			_, err = source.AccessToken(context.TODO())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exchanges).To(Equal(1))
+
+			now = now.Add(300*time.Second - expiryMargin)
+			_, err = source.AccessToken(context.TODO())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exchanges).To(Equal(2))
+		})
+
+		It("should fail with the error of the token endpoint", func() {
+			cfg.Token = "other"
+			source, err := NewTokenSource(cfg, server.Client())
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = source.AccessToken(context.TODO())
+			Expect(err).To(MatchError(ContainSubstring("invalid_grant: invalid subject token")))
+		})
+	})
+})
@@ -0,0 +1,53 @@
+package openshift
+
+import (
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// connectionErrorMessages are parts of the messages of errors caused by the connection to
+// the API server, for errors that were wrapped without %w, e.g. by operator-sdk.
+var connectionErrorMessages = []string{
+	"connection refused",
+	"connection reset by peer",
+	"broken pipe",
+	"no route to host",
+	"network is unreachable",
+	"i/o timeout",
+	"TLS handshake timeout",
+	"http2: client connection lost",
+	"unexpected EOF",
+}
+
+// IsConnectionError returns true if err was caused by losing the connection to the API
+// server of the cluster, rather than by the API server rejecting a request.
+func IsConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var netErr net.Error
+	switch {
+	case errors.As(err, &netErr),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.ECONNRESET),
+		apierrors.IsServiceUnavailable(err),
+		apierrors.IsServerTimeout(err):
+		return true
+	}
+
+	msg := err.Error()
+	for _, m := range connectionErrorMessages {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,29 @@
+package openshift
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"syscall"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var _ = Describe("Connection errors", func() {
+	DescribeTable("should tell connection errors from other errors",
+		func(err error, expected bool) {
+			Expect(IsConnectionError(err)).To(Equal(expected))
+		},
+		Entry("nil", nil, false),
+		Entry("a refused connection", &url.Error{Op: "Get", URL: "https://api.example.com:6443", Err: syscall.ECONNREFUSED}, true),
+		Entry("a wrapped reset connection", fmt.Errorf("could not create namespace: %w", syscall.ECONNRESET), true),
+		Entry("an error formatted without wrapping", fmt.Errorf("scorecard failed: %v", errors.New("read tcp 10.0.0.1:443: connection reset by peer")), true),
+		Entry("an unavailable API server", apierrors.NewServiceUnavailable("unavailable"), true),
+		Entry("a missing resource", apierrors.NewNotFound(schema.GroupResource{Resource: "namespaces"}, "preflight"), false),
+		Entry("a failed check", errors.New("operator was not installed"), false),
+	)
+})
@@ -4,8 +4,6 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/go-logr/logr"
-
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/log"
 
 	imagestreamv1 "github.com/openshift/api/image/v1"
@@ -51,7 +49,7 @@ func AddSchemes(scheme *apiruntime.Scheme) error {
 
 // CreateNamespace can return an ErrAlreadyExists
 func (oe *openshiftClient) CreateNamespace(ctx context.Context, name string) (*corev1.Namespace, error) {
-	logger := logr.FromContextOrDiscard(ctx)
+	logger := log.FromContext(ctx, log.ComponentOLM)
 
 	logger.V(log.TRC).Info("creating namespace", "namespace", name)
 	nsSpec := corev1.Namespace{
@@ -70,7 +68,7 @@ func (oe *openshiftClient) CreateNamespace(ctx context.Context, name string) (*c
 }
 
 func (oe *openshiftClient) DeleteNamespace(ctx context.Context, name string) error {
-	logger := logr.FromContextOrDiscard(ctx)
+	logger := log.FromContext(ctx, log.ComponentOLM)
 
 	logger.V(log.TRC).Info("deleting namespace", "namespace", name)
 	nsSpec := corev1.Namespace{
@@ -88,7 +86,7 @@ func (oe *openshiftClient) DeleteNamespace(ctx context.Context, name string) err
 
 // GetNamespace can return am ErrNotFound
 func (oe *openshiftClient) GetNamespace(ctx context.Context, name string) (*corev1.Namespace, error) {
-	logger := logr.FromContextOrDiscard(ctx)
+	logger := log.FromContext(ctx, log.ComponentOLM)
 
 	logger.V(log.TRC).Info("fetching namespace", "namespace", name)
 	nsSpec := corev1.Namespace{
@@ -111,7 +109,7 @@ func (oe *openshiftClient) GetNamespace(ctx context.Context, name string) (*core
 
 // CreateOperatorGroup can return an ErrAlreadyExists
 func (oe *openshiftClient) CreateOperatorGroup(ctx context.Context, data OperatorGroupData, namespace string) (*operatorsv1.OperatorGroup, error) {
-	logger := logr.FromContextOrDiscard(ctx)
+	logger := log.FromContext(ctx, log.ComponentOLM)
 
 	logger.V(log.TRC).Info("creating OperatorGroup", "namespace", namespace, "name", data.Name)
 	operatorGroup := &operatorsv1.OperatorGroup{
@@ -135,7 +133,7 @@ func (oe *openshiftClient) CreateOperatorGroup(ctx context.Context, data Operato
 }
 
 func (oe *openshiftClient) DeleteOperatorGroup(ctx context.Context, name string, namespace string) error {
-	logger := logr.FromContextOrDiscard(ctx)
+	logger := log.FromContext(ctx, log.ComponentOLM)
 
 	logger.V(log.TRC).Info("deleting OperatorGroup", "namespace", namespace, "name", name)
 	operatorGroup := operatorsv1.OperatorGroup{
@@ -154,7 +152,7 @@ func (oe *openshiftClient) DeleteOperatorGroup(ctx context.Context, name string,
 
 // GetOperatorGroup can return an ErrNotFound
 func (oe *openshiftClient) GetOperatorGroup(ctx context.Context, name string, namespace string) (*operatorsv1.OperatorGroup, error) {
-	logger := logr.FromContextOrDiscard(ctx)
+	logger := log.FromContext(ctx, log.ComponentOLM)
 
 	logger.V(log.TRC).Info("fetching operatorgroup", "namespace", namespace, "name", name)
 	operatorGroup := operatorsv1.OperatorGroup{}
@@ -173,7 +171,7 @@ func (oe *openshiftClient) GetOperatorGroup(ctx context.Context, name string, na
 
 // CreateSecret can return an ErrAlreadyExists
 func (oe openshiftClient) CreateSecret(ctx context.Context, name string, content map[string]string, secretType corev1.SecretType, namespace string) (*corev1.Secret, error) {
-	logger := logr.FromContextOrDiscard(ctx)
+	logger := log.FromContext(ctx, log.ComponentOLM)
 
 	logger.V(log.TRC).Info("creating secret", "namespace", namespace, "name", name)
 	secret := corev1.Secret{
@@ -200,7 +198,7 @@ func (oe openshiftClient) CreateSecret(ctx context.Context, name string, content
 }
 
 func (oe openshiftClient) DeleteSecret(ctx context.Context, name string, namespace string) error {
-	logger := logr.FromContextOrDiscard(ctx)
+	logger := log.FromContext(ctx, log.ComponentOLM)
 
 	logger.V(log.TRC).Info("deleting secret", "namespace", namespace, "name", name)
 	secret := corev1.Secret{
@@ -219,7 +217,7 @@ func (oe openshiftClient) DeleteSecret(ctx context.Context, name string, namespa
 
 // GetSecret can return an ErrNotFound
 func (oe openshiftClient) GetSecret(ctx context.Context, name string, namespace string) (*corev1.Secret, error) {
-	logger := logr.FromContextOrDiscard(ctx)
+	logger := log.FromContext(ctx, log.ComponentOLM)
 
 	logger.V(log.TRC).Info("fetching secret", "namespace", namespace, "name", name)
 	secret := corev1.Secret{}
@@ -238,7 +236,7 @@ func (oe openshiftClient) GetSecret(ctx context.Context, name string, namespace
 
 // CreateCatalogSource can return an ErrAlreadyExists
 func (oe openshiftClient) CreateCatalogSource(ctx context.Context, data CatalogSourceData, namespace string) (*operatorsv1alpha1.CatalogSource, error) {
-	logger := logr.FromContextOrDiscard(ctx)
+	logger := log.FromContext(ctx, log.ComponentOLM)
 
 	logger.V(log.TRC).Info("creating CatalogSource", "namespace", namespace, "name", data.Name)
 	catalogSource := &operatorsv1alpha1.CatalogSource{
@@ -264,7 +262,7 @@ func (oe openshiftClient) CreateCatalogSource(ctx context.Context, data CatalogS
 }
 
 func (oe *openshiftClient) DeleteCatalogSource(ctx context.Context, name string, namespace string) error {
-	logger := logr.FromContextOrDiscard(ctx)
+	logger := log.FromContext(ctx, log.ComponentOLM)
 
 	logger.V(log.TRC).Info("deleting CatalogSource", "namespace", namespace, "name", name)
 	catalogSource := operatorsv1alpha1.CatalogSource{
@@ -282,7 +280,7 @@ func (oe *openshiftClient) DeleteCatalogSource(ctx context.Context, name string,
 
 // GetCatalogSource cat return an ErrNotFound
 func (oe *openshiftClient) GetCatalogSource(ctx context.Context, name string, namespace string) (*operatorsv1alpha1.CatalogSource, error) {
-	logger := logr.FromContextOrDiscard(ctx)
+	logger := log.FromContext(ctx, log.ComponentOLM)
 
 	logger.V(log.TRC).Info("fetching catalogsource", "name", name)
 	catalogSource := &operatorsv1alpha1.CatalogSource{}
@@ -301,7 +299,7 @@ func (oe *openshiftClient) GetCatalogSource(ctx context.Context, name string, na
 
 // CreateSubscription can return an ErrAlreadyExists
 func (oe openshiftClient) CreateSubscription(ctx context.Context, data SubscriptionData, namespace string) (*operatorsv1alpha1.Subscription, error) {
-	logger := logr.FromContextOrDiscard(ctx)
+	logger := log.FromContext(ctx, log.ComponentOLM)
 
 	logger.V(log.TRC).Info("creating Subscription", "namespace", namespace, "name", data.Name)
 	subscription := &operatorsv1alpha1.Subscription{
@@ -328,7 +326,7 @@ func (oe openshiftClient) CreateSubscription(ctx context.Context, data Subscript
 
 // GetSubscription can return an ErrNotFound
 func (oe *openshiftClient) GetSubscription(ctx context.Context, name string, namespace string) (*operatorsv1alpha1.Subscription, error) {
-	logger := logr.FromContextOrDiscard(ctx)
+	logger := log.FromContext(ctx, log.ComponentOLM)
 
 	logger.V(log.TRC).Info("fetching subscription", "namespace", namespace, "name", name)
 	subscription := &operatorsv1alpha1.Subscription{}
@@ -346,7 +344,7 @@ func (oe *openshiftClient) GetSubscription(ctx context.Context, name string, nam
 }
 
 func (oe openshiftClient) DeleteSubscription(ctx context.Context, name string, namespace string) error {
-	logger := logr.FromContextOrDiscard(ctx)
+	logger := log.FromContext(ctx, log.ComponentOLM)
 
 	logger.V(log.TRC).Info("deleting Subscription", "namespace", namespace, "name", name)
 
@@ -365,7 +363,7 @@ func (oe openshiftClient) DeleteSubscription(ctx context.Context, name string, n
 
 // GetCSV can return an ErrNotFound
 func (oe *openshiftClient) GetCSV(ctx context.Context, name string, namespace string) (*operatorsv1alpha1.ClusterServiceVersion, error) {
-	logger := logr.FromContextOrDiscard(ctx)
+	logger := log.FromContext(ctx, log.ComponentOLM)
 
 	logger.V(log.DBG).Info("fetching csv", "csvName", name, "namespace", namespace)
 	csv := &operatorsv1alpha1.ClusterServiceVersion{}
@@ -382,6 +380,25 @@ func (oe *openshiftClient) GetCSV(ctx context.Context, name string, namespace st
 	return csv, nil
 }
 
+// GetInstallPlan can return an ErrNotFound
+func (oe *openshiftClient) GetInstallPlan(ctx context.Context, name string, namespace string) (*operatorsv1alpha1.InstallPlan, error) {
+	logger := log.FromContext(ctx, log.ComponentOLM)
+
+	logger.V(log.TRC).Info("fetching installplan", "namespace", namespace, "name", name)
+	installPlan := &operatorsv1alpha1.InstallPlan{}
+	err := oe.Client.Get(ctx, crclient.ObjectKey{
+		Name:      name,
+		Namespace: namespace,
+	}, installPlan)
+	if apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("could not retrieve installplan: %s/%s: %w: %v", namespace, name, ErrNotFound, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve installplan: %s/%s: %v", namespace, name, err)
+	}
+	return installPlan, nil
+}
+
 func (oe *openshiftClient) GetImages(ctx context.Context) (map[string]struct{}, error) {
 	var pods corev1.PodList
 	err := oe.Client.List(ctx, &pods, &crclient.ListOptions{})
@@ -413,7 +430,7 @@ func (oe *openshiftClient) GetImages(ctx context.Context) (map[string]struct{},
 
 // CreateRoleBinding can return an ErrAlreadyExists
 func (oe *openshiftClient) CreateRoleBinding(ctx context.Context, data RoleBindingData, namespace string) (*rbacv1.RoleBinding, error) {
-	logger := logr.FromContextOrDiscard(ctx)
+	logger := log.FromContext(ctx, log.ComponentOLM)
 	logger.V(log.TRC).Info("creating RoleBinding", "name", data.Name, "namespace", namespace)
 	subjectsObj := make([]rbacv1.Subject, 0, len(data.Subjects))
 	for _, subject := range data.Subjects {
@@ -454,7 +471,7 @@ func (oe *openshiftClient) CreateRoleBinding(ctx context.Context, data RoleBindi
 
 // GetRoleBinding can return an ErrNotFound
 func (oe *openshiftClient) GetRoleBinding(ctx context.Context, name string, namespace string) (*rbacv1.RoleBinding, error) {
-	logger := logr.FromContextOrDiscard(ctx)
+	logger := log.FromContext(ctx, log.ComponentOLM)
 
 	logger.V(log.TRC).Info("fetching RoleBinding", "namespace", namespace, "name", name)
 	roleBinding := rbacv1.RoleBinding{
@@ -481,7 +498,7 @@ func (oe *openshiftClient) GetRoleBinding(ctx context.Context, name string, name
 }
 
 func (oe *openshiftClient) DeleteRoleBinding(ctx context.Context, name string, namespace string) error {
-	logger := logr.FromContextOrDiscard(ctx)
+	logger := log.FromContext(ctx, log.ComponentOLM)
 
 	logger.V(log.TRC).Info("deleting RoleBinding", "namespace", namespace, "name", name)
 
@@ -91,11 +91,18 @@ var _ = Describe("OpenShift Engine", func() {
 			},
 		}
 
+		installPlan := operatorsv1alpha1.InstallPlan{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "install-abcde",
+				Namespace: "testns",
+			},
+		}
+
 		scheme := apiruntime.NewScheme()
 		Expect(AddSchemes(scheme)).To(Succeed())
 		cl := fake.NewClientBuilder().
 			WithScheme(scheme).
-			WithObjects(&csv).
+			WithObjects(&csv, &installPlan).
 			WithLists(&pods, &isList).
 			Build()
 		oc = NewClient(cl)
@@ -346,4 +353,17 @@ var _ = Describe("OpenShift Engine", func() {
 			Expect(csv).To(BeNil())
 		})
 	})
+	Context("InstallPlans", func() {
+		It("should get an InstallPlan", func() {
+			installPlan, err := oc.GetInstallPlan(context.TODO(), "install-abcde", "testns")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(installPlan).ToNot(BeNil())
+		})
+		It("should error if InstallPlan doesn't exist", func() {
+			installPlan, err := oc.GetInstallPlan(context.TODO(), "install-bad", "badns")
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(MatchError(ErrNotFound))
+			Expect(installPlan).To(BeNil())
+		})
+	})
 })
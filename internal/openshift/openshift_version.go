@@ -7,14 +7,13 @@ import (
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/log"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/runtime"
 
-	"github.com/go-logr/logr"
 	configv1Client "github.com/openshift/client-go/config/clientset/versioned/typed/config/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
 func GetOpenshiftClusterVersion(ctx context.Context, kubeconfig []byte) (runtime.OpenshiftClusterVersion, error) {
-	logger := logr.FromContextOrDiscard(ctx)
+	logger := log.FromContext(ctx, log.ComponentOLM)
 	if len(kubeconfig) == 0 {
 		return runtime.UnknownOpenshiftClusterVersion(), fmt.Errorf("kubeconfig was not provided")
 	}
@@ -52,6 +52,7 @@ type Client interface {
 	DeleteSubscription(ctx context.Context, name string, namespace string) error
 	GetSubscription(ctx context.Context, name string, namespace string) (*operatorsv1alpha1.Subscription, error)
 	GetCSV(ctx context.Context, name string, namespace string) (*operatorsv1alpha1.ClusterServiceVersion, error)
+	GetInstallPlan(ctx context.Context, name string, namespace string) (*operatorsv1alpha1.InstallPlan, error)
 	GetImages(ctx context.Context) (map[string]struct{}, error)
 	CreateRoleBinding(ctx context.Context, data RoleBindingData, namespace string) (*rbacv1.RoleBinding, error)
 	GetRoleBinding(ctx context.Context, name string, namespace string) (*rbacv1.RoleBinding, error)
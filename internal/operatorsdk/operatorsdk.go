@@ -12,8 +12,6 @@ import (
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/artifacts"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/log"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/runtime"
-
-	"github.com/go-logr/logr"
 )
 
 func New(userProvidedScorecardImage string, cmdContext execContext) *operatorSdk {
@@ -32,7 +30,7 @@ type operatorSdk struct {
 type execContext = func(name string, arg ...string) *exec.Cmd
 
 func (o operatorSdk) Scorecard(ctx context.Context, image string, opts OperatorSdkScorecardOptions) (*OperatorSdkScorecardReport, error) {
-	logger := logr.FromContextOrDiscard(ctx)
+	logger := log.FromContext(ctx, log.ComponentScorecard)
 
 	cmdArgs := []string{"scorecard"}
 	if opts.OutputFormat == "" {
@@ -117,7 +115,7 @@ func (o operatorSdk) Scorecard(ctx context.Context, image string, opts OperatorS
 }
 
 func (o operatorSdk) BundleValidate(ctx context.Context, image string, opts OperatorSdkBundleValidateOptions) (*OperatorSdkBundleValidateReport, error) {
-	logger := logr.FromContextOrDiscard(ctx)
+	logger := log.FromContext(ctx, log.ComponentScorecard)
 
 	cmdArgs := []string{"bundle", "validate"}
 	if opts.ContainerEngine == "" {
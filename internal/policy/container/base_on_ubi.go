@@ -88,3 +88,28 @@ func (p *BasedOnUBICheck) Help() check.HelpText {
 		Suggestion: "Change the FROM directive in your Dockerfile or Containerfile to FROM registry.access.redhat.com/ubi8/ubi",
 	}
 }
+
+// Provenance returns the provenance of the results of the check: the layers of the
+// image are looked up in Pyxis.
+func (p *BasedOnUBICheck) Provenance() check.Provenance {
+	return check.Provenance{
+		DataSources: []check.DataSource{check.DataSourceManifest, check.DataSourcePyxis},
+	}
+}
+
+func (p *BasedOnUBICheck) Explain() check.Explanation {
+	return check.Explanation{
+		Criteria: []string{
+			"At least one certified image in Pyxis contains the uncompressed layers of the image, i.e. the image is built on a certified Red Hat base image, such as a UBI.",
+		},
+		CommonCauses: []string{
+			"The image is not built on a Red Hat base image, e.g. on a community distribution.",
+			"The layers of the base image were squashed together with the layers added by the build.",
+			"The base image is a version that is no longer published in Pyxis.",
+		},
+		Remediation: []string{
+			"Change the FROM directive of the Dockerfile or Containerfile to a UBI, e.g. registry.access.redhat.com/ubi9/ubi.",
+			"Do not squash the layers of the base image.",
+		},
+	}
+}
@@ -0,0 +1,240 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/authn"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/image"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/log"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/transport"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/name"
+	cranev1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+const (
+	// baseNameAnnotation and baseDigestAnnotation are the OCI annotations recording the
+	// base image an image was built from, as set by buildah.
+	baseNameAnnotation   = "org.opencontainers.image.base.name"
+	baseDigestAnnotation = "org.opencontainers.image.base.digest"
+)
+
+var _ check.Check = &BasedOnApprovedBaseImageCheck{}
+
+// ApprovedBaseImage is a base image, or repository of base images, that an
+// organization approves building images on.
+type ApprovedBaseImage struct {
+	// Repository is the repository of the base image, e.g. registry.access.redhat.com/ubi9/ubi.
+	Repository string
+	// Digest is the digest of the base image. If empty, any image in Repository is approved.
+	Digest string
+}
+
+// ParseApprovedBaseImage returns the ApprovedBaseImage described by s, which is either a
+// repository, or an image referenced by digest.
+func ParseApprovedBaseImage(s string) (ApprovedBaseImage, error) {
+	if d, err := name.NewDigest(s); err == nil {
+		return ApprovedBaseImage{Repository: d.Context().Name(), Digest: d.DigestStr()}, nil
+	}
+
+	repo, err := name.NewRepository(s)
+	if err != nil {
+		return ApprovedBaseImage{}, fmt.Errorf("invalid approved base image %q: must be a repository, or an image referenced by digest", s)
+	}
+
+	return ApprovedBaseImage{Repository: repo.Name()}, nil
+}
+
+func (a ApprovedBaseImage) String() string {
+	if a.Digest == "" {
+		return a.Repository
+	}
+	return a.Repository + "@" + a.Digest
+}
+
+// BasedOnApprovedBaseImageCheck evaluates if the image is built on one of the base images
+// approved by an organization. It is not part of the certification policy, and is only
+// executed when approved base images are configured.
+type BasedOnApprovedBaseImageCheck struct {
+	approved  []string
+	dockercfg string
+}
+
+// NewBasedOnApprovedBaseImageCheck returns a check that the image is built on one of the
+// approved base images, each either a repository or an image referenced by digest.
+func NewBasedOnApprovedBaseImageCheck(approved []string, dockercfg string) *BasedOnApprovedBaseImageCheck {
+	return &BasedOnApprovedBaseImageCheck{approved: approved, dockercfg: dockercfg}
+}
+
+func (p *BasedOnApprovedBaseImageCheck) Validate(ctx context.Context, imgRef image.ImageReference) (bool, error) {
+	logger := logr.FromContextOrDiscard(ctx)
+
+	approved := make([]ApprovedBaseImage, 0, len(p.approved))
+	for _, s := range p.approved {
+		a, err := ParseApprovedBaseImage(s)
+		if err != nil {
+			return false, err
+		}
+		approved = append(approved, a)
+	}
+
+	manifest, err := imgRef.ImageInfo.Manifest()
+	if err != nil {
+		return false, fmt.Errorf("could not get image manifest: %v", err)
+	}
+
+	// Images built by buildah record their base image.
+	if a, ok := matchBaseAnnotations(manifest.Annotations, approved); ok {
+		logger.V(log.DBG).Info("image is built on an approved base image", "base", a.String(), "source", "annotations")
+		return true, nil
+	}
+
+	configFile, err := imgRef.ImageInfo.ConfigFile()
+	if err != nil {
+		return false, fmt.Errorf("could not get image config: %v", err)
+	}
+
+	// Otherwise, an image is built on a base image referenced by digest if it starts
+	// with all of its layers.
+	for _, a := range approved {
+		if a.Digest == "" {
+			continue
+		}
+
+		baseLayers, err := p.baseLayers(ctx, a, configFile.Platform())
+		if err != nil {
+			return false, fmt.Errorf("could not get layers of approved base image %s: %w", a, err)
+		}
+
+		if hasLayerPrefix(configFile.RootFS.DiffIDs, baseLayers) {
+			logger.V(log.DBG).Info("image is built on an approved base image", "base", a.String(), "source", "layers")
+			return true, nil
+		}
+	}
+
+	logger.V(log.DBG).Info("image is not built on an approved base image", "approved", p.approved)
+	return false, nil
+}
+
+// matchBaseAnnotations returns the approved base image recorded in annotations, if any.
+func matchBaseAnnotations(annotations map[string]string, approved []ApprovedBaseImage) (ApprovedBaseImage, bool) {
+	baseName := annotations[baseNameAnnotation]
+	baseDigest := annotations[baseDigestAnnotation]
+	if baseName == "" && baseDigest == "" {
+		return ApprovedBaseImage{}, false
+	}
+
+	baseRepository := ""
+	if ref, err := name.ParseReference(baseName); err == nil {
+		baseRepository = ref.Context().Name()
+		if d, ok := ref.(name.Digest); ok && baseDigest == "" {
+			baseDigest = d.DigestStr()
+		}
+	}
+
+	for _, a := range approved {
+		switch {
+		case a.Digest != "" && a.Digest == baseDigest:
+			return a, true
+		case a.Digest == "" && baseRepository != "" && a.Repository == baseRepository:
+			return a, true
+		}
+	}
+
+	return ApprovedBaseImage{}, false
+}
+
+// baseLayers returns the uncompressed layer digests of the approved base image a, for
+// platform if it is a manifest list.
+func (p *BasedOnApprovedBaseImageCheck) baseLayers(ctx context.Context, a ApprovedBaseImage, platform *cranev1.Platform) ([]cranev1.Hash, error) {
+	options := []crane.Option{
+		crane.WithContext(ctx),
+		crane.WithAuthFromKeychain(authn.PreflightKeychain(ctx, authn.WithDockerConfig(p.dockercfg))),
+	}
+	if transport.IsConfigured(ctx) {
+		options = append(options, crane.WithTransport(transport.Transport(ctx, remote.DefaultTransport.(*http.Transport))))
+	}
+	if platform != nil && platform.Architecture != "" {
+		options = append(options, crane.WithPlatform(platform))
+	}
+
+	img, err := crane.Pull(a.String(), options...)
+	if err != nil {
+		return nil, err
+	}
+
+	configFile, err := img.ConfigFile()
+	if err != nil {
+		return nil, err
+	}
+
+	return configFile.RootFS.DiffIDs, nil
+}
+
+// hasLayerPrefix returns true if layers starts with all of prefix.
+func hasLayerPrefix(layers, prefix []cranev1.Hash) bool {
+	if len(prefix) == 0 || len(prefix) > len(layers) {
+		return false
+	}
+
+	for i := range prefix {
+		if layers[i] != prefix[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (p *BasedOnApprovedBaseImageCheck) Name() string {
+	return "BasedOnApprovedBaseImage"
+}
+
+func (p *BasedOnApprovedBaseImageCheck) Metadata() check.Metadata {
+	return check.Metadata{
+		Description: "Checking if the container is built on a base image approved by your organization",
+		Level:       "best",
+	}
+}
+
+func (p *BasedOnApprovedBaseImageCheck) Help() check.HelpText {
+	return check.HelpText{
+		Message: "Check BasedOnApprovedBaseImage encountered an error. Please review the preflight.log file for more information.",
+		Suggestion: fmt.Sprintf("Change the FROM directive in your Dockerfile or Containerfile to one of the base images approved by your organization: %s",
+			strings.Join(p.approved, ", ")),
+	}
+}
+
+// Provenance returns the provenance of the results of the check: the layers of the
+// image are compared to those of the approved base images, which are pulled from their
+// registries.
+func (p *BasedOnApprovedBaseImageCheck) Provenance() check.Provenance {
+	return check.Provenance{
+		DataSources: []check.DataSource{check.DataSourceManifest, check.DataSourceRegistry},
+	}
+}
+
+func (p *BasedOnApprovedBaseImageCheck) Explain() check.Explanation {
+	return check.Explanation{
+		Criteria: []string{
+			"The image is annotated with the name of one of the base images approved by your organization, or",
+			"the layers of the image start with the layers of one of the approved base images.",
+		},
+		CommonCauses: []string{
+			"The image is built on a base image that is not approved.",
+			"The image is built on an older version of an approved base image, whose layers differ from the version that is approved.",
+			"The layers of the base image were squashed together with the layers added by the build.",
+		},
+		Remediation: []string{
+			"Change the FROM directive of the Dockerfile or Containerfile to one of the approved base images.",
+			"Rebuild the image on the approved version of its base image.",
+		},
+	}
+}
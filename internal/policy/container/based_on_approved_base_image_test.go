@@ -0,0 +1,126 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/registry"
+	cranev1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/image"
+)
+
+var _ = Describe("BasedOnApprovedBaseImage", func() {
+	var host, baseRef string
+	var base, built cranev1.Image
+
+	BeforeEach(func() {
+		// Set up a fake registry.
+		registryLogger := log.New(io.Discard, "", log.Ldate)
+		s := httptest.NewServer(registry.New(registry.Logger(registryLogger)))
+		DeferCleanup(s.Close)
+		u, err := url.Parse(s.URL)
+		Expect(err).ToNot(HaveOccurred())
+		host = u.Host
+
+		base, err = random.Image(1024, 2)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(crane.Push(base, fmt.Sprintf("%s/approved/base:latest", host))).To(Succeed())
+		digest, err := base.Digest()
+		Expect(err).ToNot(HaveOccurred())
+		baseRef = fmt.Sprintf("%s/approved/base@%s", host, digest)
+
+		layer, err := random.Layer(1024, "application/vnd.oci.image.layer.v1.tar")
+		Expect(err).ToNot(HaveOccurred())
+		built, err = mutate.AppendLayers(base, layer)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	Describe("Parsing approved base images", func() {
+		It("should accept repositories and images referenced by digest", func() {
+			a, err := ParseApprovedBaseImage("registry.access.redhat.com/ubi9/ubi")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(a).To(Equal(ApprovedBaseImage{Repository: "registry.access.redhat.com/ubi9/ubi"}))
+
+			a, err = ParseApprovedBaseImage(baseRef)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(a.String()).To(Equal(baseRef))
+		})
+		It("should reject images referenced by tag", func() {
+			_, err := ParseApprovedBaseImage("registry.access.redhat.com/ubi9/ubi:latest")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Checking the base image", func() {
+		Context("When the image starts with the layers of an approved digest", func() {
+			It("should pass Validate", func() {
+				ok, err := NewBasedOnApprovedBaseImageCheck([]string{baseRef}, "").Validate(context.TODO(), image.ImageReference{ImageInfo: built})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ok).To(BeTrue())
+			})
+		})
+		Context("When the image does not start with the layers of an approved digest", func() {
+			It("should not pass Validate", func() {
+				other, err := random.Image(1024, 3)
+				Expect(err).ToNot(HaveOccurred())
+
+				ok, err := NewBasedOnApprovedBaseImageCheck([]string{baseRef}, "").Validate(context.TODO(), image.ImageReference{ImageInfo: other})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ok).To(BeFalse())
+			})
+		})
+		Context("When the image records an approved repository as its base", func() {
+			It("should pass Validate", func() {
+				annotated := mutate.Annotations(built, map[string]string{
+					baseNameAnnotation: "registry.access.redhat.com/ubi9/ubi:latest",
+				}).(cranev1.Image)
+
+				ok, err := NewBasedOnApprovedBaseImageCheck([]string{"registry.access.redhat.com/ubi9/ubi"}, "").Validate(context.TODO(), image.ImageReference{ImageInfo: annotated})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ok).To(BeTrue())
+			})
+		})
+		Context("When the image records a base in a repository that is not approved", func() {
+			It("should not pass Validate", func() {
+				annotated := mutate.Annotations(built, map[string]string{
+					baseNameAnnotation: "docker.io/library/alpine:latest",
+				}).(cranev1.Image)
+
+				ok, err := NewBasedOnApprovedBaseImageCheck([]string{"registry.access.redhat.com/ubi9/ubi"}, "").Validate(context.TODO(), image.ImageReference{ImageInfo: annotated})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ok).To(BeFalse())
+			})
+		})
+		Context("When the image records an approved digest as its base", func() {
+			It("should pass Validate without pulling the base image", func() {
+				annotated := mutate.Annotations(built, map[string]string{
+					baseNameAnnotation:   "registry.example.com/unreachable/base:latest",
+					baseDigestAnnotation: "sha256:0000000000000000000000000000000000000000000000000000000000000000",
+				}).(cranev1.Image)
+
+				ok, err := NewBasedOnApprovedBaseImageCheck([]string{"registry.example.com/unreachable/base@sha256:0000000000000000000000000000000000000000000000000000000000000000"}, "").
+					Validate(context.TODO(), image.ImageReference{ImageInfo: annotated})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ok).To(BeTrue())
+			})
+		})
+		Context("When an approved base image cannot be pulled", func() {
+			It("should throw an error", func() {
+				missing := fmt.Sprintf("%s/approved/missing@sha256:0000000000000000000000000000000000000000000000000000000000000000", host)
+				ok, err := NewBasedOnApprovedBaseImageCheck([]string{missing}, "").Validate(context.TODO(), image.ImageReference{ImageInfo: built})
+				Expect(err).To(HaveOccurred())
+				Expect(ok).To(BeFalse())
+			})
+		})
+	})
+})
@@ -25,11 +25,11 @@ var errLicensesNotADir = errors.New("licenses is not a directory")
 var _ check.Check = &HasLicenseCheck{}
 
 // HasLicenseCheck evaluates that the image contains a license definition available at
-// /licenses.
+// /licenses, or C:\licenses in Windows images.
 type HasLicenseCheck struct{}
 
 func (p *HasLicenseCheck) Validate(ctx context.Context, imgRef image.ImageReference) (bool, error) {
-	licenseFileList, err := p.getDataToValidate(ctx, imgRef.ImageFSPath)
+	licenseFileList, err := p.getDataToValidate(ctx, imgRef.RootPath())
 	if err != nil {
 		if errors.Is(err, fs.ErrNotExist) || errors.Is(err, errLicensesNotADir) {
 			return false, nil
@@ -95,3 +95,35 @@ func (p *HasLicenseCheck) Help() check.HelpText {
 		Suggestion: "Create a directory named /licenses and include all relevant licensing and/or terms and conditions as text file(s) in that directory.",
 	}
 }
+
+// FilesystemPaths returns the paths the check reads: the licenses of either Linux or
+// Windows images.
+func (p *HasLicenseCheck) FilesystemPaths() []string {
+	return []string{licensePath, image.WindowsPath(licensePath)}
+}
+
+// Provenance returns the provenance of the results of the check: the licenses are read
+// from the filesystem of the image.
+func (p *HasLicenseCheck) Provenance() check.Provenance {
+	return check.Provenance{
+		DataSources: []check.DataSource{check.DataSourceFilesystem},
+	}
+}
+
+func (p *HasLicenseCheck) Explain() check.Explanation {
+	return check.Explanation{
+		Criteria: []string{
+			fmt.Sprintf("%s is a directory in the filesystem of the image.", licensePath),
+			fmt.Sprintf("%s contains at least %d file that is not empty.", licensePath, minLicenseFileCount),
+			"In Windows images, the directory is C:\\licenses.",
+		},
+		CommonCauses: []string{
+			"The Dockerfile or Containerfile does not copy the licenses into the image.",
+			fmt.Sprintf("%s is a file, e.g. the license itself, rather than a directory.", licensePath),
+			"The license files are empty, e.g. placeholders created when the image is built.",
+		},
+		Remediation: []string{
+			fmt.Sprintf("Copy the terms and conditions of the image, including the licenses of the open source software it contains, into %s as text files, e.g. COPY LICENSE %s/.", licensePath, licensePath),
+		},
+	}
+}
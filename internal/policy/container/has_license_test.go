@@ -32,7 +32,7 @@ var _ = Describe("HasLicense", func() {
 			Expect(err).ToNot(HaveOccurred())
 			_, err = os.Create(filepath.Join(tmpDir, licenses, emptyLicense))
 			Expect(err).ToNot(HaveOccurred())
-			imgRef.ImageFSPath = tmpDir
+			imgRef = image.ImageReference{ImageFSPath: tmpDir}
 		})
 		Context("When license(s) are found", func() {
 			It("Should pass Validate", func() {
@@ -62,6 +62,24 @@ var _ = Describe("HasLicense", func() {
 				Expect(ok).To(BeFalse())
 			})
 		})
+		Context("When the image is a Windows image", func() {
+			JustBeforeEach(func() {
+				imgRef.ImageOS = image.OSWindows
+			})
+			It("Should not pass Validate if the licenses are not in C:\\licenses", func() {
+				ok, err := hasLicense.Validate(context.TODO(), imgRef)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ok).To(BeFalse())
+			})
+			It("Should pass Validate if the licenses are in C:\\licenses", func() {
+				Expect(os.Mkdir(filepath.Join(imgRef.ImageFSPath, "Files"), 0o755)).To(Succeed())
+				Expect(os.Rename(filepath.Join(imgRef.ImageFSPath, licenses), filepath.Join(imgRef.ImageFSPath, "Files", licenses))).To(Succeed())
+
+				ok, err := hasLicense.Validate(context.TODO(), imgRef)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ok).To(BeTrue())
+			})
+		})
 		Context("Only an empty license", func() {
 			JustBeforeEach(func() {
 				os.Remove(filepath.Join(imgRef.ImageFSPath, licenses, validLicense))
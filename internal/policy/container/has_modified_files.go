@@ -69,6 +69,16 @@ func (p *HasModifiedFilesCheck) getDataToValidate(ctx context.Context, imgRef im
 	// Uncompress each layer and build a slice containing the files
 	// modified by each layer.
 	for _, layer := range layers {
+		// Layers that cannot be extracted were not checked, and are
+		// recorded as skipped in the results.
+		reason, err := image.UnsupportedLayerReason(layer)
+		if err != nil {
+			return nil, err
+		}
+		if reason != "" {
+			continue
+		}
+
 		r, err := layer.Uncompressed()
 		if err != nil {
 			return nil, fmt.Errorf("could not extract layers: %w", err)
@@ -171,6 +181,38 @@ func (p HasModifiedFilesCheck) Help() check.HelpText {
 	}
 }
 
+// FilesystemPaths returns the paths the check reads: the RPM database. The files
+// modified by each layer are read from the layers.
+func (p HasModifiedFilesCheck) FilesystemPaths() []string {
+	return []string{rpm.DatabaseDir}
+}
+
+// Provenance returns the provenance of the results of the check: the files of each
+// layer are compared to the RPM database in the filesystem of the image.
+func (p HasModifiedFilesCheck) Provenance() check.Provenance {
+	return check.Provenance{
+		DataSources: []check.DataSource{check.DataSourceManifest, check.DataSourceFilesystem},
+	}
+}
+
+func (p HasModifiedFilesCheck) Explain() check.Explanation {
+	return check.Explanation{
+		Criteria: []string{
+			"The files installed by the packages in the RPM database of the image are read.",
+			"None of the files installed by packages in the base layer of the image is modified, or replaced, by a later layer.",
+		},
+		CommonCauses: []string{
+			"The Dockerfile or Containerfile edits configuration files provided by packages of the base image, e.g. with sed.",
+			"Files of the base image are overwritten with COPY or ADD.",
+			"Packages of the base image are updated or reinstalled by the build, rather than by updating the base image.",
+		},
+		Remediation: []string{
+			"Do not modify files installed by the packages of the base image. Configure the software with files of your own, or environment variables, instead.",
+			"Rebuild the image on the latest version of its base image, rather than updating its packages in a later layer.",
+		},
+	}
+}
+
 func (p HasModifiedFilesCheck) Metadata() check.Metadata {
 	return check.Metadata{
 		Description:      "Checks that no files installed via RPM in the base Red Hat layer have been modified",
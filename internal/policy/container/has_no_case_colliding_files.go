@@ -0,0 +1,240 @@
+package container
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/artifacts"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/image"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/rpm"
+)
+
+// CaseCollidingFilesFilename is the name of the artifact listing the groups of paths
+// that differ only by case.
+const CaseCollidingFilesFilename = "case-colliding-files.json"
+
+var _ check.Check = &HasNoCaseCollidingFilesCheck{}
+
+// HasNoCaseCollidingFilesCheck evaluates that no two paths in the filesystem of the image
+// differ only by case, e.g. /opt/app/Config and /opt/app/config. Only one of them survives
+// the extraction of the image on a case-insensitive filesystem, like the default on macOS,
+// and some tools of registries reject them. Paths installed by RPM packages are not
+// evaluated, as they are provided by the distribution, e.g. the netfilter headers of
+// kernel-headers.
+type HasNoCaseCollidingFilesCheck struct{}
+
+func (p *HasNoCaseCollidingFilesCheck) Validate(ctx context.Context, imgRef image.ImageReference) (bool, error) {
+	paths, packageFiles, err := p.getDataToValidate(ctx, imgRef)
+	if err != nil {
+		return false, fmt.Errorf("could not list the files of the image: %v", err)
+	}
+	return p.validate(ctx, paths, packageFiles)
+}
+
+// getDataToValidate returns the paths in the filesystem of the image, and the files
+// installed by its RPM packages, if any. The paths are read from the layers of the image,
+// rather than its extracted filesystem, in which colliding paths may have been merged
+// already.
+func (p *HasNoCaseCollidingFilesCheck) getDataToValidate(ctx context.Context, imgRef image.ImageReference) ([]string, map[string]struct{}, error) {
+	paths, err := imagePaths(imgRef)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	packageFiles := map[string]struct{}{}
+	pkgList, err := rpm.GetPackageList(ctx, imgRef.ImageFSPath)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		// Images without an RPM database, e.g. those built from scratch, have no
+		// files installed by packages.
+	case err != nil:
+		return nil, nil, fmt.Errorf("could not get rpm list: %w", err)
+	default:
+		if packageFiles, err = (&HasModifiedFilesCheck{}).getInstalledFilesFor(pkgList); err != nil {
+			return nil, nil, fmt.Errorf("could not list installed files: %w", err)
+		}
+	}
+
+	return paths, packageFiles, nil
+}
+
+// imagePaths returns the absolute path of every entry in the filesystem of the image,
+// from the entries recorded as it was extracted, or else from its layers.
+func imagePaths(imgRef image.ImageReference) ([]string, error) {
+	if imgRef.Files != nil {
+		paths := make([]string, 0, len(imgRef.Files))
+		for _, f := range imgRef.Files {
+			if f.Path != "/" {
+				paths = append(paths, f.Path)
+			}
+		}
+		return paths, nil
+	}
+
+	// Layers that cannot be extracted are recorded as skipped in the results.
+	supported, _, err := image.SupportedLayers(imgRef.ImageInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	rc := mutate.Extract(image.WithLayers(imgRef.ImageInfo, supported))
+	defer rc.Close()
+
+	paths, err := tarPaths(rc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tarball: %w", err)
+	}
+
+	return paths, nil
+}
+
+// tarPaths returns the absolute path of every entry in the tarball read from r.
+func tarPaths(r io.Reader) ([]string, error) {
+	tr := tar.NewReader(r)
+
+	var paths []string
+	for {
+		header, err := tr.Next()
+		switch {
+		case err == io.EOF:
+			return paths, nil
+		case err != nil:
+			return nil, err
+		}
+
+		if p := path.Clean("/" + header.Name); p != "/" {
+			paths = append(paths, p)
+		}
+	}
+}
+
+func (p *HasNoCaseCollidingFilesCheck) validate(ctx context.Context, paths []string, packageFiles map[string]struct{}) (bool, error) {
+	logger := logr.FromContextOrDiscard(ctx)
+
+	collisions := caseCollisions(paths, packageFiles)
+	if len(collisions) == 0 {
+		return true, nil
+	}
+
+	for _, collision := range collisions {
+		logger.Info("paths differ only by case", "paths", collision)
+	}
+
+	b, err := json.MarshalIndent(collisions, "", "    ")
+	if err != nil {
+		return false, fmt.Errorf("unable to marshal case colliding files to json: %w", err)
+	}
+	if artifactWriter := artifacts.WriterFromContext(ctx); artifactWriter != nil {
+		if _, err := artifactWriter.WriteFile(CaseCollidingFilesFilename, bytes.NewReader(b)); err != nil {
+			return false, fmt.Errorf("failed to write case colliding files: %w", err)
+		}
+	}
+
+	return false, nil
+}
+
+// caseCollisions returns the groups of distinct paths that differ only by case, each
+// sorted, in the order of their first path. Groups in which every path is in
+// packageFiles are not returned.
+func caseCollisions(paths []string, packageFiles map[string]struct{}) [][]string {
+	byFolded := make(map[string][]string, len(paths))
+	seen := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+		folded := strings.ToLower(p)
+		byFolded[folded] = append(byFolded[folded], p)
+	}
+
+	var collisions [][]string
+	for _, group := range byFolded {
+		if len(group) < 2 || allInstalledByPackages(group, packageFiles) {
+			continue
+		}
+		sort.Strings(group)
+		collisions = append(collisions, group)
+	}
+	sort.Slice(collisions, func(i, j int) bool {
+		return collisions[i][0] < collisions[j][0]
+	})
+
+	return collisions
+}
+
+// allInstalledByPackages returns true if every path in paths is in packageFiles.
+func allInstalledByPackages(paths []string, packageFiles map[string]struct{}) bool {
+	for _, p := range paths {
+		if _, ok := packageFiles[p]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (p *HasNoCaseCollidingFilesCheck) Name() string {
+	return "HasNoCaseCollidingFiles"
+}
+
+func (p *HasNoCaseCollidingFilesCheck) Metadata() check.Metadata {
+	return check.Metadata{
+		Description:      "Checking that no two paths in the image differ only by case, which breaks its extraction on case-insensitive filesystems.",
+		Level:            "optional",
+		KnowledgeBaseURL: certDocumentationURL,
+		CheckURL:         certDocumentationURL,
+	}
+}
+
+func (p *HasNoCaseCollidingFilesCheck) Help() check.HelpText {
+	return check.HelpText{
+		Message:    "Check HasNoCaseCollidingFiles encountered an error. Please review the preflight.log file for more information.",
+		Suggestion: "Rename or remove the files that differ only by case from other files in the same directory. The paths are listed in the " + CaseCollidingFilesFilename + " artifact.",
+	}
+}
+
+// FilesystemPaths returns the paths the check reads: the RPM database. The paths of the
+// image are read from its layers.
+func (p *HasNoCaseCollidingFilesCheck) FilesystemPaths() []string {
+	return []string{rpm.DatabaseDir}
+}
+
+// Provenance returns the provenance of the results of the check: the paths are read
+// from the layers of the image, and the files installed by packages from its RPM
+// database.
+func (p *HasNoCaseCollidingFilesCheck) Provenance() check.Provenance {
+	return check.Provenance{
+		DataSources: []check.DataSource{check.DataSourceManifest, check.DataSourceFilesystem},
+	}
+}
+
+func (p *HasNoCaseCollidingFilesCheck) Explain() check.Explanation {
+	return check.Explanation{
+		Criteria: []string{
+			"The paths of the files, directories, and links in the layers of the image are read as the image is extracted, including the paths that are not extracted.",
+			"No two of them differ only by case, e.g. /opt/app/Config and /opt/app/config, unless both are installed by RPM packages.",
+		},
+		CommonCauses: []string{
+			"The application, or a dependency vendored into the image, ships files whose names differ only by case, e.g. README and readme.",
+			"A build step writes a file that already exists with a different case, e.g. a generated Makefile next to a makefile.",
+			"Directories of the same name are copied into the image with different cases, e.g. /opt/App and /opt/app.",
+		},
+		Remediation: []string{
+			"Rename or remove one of each group of colliding paths listed in the " + CaseCollidingFilesFilename + " artifact, e.g. with RUN rm in the Dockerfile or Containerfile, or by excluding it from COPY.",
+		},
+	}
+}
@@ -0,0 +1,113 @@
+package container
+
+import (
+	"context"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/artifacts"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/image"
+)
+
+var _ = Describe("HasNoCaseCollidingFiles", func() {
+	var hasNoCaseCollidingFiles HasNoCaseCollidingFilesCheck
+
+	imageWith := func(layers ...map[string][]byte) image.ImageReference {
+		img, err := crane.Image(layers[0])
+		Expect(err).ToNot(HaveOccurred())
+		for _, files := range layers[1:] {
+			layer, err := crane.Layer(files)
+			Expect(err).ToNot(HaveOccurred())
+			img, err = mutate.AppendLayers(img, layer)
+			Expect(err).ToNot(HaveOccurred())
+		}
+		return image.ImageReference{ImageInfo: img, ImageFSPath: GinkgoT().TempDir()}
+	}
+
+	Context("When no paths differ only by case", func() {
+		It("should pass Validate", func() {
+			imgRef := imageWith(map[string][]byte{
+				"opt/app/config":   []byte("a"),
+				"opt/app/config.d": []byte("b"),
+			})
+			ok, err := hasNoCaseCollidingFiles.Validate(context.Background(), imgRef)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ok).To(BeTrue())
+		})
+	})
+
+	Context("When paths in the same layer differ only by case", func() {
+		It("should not pass Validate, and write the paths as an artifact", func() {
+			imgRef := imageWith(map[string][]byte{
+				"opt/app/Config": []byte("a"),
+				"opt/app/config": []byte("b"),
+			})
+			aw, err := artifacts.NewMapWriter()
+			Expect(err).ToNot(HaveOccurred())
+			ok, err := hasNoCaseCollidingFiles.Validate(artifacts.ContextWithWriter(context.Background(), aw), imgRef)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ok).To(BeFalse())
+
+			b, err := aw.ReadFile(CaseCollidingFilesFilename)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(b).To(MatchJSON(`[["/opt/app/Config", "/opt/app/config"]]`))
+		})
+	})
+
+	Context("When a later layer adds a path that differs only by case", func() {
+		It("should not pass Validate", func() {
+			imgRef := imageWith(
+				map[string][]byte{"opt/app/README": []byte("a")},
+				map[string][]byte{"opt/app/readme": []byte("b")},
+			)
+			ok, err := hasNoCaseCollidingFiles.Validate(context.Background(), imgRef)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Context("When the entries of the image were recorded as it was extracted", func() {
+		It("should evaluate the recorded paths rather than read the layers", func() {
+			imgRef := image.ImageReference{
+				ImageFSPath: GinkgoT().TempDir(),
+				Files: []image.File{
+					{Path: "/"},
+					{Path: "/opt/app/Config", Size: 1},
+					{Path: "/opt/app/config", Size: 1},
+				},
+			}
+			ok, err := hasNoCaseCollidingFiles.Validate(context.Background(), imgRef)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Context("When finding the paths that differ only by case", func() {
+		It("should group them, and ignore those installed by packages", func() {
+			collisions := caseCollisions([]string{
+				"/opt/app/b",
+				"/opt/app/B",
+				"/opt/App",
+				"/opt/app",
+				"/usr/include/linux/netfilter/xt_MARK.h",
+				"/usr/include/linux/netfilter/xt_mark.h",
+				"/opt/app/b",
+			}, map[string]struct{}{
+				"/usr/include/linux/netfilter/xt_MARK.h": {},
+				"/usr/include/linux/netfilter/xt_mark.h": {},
+			})
+			Expect(collisions).To(Equal([][]string{
+				{"/opt/App", "/opt/app"},
+				{"/opt/app/B", "/opt/app/b"},
+			}))
+		})
+
+		It("should report collisions with paths that were not installed by packages", func() {
+			collisions := caseCollisions([]string{"/etc/Hosts", "/etc/hosts"}, map[string]struct{}{"/etc/hosts": {}})
+			Expect(collisions).To(Equal([][]string{{"/etc/Hosts", "/etc/hosts"}}))
+		})
+	})
+})
@@ -3,6 +3,7 @@ package container
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
@@ -86,6 +87,45 @@ func (p *HasNoProhibitedPackagesCheck) Help() check.HelpText {
 	}
 }
 
+// FilesystemPaths returns the paths the check reads: the RPM database.
+func (p *HasNoProhibitedPackagesCheck) FilesystemPaths() []string {
+	return []string{rpm.DatabaseDir}
+}
+
+// Provenance returns the provenance of the results of the check: the packages are read
+// from the RPM database in the filesystem of the image.
+func (p *HasNoProhibitedPackagesCheck) Provenance() check.Provenance {
+	return check.Provenance{
+		DataSources: []check.DataSource{check.DataSourceFilesystem},
+	}
+}
+
+func (p *HasNoProhibitedPackagesCheck) Explain() check.Explanation {
+	prohibited := make([]string, 0, len(prohibitedPackageList))
+	for pkg := range prohibitedPackageList {
+		prohibited = append(prohibited, pkg)
+	}
+	sort.Strings(prohibited)
+
+	return check.Explanation{
+		Criteria: []string{
+			"The packages installed in the image are read from its RPM database.",
+			fmt.Sprintf("None of them is one of the packages that are not redistributable outside of RHEL: %s.", strings.Join(prohibited, ", ")),
+			fmt.Sprintf("None of their names starts with: %s.", strings.Join(prohibitedPackageGlobList, ", ")),
+		},
+		CommonCauses: []string{
+			"The image is based on a RHEL image, rather than a Universal Base Image (UBI), and inherits its kernel or bootloader packages.",
+			"A package installed by the Dockerfile or Containerfile depends on one of the packages, e.g. tools that require kernel-devel to build kernel modules.",
+			"The image installs packages from RHEL repositories that are not available in the UBI repositories.",
+		},
+		Remediation: []string{
+			"Base the image on a UBI, e.g. registry.access.redhat.com/ubi9/ubi.",
+			"Remove the packages, e.g. with dnf remove, or avoid installing the packages that depend on them.",
+			"Only install packages from the UBI repositories, e.g. with dnf --disablerepo='*' --enablerepo='ubi-*'.",
+		},
+	}
+}
+
 // prohibitedPackageList is a list of packages commonly present in the RHEL container images that are not redistributable
 // without proper licensing (i.e. packages that are not under the same availability as those found in UBI).
 // Implementation detail: Use a map[string]struct{} so that lookups can be done, and determine their existence
@@ -55,4 +55,13 @@ var _ = Describe("HasNoProhibitedPackages", func() {
 			})
 		})
 	})
+
+	Describe("Explaining the check", func() {
+		It("should list the prohibited packages, and the prefixes of prohibited packages", func() {
+			explanation := hasNoProhibitedPackages.Explain()
+			Expect(explanation.Criteria).To(ContainElement(ContainSubstring("kernel-core")))
+			Expect(explanation.Criteria).To(ContainElement(ContainSubstring("kpatch")))
+			Expect(explanation.Remediation).ToNot(BeEmpty())
+		})
+	})
 })
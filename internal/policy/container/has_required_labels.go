@@ -3,6 +3,7 @@ package container
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/image"
@@ -16,6 +17,11 @@ var requiredLabels = []string{"name", "vendor", "version", "release", "summary",
 
 var _ check.Check = &HasRequiredLabelsCheck{}
 
+// RequiredLabels returns the labels that HasRequiredLabelsCheck requires to have a value.
+func RequiredLabels() []string {
+	return append([]string{}, requiredLabels...)
+}
+
 // HasRequiredLabelsCheck evaluates the image manifest to ensure that the appropriate metadata
 // labels are present on the image asset as it exists in its current container registry.
 type HasRequiredLabelsCheck struct{}
@@ -71,3 +77,36 @@ func (p *HasRequiredLabelsCheck) Help() check.HelpText {
 		Suggestion: "Add the following labels to your Dockerfile or Containerfile: name, vendor, version, release, summary, description",
 	}
 }
+
+// Provenance returns the provenance of the results of the check: the labels are read
+// from the configuration of the image.
+func (p *HasRequiredLabelsCheck) Provenance() check.Provenance {
+	return check.Provenance{
+		DataSources: []check.DataSource{check.DataSourceManifest},
+	}
+}
+
+func (p *HasRequiredLabelsCheck) Explain() check.Explanation {
+	return check.Explanation{
+		Criteria: []string{
+			fmt.Sprintf("Each of the labels %s has a value in the config of the image.", strings.Join(requiredLabels, ", ")),
+		},
+		CommonCauses: []string{
+			"The Dockerfile or Containerfile does not set the labels with a LABEL instruction.",
+			"The labels are set on an earlier stage of a multi-stage build, rather than on its final stage.",
+			"A label is set to an empty value, e.g. by a build argument that was not passed.",
+		},
+		Remediation: []string{
+			fmt.Sprintf("Set the labels in the final stage of the Dockerfile or Containerfile, e.g. LABEL %s.", labelExample()),
+		},
+	}
+}
+
+// labelExample returns an example LABEL instruction setting the required labels.
+func labelExample() string {
+	labels := make([]string, 0, len(requiredLabels))
+	for _, label := range requiredLabels {
+		labels = append(labels, fmt.Sprintf("%s=\"...\"", label))
+	}
+	return strings.Join(labels, " ")
+}
@@ -3,13 +3,16 @@ package container
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"strings"
 
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/authn"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/image"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/transport"
 
 	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 )
 
 var _ check.Check = &hasUniqueTagCheck{}
@@ -59,6 +62,9 @@ func (p *hasUniqueTagCheck) getDataToValidate(ctx context.Context, image string)
 		crane.WithContext(ctx),
 		crane.WithAuthFromKeychain(authn.PreflightKeychain(ctx, authn.WithDockerConfig(p.dockercfg))),
 	}
+	if transport.IsConfigured(ctx) {
+		options = append(options, crane.WithTransport(transport.Transport(ctx, remote.DefaultTransport.(*http.Transport))))
+	}
 
 	return crane.ListTags(image, options...)
 }
@@ -90,3 +96,28 @@ func (p *hasUniqueTagCheck) Help() check.HelpText {
 		Suggestion: "Add a tag to your image. Consider using Semantic Versioning. https://semver.org/",
 	}
 }
+
+// Provenance returns the provenance of the results of the check: the tags of the image
+// are listed by its registry.
+func (p *hasUniqueTagCheck) Provenance() check.Provenance {
+	return check.Provenance{
+		DataSources: []check.DataSource{check.DataSourceRegistry},
+	}
+}
+
+func (p *hasUniqueTagCheck) Explain() check.Explanation {
+	return check.Explanation{
+		Criteria: []string{
+			"The image is checked by a tag other than latest, or",
+			"the image is checked by the latest tag, or by digest, and its repository has a tag other than latest.",
+		},
+		CommonCauses: []string{
+			"The image was only pushed with the latest tag.",
+			"The image is checked by digest, and the registry does not list the tags of its repository, e.g. because the credentials used cannot.",
+		},
+		Remediation: []string{
+			"Tag the image with a unique tag, e.g. its semantic version, and push the tag.",
+			"Check the image by that tag, rather than by latest.",
+		},
+	}
+}
@@ -18,6 +18,11 @@ const (
 
 var _ check.Check = &MaxLayersCheck{}
 
+// MaxLayers returns the maximum number of layers that MaxLayersCheck accepts.
+func MaxLayers() int {
+	return acceptableLayerMax
+}
+
 // UnderLayerMaxCheck ensures that the image has less layers in its assembly than a predefined maximum.
 type MaxLayersCheck struct{}
 
@@ -58,3 +63,28 @@ func (p *MaxLayersCheck) Help() check.HelpText {
 		Suggestion: "Optimize your Dockerfile to consolidate and minimize the number of layers. Each RUN command will produce a new layer. Try combining RUN commands using && where possible.",
 	}
 }
+
+// Provenance returns the provenance of the results of the check: the layers are counted
+// in the manifest of the image.
+func (p *MaxLayersCheck) Provenance() check.Provenance {
+	return check.Provenance{
+		DataSources: []check.DataSource{check.DataSourceManifest},
+	}
+}
+
+func (p *MaxLayersCheck) Explain() check.Explanation {
+	return check.Explanation{
+		Criteria: []string{
+			fmt.Sprintf("The image has at most %d layers, including the layers of its base image.", acceptableLayerMax),
+		},
+		CommonCauses: []string{
+			"Each RUN, COPY, and ADD instruction of the Dockerfile or Containerfile adds a layer.",
+			"The base image already has many layers.",
+		},
+		Remediation: []string{
+			"Combine RUN instructions using &&.",
+			"Use a multi-stage build, and only copy what the image needs into its final stage.",
+			"Squash the layers added by the build, e.g. with podman build --squash.",
+		},
+	}
+}
@@ -3,6 +3,7 @@ package container
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/image"
@@ -13,6 +14,16 @@ import (
 
 var _ check.Check = &RunAsNonRootCheck{}
 
+// rootUsers are the values of USER that are the root user. An empty USER is
+// presumed to be the root user too.
+var rootUsers = []string{"0", "root"}
+
+// RootUsers returns the values of USER that RunAsNonRootCheck considers to be the
+// root user, including the empty USER.
+func RootUsers() []string {
+	return append([]string{""}, rootUsers...)
+}
+
 // RunAsNonRootCheck evaluates the image to determine that the runtime UID is not 0,
 // which correlates to the root user.
 type RunAsNonRootCheck struct{}
@@ -43,10 +54,12 @@ func (p *RunAsNonRootCheck) validate(ctx context.Context, user string) (bool, er
 		return false, nil
 	}
 
-	if user == "0" || user == "root" {
-		logger.Info("detected USER specified as root or UID 0")
-		logger.Info("USER other than root is required for this check to pass")
-		return false, nil
+	for _, root := range rootUsers {
+		if user == root {
+			logger.Info("detected USER specified as root or UID 0")
+			logger.Info("USER other than root is required for this check to pass")
+			return false, nil
+		}
 	}
 
 	logger.Info(fmt.Sprintf("USER %s specified that is non-root", user))
@@ -72,3 +85,30 @@ func (p *RunAsNonRootCheck) Help() check.HelpText {
 		Suggestion: "Indicate a specific USER in the dockerfile or containerfile",
 	}
 }
+
+// Provenance returns the provenance of the results of the check: the USER is read from
+// the configuration of the image. A USER that is a name, rather than a UID, is not
+// resolved, and is presumed not to be root, so the confidence is medium.
+func (p *RunAsNonRootCheck) Provenance() check.Provenance {
+	return check.Provenance{
+		DataSources: []check.DataSource{check.DataSourceManifest},
+		Confidence:  check.ConfidenceMedium,
+	}
+}
+
+func (p *RunAsNonRootCheck) Explain() check.Explanation {
+	return check.Explanation{
+		Criteria: []string{
+			"The config of the image sets USER.",
+			fmt.Sprintf("USER is not the root user, i.e. none of: %s.", strings.Join(rootUsers, ", ")),
+		},
+		CommonCauses: []string{
+			"The Dockerfile or Containerfile does not set USER, so the image runs as root.",
+			"USER is set to root to install packages, and not set back to another user afterwards.",
+		},
+		Remediation: []string{
+			"Set USER to a non-root user, e.g. USER 1001, after the instructions that need to run as root.",
+			"If the image must run as root, request a root exception for the certification project.",
+		},
+	}
+}
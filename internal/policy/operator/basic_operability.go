@@ -0,0 +1,292 @@
+package operator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/events"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/image"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/log"
+
+	"github.com/go-logr/logr"
+	"github.com/operator-framework/api/pkg/manifests"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	apiruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	ctrl "sigs.k8s.io/controller-runtime"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	_ check.Check     = &BasicOperabilityCheck{}
+	_ check.Retryable = &BasicOperabilityCheck{}
+)
+
+// defaultOperabilityNamespace is the namespace the objects of the operator are applied
+// in when none is configured.
+const defaultOperabilityNamespace = "default"
+
+// BasicOperabilityCheck is a lighter-weight alternative to the scorecard checks for
+// clusters that cannot run the scorecard pod. It verifies that the CSV of the bundle is
+// complete and consistent, and that the cluster accepts its CRDs, deployments, service
+// accounts, and RBAC in a server-side dry-run, without creating anything.
+type BasicOperabilityCheck struct {
+	namespace  string
+	kubeconfig []byte
+
+	// apply applies obj to the cluster in dry-run mode. It is set by initClient if it
+	// is nil.
+	apply func(ctx context.Context, obj *unstructured.Unstructured) error
+}
+
+// NewBasicOperabilityCheck returns a BasicOperabilityCheck that applies the objects of
+// the operator in namespace, or the default namespace if it is empty, of the cluster
+// in kubeconfig. If kubeconfig is empty, the cluster is found as controller-runtime
+// does.
+func NewBasicOperabilityCheck(namespace string, kubeconfig []byte) *BasicOperabilityCheck {
+	if namespace == "" {
+		namespace = defaultOperabilityNamespace
+	}
+
+	return &BasicOperabilityCheck{
+		namespace:  namespace,
+		kubeconfig: kubeconfig,
+	}
+}
+
+func (p *BasicOperabilityCheck) initClient() error {
+	if p.apply != nil {
+		return nil
+	}
+
+	var (
+		cfg *rest.Config
+		err error
+	)
+	if len(p.kubeconfig) > 0 {
+		cfg, err = clientcmd.RESTConfigFromKubeConfig(p.kubeconfig)
+	} else {
+		cfg, err = ctrl.GetConfig()
+	}
+	if err != nil {
+		return fmt.Errorf("could not get kubeconfig: %w", err)
+	}
+
+	client, err := crclient.New(cfg, crclient.Options{})
+	if err != nil {
+		return fmt.Errorf("could not get controller-runtime client: %w", err)
+	}
+
+	p.apply = func(ctx context.Context, obj *unstructured.Unstructured) error {
+		return client.Patch(ctx, obj, crclient.Apply, crclient.DryRunAll, crclient.FieldOwner("preflight"), crclient.ForceOwnership)
+	}
+	return nil
+}
+
+func (p *BasicOperabilityCheck) Validate(ctx context.Context, bundleRef image.ImageReference) (bool, error) {
+	logger := logr.FromContextOrDiscard(ctx)
+
+	bundle, err := manifests.GetBundleFromDir(bundleRef.ImageFSPath)
+	if err != nil {
+		return false, fmt.Errorf("could not load bundle: %w", err)
+	}
+	if bundle.CSV == nil {
+		return false, errors.New("the bundle does not contain a ClusterServiceVersion")
+	}
+
+	problems := csvProblems(bundle)
+	if len(problems) == 0 {
+		objects, err := operabilityObjects(ctx, bundle, p.namespace)
+		if err != nil {
+			return false, err
+		}
+
+		if err := p.initClient(); err != nil {
+			return false, err
+		}
+
+		events.EmitPhase(ctx, p.Name(), "applying the operator in dry-run mode")
+		for _, obj := range objects {
+			logger.V(log.DBG).Info("applying in dry-run mode", "kind", obj.GetKind(), "name", obj.GetName(), "namespace", obj.GetNamespace())
+			if err := p.apply(ctx, obj); err != nil {
+				problems = append(problems, fmt.Sprintf("the cluster rejected %s %s: %v", obj.GetKind(), obj.GetName(), err))
+			}
+		}
+	}
+
+	for _, problem := range problems {
+		logger.Error(errors.New("basic operability problem"), problem)
+	}
+
+	return len(problems) == 0, nil
+}
+
+// csvProblems returns the reasons the CSV of bundle could not be installed.
+func csvProblems(bundle *manifests.Bundle) []string {
+	var problems []string
+	csv := bundle.CSV
+	strategy := csv.Spec.InstallStrategy.StrategySpec
+
+	if len(strategy.DeploymentSpecs) == 0 {
+		problems = append(problems, "the install strategy of the CSV does not have any deployments")
+	}
+
+	crds := map[string]struct{}{}
+	for _, crd := range bundle.V1CRDs {
+		crds[crd.Name] = struct{}{}
+	}
+	for _, crd := range bundle.V1beta1CRDs {
+		crds[crd.Name] = struct{}{}
+	}
+	for _, owned := range csv.Spec.CustomResourceDefinitions.Owned {
+		if _, ok := crds[owned.Name]; !ok {
+			problems = append(problems, fmt.Sprintf("the CSV owns CRD %s, which is not in the bundle", owned.Name))
+		}
+	}
+
+	serviceAccounts := map[string]struct{}{"default": {}, "": {}}
+	for _, perms := range []struct {
+		scope       string
+		permissions []operatorsv1alpha1.StrategyDeploymentPermissions
+	}{
+		{"permissions", strategy.Permissions},
+		{"clusterPermissions", strategy.ClusterPermissions},
+	} {
+		for _, perm := range perms.permissions {
+			serviceAccounts[perm.ServiceAccountName] = struct{}{}
+			for i, rule := range perm.Rules {
+				switch {
+				case len(rule.Verbs) == 0:
+					problems = append(problems, fmt.Sprintf("rule %d of the %s of %s does not have any verbs", i+1, perms.scope, perm.ServiceAccountName))
+				case len(rule.Resources) == 0 && len(rule.NonResourceURLs) == 0:
+					problems = append(problems, fmt.Sprintf("rule %d of the %s of %s does not have any resources or nonResourceURLs", i+1, perms.scope, perm.ServiceAccountName))
+				case perms.scope == "permissions" && len(rule.NonResourceURLs) > 0:
+					problems = append(problems, fmt.Sprintf("rule %d of the permissions of %s has nonResourceURLs, which only clusterPermissions may have", i+1, perm.ServiceAccountName))
+				}
+			}
+		}
+	}
+
+	for _, dep := range strategy.DeploymentSpecs {
+		sa := dep.Spec.Template.Spec.ServiceAccountName
+		if _, ok := serviceAccounts[sa]; !ok {
+			problems = append(problems, fmt.Sprintf("deployment %s uses service account %s, which is not in the permissions or clusterPermissions of the CSV", dep.Name, sa))
+		}
+	}
+
+	return problems
+}
+
+// operabilityObjects returns the objects OLM would create to install the CSV of bundle
+// in namespace, as unstructured objects that may be applied.
+func operabilityObjects(ctx context.Context, bundle *manifests.Bundle, namespace string) ([]*unstructured.Unstructured, error) {
+	logger := logr.FromContextOrDiscard(ctx)
+	csv := bundle.CSV
+	strategy := csv.Spec.InstallStrategy.StrategySpec
+
+	var objects []apiruntime.Object
+	for _, crd := range bundle.V1CRDs {
+		crd := crd.DeepCopy()
+		crd.APIVersion, crd.Kind = "apiextensions.k8s.io/v1", "CustomResourceDefinition"
+		objects = append(objects, crd)
+	}
+	for _, crd := range bundle.V1beta1CRDs {
+		logger.Info("skipping v1beta1 CRD, which clusters no longer serve", "name", crd.Name)
+	}
+
+	serviceAccounts := map[string]struct{}{}
+	addServiceAccount := func(name string) {
+		if _, ok := serviceAccounts[name]; ok || name == "" || name == "default" {
+			return
+		}
+		serviceAccounts[name] = struct{}{}
+		objects = append(objects, &corev1.ServiceAccount{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		})
+	}
+
+	for i, perm := range strategy.Permissions {
+		addServiceAccount(perm.ServiceAccountName)
+		objects = append(objects, &rbacv1.Role{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "Role"},
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-%d", csv.Name, i), Namespace: namespace},
+			Rules:      perm.Rules,
+		})
+	}
+	for i, perm := range strategy.ClusterPermissions {
+		addServiceAccount(perm.ServiceAccountName)
+		objects = append(objects, &rbacv1.ClusterRole{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole"},
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-%d", csv.Name, i)},
+			Rules:      perm.Rules,
+		})
+	}
+
+	for _, dep := range strategy.DeploymentSpecs {
+		objects = append(objects, &appsv1.Deployment{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+			ObjectMeta: metav1.ObjectMeta{Name: dep.Name, Namespace: namespace, Labels: dep.Label},
+			Spec:       dep.Spec,
+		})
+	}
+
+	unstructuredObjects := make([]*unstructured.Unstructured, 0, len(objects))
+	for _, obj := range objects {
+		content, err := apiruntime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return nil, fmt.Errorf("could not convert %T to an unstructured object: %w", obj, err)
+		}
+		// The status is not applied, and the server rejects empty ones of some kinds.
+		delete(content, "status")
+		unstructuredObjects = append(unstructuredObjects, &unstructured.Unstructured{Object: content})
+	}
+
+	return unstructuredObjects, nil
+}
+
+func (p *BasicOperabilityCheck) Retryable() bool {
+	return true
+}
+
+func (p *BasicOperabilityCheck) RequiresCluster() bool {
+	return true
+}
+
+func (p *BasicOperabilityCheck) Name() string {
+	return "BasicOperabilityCheck"
+}
+
+func (p *BasicOperabilityCheck) Metadata() check.Metadata {
+	return check.Metadata{
+		Description:      "Check that the CSV is consistent, and that the cluster accepts the CRDs, deployments, and RBAC of the operator in a dry-run.",
+		Level:            "best",
+		KnowledgeBaseURL: "https://olm.operatorframework.io/docs/concepts/crds/clusterserviceversion/",
+		CheckURL:         "https://kubernetes.io/docs/reference/using-api/api-concepts/#dry-run",
+	}
+}
+
+func (p *BasicOperabilityCheck) Help() check.HelpText {
+	return check.HelpText{
+		Message:    "Check BasicOperabilityCheck encountered an error. Please review the preflight.log file for more information.",
+		Suggestion: "Make sure that the CSV declares the deployments, CRDs, and permissions of the operator, and that they are valid for the cluster.",
+	}
+}
+
+// Provenance returns the provenance of the results of the check: the objects of the
+// bundle are applied to the cluster in a dry-run. The operator is not deployed, so the
+// confidence is medium.
+func (p *BasicOperabilityCheck) Provenance() check.Provenance {
+	return check.Provenance{
+		DataSources: []check.DataSource{check.DataSourceManifest, check.DataSourceCluster},
+		Confidence:  check.ConfidenceMedium,
+	}
+}
@@ -0,0 +1,113 @@
+package operator
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/image"
+)
+
+var _ = Describe("BasicOperabilityCheck", func() {
+	var (
+		basicOperabilityCheck *BasicOperabilityCheck
+		imageRef              image.ImageReference
+		applied               []*unstructured.Unstructured
+		rejected              string
+	)
+
+	AssertMetaData(NewBasicOperabilityCheck("", nil))
+
+	BeforeEach(func() {
+		applied = nil
+		rejected = ""
+		imageRef = image.ImageReference{ImageFSPath: "./testdata/all_namespaces"}
+		basicOperabilityCheck = NewBasicOperabilityCheck("preflight", nil)
+		basicOperabilityCheck.apply = func(ctx context.Context, obj *unstructured.Unstructured) error {
+			applied = append(applied, obj)
+			if obj.GetKind() == rejected {
+				return errors.New("forbidden")
+			}
+			return nil
+		}
+	})
+
+	When("the cluster accepts the objects of the operator", func() {
+		It("should pass", func() {
+			ok, err := basicOperabilityCheck.Validate(context.TODO(), imageRef)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ok).To(BeTrue())
+
+			kinds := []string{}
+			for _, obj := range applied {
+				kinds = append(kinds, obj.GetKind())
+				Expect(obj.Object).ToNot(HaveKey("status"))
+			}
+			Expect(kinds).To(Equal([]string{"ServiceAccount", "Role", "ClusterRole", "Deployment"}))
+			Expect(applied[0].GetNamespace()).To(Equal("preflight"))
+			Expect(applied[3].GetName()).To(Equal("memcached-operator-controller-manager"))
+		})
+	})
+
+	When("the cluster rejects an object of the operator", func() {
+		It("should fail", func() {
+			rejected = "ClusterRole"
+			ok, err := basicOperabilityCheck.Validate(context.TODO(), imageRef)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	When("the CSV is not consistent", func() {
+		var csvPath string
+
+		BeforeEach(func() {
+			tmpDir := GinkgoT().TempDir()
+			Expect(os.MkdirAll(filepath.Join(tmpDir, "manifests"), 0o755)).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(tmpDir, "metadata"), 0o755)).To(Succeed())
+			annotations, err := os.ReadFile("./testdata/all_namespaces/metadata/annotations.yaml")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(os.WriteFile(filepath.Join(tmpDir, "metadata", "annotations.yaml"), annotations, 0o644)).To(Succeed())
+			imageRef.ImageFSPath = tmpDir
+			csvPath = filepath.Join(tmpDir, "manifests", "memcached-operator.clusterserviceversion.yaml")
+		})
+
+		DescribeTable("should fail without applying anything",
+			func(old, new string) {
+				csv, err := os.ReadFile("./testdata/all_namespaces/manifests/memcached-operator.clusterserviceversion.yaml")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(csv)).To(ContainSubstring(old))
+				Expect(os.WriteFile(csvPath, []byte(strings.Replace(string(csv), old, new, 1)), 0o644)).To(Succeed())
+
+				ok, err := basicOperabilityCheck.Validate(context.TODO(), imageRef)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ok).To(BeFalse())
+				Expect(applied).To(BeEmpty())
+			},
+			Entry("when a deployment uses an undeclared service account",
+				"              serviceAccountName: memcached-operator-controller-manager",
+				"              serviceAccountName: undeclared"),
+			Entry("when a rule does not have any verbs",
+				"          verbs:\n          - update\n",
+				"          verbs: []\n"),
+			Entry("when the CSV owns a CRD that is not in the bundle",
+				"  apiservicedefinitions: {}\n",
+				"  apiservicedefinitions: {}\n  customresourcedefinitions:\n    owned:\n    - name: memcacheds.cache.example.com\n      kind: Memcached\n      version: v1alpha1\n"),
+		)
+	})
+
+	When("there is no bundle", func() {
+		It("should throw an error", func() {
+			imageRef.ImageFSPath = "./testdata/missing"
+			ok, err := basicOperabilityCheck.Validate(context.TODO(), imageRef)
+			Expect(err).To(HaveOccurred())
+			Expect(ok).To(BeFalse())
+		})
+	})
+})
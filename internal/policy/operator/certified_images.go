@@ -121,3 +121,11 @@ func (p *certifiedImagesCheck) Help() check.HelpText {
 		Suggestion: "Ensure that any images referenced in the CSV, including the relatedImages section, have been certified.",
 	}
 }
+
+// Provenance returns the provenance of the results of the check: the images the bundle
+// references are looked up in Pyxis.
+func (p *certifiedImagesCheck) Provenance() check.Provenance {
+	return check.Provenance{
+		DataSources: []check.DataSource{check.DataSourceManifest, check.DataSourcePyxis},
+	}
+}
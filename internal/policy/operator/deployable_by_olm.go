@@ -15,11 +15,12 @@ import (
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/artifacts"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/bundle"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/events"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/image"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/interrupt"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/log"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/openshift"
 
-	"github.com/go-logr/logr"
 	"github.com/operator-framework/api/pkg/manifests"
 	operatorsv1 "github.com/operator-framework/api/pkg/operators/v1"
 	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
@@ -28,11 +29,16 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	apiruntime "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
 	ctrl "sigs.k8s.io/controller-runtime"
 	crclient "sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-var _ check.Check = &DeployableByOlmCheck{}
+var (
+	_ check.Check     = &DeployableByOlmCheck{}
+	_ check.Retryable = &DeployableByOlmCheck{}
+)
 
 type operatorData struct {
 	CatalogImage     string
@@ -53,11 +59,17 @@ type DeployableByOlmCheck struct {
 	indexImage string
 	// channel is optional. If empty, we will introspect.
 	channel string
+	// kubeconfig is optional. If empty, the config is read from the environment.
+	kubeconfig []byte
 
 	openshiftClient openshift.Client
 	client          crclient.Client
 	csvReady        bool
 	validImages     bool
+
+	// digest returns the digest of an image that is not referenced by digest. If it is
+	// nil, the digest is retrieved from the registry.
+	digest func(ctx context.Context, image string) (string, error)
 }
 
 func (p *DeployableByOlmCheck) initClient() error {
@@ -69,15 +81,20 @@ func (p *DeployableByOlmCheck) initClient() error {
 		return fmt.Errorf("could not add new schemes to client: %w", err)
 	}
 
-	// TODO(): GetConfig generates a rest config from environment paths. We already have
-	// the Kubeconfig at this point, so we should potentially find another way to generate
-	// a rest config that doesn't rely on ctrl's implicit locations for it.
-	kubeconfig, err := ctrl.GetConfig()
+	var (
+		cfg *rest.Config
+		err error
+	)
+	if len(p.kubeconfig) > 0 {
+		cfg, err = clientcmd.RESTConfigFromKubeConfig(p.kubeconfig)
+	} else {
+		cfg, err = ctrl.GetConfig()
+	}
 	if err != nil {
 		return fmt.Errorf("could not get kubeconfig: %w", err)
 	}
 
-	client, err := crclient.New(kubeconfig, crclient.Options{
+	client, err := crclient.New(cfg, crclient.Options{
 		Scheme: scheme,
 	})
 	if err != nil {
@@ -97,21 +114,24 @@ func (p *DeployableByOlmCheck) initOpenShifeEngine() {
 // NewDeployableByOlmCheck will return a check that validates if an operator
 // is deployable by OLM. An empty dockerConfig value implies that the images
 // in scope are public. An empty channel value implies that the check should
-// introspect the channel from the bundle. indexImage is required.
+// introspect the channel from the bundle. indexImage is required. The cluster
+// is accessed with kubeconfig, or, if it is empty, the config in the environment.
 func NewDeployableByOlmCheck(
 	indexImage,
 	dockerConfig,
 	channel string,
+	kubeconfig []byte,
 ) *DeployableByOlmCheck {
 	return &DeployableByOlmCheck{
 		dockerConfig: dockerConfig,
 		indexImage:   indexImage,
 		channel:      channel,
+		kubeconfig:   kubeconfig,
 	}
 }
 
 func (p *DeployableByOlmCheck) Validate(ctx context.Context, bundleRef image.ImageReference) (bool, error) {
-	logger := logr.FromContextOrDiscard(ctx)
+	logger := log.FromContext(ctx, log.ComponentOLM)
 
 	if err := p.initClient(); err != nil {
 		return false, fmt.Errorf("%v", err)
@@ -148,13 +168,17 @@ func (p *DeployableByOlmCheck) Validate(ctx context.Context, bundleRef image.Ima
 	logger.V(log.DBG).Info("operator metadata", "metadata", *operatorData)
 
 	// create k8s custom resources for the operator deployment
+	events.EmitPhase(ctx, p.Name(), "deploying operator")
 	err = p.setUp(ctx, operatorData)
-	defer p.cleanUp(ctx, *operatorData)
+	// The resources are removed even if the check is stopped, e.g. because preflight was
+	// interrupted, so that they are not left on the cluster.
+	defer p.cleanUp(interrupt.Detach(ctx), *operatorData)
 
 	if err != nil {
 		return false, fmt.Errorf("%v", err)
 	}
 
+	events.EmitPhase(ctx, p.Name(), "waiting on OLM")
 	installedCSV, err := p.installedCSV(ctx, *operatorData)
 	if err != nil {
 		return false, fmt.Errorf("%v", err)
@@ -189,7 +213,7 @@ func diffImageList(before, after map[string]struct{}) []string {
 }
 
 func checkImageSource(ctx context.Context, operatorImages []string) bool {
-	logger := logr.FromContextOrDiscard(ctx)
+	logger := log.FromContext(ctx, log.ComponentOLM)
 
 	logger.V(log.DBG).Info("checking that images are from approved sources")
 
@@ -260,7 +284,7 @@ func (p *DeployableByOlmCheck) operatorMetadata(ctx context.Context, bundleRef i
 }
 
 func (p *DeployableByOlmCheck) setUp(ctx context.Context, operatorData *operatorData) error {
-	logger := logr.FromContextOrDiscard(ctx)
+	logger := log.FromContext(ctx, log.ComponentOLM)
 
 	if _, err := p.openshiftClient.CreateNamespace(ctx, operatorData.InstallNamespace); err != nil && !errors.Is(err, openshift.ErrAlreadyExists) {
 		return err
@@ -364,7 +388,7 @@ func (p *DeployableByOlmCheck) setUp(ctx context.Context, operatorData *operator
 }
 
 func (p *DeployableByOlmCheck) generateOperatorGroupData(ctx context.Context, operatorData *operatorData) openshift.OperatorGroupData {
-	logger := logr.FromContextOrDiscard(ctx)
+	logger := log.FromContext(ctx, log.ComponentOLM)
 
 	var installMode operatorsv1alpha1.InstallModeType
 	for _, v := range prioritizedInstallModes {
@@ -413,7 +437,7 @@ func (p *DeployableByOlmCheck) grantRegistryPermissionToServiceAccount(ctx conte
 type watchFunc func(ctx context.Context, client openshift.Client, name, namespace string) (string, bool, error)
 
 func watch(ctx context.Context, client openshift.Client, wg *sync.WaitGroup, name, namespace string, timeout time.Duration, channel chan string, fn watchFunc) {
-	logger := logr.FromContextOrDiscard(ctx)
+	logger := log.FromContext(ctx, log.ComponentOLM)
 
 	defer wg.Done()
 
@@ -447,7 +471,7 @@ func watch(ctx context.Context, client openshift.Client, wg *sync.WaitGroup, nam
 }
 
 func csvStatusSucceeded(ctx context.Context, client openshift.Client, name, namespace string) (string, bool, error) {
-	logger := logr.FromContextOrDiscard(ctx)
+	logger := log.FromContext(ctx, log.ComponentOLM)
 
 	csv, err := client.GetCSV(ctx, name, namespace)
 	if err != nil && !errors.Is(err, openshift.ErrNotFound) {
@@ -463,7 +487,7 @@ func csvStatusSucceeded(ctx context.Context, client openshift.Client, name, name
 }
 
 func (p *DeployableByOlmCheck) isCSVReady(ctx context.Context, operatorData operatorData) (bool, error) {
-	logger := logr.FromContextOrDiscard(ctx)
+	logger := log.FromContext(ctx, log.ComponentOLM)
 
 	var CsvNamespaces []string
 	if len(operatorData.CsvNamespaces) == 0 {
@@ -499,7 +523,7 @@ func (p *DeployableByOlmCheck) isCSVReady(ctx context.Context, operatorData oper
 }
 
 func subscriptionCsvIsInstalled(ctx context.Context, client openshift.Client, name, namespace string) (string, bool, error) {
-	logger := logr.FromContextOrDiscard(ctx)
+	logger := log.FromContext(ctx, log.ComponentOLM)
 
 	sub, err := client.GetSubscription(ctx, name, namespace)
 	if err != nil && !errors.Is(err, openshift.ErrNotFound) {
@@ -539,9 +563,10 @@ func (p *DeployableByOlmCheck) installedCSV(ctx context.Context, operatorData op
 }
 
 func (p *DeployableByOlmCheck) cleanUp(ctx context.Context, operatorData operatorData) {
-	logger := logr.FromContextOrDiscard(ctx)
+	logger := log.FromContext(ctx, log.ComponentOLM)
 
 	logger.V(log.DBG).Info("dumping data in artifacts/ directory")
+	events.EmitPhase(ctx, p.Name(), "cleaning up")
 
 	subs, err := p.openshiftClient.GetSubscription(ctx, operatorData.App, operatorData.InstallNamespace)
 	if err != nil {
@@ -551,6 +576,20 @@ func (p *DeployableByOlmCheck) cleanUp(ctx context.Context, operatorData operato
 		if err != nil {
 			logger.Error(err, "could not write subscription to storage")
 		}
+
+		var installPlan *operatorsv1alpha1.InstallPlan
+		if ref := subs.Status.InstallPlanRef; ref != nil {
+			if installPlan, err = p.openshiftClient.GetInstallPlan(ctx, ref.Name, ref.Namespace); err != nil {
+				logger.Info("warning: unable to retrieve the installplan")
+				installPlan = nil
+			} else if err := p.writeToFile(ctx, installPlan); err != nil {
+				logger.Error(err, "could not write installplan to storage")
+			}
+		}
+
+		if err := p.writeResolvedImages(ctx, operatorData, subs, installPlan); err != nil {
+			logger.Error(err, "could not write resolved images to storage")
+		}
 	}
 
 	cs, err := p.openshiftClient.GetCatalogSource(ctx, operatorData.App, operatorData.InstallNamespace)
@@ -632,6 +671,9 @@ func (p *DeployableByOlmCheck) writeToFile(ctx context.Context, data interface{}
 	case *operatorsv1alpha1.Subscription:
 		version = "v1alpha1"
 		kind = "Subscription"
+	case *operatorsv1alpha1.InstallPlan:
+		version = "v1alpha1"
+		kind = "InstallPlan"
 	case *corev1.Namespace:
 		group = ""
 		version = "v1"
@@ -672,6 +714,17 @@ func (p *DeployableByOlmCheck) getImages(ctx context.Context) (map[string]struct
 	return p.openshiftClient.GetImages(ctx)
 }
 
+// Retryable returns true, because the operator is deployed in a cluster, and may fail to
+// be deployed because of it.
+func (p *DeployableByOlmCheck) Retryable() bool {
+	return true
+}
+
+// RequiresCluster returns true, because the operator is deployed in a cluster.
+func (p *DeployableByOlmCheck) RequiresCluster() bool {
+	return true
+}
+
 func (p *DeployableByOlmCheck) Name() string {
 	return "DeployableByOLM"
 }
@@ -691,3 +744,33 @@ func (p *DeployableByOlmCheck) Help() check.HelpText {
 		Suggestion: "Follow the guidelines on the operator-sdk website to learn how to package your operator https://sdk.operatorframework.io/docs/olm-integration/cli-overview/",
 	}
 }
+
+// Provenance returns the provenance of the results of the check: the bundle is deployed
+// by OLM on the cluster, and the digests of its images are resolved by their
+// registries.
+func (p *DeployableByOlmCheck) Provenance() check.Provenance {
+	return check.Provenance{
+		DataSources: []check.DataSource{check.DataSourceManifest, check.DataSourceRegistry, check.DataSourceCluster},
+	}
+}
+
+func (p *DeployableByOlmCheck) Explain() check.Explanation {
+	return check.Explanation{
+		Criteria: []string{
+			"The bundle passes the operator-sdk bundle validation.",
+			"The bundle is added to an index image, and the operator is installed from it by OLM into a new namespace of the cluster, with a CatalogSource, an OperatorGroup, and a Subscription to the channel of the bundle.",
+			"The ClusterServiceVersion of the bundle reaches the Succeeded phase before the timeout.",
+		},
+		CommonCauses: []string{
+			"The InstallModes of the ClusterServiceVersion do not support the OperatorGroup the operator is installed with.",
+			"The images of the operator cannot be pulled by the cluster, e.g. because they are private and no docker config was provided.",
+			"The deployments of the operator do not become ready, e.g. because the operator crashes, or its readiness probe fails.",
+			"The operator takes longer than the timeout to install.",
+		},
+		Remediation: []string{
+			"Install the bundle with operator-sdk run bundle, and inspect the events of the namespace, the InstallPlan, and the logs of the operator.",
+			"Pass a docker config with credentials for the private images, or make the images public.",
+			"Reduce the time the operator takes to become ready, e.g. the size of its images, or the initial delay of its readiness probe.",
+		},
+	}
+}
@@ -2,7 +2,10 @@ package operator
 
 import (
 	"context"
+	"encoding/json"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/artifacts"
@@ -12,6 +15,9 @@ import (
 	fakecranev1 "github.com/google/go-containerregistry/pkg/v1/fake"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	apiruntime "k8s.io/apimachinery/pkg/runtime"
 	crclient "sigs.k8s.io/controller-runtime/pkg/client"
@@ -24,6 +30,7 @@ var _ = Describe("DeployableByOLMCheck", func() {
 		imageRef             image.ImageReference
 		testcontext          context.Context
 		clientBuilder        *fake.ClientBuilder
+		artifactsDir         string
 	)
 
 	BeforeEach(func() {
@@ -37,7 +44,10 @@ var _ = Describe("DeployableByOLMCheck", func() {
 
 		now := metav1.Now()
 		og.Status.LastUpdated = &now
-		deployableByOLMCheck = *NewDeployableByOlmCheck("test_indeximage", "", "")
+		deployableByOLMCheck = *NewDeployableByOlmCheck("test_indeximage", "", "", nil)
+		deployableByOLMCheck.digest = func(ctx context.Context, image string) (string, error) {
+			return "sha256:" + strings.Repeat("a", 64), nil
+		}
 		scheme := apiruntime.NewScheme()
 		Expect(openshift.AddSchemes(scheme)).To(Succeed())
 		clientBuilder = fake.NewClientBuilder().
@@ -52,6 +62,7 @@ var _ = Describe("DeployableByOLMCheck", func() {
 		// Temp artifacts dir
 		tmpDir, err := os.MkdirTemp("", "deployable-by-olm-*")
 		Expect(err).ToNot(HaveOccurred())
+		artifactsDir = tmpDir
 		aw, err := artifacts.NewFilesystemWriter(artifacts.WithDirectory(tmpDir))
 		Expect(err).ToNot(HaveOccurred())
 		testcontext = artifacts.ContextWithWriter(context.Background(), aw)
@@ -141,6 +152,56 @@ var _ = Describe("DeployableByOLMCheck", func() {
 				Expect(ok).To(BeTrue())
 			})
 		})
+		Context("When OLM resolved the operator with an InstallPlan", func() {
+			BeforeEach(func() {
+				installPlan := operatorsv1alpha1.InstallPlan{
+					ObjectMeta: metav1.ObjectMeta{Name: "install-abcde", Namespace: "testPackage"},
+					Status: operatorsv1alpha1.InstallPlanStatus{
+						BundleLookups: []operatorsv1alpha1.BundleLookup{{Path: "quay.io/example/bundle:v0.0.1"}},
+					},
+				}
+				installedSub := sub.DeepCopy()
+				installedSub.Status.InstallPlanRef = &corev1.ObjectReference{Name: installPlan.Name, Namespace: installPlan.Namespace}
+				installedCSV := csv.DeepCopy()
+				installedCSV.Namespace = "testPackage"
+				installedCSV.Spec.RelatedImages = []operatorsv1alpha1.RelatedImage{
+					{Name: "operand", Image: "quay.io/example/operand@sha256:" + strings.Repeat("b", 64)},
+				}
+				installedCSV.Spec.InstallStrategy.StrategySpec.DeploymentSpecs = []operatorsv1alpha1.StrategyDeploymentSpec{{
+					Name: "controller-manager",
+					Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "manager", Image: "quay.io/example/operator:v0.0.1"}},
+					}}},
+				}}
+
+				scheme := apiruntime.NewScheme()
+				Expect(openshift.AddSchemes(scheme)).To(Succeed())
+				deployableByOLMCheck.client = fake.NewClientBuilder().
+					WithScheme(scheme).
+					WithObjects(&csv, &csvDefault, &csvMarketplace, &ns, &secret, installedSub, &og, &installPlan, installedCSV).
+					WithLists(&pods, &isList).
+					Build()
+			})
+			It("Should write the InstallPlan and the images OLM resolved", func() {
+				ok, err := deployableByOLMCheck.Validate(testcontext, imageRef)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ok).To(BeTrue())
+
+				Expect(filepath.Join(artifactsDir, "install-abcde-InstallPlan.json")).To(BeAnExistingFile())
+				b, err := os.ReadFile(filepath.Join(artifactsDir, ResolvedImagesFilename))
+				Expect(err).ToNot(HaveOccurred())
+				var resolved resolvedImages
+				Expect(json.Unmarshal(b, &resolved)).To(Succeed())
+				Expect(resolved.InstallPlan).To(Equal("install-abcde"))
+				Expect(resolved.CSV).To(Equal("csv-v0.0.0"))
+				Expect(resolved.Images).To(ConsistOf(
+					resolvedImage{Image: "quay.io/example/bundle:v0.0.1", Digest: "sha256:" + strings.Repeat("a", 64), Sources: []string{"bundle"}},
+					resolvedImage{Image: "quay.io/example/operand@sha256:" + strings.Repeat("b", 64), Digest: "sha256:" + strings.Repeat("b", 64), Sources: []string{"relatedImages"}},
+					resolvedImage{Image: "quay.io/example/operator:v0.0.1", Digest: "sha256:" + strings.Repeat("a", 64), Sources: []string{"deployment/controller-manager/manager"}},
+					resolvedImage{Image: "test_indeximage", Digest: "sha256:" + strings.Repeat("a", 64), Sources: []string{"catalog"}},
+				))
+			})
+		})
 		Context("When the non-default channel is being tested", func() {
 			BeforeEach(func() {
 				deployableByOLMCheck.channel = "non-default-channel"
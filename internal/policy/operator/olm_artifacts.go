@@ -0,0 +1,130 @@
+package operator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/artifacts"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/authn"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/log"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/transport"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ResolvedImagesFilename is the artifact that lists the images OLM pulled to install
+// the operator, and their digests.
+const ResolvedImagesFilename = "olm-resolved-images.json"
+
+// resolvedImages are the images OLM pulled to install an operator, which must be
+// mirrored to install it in a disconnected cluster.
+type resolvedImages struct {
+	// InstallPlan is the name of the InstallPlan OLM resolved the operator with.
+	InstallPlan string `json:"install_plan,omitempty"`
+	// CSV is the name of the ClusterServiceVersion OLM installed.
+	CSV    string          `json:"csv,omitempty"`
+	Images []resolvedImage `json:"images"`
+}
+
+// resolvedImage is an image OLM pulled to install an operator.
+type resolvedImage struct {
+	Image string `json:"image"`
+	// Digest is the digest of the image, or empty if it could not be resolved.
+	Digest string `json:"digest,omitempty"`
+	// Sources are where the image is referenced, e.g. catalog, bundle, relatedImages,
+	// or deployment/<name>/<container>.
+	Sources []string `json:"sources"`
+}
+
+// writeResolvedImages writes the images OLM pulled to install the operator subscribed
+// to by sub, and their digests, to the ResolvedImagesFilename artifact. installPlan may
+// be nil if it could not be retrieved.
+func (p *DeployableByOlmCheck) writeResolvedImages(ctx context.Context, operatorData operatorData, sub *operatorsv1alpha1.Subscription, installPlan *operatorsv1alpha1.InstallPlan) error {
+	logger := log.FromContext(ctx, log.ComponentOLM)
+
+	resolved := resolvedImages{CSV: sub.Status.InstalledCSV}
+	sources := map[string][]string{}
+	add := func(image, source string) {
+		if image != "" {
+			sources[image] = append(sources[image], source)
+		}
+	}
+
+	add(operatorData.CatalogImage, "catalog")
+	if installPlan != nil {
+		resolved.InstallPlan = installPlan.Name
+		for _, lookup := range installPlan.Status.BundleLookups {
+			add(lookup.Path, "bundle")
+		}
+	}
+
+	if resolved.CSV != "" {
+		csv, err := p.openshiftClient.GetCSV(ctx, resolved.CSV, operatorData.InstallNamespace)
+		if err != nil {
+			logger.Info("warning: unable to retrieve the installed csv, its images are not listed", "csv", resolved.CSV, "error", err.Error())
+		} else {
+			for _, related := range csv.Spec.RelatedImages {
+				add(related.Image, "relatedImages")
+			}
+			for _, dep := range csv.Spec.InstallStrategy.StrategySpec.DeploymentSpecs {
+				podSpec := dep.Spec.Template.Spec
+				for _, container := range append(append([]corev1.Container{}, podSpec.InitContainers...), podSpec.Containers...) {
+					add(container.Image, fmt.Sprintf("deployment/%s/%s", dep.Name, container.Name))
+				}
+			}
+		}
+	}
+
+	resolved.Images = make([]resolvedImage, 0, len(sources))
+	for image, imageSources := range sources {
+		digest, err := p.imageDigest(ctx, image)
+		if err != nil {
+			logger.Info("warning: unable to resolve the digest of image", "image", image, "error", err.Error())
+		}
+		resolved.Images = append(resolved.Images, resolvedImage{Image: image, Digest: digest, Sources: imageSources})
+	}
+	sort.Slice(resolved.Images, func(i, j int) bool {
+		return resolved.Images[i].Image < resolved.Images[j].Image
+	})
+
+	b, err := json.MarshalIndent(resolved, "", "    ")
+	if err != nil {
+		return fmt.Errorf("unable to marshal resolved images to json: %w", err)
+	}
+	if artifactWriter := artifacts.WriterFromContext(ctx); artifactWriter != nil {
+		if _, err := artifactWriter.WriteFile(ResolvedImagesFilename, bytes.NewReader(b)); err != nil {
+			return fmt.Errorf("failed to write resolved images: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// imageDigest returns the digest of image, from its reference if it is referenced by
+// digest, or from its registry otherwise.
+func (p *DeployableByOlmCheck) imageDigest(ctx context.Context, image string) (string, error) {
+	if ref, err := name.NewDigest(image); err == nil {
+		return ref.DigestStr(), nil
+	}
+	if p.digest != nil {
+		return p.digest(ctx, image)
+	}
+
+	options := []crane.Option{
+		crane.WithContext(ctx),
+		crane.WithAuthFromKeychain(authn.PreflightKeychain(ctx, authn.WithDockerConfig(p.dockerConfig))),
+	}
+	if transport.IsConfigured(ctx) {
+		options = append(options, crane.WithTransport(transport.Transport(ctx, remote.DefaultTransport.(*http.Transport))))
+	}
+
+	return crane.Digest(image, options...)
+}
@@ -94,3 +94,11 @@ func (p *RelatedImagesCheck) Help() check.HelpText {
 		Suggestion: "Either manually or with a tool, populate the RelatedImages section of the CSV",
 	}
 }
+
+// Provenance returns the provenance of the results of the check: the images are read
+// from the manifests of the bundle.
+func (p *RelatedImagesCheck) Provenance() check.Provenance {
+	return check.Provenance{
+		DataSources: []check.DataSource{check.DataSourceManifest},
+	}
+}
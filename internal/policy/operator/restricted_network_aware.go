@@ -97,3 +97,13 @@ func (p FollowsRestrictedNetworkEnablementGuidelines) Help() check.HelpText {
 		Suggestion: "If consumers of your operator may need to do so on a restricted network, implement the guidelines outlines in OCP documentation for your cluster version, such as https://docs.openshift.com/container-platform/4.11/operators/operator_sdk/osdk-generating-csvs.html#olm-enabling-operator-for-restricted-network_osdk-generating-csvs for OCP 4.11",
 	}
 }
+
+// Provenance returns the provenance of the results of the check: the CSV is read from
+// the bundle. Passing related images in RELATED_IMAGE_ environment variables is a
+// convention the operator is not required to follow, so the confidence is medium.
+func (p FollowsRestrictedNetworkEnablementGuidelines) Provenance() check.Provenance {
+	return check.Provenance{
+		DataSources: []check.DataSource{check.DataSourceManifest},
+		Confidence:  check.ConfidenceMedium,
+	}
+}
@@ -95,3 +95,11 @@ func (p *securityContextConstraintsInCSV) Help() check.HelpText {
 		Suggestion: "If no scc is detected the default restricted scc will be used.",
 	}
 }
+
+// Provenance returns the provenance of the results of the check: the requested SCCs are
+// read from the CSV of the bundle.
+func (p *securityContextConstraintsInCSV) Provenance() check.Provenance {
+	return check.Provenance{
+		DataSources: []check.DataSource{check.DataSourceManifest},
+	}
+}
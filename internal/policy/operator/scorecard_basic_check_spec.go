@@ -5,13 +5,17 @@ import (
 	"fmt"
 
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/events"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/image"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/log"
 
 	"github.com/go-logr/logr"
 )
 
-var _ check.Check = &ScorecardBasicSpecCheck{}
+var (
+	_ check.Check     = &ScorecardBasicSpecCheck{}
+	_ check.Retryable = &ScorecardBasicSpecCheck{}
+)
 
 // ScorecardBasicSpecCheck evaluates the image to ensure it passes the operator-sdk
 // scorecard check with the basic-check-spec-test suite selected.
@@ -40,6 +44,9 @@ func (p *ScorecardBasicSpecCheck) Validate(ctx context.Context, bundleRef image.
 	logger.V(log.TRC).Info("running operator-sdk scorecard check", "image", bundleRef.ImageURI)
 
 	selector := []string{"test=basic-check-spec-test"}
+	events.EmitPhase(ctx, p.Name(), "waiting on operator-sdk scorecard")
+	// The check may be executed again after a fatal error.
+	p.fatalError = false
 	scorecardReport, err := p.getDataToValidate(ctx, bundleRef.ImageFSPath, selector, scorecardBasicCheckResult)
 	if err != nil {
 		p.fatalError = true
@@ -75,3 +82,20 @@ func (p *ScorecardBasicSpecCheck) Help() check.HelpText {
 		Suggestion: "Make sure that all CRs have a spec block",
 	}
 }
+
+func (p *ScorecardBasicSpecCheck) Explain() check.Explanation {
+	return check.Explanation{
+		Criteria: []string{
+			"The operator-sdk scorecard basic-check-spec-test runs in the cluster against the bundle.",
+			"Each custom resource of the alm-examples annotation of the ClusterServiceVersion has a spec block.",
+		},
+		CommonCauses: []string{
+			"An example custom resource of the alm-examples annotation has no spec, or an empty one.",
+			"The scorecard tests time out, e.g. because the images of the tests cannot be pulled by the cluster.",
+		},
+		Remediation: []string{
+			"Add a spec block to each example custom resource of the alm-examples annotation.",
+			"Run operator-sdk scorecard with --selector=test=basic-check-spec-test to reproduce the result, and increase the wait time with --scorecard-wait-time if the tests time out.",
+		},
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/operatorsdk"
 
 	"github.com/go-logr/logr"
@@ -57,3 +58,22 @@ func (p *scorecardCheck) getDataToValidate(ctx context.Context, bundleImage stri
 	}
 	return result, nil
 }
+
+// Retryable returns true, because scorecard tests are executed in a cluster, and may fail
+// because of it.
+func (p *scorecardCheck) Retryable() bool {
+	return true
+}
+
+// RequiresCluster returns true, because scorecard tests are executed in a cluster.
+func (p *scorecardCheck) RequiresCluster() bool {
+	return true
+}
+
+// Provenance returns the provenance of the results of the check: scorecard tests the
+// bundle on the cluster.
+func (p *scorecardCheck) Provenance() check.Provenance {
+	return check.Provenance{
+		DataSources: []check.DataSource{check.DataSourceManifest, check.DataSourceCluster},
+	}
+}
@@ -5,13 +5,17 @@ import (
 	"fmt"
 
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/events"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/image"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/log"
 
 	"github.com/go-logr/logr"
 )
 
-var _ check.Check = &ScorecardOlmSuiteCheck{}
+var (
+	_ check.Check     = &ScorecardOlmSuiteCheck{}
+	_ check.Retryable = &ScorecardOlmSuiteCheck{}
+)
 
 // ScorecardOlmSuiteCheck evaluates the image to ensure it passes the operator-sdk
 // scorecard check with the olm suite selected.
@@ -40,6 +44,9 @@ func (p *ScorecardOlmSuiteCheck) Validate(ctx context.Context, bundleRef image.I
 	logger.V(log.TRC).Info("running operator-sdk scorecard check", "image", bundleRef.ImageURI)
 
 	selector := []string{"suite=olm"}
+	events.EmitPhase(ctx, p.Name(), "waiting on operator-sdk scorecard")
+	// The check may be executed again after a fatal error.
+	p.fatalError = false
 	scorecardReport, err := p.getDataToValidate(ctx, bundleRef.ImageFSPath, selector, scorecardOlmSuiteResult)
 	if err != nil {
 		p.fatalError = true
@@ -0,0 +1,119 @@
+package operator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/artifacts"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/bundle"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/image"
+
+	"github.com/go-logr/logr"
+)
+
+// RemovedAPIsFilename is the name of the artifact that lists the removed APIs used by
+// the bundle.
+const RemovedAPIsFilename = "removed-apis.json"
+
+var _ check.Check = &SupportedAPIsCheck{}
+
+// SupportedAPIsCheck evaluates the manifests of the bundle, and ensures that they do not
+// use APIs that were removed in the OpenShift versions the bundle targets, which would
+// make installing the bundle fail on them.
+type SupportedAPIsCheck struct{}
+
+func NewSupportedAPIsCheck() *SupportedAPIsCheck {
+	return &SupportedAPIsCheck{}
+}
+
+func (p *SupportedAPIsCheck) Validate(ctx context.Context, bundleRef image.ImageReference) (bool, error) {
+	removed, err := p.dataToValidate(ctx, bundleRef.ImageFSPath)
+	if err != nil {
+		return false, err
+	}
+
+	return p.validate(ctx, removed)
+}
+
+func (p *SupportedAPIsCheck) dataToValidate(ctx context.Context, imagePath string) ([]bundle.RemovedAPI, error) {
+	removed, err := bundle.GetRemovedAPIs(ctx, imagePath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find the APIs used by the bundle: %w", err)
+	}
+
+	return removed, nil
+}
+
+func (p *SupportedAPIsCheck) validate(ctx context.Context, removed []bundle.RemovedAPI) (bool, error) {
+	logger := logr.FromContextOrDiscard(ctx)
+
+	if len(removed) == 0 {
+		return true, nil
+	}
+
+	for _, r := range removed {
+		logger.Error(errors.New("removed API"), r.String())
+	}
+
+	b, err := json.MarshalIndent(removed, "", "    ")
+	if err != nil {
+		return false, fmt.Errorf("unable to marshal removed APIs to json: %w", err)
+	}
+	if artifactWriter := artifacts.WriterFromContext(ctx); artifactWriter != nil {
+		if _, err := artifactWriter.WriteFile(RemovedAPIsFilename, bytes.NewReader(b)); err != nil {
+			return false, fmt.Errorf("failed to write removed APIs: %w", err)
+		}
+	}
+
+	return false, nil
+}
+
+func (p *SupportedAPIsCheck) Name() string {
+	return "BundleUsesSupportedAPIs"
+}
+
+func (p *SupportedAPIsCheck) Metadata() check.Metadata {
+	return check.Metadata{
+		Description:      "Checking that the bundle does not use APIs, such as v1beta1 CustomResourceDefinitions or PodSecurityPolicies, that were removed in the OpenShift versions it targets",
+		Level:            "best",
+		KnowledgeBaseURL: "https://kubernetes.io/docs/reference/using-api/deprecation-guide/",
+		CheckURL:         "https://kubernetes.io/docs/reference/using-api/deprecation-guide/",
+	}
+}
+
+func (p *SupportedAPIsCheck) Help() check.HelpText {
+	return check.HelpText{
+		Message: "Check BundleUsesSupportedAPIs encountered an error. Please review the preflight.log file, and the " +
+			RemovedAPIsFilename + " artifact, for the resources that use removed APIs.",
+		Suggestion: "Migrate the resources to the APIs that replace the removed ones, or limit the OpenShift versions the bundle targets " +
+			"with the com.redhat.openshift.versions annotation to those that serve the APIs.",
+	}
+}
+
+// Provenance returns the provenance of the results of the check: the APIs are read from
+// the manifests of the bundle.
+func (p *SupportedAPIsCheck) Provenance() check.Provenance {
+	return check.Provenance{
+		DataSources: []check.DataSource{check.DataSourceManifest},
+	}
+}
+
+func (p *SupportedAPIsCheck) Explain() check.Explanation {
+	return check.Explanation{
+		Criteria: []string{
+			"None of the manifests of the bundle uses an API that was removed in one of the OpenShift versions the bundle targets with the com.redhat.openshift.versions annotation.",
+		},
+		CommonCauses: []string{
+			"The bundle still ships resources, e.g. PodDisruptionBudgets or CronJobs, with beta APIs that newer versions of Kubernetes no longer serve.",
+			"The com.redhat.openshift.versions annotation has no upper bound, so it targets versions that removed the APIs.",
+		},
+		Remediation: []string{
+			"Migrate the resources listed in the " + RemovedAPIsFilename + " artifact to the APIs that replace the removed ones.",
+			"Limit the OpenShift versions the bundle targets, e.g. com.redhat.openshift.versions: v4.8-v4.10, to those that serve the APIs.",
+		},
+	}
+}
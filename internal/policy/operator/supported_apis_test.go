@@ -0,0 +1,78 @@
+package operator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/artifacts"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/image"
+)
+
+var _ = Describe("SupportedAPIs", func() {
+	var (
+		supportedAPIsCheck *SupportedAPIsCheck
+		imageRef           image.ImageReference
+		artifactsDir       string
+		ctx                context.Context
+	)
+
+	writeManifest := func(contents string) {
+		Expect(os.WriteFile(filepath.Join(imageRef.ImageFSPath, "manifests", "manifest.yaml"), []byte(contents), 0o644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		supportedAPIsCheck = NewSupportedAPIsCheck()
+		imageRef.ImageFSPath = GinkgoT().TempDir()
+		Expect(os.MkdirAll(filepath.Join(imageRef.ImageFSPath, "manifests"), 0o755)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(imageRef.ImageFSPath, "metadata"), 0o755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(imageRef.ImageFSPath, "metadata", "annotations.yaml"),
+			[]byte("annotations:\n  com.redhat.openshift.versions: v4.10\n"), 0o644)).To(Succeed())
+
+		artifactsDir = GinkgoT().TempDir()
+		writer, err := artifacts.NewFilesystemWriter(artifacts.WithDirectory(artifactsDir))
+		Expect(err).ToNot(HaveOccurred())
+		ctx = artifacts.ContextWithWriter(context.TODO(), writer)
+	})
+
+	When("the bundle uses only supported APIs", func() {
+		It("should succeed", func() {
+			writeManifest("apiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\nmetadata:\n  name: memcacheds.cache.example.com\n")
+			ok, err := supportedAPIsCheck.Validate(ctx, imageRef)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ok).To(BeTrue())
+			Expect(filepath.Join(artifactsDir, RemovedAPIsFilename)).ToNot(BeAnExistingFile())
+		})
+	})
+
+	When("the bundle uses a removed API", func() {
+		It("should fail, and record the resources that use it", func() {
+			writeManifest("apiVersion: apiextensions.k8s.io/v1beta1\nkind: CustomResourceDefinition\nmetadata:\n  name: memcacheds.cache.example.com\n")
+			ok, err := supportedAPIsCheck.Validate(ctx, imageRef)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ok).To(BeFalse())
+
+			b, err := os.ReadFile(filepath.Join(artifactsDir, RemovedAPIsFilename))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(b)).To(ContainSubstring(`"name": "memcacheds.cache.example.com"`))
+			Expect(string(b)).To(ContainSubstring(`"removedIn": "4.9"`))
+		})
+	})
+
+	When("the manifests cannot be read", func() {
+		It("should error", func() {
+			imageRef.ImageFSPath = filepath.Join(imageRef.ImageFSPath, "missing")
+			_, err := supportedAPIsCheck.Validate(ctx, imageRef)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	It("should have metadata and help", func() {
+		Expect(supportedAPIsCheck.Name()).To(Equal("BundleUsesSupportedAPIs"))
+		Expect(supportedAPIsCheck.Metadata().Level).To(Equal("best"))
+		Expect(supportedAPIsCheck.Help().Suggestion).To(ContainSubstring("com.redhat.openshift.versions"))
+	})
+})
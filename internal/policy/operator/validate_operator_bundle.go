@@ -70,3 +70,29 @@ func (p *ValidateOperatorBundleCheck) Help() check.HelpText {
 		Suggestion: "Valid bundles are defined by bundle spec, so make sure that this bundle conforms to that spec. More Information: https://github.com/operator-framework/operator-registry/blob/master/docs/design/operator-bundle.md",
 	}
 }
+
+// Provenance returns the provenance of the results of the check: the manifests of the
+// bundle are validated.
+func (p *ValidateOperatorBundleCheck) Provenance() check.Provenance {
+	return check.Provenance{
+		DataSources: []check.DataSource{check.DataSourceManifest},
+	}
+}
+
+func (p *ValidateOperatorBundleCheck) Explain() check.Explanation {
+	return check.Explanation{
+		Criteria: []string{
+			"The manifests and metadata of the bundle pass the operator-sdk bundle validation, i.e. they conform to the bundle format, and the ClusterServiceVersion and CustomResourceDefinitions are valid.",
+			"The bundle targets OpenShift versions, with the com.redhat.openshift.versions annotation, that serve the APIs it uses.",
+		},
+		CommonCauses: []string{
+			"metadata/annotations.yaml is missing, or its annotations do not match the labels of the bundle image.",
+			"The ClusterServiceVersion misses required fields, or references CustomResourceDefinitions that are not in the bundle.",
+			"The bundle uses APIs that were removed in the OpenShift versions it targets.",
+		},
+		Remediation: []string{
+			"Run operator-sdk bundle validate on the bundle, and fix the errors it reports.",
+			"Review preflight.log for the errors and warnings of the validation.",
+		},
+	}
+}
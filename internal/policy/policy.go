@@ -7,4 +7,5 @@ const (
 	PolicyContainer Policy = "container"
 	PolicyScratch   Policy = "scratch"
 	PolicyRoot      Policy = "root"
+	PolicyWindows   Policy = "windows"
 )
@@ -0,0 +1,43 @@
+package policy
+
+// Requirement is a certification program requirement, and the names of
+// the checks that verify it.
+type Requirement struct {
+	Description string
+	Checks      []string
+}
+
+// containerRequirements are the requirements verified by the container
+// policy, and its scratch and root exceptions.
+var containerRequirements = []Requirement{
+	{"The image includes the terms and conditions, including licensing information, of the software it contains", []string{"HasLicense"}},
+	{"The image is tagged with a unique tag, other than latest", []string{"HasUniqueTag"}},
+	{"The image has fewer than the maximum number of layers", []string{"LayerCountAcceptable"}},
+	{"The image does not redistribute prohibited Red Hat packages", []string{"HasNoProhibitedPackages"}},
+	{"The image declares the required labels", []string{"HasRequiredLabel"}},
+	{"The image runs as a non-root user", []string{"RunAsNonRoot"}},
+	{"The image does not modify content provided by Red Hat packages", []string{"HasModifiedFiles"}},
+	{"The image is based on a Red Hat Universal Base Image", []string{"BasedOnUbi"}},
+	{"The image has no paths that differ only by case", []string{"HasNoCaseCollidingFiles"}},
+}
+
+// operatorRequirements are the requirements verified by the operator policy.
+var operatorRequirements = []Requirement{
+	{"The operator bundle is valid", []string{"ValidateOperatorBundle", "ScorecardBasicSpecCheck", "ScorecardOlmSuiteCheck", "BasicOperabilityCheck"}},
+	{"The operator can be deployed by Operator Lifecycle Manager", []string{"DeployableByOLM"}},
+	{"The operator bundle uses only APIs served by the OpenShift versions it targets", []string{"BundleUsesSupportedAPIs"}},
+	{"The operator bundle references only certified images", []string{"BundleImageRefsAreCertified"}},
+	{"All images used by the operator are listed in relatedImages", []string{"AllImageRefsInRelatedImages"}},
+	{"The operator declares the SecurityContextConstraints it requires", []string{"SecurityContextConstraintsInCSV"}},
+	{"The operator follows the restricted network enablement guidelines", []string{"FollowsRestrictedNetworkEnablementGuidelines"}},
+}
+
+// Requirements returns the certification requirements verified by all
+// policies, in the order in which they should be presented.
+func Requirements() []Requirement {
+	reqs := make([]Requirement, 0, len(containerRequirements)+len(operatorRequirements))
+	reqs = append(reqs, containerRequirements...)
+	reqs = append(reqs, operatorRequirements...)
+
+	return reqs
+}
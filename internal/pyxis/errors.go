@@ -2,4 +2,7 @@ package pyxis
 
 import "errors"
 
-var ErrPyxis409StatusCode = errors.New("pyxis API returned a conflict")
+var (
+	ErrPyxis409StatusCode = errors.New("pyxis API returned a conflict")
+	ErrImageNotFound      = errors.New("image was not found in the certification project")
+)
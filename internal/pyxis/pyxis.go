@@ -8,10 +8,10 @@ import (
 	"io"
 	"net/http"
 
-	"github.com/go-logr/logr"
 	"github.com/shurcooL/graphql"
 
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/log"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/tracing"
 )
 
 const (
@@ -27,6 +27,8 @@ type pyxisClient struct {
 	ProjectID string
 	Client    HTTPClient
 	PyxisHost string
+
+	maxQPS float64
 }
 
 func (p *pyxisClient) getPyxisURL(path string) string {
@@ -37,17 +39,33 @@ func (p *pyxisClient) getPyxisGraphqlURL() string {
 	return fmt.Sprintf("https://%s/graphql/", p.PyxisHost)
 }
 
-func NewPyxisClient(pyxisHost string, apiToken string, projectID string, httpClient HTTPClient) *pyxisClient {
-	return &pyxisClient{
+// NewPyxisClient returns a client for the Pyxis API at pyxisHost. If httpClient is an
+// *http.Client, requests that are rate limited by Pyxis are retried after the delay
+// Pyxis requests, requests are limited to the rate set by WithMaxQPS, if any, and
+// each attempt of a request is traced.
+func NewPyxisClient(pyxisHost string, apiToken string, projectID string, httpClient HTTPClient, opts ...Option) *pyxisClient {
+	p := &pyxisClient{
 		APIToken:  apiToken,
 		ProjectID: projectID,
 		Client:    httpClient,
 		PyxisHost: pyxisHost,
 	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	if hc, ok := httpClient.(*http.Client); ok {
+		rateLimited := *hc
+		rateLimited.Transport = newRateLimitTransport(tracing.Transport(hc.Transport, "pyxis"), p.maxQPS)
+		p.Client = &rateLimited
+	}
+
+	return p
 }
 
 func (p *pyxisClient) createImage(ctx context.Context, certImage *CertImage) (*CertImage, error) {
-	logger := logr.FromContextOrDiscard(ctx)
+	logger := log.FromContext(ctx, log.ComponentPyxis)
 	b, err := json.Marshal(certImage)
 	if err != nil {
 		return nil, fmt.Errorf("could not marshal certImage: %w", err)
@@ -91,7 +109,7 @@ func (p *pyxisClient) createImage(ctx context.Context, certImage *CertImage) (*C
 }
 
 func (p *pyxisClient) getImage(ctx context.Context, dockerImageDigest string) (*CertImage, error) {
-	logger := logr.FromContextOrDiscard(ctx)
+	logger := log.FromContext(ctx, log.ComponentPyxis)
 	req, err := p.newRequestWithAPIToken(ctx, http.MethodGet,
 		p.getPyxisURL(fmt.Sprintf("projects/certification/id/%s/images?filter=docker_image_digest==%s", p.ProjectID, dockerImageDigest)), nil)
 	if err != nil {
@@ -128,6 +146,10 @@ func (p *pyxisClient) getImage(ctx context.Context, dockerImageDigest string) (*
 		return nil, fmt.Errorf("could not unmarshal body: %s: %w", string(body), err)
 	}
 
+	if len(data.Data) == 0 {
+		return nil, ErrImageNotFound
+	}
+
 	return &data.Data[0], nil
 }
 
@@ -236,7 +258,7 @@ func (p *pyxisClient) FindImagesByDigest(ctx context.Context, digests []string)
 }
 
 func (p *pyxisClient) createRPMManifest(ctx context.Context, rpmManifest *RPMManifest) (*RPMManifest, error) {
-	logger := logr.FromContextOrDiscard(ctx)
+	logger := log.FromContext(ctx, log.ComponentPyxis)
 
 	b, err := json.Marshal(rpmManifest)
 	if err != nil {
@@ -281,7 +303,7 @@ func (p *pyxisClient) createRPMManifest(ctx context.Context, rpmManifest *RPMMan
 }
 
 func (p *pyxisClient) getRPMManifest(ctx context.Context, imageID string) (*RPMManifest, error) {
-	logger := logr.FromContextOrDiscard(ctx)
+	logger := log.FromContext(ctx, log.ComponentPyxis)
 
 	req, err := p.newRequestWithAPIToken(ctx, http.MethodGet, p.getPyxisURL(fmt.Sprintf("images/id/%s/rpm-manifest", imageID)), nil)
 	if err != nil {
@@ -318,7 +340,7 @@ func (p *pyxisClient) getRPMManifest(ctx context.Context, imageID string) (*RPMM
 }
 
 func (p *pyxisClient) GetProject(ctx context.Context) (*CertProject, error) {
-	logger := logr.FromContextOrDiscard(ctx)
+	logger := log.FromContext(ctx, log.ComponentPyxis)
 
 	req, err := p.newRequestWithAPIToken(ctx, http.MethodGet, p.getPyxisURL(fmt.Sprintf("projects/certification/id/%s", p.ProjectID)), nil)
 	if err != nil {
@@ -355,7 +377,7 @@ func (p *pyxisClient) GetProject(ctx context.Context) (*CertProject, error) {
 }
 
 func (p *pyxisClient) updateProject(ctx context.Context, certProject *CertProject) (*CertProject, error) {
-	logger := logr.FromContextOrDiscard(ctx)
+	logger := log.FromContext(ctx, log.ComponentPyxis)
 
 	// We cannot send the project type or container type
 	// to pyxis in a Patch. Copy the CertProject and strip type
@@ -446,7 +468,7 @@ func (p *pyxisClient) createTestResults(ctx context.Context, testResults *TestRe
 }
 
 func (p *pyxisClient) createArtifact(ctx context.Context, artifact *Artifact) (*Artifact, error) {
-	logger := logr.FromContextOrDiscard(ctx)
+	logger := log.FromContext(ctx, log.ComponentPyxis)
 
 	b, err := json.Marshal(artifact)
 	if err != nil {
@@ -492,11 +514,43 @@ func (p *pyxisClient) newRequestWithAPIToken(ctx context.Context, method string,
 		return nil, err
 	}
 
+	if source, ok := AccessTokenSourceFromContext(ctx); ok {
+		token, err := source.AccessToken(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("could not get an access token for pyxis: %w", err)
+		}
+		req.Header.Add("Authorization", "Bearer "+token)
+
+		return req, nil
+	}
+
 	req.Header.Add("X-API-KEY", p.APIToken)
 
 	return req, nil
 }
 
+// AccessTokenSource returns short-lived access tokens that authenticate with Pyxis,
+// instead of an API token.
+type AccessTokenSource interface {
+	AccessToken(ctx context.Context) (string, error)
+}
+
+type contextKey string
+
+const accessTokenSourceContextKey contextKey = "PyxisAccessTokenSource"
+
+// ContextWithAccessTokenSource returns a copy of ctx in which requests to Pyxis are
+// authenticated with the access tokens returned by source, instead of an API token.
+func ContextWithAccessTokenSource(ctx context.Context, source AccessTokenSource) context.Context {
+	return context.WithValue(ctx, accessTokenSourceContextKey, source)
+}
+
+// AccessTokenSourceFromContext returns the AccessTokenSource in ctx, and whether there was one.
+func AccessTokenSourceFromContext(ctx context.Context) (AccessTokenSource, bool) {
+	source, ok := ctx.Value(accessTokenSourceContextKey).(AccessTokenSource)
+	return source, ok && source != nil
+}
+
 func (p *pyxisClient) newRequest(ctx context.Context, method string, url string, body io.Reader) (*http.Request, error) {
 	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
@@ -2,6 +2,7 @@ package pyxis
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
 	. "github.com/onsi/ginkgo/v2/dsl/core"
@@ -38,4 +39,36 @@ var _ = Describe("Pyxis", func() {
 			})
 		})
 	})
+	Context("Authenticating requests", func() {
+		It("should use the API token", func() {
+			req, err := pyxisClient.newRequestWithAPIToken(ctx, http.MethodGet, pyxisClient.getPyxisURL("images"), nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(req.Header.Get("X-API-KEY")).To(Equal("my-spiffy-api-token"))
+			Expect(req.Header.Get("Authorization")).To(BeEmpty())
+		})
+
+		It("should use an access token instead, if there is a source in the context", func() {
+			ctx := ContextWithAccessTokenSource(ctx, staticAccessTokenSource("my-access-token"))
+			req, err := pyxisClient.newRequestWithAPIToken(ctx, http.MethodGet, pyxisClient.getPyxisURL("images"), nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(req.Header.Get("Authorization")).To(Equal("Bearer my-access-token"))
+			Expect(req.Header.Get("X-API-KEY")).To(BeEmpty())
+		})
+
+		It("should fail if an access token cannot be returned", func() {
+			ctx := ContextWithAccessTokenSource(ctx, staticAccessTokenSource(""))
+			_, err := pyxisClient.newRequestWithAPIToken(ctx, http.MethodGet, pyxisClient.getPyxisURL("images"), nil)
+			Expect(err).To(HaveOccurred())
+		})
+	})
 })
+
+// staticAccessTokenSource returns itself as the access token, or an error if it is empty.
+type staticAccessTokenSource string
+
+func (s staticAccessTokenSource) AccessToken(context.Context) (string, error) {
+	if s == "" {
+		return "", errors.New("no access token")
+	}
+	return string(s), nil
+}
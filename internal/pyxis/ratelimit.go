@@ -0,0 +1,139 @@
+package pyxis
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/log"
+)
+
+const (
+	// maxRateLimitRetries is the number of times a request is retried after
+	// Pyxis responds that the client is being rate limited.
+	maxRateLimitRetries = 5
+	// maxRetryDelay bounds how long to wait before retrying a request, including
+	// a delay requested by Pyxis in a Retry-After header.
+	maxRetryDelay = 5 * time.Minute
+)
+
+type Option = func(*pyxisClient)
+
+// WithMaxQPS limits the requests made to Pyxis to qps requests per second.
+// A qps of 0 or less does not limit requests.
+func WithMaxQPS(qps float64) Option {
+	return func(p *pyxisClient) {
+		p.maxQPS = qps
+	}
+}
+
+// rateLimitTransport is an http.RoundTripper that limits the rate of requests,
+// and retries requests that are rate limited by the server after the delay
+// requested in the Retry-After header.
+type rateLimitTransport struct {
+	base http.RoundTripper
+	// limiter is nil if requests are not limited.
+	limiter    *rate.Limiter
+	maxRetries int
+	// sleep waits for d, or until ctx is done.
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+func newRateLimitTransport(base http.RoundTripper, qps float64) *rateLimitTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	t := &rateLimitTransport{
+		base:       base,
+		maxRetries: maxRateLimitRetries,
+		sleep:      sleepContext,
+	}
+
+	if qps > 0 {
+		t.limiter = rate.NewLimiter(rate.Limit(qps), 1)
+	}
+
+	return t
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	logger := log.FromContext(ctx, log.ComponentPyxis)
+
+	for attempt := 0; ; attempt++ {
+		if t.limiter != nil {
+			if err := t.limiter.Wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := t.base.RoundTrip(req)
+		if err != nil || resp.StatusCode != http.StatusTooManyRequests || attempt >= t.maxRetries {
+			return resp, err
+		}
+
+		// The request can only be sent again if its body can be.
+		retry := req
+		if req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				return resp, nil
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return resp, nil
+			}
+			retry = req.Clone(ctx)
+			retry.Body = body
+		}
+
+		delay := retryDelay(resp.Header.Get("Retry-After"), attempt, time.Now())
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		logger.V(log.DBG).Info("rate limited by pyxis, retrying", "url", req.URL, "delay", delay.String(), "attempt", attempt+1)
+		if err := t.sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+
+		req = retry
+	}
+}
+
+// retryDelay returns how long to wait before retrying a request, given the value of
+// the Retry-After header, which is either a number of seconds or an HTTP date. If the
+// header is missing or invalid, the delay doubles with each attempt, starting at one second.
+func retryDelay(retryAfter string, attempt int, now time.Time) time.Duration {
+	delay := time.Second << attempt
+
+	if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+		delay = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(retryAfter); err == nil {
+		delay = date.Sub(now)
+		if delay < 0 {
+			delay = 0
+		}
+	}
+
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+
+	return delay
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
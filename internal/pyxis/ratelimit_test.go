@@ -0,0 +1,137 @@
+package pyxis
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/ginkgo/v2/dsl/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Pyxis rate limiting", func() {
+	var (
+		responses []int
+		bodies    []string
+		delays    []time.Duration
+		transport *rateLimitTransport
+	)
+
+	BeforeEach(func() {
+		responses = nil
+		bodies = nil
+		delays = nil
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			// Requests are handled without a server, which would give them an empty body.
+			var body []byte
+			if r.Body != nil {
+				body, _ = io.ReadAll(r.Body)
+			}
+			bodies = append(bodies, string(body))
+			if len(bodies) <= len(responses) {
+				w.Header().Set("Retry-After", "7")
+				w.WriteHeader(responses[len(bodies)-1])
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		})
+
+		transport = newRateLimitTransport(localRoundTripper{handler: mux}, 0)
+		transport.sleep = func(_ context.Context, d time.Duration) error {
+			delays = append(delays, d)
+			return nil
+		}
+	})
+
+	do := func() *http.Response {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, "https://my.pyxis.host/v1/images", bytes.NewReader([]byte("payload")))
+		Expect(err).ToNot(HaveOccurred())
+		resp, err := transport.RoundTrip(req)
+		Expect(err).ToNot(HaveOccurred())
+		return resp
+	}
+
+	Context("when the request is rate limited", func() {
+		It("should retry after the requested delay with the same body", func() {
+			responses = []int{http.StatusTooManyRequests, http.StatusTooManyRequests}
+			resp := do()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(delays).To(Equal([]time.Duration{7 * time.Second, 7 * time.Second}))
+			Expect(bodies).To(Equal([]string{"payload", "payload", "payload"}))
+		})
+	})
+
+	Context("when the request is rate limited too many times", func() {
+		It("should return the rate limited response", func() {
+			responses = []int{429, 429, 429, 429, 429, 429, 429}
+			resp := do()
+			Expect(resp.StatusCode).To(Equal(http.StatusTooManyRequests))
+			Expect(delays).To(HaveLen(maxRateLimitRetries))
+		})
+	})
+
+	Context("when the request fails for another reason", func() {
+		It("should not retry", func() {
+			responses = []int{http.StatusServiceUnavailable}
+			resp := do()
+			Expect(resp.StatusCode).To(Equal(http.StatusServiceUnavailable))
+			Expect(delays).To(BeEmpty())
+		})
+	})
+
+	Context("when the context is cancelled while waiting", func() {
+		It("should return the context's error", func() {
+			responses = []int{http.StatusTooManyRequests}
+			transport.sleep = sleepContext
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://my.pyxis.host/v1/images", nil)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = transport.RoundTrip(req)
+			Expect(err).To(MatchError(context.Canceled))
+		})
+	})
+
+	Context("when a max QPS is set", func() {
+		It("should limit the rate of requests", func() {
+			transport.limiter = newRateLimitTransport(nil, 20).limiter
+			start := time.Now()
+			for i := 0; i < 3; i++ {
+				Expect(do().StatusCode).To(Equal(http.StatusOK))
+			}
+			Expect(time.Since(start)).To(BeNumerically(">=", 90*time.Millisecond))
+		})
+	})
+
+	Context("when creating a client", func() {
+		It("should wrap an http.Client's transport", func() {
+			client := NewPyxisClient("my.pyxis.host", "token", "project", &http.Client{}, WithMaxQPS(5))
+			hc, ok := client.Client.(*http.Client)
+			Expect(ok).To(BeTrue())
+			rt, ok := hc.Transport.(*rateLimitTransport)
+			Expect(ok).To(BeTrue())
+			Expect(rt.limiter).ToNot(BeNil())
+			Expect(float64(rt.limiter.Limit())).To(Equal(5.0))
+		})
+	})
+
+	DescribeTable("retryDelay",
+		func(retryAfter string, attempt int, expected time.Duration) {
+			now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+			Expect(retryDelay(retryAfter, attempt, now)).To(Equal(expected))
+		},
+		Entry("seconds", "3", 0, 3*time.Second),
+		Entry("HTTP date", "Sun, 01 Jan 2023 00:00:10 GMT", 0, 10*time.Second),
+		Entry("HTTP date in the past", "Sat, 31 Dec 2022 00:00:00 GMT", 0, time.Duration(0)),
+		Entry("missing, first attempt", "", 0, time.Second),
+		Entry("missing, third attempt", "", 2, 4*time.Second),
+		Entry("invalid", "soon", 1, 2*time.Second),
+		Entry("too long", "3600", 0, maxRetryDelay),
+	)
+})
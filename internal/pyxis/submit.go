@@ -13,40 +13,17 @@ var defaultRegistryAlias = "docker.io"
 // SubmitResults takes certInput and sends requests to Pyxis to create or update entries
 // based on certInput.
 func (p *pyxisClient) SubmitResults(ctx context.Context, certInput *CertificationInput) (*CertificationResults, error) {
-	var err error
+	if err := prepareSubmission(certInput); err != nil {
+		return nil, err
+	}
 
 	certProject := certInput.CertProject
 	certImage := certInput.CertImage
 
-	// Submission effectively starts the certification process, so switch
-	// the status to reflect this if needed. This only needs to be done for net new projects.
-	// Existing projects that are in "In Progress" can stay "In Progress" until they moved to "Published" which is triggered
-	// once an image in a project is moved to "Published" status. The status on the project would stay in "Published" status,
-	// unless the partner decides to un-publish all of their images. At that point backed systems/processes would move
-	// the project back to "In Process" and there would still be nothing that preflight need to update on the project.
-	if certProject.CertificationStatus == "Started" {
-		certProject.CertificationStatus = "In Progress"
-	}
-
-	// You must have an existing repository.
-	if len(certImage.Repositories) == 0 {
-		return nil, fmt.Errorf("certImage has not been properly populated")
-	}
-
-	// Set this project's metadata to match the image that we're certifying.
-	if certProject.Container.Registry == "" {
-		// normalizing index.docker.io to docker.io for the certProject
-		certProject.Container.Registry = normalizeDockerRegistry(certImage.Repositories[0].Registry)
-	}
-
-	if certProject.Container.Repository == "" {
-		certProject.Container.Repository = certImage.Repositories[0].Repository
-	}
-
 	// always update the project no matter the status to ensure the dockerconfig preflight used to pull the image
 	// is the dockerfile that resides on the project and other backend processes ie clair use the same file
 	// Note: users no longer have the ability to update their project's dockerconfig in connect
-	certProject, err = p.updateProject(ctx, certProject)
+	certProject, err := p.updateProject(ctx, certProject)
 	if err != nil {
 		return nil, fmt.Errorf("could not update project: %v", err)
 	}
@@ -58,9 +35,6 @@ func (p *pyxisClient) SubmitResults(ctx context.Context, certInput *Certificatio
 	// store the certification status for this execution, in case a previous execution failed and we need to patch the image
 	certified := certInput.CertImage.Certified
 
-	// normalizing index.docker.io to docker.io for the certImage
-	certImage.Repositories[0].Registry = normalizeDockerRegistry(certImage.Repositories[0].Registry)
-
 	// Create the image, or get it if it already exists.
 	certImage, err = p.createImage(ctx, certImage)
 	if err != nil {
@@ -125,6 +99,110 @@ func (p *pyxisClient) SubmitResults(ctx context.Context, certInput *Certificatio
 	}, nil
 }
 
+// SubmissionPlan contains the payloads that SubmitResults would send to Pyxis
+// for a given CertificationInput.
+type SubmissionPlan struct {
+	// ProjectUpdate is the project, as it would be updated.
+	ProjectUpdate *CertProject `json:"project_update"`
+	// ImageCreate is the image that would be created. It is nil if the image exists.
+	ImageCreate *CertImage `json:"image_create,omitempty"`
+	// ImageUpdate is the existing image, as it would be updated. It is nil if the
+	// image does not exist, or would not be updated.
+	ImageUpdate *CertImage `json:"image_update,omitempty"`
+	// RPMManifestCreate is the RPM manifest that would be created. It is nil if the
+	// image exists and already has an RPM manifest.
+	RPMManifestCreate *RPMManifest `json:"rpm_manifest_create,omitempty"`
+	// ArtifactsCreate are the artifacts that would be created.
+	ArtifactsCreate []Artifact `json:"artifacts_create"`
+	// TestResultsCreate are the test results that would be created.
+	TestResultsCreate *TestResults `json:"test_results_create"`
+}
+
+// PlanSubmission returns the payloads that SubmitResults would send to Pyxis for
+// certInput. Only read-only requests are made to Pyxis, to determine whether the
+// image and its RPM manifest already exist. The IDs of anything that would be
+// created are left empty.
+func (p *pyxisClient) PlanSubmission(ctx context.Context, certInput *CertificationInput) (*SubmissionPlan, error) {
+	if err := prepareSubmission(certInput); err != nil {
+		return nil, err
+	}
+
+	plan := SubmissionPlan{
+		ProjectUpdate:     certInput.CertProject,
+		ArtifactsCreate:   certInput.Artifacts,
+		TestResultsCreate: certInput.TestResults,
+	}
+
+	existing, err := p.getImage(ctx, certInput.CertImage.DockerImageDigest)
+	switch {
+	case errors.Is(err, ErrImageNotFound):
+		plan.ImageCreate = certInput.CertImage
+		plan.RPMManifestCreate = certInput.RpmManifest
+	case err != nil:
+		return nil, fmt.Errorf("could not get image: %v", err)
+	default:
+		if certInput.CertImage.Certified && !existing.Certified {
+			updated := *existing
+			updated.Certified = true
+			plan.ImageUpdate = &updated
+		}
+
+		if _, err := p.getRPMManifest(ctx, existing.ID); err != nil {
+			plan.RPMManifestCreate = certInput.RpmManifest
+		}
+	}
+
+	imageID := ""
+	if existing != nil {
+		imageID = existing.ID
+	}
+	if plan.RPMManifestCreate != nil {
+		plan.RPMManifestCreate.ImageID = imageID
+	}
+	for i := range plan.ArtifactsCreate {
+		plan.ArtifactsCreate[i].ImageID = imageID
+	}
+	plan.TestResultsCreate.ImageID = imageID
+
+	return &plan, nil
+}
+
+// prepareSubmission normalizes certInput as it will be submitted.
+func prepareSubmission(certInput *CertificationInput) error {
+	certProject := certInput.CertProject
+	certImage := certInput.CertImage
+
+	// Submission effectively starts the certification process, so switch
+	// the status to reflect this if needed. This only needs to be done for net new projects.
+	// Existing projects that are in "In Progress" can stay "In Progress" until they moved to "Published" which is triggered
+	// once an image in a project is moved to "Published" status. The status on the project would stay in "Published" status,
+	// unless the partner decides to un-publish all of their images. At that point backed systems/processes would move
+	// the project back to "In Process" and there would still be nothing that preflight need to update on the project.
+	if certProject.CertificationStatus == "Started" {
+		certProject.CertificationStatus = "In Progress"
+	}
+
+	// You must have an existing repository.
+	if len(certImage.Repositories) == 0 {
+		return fmt.Errorf("certImage has not been properly populated")
+	}
+
+	// Set this project's metadata to match the image that we're certifying.
+	if certProject.Container.Registry == "" {
+		// normalizing index.docker.io to docker.io for the certProject
+		certProject.Container.Registry = normalizeDockerRegistry(certImage.Repositories[0].Registry)
+	}
+
+	if certProject.Container.Repository == "" {
+		certProject.Container.Repository = certImage.Repositories[0].Repository
+	}
+
+	// normalizing index.docker.io to docker.io for the certImage
+	certImage.Repositories[0].Registry = normalizeDockerRegistry(certImage.Repositories[0].Registry)
+
+	return nil
+}
+
 // normalizeDockerRegistry sets registry to the value we get from certImage from crane and then normalizes
 // index.docker.io to docker.io so project/image info shows properly in the Red Hat Catalog and other backend systems (Clair)
 func normalizeDockerRegistry(registry string) string {
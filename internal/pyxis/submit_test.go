@@ -320,3 +320,102 @@ var _ = Describe("Pyxis Submit", func() {
 		})
 	})
 })
+
+var _ = Describe("Pyxis PlanSubmission", func() {
+	ctx := context.Background()
+
+	var pyxisClient *pyxisClient
+	var certInput CertificationInput
+	var methods []string
+	var existingImages string
+
+	BeforeEach(func() {
+		methods = []string{}
+		existingImages = `{"data":[]}`
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/api/v1/projects/certification/id/my-awesome-project-id/images", func(response http.ResponseWriter, request *http.Request) {
+			methods = append(methods, request.Method)
+			mustWrite(response, existingImages)
+		})
+		mux.HandleFunc("/api/v1/images/id/existing/rpm-manifest", func(response http.ResponseWriter, request *http.Request) {
+			methods = append(methods, request.Method)
+			mustWrite(response, `{"_id":"manifest"}`)
+		})
+		mux.HandleFunc("/", func(response http.ResponseWriter, request *http.Request) {
+			methods = append(methods, request.Method)
+			response.WriteHeader(http.StatusNotFound)
+		})
+
+		pyxisClient = NewPyxisClient(
+			"my.pyxis.host/api",
+			"my-spiffy-api-token",
+			"my-awesome-project-id",
+			&http.Client{Transport: localRoundTripper{handler: mux}},
+		)
+		certInput = CertificationInput{
+			CertProject: &CertProject{CertificationStatus: "Started"},
+			CertImage: &CertImage{
+				Certified: true,
+				Repositories: []Repository{
+					{
+						Registry:   "index.docker.io",
+						Repository: "my/repo",
+					},
+				},
+				DockerImageDigest: "sha256:deadb33f",
+			},
+			RpmManifest: &RPMManifest{},
+			TestResults: &TestResults{},
+			Artifacts:   []Artifact{{Filename: "preflight.log"}},
+		}
+	})
+
+	Context("when the image does not exist", func() {
+		It("should plan to create everything without mutating anything", func() {
+			plan, err := pyxisClient.PlanSubmission(ctx, &certInput)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(methods).To(Equal([]string{http.MethodGet}))
+
+			Expect(plan.ProjectUpdate.CertificationStatus).To(Equal("In Progress"))
+			Expect(plan.ProjectUpdate.Container.Registry).To(Equal("docker.io"))
+			Expect(plan.ProjectUpdate.Container.Repository).To(Equal("my/repo"))
+			Expect(plan.ImageCreate).To(Equal(certInput.CertImage))
+			Expect(plan.ImageUpdate).To(BeNil())
+			Expect(plan.RPMManifestCreate).ToNot(BeNil())
+			Expect(plan.ArtifactsCreate).To(HaveLen(1))
+			Expect(plan.TestResultsCreate).ToNot(BeNil())
+		})
+	})
+
+	Context("when the image and its RPM manifest exist", func() {
+		BeforeEach(func() {
+			existingImages = `{"data":[{"_id":"existing","certified":false}]}`
+		})
+
+		It("should plan to update the image and attach results to it", func() {
+			plan, err := pyxisClient.PlanSubmission(ctx, &certInput)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(methods).To(Equal([]string{http.MethodGet, http.MethodGet}))
+
+			Expect(plan.ImageCreate).To(BeNil())
+			Expect(plan.ImageUpdate).ToNot(BeNil())
+			Expect(plan.ImageUpdate.ID).To(Equal("existing"))
+			Expect(plan.ImageUpdate.Certified).To(BeTrue())
+			Expect(plan.RPMManifestCreate).To(BeNil())
+			Expect(plan.ArtifactsCreate[0].ImageID).To(Equal("existing"))
+			Expect(plan.TestResultsCreate.ImageID).To(Equal("existing"))
+		})
+	})
+
+	Context("when the image cannot be looked up", func() {
+		BeforeEach(func() {
+			pyxisClient.ProjectID = "some-other-project-id"
+		})
+
+		It("should throw an error", func() {
+			_, err := pyxisClient.PlanSubmission(ctx, &certInput)
+			Expect(err).To(MatchError(ContainSubstring("could not get image")))
+		})
+	})
+})
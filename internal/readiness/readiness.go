@@ -0,0 +1,211 @@
+// Package readiness probes the services that checks depend on, such as the registry,
+// Pyxis, and the cluster, so that preflight fails fast, with a report of every service
+// that is not ready, rather than when a check first uses it.
+package readiness
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-containerregistry/pkg/name"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/mirror"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/transport"
+)
+
+// DefaultTimeout is how long each probe waits for its service to respond.
+const DefaultTimeout = 10 * time.Second
+
+// Probe checks that a service is ready.
+type Probe struct {
+	// Service is the kind of service probed, e.g. registry.
+	Service string
+	// Target identifies the service probed, e.g. the host of the registry.
+	Target string
+
+	probe func(ctx context.Context) error
+}
+
+// Result is the outcome of a Probe.
+type Result struct {
+	Service string
+	Target  string
+	// Err is why the service is not ready, or nil if it is.
+	Err         error
+	ElapsedTime time.Duration
+}
+
+// Results are the outcomes of every Probe, in the order they were given.
+type Results []Result
+
+// Ready returns true if every service probed is ready.
+func (r Results) Ready() bool {
+	for _, result := range r {
+		if result.Err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// String returns a table of every service probed and whether it is ready.
+func (r Results) String() string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+	for _, result := range r {
+		status := fmt.Sprintf("ready (%s)", result.ElapsedTime.Round(time.Millisecond))
+		if result.Err != nil {
+			status = "NOT READY: " + result.Err.Error()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", result.Service, result.Target, status)
+	}
+	_ = w.Flush()
+
+	return b.String()
+}
+
+// Run executes probes concurrently, each waiting up to timeout for its service, and
+// reports their outcomes.
+func Run(ctx context.Context, timeout time.Duration, probes ...Probe) Results {
+	logger := logr.FromContextOrDiscard(ctx)
+
+	results := make(Results, len(probes))
+	var wg sync.WaitGroup
+	for i, p := range probes {
+		wg.Add(1)
+		go func(i int, p Probe) {
+			defer wg.Done()
+
+			probeCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := p.probe(probeCtx)
+			results[i] = Result{Service: p.Service, Target: p.Target, Err: err, ElapsedTime: time.Since(start)}
+		}(i, p)
+	}
+	wg.Wait()
+
+	for _, result := range results {
+		logger.Info("probed service", "service", result.Service, "target", result.Target, "ready", result.Err == nil)
+	}
+
+	return results
+}
+
+// Registry probes the registry that image is pulled from. If image is pulled from
+// mirrors, the registry is ready if the registry of any of the references image may be
+// pulled from responds.
+func Registry(image string, insecure bool, mirrors []mirror.Mirror) Probe {
+	target := image
+	if ref, err := name.ParseReference(image); err == nil {
+		target = ref.Context().RegistryStr()
+	}
+
+	return Probe{
+		Service: "registry",
+		Target:  target,
+		probe: func(ctx context.Context) error {
+			candidates, err := mirror.Candidates(image, mirrors)
+			if err != nil {
+				return err
+			}
+
+			var errs []string
+			for _, candidate := range candidates {
+				var opts []name.Option
+				if insecure {
+					opts = append(opts, name.Insecure)
+				}
+				ref, err := name.ParseReference(candidate, opts...)
+				if err != nil {
+					return fmt.Errorf("image uri could not be parsed: %w", err)
+				}
+
+				// The API version check responds to any client, if only to request
+				// authentication.
+				registry := ref.Context().Registry
+				err = get(ctx, fmt.Sprintf("%s://%s/v2/", registry.Scheme(), registry.RegistryStr()))
+				if err == nil {
+					return nil
+				}
+				errs = append(errs, err.Error())
+			}
+
+			return errors.New(strings.Join(errs, "; "))
+		},
+	}
+}
+
+// Pyxis probes the Pyxis API at host, which includes the path to the API.
+func Pyxis(host string) Probe {
+	return Probe{
+		Service: "pyxis",
+		Target:  host,
+		probe: func(ctx context.Context) error {
+			// Any response, other than a server error, means the API is reachable.
+			return get(ctx, fmt.Sprintf("https://%s/v1/", host))
+		},
+	}
+}
+
+// Cluster probes the API server of the cluster that kubeconfig connects to, with its
+// credentials.
+func Cluster(kubeconfig []byte) Probe {
+	p := Probe{Service: "cluster"}
+
+	restconfig, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		p.probe = func(context.Context) error {
+			return fmt.Errorf("unable to load the kubeconfig: %w", err)
+		}
+		return p
+	}
+
+	p.Target = restconfig.Host
+	p.probe = func(ctx context.Context) error {
+		client, err := discovery.NewDiscoveryClientForConfig(restconfig)
+		if err != nil {
+			return fmt.Errorf("unable to create a client with the kubeconfig: %w", err)
+		}
+
+		if _, err := client.RESTClient().Get().AbsPath("/readyz").DoRaw(ctx); err != nil {
+			return fmt.Errorf("the API server is not ready: %w", err)
+		}
+
+		return nil
+	}
+
+	return p
+}
+
+// get requests url, using the proxy and certificate authorities configured in ctx.
+// The service is ready if it responds, unless it reports a server error.
+func get(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := transport.HTTPClient(ctx, 0).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("%s responded with status code: %d", url, resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package readiness
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/mirror"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/transport"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Probing services", func() {
+	var (
+		server *httptest.Server
+		host   string
+	)
+
+	BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/v2/":
+				w.WriteHeader(http.StatusUnauthorized)
+			case "/readyz":
+				_, _ = w.Write([]byte("ok"))
+			case "/slow/readyz":
+				<-r.Context().Done()
+			default:
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		}))
+		DeferCleanup(server.Close)
+		host = strings.TrimPrefix(server.URL, "http://")
+	})
+
+	Context("When probing a registry", func() {
+		It("should be ready if the registry responds, even if only to request authentication", func() {
+			results := Run(context.TODO(), time.Second, Registry(host+"/namespace/image:tag", false, nil))
+			Expect(results.Ready()).To(BeTrue())
+			Expect(results).To(ConsistOf(HaveField("Target", host)))
+		})
+
+		It("should not be ready if the registry does not respond", func() {
+			server.Close()
+			results := Run(context.TODO(), time.Second, Registry(host+"/namespace/image:tag", false, nil))
+			Expect(results.Ready()).To(BeFalse())
+			Expect(results.String()).To(ContainSubstring("NOT READY"))
+		})
+
+		It("should be ready if a mirror of the registry responds", func() {
+			mirrors := []mirror.Mirror{{Source: "registry.example.com", Mirrors: []string{host + "/mirror"}, NeverContactSource: true}}
+			results := Run(context.TODO(), time.Second, Registry("registry.example.com/namespace/image:tag", false, mirrors))
+			Expect(results.Ready()).To(BeTrue())
+		})
+	})
+
+	Context("When probing Pyxis", func() {
+		var ctx context.Context
+
+		BeforeEach(func() {
+			tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if strings.HasPrefix(r.URL.Path, "/down/") {
+					w.WriteHeader(http.StatusBadGateway)
+					return
+				}
+				w.WriteHeader(http.StatusNotFound)
+			}))
+			DeferCleanup(tlsServer.Close)
+			host = strings.TrimPrefix(tlsServer.URL, "https://")
+
+			rootCAs := x509.NewCertPool()
+			rootCAs.AddCert(tlsServer.Certificate())
+			ctx = transport.ContextWithRootCAs(context.TODO(), rootCAs)
+		})
+
+		It("should be ready if the API responds", func() {
+			results := Run(ctx, time.Second, Pyxis(host+"/api/containers"))
+			Expect(results.Ready()).To(BeTrue())
+		})
+
+		It("should not be ready if the API responds with a server error", func() {
+			results := Run(ctx, time.Second, Pyxis(host+"/down"))
+			Expect(results.Ready()).To(BeFalse())
+			Expect(results[0].Err).To(MatchError(ContainSubstring("status code: 502")))
+		})
+	})
+
+	Context("When probing a cluster", func() {
+		It("should be ready if the API server is ready", func() {
+			results := Run(context.TODO(), time.Second, Cluster(kubeconfig(server.URL)))
+			Expect(results.Ready()).To(BeTrue())
+			Expect(results).To(ConsistOf(HaveField("Target", server.URL)))
+		})
+
+		It("should not be ready if the API server is not", func() {
+			results := Run(context.TODO(), time.Second, Cluster(kubeconfig(server.URL+"/down")))
+			Expect(results.Ready()).To(BeFalse())
+		})
+
+		It("should not be ready if the kubeconfig is invalid", func() {
+			results := Run(context.TODO(), time.Second, Cluster([]byte("not a kubeconfig")))
+			Expect(results.Ready()).To(BeFalse())
+		})
+	})
+
+	It("should not wait for a service longer than the timeout", func() {
+		results := Run(context.TODO(), 50*time.Millisecond, Cluster(kubeconfig(server.URL+"/slow")), Registry(host+"/image:tag", false, nil))
+		Expect(results.Ready()).To(BeFalse())
+		Expect(results[0].Err).To(HaveOccurred())
+		Expect(results[1].Err).ToNot(HaveOccurred())
+	})
+})
+
+// kubeconfig returns a kubeconfig that connects to server with a token.
+func kubeconfig(server string) []byte {
+	return []byte(fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: cluster
+  cluster:
+    server: %s
+contexts:
+- name: context
+  context:
+    cluster: cluster
+    user: user
+current-context: context
+users:
+- name: user
+  user:
+    token: token
+`, server))
+}
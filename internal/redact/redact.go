@@ -0,0 +1,285 @@
+// Package redact removes identifying details, such as registry hostnames,
+// certification project IDs, and local filesystem paths, from preflight
+// results so that they can be shared publicly.
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+const (
+	// RedactedHostname replaces registry hostnames.
+	RedactedHostname = "registry.example.com"
+	// RedactedProjectID replaces certification project IDs.
+	RedactedProjectID = "<project-id>"
+	// RedactedPath replaces local filesystem paths.
+	RedactedPath = "<path>"
+)
+
+var (
+	// projectIDPattern matches certification project IDs, with or without
+	// the ospid- prefix.
+	projectIDPattern = regexp.MustCompile(`\b(ospid-)?[0-9a-f]{24}\b`)
+	// homePathPattern matches paths within user home directories that are
+	// not part of a URL.
+	homePathPattern = regexp.MustCompile(`(^|[^\w.:/-])(?:/home|/Users|/root)(?:/[^/\s"']+)+`)
+)
+
+type Option = func(*Redactor)
+
+// WithHostnames adds hostnames to be replaced with RedactedHostname,
+// in addition to the registry of the tested image.
+func WithHostnames(hostnames ...string) Option {
+	return func(r *Redactor) {
+		r.hostnames = append(r.hostnames, hostnames...)
+	}
+}
+
+// WithPathPrefixes adds filesystem path prefixes to be replaced
+// with RedactedPath, in addition to user home directories.
+func WithPathPrefixes(prefixes ...string) Option {
+	return func(r *Redactor) {
+		r.pathPrefixes = append(r.pathPrefixes, prefixes...)
+	}
+}
+
+// WithPattern adds a custom pattern whose matches are replaced with replacement.
+func WithPattern(pattern *regexp.Regexp, replacement string) Option {
+	return func(r *Redactor) {
+		r.patterns = append(r.patterns, replacementPattern{pattern, replacement})
+	}
+}
+
+type replacementPattern struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// Redactor replaces identifying details in results.
+type Redactor struct {
+	hostnames    []string
+	pathPrefixes []string
+	patterns     []replacementPattern
+}
+
+// New returns a Redactor that redacts certification project IDs and user
+// home directories, along with anything configured by opts.
+func New(opts ...Option) *Redactor {
+	r := &Redactor{}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// String returns s with all identifying details replaced.
+func (r *Redactor) String(s string) string {
+	// Replace the longest values first, so that a hostname or prefix
+	// contained in another is not replaced partially.
+	hostnames := longestFirst(r.hostnames)
+	for _, h := range hostnames {
+		s = strings.ReplaceAll(s, h, RedactedHostname)
+	}
+
+	for _, p := range longestFirst(r.pathPrefixes) {
+		s = strings.ReplaceAll(s, p, RedactedPath)
+	}
+
+	s = homePathPattern.ReplaceAllString(s, "${1}"+RedactedPath)
+	s = projectIDPattern.ReplaceAllString(s, RedactedProjectID)
+
+	for _, p := range r.patterns {
+		s = p.pattern.ReplaceAllString(s, p.replacement)
+	}
+
+	return s
+}
+
+// JSON redacts every string value in the JSON document b. Object keys, numbers,
+// and the order of keys are preserved. If the document has a top-level "image"
+// key, the registry hostname of that image is redacted throughout the document
+// as well.
+func (r *Redactor) JSON(b []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	doc, err := decodeValue(dec)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse results: %w", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("could not parse results: unexpected content after the document")
+	}
+
+	rr := *r
+	if obj, ok := doc.(object); ok {
+		if img, ok := obj.get("image").(string); ok && img != "" {
+			if ref, err := name.ParseReference(img); err == nil {
+				rr.hostnames = append(append([]string{}, r.hostnames...), ref.Context().RegistryStr())
+			}
+		}
+	}
+
+	redacted, err := marshal(rr.walk(doc))
+	if err != nil {
+		return nil, fmt.Errorf("could not format redacted results: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, redacted, "", "    "); err != nil {
+		return nil, fmt.Errorf("could not format redacted results: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// walk returns v with all string values redacted.
+func (r *Redactor) walk(v interface{}) interface{} {
+	switch t := v.(type) {
+	case string:
+		return r.String(t)
+	case []interface{}:
+		for i := range t {
+			t[i] = r.walk(t[i])
+		}
+		return t
+	case object:
+		for i := range t {
+			t[i].value = r.walk(t[i].value)
+		}
+		return t
+	default:
+		return v
+	}
+}
+
+// object is a JSON object that retains the order of its members.
+type object []member
+
+type member struct {
+	key   string
+	value interface{}
+}
+
+// get returns the value of the member with key, or nil.
+func (o object) get(key string) interface{} {
+	for _, m := range o {
+		if m.key == key {
+			return m.value
+		}
+	}
+
+	return nil
+}
+
+// MarshalJSON encodes o with its members in order.
+func (o object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, m := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := marshal(m.key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := marshal(m.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// marshal encodes v without escaping HTML characters, so that the angle
+// brackets in the redacted placeholders remain readable.
+func marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// decodeValue decodes the next JSON value from dec, representing objects
+// as object so that the order of their members is retained.
+func decodeValue(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return tok, nil
+	}
+
+	switch delim {
+	case '{':
+		obj := object{}
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, ok := keyTok.(string)
+			if !ok {
+				return nil, fmt.Errorf("unexpected object key %v", keyTok)
+			}
+			value, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, member{key: key, value: value})
+		}
+		// Consume the closing delimiter.
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return obj, nil
+	case '[':
+		arr := []interface{}{}
+		for dec.More() {
+			value, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, value)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return arr, nil
+	}
+
+	return nil, fmt.Errorf("unexpected delimiter %v", delim)
+}
+
+func longestFirst(values []string) []string {
+	sorted := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != "" {
+			sorted = append(sorted, v)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+
+	return sorted
+}
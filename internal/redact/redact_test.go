@@ -0,0 +1,73 @@
+package redact
+
+import (
+	"encoding/json"
+	"regexp"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Redactor", func() {
+	Context("When redacting strings", func() {
+		It("should redact certification project IDs", func() {
+			r := New()
+			Expect(r.String("project 0123456789abcdef01234567 failed")).To(Equal("project <project-id> failed"))
+			Expect(r.String("ospid-0123456789abcdef01234567")).To(Equal("<project-id>"))
+		})
+
+		It("should not redact sha256 digests", func() {
+			digest := "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+			Expect(New().String(digest)).To(Equal(digest))
+		})
+
+		It("should redact paths in home directories but not URLs", func() {
+			r := New()
+			Expect(r.String("wrote /home/jdoe/work/artifacts/cert.json")).To(Equal("wrote <path>"))
+			Expect(r.String("https://example.com/home/docs")).To(Equal("https://example.com/home/docs"))
+			Expect(r.String("/rootfs/etc")).To(Equal("/rootfs/etc"))
+		})
+
+		It("should redact configured hostnames and path prefixes", func() {
+			r := New(WithHostnames("quay.internal.corp"), WithPathPrefixes("/srv/ci/builds"))
+			Expect(r.String("quay.internal.corp/team/app:1")).To(Equal("registry.example.com/team/app:1"))
+			Expect(r.String("/srv/ci/builds/42/artifacts")).To(Equal("<path>/42/artifacts"))
+		})
+
+		It("should apply custom patterns", func() {
+			r := New(WithPattern(regexp.MustCompile(`team-[a-z]+`), "<team>"))
+			Expect(r.String("owned by team-blue")).To(Equal("owned by <team>"))
+		})
+	})
+
+	Context("When redacting a results document", func() {
+		It("should redact the registry of the tested image throughout", func() {
+			in := []byte(`{
+				"image": "quay.internal.corp/team/app:1.0",
+				"passed": false,
+				"results": {"failed": [{"name": "HasLicense", "help": "pull quay.internal.corp/team/app:1.0 failed"}]}
+			}`)
+			out, err := New().JSON(in)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(out)).ToNot(ContainSubstring("quay.internal.corp"))
+
+			var doc map[string]interface{}
+			Expect(json.Unmarshal(out, &doc)).To(Succeed())
+			Expect(doc["image"]).To(Equal("registry.example.com/team/app:1.0"))
+			Expect(doc["passed"]).To(BeFalse())
+		})
+
+		It("should preserve key order, numbers, and keys", func() {
+			in := []byte(`{"image": "example.com/app:1", "passed": true, "results": {"passed": [{"name": "HasLicense", "elapsed_time": 1234567890123}]}, "/home/jdoe/key": 1}`)
+			out, err := New().JSON(in)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(out)).To(MatchRegexp(`(?s)"image".*"passed".*"results".*"/home/jdoe/key"`))
+			Expect(string(out)).To(ContainSubstring(`"elapsed_time": 1234567890123`))
+		})
+
+		It("should return an error if the document is not JSON", func() {
+			_, err := New().JSON([]byte("not json"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,322 @@
+// Package release orchestrates checks across every image that makes up an
+// operator release, i.e. the operator bundle and its operand images.
+package release
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/certification"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/events"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/interrupt"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Kind is the kind of checks executed for a component.
+type Kind string
+
+const (
+	KindContainer Kind = "container"
+	KindOperator  Kind = "operator"
+)
+
+// StatusSkipped is the status of a component that was not checked because
+// one of its dependencies could not be checked.
+const StatusSkipped = certification.StatusSkipped
+
+// Manifest describes a release.
+type Manifest struct {
+	// Bundle is the operator bundle image. Operator checks are executed
+	// against it after all of the images it depends on have been checked.
+	Bundle string `json:"bundle,omitempty"`
+	// IndexImage is the index image used to deploy the bundle. If empty,
+	// the configured index image is used.
+	IndexImage string `json:"indexImage,omitempty"`
+	// Channel is the channel used to deploy the bundle. If empty, the
+	// default channel of the bundle is used.
+	Channel string `json:"channel,omitempty"`
+	// Images are the operator and operand images shipped in the release.
+	// Container checks are executed against each of them.
+	Images []Image `json:"images,omitempty"`
+}
+
+// Image is a container image shipped in a release.
+type Image struct {
+	Image string `json:"image"`
+	// DependsOn lists images in the release that must be checked before this one.
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+// Component is a single image in the release and the kind of checks to run against it.
+type Component struct {
+	Image     string
+	Kind      Kind
+	DependsOn []string
+}
+
+// ReadManifest reads and validates the release manifest at path.
+func ReadManifest(path string) (*Manifest, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read release manifest: %w", err)
+	}
+
+	var m Manifest
+	if err := yaml.UnmarshalStrict(b, &m); err != nil {
+		return nil, fmt.Errorf("could not parse release manifest: %w", err)
+	}
+
+	if err := m.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid release manifest: %w", err)
+	}
+
+	return &m, nil
+}
+
+// Validate returns an error if m has no images, lists an image more than
+// once, or has a dependency on an image that is not part of the release.
+func (m *Manifest) Validate() error {
+	if m.Bundle == "" && len(m.Images) == 0 {
+		return fmt.Errorf("a bundle or at least one image is required")
+	}
+
+	seen := make(map[string]bool, len(m.Images))
+	for _, img := range m.Images {
+		if img.Image == "" {
+			return fmt.Errorf("an image reference is required for every image")
+		}
+		if seen[img.Image] {
+			return fmt.Errorf("image %s is listed more than once", img.Image)
+		}
+		seen[img.Image] = true
+	}
+
+	for _, img := range m.Images {
+		for _, dep := range img.DependsOn {
+			if !seen[dep] {
+				return fmt.Errorf("image %s depends on %s, which is not part of the release", img.Image, dep)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Components returns the components of the release in the order in which
+// they should be checked. Every image is checked after the images it depends
+// on, and the bundle is checked last, since it depends on every image in the
+// release. Otherwise, images are checked in the order they are listed.
+func (m *Manifest) Components() ([]Component, error) {
+	byImage := make(map[string]Image, len(m.Images))
+	for _, img := range m.Images {
+		byImage[img.Image] = img
+	}
+
+	const (
+		visiting = iota + 1
+		visited
+	)
+	state := make(map[string]int, len(m.Images))
+	components := make([]Component, 0, len(m.Images)+1)
+
+	var visit func(img Image, path []string) error
+	visit = func(img Image, path []string) error {
+		switch state[img.Image] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("dependency cycle: %s", strings.Join(append(path, img.Image), " -> "))
+		}
+
+		state[img.Image] = visiting
+		for _, dep := range img.DependsOn {
+			if err := visit(byImage[dep], append(path, img.Image)); err != nil {
+				return err
+			}
+		}
+		state[img.Image] = visited
+
+		components = append(components, Component{Image: img.Image, Kind: KindContainer, DependsOn: img.DependsOn})
+		return nil
+	}
+
+	for _, img := range m.Images {
+		if err := visit(img, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	if m.Bundle != "" {
+		deps := make([]string, 0, len(m.Images))
+		for _, img := range m.Images {
+			deps = append(deps, img.Image)
+		}
+		components = append(components, Component{Image: m.Bundle, Kind: KindOperator, DependsOn: deps})
+	}
+
+	return components, nil
+}
+
+// Results is the unified result of checking every component of a release.
+type Results struct {
+	PassedOverall bool              `json:"passed"`
+	Components    []ComponentResult `json:"components"`
+}
+
+// ComponentResult is the result of checking a single component.
+type ComponentResult struct {
+	Image       string               `json:"image"`
+	Kind        Kind                 `json:"kind"`
+	Status      certification.Status `json:"status"`
+	ResultsFile string               `json:"results_file,omitempty"`
+	Passed      int                  `json:"passed"`
+	Failed      int                  `json:"failed"`
+	Errors      int                  `json:"errors"`
+	Error       string               `json:"error,omitempty"`
+}
+
+// CheckFunc executes the checks for c, and returns its results and the path
+// to which they were written.
+type CheckFunc func(ctx context.Context, c Component) (certification.Results, string, error)
+
+type Option = func(*run)
+
+// WithCompleted reuses the results of the components that were checked by a previous
+// run, e.g. one that was interrupted, rather than checking them again. Components
+// that errored or were skipped are checked again.
+func WithCompleted(completed []ComponentResult) Option {
+	return func(r *run) {
+		for _, cr := range completed {
+			if cr.Status == certification.StatusPassed || cr.Status == certification.StatusFailed {
+				r.completed[Component{Image: cr.Image, Kind: cr.Kind}.key()] = cr
+			}
+		}
+	}
+}
+
+// WithProgress calls fn with the result of each component as soon as it is known, e.g.
+// to checkpoint the run.
+func WithProgress(fn func(ComponentResult)) Option {
+	return func(r *run) {
+		r.progress = fn
+	}
+}
+
+// WithFailFast skips the components after the first component that does not pass,
+// rather than checking them.
+func WithFailFast() Option {
+	return func(r *run) {
+		r.failFast = true
+	}
+}
+
+type run struct {
+	completed map[string]ComponentResult
+	progress  func(ComponentResult)
+	failFast  bool
+}
+
+// key identifies c in the results of a run.
+func (c Component) key() string {
+	return string(c.Kind) + "/" + c.Image
+}
+
+// Run checks each of components in order using check, and returns the unified
+// results. A component is skipped if any of its dependencies could not be
+// checked or were skipped, and every component is skipped once ctx is cancelled, e.g.
+// because preflight was interrupted. Components with failed checks do not prevent their
+// dependents from being checked, unless WithFailFast is used. A run summary event is
+// emitted for each component that is checked, which excludes the completed components
+// reused by WithCompleted.
+func Run(ctx context.Context, components []Component, check CheckFunc, opts ...Option) Results {
+	r := run{completed: map[string]ComponentResult{}, progress: func(ComponentResult) {}}
+	for _, opt := range opts {
+		opt(&r)
+	}
+
+	report := Results{PassedOverall: true, Components: make([]ComponentResult, 0, len(components))}
+	unchecked := map[string]bool{}
+	// stoppedAt is the first component that did not pass, with WithFailFast.
+	var stoppedAt string
+
+	for _, c := range components {
+		if result, ok := r.completed[c.key()]; ok {
+			if result.Status != certification.StatusPassed {
+				report.PassedOverall = false
+				if r.failFast && stoppedAt == "" {
+					stoppedAt = c.Image
+				}
+			}
+			report.Components = append(report.Components, result)
+			r.progress(result)
+			continue
+		}
+
+		result := ComponentResult{Image: c.Image, Kind: c.Kind}
+
+		for _, dep := range c.DependsOn {
+			if unchecked[dep] {
+				result.Status = StatusSkipped
+				result.Error = fmt.Sprintf("dependency %s could not be checked", dep)
+				break
+			}
+		}
+
+		if stoppedAt != "" {
+			result.Status = StatusSkipped
+			result.Error = fmt.Sprintf("stopped after %s did not pass", stoppedAt)
+		}
+
+		if interrupt.Interrupted(ctx) {
+			result.Status = StatusSkipped
+			result.Error = interrupt.ErrInterrupted.Error()
+		}
+
+		if result.Status != StatusSkipped {
+			results, resultsFile, err := check(ctx, c)
+			switch {
+			case err != nil:
+				result.Status = certification.StatusErrored
+				result.Error = err.Error()
+			default:
+				result.Status = certification.OverallStatus(results.PassedOverall)
+				result.ResultsFile = resultsFile
+				result.Passed = len(results.Passed)
+				result.Failed = len(results.Failed)
+				result.Errors = len(results.Errors)
+			}
+			// A component whose checks were interrupted is checked again on resume.
+			if interrupt.Interrupted(ctx) {
+				result.Status = StatusSkipped
+				result.Error = interrupt.ErrInterrupted.Error()
+			}
+			events.Emit(ctx, events.Event{
+				Type:   events.TypeRunSummary,
+				Image:  c.Image,
+				Result: string(result.Status),
+				Passed: result.Passed,
+				Failed: result.Failed,
+				Errors: result.Errors,
+				Error:  result.Error,
+			})
+		}
+
+		if result.Status != certification.StatusPassed {
+			report.PassedOverall = false
+			if r.failFast && stoppedAt == "" {
+				stoppedAt = c.Image
+			}
+		}
+		if result.Status == certification.StatusErrored || result.Status == StatusSkipped {
+			unchecked[c.Image] = true
+		}
+
+		report.Components = append(report.Components, result)
+		r.progress(result)
+	}
+
+	return report
+}
@@ -0,0 +1,233 @@
+package release
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/certification"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/events"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/interrupt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Release", func() {
+	Context("When reading a manifest", func() {
+		var path string
+		BeforeEach(func() {
+			path = filepath.Join(GinkgoT().TempDir(), "release.yaml")
+		})
+
+		It("should parse a valid manifest", func() {
+			Expect(os.WriteFile(path, []byte(`bundle: example.com/bundle:v1
+channel: stable
+images:
+- image: example.com/operator:v1
+- image: example.com/operand:v1
+  dependsOn:
+  - example.com/operator:v1
+`), 0o644)).To(Succeed())
+
+			m, err := ReadManifest(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(m.Bundle).To(Equal("example.com/bundle:v1"))
+			Expect(m.Channel).To(Equal("stable"))
+			Expect(m.Images).To(HaveLen(2))
+			Expect(m.Images[1].DependsOn).To(Equal([]string{"example.com/operator:v1"}))
+		})
+
+		It("should reject unknown fields", func() {
+			Expect(os.WriteFile(path, []byte("bundel: example.com/bundle:v1\n"), 0o644)).To(Succeed())
+			_, err := ReadManifest(path)
+			Expect(err).To(MatchError(ContainSubstring("could not parse release manifest")))
+		})
+
+		It("should reject an empty manifest", func() {
+			Expect(os.WriteFile(path, []byte("channel: stable\n"), 0o644)).To(Succeed())
+			_, err := ReadManifest(path)
+			Expect(err).To(MatchError(ContainSubstring("a bundle or at least one image is required")))
+		})
+
+		It("should reject duplicate images", func() {
+			m := Manifest{Images: []Image{{Image: "a"}, {Image: "a"}}}
+			Expect(m.Validate()).To(MatchError(ContainSubstring("listed more than once")))
+		})
+
+		It("should reject dependencies outside of the release", func() {
+			m := Manifest{Images: []Image{{Image: "a", DependsOn: []string{"b"}}}}
+			Expect(m.Validate()).To(MatchError(ContainSubstring("not part of the release")))
+		})
+	})
+
+	Context("When ordering components", func() {
+		It("should check dependencies first and the bundle last", func() {
+			m := Manifest{
+				Bundle: "bundle",
+				Images: []Image{
+					{Image: "operand", DependsOn: []string{"operator"}},
+					{Image: "operator"},
+				},
+			}
+			components, err := m.Components()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(components).To(Equal([]Component{
+				{Image: "operator", Kind: KindContainer},
+				{Image: "operand", Kind: KindContainer, DependsOn: []string{"operator"}},
+				{Image: "bundle", Kind: KindOperator, DependsOn: []string{"operand", "operator"}},
+			}))
+		})
+
+		It("should reject dependency cycles", func() {
+			m := Manifest{
+				Images: []Image{
+					{Image: "a", DependsOn: []string{"b"}},
+					{Image: "b", DependsOn: []string{"a"}},
+				},
+			}
+			_, err := m.Components()
+			Expect(err).To(MatchError("dependency cycle: a -> b -> a"))
+		})
+	})
+
+	Context("When running checks", func() {
+		components := []Component{
+			{Image: "operator", Kind: KindContainer},
+			{Image: "operand", Kind: KindContainer, DependsOn: []string{"operator"}},
+			{Image: "bundle", Kind: KindOperator, DependsOn: []string{"operand", "operator"}},
+		}
+
+		It("should report every component", func() {
+			report := Run(context.TODO(), components, func(ctx context.Context, c Component) (certification.Results, string, error) {
+				return certification.Results{PassedOverall: true, Passed: []certification.Result{{}}}, c.Image + "/results.json", nil
+			})
+			Expect(report.PassedOverall).To(BeTrue())
+			Expect(report.Components).To(HaveLen(3))
+			Expect(report.Components[2]).To(Equal(ComponentResult{
+				Image:       "bundle",
+				Kind:        KindOperator,
+				Status:      certification.StatusPassed,
+				ResultsFile: "bundle/results.json",
+				Passed:      1,
+			}))
+		})
+
+		It("should still check dependents of components with failed checks", func() {
+			var checked []string
+			report := Run(context.TODO(), components, func(ctx context.Context, c Component) (certification.Results, string, error) {
+				checked = append(checked, c.Image)
+				return certification.Results{PassedOverall: c.Image != "operator"}, "", nil
+			})
+			Expect(checked).To(Equal([]string{"operator", "operand", "bundle"}))
+			Expect(report.PassedOverall).To(BeFalse())
+			Expect(report.Components[0].Status).To(Equal(certification.StatusFailed))
+		})
+
+		It("should skip every component after one with failed checks when failing fast", func() {
+			var checked []string
+			report := Run(context.TODO(), components, func(ctx context.Context, c Component) (certification.Results, string, error) {
+				checked = append(checked, c.Image)
+				return certification.Results{PassedOverall: c.Image != "operator"}, "", nil
+			}, WithFailFast())
+			Expect(checked).To(Equal([]string{"operator"}))
+			Expect(report.PassedOverall).To(BeFalse())
+			Expect(report.Components[0].Status).To(Equal(certification.StatusFailed))
+			Expect(report.Components[1].Status).To(Equal(StatusSkipped))
+			Expect(report.Components[1].Error).To(Equal("stopped after operator did not pass"))
+			Expect(report.Components[2].Status).To(Equal(StatusSkipped))
+		})
+
+		It("should skip dependents of components that could not be checked", func() {
+			var checked []string
+			report := Run(context.TODO(), components, func(ctx context.Context, c Component) (certification.Results, string, error) {
+				checked = append(checked, c.Image)
+				return certification.Results{}, "", errors.New("unable to pull image")
+			})
+			Expect(checked).To(Equal([]string{"operator"}))
+			Expect(report.PassedOverall).To(BeFalse())
+			Expect(report.Components[0].Status).To(Equal(certification.StatusErrored))
+			Expect(report.Components[0].Error).To(Equal("unable to pull image"))
+			Expect(report.Components[1].Status).To(Equal(StatusSkipped))
+			Expect(report.Components[2].Status).To(Equal(StatusSkipped))
+			Expect(report.Components[2].Error).To(Equal("dependency operand could not be checked"))
+		})
+
+		It("should skip the component being checked and the remaining components once interrupted", func() {
+			ctx, cancel := interrupt.WithInterrupt(context.TODO())
+			defer cancel()
+			var checked []string
+			report := Run(ctx, components, func(ctx context.Context, c Component) (certification.Results, string, error) {
+				checked = append(checked, c.Image)
+				if c.Image == "operand" {
+					cancel()
+				}
+				return certification.Results{PassedOverall: true}, "", nil
+			})
+			Expect(checked).To(Equal([]string{"operator", "operand"}))
+			Expect(report.PassedOverall).To(BeFalse())
+			Expect(report.Components[0].Status).To(Equal(certification.StatusPassed))
+			Expect(report.Components[1].Status).To(Equal(StatusSkipped))
+			Expect(report.Components[1].Error).To(Equal("preflight was interrupted"))
+			Expect(report.Components[2].Status).To(Equal(StatusSkipped))
+		})
+
+		It("should emit a run summary for each component that is checked", func() {
+			listener := &recordingListener{}
+			ctx := events.ContextWithListener(context.TODO(), listener)
+			Run(ctx, components, func(ctx context.Context, c Component) (certification.Results, string, error) {
+				if c.Image == "operand" {
+					return certification.Results{}, "", errors.New("unable to pull image")
+				}
+				return certification.Results{PassedOverall: true, Passed: []certification.Result{{}}}, "", nil
+			})
+			Expect(listener.events).To(HaveLen(2))
+			Expect(listener.events[0].Type).To(Equal(events.TypeRunSummary))
+			Expect(listener.events[0].Image).To(Equal("operator"))
+			Expect(listener.events[0].Result).To(Equal("PASSED"))
+			Expect(listener.events[0].Passed).To(Equal(1))
+			Expect(listener.events[1].Image).To(Equal("operand"))
+			Expect(listener.events[1].Result).To(Equal("ERROR"))
+			Expect(listener.events[1].Error).To(Equal("unable to pull image"))
+		})
+
+		It("should reuse the results of completed components, and check the others again", func() {
+			listener := &recordingListener{}
+			ctx := events.ContextWithListener(context.TODO(), listener)
+			completed := []ComponentResult{
+				{Image: "operator", Kind: KindContainer, Status: certification.StatusFailed, ResultsFile: "operator/results.json", Failed: 1},
+				{Image: "operand", Kind: KindContainer, Status: certification.StatusErrored, Error: "context canceled"},
+			}
+
+			var checked []string
+			report := Run(ctx, components, func(ctx context.Context, c Component) (certification.Results, string, error) {
+				checked = append(checked, c.Image)
+				return certification.Results{PassedOverall: true}, "", nil
+			}, WithCompleted(completed))
+			Expect(checked).To(Equal([]string{"operand", "bundle"}))
+			Expect(report.PassedOverall).To(BeFalse())
+			Expect(report.Components[0]).To(Equal(completed[0]))
+			Expect(report.Components[1].Status).To(Equal(certification.StatusPassed))
+			Expect(listener.events).To(HaveLen(2))
+		})
+
+		It("should report the progress of each component in order", func() {
+			var progress []string
+			Run(context.TODO(), components, func(ctx context.Context, c Component) (certification.Results, string, error) {
+				return certification.Results{}, "", errors.New("unable to pull image")
+			}, WithProgress(func(cr ComponentResult) {
+				progress = append(progress, cr.Image+" "+string(cr.Status))
+			}))
+			Expect(progress).To(Equal([]string{"operator ERROR", "operand SKIPPED", "bundle SKIPPED"}))
+		})
+	})
+})
+
+type recordingListener struct {
+	events []events.Event
+}
+
+func (l *recordingListener) OnEvent(e events.Event) {
+	l.events = append(l.events, e)
+}
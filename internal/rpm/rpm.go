@@ -12,11 +12,19 @@ import (
 	_ "github.com/glebarez/go-sqlite"
 )
 
+// DatabaseDir is the path of the directory of the rpm database in the filesystem of
+// an image.
+const DatabaseDir = "/var/lib/rpm"
+
+// SysimageDatabaseDir is the path of the directory of the rpm database in the filesystem
+// of images based on RHEL 9 or Fedora 36 and later, which DatabaseDir links to.
+const SysimageDatabaseDir = "/usr/lib/sysimage/rpm"
+
 // GetPackageList returns the list of packages in the rpm database from either
 // /var/lib/rpm/rpmdb.sqlite, or /var/lib/rpm/Packages if the former does not exist.
 // If neither exists, this returns an error of type os.ErrNotExists
 func GetPackageList(ctx context.Context, basePath string) ([]*rpmdb.PackageInfo, error) {
-	rpmdirPath := filepath.Join(basePath, "var", "lib", "rpm")
+	rpmdirPath := filepath.Join(basePath, DatabaseDir)
 	rpmdbPath := filepath.Join(rpmdirPath, "rpmdb.sqlite")
 
 	if _, err := os.Stat(rpmdbPath); errors.Is(err, os.ErrNotExist) {
@@ -1,7 +1,9 @@
 package runtime
 
 import (
+	"fmt"
 	"os"
+	"strings"
 
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/policy"
 
@@ -10,22 +12,99 @@ import (
 
 // Config contains configuration details for running preflight.
 type Config struct {
-	Image          string
-	Policy         policy.Policy
-	ResponseFormat string
-	Bundle         bool
-	Scratch        bool
-	LogFile        string
-	Artifacts      string
-	WriteJUnit     bool
+	Image                      string
+	Policy                     policy.Policy
+	ResponseFormat             string
+	Bundle                     bool
+	Scratch                    bool
+	LogFile                    string
+	Artifacts                  string
+	WriteJUnit                 bool
+	WriteChecklist             bool
+	EventsFile                 string
+	Deterministic              bool
+	Progress                   bool
+	Quiet                      bool
+	Summary                    bool
+	TraceOnFailure             bool
+	JUnitPath                  string
+	Proxy                      string
+	NoProxy                    string
+	CABundle                   string
+	WriteCodeQuality           bool
+	CI                         string
+	RegistryMirrors            []string
+	MirrorConfig               string
+	Watch                      bool
+	RegistryUsername           string
+	RegistryPassword           string
+	RegistryToken              string
+	CompareTo                  string
+	VaultAddress               string
+	VaultNamespace             string
+	VaultToken                 string
+	VaultRoleID                string
+	VaultSecretID              string
+	VaultAppRoleMount          string
+	VaultPath                  string
+	DockerConfigSecret         string
+	PyxisTokenSecret           string
+	ArtifactsQuota             string
+	PyxisOIDCToken             string
+	PyxisOIDCTokenFile         string
+	PyxisOIDCClientID          string
+	PyxisOIDCTokenURL          string
+	SubmitOffline              bool
+	SubmitToURL                string
+	SubmitToURLSecret          string
+	ProbeServices              bool
+	ArtifactArchive            string
+	MarkSubmitted              string
+	OpenSearchURL              string
+	OpenSearchAPIKey           string
+	IssueTrackerConfig         string
+	ExceptionsFile             string
+	ImageResolver              string
+	PostRunCommand             string
+	KnownIssuesFeed            string
+	OperabilityCheck           string
+	ClusterProvider            string
+	ClusterProviderURL         string
+	ClusterProviderToken       string
+	OCMToken                   string
+	ManagedClusterProduct      string
+	ManagedClusterRegion       string
+	ManagedClusterVersion      string
+	ManagedClusterAWSAccountID string
+	ClusterProxy               string
+	Lockfile                   string
+	UpdateLockfile             bool
+	Tmpdir                     string
+	RerunFailed                string
+	FailFast                   bool
+	CheckTimeout               string
+	CheckTimeouts              []string
+	DenyCheckEgress            bool
+	AllowCheckEgress           []string
+	ExitCodeFailed             int
+	ExitCodeError              int
+	ExitCodeWarning            int
+	ExpectedDigest             string
+	Compat                     string
 	// Container-Specific Fields
 	CertificationProjectID string
+	PyxisEnv               string
 	PyxisHost              string
 	PyxisAPIToken          string
+	PyxisMaxQPS            float64
 	DockerConfig           string
 	Submit                 bool
+	SubmitDryRun           bool
 	Platform               string
 	Insecure               bool
+	AttachResults          bool
+	ApprovedBaseImages     []string
+	DecryptionKeys         []string
 	// Operator-Specific Fields
 	Namespace         string
 	ServiceAccount    string
@@ -34,6 +113,7 @@ type Config struct {
 	Channel           string
 	IndexImage        string
 	Kubeconfig        string
+	CheckAttempts     int
 }
 
 // ReadOnly returns an uneditably configuration.
@@ -53,20 +133,148 @@ func NewConfigFrom(vcfg viper.Viper) (*Config, error) {
 	cfg.DockerConfig = vcfg.GetString("dockerConfig")
 	cfg.Artifacts = vcfg.GetString("artifacts")
 	cfg.WriteJUnit = vcfg.GetBool("junit")
+	cfg.WriteChecklist = vcfg.GetBool("checklist")
+	cfg.EventsFile = vcfg.GetString("events_file")
+	cfg.Deterministic = vcfg.GetBool("deterministic")
+	cfg.Progress = vcfg.GetBool("progress")
+	cfg.Quiet = vcfg.GetBool("quiet")
+	cfg.Summary = vcfg.GetBool("summary")
+	cfg.TraceOnFailure = vcfg.GetBool("trace_on_failure")
+	cfg.JUnitPath = vcfg.GetString("junit_path")
+	cfg.Proxy = vcfg.GetString("https_proxy")
+	cfg.NoProxy = vcfg.GetString("no_proxy")
+	cfg.CABundle = vcfg.GetString("ca_bundle")
+	cfg.WriteCodeQuality = vcfg.GetBool("gitlab_codequality")
+	cfg.CI = vcfg.GetString("ci")
+	cfg.RegistryMirrors = vcfg.GetStringSlice("registry_mirrors")
+	cfg.MirrorConfig = vcfg.GetString("mirror_config")
+	cfg.Watch = vcfg.GetBool("watch")
+	cfg.RegistryUsername = vcfg.GetString("registry_username")
+	cfg.RegistryPassword = vcfg.GetString("registry_password")
+	cfg.RegistryToken = vcfg.GetString("registry_token")
+	cfg.CompareTo = vcfg.GetString("compare_to")
+	cfg.VaultAddress = vcfg.GetString("vault_addr")
+	cfg.VaultNamespace = vcfg.GetString("vault_namespace")
+	cfg.VaultToken = vcfg.GetString("vault_token")
+	cfg.VaultRoleID = vcfg.GetString("vault_role_id")
+	cfg.VaultSecretID = vcfg.GetString("vault_secret_id")
+	cfg.VaultAppRoleMount = vcfg.GetString("vault_approle_mount")
+	cfg.VaultPath = vcfg.GetString("vault_path")
+	cfg.DockerConfigSecret = vcfg.GetString("docker_config_secret")
+	cfg.PyxisTokenSecret = vcfg.GetString("pyxis_token_secret")
+	cfg.ArtifactsQuota = vcfg.GetString("artifacts_quota")
+	cfg.PyxisOIDCToken = vcfg.GetString("pyxis_oidc_token")
+	cfg.PyxisOIDCTokenFile = vcfg.GetString("pyxis_oidc_token_file")
+	cfg.PyxisOIDCClientID = vcfg.GetString("pyxis_oidc_client_id")
+	cfg.PyxisOIDCTokenURL = vcfg.GetString("pyxis_oidc_token_url")
+	cfg.SubmitOffline = vcfg.GetBool("submit_offline")
+	cfg.SubmitToURL = vcfg.GetString("submit_to_url")
+	cfg.SubmitToURLSecret = vcfg.GetString("submit_to_url_secret")
+	cfg.ProbeServices = vcfg.GetBool("probe_services")
+	cfg.ArtifactArchive = vcfg.GetString("artifact_archive")
+	cfg.MarkSubmitted = vcfg.GetString("mark_submitted")
+	cfg.OpenSearchURL = vcfg.GetString("opensearch_url")
+	cfg.OpenSearchAPIKey = vcfg.GetString("opensearch_api_key")
+	cfg.IssueTrackerConfig = vcfg.GetString("issue_tracker_config")
+	cfg.ExceptionsFile = vcfg.GetString("exceptions_file")
+	cfg.ImageResolver = vcfg.GetString("image_resolver")
+	cfg.PostRunCommand = vcfg.GetString("post_run_cmd")
+	cfg.KnownIssuesFeed = vcfg.GetString("known_issues_feed")
+	cfg.OperabilityCheck = vcfg.GetString("operability_check")
+	cfg.ClusterProvider = vcfg.GetString("cluster_provider")
+	cfg.ClusterProviderURL = vcfg.GetString("cluster_provider_url")
+	cfg.ClusterProviderToken = vcfg.GetString("cluster_provider_token")
+	cfg.OCMToken = vcfg.GetString("ocm_token")
+	cfg.ManagedClusterProduct = vcfg.GetString("managed_cluster_product")
+	cfg.ManagedClusterRegion = vcfg.GetString("managed_cluster_region")
+	cfg.ManagedClusterVersion = vcfg.GetString("managed_cluster_version")
+	cfg.ManagedClusterAWSAccountID = vcfg.GetString("managed_cluster_aws_account_id")
+	cfg.ClusterProxy = vcfg.GetString("cluster_proxy")
+	cfg.Lockfile = vcfg.GetString("lockfile")
+	cfg.UpdateLockfile = vcfg.GetBool("update_lockfile")
+	cfg.Tmpdir = vcfg.GetString("tmpdir")
+	cfg.RerunFailed = vcfg.GetString("rerun_failed")
+	cfg.FailFast = vcfg.GetBool("fail_fast")
+	cfg.CheckTimeout = vcfg.GetString("check_timeout")
+	cfg.CheckTimeouts = vcfg.GetStringSlice("check_timeouts")
+	cfg.DenyCheckEgress = vcfg.GetBool("deny_check_egress")
+	cfg.AllowCheckEgress = vcfg.GetStringSlice("allow_check_egress")
+	cfg.ExitCodeFailed = vcfg.GetInt("exit_code_failed")
+	cfg.ExitCodeError = vcfg.GetInt("exit_code_error")
+	cfg.ExitCodeWarning = vcfg.GetInt("exit_code_warning")
+	cfg.ExpectedDigest = vcfg.GetString("expected_digest")
+	cfg.Compat = vcfg.GetString("compat")
 	cfg.storeContainerPolicyConfiguration(vcfg)
 	cfg.storeOperatorPolicyConfiguration(vcfg)
+
+	// Secrets may instead be read from files, e.g. mounted Kubernetes secrets.
+	for _, secret := range []struct {
+		key   string
+		value *string
+	}{
+		{"pyxis_api_token", &cfg.PyxisAPIToken},
+		{"registry_password", &cfg.RegistryPassword},
+		{"registry_token", &cfg.RegistryToken},
+		{"vault_token", &cfg.VaultToken},
+		{"vault_secret_id", &cfg.VaultSecretID},
+		{"submit_to_url_secret", &cfg.SubmitToURLSecret},
+		{"opensearch_api_key", &cfg.OpenSearchAPIKey},
+	} {
+		value, err := secretFromFile(vcfg, secret.key, *secret.value)
+		if err != nil {
+			return nil, err
+		}
+		*secret.value = value
+	}
+
 	return &cfg, nil
 }
 
+// secretFromFile returns the secret in the file configured by the key with the
+// suffix "_file", or value if no file is configured.
+func secretFromFile(vcfg viper.Viper, key, value string) (string, error) {
+	path := vcfg.GetString(key + "_file")
+	if path == "" {
+		return value, nil
+	}
+	if value != "" {
+		return "", fmt.Errorf("only one of %s and %s_file can be set", key, key)
+	}
+
+	return ReadSecretFile(path)
+}
+
+// ReadSecretFile returns the secret in the file at path, without surrounding
+// whitespace, such as the trailing newline many editors add.
+func ReadSecretFile(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("could not read secret: %w", err)
+	}
+
+	secret := strings.TrimSpace(string(b))
+	if secret == "" {
+		return "", fmt.Errorf("secret file %s is empty", path)
+	}
+
+	return secret, nil
+}
+
 // storeContainerPolicyConfiguration reads container-policy-specific config
 // items in viper, normalizes them, and stores them in Config.
 func (c *Config) storeContainerPolicyConfiguration(vcfg viper.Viper) {
 	c.PyxisAPIToken = vcfg.GetString("pyxis_api_token")
+	c.PyxisMaxQPS = vcfg.GetFloat64("pyxis_max_qps")
 	c.Submit = vcfg.GetBool("submit")
-	c.PyxisHost = PyxisHostLookup(vcfg.GetString("pyxis_env"), vcfg.GetString("pyxis_host"))
+	c.SubmitDryRun = vcfg.GetBool("submit_dry_run")
+	c.PyxisEnv = vcfg.GetString("pyxis_env")
+	c.PyxisHost = PyxisHostLookup(c.PyxisEnv, vcfg.GetString("pyxis_host"))
 	c.CertificationProjectID = vcfg.GetString("certification_project_id")
 	c.Platform = vcfg.GetString("platform")
 	c.Insecure = vcfg.GetBool("insecure")
+	c.AttachResults = vcfg.GetBool("attach_results")
+	c.ApprovedBaseImages = vcfg.GetStringSlice("approved_base_images")
+	c.DecryptionKeys = vcfg.GetStringSlice("decryption_keys")
 }
 
 // storeOperatorPolicyConfiguration reads operator-policy-specific config
@@ -79,4 +287,5 @@ func (c *Config) storeOperatorPolicyConfiguration(vcfg viper.Viper) {
 	c.ScorecardWaitTime = vcfg.GetString("scorecard_wait_time")
 	c.Channel = vcfg.GetString("channel")
 	c.IndexImage = vcfg.GetString("indeximage")
+	c.CheckAttempts = vcfg.GetInt("cluster_check_attempts")
 }
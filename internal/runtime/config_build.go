@@ -0,0 +1,216 @@
+package runtime
+
+import (
+	goruntime "runtime"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/artifacts"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
+)
+
+// The defaults of the preflight binary, which NewConfig also uses.
+const (
+	DefaultLogFile           = "preflight.log"
+	DefaultLogLevel          = "info"
+	DefaultNamespace         = "default"
+	DefaultServiceAccount    = "default"
+	DefaultScorecardWaitTime = "240"
+	DefaultExitCodeError     = 1
+)
+
+// NewConfig returns a Config with the same defaults as the preflight binary. Use its
+// With methods to change them.
+func NewConfig() *Config {
+	return &Config{
+		LogFile:           DefaultLogFile,
+		Artifacts:         artifacts.DefaultArtifactsDir,
+		PyxisEnv:          check.DefaultPyxisEnv,
+		PyxisHost:         PyxisHostLookup(check.DefaultPyxisEnv, ""),
+		Platform:          goruntime.GOARCH,
+		Namespace:         DefaultNamespace,
+		ServiceAccount:    DefaultServiceAccount,
+		ScorecardWaitTime: DefaultScorecardWaitTime,
+		ExitCodeError:     DefaultExitCodeError,
+	}
+}
+
+// WithArtifactsDir sets the directory artifacts are written to.
+func (c *Config) WithArtifactsDir(dir string) *Config {
+	c.Artifacts = dir
+	return c
+}
+
+// WithPlatform sets the platform of the image to pull, e.g. amd64, s390x, or
+// linux/arm/v7.
+func (c *Config) WithPlatform(platform string) *Config {
+	c.Platform = platform
+	return c
+}
+
+// WithDockerConfig sets the path to the docker config used to pull images.
+func (c *Config) WithDockerConfig(path string) *Config {
+	c.DockerConfig = path
+	return c
+}
+
+// WithCertificationProject sets the certification project, and the Pyxis API token
+// to read it with, so that its policy exceptions are applied.
+func (c *Config) WithCertificationProject(id, token string) *Config {
+	c.CertificationProjectID = id
+	c.PyxisAPIToken = token
+	return c
+}
+
+// WithPyxisEnv sets the Pyxis environment, e.g. qa, and the Pyxis host for it. If
+// the environment is unknown, prod is used.
+func (c *Config) WithPyxisEnv(env string) *Config {
+	c.PyxisEnv = env
+	c.PyxisHost = PyxisHostLookup(env, "")
+	return c
+}
+
+// WithPyxisHost overrides the Pyxis host of the Pyxis environment.
+func (c *Config) WithPyxisHost(host string) *Config {
+	c.PyxisHost = PyxisHostLookup(c.PyxisEnv, host)
+	return c
+}
+
+// WithInsecureConnection allows images to be pulled from registries without TLS,
+// or with self-signed certificates.
+func (c *Config) WithInsecureConnection() *Config {
+	c.Insecure = true
+	return c
+}
+
+// WithProxy sends registry and Pyxis requests through the proxy at proxyURL, except
+// for requests to the hosts in noProxy.
+func (c *Config) WithProxy(proxyURL, noProxy string) *Config {
+	c.Proxy = proxyURL
+	c.NoProxy = noProxy
+	return c
+}
+
+// WithCABundle trusts the PEM encoded certificate authorities in the file at path.
+func (c *Config) WithCABundle(path string) *Config {
+	c.CABundle = path
+	return c
+}
+
+// WithRegistryCredentials authenticates with the registry of the image under test
+// using username and password, or a bearer token.
+func (c *Config) WithRegistryCredentials(username, password, token string) *Config {
+	c.RegistryUsername = username
+	c.RegistryPassword = password
+	c.RegistryToken = token
+	return c
+}
+
+// WithRegistryMirrors adds mirrors in the form source=mirror, as
+// configured with PFLT_REGISTRY_MIRRORS.
+func (c *Config) WithRegistryMirrors(mirrors ...string) *Config {
+	c.RegistryMirrors = append(c.RegistryMirrors, mirrors...)
+	return c
+}
+
+// WithMirrorConfigFile sets the path to a file of OpenShift mirror resources.
+func (c *Config) WithMirrorConfigFile(path string) *Config {
+	c.MirrorConfig = path
+	return c
+}
+
+// WithLockfile verifies the digest of the image under test against the lockfile at
+// path.
+func (c *Config) WithLockfile(path string) *Config {
+	c.Lockfile = path
+	return c
+}
+
+// WithUpdateLockfile pins the image under test to its digest in the lockfile, rather
+// than verifying it.
+func (c *Config) WithUpdateLockfile() *Config {
+	c.UpdateLockfile = true
+	return c
+}
+
+// WithTmpdir extracts the image under test to dir, rather than to the default temporary
+// directory.
+func (c *Config) WithTmpdir(dir string) *Config {
+	c.Tmpdir = dir
+	return c
+}
+
+// WithApprovedBaseImages additionally checks that the image is built on one of images.
+func (c *Config) WithApprovedBaseImages(images ...string) *Config {
+	c.ApprovedBaseImages = append(c.ApprovedBaseImages, images...)
+	return c
+}
+
+// WithDecryptionKeys decrypts the encrypted layers of the image with the private keys
+// at paths.
+func (c *Config) WithDecryptionKeys(paths ...string) *Config {
+	c.DecryptionKeys = append(c.DecryptionKeys, paths...)
+	return c
+}
+
+// WithTraceOnFailure executes checks that fail or error again with trace logging.
+func (c *Config) WithTraceOnFailure() *Config {
+	c.TraceOnFailure = true
+	return c
+}
+
+// WithFailFast stops executing checks after the first check that fails or errors.
+func (c *Config) WithFailFast() *Config {
+	c.FailFast = true
+	return c
+}
+
+// WithCheckTimeout errors checks that do not complete within timeout, e.g. 10m, unless
+// they have a timeout of their own.
+func (c *Config) WithCheckTimeout(timeout string) *Config {
+	c.CheckTimeout = timeout
+	return c
+}
+
+// WithCheckTimeouts adds timeouts of individual checks in the form CheckName=duration,
+// as configured with PFLT_CHECK_TIMEOUTS.
+func (c *Config) WithCheckTimeouts(timeouts ...string) *Config {
+	c.CheckTimeouts = append(c.CheckTimeouts, timeouts...)
+	return c
+}
+
+// WithDeniedCheckEgress denies the checks access to the network, except those named
+// allowed.
+func (c *Config) WithDeniedCheckEgress(allowed ...string) *Config {
+	c.DenyCheckEgress = true
+	c.AllowCheckEgress = append(c.AllowCheckEgress, allowed...)
+	return c
+}
+
+// WithExitCodes exits with failed when checks fail or error, with errored when preflight
+// itself errors, and with warning when checks pass, but failures were waived, or checks
+// only passed after they were executed again.
+func (c *Config) WithExitCodes(failed, errored, warning int) *Config {
+	c.ExitCodeFailed = failed
+	c.ExitCodeError = errored
+	c.ExitCodeWarning = warning
+	return c
+}
+
+// WithExpectedDigest fails the check if the manifest digest of the image that is
+// pulled is not digest, e.g. because its tag was moved after it was built.
+func (c *Config) WithExpectedDigest(digest string) *Config {
+	c.ExpectedDigest = digest
+	return c
+}
+
+// WithCompat pins the format of the results, the exit codes, and the meaning of the
+// options to those of the minor release version, e.g. 1.5.
+func (c *Config) WithCompat(version string) *Config {
+	c.Compat = version
+	return c
+}
+
+// WithServiceProbes probes the registry and Pyxis before executing any check.
+func (c *Config) WithServiceProbes() *Config {
+	c.ProbeServices = true
+	return c
+}
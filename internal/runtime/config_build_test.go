@@ -0,0 +1,94 @@
+package runtime
+
+import (
+	goruntime "runtime"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/artifacts"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Building a runtime Config", func() {
+	It("should have the defaults of the preflight binary", func() {
+		cfg := NewConfig()
+		Expect(cfg.LogFile).To(Equal(DefaultLogFile))
+		Expect(cfg.Artifacts).To(Equal(artifacts.DefaultArtifactsDir))
+		Expect(cfg.PyxisEnv).To(Equal(check.DefaultPyxisEnv))
+		Expect(cfg.PyxisHost).To(Equal(check.DefaultPyxisHost))
+		Expect(cfg.Platform).To(Equal(goruntime.GOARCH))
+		Expect(cfg.Namespace).To(Equal(DefaultNamespace))
+		Expect(cfg.ServiceAccount).To(Equal(DefaultServiceAccount))
+		Expect(cfg.ScorecardWaitTime).To(Equal(DefaultScorecardWaitTime))
+		Expect(cfg.ExitCodeError).To(Equal(DefaultExitCodeError))
+	})
+
+	It("should store the values of its With methods", func() {
+		cfg := NewConfig().
+			WithArtifactsDir("/tmp/artifacts").
+			WithPlatform("s390x").
+			WithDockerConfig("dockerconfig.json").
+			WithCertificationProject("certproject", "token").
+			WithPyxisEnv("qa").
+			WithInsecureConnection().
+			WithProxy("http://proxy.example.com:3128", ".example.com").
+			WithCABundle("/etc/pki/ca.pem").
+			WithRegistryCredentials("user", "pass", "").
+			WithRegistryMirrors("registry.redhat.io=mirror.example.com/redhat").
+			WithMirrorConfigFile("mirrors.yaml").
+			WithLockfile("preflight.lock").
+			WithUpdateLockfile().
+			WithTmpdir("/var/tmp").
+			WithApprovedBaseImages("registry.access.redhat.com/ubi9/ubi").
+			WithDecryptionKeys("private.pem").
+			WithTraceOnFailure().
+			WithFailFast().
+			WithCheckTimeout("10m").
+			WithCheckTimeouts("DeployableByOLM=30m").
+			WithDeniedCheckEgress("HasUniqueTag").
+			WithExitCodes(2, 3, 4).
+			WithExpectedDigest("sha256:0123").
+			WithCompat("1.5").
+			WithServiceProbes()
+
+		Expect(cfg.Artifacts).To(Equal("/tmp/artifacts"))
+		Expect(cfg.Platform).To(Equal("s390x"))
+		Expect(cfg.DockerConfig).To(Equal("dockerconfig.json"))
+		Expect(cfg.CertificationProjectID).To(Equal("certproject"))
+		Expect(cfg.PyxisAPIToken).To(Equal("token"))
+		Expect(cfg.PyxisEnv).To(Equal("qa"))
+		Expect(cfg.PyxisHost).To(Equal(PyxisHostLookup("qa", "")))
+		Expect(cfg.Insecure).To(BeTrue())
+		Expect(cfg.Proxy).To(Equal("http://proxy.example.com:3128"))
+		Expect(cfg.NoProxy).To(Equal(".example.com"))
+		Expect(cfg.CABundle).To(Equal("/etc/pki/ca.pem"))
+		Expect(cfg.RegistryUsername).To(Equal("user"))
+		Expect(cfg.RegistryPassword).To(Equal("pass"))
+		Expect(cfg.RegistryMirrors).To(ConsistOf("registry.redhat.io=mirror.example.com/redhat"))
+		Expect(cfg.MirrorConfig).To(Equal("mirrors.yaml"))
+		Expect(cfg.Lockfile).To(Equal("preflight.lock"))
+		Expect(cfg.UpdateLockfile).To(BeTrue())
+		Expect(cfg.Tmpdir).To(Equal("/var/tmp"))
+		Expect(cfg.ApprovedBaseImages).To(ConsistOf("registry.access.redhat.com/ubi9/ubi"))
+		Expect(cfg.DecryptionKeys).To(ConsistOf("private.pem"))
+		Expect(cfg.TraceOnFailure).To(BeTrue())
+		Expect(cfg.FailFast).To(BeTrue())
+		Expect(cfg.CheckTimeout).To(Equal("10m"))
+		Expect(cfg.CheckTimeouts).To(ConsistOf("DeployableByOLM=30m"))
+		Expect(cfg.DenyCheckEgress).To(BeTrue())
+		Expect(cfg.AllowCheckEgress).To(ConsistOf("HasUniqueTag"))
+		Expect(cfg.ExitCodeFailed).To(Equal(2))
+		Expect(cfg.ExitCodeError).To(Equal(3))
+		Expect(cfg.ExitCodeWarning).To(Equal(4))
+		Expect(cfg.ExpectedDigest).To(Equal("sha256:0123"))
+		Expect(cfg.Compat).To(Equal("1.5"))
+		Expect(cfg.ProbeServices).To(BeTrue())
+	})
+
+	It("should override the host of the Pyxis environment", func() {
+		cfg := NewConfig().WithPyxisEnv("qa").WithPyxisHost("pyxis.example.com")
+		Expect(cfg.PyxisEnv).To(Equal("qa"))
+		Expect(cfg.PyxisHost).To(Equal("pyxis.example.com"))
+	})
+})
@@ -42,6 +42,10 @@ func (ro *ReadOnlyConfig) CertificationProjectID() string {
 	return ro.cfg.CertificationProjectID
 }
 
+func (ro *ReadOnlyConfig) PyxisEnv() string {
+	return ro.cfg.PyxisEnv
+}
+
 func (ro *ReadOnlyConfig) PyxisHost() string {
 	return ro.cfg.PyxisHost
 }
@@ -50,6 +54,10 @@ func (ro *ReadOnlyConfig) PyxisAPIToken() string {
 	return ro.cfg.PyxisAPIToken
 }
 
+func (ro *ReadOnlyConfig) PyxisMaxQPS() float64 {
+	return ro.cfg.PyxisMaxQPS
+}
+
 func (ro *ReadOnlyConfig) DockerConfig() string {
 	return ro.cfg.DockerConfig
 }
@@ -58,6 +66,10 @@ func (ro *ReadOnlyConfig) Submit() bool {
 	return ro.cfg.Submit
 }
 
+func (ro *ReadOnlyConfig) SubmitDryRun() bool {
+	return ro.cfg.SubmitDryRun
+}
+
 func (ro *ReadOnlyConfig) Namespace() string {
 	return ro.cfg.Namespace
 }
@@ -86,6 +98,294 @@ func (ro *ReadOnlyConfig) WriteJUnit() bool {
 	return ro.cfg.WriteJUnit
 }
 
+func (ro *ReadOnlyConfig) WriteChecklist() bool {
+	return ro.cfg.WriteChecklist
+}
+
+func (ro *ReadOnlyConfig) EventsFile() string {
+	return ro.cfg.EventsFile
+}
+
+func (ro *ReadOnlyConfig) Deterministic() bool {
+	return ro.cfg.Deterministic
+}
+
+func (ro *ReadOnlyConfig) Progress() bool {
+	return ro.cfg.Progress
+}
+
+func (ro *ReadOnlyConfig) Quiet() bool {
+	return ro.cfg.Quiet
+}
+
+func (ro *ReadOnlyConfig) Summary() bool {
+	return ro.cfg.Summary
+}
+
+func (ro *ReadOnlyConfig) TraceOnFailure() bool {
+	return ro.cfg.TraceOnFailure
+}
+
+func (ro *ReadOnlyConfig) JUnitPath() string {
+	return ro.cfg.JUnitPath
+}
+
+func (ro *ReadOnlyConfig) Proxy() string {
+	return ro.cfg.Proxy
+}
+
+func (ro *ReadOnlyConfig) NoProxy() string {
+	return ro.cfg.NoProxy
+}
+
+func (ro *ReadOnlyConfig) CABundle() string {
+	return ro.cfg.CABundle
+}
+
+func (ro *ReadOnlyConfig) WriteCodeQuality() bool {
+	return ro.cfg.WriteCodeQuality
+}
+
+func (ro *ReadOnlyConfig) CI() string {
+	return ro.cfg.CI
+}
+
+func (ro *ReadOnlyConfig) RegistryMirrors() []string {
+	return ro.cfg.RegistryMirrors
+}
+
+func (ro *ReadOnlyConfig) MirrorConfig() string {
+	return ro.cfg.MirrorConfig
+}
+
+func (ro *ReadOnlyConfig) Watch() bool {
+	return ro.cfg.Watch
+}
+
+func (ro *ReadOnlyConfig) RegistryUsername() string {
+	return ro.cfg.RegistryUsername
+}
+
+func (ro *ReadOnlyConfig) RegistryPassword() string {
+	return ro.cfg.RegistryPassword
+}
+
+func (ro *ReadOnlyConfig) RegistryToken() string {
+	return ro.cfg.RegistryToken
+}
+
+func (ro *ReadOnlyConfig) CompareTo() string {
+	return ro.cfg.CompareTo
+}
+
+func (ro *ReadOnlyConfig) VaultAddress() string {
+	return ro.cfg.VaultAddress
+}
+
+func (ro *ReadOnlyConfig) VaultNamespace() string {
+	return ro.cfg.VaultNamespace
+}
+
+func (ro *ReadOnlyConfig) VaultToken() string {
+	return ro.cfg.VaultToken
+}
+
+func (ro *ReadOnlyConfig) VaultRoleID() string {
+	return ro.cfg.VaultRoleID
+}
+
+func (ro *ReadOnlyConfig) VaultSecretID() string {
+	return ro.cfg.VaultSecretID
+}
+
+func (ro *ReadOnlyConfig) VaultAppRoleMount() string {
+	return ro.cfg.VaultAppRoleMount
+}
+
+func (ro *ReadOnlyConfig) VaultPath() string {
+	return ro.cfg.VaultPath
+}
+
+func (ro *ReadOnlyConfig) DockerConfigSecret() string {
+	return ro.cfg.DockerConfigSecret
+}
+
+func (ro *ReadOnlyConfig) PyxisTokenSecret() string {
+	return ro.cfg.PyxisTokenSecret
+}
+
+func (ro *ReadOnlyConfig) ArtifactsQuota() string {
+	return ro.cfg.ArtifactsQuota
+}
+
+func (ro *ReadOnlyConfig) PyxisOIDCToken() string {
+	return ro.cfg.PyxisOIDCToken
+}
+
+func (ro *ReadOnlyConfig) PyxisOIDCTokenFile() string {
+	return ro.cfg.PyxisOIDCTokenFile
+}
+
+func (ro *ReadOnlyConfig) PyxisOIDCClientID() string {
+	return ro.cfg.PyxisOIDCClientID
+}
+
+func (ro *ReadOnlyConfig) PyxisOIDCTokenURL() string {
+	return ro.cfg.PyxisOIDCTokenURL
+}
+
+func (ro *ReadOnlyConfig) SubmitOffline() bool {
+	return ro.cfg.SubmitOffline
+}
+
+func (ro *ReadOnlyConfig) SubmitToURL() string {
+	return ro.cfg.SubmitToURL
+}
+
+func (ro *ReadOnlyConfig) SubmitToURLSecret() string {
+	return ro.cfg.SubmitToURLSecret
+}
+
+func (ro *ReadOnlyConfig) CheckAttempts() int {
+	return ro.cfg.CheckAttempts
+}
+
+func (ro *ReadOnlyConfig) ProbeServices() bool {
+	return ro.cfg.ProbeServices
+}
+
+func (ro *ReadOnlyConfig) ArtifactArchive() string {
+	return ro.cfg.ArtifactArchive
+}
+
+func (ro *ReadOnlyConfig) MarkSubmitted() string {
+	return ro.cfg.MarkSubmitted
+}
+
+func (ro *ReadOnlyConfig) OpenSearchURL() string {
+	return ro.cfg.OpenSearchURL
+}
+
+func (ro *ReadOnlyConfig) OpenSearchAPIKey() string {
+	return ro.cfg.OpenSearchAPIKey
+}
+
+func (ro *ReadOnlyConfig) IssueTrackerConfig() string {
+	return ro.cfg.IssueTrackerConfig
+}
+
+func (ro *ReadOnlyConfig) ExceptionsFile() string {
+	return ro.cfg.ExceptionsFile
+}
+
+func (ro *ReadOnlyConfig) ImageResolver() string {
+	return ro.cfg.ImageResolver
+}
+
+func (ro *ReadOnlyConfig) PostRunCommand() string {
+	return ro.cfg.PostRunCommand
+}
+
+func (ro *ReadOnlyConfig) KnownIssuesFeed() string {
+	return ro.cfg.KnownIssuesFeed
+}
+
+func (ro *ReadOnlyConfig) OperabilityCheck() string {
+	return ro.cfg.OperabilityCheck
+}
+
+func (ro *ReadOnlyConfig) ClusterProvider() string {
+	return ro.cfg.ClusterProvider
+}
+
+func (ro *ReadOnlyConfig) ClusterProviderURL() string {
+	return ro.cfg.ClusterProviderURL
+}
+
+func (ro *ReadOnlyConfig) ClusterProviderToken() string {
+	return ro.cfg.ClusterProviderToken
+}
+
+func (ro *ReadOnlyConfig) OCMToken() string {
+	return ro.cfg.OCMToken
+}
+
+func (ro *ReadOnlyConfig) ManagedClusterProduct() string {
+	return ro.cfg.ManagedClusterProduct
+}
+
+func (ro *ReadOnlyConfig) ManagedClusterRegion() string {
+	return ro.cfg.ManagedClusterRegion
+}
+
+func (ro *ReadOnlyConfig) ManagedClusterVersion() string {
+	return ro.cfg.ManagedClusterVersion
+}
+
+func (ro *ReadOnlyConfig) ManagedClusterAWSAccountID() string {
+	return ro.cfg.ManagedClusterAWSAccountID
+}
+
+func (ro *ReadOnlyConfig) ClusterProxy() string {
+	return ro.cfg.ClusterProxy
+}
+
+func (ro *ReadOnlyConfig) Lockfile() string {
+	return ro.cfg.Lockfile
+}
+
+func (ro *ReadOnlyConfig) UpdateLockfile() bool {
+	return ro.cfg.UpdateLockfile
+}
+
+func (ro *ReadOnlyConfig) Tmpdir() string {
+	return ro.cfg.Tmpdir
+}
+
+func (ro *ReadOnlyConfig) RerunFailed() string {
+	return ro.cfg.RerunFailed
+}
+
+func (ro *ReadOnlyConfig) FailFast() bool {
+	return ro.cfg.FailFast
+}
+
+func (ro *ReadOnlyConfig) CheckTimeout() string {
+	return ro.cfg.CheckTimeout
+}
+
+func (ro *ReadOnlyConfig) CheckTimeouts() []string {
+	return ro.cfg.CheckTimeouts
+}
+
+func (ro *ReadOnlyConfig) DenyCheckEgress() bool {
+	return ro.cfg.DenyCheckEgress
+}
+
+func (ro *ReadOnlyConfig) AllowCheckEgress() []string {
+	return ro.cfg.AllowCheckEgress
+}
+
+func (ro *ReadOnlyConfig) ExitCodeFailed() int {
+	return ro.cfg.ExitCodeFailed
+}
+
+func (ro *ReadOnlyConfig) ExitCodeError() int {
+	return ro.cfg.ExitCodeError
+}
+
+func (ro *ReadOnlyConfig) ExitCodeWarning() int {
+	return ro.cfg.ExitCodeWarning
+}
+
+func (ro *ReadOnlyConfig) ExpectedDigest() string {
+	return ro.cfg.ExpectedDigest
+}
+
+func (ro *ReadOnlyConfig) Compat() string {
+	return ro.cfg.Compat
+}
+
 func (ro *ReadOnlyConfig) Kubeconfig() string {
 	return ro.cfg.Kubeconfig
 }
@@ -101,3 +401,15 @@ func (ro *ReadOnlyConfig) Platform() string {
 func (ro *ReadOnlyConfig) Insecure() bool {
 	return ro.cfg.Insecure
 }
+
+func (ro *ReadOnlyConfig) AttachResults() bool {
+	return ro.cfg.AttachResults
+}
+
+func (ro *ReadOnlyConfig) ApprovedBaseImages() []string {
+	return ro.cfg.ApprovedBaseImages
+}
+
+func (ro *ReadOnlyConfig) DecryptionKeys() []string {
+	return ro.cfg.DecryptionKeys
+}
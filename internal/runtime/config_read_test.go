@@ -8,28 +8,106 @@ import (
 var _ = Describe("Runtime ReadOnlyConfig test", func() {
 	Context("When calling ReadOnly on a config", func() {
 		c := &Config{
-			Image:                  "image",
-			Policy:                 "policy",
-			ResponseFormat:         "format",
-			Bundle:                 true,
-			Scratch:                true,
-			LogFile:                "logfile",
-			Artifacts:              "artifacts",
-			WriteJUnit:             true,
-			CertificationProjectID: "certprojid",
-			PyxisHost:              "pyxishost",
-			PyxisAPIToken:          "pyxisapitoken",
-			DockerConfig:           "dockercfg",
-			Submit:                 true,
-			Platform:               "s390x",
-			Insecure:               true,
-			Namespace:              "ns",
-			ServiceAccount:         "sa",
-			ScorecardImage:         "scorecardimg",
-			ScorecardWaitTime:      "waittime",
-			Channel:                "channel",
-			IndexImage:             "indeximg",
-			Kubeconfig:             "kubeconfig",
+			Image:                      "image",
+			Policy:                     "policy",
+			ResponseFormat:             "format",
+			Bundle:                     true,
+			Scratch:                    true,
+			LogFile:                    "logfile",
+			Artifacts:                  "artifacts",
+			WriteJUnit:                 true,
+			WriteChecklist:             true,
+			EventsFile:                 "events.ndjson",
+			Deterministic:              true,
+			Progress:                   true,
+			Quiet:                      true,
+			Summary:                    true,
+			TraceOnFailure:             true,
+			PyxisMaxQPS:                2.5,
+			JUnitPath:                  "reports/preflight.xml",
+			Proxy:                      "http://proxy.example.com:3128",
+			NoProxy:                    ".example.com",
+			CABundle:                   "/etc/pki/ca.pem",
+			WriteCodeQuality:           true,
+			CI:                         "azure",
+			RegistryMirrors:            []string{"registry.redhat.io=mirror.local/redhat"},
+			MirrorConfig:               "/etc/preflight/idms.yaml",
+			Watch:                      true,
+			RegistryUsername:           "robot",
+			RegistryPassword:           "secret",
+			RegistryToken:              "token",
+			CompareTo:                  "quay.io/example/image:1.0",
+			ApprovedBaseImages:         []string{"registry.access.redhat.com/ubi9/ubi"},
+			DecryptionKeys:             []string{"private.pem"},
+			VaultAddress:               "https://vault.example.com:8200",
+			VaultNamespace:             "ns",
+			VaultToken:                 "s.token",
+			VaultRoleID:                "role",
+			VaultSecretID:              "secretid",
+			VaultAppRoleMount:          "approle",
+			VaultPath:                  "secret/data/preflight",
+			DockerConfigSecret:         "preflight/registry",
+			PyxisTokenSecret:           "pyxis:token",
+			ArtifactsQuota:             "500Mi",
+			PyxisOIDCToken:             "oidc-token",
+			PyxisOIDCTokenFile:         "/var/run/secrets/tokens/pyxis",
+			PyxisOIDCClientID:          "preflight",
+			PyxisOIDCTokenURL:          "https://sso.example.com/token",
+			SubmitOffline:              true,
+			SubmitToURL:                "https://compliance.example.com/preflight",
+			SubmitToURLSecret:          "webhooksecret",
+			ProbeServices:              true,
+			ArtifactArchive:            "results.tar.gz",
+			MarkSubmitted:              "tag",
+			OpenSearchURL:              "https://search.example.com:9200/preflight-results",
+			OpenSearchAPIKey:           "opensearchkey",
+			IssueTrackerConfig:         "issue-tracker.yaml",
+			ExceptionsFile:             "/path/to/exceptions.yaml",
+			PyxisEnv:                   "prod",
+			ImageResolver:              "/usr/local/bin/resolve-image",
+			PostRunCommand:             "/usr/local/bin/notify",
+			KnownIssuesFeed:            "https://status.example.com/preflight/known-issues.yaml",
+			OperabilityCheck:           "basic",
+			ClusterProvider:            "hosted",
+			ClusterProviderURL:         "https://clusters.example.com/leases",
+			ClusterProviderToken:       "leasetoken",
+			OCMToken:                   "offlinetoken",
+			ManagedClusterProduct:      "rosa",
+			ManagedClusterRegion:       "us-east-1",
+			ManagedClusterVersion:      "4.14.8",
+			ManagedClusterAWSAccountID: "123456789012",
+			ClusterProxy:               "socks5://bastion.example.com:1080",
+			Lockfile:                   "preflight.lock",
+			UpdateLockfile:             true,
+			Tmpdir:                     "/var/tmp",
+			RerunFailed:                "artifacts/results.json",
+			FailFast:                   true,
+			CheckTimeout:               "10m",
+			CheckTimeouts:              []string{"DeployableByOLM=30m"},
+			DenyCheckEgress:            true,
+			AllowCheckEgress:           []string{"HasUniqueTag"},
+			ExitCodeFailed:             2,
+			ExitCodeError:              3,
+			ExitCodeWarning:            4,
+			ExpectedDigest:             "sha256:0123",
+			Compat:                     "1.5",
+			CertificationProjectID:     "certprojid",
+			PyxisHost:                  "pyxishost",
+			PyxisAPIToken:              "pyxisapitoken",
+			DockerConfig:               "dockercfg",
+			Submit:                     true,
+			SubmitDryRun:               true,
+			Platform:                   "s390x",
+			Insecure:                   true,
+			AttachResults:              true,
+			Namespace:                  "ns",
+			ServiceAccount:             "sa",
+			ScorecardImage:             "scorecardimg",
+			ScorecardWaitTime:          "waittime",
+			CheckAttempts:              3,
+			Channel:                    "channel",
+			IndexImage:                 "indeximg",
+			Kubeconfig:                 "kubeconfig",
 		}
 		cro := c.ReadOnly()
 		It("should return values assigned to corresponding struct fields", func() {
@@ -41,17 +119,95 @@ var _ = Describe("Runtime ReadOnlyConfig test", func() {
 			Expect(cro.LogFile()).To(Equal("logfile"))
 			Expect(cro.Artifacts()).To(Equal("artifacts"))
 			Expect(cro.WriteJUnit()).To(Equal(true))
+			Expect(cro.WriteChecklist()).To(BeTrue())
+			Expect(cro.EventsFile()).To(Equal("events.ndjson"))
+			Expect(cro.Deterministic()).To(BeTrue())
+			Expect(cro.Progress()).To(BeTrue())
+			Expect(cro.Quiet()).To(BeTrue())
+			Expect(cro.Summary()).To(BeTrue())
+			Expect(cro.TraceOnFailure()).To(BeTrue())
+			Expect(cro.PyxisMaxQPS()).To(Equal(2.5))
+			Expect(cro.JUnitPath()).To(Equal("reports/preflight.xml"))
+			Expect(cro.Proxy()).To(Equal("http://proxy.example.com:3128"))
+			Expect(cro.NoProxy()).To(Equal(".example.com"))
+			Expect(cro.CABundle()).To(Equal("/etc/pki/ca.pem"))
+			Expect(cro.WriteCodeQuality()).To(BeTrue())
+			Expect(cro.CI()).To(Equal("azure"))
+			Expect(cro.RegistryMirrors()).To(Equal([]string{"registry.redhat.io=mirror.local/redhat"}))
+			Expect(cro.MirrorConfig()).To(Equal("/etc/preflight/idms.yaml"))
+			Expect(cro.Watch()).To(BeTrue())
+			Expect(cro.RegistryUsername()).To(Equal("robot"))
+			Expect(cro.RegistryPassword()).To(Equal("secret"))
+			Expect(cro.RegistryToken()).To(Equal("token"))
+			Expect(cro.CompareTo()).To(Equal("quay.io/example/image:1.0"))
+			Expect(cro.ApprovedBaseImages()).To(Equal([]string{"registry.access.redhat.com/ubi9/ubi"}))
+			Expect(cro.DecryptionKeys()).To(Equal([]string{"private.pem"}))
+			Expect(cro.VaultAddress()).To(Equal("https://vault.example.com:8200"))
+			Expect(cro.VaultNamespace()).To(Equal("ns"))
+			Expect(cro.VaultToken()).To(Equal("s.token"))
+			Expect(cro.VaultRoleID()).To(Equal("role"))
+			Expect(cro.VaultSecretID()).To(Equal("secretid"))
+			Expect(cro.VaultAppRoleMount()).To(Equal("approle"))
+			Expect(cro.VaultPath()).To(Equal("secret/data/preflight"))
+			Expect(cro.DockerConfigSecret()).To(Equal("preflight/registry"))
+			Expect(cro.PyxisTokenSecret()).To(Equal("pyxis:token"))
+			Expect(cro.ArtifactsQuota()).To(Equal("500Mi"))
+			Expect(cro.PyxisOIDCToken()).To(Equal("oidc-token"))
+			Expect(cro.PyxisOIDCTokenFile()).To(Equal("/var/run/secrets/tokens/pyxis"))
+			Expect(cro.PyxisOIDCClientID()).To(Equal("preflight"))
+			Expect(cro.PyxisOIDCTokenURL()).To(Equal("https://sso.example.com/token"))
+			Expect(cro.SubmitOffline()).To(BeTrue())
+			Expect(cro.SubmitToURL()).To(Equal("https://compliance.example.com/preflight"))
+			Expect(cro.SubmitToURLSecret()).To(Equal("webhooksecret"))
+			Expect(cro.ProbeServices()).To(BeTrue())
+			Expect(cro.ArtifactArchive()).To(Equal("results.tar.gz"))
+			Expect(cro.MarkSubmitted()).To(Equal("tag"))
+			Expect(cro.OpenSearchURL()).To(Equal("https://search.example.com:9200/preflight-results"))
+			Expect(cro.OpenSearchAPIKey()).To(Equal("opensearchkey"))
+			Expect(cro.IssueTrackerConfig()).To(Equal("issue-tracker.yaml"))
+			Expect(cro.ExceptionsFile()).To(Equal("/path/to/exceptions.yaml"))
+			Expect(cro.PyxisEnv()).To(Equal("prod"))
+			Expect(cro.ImageResolver()).To(Equal("/usr/local/bin/resolve-image"))
+			Expect(cro.PostRunCommand()).To(Equal("/usr/local/bin/notify"))
+			Expect(cro.KnownIssuesFeed()).To(Equal("https://status.example.com/preflight/known-issues.yaml"))
+			Expect(cro.OperabilityCheck()).To(Equal("basic"))
+			Expect(cro.ClusterProvider()).To(Equal("hosted"))
+			Expect(cro.ClusterProviderURL()).To(Equal("https://clusters.example.com/leases"))
+			Expect(cro.ClusterProviderToken()).To(Equal("leasetoken"))
+			Expect(cro.OCMToken()).To(Equal("offlinetoken"))
+			Expect(cro.ManagedClusterProduct()).To(Equal("rosa"))
+			Expect(cro.ManagedClusterRegion()).To(Equal("us-east-1"))
+			Expect(cro.ManagedClusterVersion()).To(Equal("4.14.8"))
+			Expect(cro.ManagedClusterAWSAccountID()).To(Equal("123456789012"))
+			Expect(cro.ClusterProxy()).To(Equal("socks5://bastion.example.com:1080"))
+			Expect(cro.Lockfile()).To(Equal("preflight.lock"))
+			Expect(cro.UpdateLockfile()).To(BeTrue())
+			Expect(cro.Tmpdir()).To(Equal("/var/tmp"))
+			Expect(cro.RerunFailed()).To(Equal("artifacts/results.json"))
+			Expect(cro.FailFast()).To(BeTrue())
+			Expect(cro.CheckTimeout()).To(Equal("10m"))
+			Expect(cro.CheckTimeouts()).To(Equal([]string{"DeployableByOLM=30m"}))
+			Expect(cro.DenyCheckEgress()).To(BeTrue())
+			Expect(cro.AllowCheckEgress()).To(Equal([]string{"HasUniqueTag"}))
+			Expect(cro.ExitCodeFailed()).To(Equal(2))
+			Expect(cro.ExitCodeError()).To(Equal(3))
+			Expect(cro.ExitCodeWarning()).To(Equal(4))
+			Expect(cro.ExpectedDigest()).To(Equal("sha256:0123"))
+			Expect(cro.Compat()).To(Equal("1.5"))
 			Expect(cro.CertificationProjectID()).To(Equal("certprojid"))
 			Expect(cro.PyxisHost()).To(Equal("pyxishost"))
 			Expect(cro.PyxisAPIToken()).To(Equal("pyxisapitoken"))
 			Expect(cro.DockerConfig()).To(Equal("dockercfg"))
 			Expect(cro.Submit()).To(Equal(true))
+			Expect(cro.SubmitDryRun()).To(BeTrue())
 			Expect(cro.Platform()).To(Equal("s390x"))
 			Expect(cro.Insecure()).To(BeTrue())
+			Expect(cro.AttachResults()).To(BeTrue())
 			Expect(cro.Namespace()).To(Equal("ns"))
 			Expect(cro.ServiceAccount()).To(Equal("sa"))
 			Expect(cro.ScorecardImage()).To(Equal("scorecardimg"))
 			Expect(cro.ScorecardWaitTime()).To(Equal("waittime"))
+			Expect(cro.CheckAttempts()).To(Equal(3))
 			Expect(cro.Channel()).To(Equal("channel"))
 			Expect(cro.IndexImage()).To(Equal("indeximg"))
 			Expect(cro.Kubeconfig()).To(Equal("kubeconfig"))
@@ -2,6 +2,7 @@ package runtime
 
 import (
 	"os"
+	"path/filepath"
 	"reflect"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -28,11 +29,163 @@ var _ = Describe("Viper to Runtime Config", func() {
 		expectedRuntimeCfg.Artifacts = "artifacts"
 		baseViperCfg.Set("junit", true)
 		expectedRuntimeCfg.WriteJUnit = true
+		baseViperCfg.Set("checklist", true)
+		expectedRuntimeCfg.WriteChecklist = true
+		baseViperCfg.Set("events_file", "events.ndjson")
+		expectedRuntimeCfg.EventsFile = "events.ndjson"
+		baseViperCfg.Set("deterministic", true)
+		expectedRuntimeCfg.Deterministic = true
+		baseViperCfg.Set("progress", true)
+		expectedRuntimeCfg.Progress = true
+		baseViperCfg.Set("quiet", true)
+		expectedRuntimeCfg.Quiet = true
+		baseViperCfg.Set("summary", true)
+		expectedRuntimeCfg.Summary = true
+		baseViperCfg.Set("trace_on_failure", true)
+		expectedRuntimeCfg.TraceOnFailure = true
+		baseViperCfg.Set("pyxis_max_qps", 2.5)
+		expectedRuntimeCfg.PyxisMaxQPS = 2.5
+		baseViperCfg.Set("junit_path", "reports/preflight.xml")
+		expectedRuntimeCfg.JUnitPath = "reports/preflight.xml"
+		baseViperCfg.Set("https_proxy", "http://proxy.example.com:3128")
+		expectedRuntimeCfg.Proxy = "http://proxy.example.com:3128"
+		baseViperCfg.Set("no_proxy", ".example.com")
+		expectedRuntimeCfg.NoProxy = ".example.com"
+		baseViperCfg.Set("ca_bundle", "/etc/pki/ca.pem")
+		expectedRuntimeCfg.CABundle = "/etc/pki/ca.pem"
+		baseViperCfg.Set("gitlab_codequality", true)
+		expectedRuntimeCfg.WriteCodeQuality = true
+		baseViperCfg.Set("ci", "azure")
+		expectedRuntimeCfg.CI = "azure"
+		baseViperCfg.Set("registry_mirrors", []string{"registry.redhat.io=mirror.local/redhat"})
+		expectedRuntimeCfg.RegistryMirrors = []string{"registry.redhat.io=mirror.local/redhat"}
+		baseViperCfg.Set("mirror_config", "/etc/preflight/idms.yaml")
+		expectedRuntimeCfg.MirrorConfig = "/etc/preflight/idms.yaml"
+		baseViperCfg.Set("watch", true)
+		expectedRuntimeCfg.Watch = true
+		baseViperCfg.Set("registry_username", "robot")
+		expectedRuntimeCfg.RegistryUsername = "robot"
+		baseViperCfg.Set("registry_password", "secret")
+		expectedRuntimeCfg.RegistryPassword = "secret"
+		baseViperCfg.Set("registry_token", "token")
+		expectedRuntimeCfg.RegistryToken = "token"
+		baseViperCfg.Set("compare_to", "quay.io/example/image:1.0")
+		expectedRuntimeCfg.CompareTo = "quay.io/example/image:1.0"
+		baseViperCfg.Set("approved_base_images", []string{"registry.access.redhat.com/ubi9/ubi"})
+		expectedRuntimeCfg.ApprovedBaseImages = []string{"registry.access.redhat.com/ubi9/ubi"}
+		baseViperCfg.Set("decryption_keys", []string{"private.pem"})
+		expectedRuntimeCfg.DecryptionKeys = []string{"private.pem"}
+		baseViperCfg.Set("vault_addr", "https://vault.example.com:8200")
+		expectedRuntimeCfg.VaultAddress = "https://vault.example.com:8200"
+		baseViperCfg.Set("vault_namespace", "ns")
+		expectedRuntimeCfg.VaultNamespace = "ns"
+		baseViperCfg.Set("vault_token", "s.token")
+		expectedRuntimeCfg.VaultToken = "s.token"
+		baseViperCfg.Set("vault_role_id", "role")
+		expectedRuntimeCfg.VaultRoleID = "role"
+		baseViperCfg.Set("vault_secret_id", "secretid")
+		expectedRuntimeCfg.VaultSecretID = "secretid"
+		baseViperCfg.Set("vault_approle_mount", "approle")
+		expectedRuntimeCfg.VaultAppRoleMount = "approle"
+		baseViperCfg.Set("vault_path", "secret/data/preflight")
+		expectedRuntimeCfg.VaultPath = "secret/data/preflight"
+		baseViperCfg.Set("docker_config_secret", "preflight/registry")
+		expectedRuntimeCfg.DockerConfigSecret = "preflight/registry"
+		baseViperCfg.Set("pyxis_token_secret", "pyxis:token")
+		expectedRuntimeCfg.PyxisTokenSecret = "pyxis:token"
+		baseViperCfg.Set("artifacts_quota", "500Mi")
+		expectedRuntimeCfg.ArtifactsQuota = "500Mi"
+		baseViperCfg.Set("pyxis_oidc_token", "oidc-token")
+		expectedRuntimeCfg.PyxisOIDCToken = "oidc-token"
+		baseViperCfg.Set("pyxis_oidc_token_file", "/var/run/secrets/tokens/pyxis")
+		expectedRuntimeCfg.PyxisOIDCTokenFile = "/var/run/secrets/tokens/pyxis"
+		baseViperCfg.Set("pyxis_oidc_client_id", "preflight")
+		expectedRuntimeCfg.PyxisOIDCClientID = "preflight"
+		baseViperCfg.Set("pyxis_oidc_token_url", "https://sso.example.com/token")
+		expectedRuntimeCfg.PyxisOIDCTokenURL = "https://sso.example.com/token"
+		baseViperCfg.Set("submit_offline", true)
+		expectedRuntimeCfg.SubmitOffline = true
+		baseViperCfg.Set("submit_to_url", "https://compliance.example.com/preflight")
+		expectedRuntimeCfg.SubmitToURL = "https://compliance.example.com/preflight"
+		baseViperCfg.Set("submit_to_url_secret", "webhooksecret")
+		expectedRuntimeCfg.SubmitToURLSecret = "webhooksecret"
+		baseViperCfg.Set("probe_services", true)
+		expectedRuntimeCfg.ProbeServices = true
+		baseViperCfg.Set("artifact_archive", "results.tar.gz")
+		expectedRuntimeCfg.ArtifactArchive = "results.tar.gz"
+		baseViperCfg.Set("mark_submitted", "tag")
+		expectedRuntimeCfg.MarkSubmitted = "tag"
+		baseViperCfg.Set("opensearch_url", "https://search.example.com:9200/preflight-results")
+		expectedRuntimeCfg.OpenSearchURL = "https://search.example.com:9200/preflight-results"
+		baseViperCfg.Set("opensearch_api_key", "opensearchkey")
+		expectedRuntimeCfg.OpenSearchAPIKey = "opensearchkey"
+		baseViperCfg.Set("issue_tracker_config", "issue-tracker.yaml")
+		expectedRuntimeCfg.IssueTrackerConfig = "issue-tracker.yaml"
+		baseViperCfg.Set("exceptions_file", "/path/to/exceptions.yaml")
+		expectedRuntimeCfg.ExceptionsFile = "/path/to/exceptions.yaml"
+		baseViperCfg.Set("pyxis_env", "prod")
+		expectedRuntimeCfg.PyxisEnv = "prod"
+		baseViperCfg.Set("image_resolver", "/usr/local/bin/resolve-image")
+		expectedRuntimeCfg.ImageResolver = "/usr/local/bin/resolve-image"
+		baseViperCfg.Set("post_run_cmd", "/usr/local/bin/notify")
+		expectedRuntimeCfg.PostRunCommand = "/usr/local/bin/notify"
+		baseViperCfg.Set("known_issues_feed", "https://status.example.com/preflight/known-issues.yaml")
+		expectedRuntimeCfg.KnownIssuesFeed = "https://status.example.com/preflight/known-issues.yaml"
+		baseViperCfg.Set("operability_check", "basic")
+		expectedRuntimeCfg.OperabilityCheck = "basic"
+		baseViperCfg.Set("cluster_provider", "hosted")
+		expectedRuntimeCfg.ClusterProvider = "hosted"
+		baseViperCfg.Set("cluster_provider_url", "https://clusters.example.com/leases")
+		expectedRuntimeCfg.ClusterProviderURL = "https://clusters.example.com/leases"
+		baseViperCfg.Set("cluster_provider_token", "leasetoken")
+		expectedRuntimeCfg.ClusterProviderToken = "leasetoken"
+		baseViperCfg.Set("ocm_token", "offlinetoken")
+		expectedRuntimeCfg.OCMToken = "offlinetoken"
+		baseViperCfg.Set("managed_cluster_product", "rosa")
+		expectedRuntimeCfg.ManagedClusterProduct = "rosa"
+		baseViperCfg.Set("managed_cluster_region", "us-east-1")
+		expectedRuntimeCfg.ManagedClusterRegion = "us-east-1"
+		baseViperCfg.Set("managed_cluster_version", "4.14.8")
+		expectedRuntimeCfg.ManagedClusterVersion = "4.14.8"
+		baseViperCfg.Set("managed_cluster_aws_account_id", "123456789012")
+		expectedRuntimeCfg.ManagedClusterAWSAccountID = "123456789012"
+		baseViperCfg.Set("cluster_proxy", "socks5://bastion.example.com:1080")
+		expectedRuntimeCfg.ClusterProxy = "socks5://bastion.example.com:1080"
+		baseViperCfg.Set("lockfile", "preflight.lock")
+		expectedRuntimeCfg.Lockfile = "preflight.lock"
+		baseViperCfg.Set("update_lockfile", true)
+		expectedRuntimeCfg.UpdateLockfile = true
+		baseViperCfg.Set("tmpdir", "/var/tmp")
+		expectedRuntimeCfg.Tmpdir = "/var/tmp"
+		baseViperCfg.Set("rerun_failed", "artifacts/results.json")
+		expectedRuntimeCfg.RerunFailed = "artifacts/results.json"
+		baseViperCfg.Set("fail_fast", true)
+		expectedRuntimeCfg.FailFast = true
+		baseViperCfg.Set("check_timeout", "10m")
+		expectedRuntimeCfg.CheckTimeout = "10m"
+		baseViperCfg.Set("check_timeouts", []string{"DeployableByOLM=30m"})
+		expectedRuntimeCfg.CheckTimeouts = []string{"DeployableByOLM=30m"}
+		baseViperCfg.Set("deny_check_egress", true)
+		expectedRuntimeCfg.DenyCheckEgress = true
+		baseViperCfg.Set("allow_check_egress", []string{"HasUniqueTag"})
+		expectedRuntimeCfg.AllowCheckEgress = []string{"HasUniqueTag"}
+		baseViperCfg.Set("exit_code_failed", 2)
+		expectedRuntimeCfg.ExitCodeFailed = 2
+		baseViperCfg.Set("exit_code_error", 3)
+		expectedRuntimeCfg.ExitCodeError = 3
+		baseViperCfg.Set("exit_code_warning", 4)
+		expectedRuntimeCfg.ExitCodeWarning = 4
+		baseViperCfg.Set("expected_digest", "sha256:0123")
+		expectedRuntimeCfg.ExpectedDigest = "sha256:0123"
+		baseViperCfg.Set("compat", "1.5")
+		expectedRuntimeCfg.Compat = "1.5"
 
 		baseViperCfg.Set("pyxis_api_token", "apitoken")
 		expectedRuntimeCfg.PyxisAPIToken = "apitoken"
 		baseViperCfg.Set("submit", true)
 		expectedRuntimeCfg.Submit = true
+		baseViperCfg.Set("submit_dry_run", true)
+		expectedRuntimeCfg.SubmitDryRun = true
 		baseViperCfg.Set("pyxis_env", "prod")
 		expectedRuntimeCfg.PyxisHost = "catalog.redhat.com/api/containers"
 		baseViperCfg.Set("certification_project_id", "000000000000")
@@ -41,6 +194,8 @@ var _ = Describe("Viper to Runtime Config", func() {
 		expectedRuntimeCfg.Platform = "s390x"
 		baseViperCfg.Set("insecure", true)
 		expectedRuntimeCfg.Insecure = true
+		baseViperCfg.Set("attach_results", true)
+		expectedRuntimeCfg.AttachResults = true
 
 		baseViperCfg.Set("namespace", "myns")
 		expectedRuntimeCfg.Namespace = "myns"
@@ -54,6 +209,8 @@ var _ = Describe("Viper to Runtime Config", func() {
 		expectedRuntimeCfg.Channel = "mychannel"
 		baseViperCfg.Set("indeximage", "myindeximage")
 		expectedRuntimeCfg.IndexImage = "myindeximage"
+		baseViperCfg.Set("cluster_check_attempts", 3)
+		expectedRuntimeCfg.CheckAttempts = 3
 	})
 
 	Context("With values in a viper config", func() {
@@ -64,12 +221,69 @@ var _ = Describe("Viper to Runtime Config", func() {
 		})
 	})
 
+	Context("With secrets in files", func() {
+		var secretsDir string
+
+		BeforeEach(func() {
+			secretsDir = GinkgoT().TempDir()
+			Expect(os.WriteFile(filepath.Join(secretsDir, "pyxis-api-token"), []byte("filetoken\n"), 0o600)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(secretsDir, "registry-password"), []byte("filepassword"), 0o600)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(secretsDir, "empty"), []byte("\n"), 0o600)).To(Succeed())
+
+			baseViperCfg.Set("pyxis_api_token", "")
+			baseViperCfg.Set("registry_password", "")
+			baseViperCfg.Set("registry_token", "")
+			baseViperCfg.Set("submit_to_url_secret", "")
+		})
+
+		It("should read the secrets from the files without surrounding whitespace", func() {
+			baseViperCfg.Set("pyxis_api_token_file", filepath.Join(secretsDir, "pyxis-api-token"))
+			baseViperCfg.Set("registry_password_file", filepath.Join(secretsDir, "registry-password"))
+
+			cfg, err := NewConfigFrom(*baseViperCfg)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cfg.PyxisAPIToken).To(Equal("filetoken"))
+			Expect(cfg.RegistryPassword).To(Equal("filepassword"))
+			Expect(cfg.RegistryToken).To(BeEmpty())
+		})
+
+		It("should read the secret to sign webhook requests with from a file", func() {
+			baseViperCfg.Set("submit_to_url_secret_file", filepath.Join(secretsDir, "registry-password"))
+
+			cfg, err := NewConfigFrom(*baseViperCfg)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cfg.SubmitToURLSecret).To(Equal("filepassword"))
+		})
+
+		It("should not allow a secret and its file to both be set", func() {
+			baseViperCfg.Set("registry_token", "token")
+			baseViperCfg.Set("registry_token_file", filepath.Join(secretsDir, "registry-password"))
+
+			_, err := NewConfigFrom(*baseViperCfg)
+			Expect(err).To(MatchError(ContainSubstring("only one of registry_token and registry_token_file can be set")))
+		})
+
+		It("should fail if a file cannot be read", func() {
+			baseViperCfg.Set("pyxis_api_token_file", filepath.Join(secretsDir, "missing"))
+
+			_, err := NewConfigFrom(*baseViperCfg)
+			Expect(err).To(MatchError(ContainSubstring("could not read secret")))
+		})
+
+		It("should fail if a file is empty", func() {
+			baseViperCfg.Set("pyxis_api_token_file", filepath.Join(secretsDir, "empty"))
+
+			_, err := NewConfigFrom(*baseViperCfg)
+			Expect(err).To(MatchError(ContainSubstring("is empty")))
+		})
+	})
+
 	It("should only have 20 struct keys for tests to be valid", func() {
 		// If this test fails, it means a developer has added or removed
 		// keys from runtime.Config, and so these tests may no longer be
 		// accurate in confirming that the derived configuration from viper
 		// matches.
 		keys := reflect.TypeOf(Config{}).NumField()
-		Expect(keys).To(Equal(22))
+		Expect(keys).To(Equal(100))
 	})
 })
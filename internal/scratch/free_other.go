@@ -0,0 +1,8 @@
+//go:build !unix
+
+package scratch
+
+// freeSpace returns false, as the free space of filesystems is only read on Unix.
+func freeSpace(dir string) (int64, bool, error) {
+	return 0, false, nil
+}
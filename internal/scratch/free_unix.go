@@ -0,0 +1,24 @@
+//go:build unix
+
+package scratch
+
+import (
+	"math"
+	"syscall"
+)
+
+// freeSpace returns the bytes available to unprivileged users in the filesystem of dir,
+// and true.
+func freeSpace(dir string) (int64, bool, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return 0, false, err
+	}
+
+	available := uint64(st.Bavail) * uint64(st.Bsize) //nolint: unconvert // the types differ by platform
+	if available > math.MaxInt64 {
+		return math.MaxInt64, true, nil
+	}
+
+	return int64(available), true, nil
+}
@@ -0,0 +1,59 @@
+// Package scratch configures the directory images are extracted to, and checks that it
+// has enough free space before they are, so that an extraction that would fill the disk,
+// e.g. /tmp on a small CI runner, fails with a clear error instead.
+package scratch
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrInsufficientSpace is returned for a scratch directory that does not have the free
+// space an image needs to be extracted to it.
+var ErrInsufficientSpace = errors.New("not enough free space in the scratch directory")
+
+// Validate returns an error if dir is not an existing directory.
+func Validate(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("invalid scratch directory: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("invalid scratch directory: %s is not a directory", dir)
+	}
+
+	return nil
+}
+
+// EnsureSpace returns ErrInsufficientSpace if the filesystem of dir has less than needed
+// bytes free. The free space of filesystems that cannot be queried is not checked.
+func EnsureSpace(dir string, needed int64) error {
+	available, ok, err := freeSpace(dir)
+	if err != nil {
+		return fmt.Errorf("could not read the free space of %s: %w", dir, err)
+	}
+	if !ok || available >= needed {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s has %s free, but at least %s are needed; set --tmpdir or PFLT_TMPDIR "+
+		"to a directory with more space", ErrInsufficientSpace, dir, FormatSize(available), FormatSize(needed))
+}
+
+// FormatSize returns size in bytes in the largest binary unit it has at least one of,
+// e.g. 1.5 GiB.
+func FormatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
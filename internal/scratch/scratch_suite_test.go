@@ -0,0 +1,13 @@
+package scratch
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestScratch(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Scratch Suite")
+}
@@ -0,0 +1,57 @@
+package scratch
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Scratch directory", func() {
+	It("should be an existing directory", func() {
+		dir := GinkgoT().TempDir()
+		Expect(Validate(dir)).To(Succeed())
+		Expect(Validate(filepath.Join(dir, "missing"))).To(MatchError(ContainSubstring("invalid scratch directory")))
+
+		file := filepath.Join(dir, "file")
+		Expect(os.WriteFile(file, nil, 0o644)).To(Succeed())
+		Expect(Validate(file)).To(MatchError(ContainSubstring("is not a directory")))
+	})
+})
+
+var _ = Describe("Free space", func() {
+	It("should be enough for what fits", func() {
+		Expect(EnsureSpace(GinkgoT().TempDir(), 1)).To(Succeed())
+	})
+
+	It("should not be enough for more than any filesystem has", func() {
+		skipUnlessUnix()
+		dir := GinkgoT().TempDir()
+		err := EnsureSpace(dir, math.MaxInt64)
+		Expect(err).To(MatchError(ErrInsufficientSpace))
+		Expect(err).To(MatchError(ContainSubstring("set --tmpdir or PFLT_TMPDIR")))
+	})
+
+	It("should fail if the directory does not exist", func() {
+		skipUnlessUnix()
+		Expect(EnsureSpace(filepath.Join(GinkgoT().TempDir(), "missing"), 1)).ToNot(Succeed())
+	})
+})
+
+var _ = DescribeTable("Formatting sizes",
+	func(size int64, expected string) {
+		Expect(FormatSize(size)).To(Equal(expected))
+	},
+	Entry("bytes", int64(512), "512 B"),
+	Entry("kibibytes", int64(1536), "1.5 KiB"),
+	Entry("gibibytes", int64(18)<<30, "18.0 GiB"),
+)
+
+// skipUnlessUnix skips the spec on systems whose free space is not read.
+func skipUnlessUnix() {
+	if _, ok, _ := freeSpace(os.TempDir()); !ok {
+		Skip("the free space of filesystems is only read on Unix")
+	}
+}
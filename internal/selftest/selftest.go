@@ -0,0 +1,262 @@
+// Package selftest checks that preflight works on the current host, by executing
+// checks against a tiny test image, served from a registry in the process, end to end.
+// Nothing is pulled from, or sent to, the network, so that a self-test that fails points
+// at the host, e.g. its temporary directory, rather than at an image or a service.
+package selftest
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/artifacts"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/certification"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/engine"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/formatters"
+	containerpol "github.com/redhat-openshift-ecosystem/openshift-preflight/internal/policy/container"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/registry"
+	cranev1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+)
+
+// Step is a step of the self-test, and why it failed, if it did.
+type Step struct {
+	Name string
+	Err  error
+}
+
+// Passed returns true if every step passed.
+func Passed(steps []Step) bool {
+	for _, step := range steps {
+		if step.Err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// testRepository is the repository the test image is served from.
+const testRepository = "preflight/selftest"
+
+// run is the state the steps of a self-test share.
+type run struct {
+	dir     string
+	img     cranev1.Image
+	ref     string
+	checks  []check.Check
+	results certification.Results
+	writer  *artifacts.FilesystemWriter
+	stop    func()
+}
+
+// Run runs the self-test in a temporary directory, and returns each step that ran. The
+// steps stop at the first that fails.
+func Run(ctx context.Context) []Step {
+	r := &run{}
+	defer r.cleanup()
+
+	steps := []struct {
+		name string
+		fn   func(context.Context) error
+	}{
+		{"create a temporary directory", r.createDir},
+		{"build the test image", r.buildImage},
+		{"serve the test image", r.serveImage},
+		{"pull, extract, and check the test image", r.executeChecks},
+		{"format and write the results", r.writeResults},
+	}
+
+	ran := make([]Step, 0, len(steps))
+	for _, step := range steps {
+		err := step.fn(ctx)
+		ran = append(ran, Step{Name: step.name, Err: err})
+		if err != nil {
+			break
+		}
+	}
+
+	return ran
+}
+
+func (r *run) cleanup() {
+	if r.stop != nil {
+		r.stop()
+	}
+	if r.dir != "" {
+		_ = os.RemoveAll(r.dir)
+	}
+}
+
+func (r *run) createDir(context.Context) error {
+	dir, err := os.MkdirTemp("", "preflight-selftest-*")
+	if err != nil {
+		return fmt.Errorf("could not create temporary directory: %w", err)
+	}
+	r.dir = dir
+
+	return nil
+}
+
+// buildImage builds an image that passes the checks that do not require the network.
+func (r *run) buildImage(context.Context) error {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	license := []byte("This image is only used to test preflight.\n")
+	if err := tw.WriteHeader(&tar.Header{Name: "licenses/", Typeflag: tar.TypeDir, Mode: 0o755}); err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: "licenses/LICENSE", Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(license))}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(license); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+
+	layer, err := tarball.LayerFromOpener(func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(buf.Bytes())), nil
+	})
+	if err != nil {
+		return fmt.Errorf("could not build layer: %w", err)
+	}
+
+	img, err := mutate.AppendLayers(empty.Image, layer)
+	if err != nil {
+		return fmt.Errorf("could not build image: %w", err)
+	}
+
+	cfg, err := img.ConfigFile()
+	if err != nil {
+		return fmt.Errorf("could not get image config: %w", err)
+	}
+	cfg = cfg.DeepCopy()
+	cfg.OS = "linux"
+	cfg.Config.User = "1001"
+	cfg.Config.Labels = map[string]string{}
+	for _, label := range containerpol.RequiredLabels() {
+		cfg.Config.Labels[label] = "preflight-selftest"
+	}
+	if r.img, err = mutate.ConfigFile(img, cfg); err != nil {
+		return fmt.Errorf("could not build image: %w", err)
+	}
+
+	return nil
+}
+
+// serveImage pushes the test image to a registry served on the loopback interface.
+func (r *run) serveImage(ctx context.Context) error {
+	s := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	r.stop = s.Close
+
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		return fmt.Errorf("could not parse registry URL: %w", err)
+	}
+	r.ref = fmt.Sprintf("%s/%s:latest", u.Host, testRepository)
+
+	if err := crane.Push(r.img, r.ref, crane.WithContext(ctx)); err != nil {
+		return fmt.Errorf("could not push test image: %w", err)
+	}
+
+	return nil
+}
+
+// executeChecks executes the checks that do not require the network against the test
+// image, which passes them.
+func (r *run) executeChecks(ctx context.Context) error {
+	w, err := artifacts.NewFilesystemWriter(artifacts.WithDirectory(filepath.Join(r.dir, "artifacts")))
+	if err != nil {
+		return fmt.Errorf("could not create artifacts directory: %w", err)
+	}
+	r.writer = w
+	ctx = artifacts.ContextWithWriter(ctx, w)
+
+	r.checks = []check.Check{
+		&containerpol.HasLicenseCheck{},
+		&containerpol.MaxLayersCheck{},
+		&containerpol.HasRequiredLabelsCheck{},
+		&containerpol.RunAsNonRootCheck{},
+	}
+
+	// The test image has no RPM database, like a scratch image.
+	eng, err := engine.New(ctx, r.ref, r.checks, nil, "", false, true, false, "")
+	if err != nil {
+		return err
+	}
+	if err := eng.ExecuteChecks(ctx); err != nil {
+		return err
+	}
+
+	r.results = eng.Results(ctx)
+	if len(r.results.Passed) != len(r.checks) {
+		var notPassed []string
+		for _, result := range append(r.results.Failed, r.results.Errors...) {
+			notPassed = append(notPassed, result.Name())
+		}
+		return fmt.Errorf("checks did not pass against the test image: %s", strings.Join(notPassed, ", "))
+	}
+
+	return nil
+}
+
+// writeResults formats the results as JSON and JUnit, writes them as artifacts, and
+// reads them back.
+func (r *run) writeResults(ctx context.Context) error {
+	for _, name := range []string{formatters.DefaultFormat, "junitxml"} {
+		formatter, err := formatters.NewByName(name)
+		if err != nil {
+			return err
+		}
+
+		formatted, err := formatter.Format(ctx, r.results)
+		if err != nil {
+			return fmt.Errorf("could not format results as %s: %w", name, err)
+		}
+
+		filename := "results." + formatter.FileExtension()
+		path, err := r.writer.WriteFile(filename, bytes.NewReader(formatted))
+		if err != nil {
+			return fmt.Errorf("could not write %s: %w", filename, err)
+		}
+
+		written, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("could not read %s back: %w", filename, err)
+		}
+		if !bytes.Equal(written, formatted) {
+			return fmt.Errorf("%s was not written as formatted", filename)
+		}
+	}
+
+	var response struct {
+		Passed bool `json:"passed"`
+	}
+	written, err := os.ReadFile(filepath.Join(r.writer.Path(), "results.json"))
+	if err != nil {
+		return fmt.Errorf("could not read results.json back: %w", err)
+	}
+	if err := json.Unmarshal(written, &response); err != nil {
+		return fmt.Errorf("could not parse results.json: %w", err)
+	}
+	if !response.Passed {
+		return fmt.Errorf("results.json does not report that the test image passed")
+	}
+
+	return nil
+}
@@ -0,0 +1,24 @@
+package selftest
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Self-test", func() {
+	It("should pass every step on this host", func() {
+		steps := Run(context.TODO())
+		for _, step := range steps {
+			Expect(step.Err).ToNot(HaveOccurred(), "step %q failed", step.Name)
+		}
+		Expect(steps).To(HaveLen(5))
+		Expect(Passed(steps)).To(BeTrue())
+	})
+
+	It("should not pass if any step failed", func() {
+		Expect(Passed([]Step{{Name: "passed"}, {Name: "failed", Err: errors.New("failed")}})).To(BeFalse())
+	})
+})
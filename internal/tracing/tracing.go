@@ -0,0 +1,137 @@
+// Package tracing records where preflight spends its time, such as pulling and
+// extracting images, running each check, and calling Pyxis, as OpenTelemetry spans
+// exported with OTLP.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/version"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// serviceName is the name preflight reports its spans as.
+	serviceName = "preflight"
+	// tracerName is the name of the tracer of preflight's spans.
+	tracerName = "github.com/redhat-openshift-ecosystem/openshift-preflight"
+)
+
+// Attributes of preflight's spans.
+const (
+	ImageKey    = attribute.Key("preflight.image")
+	CheckKey    = attribute.Key("preflight.check")
+	ResultKey   = attribute.Key("preflight.result")
+	AttemptsKey = attribute.Key("preflight.attempts")
+)
+
+// Setup exports spans to the OTLP gRPC endpoint, e.g. otel-collector:4317. An
+// endpoint with the http scheme is connected to without TLS. The returned function
+// exports the spans that were not yet exported, and must be called before exiting.
+// Until Setup is called, spans are not recorded.
+func Setup(ctx context.Context, endpoint string) (func(context.Context) error, error) {
+	options, err := exporterOptions(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	exporter, err := otlptracegrpc.New(ctx, options...)
+	if err != nil {
+		return nil, fmt.Errorf("could not export traces to %s: %w", endpoint, err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceNameKey.String(serviceName),
+			semconv.ServiceVersionKey.String(version.Version.Version),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// exporterOptions returns the options of an exporter to endpoint.
+func exporterOptions(endpoint string) ([]otlptracegrpc.Option, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		// host:port, which is not parsed as a URL, or is parsed as one with
+		// the host as its scheme.
+		return []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(endpoint)}, nil
+	}
+
+	switch u.Scheme {
+	case "http":
+		return []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(u.Host), otlptracegrpc.WithInsecure()}, nil
+	case "https":
+		return []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(u.Host)}, nil
+	default:
+		return nil, fmt.Errorf("invalid OTLP endpoint %s: unsupported scheme %s", endpoint, u.Scheme)
+	}
+}
+
+// Start starts a span called name, as a child of the span in ctx, if any. The
+// span must be ended, with End.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End ends span, recording err, if any, as the reason it failed.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Transport returns an http.RoundTripper that records a span, called name followed
+// by the method, for each request made with base.
+func Transport(base http.RoundTripper, name string) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &transport{base: base, name: name}
+}
+
+type transport struct {
+	base http.RoundTripper
+	name string
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := Start(req.Context(), t.name+" "+req.Method,
+		semconv.HTTPMethodKey.String(req.Method),
+		// The query may contain filters, which are not needed to see where the
+		// time is spent.
+		semconv.HTTPURLKey.String((&url.URL{Scheme: req.URL.Scheme, Host: req.URL.Host, Path: req.URL.Path}).String()),
+	)
+
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		End(span, err)
+		return nil, err
+	}
+
+	span.SetAttributes(semconv.HTTPStatusCodeKey.Int(resp.StatusCode))
+	if resp.StatusCode >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	span.End()
+
+	return resp, nil
+}
@@ -0,0 +1,115 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var _ = Describe("Tracing", func() {
+	var recorder *tracetest.SpanRecorder
+
+	BeforeEach(func() {
+		recorder = tracetest.NewSpanRecorder()
+		previous := otel.GetTracerProvider()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+		DeferCleanup(otel.SetTracerProvider, previous)
+	})
+
+	Context("when recording spans", func() {
+		It("should record spans as children of the span in the context", func() {
+			ctx, parent := Start(context.TODO(), "preflight")
+			_, child := Start(ctx, "check HasLicense", CheckKey.String("HasLicense"))
+			End(child, nil)
+			End(parent, nil)
+
+			spans := recorder.Ended()
+			Expect(spans).To(HaveLen(2))
+			Expect(spans[0].Name()).To(Equal("check HasLicense"))
+			Expect(spans[0].Parent().SpanID()).To(Equal(spans[1].SpanContext().SpanID()))
+			Expect(spans[0].Attributes()).To(ContainElement(CheckKey.String("HasLicense")))
+			Expect(spans[0].Status().Code).To(Equal(codes.Unset))
+		})
+		It("should record the error of a span that failed", func() {
+			_, span := Start(context.TODO(), "pull image")
+			End(span, errors.New("unauthorized"))
+
+			spans := recorder.Ended()
+			Expect(spans).To(HaveLen(1))
+			Expect(spans[0].Status().Code).To(Equal(codes.Error))
+			Expect(spans[0].Status().Description).To(Equal("unauthorized"))
+			Expect(spans[0].Events()).To(HaveLen(1))
+		})
+	})
+
+	Context("when tracing requests", func() {
+		var server *httptest.Server
+
+		BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/broken" {
+					w.WriteHeader(http.StatusBadGateway)
+				}
+			}))
+			DeferCleanup(server.Close)
+		})
+
+		It("should record a span for each request", func() {
+			client := &http.Client{Transport: Transport(nil, "pyxis")}
+			resp, err := client.Get(server.URL + "/v1/images?filter=secret")
+			Expect(err).ToNot(HaveOccurred())
+			resp.Body.Close()
+
+			spans := recorder.Ended()
+			Expect(spans).To(HaveLen(1))
+			Expect(spans[0].Name()).To(Equal("pyxis GET"))
+			Expect(spans[0].Attributes()).To(ContainElements(
+				attribute.String("http.url", server.URL+"/v1/images"),
+				attribute.Int("http.status_code", http.StatusOK),
+			))
+			Expect(spans[0].Status().Code).To(Equal(codes.Unset))
+		})
+		It("should record server errors as failures", func() {
+			client := &http.Client{Transport: Transport(nil, "pyxis")}
+			resp, err := client.Get(server.URL + "/broken")
+			Expect(err).ToNot(HaveOccurred())
+			resp.Body.Close()
+
+			spans := recorder.Ended()
+			Expect(spans).To(HaveLen(1))
+			Expect(spans[0].Status().Code).To(Equal(codes.Error))
+		})
+	})
+
+	Context("when exporting spans", func() {
+		It("should export spans to an endpoint", func() {
+			shutdown, err := Setup(context.TODO(), "http://localhost:4317")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(shutdown(context.TODO())).To(Succeed())
+		})
+		DescribeTable("the options of the exporter",
+			func(endpoint string, options int, expectedErr string) {
+				opts, err := exporterOptions(endpoint)
+				if expectedErr != "" {
+					Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+					return
+				}
+				Expect(err).ToNot(HaveOccurred())
+				Expect(opts).To(HaveLen(options))
+			},
+			Entry("host and port", "otel-collector:4317", 1, ""),
+			Entry("https", "https://otel-collector:4317", 1, ""),
+			Entry("http, without TLS", "http://otel-collector:4317", 2, ""),
+			Entry("unsupported scheme", "ftp://otel-collector:4317", 0, "unsupported scheme ftp"),
+		)
+	})
+})
@@ -0,0 +1,119 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+)
+
+// ErrEgressDenied is the error of a connection that a check is not allowed to make.
+var ErrEgressDenied = errors.New("network access denied by the egress policy")
+
+// DeniedConnection is a connection that the egress policy denied.
+type DeniedConnection struct {
+	// Check is the name of the check that attempted the connection.
+	Check string
+	// Address is the address the check attempted to connect to, e.g. quay.io:443.
+	Address string
+}
+
+// EgressPolicy denies the connections of the checks that are not allowed to access the
+// network, so that the analysis of untrusted images is contained. Only the connections
+// made through the transports of this package, i.e. by the registry and API clients that
+// preflight builds, are denied. Those made before and after checks are executed, e.g. to
+// pull the image, are allowed. Those of the checks of operators are not contained: the
+// cluster client they use, e.g. for DeployableByOLM, does not use these transports, and
+// neither do the commands they execute, like operator-sdk scorecard.
+type EgressPolicy struct {
+	allowed map[string]bool
+	mu      sync.Mutex
+	denied  []DeniedConnection
+}
+
+// NewEgressPolicy returns an EgressPolicy that only allows the checks named allowed to
+// access the network.
+func NewEgressPolicy(allowed ...string) *EgressPolicy {
+	p := &EgressPolicy{allowed: make(map[string]bool, len(allowed))}
+	for _, name := range allowed {
+		p.allowed[name] = true
+	}
+
+	return p
+}
+
+// Allows returns whether the check named check may access the network.
+func (p *EgressPolicy) Allows(check string) bool {
+	return p.allowed[check]
+}
+
+// Allowed returns the names of the checks that may access the network, sorted.
+func (p *EgressPolicy) Allowed() []string {
+	allowed := make([]string, 0, len(p.allowed))
+	for name := range p.allowed {
+		allowed = append(allowed, name)
+	}
+	sort.Strings(allowed)
+
+	return allowed
+}
+
+// Denied returns the connections that were denied, in the order they were attempted.
+func (p *EgressPolicy) Denied() []DeniedConnection {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]DeniedConnection(nil), p.denied...)
+}
+
+// DeniedFor returns the connections of the check named check that were denied.
+func (p *EgressPolicy) DeniedFor(check string) []DeniedConnection {
+	var denied []DeniedConnection
+	for _, d := range p.Denied() {
+		if d.Check == check {
+			denied = append(denied, d)
+		}
+	}
+
+	return denied
+}
+
+// dialContext returns dial, denying the connections made with a context of a check
+// that is not allowed to access the network.
+func (p *EgressPolicy) dialContext(dial func(ctx context.Context, network, addr string) (net.Conn, error)) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if check, ok := ctx.Value(checkContextKey).(string); ok && !p.Allows(check) {
+			p.mu.Lock()
+			p.denied = append(p.denied, DeniedConnection{Check: check, Address: addr})
+			p.mu.Unlock()
+			return nil, fmt.Errorf("%w: check %s may not connect to %s", ErrEgressDenied, check, addr)
+		}
+
+		return dial(ctx, network, addr)
+	}
+}
+
+const (
+	egressPolicyContextKey contextKey = "EgressPolicy"
+	checkContextKey        contextKey = "Check"
+)
+
+// ContextWithEgressPolicy returns a copy of ctx in which the transports of this package
+// enforce p.
+func ContextWithEgressPolicy(ctx context.Context, p *EgressPolicy) context.Context {
+	return context.WithValue(ctx, egressPolicyContextKey, p)
+}
+
+// EgressPolicyFromContext returns the egress policy in ctx, if any.
+func EgressPolicyFromContext(ctx context.Context) *EgressPolicy {
+	p, _ := ctx.Value(egressPolicyContextKey).(*EgressPolicy)
+	return p
+}
+
+// ContextWithCheck returns a copy of ctx whose connections are made by the check named
+// name, and are denied unless the egress policy allows it to access the network.
+func ContextWithCheck(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, checkContextKey, name)
+}
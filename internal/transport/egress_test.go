@@ -0,0 +1,71 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Egress policy", func() {
+	var (
+		server *httptest.Server
+		policy *EgressPolicy
+		ctx    context.Context
+	)
+
+	BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		DeferCleanup(server.Close)
+
+		policy = NewEgressPolicy("HasUniqueTag")
+		ctx = ContextWithEgressPolicy(context.Background(), policy)
+	})
+
+	get := func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		Expect(err).ToNot(HaveOccurred())
+		resp, err := HTTPClient(ctx, time.Minute).Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	It("should deny the connections of checks that are not allowed to access the network", func() {
+		err := get(ContextWithCheck(ctx, "HasLicense"))
+		Expect(err).To(MatchError(ErrEgressDenied))
+		Expect(policy.Denied()).To(ConsistOf(DeniedConnection{Check: "HasLicense", Address: strings.TrimPrefix(server.URL, "http://")}))
+		Expect(policy.DeniedFor("HasUniqueTag")).To(BeEmpty())
+	})
+
+	It("should allow the connections of checks that are allowed to access the network", func() {
+		Expect(get(ContextWithCheck(ctx, "HasUniqueTag"))).To(Succeed())
+		Expect(policy.Denied()).To(BeEmpty())
+	})
+
+	It("should allow the connections made outside of checks", func() {
+		Expect(get(ctx)).To(Succeed())
+		Expect(policy.Denied()).To(BeEmpty())
+	})
+
+	It("should not contain the connections of clients that do not use these transports, like the cluster client", func() {
+		req, err := http.NewRequestWithContext(ContextWithCheck(ctx, "DeployableByOLM"), http.MethodGet, server.URL, nil)
+		Expect(err).ToNot(HaveOccurred())
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).ToNot(HaveOccurred())
+		resp.Body.Close()
+		Expect(policy.Denied()).To(BeEmpty())
+	})
+
+	It("should configure the transport", func() {
+		Expect(IsConfigured(ctx)).To(BeTrue())
+		Expect(policy.Allowed()).To(Equal([]string{"HasUniqueTag"}))
+	})
+})
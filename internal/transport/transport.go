@@ -0,0 +1,102 @@
+// Package transport builds the HTTP transports used for registry and Pyxis
+// traffic, applying the proxy and CA bundle configured in the context.
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/proxy"
+)
+
+type contextKey string
+
+const rootCAsContextKey contextKey = "RootCAs"
+
+// LoadRootCAs returns the system's trusted certificate authorities, along with
+// the PEM encoded certificates in the file at path.
+func LoadRootCAs(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read CA bundle: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("could not read CA bundle: %s does not contain any PEM encoded certificates", path)
+	}
+
+	return pool, nil
+}
+
+// ContextWithRootCAs returns a copy of ctx in which connections should trust
+// the certificate authorities in pool.
+func ContextWithRootCAs(ctx context.Context, pool *x509.CertPool) context.Context {
+	return context.WithValue(ctx, rootCAsContextKey, pool)
+}
+
+// RootCAsFromContext returns the certificate authorities to trust in ctx, or
+// nil if the system's certificate authorities should be used.
+func RootCAsFromContext(ctx context.Context) *x509.CertPool {
+	pool, _ := ctx.Value(rootCAsContextKey).(*x509.CertPool)
+	return pool
+}
+
+// Transport returns a clone of base that uses the proxy, trusts the certificate
+// authorities, and enforces the egress policy configured in ctx. If none of them is
+// configured, base is returned unchanged.
+func Transport(ctx context.Context, base *http.Transport) *http.Transport {
+	proxyConfig := proxy.ConfigFromContext(ctx)
+	rootCAs := RootCAsFromContext(ctx)
+	egress := EgressPolicyFromContext(ctx)
+	if proxyConfig.URL == "" && rootCAs == nil && egress == nil {
+		return base
+	}
+
+	rt := base.Clone()
+	if proxyConfig.URL != "" {
+		rt.Proxy = proxyConfig.Func()
+	}
+
+	if rootCAs != nil {
+		if rt.TLSClientConfig == nil {
+			rt.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		rt.TLSClientConfig.RootCAs = rootCAs
+	}
+
+	if egress != nil {
+		dial := rt.DialContext
+		if dial == nil {
+			dial = (&net.Dialer{}).DialContext
+		}
+		rt.DialContext = egress.dialContext(dial)
+	}
+
+	return rt
+}
+
+// IsConfigured returns true if ctx configures a proxy, certificate authorities, or an
+// egress policy, i.e. if Transport would not return its base transport unchanged.
+func IsConfigured(ctx context.Context) bool {
+	return proxy.ConfigFromContext(ctx).URL != "" || RootCAsFromContext(ctx) != nil || EgressPolicyFromContext(ctx) != nil
+}
+
+// HTTPClient returns an http.Client with timeout that uses the proxy, trusts the
+// certificate authorities, and enforces the egress policy configured in ctx.
+func HTTPClient(ctx context.Context, timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: Transport(ctx, http.DefaultTransport.(*http.Transport)),
+	}
+}
@@ -0,0 +1,86 @@
+package transport
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/proxy"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Transport", func() {
+	var (
+		server   *httptest.Server
+		caBundle string
+	)
+
+	BeforeEach(func() {
+		server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		DeferCleanup(server.Close)
+
+		caBundle = filepath.Join(GinkgoT().TempDir(), "ca.pem")
+		contents := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+		Expect(os.WriteFile(caBundle, contents, 0o644)).To(Succeed())
+	})
+
+	Context("when loading a CA bundle", func() {
+		It("should trust the certificates in the bundle", func() {
+			rootCAs, err := LoadRootCAs(caBundle)
+			Expect(err).ToNot(HaveOccurred())
+
+			ctx := ContextWithRootCAs(context.Background(), rootCAs)
+			resp, err := HTTPClient(ctx, time.Minute).Get(server.URL)
+			Expect(err).ToNot(HaveOccurred())
+			resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		})
+
+		It("should not trust the certificates without the bundle", func() {
+			_, err := HTTPClient(context.Background(), time.Minute).Get(server.URL)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should return an error if the bundle does not exist", func() {
+			_, err := LoadRootCAs(filepath.Join(GinkgoT().TempDir(), "missing.pem"))
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should return an error if the bundle does not contain certificates", func() {
+			Expect(os.WriteFile(caBundle, []byte("not a certificate"), 0o644)).To(Succeed())
+			_, err := LoadRootCAs(caBundle)
+			Expect(err).To(MatchError(ContainSubstring("does not contain any PEM encoded certificates")))
+		})
+	})
+
+	Context("when configuring a transport", func() {
+		base := http.DefaultTransport.(*http.Transport)
+
+		It("should return the base transport if nothing is configured", func() {
+			Expect(IsConfigured(context.Background())).To(BeFalse())
+			Expect(Transport(context.Background(), base)).To(BeIdenticalTo(base))
+		})
+
+		It("should return a clone using the proxy in the context", func() {
+			ctx := proxy.ContextWithConfig(context.Background(), proxy.Config{URL: "http://proxy.example.com:3128"})
+			Expect(IsConfigured(ctx)).To(BeTrue())
+
+			rt := Transport(ctx, base)
+			Expect(rt).ToNot(BeIdenticalTo(base))
+
+			req, err := http.NewRequest(http.MethodGet, "https://quay.io/v2/", nil)
+			Expect(err).ToNot(HaveOccurred())
+			u, err := rt.Proxy(req)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(u.Host).To(Equal("proxy.example.com:3128"))
+		})
+	})
+})
@@ -0,0 +1,244 @@
+// Package vault reads the Pyxis API token and registry credentials from a secret in
+// HashiCorp Vault, so that they do not have to be stored as CI variables.
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/go-logr/logr"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/log"
+)
+
+// DefaultAppRoleMount is the path at which the AppRole auth method is mounted by default.
+const DefaultAppRoleMount = "approle"
+
+// The keys of the secret that credentials are read from.
+const (
+	KeyPyxisAPIToken    = "pyxis_api_token"
+	KeyRegistryUsername = "registry_username"
+	KeyRegistryPassword = "registry_password"
+	KeyRegistryToken    = "registry_token"
+)
+
+// Config configures how Vault is reached, authenticated with, and which secret is read.
+type Config struct {
+	// Address is the address of Vault, e.g. https://vault.example.com:8200. Defaults
+	// to the VAULT_ADDR environment variable.
+	Address string
+	// Namespace is the Vault Enterprise namespace. Defaults to the VAULT_NAMESPACE
+	// environment variable.
+	Namespace string
+	// Token authenticates with Vault. Defaults to the VAULT_TOKEN environment variable,
+	// unless RoleID is set.
+	Token string
+	// RoleID and SecretID authenticate with the AppRole auth method, instead of Token.
+	RoleID   string
+	SecretID string
+	// AppRoleMount is the path the AppRole auth method is mounted at. Defaults to
+	// DefaultAppRoleMount.
+	AppRoleMount string
+	// Path is the path of the secret, as in the Vault HTTP API, e.g. secret/data/preflight
+	// for a KV version 2 secrets engine mounted at secret.
+	Path string
+}
+
+// withDefaults returns c with the defaults read from the environment applied.
+func (c Config) withDefaults() Config {
+	if c.Address == "" {
+		c.Address = os.Getenv("VAULT_ADDR")
+	}
+	if c.Namespace == "" {
+		c.Namespace = os.Getenv("VAULT_NAMESPACE")
+	}
+	if c.Token == "" && c.RoleID == "" {
+		c.Token = os.Getenv("VAULT_TOKEN")
+	}
+	if c.AppRoleMount == "" {
+		c.AppRoleMount = DefaultAppRoleMount
+	}
+
+	return c
+}
+
+// Validate returns an error if c, with its defaults applied, cannot be used to read a secret.
+func (c Config) Validate() error {
+	c = c.withDefaults()
+	switch {
+	case c.Path == "":
+		return errors.New("a vault secret path is required")
+	case c.Address == "":
+		return errors.New("a vault address is required")
+	case c.RoleID != "" && c.SecretID == "":
+		return errors.New("a vault secret id is required with a vault role id")
+	case c.RoleID == "" && c.Token == "":
+		return errors.New("a vault token, or a vault role id and secret id, are required")
+	}
+
+	return nil
+}
+
+// HTTPClient sends requests to Vault.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Client reads secrets from Vault.
+type Client struct {
+	cfg    Config
+	client HTTPClient
+}
+
+// NewClient returns a Client reading secrets from Vault as configured by cfg, with httpClient.
+func NewClient(cfg Config, httpClient HTTPClient) (*Client, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &Client{cfg: cfg.withDefaults(), client: httpClient}, nil
+}
+
+// Credentials are the credentials read from a secret.
+type Credentials struct {
+	PyxisAPIToken    string
+	RegistryUsername string
+	RegistryPassword string
+	RegistryToken    string
+}
+
+// Credentials returns the credentials in the configured secret. Keys that the secret
+// does not contain are empty.
+func (c *Client) Credentials(ctx context.Context) (Credentials, error) {
+	secret, err := c.Secret(ctx)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	return Credentials{
+		PyxisAPIToken:    secret[KeyPyxisAPIToken],
+		RegistryUsername: secret[KeyRegistryUsername],
+		RegistryPassword: secret[KeyRegistryPassword],
+		RegistryToken:    secret[KeyRegistryToken],
+	}, nil
+}
+
+// Secret returns the string values of the configured secret, authenticating with the
+// AppRole auth method first if it is configured. Secrets in KV version 1 and version 2
+// secrets engines are supported.
+func (c *Client) Secret(ctx context.Context) (map[string]string, error) {
+	logger := logr.FromContextOrDiscard(ctx)
+
+	token := c.cfg.Token
+	if c.cfg.RoleID != "" {
+		var err error
+		token, err = c.appRoleLogin(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("could not log in to vault: %w", err)
+		}
+	}
+
+	logger.V(log.DBG).Info("reading secret from vault", "path", c.cfg.Path)
+
+	var resp struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodGet, c.cfg.Path, token, nil, &resp); err != nil {
+		return nil, fmt.Errorf("could not read vault secret %s: %w", c.cfg.Path, err)
+	}
+
+	data := resp.Data
+	// KV version 2 nests the secret, alongside its metadata.
+	if nested, ok := data["data"]; ok {
+		if _, ok := data["metadata"]; ok {
+			data = nil
+			if err := json.Unmarshal(nested, &data); err != nil {
+				return nil, fmt.Errorf("could not parse vault secret %s: %w", c.cfg.Path, err)
+			}
+		}
+	}
+	if data == nil {
+		return nil, fmt.Errorf("vault secret %s does not exist, or has been deleted", c.cfg.Path)
+	}
+
+	secret := make(map[string]string, len(data))
+	for k, v := range data {
+		var s string
+		if err := json.Unmarshal(v, &s); err == nil {
+			secret[k] = s
+		}
+	}
+
+	return secret, nil
+}
+
+// appRoleLogin returns a token for the configured role.
+func (c *Client) appRoleLogin(ctx context.Context) (string, error) {
+	body, err := json.Marshal(map[string]string{"role_id": c.cfg.RoleID, "secret_id": c.cfg.SecretID})
+	if err != nil {
+		return "", err
+	}
+
+	var resp struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}
+	path := fmt.Sprintf("auth/%s/login", strings.Trim(c.cfg.AppRoleMount, "/"))
+	if err := c.do(ctx, http.MethodPost, path, "", body, &resp); err != nil {
+		return "", err
+	}
+	if resp.Auth.ClientToken == "" {
+		return "", errors.New("vault did not return a token")
+	}
+
+	return resp.Auth.ClientToken, nil
+}
+
+// do sends a request to the Vault HTTP API at path, and decodes the response into v.
+func (c *Client) do(ctx context.Context, method, path, token string, body []byte, v interface{}) error {
+	url := fmt.Sprintf("%s/v1/%s", strings.TrimSuffix(c.cfg.Address, "/"), strings.TrimPrefix(path, "/"))
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if c.cfg.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", c.cfg.Namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var vaultErr struct {
+			Errors []string `json:"errors"`
+		}
+		if err := json.Unmarshal(b, &vaultErr); err == nil && len(vaultErr.Errors) > 0 {
+			return fmt.Errorf("status %d: %s", resp.StatusCode, strings.Join(vaultErr.Errors, "; "))
+		}
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	return json.Unmarshal(b, v)
+}
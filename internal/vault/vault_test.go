@@ -0,0 +1,121 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeVault serves a KV version 1 secret at secret/preflight, a KV version 2 secret
+// at kv/data/preflight, and AppRole logins at auth/approle/login.
+func fakeVault() *httptest.Server {
+	const token = "s.token"
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/auth/approle/login", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body["role_id"] != "role" || body["secret_id"] != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"errors": ["invalid role or secret ID"]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"auth": {"client_token": "` + token + `"}}`))
+	})
+	authenticated := func(h http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Vault-Token") != token {
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = w.Write([]byte(`{"errors": ["permission denied"]}`))
+				return
+			}
+			h(w, r)
+		}
+	}
+	mux.HandleFunc("/v1/secret/preflight", authenticated(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data": {"pyxis_api_token": "pyxis", "registry_username": "robot", "registry_password": "password", "ttl": 3600}}`))
+	}))
+	mux.HandleFunc("/v1/kv/data/preflight", authenticated(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data": {"data": {"registry_token": "registry"}, "metadata": {"version": 2}}}`))
+	}))
+	mux.HandleFunc("/v1/kv/data/deleted", authenticated(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data": {"data": null, "metadata": {"version": 3}}}`))
+	}))
+
+	return httptest.NewServer(mux)
+}
+
+var _ = Describe("Vault", func() {
+	var server *httptest.Server
+
+	BeforeEach(func() {
+		server = fakeVault()
+		DeferCleanup(server.Close)
+	})
+
+	Context("When validating the configuration", func() {
+		BeforeEach(func() {
+			GinkgoT().Setenv("VAULT_ADDR", "")
+			GinkgoT().Setenv("VAULT_TOKEN", "")
+		})
+
+		It("should require a path, an address, and credentials", func() {
+			Expect(Config{Address: server.URL, Token: "t"}.Validate()).To(MatchError(ContainSubstring("path")))
+			Expect(Config{Path: "secret/preflight", Token: "t"}.Validate()).To(MatchError(ContainSubstring("address")))
+			Expect(Config{Address: server.URL, Path: "secret/preflight"}.Validate()).To(MatchError(ContainSubstring("token")))
+			Expect(Config{Address: server.URL, Path: "secret/preflight", RoleID: "role"}.Validate()).To(MatchError(ContainSubstring("secret id")))
+			Expect(Config{Address: server.URL, Path: "secret/preflight", RoleID: "role", SecretID: "secret"}.Validate()).To(Succeed())
+		})
+
+		It("should read the address and token from the environment", func() {
+			GinkgoT().Setenv("VAULT_ADDR", server.URL)
+			GinkgoT().Setenv("VAULT_TOKEN", "s.token")
+			Expect(Config{Path: "secret/preflight"}.Validate()).To(Succeed())
+		})
+	})
+
+	Context("When reading credentials", func() {
+		It("should read a KV version 1 secret with a token", func() {
+			client, err := NewClient(Config{Address: server.URL, Token: "s.token", Path: "secret/preflight"}, http.DefaultClient)
+			Expect(err).ToNot(HaveOccurred())
+
+			creds, err := client.Credentials(context.TODO())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(creds).To(Equal(Credentials{PyxisAPIToken: "pyxis", RegistryUsername: "robot", RegistryPassword: "password"}))
+		})
+
+		It("should read a KV version 2 secret after logging in with AppRole", func() {
+			client, err := NewClient(Config{Address: server.URL + "/", RoleID: "role", SecretID: "secret", Path: "/kv/data/preflight"}, http.DefaultClient)
+			Expect(err).ToNot(HaveOccurred())
+
+			creds, err := client.Credentials(context.TODO())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(creds).To(Equal(Credentials{RegistryToken: "registry"}))
+		})
+
+		It("should report the errors returned by vault", func() {
+			client, err := NewClient(Config{Address: server.URL, RoleID: "role", SecretID: "wrong", Path: "secret/preflight"}, http.DefaultClient)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = client.Credentials(context.TODO())
+			Expect(err).To(MatchError(ContainSubstring("invalid role or secret ID")))
+
+			client, err = NewClient(Config{Address: server.URL, Token: "s.wrong", Path: "secret/preflight"}, http.DefaultClient)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = client.Credentials(context.TODO())
+			Expect(err).To(MatchError(ContainSubstring("permission denied")))
+		})
+
+		It("should fail if the secret has been deleted", func() {
+			client, err := NewClient(Config{Address: server.URL, Token: "s.token", Path: "kv/data/deleted"}, http.DefaultClient)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = client.Credentials(context.TODO())
+			Expect(err).To(MatchError(ContainSubstring("does not exist")))
+		})
+	})
+})
@@ -4,11 +4,25 @@ import (
 	"context"
 	"fmt"
 	goruntime "runtime"
+	"time"
 
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/certification"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/clock"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/cluster"
 	preflighterr "github.com/redhat-openshift-ecosystem/openshift-preflight/errors"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/audit"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/authn"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/engine"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/lockfile"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/mirror"
 	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/policy"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/proxy"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/readiness"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/transport"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/resolve"
+
+	"github.com/go-logr/logr"
 )
 
 type Option = func(*operatorCheck)
@@ -37,12 +51,139 @@ func (c operatorCheck) Run(ctx context.Context) (certification.Results, error) {
 	switch {
 	case c.image == "":
 		return certification.Results{}, preflighterr.ErrImageEmpty
-	case c.kubeconfig == nil:
+	case c.kubeconfig == nil && c.clusterProvider == nil:
 		return certification.Results{}, preflighterr.ErrKubeconfigEmpty
 	case c.indeximage == "":
 		return certification.Results{}, preflighterr.ErrIndexImageEmpty
 	}
 
+	if len(c.resolvers) > 0 {
+		var err error
+		if c.image, err = resolve.Image(ctx, c.image, c.resolvers...); err != nil {
+			return certification.Results{}, err
+		}
+		if c.indeximage, err = resolve.Image(ctx, c.indeximage, c.resolvers...); err != nil {
+			return certification.Results{}, err
+		}
+	}
+
+	if c.clock != nil {
+		ctx = clock.ContextWithClock(ctx, c.clock)
+	}
+
+	engineOpts := []engine.Option{engine.WithTimeouts(c.timeouts), engine.WithAttempts(c.checkAttempts)}
+
+	if c.traceOnFailure {
+		engineOpts = append(engineOpts, engine.WithTraceOnFailure())
+	}
+
+	if c.failFast {
+		engineOpts = append(engineOpts, engine.WithFailFast())
+	}
+
+	if c.proxy.URL != "" {
+		if err := c.proxy.Validate(); err != nil {
+			return certification.Results{}, err
+		}
+		ctx = proxy.ContextWithConfig(ctx, c.proxy)
+	}
+
+	if c.clusterProxy != "" {
+		if err := (proxy.Config{URL: c.clusterProxy}).Validate(); err != nil {
+			return certification.Results{}, fmt.Errorf("invalid cluster proxy: %w", err)
+		}
+	}
+
+	if c.caBundle != "" {
+		rootCAs, err := transport.LoadRootCAs(c.caBundle)
+		if err != nil {
+			return certification.Results{}, err
+		}
+		ctx = transport.ContextWithRootCAs(ctx, rootCAs)
+	}
+
+	if c.denyEgress {
+		ctx = transport.ContextWithEgressPolicy(ctx, transport.NewEgressPolicy(c.egressAllowed...))
+	}
+
+	if err := c.registryCredentials.Validate(); err != nil {
+		return certification.Results{}, err
+	}
+	if c.registryCredentials.IsSet() {
+		creds, err := c.registryCredentials.ForImage(c.image)
+		if err != nil {
+			return certification.Results{}, err
+		}
+		ctx = authn.ContextWithCredentials(ctx, creds)
+	}
+
+	mirrors := append([]mirror.Mirror{}, c.mirrors...)
+	if c.mirrorConfig != "" {
+		fileMirrors, err := mirror.LoadFile(c.mirrorConfig)
+		if err != nil {
+			return certification.Results{}, err
+		}
+		mirrors = append(mirrors, fileMirrors...)
+	}
+	engineOpts = append(engineOpts, engine.WithMirrors(mirrors))
+
+	if c.lockfile != "" {
+		var opts []lockfile.Option
+		if c.updateLockfile {
+			opts = append(opts, lockfile.WithUpdate())
+		}
+		lock, err := lockfile.Load(c.lockfile, opts...)
+		if err != nil {
+			return certification.Results{}, err
+		}
+		engineOpts = append(engineOpts, engine.WithLockfile(lock))
+	}
+
+	if c.scratchDir != "" {
+		engineOpts = append(engineOpts, engine.WithScratchDir(c.scratchDir))
+	}
+
+	if c.clusterProvider != nil {
+		provided, err := c.clusterProvider.Provide(ctx)
+		if err != nil {
+			return certification.Results{}, fmt.Errorf("%w: %s", preflighterr.ErrClusterNotProvided, err)
+		}
+		if provided.Release != nil {
+			defer func() {
+				// The cluster is released even if the check is stopped, e.g. because
+				// preflight was interrupted, so that it is not left running.
+				releaseCtx, cancel := cluster.ReleaseContext(ctx)
+				defer cancel()
+				if err := provided.Release(releaseCtx); err != nil {
+					logr.FromContextOrDiscard(ctx).Error(err, "could not release the cluster the operator was checked on")
+				}
+			}()
+		}
+		if provided.Kubeconfig == nil {
+			return certification.Results{}, preflighterr.ErrKubeconfigEmpty
+		}
+		c.kubeconfig = provided.Kubeconfig
+	}
+
+	if c.clusterProxy != "" {
+		kubeconfig, err := proxy.Kubeconfig(c.kubeconfig, c.clusterProxy)
+		if err != nil {
+			return certification.Results{}, err
+		}
+		c.kubeconfig = kubeconfig
+	}
+
+	if c.probeServices {
+		probes := readiness.Run(ctx, readiness.DefaultTimeout,
+			readiness.Registry(c.image, c.insecure, mirrors),
+			readiness.Pyxis(check.DefaultPyxisHost),
+			readiness.Cluster(c.kubeconfig),
+		)
+		if !probes.Ready() {
+			return certification.Results{}, fmt.Errorf("%w:\n%s", preflighterr.ErrServicesNotReady, probes)
+		}
+	}
+
 	pol := policy.PolicyOperator
 
 	checks, err := engine.InitializeOperatorChecks(ctx, pol, engine.OperatorCheckConfig{
@@ -54,12 +195,18 @@ func (c operatorCheck) Run(ctx context.Context) (certification.Results, error) {
 		DockerConfig:            c.dockerConfigFilePath,
 		Channel:                 c.operatorChannel,
 		Kubeconfig:              c.kubeconfig,
+		BasicOperability:        c.basicOperability,
 	})
 	if err != nil {
 		return certification.Results{}, fmt.Errorf("%w: %s", preflighterr.ErrCannotInitializeChecks, err)
 	}
 
-	eng, err := engine.New(ctx, c.image, checks, c.kubeconfig, c.dockerConfigFilePath, true, true, c.insecure, goruntime.GOARCH)
+	// Record the policy, so that the results can be evaluated under a later version of it.
+	if err := audit.WritePolicyManifest(ctx, pol, checks); err != nil {
+		return certification.Results{}, err
+	}
+
+	eng, err := engine.New(ctx, c.image, checks, c.kubeconfig, c.dockerConfigFilePath, true, true, c.insecure, goruntime.GOARCH, engineOpts...)
 	if err != nil {
 		return certification.Results{}, err
 	}
@@ -82,6 +229,13 @@ func (c operatorCheck) Run(ctx context.Context) (certification.Results, error) {
 	return eng.Results(ctx), nil
 }
 
+// Stream executes the check like Run, but returns a ResultStream yielding each check's
+// result as soon as it completes. The final results are available from the stream once
+// all checks have completed.
+func (c operatorCheck) Stream(ctx context.Context) *certification.ResultStream {
+	return certification.NewResultStream(ctx, c.Run)
+}
+
 // WithScorecardNamespace configures the namespace value to use for OperatorSDK Scorecard checks.
 func WithScorecardNamespace(ns string) Option {
 	return func(oc *operatorCheck) {
@@ -131,6 +285,25 @@ func WithScorecardImage(image string) Option {
 	}
 }
 
+// WithBasicOperabilityCheck replaces the scorecard checks with BasicOperabilityCheck,
+// which verifies the CSV of the bundle and applies its CRDs, deployments, and RBAC to
+// the cluster in dry-run mode. This allows some cluster validation in environments
+// that cannot run the scorecard pod. The scorecard namespace is used.
+func WithBasicOperabilityCheck() Option {
+	return func(oc *operatorCheck) {
+		oc.basicOperability = true
+	}
+}
+
+// WithClusterProvider checks the operator on the cluster provided by p, rather than
+// the cluster accessed with the kubeconfig passed to NewCheck, which may then be nil.
+// The cluster is released once the operator is checked.
+func WithClusterProvider(p cluster.Provider) Option {
+	return func(oc *operatorCheck) {
+		oc.clusterProvider = p
+	}
+}
+
 // WithInsecureConnection allows for preflight to connect to an insecure registry
 // to pull images.
 func WithInsecureConnection() Option {
@@ -139,6 +312,177 @@ func WithInsecureConnection() Option {
 	}
 }
 
+// WithDeterministicTimes zeroes all timestamps and durations recorded in
+// results and artifacts, so that output is reproducible.
+func WithDeterministicTimes() Option {
+	return WithClock(clock.Deterministic())
+}
+
+// WithImageResolver resolves the bundle and index images, before they are used, with r,
+// if they are not resolved by a resolver added before it. This allows images to be
+// checked by the names an organization uses for them, rather than their registry
+// references.
+func WithImageResolver(r resolve.Resolver) Option {
+	return func(oc *operatorCheck) {
+		oc.resolvers = append(oc.resolvers, r)
+	}
+}
+
+// WithClock sets the Clock used to record timestamps and durations in
+// results and artifacts. This is useful for snapshot tests that need
+// control over the recorded times.
+func WithClock(c clock.Clock) Option {
+	return func(oc *operatorCheck) {
+		oc.clock = c
+	}
+}
+
+// WithTraceOnFailure executes checks that fail or error again with trace
+// logging, and writes the log of each to the ArtifactWriter in the context.
+func WithTraceOnFailure() Option {
+	return func(oc *operatorCheck) {
+		oc.traceOnFailure = true
+	}
+}
+
+// WithFailFast stops executing checks after the first check that fails or errors, so
+// that a single problem is reported as soon as possible. The checks after it are not
+// executed, and are not included in the results.
+func WithFailFast() Option {
+	return func(oc *operatorCheck) {
+		oc.failFast = true
+	}
+}
+
+// WithEgressPolicy denies the checks access to the network, except those named allowed,
+// so that the analysis of untrusted images is contained. The connections that checks are
+// denied are reported in the results, and the checks that are denied one error.
+func WithEgressPolicy(allowed ...string) Option {
+	return func(oc *operatorCheck) {
+		oc.denyEgress = true
+		oc.egressAllowed = append(oc.egressAllowed, allowed...)
+	}
+}
+
+// WithCheckTimeout errors the checks that do not complete within timeout, e.g. because
+// a registry or cluster they depend on hangs, rather than waiting for them, unless they
+// have a timeout of their own set with WithCheckTimeoutFor.
+func WithCheckTimeout(timeout time.Duration) Option {
+	return func(oc *operatorCheck) {
+		oc.timeouts.Default = timeout
+	}
+}
+
+// WithCheckTimeoutFor errors the check named name if it does not complete within
+// timeout, overriding the timeout set with WithCheckTimeout.
+func WithCheckTimeoutFor(name string, timeout time.Duration) Option {
+	return func(oc *operatorCheck) {
+		if oc.timeouts.Checks == nil {
+			oc.timeouts.Checks = map[string]time.Duration{}
+		}
+		oc.timeouts.Checks[name] = timeout
+	}
+}
+
+// WithCheckAttempts executes the checks that depend on the cluster, such as scorecard
+// and DeployableByOLM, up to attempts times until they pass, as their failures may be
+// flakes of the cluster. Checks that pass after a retry are recorded in the results.
+func WithCheckAttempts(attempts int) Option {
+	return func(oc *operatorCheck) {
+		oc.checkAttempts = attempts
+	}
+}
+
+// WithServiceProbes probes the registry, Pyxis, and the cluster before executing any
+// check, and fails with a report of those that are not ready, rather than when a check
+// first uses them.
+func WithServiceProbes() Option {
+	return func(oc *operatorCheck) {
+		oc.probeServices = true
+	}
+}
+
+// WithProxy sends registry and Pyxis requests through the proxy at proxyURL, except
+// for requests to the hosts in noProxy, a comma-separated list in the same format as
+// NO_PROXY. By default, the proxy is read from the environment.
+func WithProxy(proxyURL, noProxy string) Option {
+	return func(oc *operatorCheck) {
+		oc.proxy = proxy.Config{URL: proxyURL, NoProxy: noProxy}
+	}
+}
+
+// WithClusterProxy accesses the cluster through the proxy at proxyURL, e.g. a SOCKS5
+// proxy on a bastion, such as socks5://bastion.example.com:1080. Hostnames are resolved
+// by SOCKS5 proxies. By default, the cluster is accessed through the proxy configured in
+// the kubeconfig, or in the environment.
+func WithClusterProxy(proxyURL string) Option {
+	return func(oc *operatorCheck) {
+		oc.clusterProxy = proxyURL
+	}
+}
+
+// WithCABundle trusts the PEM encoded certificate authorities in the file at path,
+// in addition to the system's, when connecting to registries and Pyxis.
+func WithCABundle(path string) Option {
+	return func(oc *operatorCheck) {
+		oc.caBundle = path
+	}
+}
+
+// WithRegistryCredentials authenticates with the registry of the image under test using
+// username and password, or a bearer token, instead of a docker config file.
+func WithRegistryCredentials(username, password, token string) Option {
+	return func(oc *operatorCheck) {
+		oc.registryCredentials = authn.Credentials{Username: username, Password: password, Token: token}
+	}
+}
+
+// WithRegistryMirror pulls images in source, a registry, namespace, or repository, from
+// mirrors, in order, before falling back to source. Source may be prefixed with "*." to
+// match all subdomains of a registry.
+func WithRegistryMirror(source string, mirrors ...string) Option {
+	return func(oc *operatorCheck) {
+		oc.mirrors = append(oc.mirrors, mirror.Mirror{Source: source, Mirrors: mirrors})
+	}
+}
+
+// WithMirrorConfigFile pulls images from the mirrors configured by the OpenShift
+// ImageDigestMirrorSet, ImageTagMirrorSet, and ImageContentSourcePolicy resources in
+// the YAML file at path, as a cluster with those resources would.
+func WithMirrorConfigFile(path string) Option {
+	return func(oc *operatorCheck) {
+		oc.mirrorConfig = path
+	}
+}
+
+// WithLockfile fails the check if the digest of the bundle image differs from the
+// digest it is pinned to by the lockfile at path, if it is not pinned by it, or if its
+// digest is denied by it. Bundle images referenced by digest are not checked against
+// the lockfile.
+func WithLockfile(path string) Option {
+	return func(oc *operatorCheck) {
+		oc.lockfile = path
+	}
+}
+
+// WithLockfileUpdate pins the bundle image to its digest in the lockfile configured by
+// WithLockfile, rather than failing the check when it differs or is not pinned. The
+// lockfile is created if it does not exist.
+func WithLockfileUpdate() Option {
+	return func(oc *operatorCheck) {
+		oc.updateLockfile = true
+	}
+}
+
+// WithScratchDir extracts the bundle image to dir, rather than to the default temporary
+// directory. The check fails before the bundle is extracted if dir does not have the
+// free space it needs.
+func WithScratchDir(dir string) Option {
+	return func(oc *operatorCheck) {
+		oc.scratchDir = dir
+	}
+}
+
 type operatorCheck struct {
 	// required
 	image      string
@@ -149,7 +493,27 @@ type operatorCheck struct {
 	scorecardNamespace      string
 	scorecardServiceAccount string
 	scorecardWaitTime       string
+	basicOperability        bool
 	operatorChannel         string
 	dockerConfigFilePath    string
 	insecure                bool
+	clock                   clock.Clock
+	traceOnFailure          bool
+	failFast                bool
+	timeouts                check.Timeouts
+	egressAllowed           []string
+	denyEgress              bool
+	checkAttempts           int
+	probeServices           bool
+	proxy                   proxy.Config
+	clusterProxy            string
+	caBundle                string
+	mirrors                 []mirror.Mirror
+	mirrorConfig            string
+	lockfile                string
+	updateLockfile          bool
+	scratchDir              string
+	registryCredentials     authn.Credentials
+	resolvers               []resolve.Resolver
+	clusterProvider         cluster.Provider
 }
@@ -2,10 +2,17 @@ package operator
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/clock"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/cluster"
 	preflighterr "github.com/redhat-openshift-ecosystem/openshift-preflight/errors"
 )
 
@@ -31,6 +38,20 @@ var _ = Describe("Operator Check initialization", func() {
 				WithOperatorChannel(operatorChannel),
 				WithDockerConfigJSONFromFile(dockerConfigFilePath),
 				WithInsecureConnection(),
+				WithDeterministicTimes(),
+				WithTraceOnFailure(),
+				WithFailFast(),
+				WithServiceProbes(),
+				WithCheckAttempts(3),
+				WithCheckTimeout(10*time.Minute),
+				WithCheckTimeoutFor("DeployableByOLM", 30*time.Minute),
+				WithEgressPolicy("DeployableByOLM"),
+				WithProxy("http://proxy.example.com:3128", ".example.com"),
+				WithCABundle("/etc/pki/ca.pem"),
+				WithClusterProxy("socks5://bastion.example.com:1080"),
+				WithLockfile("preflight.lock"),
+				WithLockfileUpdate(),
+				WithScratchDir("/var/tmp"),
 			)
 			Expect(c.image).To(Equal(image))
 			Expect(c.kubeconfig).To(Equal(kubeconfig))
@@ -43,6 +64,22 @@ var _ = Describe("Operator Check initialization", func() {
 			Expect(c.operatorChannel).To(Equal(operatorChannel))
 			Expect(c.dockerConfigFilePath).To(Equal(dockerConfigFilePath))
 			Expect(c.insecure).To(Equal(insecure))
+			Expect(c.clock).To(Equal(clock.Deterministic()))
+			Expect(c.traceOnFailure).To(BeTrue())
+			Expect(c.failFast).To(BeTrue())
+			Expect(c.probeServices).To(BeTrue())
+			Expect(c.checkAttempts).To(Equal(3))
+			Expect(c.timeouts.For("ValidateOperatorBundle")).To(Equal(10 * time.Minute))
+			Expect(c.timeouts.For("DeployableByOLM")).To(Equal(30 * time.Minute))
+			Expect(c.denyEgress).To(BeTrue())
+			Expect(c.egressAllowed).To(ConsistOf("DeployableByOLM"))
+			Expect(c.proxy.URL).To(Equal("http://proxy.example.com:3128"))
+			Expect(c.proxy.NoProxy).To(Equal(".example.com"))
+			Expect(c.caBundle).To(Equal("/etc/pki/ca.pem"))
+			Expect(c.clusterProxy).To(Equal("socks5://bastion.example.com:1080"))
+			Expect(c.lockfile).To(Equal("preflight.lock"))
+			Expect(c.updateLockfile).To(BeTrue())
+			Expect(c.scratchDir).To(Equal("/var/tmp"))
 		})
 	})
 })
@@ -63,6 +100,85 @@ var _ = Describe("Operator Check Execution", func() {
 			Expect(err).To(MatchError(preflighterr.ErrKubeconfigEmpty))
 		})
 
+		It("should fail if the cluster provider fails", func() {
+			failing := cluster.Func(func(ctx context.Context) (cluster.Cluster, error) {
+				return cluster.Cluster{}, errors.New("no clusters available")
+			})
+			chk := NewCheck("image", "indeximage", nil, WithClusterProvider(failing))
+			_, err := chk.Run(context.TODO())
+			Expect(err).To(MatchError(preflighterr.ErrClusterNotProvided))
+			Expect(err).To(MatchError(ContainSubstring("no clusters available")))
+		})
+
+		It("should release the provided cluster if it provides no kubeconfig", func() {
+			var released bool
+			provider := cluster.Func(func(ctx context.Context) (cluster.Cluster, error) {
+				return cluster.Cluster{Release: func(ctx context.Context) error {
+					released = true
+					return nil
+				}}, nil
+			})
+			chk := NewCheck("image", "indeximage", nil, WithClusterProvider(provider))
+			_, err := chk.Run(context.TODO())
+			Expect(err).To(MatchError(preflighterr.ErrKubeconfigEmpty))
+			Expect(released).To(BeTrue())
+		})
+
+		It("should release the provided cluster once the check is interrupted", func() {
+			var released []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				released = append(released, r.Method+" "+r.URL.Path)
+			}))
+			DeferCleanup(server.Close)
+
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			provider := cluster.Func(func(context.Context) (cluster.Cluster, error) {
+				// Preflight is interrupted once the cluster is provided.
+				cancel()
+				return cluster.Cluster{Release: func(ctx context.Context) error {
+					req, err := http.NewRequestWithContext(ctx, http.MethodDelete, server.URL+"/leases/ci-4f2a", nil)
+					if err != nil {
+						return err
+					}
+					resp, err := http.DefaultClient.Do(req)
+					if err != nil {
+						return err
+					}
+					return resp.Body.Close()
+				}}, nil
+			})
+			chk := NewCheck("image", "indeximage", nil, WithClusterProvider(provider))
+			_, err := chk.Run(ctx)
+			Expect(err).To(HaveOccurred())
+			Expect(released).To(ConsistOf("DELETE /leases/ci-4f2a"))
+		})
+
+		It("should fail if the cluster proxy is invalid, before a cluster is provided", func() {
+			var provided bool
+			provider := cluster.Func(func(ctx context.Context) (cluster.Cluster, error) {
+				provided = true
+				return cluster.Cluster{}, nil
+			})
+			chk := NewCheck("image", "indeximage", nil, WithClusterProvider(provider), WithClusterProxy("bastion.example.com:1080"))
+			_, err := chk.Run(context.TODO())
+			Expect(err).To(MatchError(ContainSubstring("invalid cluster proxy")))
+			Expect(provided).To(BeFalse())
+		})
+
+		It("should fail if the lockfile does not exist, before a cluster is provided", func() {
+			var provided bool
+			provider := cluster.Func(func(ctx context.Context) (cluster.Cluster, error) {
+				provided = true
+				return cluster.Cluster{}, nil
+			})
+			lock := filepath.Join(GinkgoT().TempDir(), "preflight.lock")
+			chk := NewCheck("image", "indeximage", nil, WithClusterProvider(provider), WithLockfile(lock))
+			_, err := chk.Run(context.TODO())
+			Expect(err).To(MatchError(ContainSubstring("could not read lockfile")))
+			Expect(provided).To(BeFalse())
+		})
+
 		It("should fail if you passed an empty index image", func() {
 			chk := NewCheck("image", "", []byte{})
 			_, err := chk.Run(context.TODO())
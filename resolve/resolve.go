@@ -0,0 +1,77 @@
+// Package resolve maps the names an organization uses for images, e.g. the coordinates
+// of an artifact in a repository manager, to the registry references that preflight
+// pulls, so that images can be checked by the names used to manage them.
+package resolve
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// Resolver resolves the names of images to registry references.
+type Resolver interface {
+	// Resolve returns the registry reference of the image named name, e.g.
+	// quay.io/example/image@sha256:..., or an empty string if name is not one
+	// that the Resolver resolves.
+	Resolve(ctx context.Context, name string) (string, error)
+}
+
+// Func is a function that implements Resolver.
+type Func func(ctx context.Context, name string) (string, error)
+
+// Resolve calls f.
+func (f Func) Resolve(ctx context.Context, name string) (string, error) {
+	return f(ctx, name)
+}
+
+// Image returns the registry reference of the image named image, as resolved by the
+// first of resolvers that resolves it, or image if none does.
+func Image(ctx context.Context, image string, resolvers ...Resolver) (string, error) {
+	for _, r := range resolvers {
+		ref, err := r.Resolve(ctx, image)
+		if err != nil {
+			return "", fmt.Errorf("could not resolve image %s: %w", image, err)
+		}
+		if ref == "" {
+			continue
+		}
+		if _, err := name.ParseReference(ref); err != nil {
+			return "", fmt.Errorf("image %s was resolved to %q, which is not a valid reference: %w", image, ref, err)
+		}
+
+		logr.FromContextOrDiscard(ctx).Info("resolved image", "name", image, "reference", ref)
+		return ref, nil
+	}
+
+	return image, nil
+}
+
+// Command returns a Resolver that executes the command at path, with args followed by
+// the name of the image, and reads the reference it resolves to from its stdout. A
+// command that writes nothing does not resolve the name. The command fails if it exits
+// with a non-zero status, and what it wrote to stderr is included in the error.
+func Command(path string, args ...string) Resolver {
+	return Func(func(ctx context.Context, name string) (string, error) {
+		var stdout, stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, path, append(append([]string{}, args...), name)...)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+
+		if err := cmd.Run(); err != nil {
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) && stderr.Len() != 0 {
+				return "", fmt.Errorf("%s failed: %w: %s", path, err, strings.TrimSpace(stderr.String()))
+			}
+			return "", fmt.Errorf("%s failed: %w", path, err)
+		}
+
+		return strings.TrimSpace(stdout.String()), nil
+	})
+}
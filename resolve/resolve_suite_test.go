@@ -0,0 +1,13 @@
+package resolve
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestResolve(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Resolve Suite")
+}
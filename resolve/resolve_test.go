@@ -0,0 +1,73 @@
+package resolve
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Image resolution", func() {
+	const reference = "quay.io/example/image@sha256:0000000000000000000000000000000000000000000000000000000000000000"
+
+	unresolved := Func(func(ctx context.Context, name string) (string, error) { return "", nil })
+	resolved := Func(func(ctx context.Context, name string) (string, error) { return reference, nil })
+
+	It("should return the reference of the first resolver that resolves the name", func() {
+		ref, err := Image(context.TODO(), "nexus:example/image:1.0", unresolved, resolved)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ref).To(Equal(reference))
+	})
+
+	It("should return the name if no resolver resolves it", func() {
+		ref, err := Image(context.TODO(), "quay.io/example/image:1.0", unresolved)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ref).To(Equal("quay.io/example/image:1.0"))
+	})
+
+	It("should throw an error if a resolver fails", func() {
+		failing := Func(func(ctx context.Context, name string) (string, error) { return "", errors.New("not found") })
+		_, err := Image(context.TODO(), "nexus:example/image:1.0", failing, resolved)
+		Expect(err).To(MatchError(ContainSubstring("could not resolve image nexus:example/image:1.0: not found")))
+	})
+
+	It("should throw an error if the name is resolved to an invalid reference", func() {
+		invalid := Func(func(ctx context.Context, name string) (string, error) { return "not a reference", nil })
+		_, err := Image(context.TODO(), "nexus:example/image:1.0", invalid)
+		Expect(err).To(MatchError(ContainSubstring("which is not a valid reference")))
+	})
+
+	When("resolving with a command", func() {
+		var path string
+
+		BeforeEach(func() {
+			path = filepath.Join(GinkgoT().TempDir(), "resolver")
+			Expect(os.WriteFile(path, []byte(`#!/bin/sh
+case "$2" in
+  nexus:*) echo "`+reference+`" ;;
+  broken:*) echo "no such artifact" >&2; exit 1 ;;
+esac
+`), 0o755)).To(Succeed())
+		})
+
+		It("should pass the arguments and the name, and read the reference", func() {
+			ref, err := Image(context.TODO(), "nexus:example/image:1.0", Command(path, "--resolve"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ref).To(Equal(reference))
+		})
+
+		It("should not resolve names the command writes nothing for", func() {
+			ref, err := Image(context.TODO(), "quay.io/example/image:1.0", Command(path, "--resolve"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ref).To(Equal("quay.io/example/image:1.0"))
+		})
+
+		It("should include what the command wrote to stderr when it fails", func() {
+			_, err := Image(context.TODO(), "broken:example/image:1.0", Command(path, "--resolve"))
+			Expect(err).To(MatchError(ContainSubstring("no such artifact")))
+		})
+	})
+})
@@ -0,0 +1,17 @@
+// Package runtime provides the configuration preflight's checks are executed with, for
+// programs that embed preflight and want the same behavior as the preflight binary
+// without reading its configuration file or environment variables.
+package runtime
+
+import (
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/runtime"
+)
+
+// Config is the configuration of an execution of preflight. Build it with NewConfig,
+// and its With methods, and pass it to container.WithConfig.
+type Config = runtime.Config
+
+// NewConfig returns a Config with the same defaults as the preflight binary.
+func NewConfig() *Config {
+	return runtime.NewConfig()
+}
@@ -0,0 +1,254 @@
+// Package scan provides a Service that checks container images against
+// preflight's Container Policy, suitable for building "scan on push"
+// services for container registries.
+package scan
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	goruntime "runtime"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/artifacts"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/certification"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/container"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/formatters"
+)
+
+const (
+	// DefaultFormat is the format of the formatted results in a Result.
+	DefaultFormat = formatters.DefaultFormat
+	// DefaultCacheTTL is how long the report for an image digest is cached.
+	DefaultCacheTTL = time.Hour
+	// DefaultMaxConcurrentScans is how many images are scanned at once.
+	DefaultMaxConcurrentScans = 2
+)
+
+type Option = func(*Service)
+
+// WithDockerConfigJSONFromFile sets the docker config used to pull images.
+func WithDockerConfigJSONFromFile(path string) Option {
+	return func(s *Service) {
+		s.dockerConfig = path
+	}
+}
+
+// WithPlatform sets the platform of the images to scan, either an architecture or a
+// full platform, e.g. linux/arm/v7. Defaults to the current platform.
+func WithPlatform(platform string) Option {
+	return func(s *Service) {
+		s.platform = platform
+	}
+}
+
+// WithInsecureConnection allows images to be pulled from registries
+// without TLS, or with self-signed certificates.
+func WithInsecureConnection() Option {
+	return func(s *Service) {
+		s.insecure = true
+	}
+}
+
+// WithFormat sets the format of the formatted results in a Result, e.g. json,
+// xml, or junitxml. Defaults to DefaultFormat.
+func WithFormat(format string) Option {
+	return func(s *Service) {
+		s.format = format
+	}
+}
+
+// WithCacheTTL sets how long the report for an image digest is cached. A ttl of
+// zero disables caching. Defaults to DefaultCacheTTL.
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(s *Service) {
+		s.cacheTTL = ttl
+	}
+}
+
+// WithMaxConcurrentScans sets how many images are scanned at once. Scans
+// beyond this limit wait for a running scan to finish. Defaults to
+// DefaultMaxConcurrentScans.
+func WithMaxConcurrentScans(n int) Option {
+	return func(s *Service) {
+		s.maxConcurrentScans = n
+	}
+}
+
+// Result is the outcome of scanning an image. Results served from the cache
+// share their contents with other results, and must not be modified.
+type Result struct {
+	Image   string
+	Results certification.Results
+	// Formatted contains Results in the format configured for the Service.
+	Formatted []byte
+	// Artifacts contains the files written by checks, by filename.
+	Artifacts map[string][]byte
+	// Cached is true if the report was served from the cache.
+	Cached bool
+}
+
+// Service scans container images against the Container Policy. Scans of
+// images referenced by digest are cached, since the content of a digest
+// cannot change. A Service is safe for concurrent use.
+type Service struct {
+	dockerConfig       string
+	platform           string
+	insecure           bool
+	format             string
+	cacheTTL           time.Duration
+	maxConcurrentScans int
+
+	formatter formatters.ResponseFormatter
+	sem       chan struct{}
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+
+	// run executes the checks for image. It is replaceable for testing.
+	run func(ctx context.Context, image string) (certification.Results, error)
+	now func() time.Time
+}
+
+type cacheEntry struct {
+	report  Result
+	expires time.Time
+}
+
+// NewService returns a Service configured by opts.
+func NewService(opts ...Option) (*Service, error) {
+	s := &Service{
+		platform:           goruntime.GOARCH,
+		format:             DefaultFormat,
+		cacheTTL:           DefaultCacheTTL,
+		maxConcurrentScans: DefaultMaxConcurrentScans,
+		cache:              map[string]cacheEntry{},
+		now:                time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.maxConcurrentScans < 1 {
+		return nil, fmt.Errorf("the maximum number of concurrent scans must be at least 1")
+	}
+
+	formatter, err := formatters.NewByName(s.format)
+	if err != nil {
+		return nil, fmt.Errorf("invalid format: %w", err)
+	}
+
+	s.formatter = formatter
+	s.sem = make(chan struct{}, s.maxConcurrentScans)
+	s.run = s.runContainerCheck
+
+	return s, nil
+}
+
+// Scan checks image, and returns its report. If ctx is cancelled while
+// waiting for another scan to finish, the context's error is returned.
+func (s *Service) Scan(ctx context.Context, image string) (*Result, error) {
+	if report, ok := s.cached(image); ok {
+		return report, nil
+	}
+
+	select {
+	case s.sem <- struct{}{}:
+		defer func() { <-s.sem }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	// Another scan of the same digest may have finished while waiting.
+	if report, ok := s.cached(image); ok {
+		return report, nil
+	}
+
+	// Each scan keeps its artifacts in memory, separate from other scans.
+	artifactsWriter, err := artifacts.NewMapWriter()
+	if err != nil {
+		return nil, err
+	}
+	ctx = artifacts.ContextWithWriter(ctx, artifactsWriter)
+
+	results, err := s.run(ctx, image)
+	if err != nil {
+		return nil, fmt.Errorf("could not scan %s: %w", image, err)
+	}
+
+	formatted, err := s.formatter.Format(ctx, results)
+	if err != nil {
+		return nil, fmt.Errorf("could not format results for %s: %w", image, err)
+	}
+
+	report := Result{
+		Image:     image,
+		Results:   results,
+		Formatted: formatted,
+		Artifacts: map[string][]byte{},
+	}
+	for _, name := range artifactsWriter.Filenames() {
+		b, err := artifactsWriter.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("could not read artifact %s: %w", name, err)
+		}
+		report.Artifacts[name] = b
+	}
+
+	s.store(report)
+
+	return &report, nil
+}
+
+// cached returns a copy of the cached report for image, if any.
+func (s *Service) cached(image string) (*Result, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.cache[image]
+	if !ok {
+		return nil, false
+	}
+
+	if !s.now().Before(entry.expires) {
+		delete(s.cache, image)
+		return nil, false
+	}
+
+	report := entry.report
+	report.Cached = true
+	return &report, true
+}
+
+// store caches report if its image is referenced by digest.
+func (s *Service) store(report Result) {
+	if s.cacheTTL <= 0 || !isDigestReference(report.Image) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cache[report.Image] = cacheEntry{report: report, expires: s.now().Add(s.cacheTTL)}
+}
+
+func (s *Service) runContainerCheck(ctx context.Context, image string) (certification.Results, error) {
+	opts := []container.Option{
+		container.WithDockerConfigJSONFromFile(s.dockerConfig),
+		container.WithPlatform(s.platform),
+	}
+	if s.insecure {
+		opts = append(opts, container.WithInsecureConnection())
+	}
+
+	return container.NewCheck(image, opts...).Run(ctx)
+}
+
+// isDigestReference returns true if image refers to a digest, rather than a
+// tag that may later refer to different content.
+func isDigestReference(image string) bool {
+	return strings.Contains(image, "@sha256:")
+}
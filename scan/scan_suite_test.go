@@ -0,0 +1,13 @@
+package scan
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestScan(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Scan Suite")
+}
@@ -0,0 +1,149 @@
+package scan
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/artifacts"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/certification"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	digestImage = "quay.io/example/image@sha256:0000000000000000000000000000000000000000000000000000000000000000"
+	tagImage    = "quay.io/example/image:latest"
+)
+
+var _ = Describe("Scan Service", func() {
+	var (
+		svc   *Service
+		runs  int
+		mu    sync.Mutex
+		now   time.Time
+		runFn func(ctx context.Context, image string) (certification.Results, error)
+	)
+
+	BeforeEach(func() {
+		var err error
+		svc, err = NewService()
+		Expect(err).ToNot(HaveOccurred())
+
+		runs = 0
+		now = time.Now()
+		svc.now = func() time.Time { return now }
+		runFn = func(ctx context.Context, image string) (certification.Results, error) {
+			mu.Lock()
+			runs++
+			mu.Unlock()
+
+			aw := artifacts.WriterFromContext(ctx)
+			Expect(aw).ToNot(BeNil())
+			_, err := aw.WriteFile("cert-image.json", strings.NewReader(`{}`))
+			Expect(err).ToNot(HaveOccurred())
+
+			return certification.Results{TestedImage: image, PassedOverall: true}, nil
+		}
+		svc.run = func(ctx context.Context, image string) (certification.Results, error) {
+			return runFn(ctx, image)
+		}
+	})
+
+	Context("When creating a service", func() {
+		It("should reject unknown formats", func() {
+			_, err := NewService(WithFormat("unknown"))
+			Expect(err).To(MatchError(ContainSubstring("invalid format")))
+		})
+
+		It("should reject a concurrency limit below one", func() {
+			_, err := NewService(WithMaxConcurrentScans(0))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("When scanning an image", func() {
+		It("should return the results, formatted results, and artifacts", func() {
+			report, err := svc.Scan(context.TODO(), tagImage)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(report.Image).To(Equal(tagImage))
+			Expect(report.Results.PassedOverall).To(BeTrue())
+			Expect(string(report.Formatted)).To(ContainSubstring(tagImage))
+			Expect(report.Artifacts).To(HaveKeyWithValue("cert-image.json", []byte(`{}`)))
+			Expect(report.Cached).To(BeFalse())
+		})
+
+		It("should return an error if the checks cannot be run", func() {
+			runFn = func(ctx context.Context, image string) (certification.Results, error) {
+				runs++
+				return certification.Results{}, errors.New("unable to pull image")
+			}
+			_, err := svc.Scan(context.TODO(), digestImage)
+			Expect(err).To(MatchError(ContainSubstring("unable to pull image")))
+
+			_, err = svc.Scan(context.TODO(), digestImage)
+			Expect(err).To(HaveOccurred())
+			Expect(runs).To(Equal(2))
+		})
+
+		It("should return an error if the context is cancelled while waiting", func() {
+			svc.sem = make(chan struct{})
+			ctx, cancel := context.WithCancel(context.TODO())
+			cancel()
+			_, err := svc.Scan(ctx, tagImage)
+			Expect(err).To(MatchError(context.Canceled))
+		})
+	})
+
+	Context("When scanning an image more than once", func() {
+		It("should cache reports of images referenced by digest", func() {
+			_, err := svc.Scan(context.TODO(), digestImage)
+			Expect(err).ToNot(HaveOccurred())
+
+			report, err := svc.Scan(context.TODO(), digestImage)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(report.Cached).To(BeTrue())
+			Expect(runs).To(Equal(1))
+		})
+
+		It("should not cache reports of images referenced by tag", func() {
+			_, err := svc.Scan(context.TODO(), tagImage)
+			Expect(err).ToNot(HaveOccurred())
+
+			report, err := svc.Scan(context.TODO(), tagImage)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(report.Cached).To(BeFalse())
+			Expect(runs).To(Equal(2))
+		})
+
+		It("should scan again once the cached report expires", func() {
+			_, err := svc.Scan(context.TODO(), digestImage)
+			Expect(err).ToNot(HaveOccurred())
+
+			now = now.Add(DefaultCacheTTL)
+			report, err := svc.Scan(context.TODO(), digestImage)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(report.Cached).To(BeFalse())
+			Expect(runs).To(Equal(2))
+		})
+
+		It("should only scan once when scanned concurrently", func() {
+			svc.sem = make(chan struct{}, 1)
+			var wg sync.WaitGroup
+			for i := 0; i < 4; i++ {
+				wg.Add(1)
+				go func() {
+					defer GinkgoRecover()
+					defer wg.Done()
+					_, err := svc.Scan(context.TODO(), digestImage)
+					Expect(err).ToNot(HaveOccurred())
+				}()
+			}
+			wg.Wait()
+			Expect(runs).To(Equal(1))
+		})
+	})
+})
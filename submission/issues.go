@@ -0,0 +1,476 @@
+package submission
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-containerregistry/pkg/name"
+	"sigs.k8s.io/yaml"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/formatters"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/transport"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/version"
+)
+
+// What an issue is filed for.
+const (
+	// IssuePerCheck files an issue for each check that did not pass.
+	IssuePerCheck = "check"
+	// IssuePerRun files one issue for all of the checks that did not pass.
+	IssuePerRun = "run"
+)
+
+// The dedup keys of issues, unless configured otherwise.
+const (
+	defaultCheckDedupKey = "preflight:{{ .Repository }}:{{ .Check.Name }}"
+	defaultRunDedupKey   = "preflight:{{ .Repository }}"
+)
+
+// IssueTrackerConfig describes how to file issues for checks that did not pass with the
+// REST API of an issue tracker, such as Jira. Every string in it, other than Per and
+// IDPath, is a text/template executed with IssueData. Besides the functions of
+// text/template, templates may call json, which encodes its argument as JSON, and env,
+// which returns the value of an environment variable, e.g. for credentials.
+type IssueTrackerConfig struct {
+	// Per is IssuePerCheck or IssuePerRun. Defaults to IssuePerCheck.
+	Per string `json:"per,omitempty"`
+	// DedupKey identifies an issue across runs, so that it is found and updated rather
+	// than filed again. Defaults to preflight:<repository>:<check>, or
+	// preflight:<repository> for IssuePerRun.
+	DedupKey string `json:"dedupKey,omitempty"`
+	// Find, if set, looks up the issue with the dedup key. If the ID of an issue is found
+	// in its response, the issue is updated with Update, or left alone if Update is not
+	// set, instead of filed with Create.
+	Find *IssueRequest `json:"find,omitempty"`
+	// Create files an issue.
+	Create IssueRequest `json:"create"`
+	// Update updates the issue that was found, e.g. by adding a comment.
+	Update *IssueRequest `json:"update,omitempty"`
+}
+
+// IssueRequest is a request to the REST API of an issue tracker.
+type IssueRequest struct {
+	// Method defaults to GET for Find, and POST otherwise.
+	Method  string            `json:"method,omitempty"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+	// IDPath is the path to the ID of the issue in the JSON response of Find, with the
+	// keys of objects and the indexes of arrays separated by dots, e.g. issues.0.key.
+	IDPath string `json:"idPath,omitempty"`
+}
+
+// IssueData is what issue templates are executed with.
+type IssueData struct {
+	// Image is the image that was checked, and Repository is the image without its
+	// tag or digest.
+	Image             string
+	Repository        string
+	CertificationHash string
+	PreflightVersion  string
+	// Checks are the checks the issue is filed for, which did not pass.
+	Checks []IssueCheck
+	// Check is the first of Checks, i.e. the check the issue is filed for with
+	// IssuePerCheck.
+	Check IssueCheck
+	// DedupKey is the dedup key of the issue. It is empty when DedupKey is executed.
+	DedupKey string
+	// IssueID is the ID of the issue that was found. It is only set for Update.
+	IssueID string
+}
+
+// IssueCheck is a check that did not pass.
+type IssueCheck struct {
+	Name string
+	// Result is ResultFailed or ResultError.
+	Result           string
+	Description      string
+	Help             string
+	Suggestion       string
+	KnowledgeBaseURL string
+	CheckURL         string
+}
+
+// LoadIssueTrackerConfig reads the IssueTrackerConfig in the YAML or JSON file at path.
+func LoadIssueTrackerConfig(path string) (IssueTrackerConfig, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return IssueTrackerConfig{}, fmt.Errorf("could not read issue tracker configuration: %w", err)
+	}
+
+	var config IssueTrackerConfig
+	if err := yaml.UnmarshalStrict(b, &config); err != nil {
+		return IssueTrackerConfig{}, fmt.Errorf("could not parse issue tracker configuration: %w", err)
+	}
+
+	return config, nil
+}
+
+type IssueTrackerOption = func(*IssueTracker)
+
+// WithIssueTrackerHTTPClient sets the client that sends requests to the issue tracker.
+// Defaults to a client using the proxy and CA bundle configured in the context passed
+// to Submit.
+func WithIssueTrackerHTTPClient(client HTTPClient) IssueTrackerOption {
+	return func(t *IssueTracker) {
+		t.client = client
+	}
+}
+
+// IssueTracker is a Submitter that files issues for the checks of a check container
+// execution that did not pass, or updates the issues filed by previous executions.
+// Nothing is filed if every check passed.
+type IssueTracker struct {
+	per      string
+	dedupKey *template.Template
+	find     *issueRequestTemplate
+	create   *issueRequestTemplate
+	update   *issueRequestTemplate
+	client   HTTPClient
+}
+
+var _ Submitter = &IssueTracker{}
+
+// issueRequestTemplate is an IssueRequest with its templates parsed.
+type issueRequestTemplate struct {
+	method  string
+	url     *template.Template
+	headers map[string]*template.Template
+	body    *template.Template
+	idPath  string
+}
+
+// NewIssueTracker returns an IssueTracker filing issues as described by config,
+// configured by opts. It returns an error if the templates of config cannot be parsed.
+func NewIssueTracker(config IssueTrackerConfig, opts ...IssueTrackerOption) (*IssueTracker, error) {
+	t := &IssueTracker{per: config.Per}
+
+	dedupKey := config.DedupKey
+	switch t.per {
+	case "", IssuePerCheck:
+		t.per = IssuePerCheck
+		if dedupKey == "" {
+			dedupKey = defaultCheckDedupKey
+		}
+	case IssuePerRun:
+		if dedupKey == "" {
+			dedupKey = defaultRunDedupKey
+		}
+	default:
+		return nil, fmt.Errorf("issues must be filed per %s or %s, not %q", IssuePerCheck, IssuePerRun, t.per)
+	}
+
+	var err error
+	if t.dedupKey, err = parseIssueTemplate("dedupKey", dedupKey); err != nil {
+		return nil, err
+	}
+	if config.Create.URL == "" {
+		return nil, errors.New("the url to create issues with must be configured")
+	}
+	if t.create, err = parseIssueRequest("create", config.Create, http.MethodPost); err != nil {
+		return nil, err
+	}
+	if config.Find != nil {
+		if config.Find.IDPath == "" {
+			return nil, errors.New("the path to the ID of the issue found must be configured")
+		}
+		if t.find, err = parseIssueRequest("find", *config.Find, http.MethodGet); err != nil {
+			return nil, err
+		}
+	}
+	if config.Update != nil {
+		if config.Find == nil {
+			return nil, errors.New("issues can only be updated if they can be found")
+		}
+		if t.update, err = parseIssueRequest("update", *config.Update, http.MethodPost); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return t, nil
+}
+
+func (t *IssueTracker) Submit(ctx context.Context) error {
+	logger := logr.FromContextOrDiscard(ctx)
+
+	b, err := resultsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	var results formatters.UserResponse
+	if err := json.Unmarshal(b, &results); err != nil {
+		return fmt.Errorf("could not parse results: %w", err)
+	}
+
+	issues := t.issues(results)
+	if len(issues) == 0 {
+		logger.Info("every check passed, so no issues were filed")
+		return nil
+	}
+
+	client := t.client
+	if client == nil {
+		client = transport.HTTPClient(ctx, 60*time.Second)
+	}
+
+	// Every issue is filed, even if filing one before it fails.
+	var errs []string
+	for _, issue := range issues {
+		if err := t.file(ctx, client, issue); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("could not file issues: %s", strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+// issues returns the data of each issue to file for results.
+func (t *IssueTracker) issues(results formatters.UserResponse) []IssueData {
+	var checks []IssueCheck
+	for _, c := range results.Results.Failed {
+		checks = append(checks, IssueCheck{
+			Name:             c.Name,
+			Result:           ResultFailed,
+			Description:      c.Description,
+			Help:             c.Help,
+			Suggestion:       c.Suggestion,
+			KnowledgeBaseURL: c.KnowledgeBaseURL,
+			CheckURL:         c.CheckURL,
+		})
+	}
+	for _, c := range results.Results.Errors {
+		checks = append(checks, IssueCheck{
+			Name:        c.Name,
+			Result:      ResultError,
+			Description: c.Description,
+			Help:        c.Help,
+		})
+	}
+	if len(checks) == 0 {
+		return nil
+	}
+
+	repository := results.Image
+	if ref, err := name.ParseReference(results.Image); err == nil {
+		repository = ref.Context().Name()
+	}
+	base := IssueData{
+		Image:             results.Image,
+		Repository:        repository,
+		CertificationHash: results.CertificationHash,
+		PreflightVersion:  results.LibraryInfo.Version,
+	}
+
+	if t.per == IssuePerRun {
+		data := base
+		data.Checks = checks
+		data.Check = checks[0]
+		return []IssueData{data}
+	}
+
+	issues := make([]IssueData, 0, len(checks))
+	for _, check := range checks {
+		data := base
+		data.Checks = []IssueCheck{check}
+		data.Check = check
+		issues = append(issues, data)
+	}
+
+	return issues
+}
+
+// file files the issue for data, or updates the issue with its dedup key.
+func (t *IssueTracker) file(ctx context.Context, client HTTPClient, data IssueData) error {
+	logger := logr.FromContextOrDiscard(ctx)
+
+	dedupKey, err := executeIssueTemplate(t.dedupKey, data)
+	if err != nil {
+		return err
+	}
+	data.DedupKey = dedupKey
+
+	if t.find != nil {
+		resp, err := t.find.do(ctx, client, data)
+		if err != nil {
+			return fmt.Errorf("could not find the issue %s: %w", dedupKey, err)
+		}
+
+		var found interface{}
+		if err := json.Unmarshal(resp, &found); err != nil {
+			return fmt.Errorf("could not parse the response finding the issue %s: %w", dedupKey, err)
+		}
+
+		if id, ok := issueID(found, t.find.idPath); ok {
+			data.IssueID = id
+			if t.update == nil {
+				logger.Info("an issue was already filed", "dedupKey", dedupKey, "issue", id)
+				return nil
+			}
+
+			if _, err := t.update.do(ctx, client, data); err != nil {
+				return fmt.Errorf("could not update the issue %s: %w", id, err)
+			}
+			logger.Info("updated issue", "dedupKey", dedupKey, "issue", id)
+			return nil
+		}
+	}
+
+	if _, err := t.create.do(ctx, client, data); err != nil {
+		return fmt.Errorf("could not file the issue %s: %w", dedupKey, err)
+	}
+	logger.Info("filed issue", "dedupKey", dedupKey)
+
+	return nil
+}
+
+// do sends the request, executed with data, and returns the body of the response.
+func (r *issueRequestTemplate) do(ctx context.Context, client HTTPClient, data IssueData) ([]byte, error) {
+	url, err := executeIssueTemplate(r.url, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var body io.Reader
+	if r.body != nil {
+		b, err := executeIssueTemplate(r.body, data)
+		if err != nil {
+			return nil, err
+		}
+		body = strings.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "preflight/"+version.Version.Version)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, value := range r.headers {
+		v, err := executeIssueTemplate(value, data)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set(key, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("%s %s: status code: %d", r.method, req.URL.Redacted(), resp.StatusCode)
+	}
+
+	return b, nil
+}
+
+// issueID returns the ID at path in v, the JSON response of a request finding an issue,
+// if there is one.
+func issueID(v interface{}, path string) (string, bool) {
+	for _, key := range strings.Split(path, ".") {
+		switch value := v.(type) {
+		case map[string]interface{}:
+			v = value[key]
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(value) {
+				return "", false
+			}
+			v = value[i]
+		default:
+			return "", false
+		}
+	}
+
+	switch id := v.(type) {
+	case string:
+		return id, id != ""
+	case float64:
+		return strconv.FormatFloat(id, 'f', -1, 64), true
+	}
+
+	return "", false
+}
+
+// issueTemplateFuncs are the functions issue templates may call, besides those of
+// text/template.
+var issueTemplateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"env": os.Getenv,
+}
+
+// parseIssueRequest parses the templates of r, named for the request, defaulting its
+// method to method.
+func parseIssueRequest(request string, r IssueRequest, method string) (*issueRequestTemplate, error) {
+	t := &issueRequestTemplate{method: r.Method, idPath: r.IDPath, headers: map[string]*template.Template{}}
+	if t.method == "" {
+		t.method = method
+	}
+
+	var err error
+	if t.url, err = parseIssueTemplate(request+".url", r.URL); err != nil {
+		return nil, err
+	}
+	if r.Body != "" {
+		if t.body, err = parseIssueTemplate(request+".body", r.Body); err != nil {
+			return nil, err
+		}
+	}
+	for key, value := range r.Headers {
+		if t.headers[key], err = parseIssueTemplate(request+".headers."+key, value); err != nil {
+			return nil, err
+		}
+	}
+
+	return t, nil
+}
+
+func parseIssueTemplate(name, text string) (*template.Template, error) {
+	t, err := template.New(name).Funcs(issueTemplateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse the issue template %s: %w", name, err)
+	}
+
+	return t, nil
+}
+
+func executeIssueTemplate(t *template.Template, data IssueData) (string, error) {
+	var b bytes.Buffer
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("could not execute the issue template %s: %w", t.Name(), err)
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,215 @@
+package submission
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/artifacts"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Issue tracker", func() {
+	const results = `{
+		"image": "quay.io/example/image:mytag",
+		"passed": false,
+		"test_library": {"version": "1.0.0"},
+		"results": {
+			"passed": [{"name": "HasLicense", "elapsed_time": 10}],
+			"failed": [{"name": "RunAsNonRoot", "elapsed_time": 20, "help": "Check that the image runs as a \"non-root\" user.", "suggestion": "Set the user."}],
+			"errors": [{"name": "HasUniqueTag", "elapsed_time": 30, "help": "Check HasUniqueTag encountered an error."}]
+		}
+	}`
+
+	type request struct {
+		method string
+		path   string
+		query  string
+		header http.Header
+		body   string
+	}
+
+	var (
+		ctx      context.Context
+		server   *httptest.Server
+		mu       sync.Mutex
+		requests []request
+		found    string
+		config   IssueTrackerConfig
+	)
+
+	writeResults := func(results string) {
+		aw, err := artifacts.NewFilesystemWriter(artifacts.WithDirectory(GinkgoT().TempDir()))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.WriteFile(filepath.Join(aw.Path(), "results.json"), []byte(results), 0o644)).To(Succeed())
+		ctx = artifacts.ContextWithWriter(context.Background(), aw)
+	}
+
+	BeforeEach(func() {
+		writeResults(results)
+
+		requests = nil
+		found = `{"issues": []}`
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			body, _ := io.ReadAll(r.Body)
+			requests = append(requests, request{method: r.Method, path: r.URL.Path, query: r.URL.RawQuery, header: r.Header.Clone(), body: string(body)})
+			if strings.HasPrefix(r.URL.Path, "/forbidden") {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			if r.URL.Path == "/search" {
+				_, _ = w.Write([]byte(found))
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+		}))
+		DeferCleanup(server.Close)
+
+		GinkgoT().Setenv("TRACKER_TOKEN", "secret")
+		config = IssueTrackerConfig{
+			Find: &IssueRequest{
+				URL:    server.URL + `/search?labels={{ urlquery .DedupKey }}`,
+				IDPath: "issues.0.key",
+			},
+			Create: IssueRequest{
+				URL:     server.URL + "/issue",
+				Headers: map[string]string{"Authorization": `Bearer {{ env "TRACKER_TOKEN" }}`},
+				Body:    `{"summary": {{ json (printf "%s failed for %s" .Check.Name .Image) }}, "description": {{ json .Check.Help }}, "labels": [{{ json .DedupKey }}]}`,
+			},
+			Update: &IssueRequest{
+				URL:  server.URL + "/issue/{{ .IssueID }}/comment",
+				Body: `{"body": {{ json (printf "%s still fails for %s" .Check.Name .Image) }}}`,
+			},
+		}
+	})
+
+	It("should file an issue for each check that did not pass", func() {
+		tracker, err := NewIssueTracker(config)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tracker.Submit(ctx)).To(Succeed())
+
+		mu.Lock()
+		defer mu.Unlock()
+		Expect(requests).To(HaveLen(4))
+		Expect(requests[0].method).To(Equal(http.MethodGet))
+		Expect(requests[0].query).To(Equal("labels=preflight%3Aquay.io%2Fexample%2Fimage%3ARunAsNonRoot"))
+		Expect(requests[1].method).To(Equal(http.MethodPost))
+		Expect(requests[1].path).To(Equal("/issue"))
+		Expect(requests[1].header.Get("Authorization")).To(Equal("Bearer secret"))
+		Expect(requests[1].body).To(MatchJSON(`{
+			"summary": "RunAsNonRoot failed for quay.io/example/image:mytag",
+			"description": "Check that the image runs as a \"non-root\" user.",
+			"labels": ["preflight:quay.io/example/image:RunAsNonRoot"]
+		}`))
+		Expect(requests[3].body).To(ContainSubstring("HasUniqueTag failed"))
+	})
+
+	It("should update the issue that was found", func() {
+		found = `{"issues": [{"key": "CERT-1"}]}`
+		tracker, err := NewIssueTracker(config)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tracker.Submit(ctx)).To(Succeed())
+
+		mu.Lock()
+		defer mu.Unlock()
+		Expect(requests).To(HaveLen(4))
+		Expect(requests[1].method).To(Equal(http.MethodPost))
+		Expect(requests[1].path).To(Equal("/issue/CERT-1/comment"))
+		Expect(requests[1].body).To(MatchJSON(`{"body": "RunAsNonRoot still fails for quay.io/example/image:mytag"}`))
+	})
+
+	It("should not file the issue again if it was found and cannot be updated", func() {
+		found = `{"issues": [{"key": "CERT-1"}]}`
+		config.Update = nil
+		tracker, err := NewIssueTracker(config)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tracker.Submit(ctx)).To(Succeed())
+
+		mu.Lock()
+		defer mu.Unlock()
+		Expect(requests).To(HaveLen(2))
+		for _, r := range requests {
+			Expect(r.path).To(Equal("/search"))
+		}
+	})
+
+	It("should file one issue for the run", func() {
+		config.Per = IssuePerRun
+		config.Find = nil
+		config.Update = nil
+		config.Create.Body = `{"summary": "{{ len .Checks }} checks did not pass", "labels": [{{ json .DedupKey }}]}`
+		tracker, err := NewIssueTracker(config)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tracker.Submit(ctx)).To(Succeed())
+
+		mu.Lock()
+		defer mu.Unlock()
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0].body).To(MatchJSON(`{"summary": "2 checks did not pass", "labels": ["preflight:quay.io/example/image"]}`))
+	})
+
+	It("should not file issues if every check passed", func() {
+		writeResults(`{"image": "quay.io/example/image:mytag", "passed": true, "results": {"passed": [{"name": "HasLicense"}], "failed": [], "errors": []}}`)
+		tracker, err := NewIssueTracker(config)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tracker.Submit(ctx)).To(Succeed())
+
+		mu.Lock()
+		defer mu.Unlock()
+		Expect(requests).To(BeEmpty())
+	})
+
+	It("should throw an error if an issue cannot be filed", func() {
+		config.Find = nil
+		config.Update = nil
+		config.Create.URL = server.URL + "/forbidden/{{ .Check.Name }}"
+		tracker, err := NewIssueTracker(config)
+		Expect(err).ToNot(HaveOccurred())
+
+		err = tracker.Submit(ctx)
+		Expect(err).To(MatchError(ContainSubstring("could not file the issue preflight:quay.io/example/image:RunAsNonRoot")))
+		Expect(err).To(MatchError(ContainSubstring("could not file the issue preflight:quay.io/example/image:HasUniqueTag")))
+		Expect(err).To(MatchError(ContainSubstring("status code: 403")))
+	})
+
+	DescribeTable("should reject invalid configuration",
+		func(modify func(*IssueTrackerConfig), expected string) {
+			modify(&config)
+			_, err := NewIssueTracker(config)
+			Expect(err).To(MatchError(ContainSubstring(expected)))
+		},
+		Entry("an unsupported per", func(c *IssueTrackerConfig) { c.Per = "image" }, "issues must be filed per check or run"),
+		Entry("no url to create issues", func(c *IssueTrackerConfig) { c.Create.URL = "" }, "the url to create issues with must be configured"),
+		Entry("no path to the id of the issue found", func(c *IssueTrackerConfig) { c.Find.IDPath = "" }, "the path to the ID of the issue found"),
+		Entry("updating without finding", func(c *IssueTrackerConfig) { c.Find = nil }, "issues can only be updated if they can be found"),
+		Entry("an invalid template", func(c *IssueTrackerConfig) { c.Create.Body = "{{ .Check" }, "could not parse the issue template create.body"),
+	)
+
+	It("should load the configuration from a file", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "tracker.yaml")
+		Expect(os.WriteFile(path, []byte(`
+per: run
+create:
+  url: https://jira.example.com/rest/api/2/issue
+  body: '{"fields": {"summary": {{ json .Image }}}}'
+`), 0o644)).To(Succeed())
+
+		loaded, err := LoadIssueTrackerConfig(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(loaded.Per).To(Equal(IssuePerRun))
+		Expect(loaded.Create.URL).To(Equal("https://jira.example.com/rest/api/2/issue"))
+
+		Expect(os.WriteFile(path, []byte("unknown: true\n"), 0o644)).To(Succeed())
+		_, err = LoadIssueTrackerConfig(path)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,274 @@
+package submission
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/clock"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/formatters"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/transport"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/version"
+)
+
+// The results of checks in the documents indexed by OpenSearch, matching the events
+// emitted while checks are executed.
+const (
+	ResultPassed = "PASSED"
+	ResultFailed = "FAILED"
+	ResultError  = "ERROR"
+)
+
+// Document is indexed by OpenSearch for each check of a check container execution.
+// Every document of an execution has the same RunID and Timestamp.
+type Document struct {
+	Timestamp time.Time `json:"@timestamp"`
+	RunID     string    `json:"run_id"`
+	Image     string    `json:"image"`
+	// PassedOverall is true if every check of the execution passed.
+	PassedOverall     bool   `json:"passed_overall"`
+	CertificationHash string `json:"certification_hash,omitempty"`
+	PreflightVersion  string `json:"preflight_version"`
+	PreflightCommit   string `json:"preflight_commit,omitempty"`
+
+	Check string `json:"check"`
+	// Result is ResultPassed, ResultFailed, or ResultError.
+	Result string `json:"result"`
+	// ElapsedTime is in milliseconds, matching the results file.
+	ElapsedTime      float64 `json:"elapsed_time"`
+	Description      string  `json:"description,omitempty"`
+	Help             string  `json:"help,omitempty"`
+	Suggestion       string  `json:"suggestion,omitempty"`
+	KnowledgeBaseURL string  `json:"knowledgebase_url,omitempty"`
+	CheckURL         string  `json:"check_url,omitempty"`
+	Attempts         int     `json:"attempts,omitempty"`
+}
+
+type OpenSearchOption = func(*OpenSearch)
+
+// WithAPIKey authenticates with apiKey, which is sent in the Authorization header as
+// an ApiKey, as Elasticsearch expects. For basic authentication, include the username
+// and password in the URL instead.
+func WithAPIKey(apiKey string) OpenSearchOption {
+	return func(o *OpenSearch) {
+		o.apiKey = apiKey
+	}
+}
+
+// WithRunID sets the ID that identifies the execution in every document, e.g. the ID of
+// the CI job. Defaults to a random ID for each submission.
+func WithRunID(runID string) OpenSearchOption {
+	return func(o *OpenSearch) {
+		o.runID = runID
+	}
+}
+
+// WithOpenSearchHTTPClient sets the client that sends requests to OpenSearch. Defaults to
+// a client using the proxy and CA bundle configured in the context passed to Submit.
+func WithOpenSearchHTTPClient(client HTTPClient) OpenSearchOption {
+	return func(o *OpenSearch) {
+		o.client = client
+	}
+}
+
+// OpenSearch is a Submitter that indexes a Document for the outcome of each check of a
+// check container execution in OpenSearch, or Elasticsearch, so that the results of
+// many images can be searched and graphed together.
+type OpenSearch struct {
+	bulkURL string
+	apiKey  string
+	runID   string
+	client  HTTPClient
+}
+
+var _ Submitter = &OpenSearch{}
+
+// NewOpenSearch returns an OpenSearch indexing documents into the index at rawURL,
+// e.g. https://search.example.com:9200/preflight-results, configured by opts.
+func NewOpenSearch(rawURL string, opts ...OpenSearchOption) (*OpenSearch, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || strings.Trim(u.Path, "/") == "" {
+		return nil, fmt.Errorf("the opensearch url %s must be an http or https URL of an index", rawURL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/_bulk"
+
+	o := &OpenSearch{bulkURL: u.String()}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o, nil
+}
+
+func (o *OpenSearch) Submit(ctx context.Context) error {
+	logger := logr.FromContextOrDiscard(ctx)
+
+	b, err := resultsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	var results formatters.UserResponse
+	if err := json.Unmarshal(b, &results); err != nil {
+		return fmt.Errorf("could not parse results: %w", err)
+	}
+
+	runID := o.runID
+	if runID == "" {
+		runID, err = newRunID()
+		if err != nil {
+			return err
+		}
+	}
+
+	docs := documents(results, runID, clock.FromContext(ctx).Now().UTC())
+	if len(docs) == 0 {
+		logger.Info("no checks were executed, so there are no results to index in opensearch")
+		return nil
+	}
+
+	body, err := bulkIndexBody(docs)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.bulkURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set("User-Agent", "preflight/"+version.Version.Version)
+	if o.apiKey != "" {
+		req.Header.Set("Authorization", "ApiKey "+o.apiKey)
+	}
+
+	client := o.client
+	if client == nil {
+		client = transport.HTTPClient(ctx, 60*time.Second)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not index results in opensearch: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+		return fmt.Errorf("could not index results in opensearch %s: status code: %d", req.URL.Redacted(), resp.StatusCode)
+	}
+
+	// The bulk API responds successfully even if documents were not indexed, and
+	// reports the outcome of each in the response.
+	var bulkResp struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			Error *struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 10<<20)).Decode(&bulkResp); err != nil {
+		return fmt.Errorf("could not parse the response of opensearch: %w", err)
+	}
+	if bulkResp.Errors {
+		for _, item := range bulkResp.Items {
+			for _, result := range item {
+				if result.Error != nil {
+					return fmt.Errorf("could not index results in opensearch %s: %s: %s", req.URL.Redacted(), result.Error.Type, result.Error.Reason)
+				}
+			}
+		}
+		return fmt.Errorf("could not index results in opensearch %s", req.URL.Redacted())
+	}
+
+	logger.Info("Test results have been indexed in opensearch.", "url", req.URL.Redacted(), "run", runID)
+
+	return nil
+}
+
+// documents returns a Document for each check in results, identified by runID and
+// timestamp.
+func documents(results formatters.UserResponse, runID string, timestamp time.Time) []Document {
+	base := Document{
+		Timestamp:         timestamp,
+		RunID:             runID,
+		Image:             results.Image,
+		PassedOverall:     results.Passed,
+		CertificationHash: results.CertificationHash,
+		PreflightVersion:  results.LibraryInfo.Version,
+		PreflightCommit:   results.LibraryInfo.Commit,
+	}
+
+	docs := make([]Document, 0, len(results.Results.Passed)+len(results.Results.Failed)+len(results.Results.Errors))
+	for _, c := range results.Results.Passed {
+		doc := base
+		doc.Check = c.Name
+		doc.Result = ResultPassed
+		doc.ElapsedTime = c.ElapsedTime
+		doc.Description = c.Description
+		doc.Attempts = c.Attempts
+		docs = append(docs, doc)
+	}
+	for _, c := range results.Results.Failed {
+		doc := base
+		doc.Check = c.Name
+		doc.Result = ResultFailed
+		doc.ElapsedTime = c.ElapsedTime
+		doc.Description = c.Description
+		doc.Help = c.Help
+		doc.Suggestion = c.Suggestion
+		doc.KnowledgeBaseURL = c.KnowledgeBaseURL
+		doc.CheckURL = c.CheckURL
+		doc.Attempts = c.Attempts
+		docs = append(docs, doc)
+	}
+	for _, c := range results.Results.Errors {
+		doc := base
+		doc.Check = c.Name
+		doc.Result = ResultError
+		doc.ElapsedTime = c.ElapsedTime
+		doc.Description = c.Description
+		doc.Help = c.Help
+		doc.Attempts = c.Attempts
+		docs = append(docs, doc)
+	}
+
+	return docs
+}
+
+// bulkIndexBody returns the body of a bulk API request indexing docs into the index of
+// the request URL.
+func bulkIndexBody(docs []Document) ([]byte, error) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	for _, doc := range docs {
+		b.WriteString("{\"index\":{}}\n")
+		if err := enc.Encode(doc); err != nil {
+			return nil, fmt.Errorf("could not encode the document for %s: %w", doc.Check, err)
+		}
+	}
+
+	return b.Bytes(), nil
+}
+
+// newRunID returns a random ID for an execution.
+func newRunID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("could not generate a run id: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,152 @@
+package submission
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/artifacts"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/clock"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OpenSearch", func() {
+	const results = `{
+		"image": "quay.io/example/image:mytag",
+		"passed": false,
+		"test_library": {"name": "github.com/redhat-openshift-ecosystem/openshift-preflight", "version": "1.0.0", "commit": "abc"},
+		"results": {
+			"passed": [{"name": "HasLicense", "elapsed_time": 10, "description": "Checking if terms and conditions applicable to the software including open source licensing information are present."}],
+			"failed": [{"name": "RunAsNonRoot", "elapsed_time": 20, "help": "Check RunAsNonRoot encountered an error.", "suggestion": "Set the user."}],
+			"errors": [{"name": "HasUniqueTag", "elapsed_time": 30, "attempts": 2}]
+		}
+	}`
+
+	var (
+		ctx      context.Context
+		server   *httptest.Server
+		mu       sync.Mutex
+		path     string
+		body     []byte
+		header   http.Header
+		status   int
+		response string
+	)
+
+	BeforeEach(func() {
+		aw, err := artifacts.NewFilesystemWriter(artifacts.WithDirectory(GinkgoT().TempDir()))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.WriteFile(filepath.Join(aw.Path(), "results.json"), []byte(results), 0o644)).To(Succeed())
+		ctx = artifacts.ContextWithWriter(context.Background(), aw)
+		ctx = clock.ContextWithClock(ctx, clock.Deterministic())
+
+		status = http.StatusOK
+		response = `{"took": 1, "errors": false, "items": []}`
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			path = r.URL.Path
+			body, _ = io.ReadAll(r.Body)
+			header = r.Header.Clone()
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(response))
+		}))
+		DeferCleanup(server.Close)
+	})
+
+	// indexed returns the documents in the body of the bulk request, checking that each
+	// is preceded by an index action.
+	indexed := func() []Document {
+		mu.Lock()
+		defer mu.Unlock()
+
+		var docs []Document
+		scanner := bufio.NewScanner(bytes.NewReader(body))
+		for scanner.Scan() {
+			Expect(scanner.Text()).To(MatchJSON(`{"index": {}}`))
+			Expect(scanner.Scan()).To(BeTrue())
+			var doc Document
+			Expect(json.Unmarshal(scanner.Bytes(), &doc)).To(Succeed())
+			docs = append(docs, doc)
+		}
+		return docs
+	}
+
+	It("should index a document for each check", func() {
+		index, err := NewOpenSearch(server.URL+"/preflight-results", WithRunID("run-1"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(index.Submit(ctx)).To(Succeed())
+
+		mu.Lock()
+		Expect(path).To(Equal("/preflight-results/_bulk"))
+		Expect(header.Get("Content-Type")).To(Equal("application/x-ndjson"))
+		Expect(header.Get("Authorization")).To(BeEmpty())
+		mu.Unlock()
+
+		docs := indexed()
+		Expect(docs).To(HaveLen(3))
+		for _, doc := range docs {
+			Expect(doc.RunID).To(Equal("run-1"))
+			Expect(doc.Image).To(Equal("quay.io/example/image:mytag"))
+			Expect(doc.PassedOverall).To(BeFalse())
+			Expect(doc.PreflightVersion).To(Equal("1.0.0"))
+			Expect(doc.Timestamp.IsZero()).To(BeTrue())
+		}
+		Expect(docs[0]).To(And(HaveField("Check", "HasLicense"), HaveField("Result", ResultPassed), HaveField("ElapsedTime", float64(10))))
+		Expect(docs[1]).To(And(HaveField("Check", "RunAsNonRoot"), HaveField("Result", ResultFailed), HaveField("Suggestion", "Set the user.")))
+		Expect(docs[2]).To(And(HaveField("Check", "HasUniqueTag"), HaveField("Result", ResultError), HaveField("Attempts", 2)))
+	})
+
+	It("should identify each submission with a new run id by default", func() {
+		index, err := NewOpenSearch(server.URL + "/preflight-results/")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(index.Submit(ctx)).To(Succeed())
+		first := indexed()[0].RunID
+		Expect(index.Submit(ctx)).To(Succeed())
+		second := indexed()[0].RunID
+
+		Expect(first).ToNot(BeEmpty())
+		Expect(second).ToNot(Equal(first))
+	})
+
+	It("should authenticate with the api key", func() {
+		index, err := NewOpenSearch(server.URL+"/preflight-results", WithAPIKey("key"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(index.Submit(ctx)).To(Succeed())
+
+		mu.Lock()
+		defer mu.Unlock()
+		Expect(header.Get("Authorization")).To(Equal("ApiKey key"))
+	})
+
+	It("should throw an error if opensearch does not accept the request", func() {
+		status = http.StatusUnauthorized
+		index, err := NewOpenSearch(server.URL + "/preflight-results")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(index.Submit(ctx)).To(MatchError(ContainSubstring("status code: 401")))
+	})
+
+	It("should throw an error if documents were not indexed", func() {
+		response = `{"errors": true, "items": [{"index": {"status": 201}}, {"index": {"status": 400, "error": {"type": "mapper_parsing_exception", "reason": "failed to parse field [elapsed_time]"}}}]}`
+		index, err := NewOpenSearch(server.URL + "/preflight-results")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(index.Submit(ctx)).To(MatchError(ContainSubstring("mapper_parsing_exception: failed to parse field [elapsed_time]")))
+	})
+
+	It("should reject urls that are not of an index", func() {
+		_, err := NewOpenSearch(server.URL)
+		Expect(err).To(MatchError(ContainSubstring("must be an http or https URL of an index")))
+		_, err = NewOpenSearch("ftp://example.com/preflight-results")
+		Expect(err).To(HaveOccurred())
+	})
+})
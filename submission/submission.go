@@ -0,0 +1,70 @@
+// Package submission provides Submitters for the results of a check container
+// execution, so that results can be sent to systems other than Red Hat, e.g. an
+// internal compliance system, in addition to or instead of Red Hat.
+package submission
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/artifacts"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
+)
+
+// Submitter submits the results of a check execution. The results, and the other
+// artifacts of the execution, are read from the artifacts writer in ctx.
+type Submitter interface {
+	Submit(ctx context.Context) error
+}
+
+// Multi returns a Submitter that submits to each of submitters in order. Every submitter
+// is submitted to, even if one before it fails, and the errors of those that failed are
+// returned together.
+func Multi(submitters ...Submitter) Submitter {
+	return multiSubmitter(submitters)
+}
+
+type multiSubmitter []Submitter
+
+func (m multiSubmitter) Submit(ctx context.Context) error {
+	var errs []error
+	for _, s := range m {
+		if err := s.Submit(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+
+	msgs := make([]string, 0, len(errs))
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+
+	return fmt.Errorf("%w; %s", errs[0], strings.Join(msgs[1:], "; "))
+}
+
+// resultsFromContext returns the results written to the artifacts directory of the
+// artifacts writer in ctx.
+func resultsFromContext(ctx context.Context) ([]byte, error) {
+	artifactWriter, ok := artifacts.WriterFromContext(ctx).(*artifacts.FilesystemWriter)
+	if artifactWriter == nil || !ok {
+		return nil, errors.New("the artifact writer was either missing or was not supported, so results cannot be submitted")
+	}
+
+	b, err := os.ReadFile(filepath.Join(artifactWriter.Path(), check.DefaultTestResultsFilename))
+	if err != nil {
+		return nil, fmt.Errorf("could not open file for submission: %s: %w", check.DefaultTestResultsFilename, err)
+	}
+
+	return b, nil
+}
@@ -0,0 +1,167 @@
+package submission
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/artifacts"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type submitterFunc func(context.Context) error
+
+func (f submitterFunc) Submit(ctx context.Context) error {
+	return f(ctx)
+}
+
+var _ = Describe("Submission", func() {
+	const results = `{"image": "quay.io/example/image:mytag", "passed": true}`
+
+	var ctx context.Context
+
+	BeforeEach(func() {
+		aw, err := artifacts.NewFilesystemWriter(artifacts.WithDirectory(GinkgoT().TempDir()))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.WriteFile(filepath.Join(aw.Path(), "results.json"), []byte(results), 0o644)).To(Succeed())
+		ctx = artifacts.ContextWithWriter(context.Background(), aw)
+	})
+
+	Context("when submitting to multiple submitters", func() {
+		It("should submit to all of them, even if one fails", func() {
+			var submitted []string
+			first := submitterFunc(func(context.Context) error {
+				submitted = append(submitted, "first")
+				return errors.New("first failed")
+			})
+			second := submitterFunc(func(context.Context) error {
+				submitted = append(submitted, "second")
+				return nil
+			})
+
+			err := Multi(first, second).Submit(ctx)
+			Expect(err).To(MatchError("first failed"))
+			Expect(submitted).To(Equal([]string{"first", "second"}))
+		})
+
+		It("should return the errors of all that failed", func() {
+			errFirst := errors.New("first failed")
+			first := submitterFunc(func(context.Context) error { return errFirst })
+			second := submitterFunc(func(context.Context) error { return errors.New("second failed") })
+
+			err := Multi(first, second).Submit(ctx)
+			Expect(err).To(MatchError(errFirst))
+			Expect(err).To(MatchError(ContainSubstring("second failed")))
+		})
+	})
+
+	Context("when submitting to a webhook", func() {
+		var (
+			server *httptest.Server
+			mu     sync.Mutex
+			body   []byte
+			header http.Header
+			status int
+		)
+
+		BeforeEach(func() {
+			status = http.StatusAccepted
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+				body, _ = io.ReadAll(r.Body)
+				header = r.Header.Clone()
+				w.WriteHeader(status)
+			}))
+			DeferCleanup(server.Close)
+		})
+
+		It("should POST the results", func() {
+			webhook, err := NewWebhook(server.URL)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(webhook.Submit(ctx)).To(Succeed())
+
+			mu.Lock()
+			defer mu.Unlock()
+			Expect(body).To(MatchJSON(results))
+			Expect(header.Get("Content-Type")).To(Equal("application/json"))
+			Expect(header.Get(SignatureHeader)).To(BeEmpty())
+			Expect(header.Get(TimestampHeader)).To(BeEmpty())
+		})
+
+		It("should sign the results with the secret", func() {
+			webhook, err := NewWebhook(server.URL, WithSecret("secret"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(webhook.Submit(ctx)).To(Succeed())
+
+			mu.Lock()
+			defer mu.Unlock()
+			timestamp := header.Get(TimestampHeader)
+			Expect(timestamp).ToNot(BeEmpty())
+			Expect(Verify("secret", body, timestamp, header.Get(SignatureHeader), time.Now())).To(BeTrue())
+			Expect(Verify("another secret", body, timestamp, header.Get(SignatureHeader), time.Now())).To(BeFalse())
+		})
+
+		It("should throw an error if the webhook does not accept the results", func() {
+			status = http.StatusForbidden
+			webhook, err := NewWebhook(server.URL)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(webhook.Submit(ctx)).To(MatchError(ContainSubstring("status code: 403")))
+		})
+
+		It("should throw an error if there are no results", func() {
+			webhook, err := NewWebhook(server.URL)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(webhook.Submit(context.Background())).To(MatchError(ContainSubstring("results cannot be submitted")))
+		})
+
+		It("should reject urls that are not http or https", func() {
+			_, err := NewWebhook("ftp://example.com/results")
+			Expect(err).To(MatchError(ContainSubstring("must be an http or https URL")))
+		})
+	})
+
+	Context("when signing", func() {
+		It("should produce the hex encoded HMAC-SHA256 of the timestamp and the body", func() {
+			// echo -n '1700000000.body' | openssl dgst -sha256 -hmac secret
+			Expect(Sign("secret", "1700000000", []byte("body"))).To(Equal("sha256=42ac6f0448c1d9c3e1e82b9726248f58fef84afffcbad5188246e96070e0ea46"))
+		})
+	})
+
+	Context("when verifying", func() {
+		var (
+			body      = []byte("body")
+			signedAt  = time.Unix(1700000000, 0)
+			timestamp = "1700000000"
+			signature string
+		)
+		BeforeEach(func() {
+			signature = Sign("secret", timestamp, body)
+		})
+
+		It("should accept a request signed within the tolerance", func() {
+			Expect(Verify("secret", body, timestamp, signature, signedAt.Add(SignatureTolerance))).To(BeTrue())
+			Expect(Verify("secret", body, timestamp, signature, signedAt.Add(-SignatureTolerance))).To(BeTrue())
+		})
+
+		It("should reject a request replayed after the tolerance", func() {
+			Expect(Verify("secret", body, timestamp, signature, signedAt.Add(SignatureTolerance+time.Second))).To(BeFalse())
+		})
+
+		It("should reject a request whose timestamp was changed", func() {
+			Expect(Verify("secret", body, "1700000300", signature, signedAt.Add(5*time.Minute))).To(BeFalse())
+		})
+
+		It("should reject a request without a timestamp", func() {
+			Expect(Verify("secret", body, "", signature, signedAt)).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,155 @@
+package submission
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/clock"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/transport"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/version"
+)
+
+// SignatureHeader is the header of webhook requests containing the signature of the
+// request, when the Webhook is configured with a secret.
+const SignatureHeader = "X-Preflight-Signature-256"
+
+// TimestampHeader is the header of signed webhook requests containing when the request
+// was signed, in seconds since the Unix epoch. It is signed with the request body, so
+// that receivers can reject requests that are replayed later.
+const TimestampHeader = "X-Preflight-Timestamp"
+
+// SignatureTolerance is how far from the time of the receiver the timestamp of a webhook
+// request may be for Verify to accept it. Receivers that verify requests themselves
+// should reject those outside a similar window.
+const SignatureTolerance = 5 * time.Minute
+
+// signaturePrefix precedes the hex encoded HMAC-SHA256 of a request in SignatureHeader.
+const signaturePrefix = "sha256="
+
+// HTTPClient sends webhook requests.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+type WebhookOption = func(*Webhook)
+
+// WithSecret signs webhook requests with secret, using HMAC-SHA256, so that the receiver
+// can verify that the results were sent by preflight, and recently. The signature is sent
+// in SignatureHeader, and the time it was made in TimestampHeader.
+func WithSecret(secret string) WebhookOption {
+	return func(w *Webhook) {
+		w.secret = secret
+	}
+}
+
+// WithHTTPClient sets the client that sends webhook requests. Defaults to a client using
+// the proxy and CA bundle configured in the context passed to Submit.
+func WithHTTPClient(client HTTPClient) WebhookOption {
+	return func(w *Webhook) {
+		w.client = client
+	}
+}
+
+// Webhook is a Submitter that POSTs the results of a check container execution, as JSON,
+// to a URL.
+type Webhook struct {
+	url    string
+	secret string
+	client HTTPClient
+}
+
+var _ Submitter = &Webhook{}
+
+// NewWebhook returns a Webhook POSTing results to rawURL, configured by opts.
+func NewWebhook(rawURL string, opts ...WebhookOption) (*Webhook, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, fmt.Errorf("the webhook url %s must be an http or https URL", rawURL)
+	}
+
+	w := &Webhook{url: rawURL}
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	return w, nil
+}
+
+func (w *Webhook) Submit(ctx context.Context) error {
+	logger := logr.FromContextOrDiscard(ctx)
+
+	body, err := resultsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "preflight/"+version.Version.Version)
+	if w.secret != "" {
+		timestamp := strconv.FormatInt(clock.FromContext(ctx).Now().Unix(), 10)
+		req.Header.Set(TimestampHeader, timestamp)
+		req.Header.Set(SignatureHeader, Sign(w.secret, timestamp, body))
+	}
+
+	client := w.client
+	if client == nil {
+		client = transport.HTTPClient(ctx, 60*time.Second)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not submit results to webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("could not submit results to webhook %s: status code: %d", req.URL.Redacted(), resp.StatusCode)
+	}
+
+	logger.Info("Test results have been submitted to the webhook.", "url", req.URL.Redacted())
+
+	return nil
+}
+
+// Sign returns the value of SignatureHeader for a request with body, sent with timestamp
+// as the value of TimestampHeader, signed with secret. The signature is the hex encoded
+// HMAC-SHA256 of the timestamp, a period, and the body.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify returns true if signature, the value of SignatureHeader of a webhook request, is
+// the signature of body and timestamp, the value of its TimestampHeader, with secret, and
+// if timestamp is within SignatureTolerance of now. Receivers of webhook requests use it to
+// verify that results were sent by preflight, and are not a request replayed later.
+func Verify(secret string, body []byte, timestamp, signature string, now time.Time) bool {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); age > SignatureTolerance || age < -SignatureTolerance {
+		return false
+	}
+
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
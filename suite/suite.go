@@ -0,0 +1,232 @@
+// Package suite executes a list of checks chosen by the caller against an image,
+// instead of the checks of one of preflight's policies. The checks may be any of
+// preflight's container checks, found by name with Builtin, or the caller's own.
+package suite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	goruntime "runtime"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/certification"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/clock"
+	preflighterr "github.com/redhat-openshift-ecosystem/openshift-preflight/errors"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/check"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/engine"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/image"
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/internal/policy"
+)
+
+// Check is a check that can be executed in a suite.
+type Check = check.Check
+
+// ImageReference is the image a check validates, with the path to its extracted
+// filesystem.
+type ImageReference = image.ImageReference
+
+// Metadata contains useful information regarding a check.
+type Metadata = check.Metadata
+
+// HelpText is the help message associated with a check.
+type HelpText = check.HelpText
+
+// ValidatorFunc validates that an image complies with a check.
+type ValidatorFunc = check.ValidatorFunc
+
+// NewCheck returns a check named name that validates images with fn.
+func NewCheck(name string, fn ValidatorFunc, metadata Metadata, help HelpText) Check {
+	return check.NewGenericCheck(name, fn, metadata, help)
+}
+
+// BuiltinConfig configures the checks returned by Builtin.
+type BuiltinConfig struct {
+	// DockerConfig is the path to a docker config file used by checks that
+	// access the registry, e.g. HasUniqueTag.
+	DockerConfig string
+	// PyxisAPIToken and CertificationProjectID are used by checks that query
+	// Pyxis, e.g. BasedOnUbi.
+	PyxisAPIToken, CertificationProjectID string
+	// ApprovedBaseImages are the base images checked by BasedOnApprovedBaseImage,
+	// which is only available if they are set.
+	ApprovedBaseImages []string
+}
+
+// Builtin returns preflight's container checks with names, in the order given,
+// configured with cfg.
+func Builtin(ctx context.Context, cfg BuiltinConfig, names ...string) ([]Check, error) {
+	available, err := engine.InitializeContainerChecks(ctx, policy.PolicyContainer, engine.ContainerCheckConfig{
+		DockerConfig:           cfg.DockerConfig,
+		PyxisAPIToken:          cfg.PyxisAPIToken,
+		CertificationProjectID: cfg.CertificationProjectID,
+		ApprovedBaseImages:     cfg.ApprovedBaseImages,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", preflighterr.ErrCannotInitializeChecks, err)
+	}
+
+	byName := make(map[string]Check, len(available))
+	availableNames := make([]string, 0, len(available))
+	for _, c := range available {
+		byName[c.Name()] = c
+		availableNames = append(availableNames, c.Name())
+	}
+
+	checks := make([]Check, 0, len(names))
+	for _, name := range names {
+		c, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown check %s, expected one of %s",
+				preflighterr.ErrCannotInitializeChecks, name, strings.Join(availableNames, ", "))
+		}
+		checks = append(checks, c)
+	}
+
+	return checks, nil
+}
+
+type Option = func(*suite)
+
+type suite struct {
+	image            string
+	checks           []Check
+	dockerconfigjson string
+	platform         string
+	insecure         bool
+	scratch          bool
+	clock            clock.Clock
+	traceOnFailure   bool
+}
+
+// New is a suite that executes checks against image.
+func New(image string, checks []Check, opts ...Option) *suite {
+	s := &suite{
+		image:    image,
+		checks:   checks,
+		platform: goruntime.GOARCH,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Run executes the checks, in order, and returns the results. No policy is resolved,
+// so the results are those of exactly the checks in the suite. Callers should add a
+// relevant ArtifactWriter to the context if they wish to work with artifact files
+// written by checks.
+func (s *suite) Run(ctx context.Context) (certification.Results, error) {
+	if s.image == "" {
+		return certification.Results{}, preflighterr.ErrImageEmpty
+	}
+
+	if err := validateChecks(s.checks); err != nil {
+		return certification.Results{}, fmt.Errorf("%w: %s", preflighterr.ErrCannotInitializeChecks, err)
+	}
+
+	if s.clock != nil {
+		ctx = clock.ContextWithClock(ctx, s.clock)
+	}
+
+	var engineOpts []engine.Option
+	if s.traceOnFailure {
+		engineOpts = append(engineOpts, engine.WithTraceOnFailure())
+	}
+
+	eng, err := engine.New(ctx, s.image, s.checks, nil, s.dockerconfigjson, false, s.scratch, s.insecure, s.platform, engineOpts...)
+	if err != nil {
+		return certification.Results{}, err
+	}
+
+	if err := eng.ExecuteChecks(ctx); err != nil {
+		return certification.Results{}, err
+	}
+
+	return eng.Results(ctx), nil
+}
+
+// Stream executes the suite like Run, but returns a ResultStream yielding each check's
+// result as soon as it completes.
+func (s *suite) Stream(ctx context.Context) *certification.ResultStream {
+	return certification.NewResultStream(ctx, s.Run)
+}
+
+// validateChecks returns an error if there are no checks, or if checks cannot be
+// told apart in the results.
+func validateChecks(checks []Check) error {
+	if len(checks) == 0 {
+		return fmt.Errorf("no checks were provided")
+	}
+
+	seen := make(map[string]bool, len(checks))
+	for i, c := range checks {
+		if c == nil {
+			return fmt.Errorf("check %d is nil", i+1)
+		}
+		if c.Name() == "" {
+			return fmt.Errorf("check %d has no name", i+1)
+		}
+		if seen[c.Name()] {
+			return fmt.Errorf("check %s is included more than once", c.Name())
+		}
+		seen[c.Name()] = true
+	}
+
+	return nil
+}
+
+// WithDockerConfigJSONFromFile sets the docker config used to pull the image.
+func WithDockerConfigJSONFromFile(s string) Option {
+	return func(st *suite) {
+		st.dockerconfigjson = s
+	}
+}
+
+// WithPlatform will define for what platform the image should be pulled.
+// E.g. amd64, s390x, or a full platform with a variant, like linux/arm/v7.
+func WithPlatform(platform string) Option {
+	return func(st *suite) {
+		st.platform = platform
+	}
+}
+
+// WithInsecureConnection allows for preflight to connect to an insecure registry
+// to pull images.
+func WithInsecureConnection() Option {
+	return func(st *suite) {
+		st.insecure = true
+	}
+}
+
+// WithScratch indicates that the image is built from scratch, so that no RPM
+// manifest is written for it.
+func WithScratch() Option {
+	return func(st *suite) {
+		st.scratch = true
+	}
+}
+
+// WithDeterministicTimes zeroes all timestamps and durations recorded in
+// results and artifacts, so that output is reproducible.
+func WithDeterministicTimes() Option {
+	return WithClock(clock.Deterministic())
+}
+
+// WithClock sets the Clock used to record timestamps and durations in
+// results and artifacts.
+func WithClock(c clock.Clock) Option {
+	return func(st *suite) {
+		st.clock = c
+	}
+}
+
+// WithTraceOnFailure executes checks that fail or error again with trace
+// logging, and writes the log of each to the ArtifactWriter in the context.
+func WithTraceOnFailure() Option {
+	return func(st *suite) {
+		st.traceOnFailure = true
+	}
+}
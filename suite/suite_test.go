@@ -0,0 +1,85 @@
+package suite
+
+import (
+	"context"
+
+	"github.com/redhat-openshift-ecosystem/openshift-preflight/clock"
+	preflighterr "github.com/redhat-openshift-ecosystem/openshift-preflight/errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Ad-hoc check suites", func() {
+	passing := func(ctx context.Context, ir ImageReference) (bool, error) { return true, nil }
+
+	When("looking up preflight's checks", func() {
+		It("should return the named checks in the order given", func() {
+			checks, err := Builtin(context.TODO(), BuiltinConfig{}, "RunAsNonRoot", "HasLicense")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(checks).To(HaveLen(2))
+			Expect(checks[0].Name()).To(Equal("RunAsNonRoot"))
+			Expect(checks[1].Name()).To(Equal("HasLicense"))
+		})
+
+		It("should only return BasedOnApprovedBaseImage if approved base images are configured", func() {
+			_, err := Builtin(context.TODO(), BuiltinConfig{}, "BasedOnApprovedBaseImage")
+			Expect(err).To(MatchError(preflighterr.ErrCannotInitializeChecks))
+
+			checks, err := Builtin(context.TODO(), BuiltinConfig{ApprovedBaseImages: []string{"registry.access.redhat.com/ubi9/ubi"}}, "BasedOnApprovedBaseImage")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(checks).To(HaveLen(1))
+		})
+
+		It("should throw an error for an unknown check", func() {
+			_, err := Builtin(context.TODO(), BuiltinConfig{}, "HasLicense", "IsAwesome")
+			Expect(err).To(MatchError(preflighterr.ErrCannotInitializeChecks))
+			Expect(err).To(MatchError(ContainSubstring("unknown check IsAwesome, expected one of HasLicense")))
+		})
+	})
+
+	When("using options to initialize a suite", func() {
+		It("should store the options with their correct values", func() {
+			checks := []Check{NewCheck("mine", passing, Metadata{}, HelpText{})}
+			s := New("placeholder", checks,
+				WithDockerConfigJSONFromFile("dockerconfig.json"),
+				WithPlatform("arm64"),
+				WithInsecureConnection(),
+				WithScratch(),
+				WithDeterministicTimes(),
+				WithTraceOnFailure(),
+			)
+
+			Expect(s.image).To(Equal("placeholder"))
+			Expect(s.checks).To(Equal(checks))
+			Expect(s.dockerconfigjson).To(Equal("dockerconfig.json"))
+			Expect(s.platform).To(Equal("arm64"))
+			Expect(s.insecure).To(BeTrue())
+			Expect(s.scratch).To(BeTrue())
+			Expect(s.clock).To(Equal(clock.Deterministic()))
+			Expect(s.traceOnFailure).To(BeTrue())
+		})
+	})
+
+	When("running a suite", func() {
+		It("should throw an error if the image is empty", func() {
+			_, err := New("", []Check{NewCheck("mine", passing, Metadata{}, HelpText{})}).Run(context.TODO())
+			Expect(err).To(MatchError(preflighterr.ErrImageEmpty))
+		})
+
+		It("should throw an error if there are no checks", func() {
+			_, err := New("placeholder", nil).Run(context.TODO())
+			Expect(err).To(MatchError(preflighterr.ErrCannotInitializeChecks))
+			Expect(err).To(MatchError(ContainSubstring("no checks were provided")))
+		})
+
+		It("should throw an error if a check is included more than once", func() {
+			checks, err := Builtin(context.TODO(), BuiltinConfig{}, "HasLicense")
+			Expect(err).ToNot(HaveOccurred())
+			checks = append(checks, NewCheck("HasLicense", passing, Metadata{}, HelpText{}))
+
+			_, err = New("placeholder", checks).Run(context.TODO())
+			Expect(err).To(MatchError(ContainSubstring("check HasLicense is included more than once")))
+		})
+	})
+})